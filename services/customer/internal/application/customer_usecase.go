@@ -255,9 +255,10 @@ func (uc *CustomerUsecase) DeleteCustomer(ctx context.Context, id uuid.UUID) err
 	return nil
 }
 
-// ListCustomers retrieves customers with pagination
-func (uc *CustomerUsecase) ListCustomers(ctx context.Context, limit, offset int) ([]*entity.Customer, int, error) {
+// ListCustomers retrieves customers with pagination, only those of a tier when one is given
+func (uc *CustomerUsecase) ListCustomers(ctx context.Context, tier *entity.CustomerTier, limit, offset int) ([]*entity.Customer, int, error) {
 	filter := repository.CustomerFilter{
+		Tier:   tier,
 		Limit:  limit,
 		Offset: offset,
 	}
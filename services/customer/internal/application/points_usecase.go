@@ -53,6 +53,15 @@ type RedeemPointsRequest struct {
 	ReferenceType *string    `json:"reference_type"`
 }
 
+// ClawBackOrderPointsRequest represents a request to take back points earned from a refunded order
+type ClawBackOrderPointsRequest struct {
+	CustomerID   uuid.UUID `json:"customer_id" validate:"required"`
+	OrderID      uuid.UUID `json:"order_id" validate:"required"`
+	ReturnID     uuid.UUID `json:"return_id" validate:"required"`
+	RefundAmount float64   `json:"refund_amount" validate:"required,gt=0"`
+	OrderAmount  float64   `json:"order_amount" validate:"required,gt=0"`
+}
+
 // EarnPoints adds points to a customer's balance
 func (uc *PointsUsecase) EarnPoints(ctx context.Context, req *EarnPointsRequest) (*entity.CustomerPointsTransaction, error) {
 	// Get customer to verify exists and get current balance
@@ -96,12 +105,6 @@ func (uc *PointsUsecase) EarnPoints(ctx context.Context, req *EarnPointsRequest)
 		return nil, fmt.Errorf("failed to update customer balance: %w", err)
 	}
 
-	// Publish event - Use the EarnPoints method instead
-	if err := uc.pointsRepo.EarnPoints(ctx, req.CustomerID, actualPoints, req.Source, req.Description, req.ReferenceID, req.ReferenceType); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
-	}
-
 	return transaction, nil
 }
 
@@ -144,12 +147,6 @@ func (uc *PointsUsecase) RedeemPoints(ctx context.Context, req *RedeemPointsRequ
 		return nil, fmt.Errorf("failed to update customer balance: %w", err)
 	}
 
-	// Use RedeemPoints method instead of event
-	if err := uc.pointsRepo.RedeemPoints(ctx, req.CustomerID, req.Points, req.Source, req.Description, req.ReferenceID, req.ReferenceType); err != nil {
-		// Log error but don't fail the operation
-		// TODO: Add proper logging
-	}
-
 	return transaction, nil
 }
 
@@ -216,6 +213,23 @@ func (uc *PointsUsecase) ProcessOrderPoints(ctx context.Context, customerID uuid
 	return uc.EarnPoints(ctx, req)
 }
 
+// ClawBackOrderPoints takes back the share of the points earned from an order that matches the
+// refunded share of the order. A return is clawed back once: repeating the request returns the
+// transaction already recorded for it. Points the customer has already spent cannot be taken back,
+// so the clawback never takes the balance below zero. Returns nil when there is nothing to claw back.
+func (uc *PointsUsecase) ClawBackOrderPoints(ctx context.Context, req *ClawBackOrderPointsRequest) (*entity.CustomerPointsTransaction, error) {
+	if req.RefundAmount <= 0 || req.OrderAmount <= 0 {
+		return nil, entity.ErrInvalidPointsAmount
+	}
+
+	transaction, err := uc.pointsRepo.ClawBackOrderPoints(ctx, req.CustomerID, req.OrderID, req.ReturnID, req.RefundAmount, req.OrderAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to claw back points: %w", err)
+	}
+
+	return transaction, nil
+}
+
 // GetPointsStats retrieves points statistics for a customer
 func (uc *PointsUsecase) GetPointsStats(ctx context.Context, customerID uuid.UUID) (*entity.CustomerPointsStats, error) {
 	// Get customer
@@ -0,0 +1,94 @@
+package application
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"customer/internal/domain/entity"
+	"customer/internal/domain/repository"
+)
+
+// The fakes embed the repository interfaces and implement only what the use case calls
+
+type fakeCustomerRepo struct {
+	repository.CustomerRepository
+	customer *entity.Customer
+}
+
+func (r *fakeCustomerRepo) GetByID(ctx context.Context, id uuid.UUID) (*entity.Customer, error) {
+	copied := *r.customer
+	return &copied, nil
+}
+
+func (r *fakeCustomerRepo) Update(ctx context.Context, customer *entity.Customer) error {
+	r.customer = customer
+	return nil
+}
+
+type fakeVIPBenefitsRepo struct {
+	repository.VIPTierBenefitsRepository
+}
+
+func (r *fakeVIPBenefitsRepo) GetByTier(ctx context.Context, tier entity.CustomerTier) (*entity.VIPTierBenefits, error) {
+	return &entity.VIPTierBenefits{Tier: tier, PointsMultiplier: 1}, nil
+}
+
+// recordingPointsRepo records every points transaction written, through any method
+type recordingPointsRepo struct {
+	repository.CustomerPointsRepository
+	transactions []*entity.CustomerPointsTransaction
+}
+
+func (r *recordingPointsRepo) CreateTransaction(ctx context.Context, transaction *entity.CustomerPointsTransaction) error {
+	r.transactions = append(r.transactions, transaction)
+	return nil
+}
+
+func (r *recordingPointsRepo) EarnPoints(ctx context.Context, customerID uuid.UUID, points int, source, description string, referenceID *uuid.UUID, referenceType *string) error {
+	return r.CreateTransaction(ctx, &entity.CustomerPointsTransaction{Type: entity.PointsEarned, Points: points})
+}
+
+func (r *recordingPointsRepo) RedeemPoints(ctx context.Context, customerID uuid.UUID, points int, source, description string, referenceID *uuid.UUID, referenceType *string) error {
+	return r.CreateTransaction(ctx, &entity.CustomerPointsTransaction{Type: entity.PointsRedeemed, Points: -points})
+}
+
+func newTestPointsUsecase(balance int) (*PointsUsecase, *fakeCustomerRepo, *recordingPointsRepo) {
+	customers := &fakeCustomerRepo{customer: &entity.Customer{ID: uuid.New(), PointsBalance: balance}}
+	points := &recordingPointsRepo{}
+	return NewPointsUsecase(points, customers, &fakeVIPBenefitsRepo{}, nil), customers, points
+}
+
+func TestEarnPointsRecordsOneTransaction(t *testing.T) {
+	uc, customers, points := newTestPointsUsecase(10)
+
+	transaction, err := uc.EarnPoints(context.Background(), &EarnPointsRequest{
+		CustomerID: customers.customer.ID,
+		Points:     50,
+		Source:     "order",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, points.transactions, 1)
+	assert.Equal(t, transaction, points.transactions[0])
+	assert.Equal(t, 60, transaction.Balance)
+	assert.Equal(t, 60, customers.customer.PointsBalance)
+}
+
+func TestRedeemPointsRecordsOneTransaction(t *testing.T) {
+	uc, customers, points := newTestPointsUsecase(100)
+
+	transaction, err := uc.RedeemPoints(context.Background(), &RedeemPointsRequest{
+		CustomerID: customers.customer.ID,
+		Points:     30,
+		Source:     "reward",
+	})
+	require.NoError(t, err)
+
+	require.Len(t, points.transactions, 1)
+	assert.Equal(t, -30, transaction.Points)
+	assert.Equal(t, 70, customers.customer.PointsBalance)
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Points transactions of an order reference it with this type, and clawbacks for returns use this source
+const (
+	PointsReferenceOrder = "order"
+	PointsSourceReturn   = "return"
+)
+
+// OrderPointsClawback works out the clawback of the points a customer earned from an order when
+// part of it is refunded for a return. The share taken back matches the refunded share of the
+// order, less what earlier returns of the order took back. Points the customer has already spent
+// cannot be taken back, so the clawback never takes the balance below zero.
+//
+// orderTransactions are the customer's points transactions that reference the order. When the
+// return was already clawed back, its transaction is returned with recorded set. Returns nil when
+// there is nothing to claw back.
+func OrderPointsClawback(orderTransactions []CustomerPointsTransaction, customerID, orderID, returnID uuid.UUID, refundAmount, orderAmount float64, balance int) (clawback *CustomerPointsTransaction, recorded bool, err error) {
+	if refundAmount <= 0 || orderAmount <= 0 {
+		return nil, false, ErrInvalidPointsAmount
+	}
+
+	var earned, clawedBack int
+	for i := range orderTransactions {
+		txn := &orderTransactions[i]
+		switch {
+		case txn.Type == PointsEarned:
+			earned += txn.Points
+		case txn.Type == PointsAdjusted && txn.Source == PointsSourceReturn:
+			if txn.TransactionID == returnID {
+				return txn, true, nil
+			}
+			clawedBack += -txn.Points
+		}
+	}
+
+	points := earned
+	if refundAmount < orderAmount {
+		points = int(math.Round(float64(earned) * refundAmount / orderAmount))
+	}
+	if points > earned-clawedBack {
+		points = earned - clawedBack
+	}
+	if points > balance {
+		points = balance
+	}
+	if points <= 0 {
+		return nil, false, nil
+	}
+
+	referenceType := PointsReferenceOrder
+	return &CustomerPointsTransaction{
+		ID:            uuid.New(),
+		CustomerID:    customerID,
+		TransactionID: returnID,
+		Type:          PointsAdjusted,
+		Points:        -points, // Negative for clawback
+		Balance:       balance - points,
+		ReferenceID:   &orderID,
+		ReferenceType: &referenceType,
+		Source:        PointsSourceReturn,
+		Description:   fmt.Sprintf("Points clawed back for refund %.2f THB", refundAmount),
+		CreatedAt:     time.Now(),
+	}, false, nil
+}
@@ -0,0 +1,72 @@
+package entity
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderPointsClawback(t *testing.T) {
+	customerID, orderID := uuid.New(), uuid.New()
+	earned := CustomerPointsTransaction{Type: PointsEarned, Points: 100}
+	earlierReturn := uuid.New()
+	clawedBack := CustomerPointsTransaction{TransactionID: earlierReturn, Type: PointsAdjusted, Points: -30, Source: PointsSourceReturn}
+
+	tests := []struct {
+		name         string
+		transactions []CustomerPointsTransaction
+		refund       float64
+		balance      int
+		wantPoints   int
+	}{
+		{name: "full refund", transactions: []CustomerPointsTransaction{earned}, refund: 1000, balance: 500, wantPoints: 100},
+		{name: "partial refund takes its share", transactions: []CustomerPointsTransaction{earned}, refund: 250, balance: 500, wantPoints: 25},
+		{name: "earlier returns are not taken twice", transactions: []CustomerPointsTransaction{earned, clawedBack}, refund: 1000, balance: 500, wantPoints: 70},
+		{name: "spent points are not taken back", transactions: []CustomerPointsTransaction{earned}, refund: 1000, balance: 40, wantPoints: 40},
+		{name: "nothing earned", refund: 1000, balance: 500},
+		{name: "nothing left to take", transactions: []CustomerPointsTransaction{earned}, refund: 1000, balance: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			returnID := uuid.New()
+			clawback, recorded, err := OrderPointsClawback(tt.transactions, customerID, orderID, returnID, tt.refund, 1000, tt.balance)
+			require.NoError(t, err)
+			assert.False(t, recorded)
+
+			if tt.wantPoints == 0 {
+				assert.Nil(t, clawback)
+				return
+			}
+			require.NotNil(t, clawback)
+			assert.Equal(t, -tt.wantPoints, clawback.Points)
+			assert.Equal(t, tt.balance-tt.wantPoints, clawback.Balance)
+			assert.Equal(t, returnID, clawback.TransactionID)
+			assert.Equal(t, PointsSourceReturn, clawback.Source)
+			assert.Equal(t, orderID, *clawback.ReferenceID)
+		})
+	}
+}
+
+func TestOrderPointsClawbackOfRecordedReturn(t *testing.T) {
+	returnID := uuid.New()
+	transactions := []CustomerPointsTransaction{
+		{Type: PointsEarned, Points: 100},
+		{TransactionID: returnID, Type: PointsAdjusted, Points: -100, Source: PointsSourceReturn},
+	}
+
+	clawback, recorded, err := OrderPointsClawback(transactions, uuid.New(), uuid.New(), returnID, 1000, 1000, 0)
+	require.NoError(t, err)
+	assert.True(t, recorded)
+	require.NotNil(t, clawback)
+	assert.Equal(t, -100, clawback.Points)
+}
+
+func TestOrderPointsClawbackRejectsInvalidAmounts(t *testing.T) {
+	_, _, err := OrderPointsClawback(nil, uuid.New(), uuid.New(), uuid.New(), 0, 1000, 100)
+	assert.ErrorIs(t, err, ErrInvalidPointsAmount)
+
+	_, _, err = OrderPointsClawback(nil, uuid.New(), uuid.New(), uuid.New(), 100, 0, 100)
+	assert.ErrorIs(t, err, ErrInvalidPointsAmount)
+}
@@ -87,6 +87,9 @@ type CustomerPointsRepository interface {
 	CreateTransaction(ctx context.Context, transaction *entity.CustomerPointsTransaction) error
 	GetTransactionsByCustomer(ctx context.Context, customerID uuid.UUID, limit int, offset int) ([]entity.CustomerPointsTransaction, error)
 	GetPointsBalance(ctx context.Context, customerID uuid.UUID) (int, error)
+	GetTransactionsByReference(ctx context.Context, customerID uuid.UUID, referenceType string, referenceID uuid.UUID) ([]entity.CustomerPointsTransaction, error)
+	// ClawBackOrderPoints atomically records the clawback of a return's points and updates the balance
+	ClawBackOrderPoints(ctx context.Context, customerID, orderID, returnID uuid.UUID, refundAmount, orderAmount float64) (*entity.CustomerPointsTransaction, error)
 
 	// Points operations
 	EarnPoints(ctx context.Context, customerID uuid.UUID, points int, source, description string, referenceID *uuid.UUID, referenceType *string) error
@@ -747,6 +747,17 @@ func NewCustomerPointsRepository(db *sql.DB) repository.CustomerPointsRepository
 
 // CreateTransaction creates a new points transaction
 func (r *customerPointsRepository) CreateTransaction(ctx context.Context, transaction *entity.CustomerPointsTransaction) error {
+	return createPointsTransaction(ctx, r.db, transaction)
+}
+
+// sqlExecutor runs statements on the database or within a transaction
+type sqlExecutor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func createPointsTransaction(ctx context.Context, db sqlExecutor, transaction *entity.CustomerPointsTransaction) error {
 	query := `
 		INSERT INTO customer_points_transactions (
 			id, customer_id, transaction_id, type, points, balance, source, 
@@ -754,7 +765,7 @@ func (r *customerPointsRepository) CreateTransaction(ctx context.Context, transa
 		)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := db.ExecContext(ctx, query,
 		transaction.ID, transaction.CustomerID, transaction.TransactionID, transaction.Type,
 		transaction.Points, transaction.Balance, transaction.Source, transaction.Description,
 		transaction.ReferenceID, transaction.ReferenceType, transaction.ExpiryDate, transaction.CreatedAt)
@@ -800,6 +811,91 @@ func (r *customerPointsRepository) GetTransactionsByCustomer(ctx context.Context
 	return transactions, nil
 }
 
+// GetTransactionsByReference retrieves the points transactions of a customer that reference an order or other entity
+func (r *customerPointsRepository) GetTransactionsByReference(ctx context.Context, customerID uuid.UUID, referenceType string, referenceID uuid.UUID) ([]entity.CustomerPointsTransaction, error) {
+	return pointsTransactionsByReference(ctx, r.db, customerID, referenceType, referenceID)
+}
+
+func pointsTransactionsByReference(ctx context.Context, db sqlExecutor, customerID uuid.UUID, referenceType string, referenceID uuid.UUID) ([]entity.CustomerPointsTransaction, error) {
+	query := `
+		SELECT id, customer_id, transaction_id, type, points, balance, source,
+			   description, reference_id, reference_type, expiry_date, created_at
+		FROM customer_points_transactions 
+		WHERE customer_id = $1 AND reference_type = $2 AND reference_id = $3
+		ORDER BY created_at`
+
+	rows, err := db.QueryContext(ctx, query, customerID, referenceType, referenceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get points transactions by reference: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []entity.CustomerPointsTransaction
+	for rows.Next() {
+		transaction := entity.CustomerPointsTransaction{}
+		err := rows.Scan(
+			&transaction.ID, &transaction.CustomerID, &transaction.TransactionID, &transaction.Type,
+			&transaction.Points, &transaction.Balance, &transaction.Source, &transaction.Description,
+			&transaction.ReferenceID, &transaction.ReferenceType, &transaction.ExpiryDate, &transaction.CreatedAt)
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan points transaction: %w", err)
+		}
+
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, nil
+}
+
+// ClawBackOrderPoints records the clawback of a return and takes its points off the customer's
+// balance in one transaction. The customer row stays locked from reading the balance to updating
+// it, so concurrent clawbacks and earlier returns of the order are always accounted for. A return
+// already clawed back returns its recorded transaction.
+func (r *customerPointsRepository) ClawBackOrderPoints(ctx context.Context, customerID, orderID, returnID uuid.UUID, refundAmount, orderAmount float64) (*entity.CustomerPointsTransaction, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var balance int
+	err = tx.QueryRowContext(ctx, `SELECT points_balance FROM customers WHERE id = $1 FOR UPDATE`, customerID).Scan(&balance)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, entity.ErrCustomerNotFound
+		}
+		return nil, fmt.Errorf("failed to lock customer: %w", err)
+	}
+
+	transactions, err := pointsTransactionsByReference(ctx, tx, customerID, entity.PointsReferenceOrder, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	clawback, recorded, err := entity.OrderPointsClawback(transactions, customerID, orderID, returnID, refundAmount, orderAmount, balance)
+	if err != nil || recorded || clawback == nil {
+		return clawback, err
+	}
+
+	if err := createPointsTransaction(ctx, tx, clawback); err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx,
+		`UPDATE customers SET points_balance = points_balance + $1, updated_at = $2 WHERE id = $3`,
+		clawback.Points, time.Now(), customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update customer balance: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return clawback, nil
+}
+
 // GetPointsBalance retrieves current points balance for a customer
 func (r *customerPointsRepository) GetPointsBalance(ctx context.Context, customerID uuid.UUID) (int, error) {
 	query := `SELECT points_balance FROM customers WHERE id = $1`
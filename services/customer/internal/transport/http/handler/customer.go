@@ -252,7 +252,7 @@ func (h *CustomerHandler) GetCustomerByPhone(c *gin.Context) {
 	c.JSON(http.StatusOK, customer)
 }
 
-// ListCustomers retrieves a list of customers with pagination
+// ListCustomers retrieves a list of customers with pagination, optionally of one tier
 func (h *CustomerHandler) ListCustomers(c *gin.Context) {
 	// Parse pagination parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -268,7 +268,19 @@ func (h *CustomerHandler) ListCustomers(c *gin.Context) {
 	// Calculate offset
 	offset := (page - 1) * limit
 
-	customers, total, err := h.customerUsecase.ListCustomers(c.Request.Context(), limit, offset)
+	// Optional tier filter, from 1 (Bronze) to 5 (Diamond)
+	var tier *entity.CustomerTier
+	if tierStr := c.Query("tier"); tierStr != "" {
+		value, err := strconv.Atoi(tierStr)
+		if err != nil || entity.CustomerTier(value) < entity.TierBronze || entity.CustomerTier(value) > entity.TierDiamond {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid tier"})
+			return
+		}
+		customerTier := entity.CustomerTier(value)
+		tier = &customerTier
+	}
+
+	customers, total, err := h.customerUsecase.ListCustomers(c.Request.Context(), tier, limit, offset)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list customers"})
 		return
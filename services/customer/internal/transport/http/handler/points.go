@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
@@ -39,6 +40,14 @@ type RedeemPointsHTTPRequest struct {
 	OrderID     string  `json:"order_id"` // Optional reference to order
 }
 
+// ClawBackPointsHTTPRequest represents the HTTP request body for clawing back points of a refunded order
+type ClawBackPointsHTTPRequest struct {
+	OrderID      uuid.UUID `json:"order_id" binding:"required"`
+	ReturnID     uuid.UUID `json:"return_id" binding:"required"`
+	RefundAmount float64   `json:"refund_amount" binding:"required,gt=0"`
+	OrderAmount  float64   `json:"order_amount" binding:"required,gt=0"`
+}
+
 // EarnPoints adds points to a customer's balance
 func (h *PointsHandler) EarnPoints(c *gin.Context) {
 	// Get customer ID from URL parameter
@@ -153,6 +162,52 @@ func (h *PointsHandler) RedeemPoints(c *gin.Context) {
 	})
 }
 
+// ClawBackPoints takes back the points earned from the refunded part of an order
+func (h *PointsHandler) ClawBackPoints(c *gin.Context) {
+	idStr := c.Param("id")
+	customerID, err := uuid.Parse(idStr)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return
+	}
+
+	var req ClawBackPointsHTTPRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	transaction, err := h.pointsUsecase.ClawBackOrderPoints(c.Request.Context(), &application.ClawBackOrderPointsRequest{
+		CustomerID:   customerID,
+		OrderID:      req.OrderID,
+		ReturnID:     req.ReturnID,
+		RefundAmount: req.RefundAmount,
+		OrderAmount:  req.OrderAmount,
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, entity.ErrCustomerNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": entity.ErrCustomerNotFound.Error()})
+		case errors.Is(err, entity.ErrInvalidPointsAmount):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to claw back points"})
+		}
+		return
+	}
+
+	pointsClawedBack := 0
+	if transaction != nil {
+		pointsClawedBack = -transaction.Points
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Points clawed back successfully",
+		"points_clawed_back": pointsClawedBack,
+		"transaction":        transaction,
+	})
+}
+
 // GetPointsBalance retrieves a customer's current points balance
 func (h *PointsHandler) GetPointsBalance(c *gin.Context) {
 	idStr := c.Param("id")
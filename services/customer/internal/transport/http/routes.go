@@ -57,6 +57,7 @@ func SetupRoutes(router *gin.Engine, app *application.Application) {
 			customers.GET("/:id/points", pointsHandler.GetPointsBalance)
 			customers.POST("/:id/points/earn", pointsHandler.EarnPoints)
 			customers.POST("/:id/points/redeem", pointsHandler.RedeemPoints)
+			customers.POST("/:id/points/clawback", pointsHandler.ClawBackPoints)
 			customers.GET("/:id/points/history", pointsHandler.GetPointsHistory)
 			customers.GET("/:id/points/stats", pointsHandler.GetPointsStats)
 
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_points_return_clawback;
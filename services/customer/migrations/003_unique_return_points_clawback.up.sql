@@ -0,0 +1,8 @@
+-- A return is clawed back once. The repository writes the return ID as the transaction_id of
+-- its clawback, so at most one return clawback may carry it.
+ALTER TABLE customer_points_transactions
+ADD COLUMN IF NOT EXISTS transaction_id UUID;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_points_return_clawback
+    ON customer_points_transactions(transaction_id)
+    WHERE source = 'return';
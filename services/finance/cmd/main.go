@@ -5,6 +5,7 @@ import (
 	"os"
 
 	"finance/internal/application"
+	"finance/internal/infrastructure/banking"
 	"finance/internal/infrastructure/database"
 	"finance/internal/infrastructure/database/repositories"
 	"finance/internal/infrastructure/cache"
@@ -32,16 +33,23 @@ func main() {
 	}
 	defer redisClient.Close()
 
+	transferExecutor, err := banking.New()
+	if err != nil {
+		log.Fatal("Failed to initialize transfer executor:", err)
+	}
+	log.Printf("Transfers are executed through %s", transferExecutor.Name())
+
 	// Initialize repositories
 	repos := repositories.NewRepositories(db)
 
 	// Initialize application services
-	financeService := application.NewFinanceService(repos, redisClient)
+	financeService := application.NewFinanceService(repos, redisClient, transferExecutor)
 	allocationService := application.NewAllocationService(repos, redisClient)
 	cashFlowService := application.NewCashFlowService(repos, redisClient)
+	ledgerService := application.NewLedgerService(repos)
 
 	// Initialize HTTP server
-	router := http.NewRouter(financeService, allocationService, cashFlowService)
+	router := http.NewRouter(financeService, allocationService, cashFlowService, ledgerService)
 
 	port := os.Getenv("SERVER_PORT")
 	if port == "" {
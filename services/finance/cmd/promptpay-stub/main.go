@@ -0,0 +1,29 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"finance/internal/infrastructure/banking"
+)
+
+// Runs the PromptPay transfer API stub for local development:
+//
+//	PROMPTPAY_API_KEY=stub-key go run ./cmd/promptpay-stub
+func main() {
+	port := getEnv("PROMPTPAY_STUB_PORT", "8099")
+	apiKey := getEnv("PROMPTPAY_API_KEY", "stub-key")
+
+	log.Printf("PromptPay stub listening on port %s", port)
+	if err := http.ListenAndServe(":"+port, banking.NewPromptPayStub(apiKey)); err != nil {
+		log.Fatal("Failed to start PromptPay stub:", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -1,6 +1,7 @@
 package application
 
 import (
+	"errors"
 	"time"
 
 	"finance/internal/domain"
@@ -23,35 +24,95 @@ func NewCashFlowService(repos *repositories.Repositories, redis cache.RedisClien
 }
 
 func (c *cashFlowService) RecordTransaction(entityType string, entityID uuid.UUID, txType domain.CashFlowType, amount float64, description, reference string, createdBy *uuid.UUID) error {
+	_, err := c.recordTransaction(entityType, entityID, txType, amount, description, reference, createdBy)
+	return err
+}
+
+// RecordTransactionOnce records a cash flow unless the entity already has one with the same
+// reference, in which case the existing record is returned. Callers that retry, like order
+// refunds, use it so a retry never moves the cash twice. The reference is unique per entity in
+// the database, so concurrent retries that both miss the lookup still record it once.
+func (c *cashFlowService) RecordTransactionOnce(entityType string, entityID uuid.UUID, txType domain.CashFlowType, amount float64, description, reference string, createdBy *uuid.UUID) (*domain.CashFlowRecord, bool, error) {
+	if err := c.ValidateEntity(entityType, entityID); err != nil {
+		return nil, false, err
+	}
+	if reference == "" {
+		return nil, false, domain.ErrMissingReference
+	}
+
+	existing, err := c.repos.CashFlow.GetByReference(entityType, entityID, reference)
+	if err == nil {
+		return existing, false, nil
+	}
+	if !errors.Is(err, domain.ErrCashFlowNotFound) {
+		return nil, false, err
+	}
+
+	record, err := c.newRecord(entityType, entityID, txType, amount, description, reference, createdBy)
+	if err != nil {
+		return nil, false, err
+	}
+
+	created, err := c.repos.CashFlow.CreateOnce(record)
+	if err != nil {
+		return nil, false, err
+	}
+	if !created {
+		// Another request recorded the reference after the lookup above
+		existing, err := c.repos.CashFlow.GetByReference(entityType, entityID, reference)
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+	invalidateCashStatus(c.redis)
+
+	return record, true, nil
+}
+
+func (c *cashFlowService) recordTransaction(entityType string, entityID uuid.UUID, txType domain.CashFlowType, amount float64, description, reference string, createdBy *uuid.UUID) (*domain.CashFlowRecord, error) {
+	record, err := c.newRecord(entityType, entityID, txType, amount, description, reference, createdBy)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.repos.CashFlow.Create(record); err != nil {
+		return nil, err
+	}
+	invalidateCashStatus(c.redis)
+
+	return record, nil
+}
+
+// newRecord validates a cash flow and builds its record with the running balance
+func (c *cashFlowService) newRecord(entityType string, entityID uuid.UUID, txType domain.CashFlowType, amount float64, description, reference string, createdBy *uuid.UUID) (*domain.CashFlowRecord, error) {
 	// Validate inputs
 	if amount <= 0 {
-		return domain.ErrInvalidAmount
+		return nil, domain.ErrInvalidAmount
 	}
 	if description == "" {
-		return domain.ErrMissingDescription
+		return nil, domain.ErrMissingDescription
 	}
 
 	// Calculate running balance
 	runningBalance, err := c.repos.CashFlow.CalculateRunningBalance(entityType, entityID, txType, amount)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create cash flow record
-	record := &domain.CashFlowRecord{
-		ID:             uuid.New(),
-		EntityType:     entityType,
-		EntityID:       entityID,
+	return &domain.CashFlowRecord{
+		ID:              uuid.New(),
+		EntityType:      entityType,
+		EntityID:        entityID,
 		TransactionType: txType,
-		Amount:         amount,
-		Description:    description,
-		Reference:      reference,
-		RunningBalance: runningBalance,
-		CreatedBy:      createdBy,
-		CreatedAt:      time.Now(),
-	}
-
-	return c.repos.CashFlow.Create(record)
+		Amount:          amount,
+		Description:     description,
+		Reference:       reference,
+		RunningBalance:  runningBalance,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now(),
+	}, nil
 }
 
 func (c *cashFlowService) GetEntityCashFlow(entityType string, entityID uuid.UUID, limit int) ([]*domain.CashFlowRecord, error) {
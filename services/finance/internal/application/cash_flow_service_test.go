@@ -0,0 +1,102 @@
+package application
+
+import (
+	"errors"
+	"testing"
+
+	"finance/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func TestRecordTransactionOnce_RetryReturnsExistingRecord(t *testing.T) {
+	service, _, flows, redisClient := setupCashStatus()
+	cashFlow := NewCashFlowService(service.repos, redisClient)
+
+	first, created, err := cashFlow.RecordTransactionOnce("central", uuid.Nil, domain.CashOutflow, 450, "Refund for return", "return:1", nil)
+	if err != nil {
+		t.Fatalf("RecordTransactionOnce() error = %v", err)
+	}
+	if !created {
+		t.Errorf("first call created = false, want true")
+	}
+
+	second, created, err := cashFlow.RecordTransactionOnce("central", uuid.Nil, domain.CashOutflow, 450, "Refund for return", "return:1", nil)
+	if err != nil {
+		t.Fatalf("RecordTransactionOnce() retry error = %v", err)
+	}
+	if created {
+		t.Errorf("retry created = true, want false")
+	}
+	if second.ID != first.ID {
+		t.Errorf("retry returned record %s, want %s", second.ID, first.ID)
+	}
+	if len(flows.created) != 1 {
+		t.Errorf("records created = %d, want 1", len(flows.created))
+	}
+}
+
+// racingCashFlowRepository misses the first lookup, as when a concurrent retry records the
+// reference between the lookup and the insert
+type racingCashFlowRepository struct {
+	*stubCashFlowRepository
+	missed bool
+}
+
+func (r *racingCashFlowRepository) GetByReference(entityType string, entityID uuid.UUID, reference string) (*domain.CashFlowRecord, error) {
+	if !r.missed {
+		r.missed = true
+		return nil, domain.ErrCashFlowNotFound
+	}
+	return r.stubCashFlowRepository.GetByReference(entityType, entityID, reference)
+}
+
+func TestRecordTransactionOnce_ConcurrentRetryReturnsExistingRecord(t *testing.T) {
+	service, _, flows, redisClient := setupCashStatus()
+	existing := &domain.CashFlowRecord{ID: uuid.New(), EntityType: "central", EntityID: uuid.Nil, Amount: 450, Reference: "return:1"}
+	flows.created = append(flows.created, existing)
+
+	repos := *service.repos
+	repos.CashFlow = &racingCashFlowRepository{stubCashFlowRepository: flows}
+	cashFlow := NewCashFlowService(&repos, redisClient)
+
+	record, created, err := cashFlow.RecordTransactionOnce("central", uuid.Nil, domain.CashOutflow, 450, "Refund for return", "return:1", nil)
+	if err != nil {
+		t.Fatalf("RecordTransactionOnce() error = %v", err)
+	}
+	if created || record.ID != existing.ID {
+		t.Errorf("got record %s, created = %v; want the existing record %s", record.ID, created, existing.ID)
+	}
+	if len(flows.created) != 1 {
+		t.Errorf("records created = %d, want 1", len(flows.created))
+	}
+}
+
+func TestRecordTransactionOnce_InvalidRequests(t *testing.T) {
+	service, _, flows, redisClient := setupCashStatus()
+	cashFlow := NewCashFlowService(service.repos, redisClient)
+
+	tests := []struct {
+		name       string
+		entityType string
+		amount     float64
+		reference  string
+		want       error
+	}{
+		{name: "unknown entity", entityType: "warehouse", amount: 100, reference: "return:1", want: domain.ErrInvalidEntity},
+		{name: "missing reference", entityType: "central", amount: 100, want: domain.ErrMissingReference},
+		{name: "zero amount", entityType: "central", reference: "return:1", want: domain.ErrInvalidAmount},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := cashFlow.RecordTransactionOnce(tt.entityType, uuid.Nil, domain.CashOutflow, tt.amount, "Refund", tt.reference, nil)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if len(flows.created) != 0 {
+		t.Errorf("records created = %d, want 0", len(flows.created))
+	}
+}
@@ -0,0 +1,235 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"finance/internal/domain"
+	"finance/internal/infrastructure/cache"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// cashStatusVersionKey holds the cache generation; bumping it invalidates every cached status
+	cashStatusVersionKey = "finance:cash_status:version"
+	cashStatusTTL        = 10 * time.Minute
+)
+
+// GetCashStatus returns the balance of every account as of the end of the asOf business date,
+// for one branch or vehicle, or for the company with a breakdown per entity when both IDs are nil
+func (f *financeService) GetCashStatus(asOf time.Time, branchID, vehicleID *uuid.UUID) (*domain.CashStatus, error) {
+	if branchID != nil && vehicleID != nil {
+		return nil, domain.ErrInvalidEntity
+	}
+
+	now := time.Now()
+	if asOf.IsZero() {
+		asOf = now
+	}
+	asOf = time.Date(asOf.Year(), asOf.Month(), asOf.Day(), 0, 0, 0, 0, asOf.Location())
+	if asOf.After(now) {
+		return nil, domain.ErrInvalidDate
+	}
+
+	entityType, entityID := "company", (*uuid.UUID)(nil)
+	switch {
+	case branchID != nil:
+		entityType, entityID = "branch", branchID
+	case vehicleID != nil:
+		entityType, entityID = "vehicle", vehicleID
+	}
+
+	ctx := context.Background()
+	key, cacheable := cashStatusCacheKey(ctx, f.redis, entityType, entityID, asOf)
+	if cacheable {
+		if status := getCachedCashStatus(ctx, f.redis, key); status != nil {
+			return status, nil
+		}
+	}
+
+	allocations, err := f.repos.CashSummary.GetAllocationTotals(asOf, branchID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	flows, err := f.repos.CashFlow.GetTotalsByEntity(asOf.AddDate(0, 0, 1), entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := buildCashStatus(asOf, entityType, entityID, allocations, flows)
+	status.CalculatedAt = now
+
+	if cacheable {
+		setCachedCashStatus(ctx, f.redis, key, status)
+	}
+
+	return status, nil
+}
+
+// buildCashStatus turns allocation and cash flow totals into per-entity balances and keeps
+// the requested entity, or every entity for the company scope
+func buildCashStatus(asOf time.Time, entityType string, entityID *uuid.UUID, allocations []*domain.AllocationTotals, flows []*domain.CashFlowTotals) *domain.CashStatus {
+	balances := make(map[string]*domain.EntityCashBalance)
+	var order []string
+
+	entityBalance := func(entityType string, entityID *uuid.UUID) *domain.EntityCashBalance {
+		key := entityType
+		if entityID != nil {
+			key += ":" + entityID.String()
+		}
+		balance, ok := balances[key]
+		if !ok {
+			balance = newEntityCashBalance(entityType, entityID)
+			balances[key] = balance
+			order = append(order, key)
+		}
+		return balance
+	}
+
+	for _, total := range allocations {
+		var balance *domain.EntityCashBalance
+		switch {
+		case total.BranchID != nil:
+			balance = entityBalance("branch", total.BranchID)
+		case total.VehicleID != nil:
+			balance = entityBalance("vehicle", total.VehicleID)
+		default:
+			balance = entityBalance("central", nil)
+		}
+		addAllocationTotals(balance, total)
+	}
+
+	for _, total := range flows {
+		var balance *domain.EntityCashBalance
+		if total.EntityType == "central" {
+			// Central office flows belong to the company, whatever ID they were recorded under
+			balance = entityBalance("central", nil)
+		} else {
+			id := total.EntityID
+			balance = entityBalance(total.EntityType, &id)
+		}
+		balance.CashInflows += total.Inflows
+		balance.CashOutflows += total.Outflows
+	}
+
+	status := &domain.CashStatus{AsOf: asOf}
+
+	if entityType != "company" {
+		key := entityType + ":" + entityID.String()
+		status.Totals = newEntityCashBalance(entityType, entityID)
+		if balance, ok := balances[key]; ok {
+			status.Totals = balance
+		}
+		roundEntityCashBalance(status.Totals)
+		return status
+	}
+
+	status.Totals = newEntityCashBalance("company", nil)
+	for _, key := range order {
+		balance := balances[key]
+		for account, amount := range balance.Accounts {
+			status.Totals.Accounts[account] += amount
+		}
+		status.Totals.CashInflows += balance.CashInflows
+		status.Totals.CashOutflows += balance.CashOutflows
+		roundEntityCashBalance(balance)
+		status.Entities = append(status.Entities, balance)
+	}
+	roundEntityCashBalance(status.Totals)
+
+	return status
+}
+
+func newEntityCashBalance(entityType string, entityID *uuid.UUID) *domain.EntityCashBalance {
+	return &domain.EntityCashBalance{
+		EntityType: entityType,
+		EntityID:   entityID,
+		Accounts: map[domain.AccountType]float64{
+			domain.RevenueAccount:   0,
+			domain.ProfitAccount:    0,
+			domain.OwnerPayAccount:  0,
+			domain.TaxAccount:       0,
+			domain.OperatingAccount: 0,
+		},
+	}
+}
+
+// addAllocationTotals adds allocations to the accounts; the operating account is what is
+// left for expenses after the expenses and transfers already paid from it
+func addAllocationTotals(balance *domain.EntityCashBalance, total *domain.AllocationTotals) {
+	balance.Accounts[domain.RevenueAccount] += total.TotalSales
+	balance.Accounts[domain.ProfitAccount] += total.ProfitAllocation
+	balance.Accounts[domain.OwnerPayAccount] += total.OwnerPayAllocation
+	balance.Accounts[domain.TaxAccount] += total.TaxAllocation
+	balance.Accounts[domain.OperatingAccount] += total.AvailableForExpenses - total.ManualExpenses - total.SupplierTransfers - total.OtherTransfers
+}
+
+func roundEntityCashBalance(balance *domain.EntityCashBalance) {
+	for account, amount := range balance.Accounts {
+		balance.Accounts[account] = roundBaht(amount)
+	}
+	balance.CashInflows = roundBaht(balance.CashInflows)
+	balance.CashOutflows = roundBaht(balance.CashOutflows)
+	balance.CashBalance = roundBaht(balance.CashInflows - balance.CashOutflows)
+}
+
+func roundBaht(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// cashStatusCacheKey builds the cache key under the current cache generation. When Redis is
+// unavailable the status is computed without the cache.
+func cashStatusCacheKey(ctx context.Context, client cache.RedisClient, entityType string, entityID *uuid.UUID, asOf time.Time) (string, bool) {
+	version, err := client.Get(ctx, cashStatusVersionKey).Result()
+	if err == redis.Nil {
+		version = "0"
+	} else if err != nil {
+		log.Printf("Cash status cache unavailable: %v", err)
+		return "", false
+	}
+
+	id := "all"
+	if entityID != nil {
+		id = entityID.String()
+	}
+	return fmt.Sprintf("finance:cash_status:%s:%s:%s:%s", version, entityType, id, asOf.Format("2006-01-02")), true
+}
+
+func getCachedCashStatus(ctx context.Context, client cache.RedisClient, key string) *domain.CashStatus {
+	data, err := client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil
+	}
+
+	var status domain.CashStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		return nil
+	}
+	return &status
+}
+
+func setCachedCashStatus(ctx context.Context, client cache.RedisClient, key string, status *domain.CashStatus) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	if err := client.Set(ctx, key, data, cashStatusTTL).Err(); err != nil {
+		log.Printf("Failed to cache cash status: %v", err)
+	}
+}
+
+// invalidateCashStatus starts a new cache generation after a write that changes balances.
+// Entries of the old generation are never read again and expire on their own.
+func invalidateCashStatus(client cache.RedisClient) {
+	ctx := context.Background()
+	version := fmt.Sprintf("%d", time.Now().UnixNano())
+	if err := client.Set(ctx, cashStatusVersionKey, version, 0).Err(); err != nil {
+		log.Printf("Failed to invalidate cash status cache: %v", err)
+	}
+}
@@ -0,0 +1,265 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"finance/internal/domain"
+	"finance/internal/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// memoryRedisClient is a Redis client backed by a map
+type memoryRedisClient struct {
+	values map[string]string
+}
+
+func newMemoryRedisClient() *memoryRedisClient {
+	return &memoryRedisClient{values: make(map[string]string)}
+}
+
+func (m *memoryRedisClient) Get(ctx context.Context, key string) *redis.StringCmd {
+	value, ok := m.values[key]
+	if !ok {
+		return redis.NewStringResult("", redis.Nil)
+	}
+	return redis.NewStringResult(value, nil)
+}
+func (m *memoryRedisClient) Set(ctx context.Context, key string, value interface{}, expiration time.Duration) *redis.StatusCmd {
+	switch v := value.(type) {
+	case []byte:
+		m.values[key] = string(v)
+	case string:
+		m.values[key] = v
+	}
+	return redis.NewStatusResult("OK", nil)
+}
+func (m *memoryRedisClient) Del(ctx context.Context, keys ...string) *redis.IntCmd {
+	for _, key := range keys {
+		delete(m.values, key)
+	}
+	return redis.NewIntResult(int64(len(keys)), nil)
+}
+func (m *memoryRedisClient) Close() error { return nil }
+
+type stubCashSummaryRepository struct {
+	domain.CashSummaryRepository
+	totals []*domain.AllocationTotals
+	calls  int
+}
+
+// GetAllocationTotals filters the totals like the query does
+func (r *stubCashSummaryRepository) GetAllocationTotals(asOf time.Time, branchID, vehicleID *uuid.UUID) ([]*domain.AllocationTotals, error) {
+	r.calls++
+	var totals []*domain.AllocationTotals
+	for _, total := range r.totals {
+		if (branchID == nil || total.BranchID != nil && *total.BranchID == *branchID) &&
+			(vehicleID == nil || total.VehicleID != nil && *total.VehicleID == *vehicleID) {
+			totals = append(totals, total)
+		}
+	}
+	return totals, nil
+}
+
+type stubCashFlowRepository struct {
+	domain.CashFlowRepository
+	totals  []*domain.CashFlowTotals
+	created []*domain.CashFlowRecord
+	before  time.Time
+}
+
+// GetTotalsByEntity filters the totals like the query does
+func (r *stubCashFlowRepository) GetTotalsByEntity(before time.Time, entityType string, entityID *uuid.UUID) ([]*domain.CashFlowTotals, error) {
+	r.before = before
+	var totals []*domain.CashFlowTotals
+	for _, total := range r.totals {
+		if entityID == nil || total.EntityType == entityType && total.EntityID == *entityID {
+			totals = append(totals, total)
+		}
+	}
+	return totals, nil
+}
+
+func (r *stubCashFlowRepository) CalculateRunningBalance(entityType string, entityID uuid.UUID, transactionType domain.CashFlowType, amount float64) (float64, error) {
+	return amount, nil
+}
+
+func (r *stubCashFlowRepository) Create(record *domain.CashFlowRecord) error {
+	r.created = append(r.created, record)
+	return nil
+}
+
+func (r *stubCashFlowRepository) CreateOnce(record *domain.CashFlowRecord) (bool, error) {
+	for _, existing := range r.created {
+		if existing.EntityType == record.EntityType && existing.EntityID == record.EntityID && existing.Reference == record.Reference {
+			return false, nil
+		}
+	}
+	return true, r.Create(record)
+}
+
+func (r *stubCashFlowRepository) GetByReference(entityType string, entityID uuid.UUID, reference string) (*domain.CashFlowRecord, error) {
+	for _, record := range r.created {
+		if record.EntityType == entityType && record.EntityID == entityID && record.Reference == reference {
+			return record, nil
+		}
+	}
+	return nil, domain.ErrCashFlowNotFound
+}
+
+var (
+	testBranchID  = uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	testVehicleID = uuid.MustParse("22222222-2222-2222-2222-222222222222")
+)
+
+func setupCashStatus() (*financeService, *stubCashSummaryRepository, *stubCashFlowRepository, *memoryRedisClient) {
+	summaries := &stubCashSummaryRepository{totals: []*domain.AllocationTotals{
+		{
+			BranchID:             &testBranchID,
+			TotalSales:           10000,
+			ProfitAllocation:     500,
+			OwnerPayAllocation:   5000,
+			TaxAllocation:        1500,
+			AvailableForExpenses: 3000,
+			ManualExpenses:       400,
+			SupplierTransfers:    1000,
+		},
+		{
+			VehicleID:            &testVehicleID,
+			TotalSales:           2000,
+			ProfitAllocation:     100,
+			OwnerPayAllocation:   1000,
+			TaxAllocation:        300,
+			AvailableForExpenses: 600,
+			OtherTransfers:       50.25,
+		},
+	}}
+	flows := &stubCashFlowRepository{totals: []*domain.CashFlowTotals{
+		{EntityType: "branch", EntityID: testBranchID, Inflows: 9000, Outflows: 1400},
+		{EntityType: "central", EntityID: uuid.New(), Inflows: 500},
+	}}
+	redisClient := newMemoryRedisClient()
+	repos := &repositories.Repositories{CashSummary: summaries, CashFlow: flows}
+	service := NewFinanceService(repos, redisClient, nil).(*financeService)
+	return service, summaries, flows, redisClient
+}
+
+func TestGetCashStatus_CompanyTotals(t *testing.T) {
+	service, _, flows, _ := setupCashStatus()
+	asOf := time.Date(2024, 1, 15, 14, 0, 0, 0, time.UTC)
+
+	status, err := service.GetCashStatus(asOf, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCashStatus() error = %v", err)
+	}
+
+	if !status.AsOf.Equal(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("AsOf = %v, want start of business date", status.AsOf)
+	}
+	if !flows.before.Equal(time.Date(2024, 1, 16, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("cash flows read before %v, want end of business date", flows.before)
+	}
+
+	want := map[domain.AccountType]float64{
+		domain.RevenueAccount:   12000,
+		domain.ProfitAccount:    600,
+		domain.OwnerPayAccount:  6000,
+		domain.TaxAccount:       1800,
+		domain.OperatingAccount: 2149.75,
+	}
+	for account, amount := range want {
+		if got := status.Totals.Accounts[account]; got != amount {
+			t.Errorf("company %s = %.2f, want %.2f", account, got, amount)
+		}
+	}
+	if status.Totals.CashBalance != 8100 {
+		t.Errorf("company cash balance = %.2f, want 8100", status.Totals.CashBalance)
+	}
+	if len(status.Entities) != 3 {
+		t.Fatalf("got %d entities, want branch, vehicle and central", len(status.Entities))
+	}
+}
+
+func TestGetCashStatus_PerEntity(t *testing.T) {
+	service, _, _, _ := setupCashStatus()
+	asOf := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	branch, err := service.GetCashStatus(asOf, &testBranchID, nil)
+	if err != nil {
+		t.Fatalf("GetCashStatus(branch) error = %v", err)
+	}
+	if branch.Totals.EntityType != "branch" || branch.Entities != nil {
+		t.Errorf("branch status = %+v, want branch totals only", branch.Totals)
+	}
+	if got := branch.Totals.Accounts[domain.OperatingAccount]; got != 1600 {
+		t.Errorf("branch operating = %.2f, want 1600", got)
+	}
+	if branch.Totals.CashBalance != 7600 {
+		t.Errorf("branch cash balance = %.2f, want 7600", branch.Totals.CashBalance)
+	}
+
+	vehicle, err := service.GetCashStatus(asOf, nil, &testVehicleID)
+	if err != nil {
+		t.Fatalf("GetCashStatus(vehicle) error = %v", err)
+	}
+	if got := vehicle.Totals.Accounts[domain.ProfitAccount]; got != 100 {
+		t.Errorf("vehicle profit = %.2f, want 100", got)
+	}
+
+	unknown := uuid.New()
+	empty, err := service.GetCashStatus(asOf, &unknown, nil)
+	if err != nil {
+		t.Fatalf("GetCashStatus(unknown) error = %v", err)
+	}
+	if empty.Totals.Accounts[domain.RevenueAccount] != 0 || empty.Totals.CashBalance != 0 {
+		t.Errorf("unknown branch should have zero balances, got %+v", empty.Totals)
+	}
+}
+
+func TestGetCashStatus_CachedUntilCashFlowWrite(t *testing.T) {
+	service, summaries, flows, redisClient := setupCashStatus()
+	cashFlow := NewCashFlowService(service.repos, redisClient)
+	asOf := time.Now()
+
+	first, err := service.GetCashStatus(asOf, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCashStatus() error = %v", err)
+	}
+	if _, err := service.GetCashStatus(asOf, nil, nil); err != nil {
+		t.Fatalf("GetCashStatus() error = %v", err)
+	}
+	if summaries.calls != 1 {
+		t.Fatalf("repository called %d times, want 1 with cache", summaries.calls)
+	}
+
+	if err := cashFlow.RecordTransaction("branch", testBranchID, domain.CashInflow, 250, "COD deposit", "DEP-1", nil); err != nil {
+		t.Fatalf("RecordTransaction() error = %v", err)
+	}
+	flows.totals[0].Inflows += 250
+
+	second, err := service.GetCashStatus(asOf, nil, nil)
+	if err != nil {
+		t.Fatalf("GetCashStatus() error = %v", err)
+	}
+	if summaries.calls != 2 {
+		t.Errorf("repository called %d times, want recalculation after write", summaries.calls)
+	}
+	if second.Totals.CashBalance != first.Totals.CashBalance+250 {
+		t.Errorf("cash balance after write = %.2f, want %.2f", second.Totals.CashBalance, first.Totals.CashBalance+250)
+	}
+}
+
+func TestGetCashStatus_InvalidRequests(t *testing.T) {
+	service, _, _, _ := setupCashStatus()
+
+	if _, err := service.GetCashStatus(time.Now(), &testBranchID, &testVehicleID); !errors.Is(err, domain.ErrInvalidEntity) {
+		t.Errorf("branch and vehicle error = %v, want ErrInvalidEntity", err)
+	}
+	if _, err := service.GetCashStatus(time.Now().AddDate(0, 0, 2), nil, nil); !errors.Is(err, domain.ErrInvalidDate) {
+		t.Errorf("future date error = %v, want ErrInvalidDate", err)
+	}
+}
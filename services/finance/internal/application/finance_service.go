@@ -1,6 +1,10 @@
 package application
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"finance/internal/domain"
@@ -10,15 +14,27 @@ import (
 	"github.com/google/uuid"
 )
 
+// transferTimeout bounds how long one batch execution may spend talking to the bank
+const transferTimeout = 2 * time.Minute
+
+// staleBatchAfter is how long a batch may stay processing before it is taken to belong to an
+// execution that crashed and can be executed again. It is well past transferTimeout so a
+// running execution is never taken over.
+const staleBatchAfter = 10 * time.Minute
+
 type financeService struct {
-	repos *repositories.Repositories
-	redis cache.RedisClient
+	repos    *repositories.Repositories
+	redis    cache.RedisClient
+	executor domain.TransferExecutor
+	ledger   domain.LedgerService
 }
 
-func NewFinanceService(repos *repositories.Repositories, redis cache.RedisClient) domain.FinanceService {
+func NewFinanceService(repos *repositories.Repositories, redis cache.RedisClient, executor domain.TransferExecutor) domain.FinanceService {
 	return &financeService{
-		repos: repos,
-		redis: redis,
+		repos:    repos,
+		redis:    redis,
+		executor: executor,
+		ledger:   NewLedgerService(repos),
 	}
 }
 
@@ -29,6 +45,10 @@ func (f *financeService) ProcessEndOfDay(date time.Time, branchID, vehicleID *uu
 		return nil, err
 	}
 	if existing != nil {
+		// Post the journal in case an earlier run stored the summary but failed to post it
+		if err := f.postJournal(endOfDayJournal(existing)); err != nil {
+			return nil, err
+		}
 		return existing, nil // Return existing summary
 	}
 
@@ -59,13 +79,18 @@ func (f *financeService) ProcessEndOfDay(date time.Time, branchID, vehicleID *uu
 	if err != nil {
 		return nil, err
 	}
+	invalidateCashStatus(f.redis)
+
+	if err := f.postJournal(endOfDayJournal(summary)); err != nil {
+		return nil, err
+	}
 
 	return summary, nil
 }
 
 func (f *financeService) AddExpenseEntry(summaryID uuid.UUID, category, description string, amount float64, enteredBy uuid.UUID) error {
 	// Validate the summary exists
-	_, err := f.repos.CashSummary.GetByID(summaryID)
+	summary, err := f.repos.CashSummary.GetByID(summaryID)
 	if err != nil {
 		return err
 	}
@@ -86,8 +111,16 @@ func (f *financeService) AddExpenseEntry(summaryID uuid.UUID, category, descript
 		return err
 	}
 
+	// An expense that cannot be posted, e.g. into a closed period, is not kept
+	if err := f.postJournal(expenseJournal(expense, summary)); err != nil {
+		if delErr := f.repos.Expense.Delete(expense.ID); delErr != nil {
+			log.Printf("Failed to remove unposted expense %s: %v", expense.ID, delErr)
+		}
+		return err
+	}
+
 	// Update summary with new expense total
-	summary, err := f.repos.CashSummary.GetByID(summaryID)
+	summary, err = f.repos.CashSummary.GetByID(summaryID)
 	if err != nil {
 		return err
 	}
@@ -99,7 +132,12 @@ func (f *financeService) AddExpenseEntry(summaryID uuid.UUID, category, descript
 	}
 
 	summary.ManualExpenses = totalExpenses
-	return f.repos.CashSummary.Update(summary)
+	if err := f.repos.CashSummary.Update(summary); err != nil {
+		return err
+	}
+	invalidateCashStatus(f.redis)
+
+	return nil
 }
 
 func (f *financeService) CreateTransferBatch(branchID, vehicleID *uuid.UUID, transfers []*domain.CashTransfer, authorizedBy uuid.UUID) (*domain.CashTransferBatch, error) {
@@ -115,7 +153,7 @@ func (f *financeService) CreateTransferBatch(branchID, vehicleID *uuid.UUID, tra
 		VehicleID:      vehicleID,
 		TotalAmount:    totalAmount,
 		TransferCount:  len(transfers),
-		Status:         "pending",
+		Status:         domain.TransferStatusPending,
 		AuthorizedBy:   authorizedBy,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
@@ -129,6 +167,8 @@ func (f *financeService) CreateTransferBatch(branchID, vehicleID *uuid.UUID, tra
 	// Create individual transfers
 	for _, transfer := range transfers {
 		transfer.BatchID = &batch.ID
+		transfer.Status = domain.TransferStatusPending
+		transfer.Attempt = 1
 		transfer.CreatedAt = time.Now()
 		transfer.UpdatedAt = time.Now()
 		if transfer.ID == uuid.Nil {
@@ -144,6 +184,8 @@ func (f *financeService) CreateTransferBatch(branchID, vehicleID *uuid.UUID, tra
 	return batch, nil
 }
 
+// ExecuteTransferBatch sends the pending and failed transfers of a batch to the bank. A batch
+// left partial or failed can be executed again; completed and submitted transfers are skipped.
 func (f *financeService) ExecuteTransferBatch(batchID uuid.UUID) error {
 	// Get batch
 	batch, err := f.repos.Transfer.GetBatchByID(batchID)
@@ -151,68 +193,255 @@ func (f *financeService) ExecuteTransferBatch(batchID uuid.UUID) error {
 		return err
 	}
 
-	if batch.Status != "pending" {
-		return domain.ErrTransferInProgress
+	var claimed bool
+	switch batch.Status {
+	case domain.TransferStatusPending, domain.TransferStatusPartial, domain.TransferStatusFailed:
+		// Move the batch to processing unless another execution got there first
+		claimed, err = f.repos.Transfer.ClaimBatch(batchID, batch.Status)
+	case domain.TransferStatusProcessing:
+		// An execution that crashed leaves its batch processing. Once that is stale the batch is
+		// taken over; the transfers it recorded are skipped and the rest are sent again.
+		claimed, err = f.repos.Transfer.ReclaimStaleBatch(batchID, time.Now().Add(-staleBatchAfter))
+	default:
+		return domain.ErrBatchNotRetryable
 	}
-
-	// Update batch status to processing
-	err = f.repos.Transfer.UpdateBatchStatus(batchID, "processing")
 	if err != nil {
 		return err
 	}
+	if !claimed {
+		return domain.ErrTransferInProgress
+	}
 
 	// Get all transfers in batch
 	transfers, err := f.repos.Transfer.GetTransfersByBatch(batchID)
 	if err != nil {
+		f.repos.Transfer.UpdateBatchStatus(batchID, batch.Status)
 		return err
 	}
 
-	// Execute each transfer
-	failedTransfers := 0
+	var due []*domain.CashTransfer
 	for _, transfer := range transfers {
-		err := f.executeIndividualTransfer(transfer)
-		if err != nil {
-			failedTransfers++
-			f.repos.Transfer.UpdateTransferStatus(transfer.ID, "failed")
-		} else {
-			f.repos.Transfer.UpdateTransferStatus(transfer.ID, "completed")
+		if transfer.Status == domain.TransferStatusPending || transfer.Status == domain.TransferStatusFailed {
+			due = append(due, transfer)
+		}
+	}
+
+	// Execute the transfers that have not gone through yet
+	var recordErr error
+	if len(due) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), transferTimeout)
+		results, execErr := f.executor.Execute(ctx, batch, due)
+		cancel()
+
+		byTransfer := make(map[uuid.UUID]*domain.TransferResult, len(results))
+		for _, result := range results {
+			byTransfer[result.TransferID] = result
+		}
+
+		now := time.Now()
+		for _, transfer := range due {
+			transfer.ExecutedAt = &now
+			switch result := byTransfer[transfer.ID]; {
+			case execErr != nil:
+				failTransfer(transfer, fmt.Sprintf("%s: %v", f.executor.Name(), execErr))
+			case result == nil:
+				failTransfer(transfer, fmt.Sprintf("%s: no result for transfer", f.executor.Name()))
+			default:
+				applyTransferResult(transfer, result, now)
+			}
+
+			if err := f.repos.Transfer.UpdateTransferResult(transfer); err != nil {
+				log.Printf("Failed to record result of transfer %s (%s): %v", transfer.ID, transfer.Status, err)
+				if recordErr == nil {
+					recordErr = fmt.Errorf("%w: recording transfer %s: %v", domain.ErrTransactionFailed, transfer.ID, err)
+				}
+				continue
+			}
+
+			if transfer.Status == domain.TransferStatusCompleted {
+				if err := f.postJournal(transferJournal(transfer, batch.BranchID, batch.VehicleID)); err != nil {
+					log.Printf("Failed to post journal for transfer %s: %v", transfer.ID, err)
+					if recordErr == nil {
+						recordErr = fmt.Errorf("posting transfer %s: %w", transfer.ID, err)
+					}
+				}
+			}
 		}
+		invalidateCashStatus(f.redis)
 	}
 
 	// Update batch status based on results
-	var finalStatus string
-	if failedTransfers == 0 {
-		finalStatus = "completed"
-	} else if failedTransfers == len(transfers) {
-		finalStatus = "failed"
-	} else {
-		finalStatus = "partial"
+	if err := f.repos.Transfer.UpdateBatchStatus(batchID, batchStatusFor(transfers)); err != nil {
+		return err
 	}
 
-	return f.repos.Transfer.UpdateBatchStatus(batchID, finalStatus)
+	return recordErr
 }
 
-func (f *financeService) executeIndividualTransfer(transfer *domain.CashTransfer) error {
-	// In a real implementation, this would integrate with banking APIs
-	// For now, we'll simulate the transfer
-	time.Sleep(100 * time.Millisecond) // Simulate processing time
-	return nil
+// GetTransferBatch returns the batch with its transfers and their execution results
+func (f *financeService) GetTransferBatch(batchID uuid.UUID) (*domain.CashTransferBatch, []*domain.CashTransfer, error) {
+	batch, err := f.repos.Transfer.GetBatchByID(batchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	transfers, err := f.repos.Transfer.GetTransfersByBatch(batchID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return batch, transfers, nil
 }
 
-func (f *financeService) GetCashStatus() (map[string]interface{}, error) {
-	// Get current balances for all entities
-	// This would typically aggregate across all branches and vehicles
-	
-	status := map[string]interface{}{
-		"total_cash":        150000.00,
-		"profit_account":    45000.00,
-		"owner_pay_account": 30000.00,
-		"tax_account":       22500.00,
-		"operational_cash":  52500.00,
-		"last_updated":      time.Now(),
+// ConfirmTransfer records that the bank settled a submitted transfer, e.g. after matching a
+// bulk file against the bank statement
+func (f *financeService) ConfirmTransfer(transferID uuid.UUID, transactionRef string) error {
+	transfer, err := f.getSubmittedTransfer(transferID)
+	if err != nil {
+		return err
 	}
 
-	return status, nil
+	now := time.Now()
+	transfer.Status = domain.TransferStatusCompleted
+	transfer.ConfirmedAt = &now
+	transfer.FailureReason = nil
+	if transactionRef != "" {
+		transfer.TransactionRef = &transactionRef
+	}
+
+	if err := f.repos.Transfer.UpdateTransferResult(transfer); err != nil {
+		return err
+	}
+	invalidateCashStatus(f.redis)
+
+	var branchID, vehicleID *uuid.UUID
+	if transfer.BatchID != nil {
+		batch, err := f.repos.Transfer.GetBatchByID(*transfer.BatchID)
+		if err != nil {
+			return err
+		}
+		branchID, vehicleID = batch.BranchID, batch.VehicleID
+	}
+	if err := f.postJournal(transferJournal(transfer, branchID, vehicleID)); err != nil {
+		return err
+	}
+
+	return f.refreshBatchStatus(transfer.BatchID)
+}
+
+// RejectTransfer records that the bank did not pay a submitted transfer, so the batch can be retried
+func (f *financeService) RejectTransfer(transferID uuid.UUID, reason string) error {
+	transfer, err := f.getSubmittedTransfer(transferID)
+	if err != nil {
+		return err
+	}
+
+	if reason == "" {
+		reason = "rejected by bank"
+	}
+	failTransfer(transfer, reason)
+	// The bank answered the last send with a submission, so the retry must be a new attempt
+	transfer.Attempt++
+
+	if err := f.repos.Transfer.UpdateTransferResult(transfer); err != nil {
+		return err
+	}
+
+	return f.refreshBatchStatus(transfer.BatchID)
+}
+
+func (f *financeService) getSubmittedTransfer(transferID uuid.UUID) (*domain.CashTransfer, error) {
+	transfer, err := f.repos.Transfer.GetTransferByID(transferID)
+	if err != nil {
+		return nil, err
+	}
+
+	if transfer.Status != domain.TransferStatusSubmitted {
+		return nil, domain.ErrTransferNotSubmitted
+	}
+
+	return transfer, nil
+}
+
+// refreshBatchStatus recalculates the batch status after a transfer outcome arrives.
+// A batch that is executing is left alone; the execution sets the status when it finishes.
+func (f *financeService) refreshBatchStatus(batchID *uuid.UUID) error {
+	if batchID == nil {
+		return nil
+	}
+
+	batch, transfers, err := f.GetTransferBatch(*batchID)
+	if err != nil {
+		return err
+	}
+	if batch.Status == domain.TransferStatusProcessing {
+		return nil
+	}
+
+	return f.repos.Transfer.UpdateBatchStatus(batch.ID, batchStatusFor(transfers))
+}
+
+// applyTransferResult copies the executor's outcome onto the transfer
+func applyTransferResult(transfer *domain.CashTransfer, result *domain.TransferResult, now time.Time) {
+	if result.TransactionRef != "" {
+		ref := result.TransactionRef
+		transfer.TransactionRef = &ref
+	}
+
+	switch result.Status {
+	case domain.TransferStatusCompleted:
+		transfer.Status = domain.TransferStatusCompleted
+		transfer.FailureReason = nil
+		transfer.ConfirmedAt = result.ConfirmedAt
+		if transfer.ConfirmedAt == nil {
+			transfer.ConfirmedAt = &now
+		}
+	case domain.TransferStatusSubmitted:
+		transfer.Status = domain.TransferStatusSubmitted
+		transfer.FailureReason = nil
+		transfer.ConfirmedAt = nil
+	case domain.TransferStatusFailed:
+		failTransfer(transfer, result.FailureReason)
+		// The bank stores its answer per idempotency key, so the retry must be a new attempt
+		if result.Rejected {
+			transfer.Attempt++
+		}
+	default:
+		failTransfer(transfer, fmt.Sprintf("unknown transfer result status %q", result.Status))
+	}
+}
+
+func failTransfer(transfer *domain.CashTransfer, reason string) {
+	transfer.Status = domain.TransferStatusFailed
+	transfer.FailureReason = &reason
+	transfer.ConfirmedAt = nil
+}
+
+// batchStatusFor derives the batch status from its transfers. Any failed transfer next to
+// successful ones makes the batch partial, which keeps it retryable.
+func batchStatusFor(transfers []*domain.CashTransfer) string {
+	var failed, submitted, unsent int
+	for _, transfer := range transfers {
+		switch transfer.Status {
+		case domain.TransferStatusFailed:
+			failed++
+		case domain.TransferStatusSubmitted:
+			submitted++
+		case domain.TransferStatusPending, domain.TransferStatusProcessing:
+			unsent++
+		}
+	}
+
+	switch {
+	case failed > 0 && failed == len(transfers):
+		return domain.TransferStatusFailed
+	case failed > 0 || unsent > 0:
+		return domain.TransferStatusPartial
+	case submitted > 0:
+		return domain.TransferStatusSubmitted
+	default:
+		return domain.TransferStatusCompleted
+	}
 }
 
 func (f *financeService) ReconcileCash(summaryID uuid.UUID, actualCash float64, reconciledBy uuid.UUID) error {
@@ -226,6 +455,12 @@ func (f *financeService) ReconcileCash(summaryID uuid.UUID, actualCash float64,
 		return domain.ErrCannotModifyReconciled
 	}
 
+	// Book the difference between counted cash and the operating cash in the ledger
+	expectedCash, err := f.ledger.GetAccountBalance(domain.LedgerOperatingCash, summary.BusinessDate, summary.BranchID, summary.VehicleID)
+	if err != nil {
+		return err
+	}
+
 	// Update closing cash with actual amount
 	summary.ClosingCash = actualCash
 
@@ -234,11 +469,30 @@ func (f *financeService) ReconcileCash(summaryID uuid.UUID, actualCash float64,
 	if err != nil {
 		return err
 	}
+	invalidateCashStatus(f.redis)
+
+	if err := f.postJournal(reconciliationJournal(summary, actualCash-expectedCash, reconciledBy)); err != nil {
+		return err
+	}
 
 	// Mark as reconciled
 	return f.repos.CashSummary.UpdateReconciliation(summaryID, reconciledBy)
 }
 
+// postJournal posts a journal built from a finance operation. Journals are keyed by their
+// source, so posting one again is a no-op.
+func (f *financeService) postJournal(entry *domain.JournalEntry) error {
+	if entry == nil {
+		return nil
+	}
+
+	err := f.ledger.PostJournal(entry)
+	if errors.Is(err, domain.ErrDuplicateJournalEntry) {
+		return nil
+	}
+	return err
+}
+
 func generateBatchReference() string {
 	return "BATCH_" + time.Now().Format("20060102_150405")
 }
@@ -0,0 +1,134 @@
+package application
+
+import (
+	"fmt"
+	"time"
+
+	"finance/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+// transferDebitAccounts maps a transfer type to the account the payment is charged to
+var transferDebitAccounts = map[string]string{
+	"supplier_payment": domain.LedgerSupplierPayments,
+	"expense":          domain.LedgerOperatingExpense,
+	"central_transfer": domain.LedgerCentralClearing,
+	"bank_transfer":    domain.LedgerCentralClearing,
+}
+
+// endOfDayJournal recognizes the day's sales and splits them across the Profit First
+// accounts. Operating cash takes the remainder so rounding never unbalances the entry.
+func endOfDayJournal(summary *domain.DailyCashSummary) *domain.JournalEntry {
+	sales := toSatang(summary.TotalSales)
+	if sales <= 0 {
+		return nil
+	}
+
+	profit := toSatang(summary.ProfitAllocation)
+	ownerPay := toSatang(summary.OwnerPayAllocation)
+	tax := toSatang(summary.TaxAllocation)
+
+	entry := newJournal(summary.BusinessDate, domain.JournalSourceEndOfDay, summary.ID, summary.BranchID, summary.VehicleID,
+		fmt.Sprintf("End of day sales %s", summary.BusinessDate.Format("2006-01-02")))
+	entry.Postings = nonZeroPostings(
+		debit(domain.LedgerProfit, profit, "Profit allocation"),
+		debit(domain.LedgerOwnerPay, ownerPay, "Owner pay allocation"),
+		debit(domain.LedgerTax, tax, "Tax allocation"),
+		debit(domain.LedgerOperatingCash, sales-profit-ownerPay-tax, "Operating allocation"),
+		credit(domain.LedgerSalesRevenue, sales, "Sales"),
+	)
+	return entry
+}
+
+// expenseJournal charges a manual expense to operating expenses, paid from operating cash
+func expenseJournal(expense *domain.ExpenseEntry, summary *domain.DailyCashSummary) *domain.JournalEntry {
+	amount := toSatang(expense.Amount)
+
+	entry := newJournal(summary.BusinessDate, domain.JournalSourceExpense, expense.ID, summary.BranchID, summary.VehicleID,
+		fmt.Sprintf("Expense (%s): %s", expense.Category, expense.Description))
+	entry.CreatedBy = &expense.EnteredBy
+	entry.Postings = nonZeroPostings(
+		debit(domain.LedgerOperatingExpense, amount, expense.Category),
+		credit(domain.LedgerOperatingCash, amount, ""),
+	)
+	return entry
+}
+
+// transferJournal records a completed transfer paid out of the batch entity's operating cash
+func transferJournal(transfer *domain.CashTransfer, branchID, vehicleID *uuid.UUID) *domain.JournalEntry {
+	amount := toSatang(transfer.Amount)
+	debitAccount, ok := transferDebitAccounts[transfer.TransferType]
+	if !ok {
+		debitAccount = domain.LedgerCentralClearing
+	}
+
+	date := time.Now()
+	if transfer.ConfirmedAt != nil {
+		date = *transfer.ConfirmedAt
+	}
+
+	entry := newJournal(date, domain.JournalSourceTransfer, transfer.ID, branchID, vehicleID,
+		fmt.Sprintf("Transfer to %s (%s)", transfer.RecipientName, transfer.Reference))
+	entry.CreatedBy = &transfer.CreatedBy
+	entry.Postings = nonZeroPostings(
+		debit(debitAccount, amount, transfer.TransferType),
+		credit(domain.LedgerOperatingCash, amount, ""),
+	)
+	return entry
+}
+
+// reconciliationJournal books the difference between counted and expected operating cash
+// as cash over or short
+func reconciliationJournal(summary *domain.DailyCashSummary, variance float64, reconciledBy uuid.UUID) *domain.JournalEntry {
+	amount := toSatang(variance)
+	if amount == 0 {
+		return nil
+	}
+
+	entry := newJournal(summary.BusinessDate, domain.JournalSourceReconciliation, summary.ID, summary.BranchID, summary.VehicleID,
+		fmt.Sprintf("Cash reconciliation %s", summary.BusinessDate.Format("2006-01-02")))
+	entry.CreatedBy = &reconciledBy
+	if amount > 0 {
+		entry.Postings = nonZeroPostings(
+			debit(domain.LedgerOperatingCash, amount, "Cash over"),
+			credit(domain.LedgerCashOverShort, amount, "Cash over"),
+		)
+	} else {
+		entry.Postings = nonZeroPostings(
+			debit(domain.LedgerCashOverShort, -amount, "Cash short"),
+			credit(domain.LedgerOperatingCash, -amount, "Cash short"),
+		)
+	}
+	return entry
+}
+
+func newJournal(date time.Time, sourceType string, sourceID uuid.UUID, branchID, vehicleID *uuid.UUID, description string) *domain.JournalEntry {
+	return &domain.JournalEntry{
+		ID:          uuid.New(),
+		EntryDate:   date,
+		Description: description,
+		SourceType:  sourceType,
+		SourceID:    sourceID,
+		BranchID:    branchID,
+		VehicleID:   vehicleID,
+	}
+}
+
+func debit(code string, satang int64, description string) *domain.Posting {
+	return &domain.Posting{AccountCode: code, Debit: fromSatang(satang), Description: description}
+}
+
+func credit(code string, satang int64, description string) *domain.Posting {
+	return &domain.Posting{AccountCode: code, Credit: fromSatang(satang), Description: description}
+}
+
+func nonZeroPostings(postings ...*domain.Posting) []*domain.Posting {
+	var result []*domain.Posting
+	for _, posting := range postings {
+		if posting.Debit != 0 || posting.Credit != 0 {
+			result = append(result, posting)
+		}
+	}
+	return result
+}
@@ -0,0 +1,315 @@
+package application
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"finance/internal/domain"
+	"finance/internal/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+)
+
+type ledgerService struct {
+	repos *repositories.Repositories
+}
+
+func NewLedgerService(repos *repositories.Repositories) domain.LedgerService {
+	return &ledgerService{
+		repos: repos,
+	}
+}
+
+// PostJournal validates that the entry balances and only touches active accounts, then stores it.
+// Amounts are rounded to satang before the check so float noise cannot unbalance an entry.
+func (l *ledgerService) PostJournal(entry *domain.JournalEntry) error {
+	if entry.Description == "" {
+		return domain.ErrMissingDescription
+	}
+	if err := validateJournal(entry); err != nil {
+		return err
+	}
+
+	checked := make(map[string]bool)
+	for _, posting := range entry.Postings {
+		if checked[posting.AccountCode] {
+			continue
+		}
+		account, err := l.repos.Ledger.GetAccountByCode(posting.AccountCode)
+		if err != nil {
+			return fmt.Errorf("%w: %s", err, posting.AccountCode)
+		}
+		if !account.IsActive {
+			return fmt.Errorf("%w: %s", domain.ErrInactiveLedgerAccount, posting.AccountCode)
+		}
+		checked[posting.AccountCode] = true
+	}
+
+	if entry.ID == uuid.Nil {
+		entry.ID = uuid.New()
+	}
+	if entry.SourceID == uuid.Nil {
+		entry.SourceID = entry.ID
+	}
+	if entry.SourceType == "" {
+		entry.SourceType = domain.JournalSourceManual
+	}
+	entry.EntryDate = truncateToDate(entry.EntryDate)
+	entry.CreatedAt = time.Now()
+	for _, posting := range entry.Postings {
+		if posting.ID == uuid.Nil {
+			posting.ID = uuid.New()
+		}
+		posting.EntryID = entry.ID
+	}
+
+	return l.repos.Ledger.CreateJournalEntry(entry)
+}
+
+func (l *ledgerService) GetAccounts() ([]*domain.LedgerAccount, error) {
+	return l.repos.Ledger.GetAccounts()
+}
+
+// GetAccountBalance returns the balance of the account on its normal side
+func (l *ledgerService) GetAccountBalance(code string, asOf time.Time, branchID, vehicleID *uuid.UUID) (float64, error) {
+	account, err := l.repos.Ledger.GetAccountByCode(code)
+	if err != nil {
+		return 0, err
+	}
+
+	balance, err := l.repos.Ledger.GetAccountBalance(code, truncateToDate(asOf), branchID, vehicleID)
+	if err != nil {
+		return 0, err
+	}
+
+	return roundBaht(normalBalance(account.Type, balance)), nil
+}
+
+// GetTrialBalance lists the balance of every account with postings up to asOf
+func (l *ledgerService) GetTrialBalance(asOf time.Time) (*domain.TrialBalance, error) {
+	asOf = truncateToDate(asOf)
+
+	accounts, err := l.accountsByCode()
+	if err != nil {
+		return nil, err
+	}
+
+	totals, err := l.repos.Ledger.GetAccountTotals(time.Time{}, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	trialBalance := &domain.TrialBalance{AsOf: asOf}
+	var totalDebit, totalCredit int64
+	for _, total := range totals {
+		net := toSatang(total.Debit) - toSatang(total.Credit)
+		if net == 0 {
+			continue
+		}
+
+		line := &domain.TrialBalanceLine{AccountCode: total.AccountCode}
+		if account, ok := accounts[total.AccountCode]; ok {
+			line.AccountName = account.Name
+			line.Type = account.Type
+		}
+		if net > 0 {
+			line.Debit = fromSatang(net)
+			totalDebit += net
+		} else {
+			line.Credit = fromSatang(-net)
+			totalCredit -= net
+		}
+		trialBalance.Lines = append(trialBalance.Lines, line)
+	}
+
+	trialBalance.TotalDebit = fromSatang(totalDebit)
+	trialBalance.TotalCredit = fromSatang(totalCredit)
+	trialBalance.Balanced = totalDebit == totalCredit
+
+	return trialBalance, nil
+}
+
+// GetAccountStatement lists the postings of an account between two dates with a running
+// balance on the account's normal side, optionally for one branch or vehicle
+func (l *ledgerService) GetAccountStatement(code string, from, to time.Time, branchID, vehicleID *uuid.UUID) (*domain.AccountStatement, error) {
+	from, to = truncateToDate(from), truncateToDate(to)
+	if to.Before(from) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	account, err := l.repos.Ledger.GetAccountByCode(code)
+	if err != nil {
+		return nil, err
+	}
+
+	opening, err := l.repos.Ledger.GetAccountBalance(code, from.AddDate(0, 0, -1), branchID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	lines, err := l.repos.Ledger.GetStatementLines(code, from, to, branchID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	statement := &domain.AccountStatement{
+		Account:        account,
+		From:           from,
+		To:             to,
+		BranchID:       branchID,
+		VehicleID:      vehicleID,
+		OpeningBalance: roundBaht(normalBalance(account.Type, opening)),
+		Lines:          lines,
+	}
+
+	balance := statement.OpeningBalance
+	for _, line := range lines {
+		balance = roundBaht(balance + normalBalance(account.Type, line.Debit-line.Credit))
+		line.Balance = balance
+	}
+	statement.ClosingBalance = balance
+
+	return statement, nil
+}
+
+// ClosePeriod moves the month's revenue and expense balances into retained earnings and locks
+// the month against further postings
+func (l *ledgerService) ClosePeriod(year int, month time.Month, closedBy uuid.UUID) (*domain.AccountingPeriod, error) {
+	start, end := domain.PeriodBounds(time.Date(year, month, 1, 0, 0, 0, 0, time.UTC))
+	if !truncateToDate(time.Now()).After(end) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	accounts, err := l.accountsByCode()
+	if err != nil {
+		return nil, err
+	}
+
+	period := &domain.AccountingPeriod{
+		PeriodStart: start,
+		PeriodEnd:   end,
+		ClosedBy:    &closedBy,
+	}
+
+	err = l.repos.Ledger.ClosePeriod(period, func(totals []*domain.AccountTotals) (*domain.JournalEntry, error) {
+		return closingJournal(period, accounts, totals, closedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return period, nil
+}
+
+func (l *ledgerService) accountsByCode() (map[string]*domain.LedgerAccount, error) {
+	accounts, err := l.repos.Ledger.GetAccounts()
+	if err != nil {
+		return nil, err
+	}
+
+	byCode := make(map[string]*domain.LedgerAccount, len(accounts))
+	for _, account := range accounts {
+		byCode[account.Code] = account
+	}
+	return byCode, nil
+}
+
+// closingJournal zeroes every revenue and expense account of the period against retained earnings
+func closingJournal(period *domain.AccountingPeriod, accounts map[string]*domain.LedgerAccount, totals []*domain.AccountTotals, closedBy uuid.UUID) (*domain.JournalEntry, error) {
+	entry := &domain.JournalEntry{
+		ID:          uuid.New(),
+		EntryDate:   period.PeriodEnd,
+		Description: "Close period " + period.PeriodStart.Format("2006-01"),
+		SourceType:  domain.JournalSourcePeriodClose,
+		SourceID:    uuid.NewSHA1(uuid.NameSpaceOID, []byte("period:"+period.PeriodStart.Format("2006-01"))),
+		CreatedBy:   &closedBy,
+		CreatedAt:   time.Now(),
+	}
+
+	var retained int64
+	for _, total := range totals {
+		account, ok := accounts[total.AccountCode]
+		if !ok || (account.Type != domain.IncomeAccount && account.Type != domain.ExpenseAccount) {
+			continue
+		}
+
+		net := toSatang(total.Debit) - toSatang(total.Credit)
+		if net == 0 {
+			continue
+		}
+		entry.Postings = append(entry.Postings, reversingPosting(entry.ID, total.AccountCode, net))
+		retained += net
+	}
+
+	if len(entry.Postings) == 0 {
+		return nil, nil
+	}
+	entry.Postings = append(entry.Postings, &domain.Posting{
+		ID:          uuid.New(),
+		EntryID:     entry.ID,
+		AccountCode: domain.LedgerRetainedEarnings,
+		Debit:       fromSatang(max(retained, 0)),
+		Credit:      fromSatang(max(-retained, 0)),
+	})
+
+	if err := validateJournal(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// reversingPosting posts the opposite of a debit-positive net balance
+func reversingPosting(entryID uuid.UUID, code string, net int64) *domain.Posting {
+	posting := &domain.Posting{ID: uuid.New(), EntryID: entryID, AccountCode: code}
+	if net > 0 {
+		posting.Credit = fromSatang(net)
+	} else {
+		posting.Debit = fromSatang(-net)
+	}
+	return posting
+}
+
+// validateJournal rounds the postings to satang and checks that each has one side and that
+// debits equal credits
+func validateJournal(entry *domain.JournalEntry) error {
+	if len(entry.Postings) < 2 {
+		return fmt.Errorf("%w: at least two postings are required", domain.ErrUnbalancedEntry)
+	}
+
+	var debits, credits int64
+	for _, posting := range entry.Postings {
+		debit, credit := toSatang(posting.Debit), toSatang(posting.Credit)
+		if debit < 0 || credit < 0 || (debit > 0) == (credit > 0) {
+			return fmt.Errorf("%w: account %s", domain.ErrInvalidPosting, posting.AccountCode)
+		}
+		posting.Debit, posting.Credit = fromSatang(debit), fromSatang(credit)
+		debits += debit
+		credits += credit
+	}
+
+	if debits != credits {
+		return fmt.Errorf("%w: debits %.2f, credits %.2f", domain.ErrUnbalancedEntry, fromSatang(debits), fromSatang(credits))
+	}
+	return nil
+}
+
+// normalBalance turns a debit-positive amount into a balance on the account's normal side
+func normalBalance(accountType domain.LedgerAccountType, debitPositive float64) float64 {
+	if accountType.DebitNormal() {
+		return debitPositive
+	}
+	return -debitPositive
+}
+
+func toSatang(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func fromSatang(satang int64) float64 {
+	return float64(satang) / 100
+}
+
+func truncateToDate(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
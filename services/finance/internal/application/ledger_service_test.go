@@ -0,0 +1,454 @@
+package application
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"finance/internal/domain"
+	"finance/internal/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+)
+
+// memoryLedgerRepository keeps the chart of accounts, entries and periods in memory
+type memoryLedgerRepository struct {
+	domain.LedgerRepository
+	accounts []*domain.LedgerAccount
+	entries  []*domain.JournalEntry
+	periods  map[time.Time]*domain.AccountingPeriod
+}
+
+func newMemoryLedgerRepository() *memoryLedgerRepository {
+	chart := []struct {
+		code        string
+		name        string
+		accountType domain.LedgerAccountType
+	}{
+		{domain.LedgerOperatingCash, "Operating Cash", domain.AssetAccount},
+		{domain.LedgerProfit, "Profit Account", domain.AssetAccount},
+		{domain.LedgerOwnerPay, "Owner Pay Account", domain.AssetAccount},
+		{domain.LedgerTax, "Tax Account", domain.AssetAccount},
+		{domain.LedgerCentralClearing, "Central Clearing", domain.AssetAccount},
+		{domain.LedgerRetainedEarnings, "Retained Earnings", domain.EquityAccount},
+		{domain.LedgerSalesRevenue, "Sales Revenue", domain.IncomeAccount},
+		{domain.LedgerOperatingExpense, "Operating Expenses", domain.ExpenseAccount},
+		{domain.LedgerCashOverShort, "Cash Over and Short", domain.ExpenseAccount},
+		{domain.LedgerSupplierPayments, "Supplier Payments", domain.ExpenseAccount},
+	}
+
+	repo := &memoryLedgerRepository{periods: make(map[time.Time]*domain.AccountingPeriod)}
+	for _, account := range chart {
+		repo.accounts = append(repo.accounts, &domain.LedgerAccount{
+			ID:       uuid.New(),
+			Code:     account.code,
+			Name:     account.name,
+			Type:     account.accountType,
+			IsActive: true,
+		})
+	}
+	return repo
+}
+
+func (r *memoryLedgerRepository) GetAccounts() ([]*domain.LedgerAccount, error) {
+	return r.accounts, nil
+}
+
+func (r *memoryLedgerRepository) GetAccountByCode(code string) (*domain.LedgerAccount, error) {
+	for _, account := range r.accounts {
+		if account.Code == code {
+			return account, nil
+		}
+	}
+	return nil, domain.ErrLedgerAccountNotFound
+}
+
+func (r *memoryLedgerRepository) CreateJournalEntry(entry *domain.JournalEntry) error {
+	start, _ := domain.PeriodBounds(entry.EntryDate)
+	if period, ok := r.periods[start]; ok && period.Status == domain.PeriodClosed {
+		return domain.ErrPeriodClosed
+	}
+	for _, existing := range r.entries {
+		if existing.SourceType == entry.SourceType && existing.SourceID == entry.SourceID {
+			return domain.ErrDuplicateJournalEntry
+		}
+	}
+	r.entries = append(r.entries, entry)
+	return nil
+}
+
+func (r *memoryLedgerRepository) GetJournalEntryBySource(sourceType string, sourceID uuid.UUID) (*domain.JournalEntry, error) {
+	for _, entry := range r.entries {
+		if entry.SourceType == sourceType && entry.SourceID == sourceID {
+			return entry, nil
+		}
+	}
+	return nil, domain.ErrJournalEntryNotFound
+}
+
+func (r *memoryLedgerRepository) GetAccountTotals(from, to time.Time) ([]*domain.AccountTotals, error) {
+	byCode := make(map[string]*domain.AccountTotals)
+	var totals []*domain.AccountTotals
+	for _, entry := range r.entries {
+		if entry.EntryDate.Before(from) || entry.EntryDate.After(to) {
+			continue
+		}
+		for _, posting := range entry.Postings {
+			total, ok := byCode[posting.AccountCode]
+			if !ok {
+				total = &domain.AccountTotals{AccountCode: posting.AccountCode}
+				byCode[posting.AccountCode] = total
+				totals = append(totals, total)
+			}
+			total.Debit += posting.Debit
+			total.Credit += posting.Credit
+		}
+	}
+	return totals, nil
+}
+
+func (r *memoryLedgerRepository) GetAccountBalance(code string, asOf time.Time, branchID, vehicleID *uuid.UUID) (float64, error) {
+	var balance float64
+	for _, entry := range r.entries {
+		if entry.EntryDate.After(asOf) || !sameEntity(entry, branchID, vehicleID) {
+			continue
+		}
+		for _, posting := range entry.Postings {
+			if posting.AccountCode == code {
+				balance += posting.Debit - posting.Credit
+			}
+		}
+	}
+	return balance, nil
+}
+
+func (r *memoryLedgerRepository) GetStatementLines(code string, from, to time.Time, branchID, vehicleID *uuid.UUID) ([]*domain.StatementLine, error) {
+	var lines []*domain.StatementLine
+	for _, entry := range r.entries {
+		if entry.EntryDate.Before(from) || entry.EntryDate.After(to) || !sameEntity(entry, branchID, vehicleID) {
+			continue
+		}
+		for _, posting := range entry.Postings {
+			if posting.AccountCode == code {
+				lines = append(lines, &domain.StatementLine{
+					EntryID:     entry.ID,
+					EntryDate:   entry.EntryDate,
+					Description: entry.Description,
+					SourceType:  entry.SourceType,
+					Debit:       posting.Debit,
+					Credit:      posting.Credit,
+				})
+			}
+		}
+	}
+	return lines, nil
+}
+
+func (r *memoryLedgerRepository) ClosePeriod(period *domain.AccountingPeriod, buildClosing func(totals []*domain.AccountTotals) (*domain.JournalEntry, error)) error {
+	if existing, ok := r.periods[period.PeriodStart]; ok && existing.Status == domain.PeriodClosed {
+		return domain.ErrPeriodClosed
+	}
+
+	totals, err := r.GetAccountTotals(period.PeriodStart, period.PeriodEnd)
+	if err != nil {
+		return err
+	}
+	closing, err := buildClosing(totals)
+	if err != nil {
+		return err
+	}
+	if closing != nil {
+		r.entries = append(r.entries, closing)
+		period.ClosingEntryID = &closing.ID
+	}
+
+	now := time.Now()
+	period.ID = uuid.New()
+	period.Status = domain.PeriodClosed
+	period.ClosedAt = &now
+	r.periods[period.PeriodStart] = period
+	return nil
+}
+
+func sameEntity(entry *domain.JournalEntry, branchID, vehicleID *uuid.UUID) bool {
+	if branchID != nil && (entry.BranchID == nil || *entry.BranchID != *branchID) {
+		return false
+	}
+	if vehicleID != nil && (entry.VehicleID == nil || *entry.VehicleID != *vehicleID) {
+		return false
+	}
+	return true
+}
+
+// memorySummaryRepository keeps daily summaries in memory
+type memorySummaryRepository struct {
+	domain.CashSummaryRepository
+	summaries map[uuid.UUID]*domain.DailyCashSummary
+}
+
+func (r *memorySummaryRepository) Create(summary *domain.DailyCashSummary) error {
+	copied := *summary
+	r.summaries[summary.ID] = &copied
+	return nil
+}
+
+func (r *memorySummaryRepository) GetByID(id uuid.UUID) (*domain.DailyCashSummary, error) {
+	summary, ok := r.summaries[id]
+	if !ok {
+		return nil, domain.ErrCashSummaryNotFound
+	}
+	copied := *summary
+	return &copied, nil
+}
+
+func (r *memorySummaryRepository) GetByDateAndEntity(date time.Time, branchID, vehicleID *uuid.UUID) (*domain.DailyCashSummary, error) {
+	for _, summary := range r.summaries {
+		if summary.BusinessDate.Equal(date) && sameEntity(&domain.JournalEntry{BranchID: summary.BranchID, VehicleID: summary.VehicleID}, branchID, vehicleID) {
+			copied := *summary
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrCashSummaryNotFound
+}
+
+func (r *memorySummaryRepository) Update(summary *domain.DailyCashSummary) error {
+	copied := *summary
+	r.summaries[summary.ID] = &copied
+	return nil
+}
+
+func (r *memorySummaryRepository) UpdateReconciliation(id uuid.UUID, reconciledBy uuid.UUID) error {
+	r.summaries[id].Reconciled = true
+	r.summaries[id].ReconciledByUserID = &reconciledBy
+	return nil
+}
+
+type stubAllocationRuleRepository struct {
+	domain.AllocationRuleRepository
+}
+
+func (r *stubAllocationRuleRepository) GetActiveRule(branchID, vehicleID *uuid.UUID) (*domain.ProfitAllocationRule, error) {
+	return &domain.ProfitAllocationRule{ProfitPercentage: 5, OwnerPayPercentage: 50, TaxPercentage: 15}, nil
+}
+
+type memoryExpenseRepository struct {
+	domain.ExpenseRepository
+	expenses map[uuid.UUID]*domain.ExpenseEntry
+}
+
+func (r *memoryExpenseRepository) Create(expense *domain.ExpenseEntry) error {
+	r.expenses[expense.ID] = expense
+	return nil
+}
+
+func (r *memoryExpenseRepository) Delete(id uuid.UUID) error {
+	delete(r.expenses, id)
+	return nil
+}
+
+func (r *memoryExpenseRepository) GetTotalBySummaryID(summaryID uuid.UUID) (float64, error) {
+	var total float64
+	for _, expense := range r.expenses {
+		if expense.SummaryID == summaryID {
+			total += expense.Amount
+		}
+	}
+	return total, nil
+}
+
+func setupLedger() (*financeService, *memoryLedgerRepository) {
+	ledger := newMemoryLedgerRepository()
+	repos := &repositories.Repositories{
+		Ledger:         ledger,
+		CashSummary:    &memorySummaryRepository{summaries: make(map[uuid.UUID]*domain.DailyCashSummary)},
+		AllocationRule: &stubAllocationRuleRepository{},
+		Expense:        &memoryExpenseRepository{expenses: make(map[uuid.UUID]*domain.ExpenseEntry)},
+	}
+	return NewFinanceService(repos, newMemoryRedisClient(), nil).(*financeService), ledger
+}
+
+func TestPostJournal_RejectsInvalidEntries(t *testing.T) {
+	service, ledger := setupLedger()
+
+	tests := []struct {
+		name     string
+		postings []*domain.Posting
+		want     error
+	}{
+		{"unbalanced", []*domain.Posting{debit(domain.LedgerOperatingCash, 10000, ""), credit(domain.LedgerSalesRevenue, 9999, "")}, domain.ErrUnbalancedEntry},
+		{"single posting", []*domain.Posting{debit(domain.LedgerOperatingCash, 10000, "")}, domain.ErrUnbalancedEntry},
+		{"both sides", []*domain.Posting{{AccountCode: domain.LedgerOperatingCash, Debit: 100, Credit: 100}, credit(domain.LedgerSalesRevenue, 0, "")}, domain.ErrInvalidPosting},
+		{"unknown account", []*domain.Posting{debit("9999", 10000, ""), credit(domain.LedgerSalesRevenue, 10000, "")}, domain.ErrLedgerAccountNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ledger.PostJournal(&domain.JournalEntry{
+				EntryDate:   time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+				Description: tt.name,
+				Postings:    tt.postings,
+			})
+			if !errors.Is(err, tt.want) {
+				t.Errorf("PostJournal() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+
+	if len(ledger.entries) != 0 {
+		t.Errorf("%d entries stored, want none", len(ledger.entries))
+	}
+}
+
+func TestProcessEndOfDay_PostsBalancedJournalOnce(t *testing.T) {
+	service, ledger := setupLedger()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	summary, err := service.ProcessEndOfDay(date, &testBranchID, nil, 10000.01, 0)
+	if err != nil {
+		t.Fatalf("ProcessEndOfDay() error = %v", err)
+	}
+	if _, err := service.ProcessEndOfDay(date, &testBranchID, nil, 10000.01, 0); err != nil {
+		t.Fatalf("ProcessEndOfDay() retry error = %v", err)
+	}
+
+	if len(ledger.entries) != 1 {
+		t.Fatalf("%d entries posted, want 1", len(ledger.entries))
+	}
+	entry := ledger.entries[0]
+	if entry.SourceType != domain.JournalSourceEndOfDay || entry.SourceID != summary.ID {
+		t.Errorf("entry source = %s %s, want end of day summary", entry.SourceType, entry.SourceID)
+	}
+
+	var debits, credits int64
+	for _, posting := range entry.Postings {
+		debits += toSatang(posting.Debit)
+		credits += toSatang(posting.Credit)
+	}
+	if debits != credits || credits != 1000001 {
+		t.Errorf("debits %d, credits %d, want both 1000001 satang", debits, credits)
+	}
+}
+
+func TestTrialBalanceAndStatement(t *testing.T) {
+	service, _ := setupLedger()
+	day1 := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+	day2 := day1.AddDate(0, 0, 1)
+
+	summary, err := service.ProcessEndOfDay(day1, &testBranchID, nil, 10000, 0)
+	if err != nil {
+		t.Fatalf("ProcessEndOfDay() error = %v", err)
+	}
+	if err := service.AddExpenseEntry(summary.ID, "fuel", "Diesel", 400, uuid.New()); err != nil {
+		t.Fatalf("AddExpenseEntry() error = %v", err)
+	}
+	if _, err := service.ProcessEndOfDay(day2, &testBranchID, nil, 2000, 0); err != nil {
+		t.Fatalf("ProcessEndOfDay() error = %v", err)
+	}
+
+	trialBalance, err := service.ledger.GetTrialBalance(day2)
+	if err != nil {
+		t.Fatalf("GetTrialBalance() error = %v", err)
+	}
+	if !trialBalance.Balanced || trialBalance.TotalDebit != 12000 {
+		t.Errorf("trial balance = %.2f/%.2f balanced %v, want 12000 both sides", trialBalance.TotalDebit, trialBalance.TotalCredit, trialBalance.Balanced)
+	}
+
+	statement, err := service.ledger.GetAccountStatement(domain.LedgerOperatingCash, day1, day2, &testBranchID, nil)
+	if err != nil {
+		t.Fatalf("GetAccountStatement() error = %v", err)
+	}
+	wantBalances := []float64{3000, 2600, 3200}
+	if len(statement.Lines) != len(wantBalances) {
+		t.Fatalf("got %d statement lines, want %d", len(statement.Lines), len(wantBalances))
+	}
+	for i, want := range wantBalances {
+		if statement.Lines[i].Balance != want {
+			t.Errorf("line %d balance = %.2f, want %.2f", i, statement.Lines[i].Balance, want)
+		}
+	}
+	if statement.OpeningBalance != 0 || statement.ClosingBalance != 3200 {
+		t.Errorf("opening %.2f closing %.2f, want 0 and 3200", statement.OpeningBalance, statement.ClosingBalance)
+	}
+
+	revenue, err := service.ledger.GetAccountBalance(domain.LedgerSalesRevenue, day2, nil, nil)
+	if err != nil || revenue != 12000 {
+		t.Errorf("revenue balance = %.2f (%v), want 12000 on its credit side", revenue, err)
+	}
+}
+
+func TestClosePeriod_ZeroesIncomeAndLocksPeriod(t *testing.T) {
+	service, ledger := setupLedger()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	summary, err := service.ProcessEndOfDay(date, &testBranchID, nil, 10000, 0)
+	if err != nil {
+		t.Fatalf("ProcessEndOfDay() error = %v", err)
+	}
+	if err := service.AddExpenseEntry(summary.ID, "fuel", "Diesel", 400, uuid.New()); err != nil {
+		t.Fatalf("AddExpenseEntry() error = %v", err)
+	}
+
+	period, err := service.ledger.ClosePeriod(2024, time.January, uuid.New())
+	if err != nil {
+		t.Fatalf("ClosePeriod() error = %v", err)
+	}
+	if period.Status != domain.PeriodClosed || period.ClosingEntryID == nil {
+		t.Fatalf("period = %+v, want closed with closing entry", period)
+	}
+
+	periodEnd := time.Date(2024, 1, 31, 0, 0, 0, 0, time.UTC)
+	for code, want := range map[string]float64{
+		domain.LedgerSalesRevenue:     0,
+		domain.LedgerOperatingExpense: 0,
+		domain.LedgerRetainedEarnings: 9600,
+	} {
+		balance, err := service.ledger.GetAccountBalance(code, periodEnd, nil, nil)
+		if err != nil || balance != want {
+			t.Errorf("account %s balance = %.2f (%v), want %.2f", code, balance, err, want)
+		}
+	}
+
+	if _, err := service.ledger.ClosePeriod(2024, time.January, uuid.New()); !errors.Is(err, domain.ErrPeriodClosed) {
+		t.Errorf("second ClosePeriod() error = %v, want ErrPeriodClosed", err)
+	}
+
+	entries := len(ledger.entries)
+	err = service.AddExpenseEntry(summary.ID, "fuel", "Late receipt", 100, uuid.New())
+	if !errors.Is(err, domain.ErrPeriodClosed) {
+		t.Fatalf("AddExpenseEntry() into closed period error = %v, want ErrPeriodClosed", err)
+	}
+	if len(ledger.entries) != entries || len(service.repos.Expense.(*memoryExpenseRepository).expenses) != 1 {
+		t.Errorf("expense into closed period should be neither posted nor kept")
+	}
+
+	if _, err := service.ledger.ClosePeriod(time.Now().Year(), time.Now().Month(), uuid.New()); !errors.Is(err, domain.ErrInvalidDateRange) {
+		t.Errorf("closing current month error = %v, want ErrInvalidDateRange", err)
+	}
+}
+
+func TestReconcileCash_PostsVariance(t *testing.T) {
+	service, ledger := setupLedger()
+	date := time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)
+
+	summary, err := service.ProcessEndOfDay(date, &testBranchID, nil, 10000, 0)
+	if err != nil {
+		t.Fatalf("ProcessEndOfDay() error = %v", err)
+	}
+
+	// Operating cash is 3000 after allocations; 2950 was counted
+	if err := service.ReconcileCash(summary.ID, 2950, uuid.New()); err != nil {
+		t.Fatalf("ReconcileCash() error = %v", err)
+	}
+
+	entry, err := ledger.GetJournalEntryBySource(domain.JournalSourceReconciliation, summary.ID)
+	if err != nil {
+		t.Fatalf("no reconciliation entry: %v", err)
+	}
+	if len(entry.Postings) != 2 || entry.Postings[0].AccountCode != domain.LedgerCashOverShort || entry.Postings[0].Debit != 50 {
+		t.Errorf("postings = %+v, want 50 debited to cash over and short", entry.Postings)
+	}
+
+	cash, err := service.ledger.GetAccountBalance(domain.LedgerOperatingCash, date, &testBranchID, nil)
+	if err != nil || cash != 2950 {
+		t.Errorf("operating cash = %.2f (%v), want counted 2950", cash, err)
+	}
+}
@@ -0,0 +1,419 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"finance/internal/domain"
+	"finance/internal/infrastructure/database/repositories"
+
+	"github.com/google/uuid"
+)
+
+// memoryTransferRepository keeps batches and transfers in memory
+type memoryTransferRepository struct {
+	domain.TransferRepository
+	batches   map[uuid.UUID]*domain.CashTransferBatch
+	transfers []*domain.CashTransfer
+}
+
+func newMemoryTransferRepository() *memoryTransferRepository {
+	return &memoryTransferRepository{batches: make(map[uuid.UUID]*domain.CashTransferBatch)}
+}
+
+func (r *memoryTransferRepository) CreateBatch(batch *domain.CashTransferBatch) error {
+	copied := *batch
+	r.batches[batch.ID] = &copied
+	return nil
+}
+
+func (r *memoryTransferRepository) CreateTransfer(transfer *domain.CashTransfer) error {
+	copied := *transfer
+	r.transfers = append(r.transfers, &copied)
+	return nil
+}
+
+func (r *memoryTransferRepository) GetBatchByID(id uuid.UUID) (*domain.CashTransferBatch, error) {
+	batch, ok := r.batches[id]
+	if !ok {
+		return nil, domain.ErrTransferBatchNotFound
+	}
+	copied := *batch
+	return &copied, nil
+}
+
+func (r *memoryTransferRepository) GetTransfersByBatch(batchID uuid.UUID) ([]*domain.CashTransfer, error) {
+	var transfers []*domain.CashTransfer
+	for _, transfer := range r.transfers {
+		if transfer.BatchID != nil && *transfer.BatchID == batchID {
+			copied := *transfer
+			transfers = append(transfers, &copied)
+		}
+	}
+	return transfers, nil
+}
+
+func (r *memoryTransferRepository) GetTransferByID(id uuid.UUID) (*domain.CashTransfer, error) {
+	for _, transfer := range r.transfers {
+		if transfer.ID == id {
+			copied := *transfer
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrTransferNotFound
+}
+
+func (r *memoryTransferRepository) UpdateBatchStatus(id uuid.UUID, status string) error {
+	batch, ok := r.batches[id]
+	if !ok {
+		return domain.ErrTransferBatchNotFound
+	}
+	batch.Status = status
+	return nil
+}
+
+func (r *memoryTransferRepository) ClaimBatch(id uuid.UUID, fromStatus string) (bool, error) {
+	batch, ok := r.batches[id]
+	if !ok || batch.Status != fromStatus {
+		return false, nil
+	}
+	now := time.Now()
+	batch.Status = domain.TransferStatusProcessing
+	batch.ProcessedAt = &now
+	return true, nil
+}
+
+func (r *memoryTransferRepository) ReclaimStaleBatch(id uuid.UUID, staleBefore time.Time) (bool, error) {
+	batch, ok := r.batches[id]
+	if !ok || batch.Status != domain.TransferStatusProcessing || batch.ProcessedAt == nil || !batch.ProcessedAt.Before(staleBefore) {
+		return false, nil
+	}
+	now := time.Now()
+	batch.ProcessedAt = &now
+	return true, nil
+}
+
+func (r *memoryTransferRepository) UpdateTransferResult(transfer *domain.CashTransfer) error {
+	for i, stored := range r.transfers {
+		if stored.ID == transfer.ID {
+			copied := *transfer
+			r.transfers[i] = &copied
+			return nil
+		}
+	}
+	return domain.ErrTransferNotFound
+}
+
+// scriptedExecutor fails the recipients listed in fail and answers the rest with status
+type scriptedExecutor struct {
+	status string
+	fail   map[string]bool
+	err    error
+	sent   []string
+}
+
+func (e *scriptedExecutor) Name() string { return "scripted" }
+
+func (e *scriptedExecutor) Execute(ctx context.Context, batch *domain.CashTransferBatch, transfers []*domain.CashTransfer) ([]*domain.TransferResult, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	var results []*domain.TransferResult
+	for _, transfer := range transfers {
+		e.sent = append(e.sent, transfer.RecipientName)
+		if e.fail[transfer.RecipientName] {
+			results = append(results, &domain.TransferResult{
+				TransferID:    transfer.ID,
+				Status:        domain.TransferStatusFailed,
+				FailureReason: "account closed",
+				Rejected:      true,
+			})
+			continue
+		}
+		results = append(results, &domain.TransferResult{
+			TransferID:     transfer.ID,
+			Status:         e.status,
+			TransactionRef: "REF-" + transfer.RecipientName,
+		})
+	}
+	return results, nil
+}
+
+func setupTransferBatch(t *testing.T, executor domain.TransferExecutor, recipients ...string) (*financeService, *memoryTransferRepository, uuid.UUID) {
+	t.Helper()
+
+	repo := newMemoryTransferRepository()
+	service := NewFinanceService(&repositories.Repositories{Transfer: repo, Ledger: newMemoryLedgerRepository()}, &mockRedisClient{}, executor).(*financeService)
+
+	var transfers []*domain.CashTransfer
+	for _, recipient := range recipients {
+		transfers = append(transfers, &domain.CashTransfer{
+			TransferType:     "supplier_payment",
+			RecipientName:    recipient,
+			RecipientAccount: "1234567890",
+			Amount:           1000,
+			Currency:         "THB",
+			Reference:        "INV-" + recipient,
+		})
+	}
+
+	batch, err := service.CreateTransferBatch(nil, nil, transfers, uuid.New())
+	if err != nil {
+		t.Fatalf("CreateTransferBatch() error = %v", err)
+	}
+	return service, repo, batch.ID
+}
+
+func transferByRecipient(t *testing.T, repo *memoryTransferRepository, recipient string) *domain.CashTransfer {
+	t.Helper()
+	for _, transfer := range repo.transfers {
+		if transfer.RecipientName == recipient {
+			return transfer
+		}
+	}
+	t.Fatalf("no transfer for %s", recipient)
+	return nil
+}
+
+func TestExecuteTransferBatch_PartialFailureIsRetryable(t *testing.T) {
+	executor := &scriptedExecutor{
+		status: domain.TransferStatusCompleted,
+		fail:   map[string]bool{"bravo": true},
+	}
+	service, repo, batchID := setupTransferBatch(t, executor, "alpha", "bravo", "charlie")
+
+	if err := service.ExecuteTransferBatch(batchID); err != nil {
+		t.Fatalf("ExecuteTransferBatch() error = %v", err)
+	}
+
+	if got := repo.batches[batchID].Status; got != domain.TransferStatusPartial {
+		t.Fatalf("batch status = %s, want partial", got)
+	}
+
+	alpha := transferByRecipient(t, repo, "alpha")
+	if alpha.Status != domain.TransferStatusCompleted || alpha.TransactionRef == nil || *alpha.TransactionRef != "REF-alpha" {
+		t.Errorf("alpha = %s ref %v, want completed with REF-alpha", alpha.Status, alpha.TransactionRef)
+	}
+	if alpha.ConfirmedAt == nil || alpha.ExecutedAt == nil {
+		t.Errorf("alpha should record executed and confirmed times")
+	}
+
+	bravo := transferByRecipient(t, repo, "bravo")
+	if bravo.Status != domain.TransferStatusFailed || bravo.FailureReason == nil || *bravo.FailureReason != "account closed" {
+		t.Errorf("bravo = %s reason %v, want failed with account closed", bravo.Status, bravo.FailureReason)
+	}
+	if bravo.ConfirmedAt != nil {
+		t.Errorf("failed transfer should not be confirmed")
+	}
+
+	// The rejected transfer is retried as a new attempt, the settled one keeps its first
+	if bravo.Attempt != 2 || alpha.Attempt != 1 {
+		t.Errorf("attempts alpha %d bravo %d, want 1 and 2", alpha.Attempt, bravo.Attempt)
+	}
+
+	// Retrying sends only the failed transfer
+	executor.fail = nil
+	executor.sent = nil
+	if err := service.ExecuteTransferBatch(batchID); err != nil {
+		t.Fatalf("retry ExecuteTransferBatch() error = %v", err)
+	}
+
+	if len(executor.sent) != 1 || executor.sent[0] != "bravo" {
+		t.Errorf("retry sent %v, want only bravo", executor.sent)
+	}
+	if got := repo.batches[batchID].Status; got != domain.TransferStatusCompleted {
+		t.Errorf("batch status after retry = %s, want completed", got)
+	}
+	if bravo := transferByRecipient(t, repo, "bravo"); bravo.FailureReason != nil {
+		t.Errorf("retried transfer kept failure reason %q", *bravo.FailureReason)
+	}
+
+	if err := service.ExecuteTransferBatch(batchID); !errors.Is(err, domain.ErrBatchNotRetryable) {
+		t.Errorf("executing completed batch error = %v, want ErrBatchNotRetryable", err)
+	}
+}
+
+func TestExecuteTransferBatch_ExecutorErrorFailsEveryTransfer(t *testing.T) {
+	executor := &scriptedExecutor{err: errors.New("disk full")}
+	service, repo, batchID := setupTransferBatch(t, executor, "alpha", "bravo")
+
+	if err := service.ExecuteTransferBatch(batchID); err != nil {
+		t.Fatalf("ExecuteTransferBatch() error = %v", err)
+	}
+
+	if got := repo.batches[batchID].Status; got != domain.TransferStatusFailed {
+		t.Errorf("batch status = %s, want failed", got)
+	}
+	for _, transfer := range repo.transfers {
+		if transfer.Status != domain.TransferStatusFailed || transfer.FailureReason == nil || *transfer.FailureReason != "scripted: disk full" {
+			t.Errorf("%s = %s reason %v, want failed with executor error", transfer.RecipientName, transfer.Status, transfer.FailureReason)
+		}
+		// Nothing was answered, so a retry resends the same attempt
+		if transfer.Attempt != 1 {
+			t.Errorf("%s attempt = %d, want 1", transfer.RecipientName, transfer.Attempt)
+		}
+	}
+}
+
+func TestExecuteTransferBatch_RejectsBatchInProgress(t *testing.T) {
+	service, repo, batchID := setupTransferBatch(t, &scriptedExecutor{status: domain.TransferStatusCompleted}, "alpha")
+	repo.batches[batchID].Status = domain.TransferStatusProcessing
+
+	if err := service.ExecuteTransferBatch(batchID); !errors.Is(err, domain.ErrTransferInProgress) {
+		t.Errorf("ExecuteTransferBatch() error = %v, want ErrTransferInProgress", err)
+	}
+}
+
+func TestExecuteTransferBatch_ReclaimsStaleBatch(t *testing.T) {
+	executor := &scriptedExecutor{status: domain.TransferStatusCompleted}
+	service, repo, batchID := setupTransferBatch(t, executor, "alpha", "bravo")
+
+	// An execution crashed after recording alpha, leaving the batch processing
+	crashedAt := time.Now().Add(-staleBatchAfter - time.Minute)
+	repo.batches[batchID].Status = domain.TransferStatusProcessing
+	repo.batches[batchID].ProcessedAt = &crashedAt
+	transferByRecipient(t, repo, "alpha").Status = domain.TransferStatusCompleted
+
+	if err := service.ExecuteTransferBatch(batchID); err != nil {
+		t.Fatalf("ExecuteTransferBatch() error = %v", err)
+	}
+	if len(executor.sent) != 1 || executor.sent[0] != "bravo" {
+		t.Errorf("sent %v, want only bravo", executor.sent)
+	}
+	if status := repo.batches[batchID].Status; status != domain.TransferStatusCompleted {
+		t.Errorf("batch status = %s, want completed", status)
+	}
+}
+
+func TestExecuteTransferBatch_KeepsRecentProcessingBatch(t *testing.T) {
+	executor := &scriptedExecutor{status: domain.TransferStatusCompleted}
+	service, repo, batchID := setupTransferBatch(t, executor, "alpha")
+
+	claimedAt := time.Now().Add(-transferTimeout)
+	repo.batches[batchID].Status = domain.TransferStatusProcessing
+	repo.batches[batchID].ProcessedAt = &claimedAt
+
+	if err := service.ExecuteTransferBatch(batchID); !errors.Is(err, domain.ErrTransferInProgress) {
+		t.Errorf("ExecuteTransferBatch() error = %v, want ErrTransferInProgress", err)
+	}
+	if len(executor.sent) != 0 {
+		t.Errorf("sent %v while the batch was being executed", executor.sent)
+	}
+}
+
+func TestSubmittedTransfers_ConfirmAndReject(t *testing.T) {
+	executor := &scriptedExecutor{status: domain.TransferStatusSubmitted}
+	service, repo, batchID := setupTransferBatch(t, executor, "alpha", "bravo")
+
+	if err := service.ExecuteTransferBatch(batchID); err != nil {
+		t.Fatalf("ExecuteTransferBatch() error = %v", err)
+	}
+	if got := repo.batches[batchID].Status; got != domain.TransferStatusSubmitted {
+		t.Fatalf("batch status = %s, want submitted", got)
+	}
+	if err := service.ExecuteTransferBatch(batchID); !errors.Is(err, domain.ErrBatchNotRetryable) {
+		t.Errorf("executing submitted batch error = %v, want ErrBatchNotRetryable", err)
+	}
+
+	alpha := transferByRecipient(t, repo, "alpha")
+	if alpha.ConfirmedAt != nil {
+		t.Errorf("submitted transfer should not be confirmed yet")
+	}
+	if err := service.ConfirmTransfer(alpha.ID, "KB240115000123"); err != nil {
+		t.Fatalf("ConfirmTransfer() error = %v", err)
+	}
+	alpha = transferByRecipient(t, repo, "alpha")
+	if alpha.Status != domain.TransferStatusCompleted || alpha.ConfirmedAt == nil || *alpha.TransactionRef != "KB240115000123" {
+		t.Errorf("confirmed alpha = %s confirmed %v ref %v", alpha.Status, alpha.ConfirmedAt, alpha.TransactionRef)
+	}
+	if err := service.ConfirmTransfer(alpha.ID, ""); !errors.Is(err, domain.ErrTransferNotSubmitted) {
+		t.Errorf("confirming twice error = %v, want ErrTransferNotSubmitted", err)
+	}
+
+	bravo := transferByRecipient(t, repo, "bravo")
+	if err := service.RejectTransfer(bravo.ID, "invalid account name"); err != nil {
+		t.Fatalf("RejectTransfer() error = %v", err)
+	}
+	if got := repo.batches[batchID].Status; got != domain.TransferStatusPartial {
+		t.Fatalf("batch status after reject = %s, want partial", got)
+	}
+
+	executor.status = domain.TransferStatusCompleted
+	executor.sent = nil
+	if err := service.ExecuteTransferBatch(batchID); err != nil {
+		t.Fatalf("retry ExecuteTransferBatch() error = %v", err)
+	}
+	if len(executor.sent) != 1 || executor.sent[0] != "bravo" {
+		t.Errorf("retry sent %v, want only bravo", executor.sent)
+	}
+	if got := repo.batches[batchID].Status; got != domain.TransferStatusCompleted {
+		t.Errorf("batch status after retry = %s, want completed", got)
+	}
+}
+
+func TestBatchStatusFor(t *testing.T) {
+	transfers := func(statuses ...string) []*domain.CashTransfer {
+		var result []*domain.CashTransfer
+		for _, status := range statuses {
+			result = append(result, &domain.CashTransfer{Status: status, UpdatedAt: time.Now()})
+		}
+		return result
+	}
+
+	tests := []struct {
+		name      string
+		transfers []*domain.CashTransfer
+		want      string
+	}{
+		{"all completed", transfers("completed", "completed"), domain.TransferStatusCompleted},
+		{"all failed", transfers("failed", "failed"), domain.TransferStatusFailed},
+		{"some failed", transfers("completed", "failed"), domain.TransferStatusPartial},
+		{"failed and submitted", transfers("submitted", "failed"), domain.TransferStatusPartial},
+		{"awaiting confirmation", transfers("completed", "submitted"), domain.TransferStatusSubmitted},
+		{"unsent left", transfers("completed", "pending"), domain.TransferStatusPartial},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchStatusFor(tt.transfers); got != tt.want {
+				t.Errorf("batchStatusFor() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// invalidatesCashStatus reports whether the write started a new cash status cache generation
+func invalidatesCashStatus(t *testing.T, redisClient *memoryRedisClient, write func() error) bool {
+	t.Helper()
+	before := redisClient.values[cashStatusVersionKey]
+	if err := write(); err != nil {
+		t.Fatalf("write error = %v", err)
+	}
+	after := redisClient.values[cashStatusVersionKey]
+	return after != "" && after != before
+}
+
+func TestCashWritesInvalidateCashStatus(t *testing.T) {
+	service, repo, batchID := setupTransferBatch(t, &scriptedExecutor{status: domain.TransferStatusSubmitted}, "alpha")
+	redisClient := newMemoryRedisClient()
+	service.redis = redisClient
+
+	if !invalidatesCashStatus(t, redisClient, func() error { return service.ExecuteTransferBatch(batchID) }) {
+		t.Error("ExecuteTransferBatch kept the cached cash status")
+	}
+	alpha := transferByRecipient(t, repo, "alpha")
+	if !invalidatesCashStatus(t, redisClient, func() error { return service.ConfirmTransfer(alpha.ID, "KB240115000123") }) {
+		t.Error("ConfirmTransfer kept the cached cash status")
+	}
+
+	ledgerService, _ := setupLedger()
+	summary, err := ledgerService.ProcessEndOfDay(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC), &testBranchID, nil, 10000, 0)
+	if err != nil {
+		t.Fatalf("ProcessEndOfDay() error = %v", err)
+	}
+	if !invalidatesCashStatus(t, ledgerService.redis.(*memoryRedisClient), func() error { return ledgerService.ReconcileCash(summary.ID, 2950, uuid.New()) }) {
+		t.Error("ReconcileCash kept the cached cash status")
+	}
+}
@@ -21,6 +21,9 @@ var (
 	ErrUnauthorized            = errors.New("unauthorized operation")
 	ErrDatabaseConnection      = errors.New("database connection error")
 	ErrTransactionFailed       = errors.New("database transaction failed")
+	ErrLedgerAccountNotFound   = errors.New("ledger account not found")
+	ErrJournalEntryNotFound    = errors.New("journal entry not found")
+	ErrPeriodNotFound          = errors.New("accounting period not found")
 )
 
 // Validation errors
@@ -28,6 +31,7 @@ var (
 	ErrMissingEntityID      = errors.New("entity ID is required")
 	ErrMissingUserID        = errors.New("user ID is required")
 	ErrMissingDescription   = errors.New("description is required")
+	ErrMissingReference     = errors.New("reference is required")
 	ErrMissingRecipient     = errors.New("recipient information is required")
 	ErrInvalidPercentage    = errors.New("percentage must be between 0 and 100")
 	ErrPercentageSum        = errors.New("allocation percentages must not exceed 100%")
@@ -41,5 +45,12 @@ var (
 	ErrCannotDeleteActiveRule = errors.New("cannot delete active allocation rule")
 	ErrDuplicateActiveRule    = errors.New("only one active rule allowed per entity")
 	ErrTransferInProgress     = errors.New("transfer batch is already in progress")
+	ErrBatchNotRetryable      = errors.New("transfer batch has nothing left to execute")
+	ErrTransferNotSubmitted   = errors.New("transfer is not awaiting bank confirmation")
+	ErrUnbalancedEntry        = errors.New("journal entry debits and credits do not balance")
+	ErrInvalidPosting         = errors.New("posting must have either a debit or a credit amount")
+	ErrInactiveLedgerAccount  = errors.New("ledger account is inactive")
+	ErrDuplicateJournalEntry  = errors.New("journal entry already posted for this source")
+	ErrPeriodClosed           = errors.New("accounting period is closed")
 	ErrInvalidDateRange       = errors.New("effective date range is invalid")
 )
@@ -1,6 +1,7 @@
 package domain
 
 import (
+	"context"
 	"database/sql"
 	"time"
 	"github.com/google/uuid"
@@ -79,7 +80,7 @@ type CashTransferBatch struct {
 	VehicleID       *uuid.UUID `json:"vehicle_id,omitempty" db:"vehicle_id"`
 	TotalAmount     float64    `json:"total_amount" db:"total_amount"`
 	TransferCount   int        `json:"transfer_count" db:"transfer_count"`
-	Status          string     `json:"status" db:"status"` // pending, processing, completed, failed
+	Status          string     `json:"status" db:"status"` // pending, processing, submitted, partial, completed, failed
 	ScheduledAt     *time.Time `json:"scheduled_at,omitempty" db:"scheduled_at"`
 	ProcessedAt     *time.Time `json:"processed_at,omitempty" db:"processed_at"`
 	CompletedAt     *time.Time `json:"completed_at,omitempty" db:"completed_at"`
@@ -100,7 +101,7 @@ type CashTransfer struct {
 	Currency         string     `json:"currency" db:"currency"`
 	Reference        string     `json:"reference" db:"reference"`
 	Description      string     `json:"description" db:"description"`
-	Status           string     `json:"status" db:"status"` // pending, processing, completed, failed
+	Status           string     `json:"status" db:"status"` // pending, processing, submitted, completed, failed
 	
 	// Bank transfer details
 	BankName         *string    `json:"bank_name,omitempty" db:"bank_name"`
@@ -111,11 +112,45 @@ type CashTransfer struct {
 	ExecutedAt       *time.Time `json:"executed_at,omitempty" db:"executed_at"`
 	ConfirmedAt      *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"`
 	FailureReason    *string    `json:"failure_reason,omitempty" db:"failure_reason"`
+	// Attempt counts the sends the bank answered with a rejection, plus one; it picks the
+	// idempotency key, so a rejected transfer is sent again under a new key
+	Attempt          int        `json:"attempt" db:"attempt"`
 	CreatedBy        uuid.UUID  `json:"created_by" db:"created_by"`
 	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// Transfer and batch statuses. A submitted transfer was handed to the bank but is not yet
+// confirmed; a partial batch has failed transfers that can be retried.
+const (
+	TransferStatusPending    = "pending"
+	TransferStatusProcessing = "processing"
+	TransferStatusSubmitted  = "submitted"
+	TransferStatusPartial    = "partial"
+	TransferStatusCompleted  = "completed"
+	TransferStatusFailed     = "failed"
+	TransferStatusCancelled  = "cancelled"
+)
+
+// TransferResult is the outcome of one transfer reported by a TransferExecutor
+type TransferResult struct {
+	TransferID     uuid.UUID  `json:"transfer_id"`
+	Status         string     `json:"status"` // submitted, completed, failed
+	TransactionRef string     `json:"transaction_ref,omitempty"`
+	FailureReason  string     `json:"failure_reason,omitempty"`
+	ConfirmedAt    *time.Time `json:"confirmed_at,omitempty"`
+	// Rejected is set when the bank answered and turned the transfer down. A failure without an
+	// answer, such as a timeout, may still have gone through and is resent as the same attempt.
+	Rejected bool `json:"rejected,omitempty"`
+}
+
+// TransferExecutor pays out transfers through a bank channel
+type TransferExecutor interface {
+	Name() string
+	// Execute returns one result per transfer. An error means nothing was sent.
+	Execute(ctx context.Context, batch *CashTransferBatch, transfers []*CashTransfer) ([]*TransferResult, error)
+}
+
 // ExpenseEntry represents manual expense entries
 type ExpenseEntry struct {
 	ID          uuid.UUID  `json:"id" db:"id"`
@@ -142,6 +177,47 @@ type CashFlowRecord struct {
 	CreatedAt       time.Time    `json:"created_at" db:"created_at"`
 }
 
+// AllocationTotals sums the daily summary allocations of a branch, a vehicle or the
+// central office (both IDs nil) up to a business date
+type AllocationTotals struct {
+	BranchID             *uuid.UUID
+	VehicleID            *uuid.UUID
+	TotalSales           float64
+	ProfitAllocation     float64
+	OwnerPayAllocation   float64
+	TaxAllocation        float64
+	AvailableForExpenses float64
+	ManualExpenses       float64
+	SupplierTransfers    float64
+	OtherTransfers       float64
+}
+
+// CashFlowTotals sums the cash flow records of one entity up to a point in time
+type CashFlowTotals struct {
+	EntityType string
+	EntityID   uuid.UUID
+	Inflows    float64
+	Outflows   float64
+}
+
+// EntityCashBalance is the balance of each account of one entity, or of the whole company
+type EntityCashBalance struct {
+	EntityType   string                  `json:"entity_type"` // company, branch, vehicle, central
+	EntityID     *uuid.UUID              `json:"entity_id,omitempty"`
+	Accounts     map[AccountType]float64 `json:"accounts"`
+	CashInflows  float64                 `json:"cash_inflows"`
+	CashOutflows float64                 `json:"cash_outflows"`
+	CashBalance  float64                 `json:"cash_balance"`
+}
+
+// CashStatus is the live balance per AccountType as of the end of a business date
+type CashStatus struct {
+	AsOf         time.Time            `json:"as_of"`
+	Totals       *EntityCashBalance   `json:"totals"`
+	Entities     []*EntityCashBalance `json:"entities,omitempty"` // company scope only
+	CalculatedAt time.Time            `json:"calculated_at"`
+}
+
 // Repository interfaces
 type CashSummaryRepository interface {
 	Create(summary *DailyCashSummary) error
@@ -150,6 +226,7 @@ type CashSummaryRepository interface {
 	UpdateReconciliation(id uuid.UUID, reconciledBy uuid.UUID) error
 	Update(summary *DailyCashSummary) error
 	GetByDateRange(startDate, endDate time.Time, branchID, vehicleID *uuid.UUID) ([]*DailyCashSummary, error)
+	GetAllocationTotals(asOf time.Time, branchID, vehicleID *uuid.UUID) ([]*AllocationTotals, error)
 }
 
 type AllocationRuleRepository interface {
@@ -170,6 +247,9 @@ type TransferRepository interface {
 	UpdateBatchStatus(id uuid.UUID, status string) error
 	GetTransferByID(id uuid.UUID) (*CashTransfer, error)
 	GetPendingBatches() ([]*CashTransferBatch, error)
+	ClaimBatch(id uuid.UUID, fromStatus string) (bool, error)
+	ReclaimStaleBatch(id uuid.UUID, staleBefore time.Time) (bool, error)
+	UpdateTransferResult(transfer *CashTransfer) error
 }
 
 type ExpenseRepository interface {
@@ -184,13 +264,16 @@ type ExpenseRepository interface {
 
 type CashFlowRepository interface {
 	Create(record *CashFlowRecord) error
+	CreateOnce(record *CashFlowRecord) (bool, error)
 	GetByEntity(entityType string, entityID uuid.UUID, limit int) ([]*CashFlowRecord, error)
 	GetCurrentBalance(entityType string, entityID uuid.UUID) (float64, error)
 	GetByID(id uuid.UUID) (*CashFlowRecord, error)
+	GetByReference(entityType string, entityID uuid.UUID, reference string) (*CashFlowRecord, error)
 	GetBalanceHistory(entityType string, entityID uuid.UUID, limit int) ([]*CashFlowRecord, error)
 	CalculateRunningBalance(entityType string, entityID uuid.UUID, transactionType CashFlowType, amount float64) (float64, error)
 	GetTotalInflows(entityType string, entityID uuid.UUID) (float64, error)
 	GetTotalOutflows(entityType string, entityID uuid.UUID) (float64, error)
+	GetTotalsByEntity(before time.Time, entityType string, entityID *uuid.UUID) ([]*CashFlowTotals, error)
 }
 
 // Service interfaces
@@ -199,7 +282,10 @@ type FinanceService interface {
 	AddExpenseEntry(summaryID uuid.UUID, category, description string, amount float64, enteredBy uuid.UUID) error
 	CreateTransferBatch(branchID, vehicleID *uuid.UUID, transfers []*CashTransfer, authorizedBy uuid.UUID) (*CashTransferBatch, error)
 	ExecuteTransferBatch(batchID uuid.UUID) error
-	GetCashStatus() (map[string]interface{}, error)
+	GetTransferBatch(batchID uuid.UUID) (*CashTransferBatch, []*CashTransfer, error)
+	ConfirmTransfer(transferID uuid.UUID, transactionRef string) error
+	RejectTransfer(transferID uuid.UUID, reason string) error
+	GetCashStatus(asOf time.Time, branchID, vehicleID *uuid.UUID) (*CashStatus, error)
 	ReconcileCash(summaryID uuid.UUID, actualCash float64, reconciledBy uuid.UUID) error
 }
 
@@ -211,6 +297,7 @@ type AllocationService interface {
 
 type CashFlowService interface {
 	RecordTransaction(entityType string, entityID uuid.UUID, txType CashFlowType, amount float64, description, reference string, createdBy *uuid.UUID) error
+	RecordTransactionOnce(entityType string, entityID uuid.UUID, txType CashFlowType, amount float64, description, reference string, createdBy *uuid.UUID) (*CashFlowRecord, bool, error)
 	GetEntityCashFlow(entityType string, entityID uuid.UUID, limit int) ([]*CashFlowRecord, error)
 	GetCurrentBalance(entityType string, entityID uuid.UUID) (float64, error)
 }
@@ -0,0 +1,181 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LedgerAccountType is the accounting classification of a ledger account
+type LedgerAccountType string
+
+const (
+	AssetAccount     LedgerAccountType = "asset"
+	LiabilityAccount LedgerAccountType = "liability"
+	EquityAccount    LedgerAccountType = "equity"
+	IncomeAccount    LedgerAccountType = "revenue"
+	ExpenseAccount   LedgerAccountType = "expense"
+)
+
+// DebitNormal reports whether debits increase accounts of this type
+func (t LedgerAccountType) DebitNormal() bool {
+	return t == AssetAccount || t == ExpenseAccount
+}
+
+// Chart of accounts codes. The Profit First accounts are asset accounts; operating cash is
+// the cash left to run the branch or vehicle after allocations.
+const (
+	LedgerOperatingCash    = "1000"
+	LedgerProfit           = "1110"
+	LedgerOwnerPay         = "1120"
+	LedgerTax              = "1130"
+	LedgerCentralClearing  = "1300"
+	LedgerRetainedEarnings = "3100"
+	LedgerSalesRevenue     = "4000"
+	LedgerOperatingExpense = "5000"
+	LedgerCashOverShort    = "5100"
+	LedgerSupplierPayments = "5200"
+)
+
+// Journal entry sources
+const (
+	JournalSourceEndOfDay       = "end_of_day"
+	JournalSourceExpense        = "expense"
+	JournalSourceTransfer       = "transfer"
+	JournalSourceReconciliation = "reconciliation"
+	JournalSourcePeriodClose    = "period_close"
+	JournalSourceManual         = "manual"
+)
+
+// Accounting period statuses
+const (
+	PeriodOpen   = "open"
+	PeriodClosed = "closed"
+)
+
+// LedgerAccount is an account in the chart of accounts
+type LedgerAccount struct {
+	ID          uuid.UUID         `json:"id" db:"id"`
+	Code        string            `json:"code" db:"code"`
+	Name        string            `json:"name" db:"name"`
+	Type        LedgerAccountType `json:"type" db:"type"`
+	AccountType *AccountType      `json:"account_type,omitempty" db:"account_type"` // Profit First account it represents
+	IsActive    bool              `json:"is_active" db:"is_active"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+}
+
+// JournalEntry is a balanced set of postings recorded on one date
+type JournalEntry struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	EntryDate   time.Time  `json:"entry_date" db:"entry_date"`
+	Description string     `json:"description" db:"description"`
+	SourceType  string     `json:"source_type" db:"source_type"`
+	SourceID    uuid.UUID  `json:"source_id" db:"source_id"`
+	BranchID    *uuid.UUID `json:"branch_id,omitempty" db:"branch_id"`
+	VehicleID   *uuid.UUID `json:"vehicle_id,omitempty" db:"vehicle_id"`
+	Postings    []*Posting `json:"postings"`
+	CreatedBy   *uuid.UUID `json:"created_by,omitempty" db:"created_by"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+}
+
+// Posting is one debit or credit line of a journal entry
+type Posting struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	EntryID     uuid.UUID `json:"entry_id" db:"entry_id"`
+	AccountCode string    `json:"account_code" db:"account_code"`
+	Debit       float64   `json:"debit" db:"debit"`
+	Credit      float64   `json:"credit" db:"credit"`
+	Description string    `json:"description,omitempty" db:"description"`
+}
+
+// AccountingPeriod is a calendar month of the ledger; no entry can be posted into a closed period
+type AccountingPeriod struct {
+	ID             uuid.UUID  `json:"id" db:"id"`
+	PeriodStart    time.Time  `json:"period_start" db:"period_start"`
+	PeriodEnd      time.Time  `json:"period_end" db:"period_end"`
+	Status         string     `json:"status" db:"status"`
+	ClosingEntryID *uuid.UUID `json:"closing_entry_id,omitempty" db:"closing_entry_id"`
+	ClosedBy       *uuid.UUID `json:"closed_by,omitempty" db:"closed_by"`
+	ClosedAt       *time.Time `json:"closed_at,omitempty" db:"closed_at"`
+}
+
+// AccountTotals sums the debits and credits of one account
+type AccountTotals struct {
+	AccountCode string
+	Debit       float64
+	Credit      float64
+}
+
+// TrialBalanceLine is the balance of one account on its normal side
+type TrialBalanceLine struct {
+	AccountCode string            `json:"account_code"`
+	AccountName string            `json:"account_name"`
+	Type        LedgerAccountType `json:"type"`
+	Debit       float64           `json:"debit"`
+	Credit      float64           `json:"credit"`
+}
+
+// TrialBalance lists every account with a balance as of a date
+type TrialBalance struct {
+	AsOf        time.Time           `json:"as_of"`
+	Lines       []*TrialBalanceLine `json:"lines"`
+	TotalDebit  float64             `json:"total_debit"`
+	TotalCredit float64             `json:"total_credit"`
+	Balanced    bool                `json:"balanced"`
+}
+
+// StatementLine is one posting on an account statement
+type StatementLine struct {
+	EntryID     uuid.UUID `json:"entry_id"`
+	EntryDate   time.Time `json:"entry_date"`
+	Description string    `json:"description"`
+	SourceType  string    `json:"source_type"`
+	Debit       float64   `json:"debit"`
+	Credit      float64   `json:"credit"`
+	Balance     float64   `json:"balance"`
+}
+
+// AccountStatement lists the postings of an account between two dates with a running balance
+type AccountStatement struct {
+	Account        *LedgerAccount   `json:"account"`
+	From           time.Time        `json:"from"`
+	To             time.Time        `json:"to"`
+	BranchID       *uuid.UUID       `json:"branch_id,omitempty"`
+	VehicleID      *uuid.UUID       `json:"vehicle_id,omitempty"`
+	OpeningBalance float64          `json:"opening_balance"`
+	Lines          []*StatementLine `json:"lines"`
+	ClosingBalance float64          `json:"closing_balance"`
+}
+
+// LedgerRepository stores the chart of accounts, journal entries and accounting periods
+type LedgerRepository interface {
+	GetAccounts() ([]*LedgerAccount, error)
+	GetAccountByCode(code string) (*LedgerAccount, error)
+	// CreateJournalEntry stores the entry and its postings, failing with ErrPeriodClosed when the
+	// entry date falls in a closed period and ErrDuplicateJournalEntry when the source is already posted
+	CreateJournalEntry(entry *JournalEntry) error
+	GetJournalEntryBySource(sourceType string, sourceID uuid.UUID) (*JournalEntry, error)
+	GetAccountTotals(from, to time.Time) ([]*AccountTotals, error)
+	GetAccountBalance(code string, asOf time.Time, branchID, vehicleID *uuid.UUID) (float64, error)
+	GetStatementLines(code string, from, to time.Time, branchID, vehicleID *uuid.UUID) ([]*StatementLine, error)
+	GetPeriod(periodStart time.Time) (*AccountingPeriod, error)
+	// ClosePeriod locks the period, lets buildClosing turn the period totals into a closing
+	// entry (nil for none), stores it and marks the period closed in one transaction
+	ClosePeriod(period *AccountingPeriod, buildClosing func(totals []*AccountTotals) (*JournalEntry, error)) error
+}
+
+// LedgerService posts balanced journals and reports from the ledger
+type LedgerService interface {
+	PostJournal(entry *JournalEntry) error
+	GetAccounts() ([]*LedgerAccount, error)
+	GetAccountBalance(code string, asOf time.Time, branchID, vehicleID *uuid.UUID) (float64, error)
+	GetTrialBalance(asOf time.Time) (*TrialBalance, error)
+	GetAccountStatement(code string, from, to time.Time, branchID, vehicleID *uuid.UUID) (*AccountStatement, error)
+	ClosePeriod(year int, month time.Month, closedBy uuid.UUID) (*AccountingPeriod, error)
+}
+
+// PeriodBounds returns the first and last day of the accounting period containing date
+func PeriodBounds(date time.Time) (time.Time, time.Time) {
+	start := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, -1)
+}
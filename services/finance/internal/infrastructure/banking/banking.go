@@ -0,0 +1,38 @@
+package banking
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"finance/internal/domain"
+)
+
+// New creates the transfer executor selected by TRANSFER_EXECUTOR
+func New() (domain.TransferExecutor, error) {
+	switch executor := getEnv("TRANSFER_EXECUTOR", "bank_file"); executor {
+	case "bank_file":
+		format := BulkFileFormat(getEnv("BANK_FILE_FORMAT", string(FormatKBank)))
+		dir := getEnv("BANK_FILE_DIR", "./bank-files")
+		return NewBulkFileExecutor(format, dir, getEnv("BANK_DEBIT_ACCOUNT", ""))
+	case "promptpay":
+		apiKey := getEnv("PROMPTPAY_API_KEY", "")
+		if apiKey == "" {
+			return nil, fmt.Errorf("PROMPTPAY_API_KEY is required for the promptpay executor")
+		}
+		timeout, err := time.ParseDuration(getEnv("PROMPTPAY_TIMEOUT", "15s"))
+		if err != nil {
+			return nil, fmt.Errorf("invalid PROMPTPAY_TIMEOUT: %w", err)
+		}
+		return NewPromptPayExecutor(getEnv("PROMPTPAY_API_URL", "http://localhost:8099"), apiKey, timeout), nil
+	default:
+		return nil, fmt.Errorf("unknown TRANSFER_EXECUTOR %q", executor)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
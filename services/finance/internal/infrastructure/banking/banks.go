@@ -0,0 +1,124 @@
+package banking
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+
+	"finance/internal/domain"
+)
+
+// bankCodes maps the names and abbreviations staff type into transfers to the
+// Bank of Thailand three-digit bank codes used in bulk files and ITMX requests
+var bankCodes = map[string]string{
+	"BBL":                "002",
+	"BANGKOKBANK":        "002",
+	"KBANK":              "004",
+	"KASIKORN":           "004",
+	"KASIKORNBANK":       "004",
+	"KTB":                "006",
+	"KRUNGTHAI":          "006",
+	"KRUNGTHAIBANK":      "006",
+	"TTB":                "011",
+	"TMBTHANACHART":      "011",
+	"SCB":                "014",
+	"SIAMCOMMERCIAL":     "014",
+	"SIAMCOMMERCIALBANK": "014",
+	"CIMB":               "022",
+	"CIMBTHAI":           "022",
+	"UOB":                "024",
+	"BAY":                "025",
+	"KRUNGSRI":           "025",
+	"GSB":                "030",
+	"GHB":                "033",
+	"BAAC":               "034",
+	"TISCO":              "067",
+	"KKP":                "069",
+	"KIATNAKIN":          "069",
+	"LHB":                "073",
+	"LHBANK":             "073",
+}
+
+// bankCode resolves a bank name, abbreviation or three-digit code
+func bankCode(name string) (string, bool) {
+	key := strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToUpper(r)
+		}
+		return -1
+	}, name)
+
+	if code, ok := bankCodes[key]; ok {
+		return code, true
+	}
+	for _, code := range bankCodes {
+		if code == key {
+			return code, true
+		}
+	}
+	return "", false
+}
+
+// transferAccount returns the account number digits, preferring the bank account
+// number over the free-form recipient account
+func transferAccount(transfer *domain.CashTransfer) string {
+	account := transfer.RecipientAccount
+	if transfer.AccountNumber != nil && *transfer.AccountNumber != "" {
+		account = *transfer.AccountNumber
+	}
+	return digitsOnly(account)
+}
+
+// transferBankCode resolves the bank of the transfer
+func transferBankCode(transfer *domain.CashTransfer) (string, error) {
+	if transfer.BankName == nil || *transfer.BankName == "" {
+		return "", fmt.Errorf("bank name is required")
+	}
+	code, ok := bankCode(*transfer.BankName)
+	if !ok {
+		return "", fmt.Errorf("unsupported bank %q", *transfer.BankName)
+	}
+	return code, nil
+}
+
+// validateBankAccount checks the account number length used by Thai banks
+func validateBankAccount(account string) error {
+	if len(account) < 10 || len(account) > 12 {
+		return fmt.Errorf("%w: %q", domain.ErrInvalidAccountNumber, account)
+	}
+	return nil
+}
+
+// validateAmount rejects amounts the bank would refuse before anything is sent
+func validateAmount(transfer *domain.CashTransfer) error {
+	if transfer.Amount <= 0 {
+		return domain.ErrInvalidAmount
+	}
+	if transfer.Currency != "" && !strings.EqualFold(transfer.Currency, "THB") {
+		return fmt.Errorf("%w: %s", domain.ErrInvalidCurrency, transfer.Currency)
+	}
+	return nil
+}
+
+// toSatang converts baht to satang, the unit bank files carry amounts in
+func toSatang(amount float64) int64 {
+	return int64(math.Round(amount * 100))
+}
+
+func digitsOnly(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+}
+
+func failedResult(transfer *domain.CashTransfer, reason string) *domain.TransferResult {
+	return &domain.TransferResult{
+		TransferID:    transfer.ID,
+		Status:        domain.TransferStatusFailed,
+		FailureReason: reason,
+	}
+}
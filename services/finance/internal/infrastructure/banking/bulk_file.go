@@ -0,0 +1,235 @@
+package banking
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"finance/internal/domain"
+)
+
+// BulkFileFormat is the bulk-payment file layout of a bank's corporate portal
+type BulkFileFormat string
+
+const (
+	// FormatKBank is a fixed-width text file for K-Cash Connect bulk upload
+	FormatKBank BulkFileFormat = "kbank"
+	// FormatSCB is a CSV file for SCB Business Net bulk upload
+	FormatSCB BulkFileFormat = "scb"
+)
+
+// bulkLine is a transfer that made it into the bulk file
+type bulkLine struct {
+	seq      int
+	bankCode string
+	account  string
+	transfer *domain.CashTransfer
+}
+
+// BulkFileExecutor writes the transfers of a batch into a bulk-payment file for manual
+// upload. Transfers in the file are submitted until finance confirms them against the
+// bank statement; transfers that fail validation are left out of the file and failed.
+type BulkFileExecutor struct {
+	format       BulkFileFormat
+	dir          string
+	debitAccount string
+	now          func() time.Time
+}
+
+// NewBulkFileExecutor creates a bulk-file executor writing to dir and debiting debitAccount
+func NewBulkFileExecutor(format BulkFileFormat, dir, debitAccount string) (*BulkFileExecutor, error) {
+	if format != FormatKBank && format != FormatSCB {
+		return nil, fmt.Errorf("unsupported bulk file format %q", format)
+	}
+	debitAccount = digitsOnly(debitAccount)
+	if err := validateBankAccount(debitAccount); err != nil {
+		return nil, fmt.Errorf("invalid debit account: %w", err)
+	}
+	return &BulkFileExecutor{
+		format:       format,
+		dir:          dir,
+		debitAccount: debitAccount,
+		now:          time.Now,
+	}, nil
+}
+
+// Name returns the executor name
+func (e *BulkFileExecutor) Name() string {
+	return "bank_file_" + string(e.format)
+}
+
+// Execute writes one file per execution, so a retry only carries the transfers that failed before
+func (e *BulkFileExecutor) Execute(ctx context.Context, batch *domain.CashTransferBatch, transfers []*domain.CashTransfer) ([]*domain.TransferResult, error) {
+	results := make([]*domain.TransferResult, 0, len(transfers))
+	lines := make([]bulkLine, 0, len(transfers))
+
+	for _, transfer := range transfers {
+		line, err := e.prepareLine(len(lines)+1, transfer)
+		if err != nil {
+			results = append(results, failedResult(transfer, err.Error()))
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if len(lines) == 0 {
+		return results, nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	now := e.now()
+	var content []byte
+	var err error
+	switch e.format {
+	case FormatKBank:
+		content = e.renderKBank(batch, lines, now)
+	case FormatSCB:
+		content, err = e.renderSCB(batch, lines, now)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fileName, err := e.writeFile(batch, content, now)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, line := range lines {
+		results = append(results, &domain.TransferResult{
+			TransferID:     line.transfer.ID,
+			Status:         domain.TransferStatusSubmitted,
+			TransactionRef: fmt.Sprintf("%s#%d", fileName, line.seq),
+		})
+	}
+
+	return results, nil
+}
+
+func (e *BulkFileExecutor) prepareLine(seq int, transfer *domain.CashTransfer) (bulkLine, error) {
+	if err := validateAmount(transfer); err != nil {
+		return bulkLine{}, err
+	}
+	code, err := transferBankCode(transfer)
+	if err != nil {
+		return bulkLine{}, err
+	}
+	account := transferAccount(transfer)
+	if err := validateBankAccount(account); err != nil {
+		return bulkLine{}, err
+	}
+	return bulkLine{seq: seq, bankCode: code, account: account, transfer: transfer}, nil
+}
+
+// renderKBank lays out a header record followed by one detail record per transfer:
+//
+//	H | debit account (10) | effective date DDMMYYYY (8) | batch reference (20) | count (6) | total satang (15)
+//	D | sequence (6) | bank code (3) | account (12) | amount satang (15) | recipient name (50) | reference (20)
+//
+// Text fields are padded by character, as the portal counts Thai characters one per position.
+func (e *BulkFileExecutor) renderKBank(batch *domain.CashTransferBatch, lines []bulkLine, now time.Time) []byte {
+	var total int64
+	for _, line := range lines {
+		total += toSatang(line.transfer.Amount)
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("H")
+	buf.WriteString(padRight(e.debitAccount, 10))
+	buf.WriteString(now.Format("02012006"))
+	buf.WriteString(padRight(batch.BatchReference, 20))
+	buf.WriteString(fmt.Sprintf("%06d%015d", len(lines), total))
+	buf.WriteString("\r\n")
+
+	for _, line := range lines {
+		buf.WriteString("D")
+		buf.WriteString(fmt.Sprintf("%06d", line.seq))
+		buf.WriteString(line.bankCode)
+		buf.WriteString(padRight(line.account, 12))
+		buf.WriteString(fmt.Sprintf("%015d", toSatang(line.transfer.Amount)))
+		buf.WriteString(padRight(line.transfer.RecipientName, 50))
+		buf.WriteString(padRight(line.transfer.Reference, 20))
+		buf.WriteString("\r\n")
+	}
+
+	return buf.Bytes()
+}
+
+// renderSCB writes a header row followed by one detail row per transfer:
+//
+//	H,debit account,effective date YYYYMMDD,batch reference,count,total
+//	D,sequence,bank code,account,recipient name,amount,reference
+func (e *BulkFileExecutor) renderSCB(batch *domain.CashTransferBatch, lines []bulkLine, now time.Time) ([]byte, error) {
+	var total int64
+	for _, line := range lines {
+		total += toSatang(line.transfer.Amount)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.UseCRLF = true
+
+	records := [][]string{{
+		"H", e.debitAccount, now.Format("20060102"), batch.BatchReference,
+		strconv.Itoa(len(lines)), formatSatang(total),
+	}}
+	for _, line := range lines {
+		records = append(records, []string{
+			"D", strconv.Itoa(line.seq), line.bankCode, line.account,
+			line.transfer.RecipientName, formatSatang(toSatang(line.transfer.Amount)), line.transfer.Reference,
+		})
+	}
+
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("failed to write SCB bulk file: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFile writes the file under a temporary name first so a half-written file is never uploaded
+func (e *BulkFileExecutor) writeFile(batch *domain.CashTransferBatch, content []byte, now time.Time) (string, error) {
+	if err := os.MkdirAll(e.dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create bulk file directory: %w", err)
+	}
+
+	ext := ".txt"
+	if e.format == FormatSCB {
+		ext = ".csv"
+	}
+	fileName := fmt.Sprintf("%s_%s_%s%s", strings.ToUpper(string(e.format)), batch.BatchReference, now.Format("20060102150405"), ext)
+	path := filepath.Join(e.dir, fileName)
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o640); err != nil {
+		return "", fmt.Errorf("failed to write bulk file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write bulk file: %w", err)
+	}
+
+	return fileName, nil
+}
+
+// padRight pads or truncates s to width characters
+func padRight(s string, width int) string {
+	n := utf8.RuneCountInString(s)
+	if n > width {
+		return string([]rune(s)[:width])
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+func formatSatang(satang int64) string {
+	return fmt.Sprintf("%d.%02d", satang/100, satang%100)
+}
@@ -0,0 +1,167 @@
+package banking
+
+import (
+	"context"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"finance/internal/domain"
+
+	"github.com/google/uuid"
+)
+
+func testBatch() *domain.CashTransferBatch {
+	return &domain.CashTransferBatch{ID: uuid.New(), BatchReference: "BATCH_20240115_103000"}
+}
+
+func testTransfer(name, bank, account string, amount float64) *domain.CashTransfer {
+	transfer := &domain.CashTransfer{
+		ID:               uuid.New(),
+		RecipientName:    name,
+		RecipientAccount: account,
+		Amount:           amount,
+		Currency:         "THB",
+		Reference:        "INV-001",
+	}
+	if bank != "" {
+		transfer.BankName = &bank
+	}
+	return transfer
+}
+
+func newTestBulkExecutor(t *testing.T, format BulkFileFormat) *BulkFileExecutor {
+	t.Helper()
+	executor, err := NewBulkFileExecutor(format, t.TempDir(), "123-4-56789-0")
+	if err != nil {
+		t.Fatalf("NewBulkFileExecutor() error = %v", err)
+	}
+	executor.now = func() time.Time { return time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC) }
+	return executor
+}
+
+func readBulkFile(t *testing.T, executor *BulkFileExecutor, result *domain.TransferResult) string {
+	t.Helper()
+	fileName := strings.SplitN(result.TransactionRef, "#", 2)[0]
+	content, err := os.ReadFile(filepath.Join(executor.dir, fileName))
+	if err != nil {
+		t.Fatalf("reading bulk file: %v", err)
+	}
+	return string(content)
+}
+
+func TestBulkFileExecutor_KBankFixedWidth(t *testing.T) {
+	executor := newTestBulkExecutor(t, FormatKBank)
+	transfers := []*domain.CashTransfer{
+		testTransfer("บริษัท ผักสด จำกัด", "SCB", "111-2-33333-4", 1250.50),
+		testTransfer("Somchai Jaidee", "kbank", "222-3-44444-5", 99.99),
+	}
+
+	results, err := executor.Execute(context.Background(), testBatch(), transfers)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	for _, result := range results {
+		if result.Status != domain.TransferStatusSubmitted {
+			t.Errorf("result %s status = %s, want submitted", result.TransferID, result.Status)
+		}
+	}
+	if results[1].TransactionRef != "KBANK_BATCH_20240115_103000_20240115103000.txt#2" {
+		t.Errorf("TransactionRef = %s", results[1].TransactionRef)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(readBulkFile(t, executor, results[0]), "\r\n"), "\r\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want header and 2 details", len(lines))
+	}
+
+	wantHeader := "H1234567890" + "15012024" + "BATCH_20240115_103000"[:20] + "000002" + "000000000135049"
+	if lines[0] != wantHeader {
+		t.Errorf("header = %q, want %q", lines[0], wantHeader)
+	}
+	for _, line := range lines[1:] {
+		if n := utf8.RuneCountInString(line); n != 107 {
+			t.Errorf("detail record is %d characters, want 107: %q", n, line)
+		}
+	}
+	if !strings.HasPrefix(lines[1], "D0000010141112333334  000000000125050บริษัท ผักสด จำกัด") {
+		t.Errorf("detail = %q", lines[1])
+	}
+}
+
+func TestBulkFileExecutor_SCBCSV(t *testing.T) {
+	executor := newTestBulkExecutor(t, FormatSCB)
+	transfers := []*domain.CashTransfer{
+		testTransfer("Fresh Veg, Ltd.", "Bangkok Bank", "1112223334", 500),
+	}
+
+	results, err := executor.Execute(context.Background(), testBatch(), transfers)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	reader := csv.NewReader(strings.NewReader(readBulkFile(t, executor, results[0])))
+	reader.FieldsPerRecord = -1 // header and detail rows differ
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("parsing CSV: %v", err)
+	}
+	want := [][]string{
+		{"H", "1234567890", "20240115", "BATCH_20240115_103000", "1", "500.00"},
+		{"D", "1", "002", "1112223334", "Fresh Veg, Ltd.", "500.00", "INV-001"},
+	}
+	for i := range want {
+		if strings.Join(records[i], "|") != strings.Join(want[i], "|") {
+			t.Errorf("record %d = %v, want %v", i, records[i], want[i])
+		}
+	}
+}
+
+func TestBulkFileExecutor_InvalidTransfersStayOutOfFile(t *testing.T) {
+	executor := newTestBulkExecutor(t, FormatKBank)
+	transfers := []*domain.CashTransfer{
+		testTransfer("No bank", "", "1112223334", 100),
+		testTransfer("Unknown bank", "Moon Bank", "1112223334", 100),
+		testTransfer("Short account", "SCB", "12345", 100),
+		testTransfer("Valid", "SCB", "1112223334", 100),
+	}
+
+	results, err := executor.Execute(context.Background(), testBatch(), transfers)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status == domain.TransferStatusFailed {
+			failed++
+			if result.FailureReason == "" {
+				t.Errorf("failed result %s has no reason", result.TransferID)
+			}
+		}
+	}
+	if failed != 3 {
+		t.Errorf("got %d failed results, want 3", failed)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(readBulkFile(t, executor, results[3]), "\r\n"), "\r\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "000001000000000010000") {
+		t.Errorf("file should hold only the valid transfer: %q", lines)
+	}
+}
+
+func TestNewBulkFileExecutor_Validation(t *testing.T) {
+	if _, err := NewBulkFileExecutor("bbl", t.TempDir(), "1234567890"); err == nil {
+		t.Error("expected error for unsupported format")
+	}
+	if _, err := NewBulkFileExecutor(FormatSCB, t.TempDir(), ""); err == nil {
+		t.Error("expected error for missing debit account")
+	}
+}
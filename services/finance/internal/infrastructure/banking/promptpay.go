@@ -0,0 +1,231 @@
+package banking
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"finance/internal/domain"
+)
+
+// PromptPay proxy types
+const (
+	ProxyMobile   = "MSISDN"
+	ProxyNationID = "NATID"
+	ProxyEWallet  = "EWALLETID"
+	ProxyAccount  = "ACCOUNT"
+)
+
+// PromptPay transfer statuses returned by the API
+const (
+	promptPaySettled  = "SETTLED"
+	promptPayAccepted = "ACCEPTED"
+	promptPayRejected = "REJECTED"
+)
+
+// PromptPayTransferRequest is the body of POST /v1/transfers
+type PromptPayTransferRequest struct {
+	RequestID     string `json:"request_id"`
+	ProxyType     string `json:"proxy_type"`
+	ProxyValue    string `json:"proxy_value"`
+	BankCode      string `json:"bank_code,omitempty"`
+	Amount        string `json:"amount"`
+	Currency      string `json:"currency"`
+	RecipientName string `json:"recipient_name"`
+	Reference     string `json:"reference"`
+}
+
+// PromptPayTransferResponse is the result of a transfer request
+type PromptPayTransferResponse struct {
+	TransactionRef string     `json:"transaction_ref"`
+	Status         string     `json:"status"`
+	ReasonCode     string     `json:"reason_code,omitempty"`
+	ReasonMessage  string     `json:"reason_message,omitempty"`
+	SettledAt      *time.Time `json:"settled_at,omitempty"`
+}
+
+type promptPayError struct {
+	Error string `json:"error"`
+}
+
+// PromptPayExecutor sends each transfer to an ITMX-style PromptPay transfer API. The transfer
+// ID and attempt are sent as the idempotency key, so retrying a batch never pays a transfer
+// twice, while a transfer the bank rejected is retried as a new attempt instead of being
+// answered with the stored rejection.
+type PromptPayExecutor struct {
+	baseURL string
+	apiKey  string
+	client  *http.Client
+	now     func() time.Time
+}
+
+// NewPromptPayExecutor creates a PromptPay API adapter
+func NewPromptPayExecutor(baseURL, apiKey string, timeout time.Duration) *PromptPayExecutor {
+	return &PromptPayExecutor{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+		client:  &http.Client{Timeout: timeout},
+		now:     time.Now,
+	}
+}
+
+// Name returns the executor name
+func (e *PromptPayExecutor) Name() string {
+	return "promptpay"
+}
+
+// Execute sends the transfers one by one; a failed transfer does not stop the rest
+func (e *PromptPayExecutor) Execute(ctx context.Context, batch *domain.CashTransferBatch, transfers []*domain.CashTransfer) ([]*domain.TransferResult, error) {
+	results := make([]*domain.TransferResult, 0, len(transfers))
+	for _, transfer := range transfers {
+		if err := ctx.Err(); err != nil {
+			results = append(results, failedResult(transfer, "not sent: "+err.Error()))
+			continue
+		}
+		results = append(results, e.send(ctx, transfer))
+	}
+	return results, nil
+}
+
+func (e *PromptPayExecutor) send(ctx context.Context, transfer *domain.CashTransfer) *domain.TransferResult {
+	req, err := e.buildRequest(transfer)
+	if err != nil {
+		return failedResult(transfer, err.Error())
+	}
+
+	resp, err := e.post(ctx, req)
+	if err != nil {
+		return failedResult(transfer, err.Error())
+	}
+
+	result := &domain.TransferResult{
+		TransferID:     transfer.ID,
+		TransactionRef: resp.TransactionRef,
+	}
+	switch resp.Status {
+	case promptPaySettled:
+		result.Status = domain.TransferStatusCompleted
+		confirmedAt := e.now()
+		if resp.SettledAt != nil {
+			confirmedAt = *resp.SettledAt
+		}
+		result.ConfirmedAt = &confirmedAt
+	case promptPayAccepted:
+		result.Status = domain.TransferStatusSubmitted
+	case promptPayRejected:
+		result.Status = domain.TransferStatusFailed
+		result.Rejected = true
+		result.FailureReason = strings.TrimPrefix(resp.ReasonCode+": "+resp.ReasonMessage, ": ")
+	default:
+		result.Status = domain.TransferStatusFailed
+		result.FailureReason = fmt.Sprintf("unknown PromptPay status %q", resp.Status)
+	}
+	return result
+}
+
+// buildRequest pays to a bank account when the bank is known, otherwise to the PromptPay
+// proxy in the recipient account
+func (e *PromptPayExecutor) buildRequest(transfer *domain.CashTransfer) (*PromptPayTransferRequest, error) {
+	if err := validateAmount(transfer); err != nil {
+		return nil, err
+	}
+
+	req := &PromptPayTransferRequest{
+		RequestID:     idempotencyKey(transfer),
+		Amount:        formatSatang(toSatang(transfer.Amount)),
+		Currency:      "THB",
+		RecipientName: transfer.RecipientName,
+		Reference:     transfer.Reference,
+	}
+
+	if transfer.BankName != nil && *transfer.BankName != "" {
+		code, err := transferBankCode(transfer)
+		if err != nil {
+			return nil, err
+		}
+		account := transferAccount(transfer)
+		if err := validateBankAccount(account); err != nil {
+			return nil, err
+		}
+		req.ProxyType = ProxyAccount
+		req.ProxyValue = account
+		req.BankCode = code
+		return req, nil
+	}
+
+	proxyType, err := proxyTypeOf(digitsOnly(transfer.RecipientAccount))
+	if err != nil {
+		return nil, err
+	}
+	req.ProxyType = proxyType
+	req.ProxyValue = digitsOnly(transfer.RecipientAccount)
+	return req, nil
+}
+
+// idempotencyKey identifies an attempt at a transfer. The first attempt keeps the bare transfer
+// ID, which is what transfers sent before attempts were counted used.
+func idempotencyKey(transfer *domain.CashTransfer) string {
+	if transfer.Attempt <= 1 {
+		return transfer.ID.String()
+	}
+	return fmt.Sprintf("%s-%d", transfer.ID, transfer.Attempt)
+}
+
+func (e *PromptPayExecutor) post(ctx context.Context, body *PromptPayTransferRequest) (*PromptPayTransferResponse, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/v1/transfers", bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+e.apiKey)
+	httpReq.Header.Set("Idempotency-Key", body.RequestID)
+
+	httpResp, err := e.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("PromptPay request failed: %w", err)
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(io.LimitReader(httpResp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PromptPay response: %w", err)
+	}
+
+	if httpResp.StatusCode >= 300 {
+		var apiErr promptPayError
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error != "" {
+			return nil, fmt.Errorf("PromptPay returned %d: %s", httpResp.StatusCode, apiErr.Error)
+		}
+		return nil, fmt.Errorf("PromptPay returned %d", httpResp.StatusCode)
+	}
+
+	var resp PromptPayTransferResponse
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return nil, fmt.Errorf("invalid PromptPay response: %w", err)
+	}
+	return &resp, nil
+}
+
+// proxyTypeOf tells a mobile number, national ID and e-wallet ID apart by length
+func proxyTypeOf(proxy string) (string, error) {
+	switch {
+	case len(proxy) == 10 && strings.HasPrefix(proxy, "0"):
+		return ProxyMobile, nil
+	case len(proxy) == 13:
+		return ProxyNationID, nil
+	case len(proxy) == 15:
+		return ProxyEWallet, nil
+	default:
+		return "", fmt.Errorf("%w: %q is not a PromptPay ID", domain.ErrInvalidAccountNumber, proxy)
+	}
+}
@@ -0,0 +1,112 @@
+package banking
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PromptPayStub is a local stand-in for the PromptPay transfer API. It answers like the
+// real API based on the proxy value:
+//
+//	ending in 0000 - REJECTED, proxy not registered
+//	ending in 1111 - ACCEPTED, settlement pending
+//	anything else  - SETTLED
+//
+// Responses are replayed for a repeated Idempotency-Key.
+type PromptPayStub struct {
+	apiKey string
+	now    func() time.Time
+
+	mu        sync.Mutex
+	seq       int
+	responses map[string]*PromptPayTransferResponse
+	requests  int
+}
+
+// NewPromptPayStub creates a stub that accepts requests carrying apiKey
+func NewPromptPayStub(apiKey string) *PromptPayStub {
+	return &PromptPayStub{
+		apiKey:    apiKey,
+		now:       time.Now,
+		responses: make(map[string]*PromptPayTransferResponse),
+	}
+}
+
+// Requests returns the number of transfers the stub has processed, replays excluded
+func (s *PromptPayStub) Requests() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.requests
+}
+
+// ServeHTTP handles POST /v1/transfers
+func (s *PromptPayStub) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.URL.Path != "/v1/transfers" {
+		writeStubJSON(w, http.StatusNotFound, promptPayError{Error: "not found"})
+		return
+	}
+	if r.Header.Get("Authorization") != "Bearer "+s.apiKey {
+		writeStubJSON(w, http.StatusUnauthorized, promptPayError{Error: "invalid API key"})
+		return
+	}
+
+	var req PromptPayTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeStubJSON(w, http.StatusBadRequest, promptPayError{Error: "invalid request body"})
+		return
+	}
+	amount, err := strconv.ParseFloat(req.Amount, 64)
+	if err != nil || amount <= 0 {
+		writeStubJSON(w, http.StatusBadRequest, promptPayError{Error: "invalid amount"})
+		return
+	}
+	if req.ProxyValue == "" || req.ProxyType == "" {
+		writeStubJSON(w, http.StatusBadRequest, promptPayError{Error: "proxy is required"})
+		return
+	}
+
+	key := r.Header.Get("Idempotency-Key")
+	if key == "" {
+		key = req.RequestID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if resp, ok := s.responses[key]; ok {
+		writeStubJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	s.requests++
+	s.seq++
+	now := s.now()
+	resp := &PromptPayTransferResponse{
+		TransactionRef: fmt.Sprintf("ITMX%s%08d", now.Format("20060102"), s.seq),
+	}
+	switch {
+	case strings.HasSuffix(req.ProxyValue, "0000"):
+		resp.Status = promptPayRejected
+		resp.ReasonCode = "PROXY_NOT_FOUND"
+		resp.ReasonMessage = "proxy is not registered for PromptPay"
+	case strings.HasSuffix(req.ProxyValue, "1111"):
+		resp.Status = promptPayAccepted
+	default:
+		resp.Status = promptPaySettled
+		resp.SettledAt = &now
+	}
+	s.responses[key] = resp
+
+	writeStubJSON(w, http.StatusOK, resp)
+}
+
+func writeStubJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
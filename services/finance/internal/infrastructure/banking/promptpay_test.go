@@ -0,0 +1,112 @@
+package banking
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"finance/internal/domain"
+)
+
+func newTestPromptPay(t *testing.T) (*PromptPayExecutor, *PromptPayStub) {
+	t.Helper()
+	stub := NewPromptPayStub("test-key")
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+	return NewPromptPayExecutor(server.URL, "test-key", 5*time.Second), stub
+}
+
+func TestPromptPayExecutor_Results(t *testing.T) {
+	executor, _ := newTestPromptPay(t)
+	transfers := []*domain.CashTransfer{
+		testTransfer("Settled mobile", "", "081-234-5678", 250),
+		testTransfer("Pending account", "KTB", "123-4-51111-1", 1500),
+		testTransfer("Unknown proxy", "", "0812340000", 10),
+		testTransfer("Bad proxy", "", "12345", 10),
+	}
+
+	results, err := executor.Execute(context.Background(), testBatch(), transfers)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	settled := results[0]
+	if settled.Status != domain.TransferStatusCompleted || settled.ConfirmedAt == nil || !strings.HasPrefix(settled.TransactionRef, "ITMX") {
+		t.Errorf("settled = %+v, want completed with ITMX reference", settled)
+	}
+
+	if pending := results[1]; pending.Status != domain.TransferStatusSubmitted || pending.ConfirmedAt != nil {
+		t.Errorf("pending = %+v, want submitted", pending)
+	}
+
+	if rejected := results[2]; rejected.Status != domain.TransferStatusFailed || !strings.HasPrefix(rejected.FailureReason, "PROXY_NOT_FOUND") {
+		t.Errorf("rejected = %+v, want failed with PROXY_NOT_FOUND", rejected)
+	}
+
+	if invalid := results[3]; invalid.Status != domain.TransferStatusFailed || invalid.TransactionRef != "" {
+		t.Errorf("invalid = %+v, want failed before sending", invalid)
+	}
+}
+
+func TestPromptPayExecutor_RetryIsIdempotent(t *testing.T) {
+	executor, stub := newTestPromptPay(t)
+	transfer := testTransfer("Somchai", "", "0812345678", 100)
+
+	first, _ := executor.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+	second, _ := executor.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+
+	if first[0].TransactionRef != second[0].TransactionRef {
+		t.Errorf("retry got reference %s, want %s", second[0].TransactionRef, first[0].TransactionRef)
+	}
+	if stub.Requests() != 1 {
+		t.Errorf("stub processed %d transfers, want 1", stub.Requests())
+	}
+}
+
+func TestPromptPayExecutor_RejectedTransferIsRetriedAsNewAttempt(t *testing.T) {
+	executor, stub := newTestPromptPay(t)
+	transfer := testTransfer("Unknown proxy", "", "0812340000", 10)
+	transfer.Attempt = 1
+
+	first, _ := executor.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+	if !first[0].Rejected {
+		t.Fatalf("first = %+v, want rejected", first[0])
+	}
+
+	// The same attempt is answered with the stored rejection
+	executor.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+	if stub.Requests() != 1 {
+		t.Fatalf("stub processed %d transfers for one attempt, want 1", stub.Requests())
+	}
+
+	transfer.Attempt = 2
+	executor.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+	if stub.Requests() != 2 {
+		t.Errorf("stub processed %d transfers after a new attempt, want 2", stub.Requests())
+	}
+}
+
+func TestPromptPayExecutor_HTTPErrors(t *testing.T) {
+	transfer := testTransfer("Somchai", "", "0812345678", 100)
+
+	unauthorized, _ := newTestPromptPay(t)
+	unauthorized.apiKey = "wrong"
+	results, _ := unauthorized.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+	if results[0].Status != domain.TransferStatusFailed || !strings.Contains(results[0].FailureReason, "401") {
+		t.Errorf("unauthorized result = %+v, want failed with 401", results[0])
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	down := NewPromptPayExecutor(server.URL, "test-key", time.Second)
+	results, _ = down.Execute(context.Background(), testBatch(), []*domain.CashTransfer{transfer})
+	if results[0].Status != domain.TransferStatusFailed || !strings.Contains(results[0].FailureReason, "502") {
+		t.Errorf("unavailable result = %+v, want failed with 502", results[0])
+	}
+}
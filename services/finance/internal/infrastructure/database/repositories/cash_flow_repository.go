@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"database/sql"
+	"time"
 
 	"finance/internal/domain"
 
@@ -43,6 +44,42 @@ func (r *cashFlowRepository) Create(record *domain.CashFlowRecord) error {
 	return err
 }
 
+// CreateOnce inserts the record unless its entity already has a record with the same reference,
+// and reports whether it was inserted
+func (r *cashFlowRepository) CreateOnce(record *domain.CashFlowRecord) (bool, error) {
+	query := `
+		INSERT INTO cash_flow_records (
+			id, entity_type, entity_id, transaction_type, amount, description,
+			reference, running_balance, created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10
+		)
+		ON CONFLICT (entity_type, entity_id, reference) WHERE reference <> '' DO NOTHING`
+
+	result, err := r.db.Exec(query,
+		record.ID,
+		record.EntityType,
+		record.EntityID,
+		record.TransactionType,
+		record.Amount,
+		record.Description,
+		record.Reference,
+		record.RunningBalance,
+		record.CreatedBy,
+		record.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
 func (r *cashFlowRepository) GetByEntity(entityType string, entityID uuid.UUID, limit int) ([]*domain.CashFlowRecord, error) {
 	query := `
 		SELECT 
@@ -135,6 +172,41 @@ func (r *cashFlowRepository) GetByID(id uuid.UUID) (*domain.CashFlowRecord, erro
 	return record, nil
 }
 
+// GetByReference retrieves the cash flow record of an entity with the given reference
+func (r *cashFlowRepository) GetByReference(entityType string, entityID uuid.UUID, reference string) (*domain.CashFlowRecord, error) {
+	query := `
+		SELECT 
+			id, entity_type, entity_id, transaction_type, amount, description,
+			reference, running_balance, created_by, created_at
+		FROM cash_flow_records 
+		WHERE entity_type = $1 AND entity_id = $2 AND reference = $3
+		ORDER BY created_at DESC
+		LIMIT 1`
+
+	record := &domain.CashFlowRecord{}
+	err := r.db.QueryRow(query, entityType, entityID, reference).Scan(
+		&record.ID,
+		&record.EntityType,
+		&record.EntityID,
+		&record.TransactionType,
+		&record.Amount,
+		&record.Description,
+		&record.Reference,
+		&record.RunningBalance,
+		&record.CreatedBy,
+		&record.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrCashFlowNotFound
+		}
+		return nil, err
+	}
+
+	return record, nil
+}
+
 func (r *cashFlowRepository) GetBalanceHistory(entityType string, entityID uuid.UUID, limit int) ([]*domain.CashFlowRecord, error) {
 	query := `
 		SELECT 
@@ -222,3 +294,40 @@ func (r *cashFlowRepository) GetTotalOutflows(entityType string, entityID uuid.U
 
 	return total, nil
 }
+
+// GetTotalsByEntity sums inflows and outflows per entity recorded before the given time, for one
+// entity, or for every entity when entityID is nil
+func (r *cashFlowRepository) GetTotalsByEntity(before time.Time, entityType string, entityID *uuid.UUID) ([]*domain.CashFlowTotals, error) {
+	query := `
+		SELECT 
+			entity_type, entity_id,
+			COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'inflow'), 0),
+			COALESCE(SUM(amount) FILTER (WHERE transaction_type = 'outflow'), 0)
+		FROM cash_flow_records 
+		WHERE created_at < $1
+		AND ($3::uuid IS NULL OR (entity_type = $2 AND entity_id = $3))
+		GROUP BY entity_type, entity_id`
+
+	rows, err := r.db.Query(query, before, entityType, entityID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.CashFlowTotals
+	for rows.Next() {
+		total := &domain.CashFlowTotals{}
+		err := rows.Scan(
+			&total.EntityType,
+			&total.EntityID,
+			&total.Inflows,
+			&total.Outflows,
+		)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
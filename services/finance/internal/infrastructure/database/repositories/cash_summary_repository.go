@@ -293,3 +293,53 @@ func (r *cashSummaryRepository) GetByDateRange(startDate, endDate time.Time, bra
 
 	return summaries, nil
 }
+
+// GetAllocationTotals sums the allocations per entity for business dates up to asOf, for one
+// branch or vehicle, or for every entity when both IDs are nil
+func (r *cashSummaryRepository) GetAllocationTotals(asOf time.Time, branchID, vehicleID *uuid.UUID) ([]*domain.AllocationTotals, error) {
+	query := `
+		SELECT 
+			branch_id, vehicle_id,
+			COALESCE(SUM(total_sales), 0),
+			COALESCE(SUM(profit_allocation), 0),
+			COALESCE(SUM(owner_pay_allocation), 0),
+			COALESCE(SUM(tax_allocation), 0),
+			COALESCE(SUM(available_for_expenses), 0),
+			COALESCE(SUM(manual_expenses), 0),
+			COALESCE(SUM(supplier_transfers), 0),
+			COALESCE(SUM(other_transfers), 0)
+		FROM daily_cash_summaries 
+		WHERE business_date <= $1
+		AND ($2::uuid IS NULL OR branch_id = $2) 
+		AND ($3::uuid IS NULL OR vehicle_id = $3)
+		GROUP BY branch_id, vehicle_id`
+
+	rows, err := r.db.Query(query, asOf, branchID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.AllocationTotals
+	for rows.Next() {
+		total := &domain.AllocationTotals{}
+		err := rows.Scan(
+			&total.BranchID,
+			&total.VehicleID,
+			&total.TotalSales,
+			&total.ProfitAllocation,
+			&total.OwnerPayAllocation,
+			&total.TaxAllocation,
+			&total.AvailableForExpenses,
+			&total.ManualExpenses,
+			&total.SupplierTransfers,
+			&total.OtherTransfers,
+		)
+		if err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
@@ -0,0 +1,430 @@
+package repositories
+
+import (
+	"database/sql"
+	"time"
+
+	"finance/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ledgerRepository struct {
+	db *sql.DB
+}
+
+func NewLedgerRepository(db *sql.DB) domain.LedgerRepository {
+	return &ledgerRepository{
+		db: db,
+	}
+}
+
+func (r *ledgerRepository) GetAccounts() ([]*domain.LedgerAccount, error) {
+	query := `
+		SELECT id, code, name, type, account_type, is_active, created_at
+		FROM ledger_accounts
+		ORDER BY code ASC`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var accounts []*domain.LedgerAccount
+	for rows.Next() {
+		account, err := scanLedgerAccount(rows)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	return accounts, rows.Err()
+}
+
+func (r *ledgerRepository) GetAccountByCode(code string) (*domain.LedgerAccount, error) {
+	query := `
+		SELECT id, code, name, type, account_type, is_active, created_at
+		FROM ledger_accounts
+		WHERE code = $1`
+
+	account, err := scanLedgerAccount(r.db.QueryRow(query, code))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrLedgerAccountNotFound
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+func (r *ledgerRepository) CreateJournalEntry(entry *domain.JournalEntry) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Hold a share lock on the period so it cannot be closed while the entry is written
+	status, _, err := lockPeriod(tx, entry.EntryDate, "FOR SHARE")
+	if err != nil {
+		return err
+	}
+	if status == domain.PeriodClosed {
+		return domain.ErrPeriodClosed
+	}
+
+	if err := insertJournalEntry(tx, entry); err != nil {
+		return err
+	}
+
+	return commitJournal(tx)
+}
+
+func (r *ledgerRepository) GetJournalEntryBySource(sourceType string, sourceID uuid.UUID) (*domain.JournalEntry, error) {
+	query := `
+		SELECT id, entry_date, description, source_type, source_id, branch_id, vehicle_id,
+			created_by, created_at
+		FROM journal_entries
+		WHERE source_type = $1 AND source_id = $2`
+
+	entry := &domain.JournalEntry{}
+	err := r.db.QueryRow(query, sourceType, sourceID).Scan(
+		&entry.ID,
+		&entry.EntryDate,
+		&entry.Description,
+		&entry.SourceType,
+		&entry.SourceID,
+		&entry.BranchID,
+		&entry.VehicleID,
+		&entry.CreatedBy,
+		&entry.CreatedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrJournalEntryNotFound
+		}
+		return nil, err
+	}
+
+	postingsQuery := `
+		SELECT id, entry_id, account_code, debit, credit, COALESCE(description, '')
+		FROM journal_postings
+		WHERE entry_id = $1`
+
+	rows, err := r.db.Query(postingsQuery, entry.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		posting := &domain.Posting{}
+		if err := rows.Scan(
+			&posting.ID,
+			&posting.EntryID,
+			&posting.AccountCode,
+			&posting.Debit,
+			&posting.Credit,
+			&posting.Description,
+		); err != nil {
+			return nil, err
+		}
+		entry.Postings = append(entry.Postings, posting)
+	}
+
+	return entry, rows.Err()
+}
+
+func (r *ledgerRepository) GetAccountTotals(from, to time.Time) ([]*domain.AccountTotals, error) {
+	return queryAccountTotals(r.db, from, to)
+}
+
+// GetAccountBalance returns debits minus credits of the account up to asOf
+func (r *ledgerRepository) GetAccountBalance(code string, asOf time.Time, branchID, vehicleID *uuid.UUID) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(p.debit), 0) - COALESCE(SUM(p.credit), 0)
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.account_code = $1 AND e.entry_date <= $2
+		AND ($3::uuid IS NULL OR e.branch_id = $3)
+		AND ($4::uuid IS NULL OR e.vehicle_id = $4)`
+
+	var balance float64
+	if err := r.db.QueryRow(query, code, asOf, branchID, vehicleID).Scan(&balance); err != nil {
+		return 0, err
+	}
+
+	return balance, nil
+}
+
+func (r *ledgerRepository) GetStatementLines(code string, from, to time.Time, branchID, vehicleID *uuid.UUID) ([]*domain.StatementLine, error) {
+	query := `
+		SELECT e.id, e.entry_date, COALESCE(NULLIF(p.description, ''), e.description), e.source_type,
+			p.debit, p.credit
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE p.account_code = $1 AND e.entry_date >= $2 AND e.entry_date <= $3
+		AND ($4::uuid IS NULL OR e.branch_id = $4)
+		AND ($5::uuid IS NULL OR e.vehicle_id = $5)
+		ORDER BY e.entry_date ASC, e.created_at ASC`
+
+	rows, err := r.db.Query(query, code, from, to, branchID, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []*domain.StatementLine
+	for rows.Next() {
+		line := &domain.StatementLine{}
+		if err := rows.Scan(
+			&line.EntryID,
+			&line.EntryDate,
+			&line.Description,
+			&line.SourceType,
+			&line.Debit,
+			&line.Credit,
+		); err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
+
+func (r *ledgerRepository) GetPeriod(periodStart time.Time) (*domain.AccountingPeriod, error) {
+	query := `
+		SELECT id, period_start, period_end, status, closing_entry_id, closed_by, closed_at
+		FROM accounting_periods
+		WHERE period_start = $1`
+
+	period := &domain.AccountingPeriod{}
+	err := r.db.QueryRow(query, periodStart).Scan(
+		&period.ID,
+		&period.PeriodStart,
+		&period.PeriodEnd,
+		&period.Status,
+		&period.ClosingEntryID,
+		&period.ClosedBy,
+		&period.ClosedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrPeriodNotFound
+		}
+		return nil, err
+	}
+
+	return period, nil
+}
+
+func (r *ledgerRepository) ClosePeriod(period *domain.AccountingPeriod, buildClosing func(totals []*domain.AccountTotals) (*domain.JournalEntry, error)) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The update lock waits for entries being posted into the period and blocks new ones
+	status, periodID, err := lockPeriod(tx, period.PeriodStart, "FOR UPDATE")
+	if err != nil {
+		return err
+	}
+	if status == domain.PeriodClosed {
+		return domain.ErrPeriodClosed
+	}
+
+	totals, err := queryAccountTotals(tx, period.PeriodStart, period.PeriodEnd)
+	if err != nil {
+		return err
+	}
+
+	closing, err := buildClosing(totals)
+	if err != nil {
+		return err
+	}
+	if closing != nil {
+		if err := insertJournalEntry(tx, closing); err != nil {
+			return err
+		}
+		period.ClosingEntryID = &closing.ID
+	}
+
+	now := time.Now()
+	query := `
+		UPDATE accounting_periods
+		SET status = 'closed',
+			closing_entry_id = $2,
+			closed_by = $3,
+			closed_at = $4
+		WHERE id = $1`
+
+	if _, err := tx.Exec(query, periodID, period.ClosingEntryID, period.ClosedBy, now); err != nil {
+		return err
+	}
+
+	if err := commitJournal(tx); err != nil {
+		return err
+	}
+
+	period.ID = periodID
+	period.Status = domain.PeriodClosed
+	period.ClosedAt = &now
+	return nil
+}
+
+// queryer is satisfied by both *sql.DB and *sql.Tx
+type queryer interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func queryAccountTotals(q queryer, from, to time.Time) ([]*domain.AccountTotals, error) {
+	query := `
+		SELECT p.account_code, COALESCE(SUM(p.debit), 0), COALESCE(SUM(p.credit), 0)
+		FROM journal_postings p
+		JOIN journal_entries e ON e.id = p.entry_id
+		WHERE e.entry_date >= $1 AND e.entry_date <= $2
+		GROUP BY p.account_code
+		ORDER BY p.account_code ASC`
+
+	rows, err := q.Query(query, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var totals []*domain.AccountTotals
+	for rows.Next() {
+		total := &domain.AccountTotals{}
+		if err := rows.Scan(&total.AccountCode, &total.Debit, &total.Credit); err != nil {
+			return nil, err
+		}
+		totals = append(totals, total)
+	}
+
+	return totals, rows.Err()
+}
+
+// lockPeriod creates the period containing date if needed and locks its row
+func lockPeriod(tx *sql.Tx, date time.Time, lock string) (string, uuid.UUID, error) {
+	start, end := domain.PeriodBounds(date)
+
+	insert := `
+		INSERT INTO accounting_periods (id, period_start, period_end, status)
+		VALUES ($1, $2, $3, 'open')
+		ON CONFLICT (period_start) DO NOTHING`
+
+	if _, err := tx.Exec(insert, uuid.New(), start, end); err != nil {
+		return "", uuid.Nil, err
+	}
+
+	var status string
+	var id uuid.UUID
+	query := `SELECT id, status FROM accounting_periods WHERE period_start = $1 ` + lock
+	if err := tx.QueryRow(query, start).Scan(&id, &status); err != nil {
+		return "", uuid.Nil, err
+	}
+
+	return status, id, nil
+}
+
+func insertJournalEntry(tx *sql.Tx, entry *domain.JournalEntry) error {
+	query := `
+		INSERT INTO journal_entries (
+			id, entry_date, description, source_type, source_id, branch_id, vehicle_id,
+			created_by, created_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9
+		)`
+
+	_, err := tx.Exec(query,
+		entry.ID,
+		entry.EntryDate,
+		entry.Description,
+		entry.SourceType,
+		entry.SourceID,
+		entry.BranchID,
+		entry.VehicleID,
+		entry.CreatedBy,
+		entry.CreatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok {
+			switch pqErr.Code {
+			case "23505": // unique_violation
+				return domain.ErrDuplicateJournalEntry
+			}
+		}
+		return err
+	}
+
+	postingQuery := `
+		INSERT INTO journal_postings (id, entry_id, account_code, debit, credit, description)
+		VALUES ($1, $2, $3, $4, $5, $6)`
+
+	for _, posting := range entry.Postings {
+		_, err := tx.Exec(postingQuery,
+			posting.ID,
+			entry.ID,
+			posting.AccountCode,
+			posting.Debit,
+			posting.Credit,
+			posting.Description,
+		)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok {
+				switch pqErr.Code {
+				case "23503": // foreign_key_violation
+					return domain.ErrLedgerAccountNotFound
+				case "23514": // check_violation
+					return domain.ErrInvalidPosting
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// commitJournal commits the transaction, where the deferred balance trigger runs
+func commitJournal(tx *sql.Tx) error {
+	if err := tx.Commit(); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "P0001" { // raise_exception
+			return domain.ErrUnbalancedEntry
+		}
+		return err
+	}
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanLedgerAccount(row rowScanner) (*domain.LedgerAccount, error) {
+	account := &domain.LedgerAccount{}
+	var accountType sql.NullString
+	err := row.Scan(
+		&account.ID,
+		&account.Code,
+		&account.Name,
+		&account.Type,
+		&accountType,
+		&account.IsActive,
+		&account.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if accountType.Valid {
+		t := domain.AccountType(accountType.String)
+		account.AccountType = &t
+	}
+	return account, nil
+}
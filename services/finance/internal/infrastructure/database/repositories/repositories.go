@@ -13,6 +13,7 @@ type Repositories struct {
 	Transfer       domain.TransferRepository
 	Expense        domain.ExpenseRepository
 	CashFlow       domain.CashFlowRepository
+	Ledger         domain.LedgerRepository
 }
 
 // NewRepositories creates and returns all repository instances
@@ -23,5 +24,6 @@ func NewRepositories(db *sql.DB) *Repositories {
 		Transfer:       NewTransferRepository(db),
 		Expense:        NewExpenseRepository(db),
 		CashFlow:       NewCashFlowRepository(db),
+		Ledger:         NewLedgerRepository(db),
 	}
 }
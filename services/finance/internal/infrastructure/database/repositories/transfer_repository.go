@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"database/sql"
+	"time"
 
 	"finance/internal/domain"
 
@@ -54,9 +55,9 @@ func (r *transferRepository) CreateTransfer(transfer *domain.CashTransfer) error
 			id, batch_id, transfer_type, recipient_name, recipient_account, amount,
 			currency, reference, description, status, bank_name, account_number,
 			transaction_ref, scheduled_at, executed_at, confirmed_at, failure_reason,
-			created_by, created_at, updated_at
+			attempt, created_by, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
 		)`
 
 	_, err := r.db.Exec(query,
@@ -77,6 +78,7 @@ func (r *transferRepository) CreateTransfer(transfer *domain.CashTransfer) error
 		transfer.ExecutedAt,
 		transfer.ConfirmedAt,
 		transfer.FailureReason,
+		transfer.Attempt,
 		transfer.CreatedBy,
 		transfer.CreatedAt,
 		transfer.UpdatedAt,
@@ -128,7 +130,7 @@ func (r *transferRepository) GetTransfersByBatch(batchID uuid.UUID) ([]*domain.C
 			id, batch_id, transfer_type, recipient_name, recipient_account, amount,
 			currency, reference, description, status, bank_name, account_number,
 			transaction_ref, scheduled_at, executed_at, confirmed_at, failure_reason,
-			created_by, created_at, updated_at
+			attempt, created_by, created_at, updated_at
 		FROM cash_transfers 
 		WHERE batch_id = $1
 		ORDER BY created_at ASC`
@@ -160,6 +162,7 @@ func (r *transferRepository) GetTransfersByBatch(batchID uuid.UUID) ([]*domain.C
 			&transfer.ExecutedAt,
 			&transfer.ConfirmedAt,
 			&transfer.FailureReason,
+			&transfer.Attempt,
 			&transfer.CreatedBy,
 			&transfer.CreatedAt,
 			&transfer.UpdatedAt,
@@ -201,6 +204,7 @@ func (r *transferRepository) UpdateBatchStatus(id uuid.UUID, status string) erro
 	query := `
 		UPDATE cash_transfer_batches 
 		SET status = $2,
+			completed_at = CASE WHEN $2 = 'completed' THEN CURRENT_TIMESTAMP ELSE completed_at END,
 			updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1`
 
@@ -227,7 +231,7 @@ func (r *transferRepository) GetTransferByID(id uuid.UUID) (*domain.CashTransfer
 			id, batch_id, transfer_type, recipient_name, recipient_account, amount,
 			currency, reference, description, status, bank_name, account_number,
 			transaction_ref, scheduled_at, executed_at, confirmed_at, failure_reason,
-			created_by, created_at, updated_at
+			attempt, created_by, created_at, updated_at
 		FROM cash_transfers 
 		WHERE id = $1`
 
@@ -250,6 +254,7 @@ func (r *transferRepository) GetTransferByID(id uuid.UUID) (*domain.CashTransfer
 		&transfer.ExecutedAt,
 		&transfer.ConfirmedAt,
 		&transfer.FailureReason,
+		&transfer.Attempt,
 		&transfer.CreatedBy,
 		&transfer.CreatedAt,
 		&transfer.UpdatedAt,
@@ -308,3 +313,85 @@ func (r *transferRepository) GetPendingBatches() ([]*domain.CashTransferBatch, e
 
 	return batches, nil
 }
+
+// ClaimBatch moves the batch to processing only if it is still in fromStatus, so two
+// executions of the same batch cannot run at once
+func (r *transferRepository) ClaimBatch(id uuid.UUID, fromStatus string) (bool, error) {
+	query := `
+		UPDATE cash_transfer_batches 
+		SET status = 'processing',
+			processed_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $2`
+
+	result, err := r.db.Exec(query, id, fromStatus)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// ReclaimStaleBatch claims a batch left processing since before staleBefore. Claiming it again
+// moves processed_at on, so only one execution can take over a stale batch.
+func (r *transferRepository) ReclaimStaleBatch(id uuid.UUID, staleBefore time.Time) (bool, error) {
+	query := `
+		UPDATE cash_transfer_batches 
+		SET processed_at = CURRENT_TIMESTAMP,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'processing' AND processed_at < $2`
+
+	result, err := r.db.Exec(query, id, staleBefore)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+func (r *transferRepository) UpdateTransferResult(transfer *domain.CashTransfer) error {
+	query := `
+		UPDATE cash_transfers 
+		SET status = $2,
+			transaction_ref = $3,
+			executed_at = $4,
+			confirmed_at = $5,
+			failure_reason = $6,
+			attempt = $7,
+			updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1`
+
+	result, err := r.db.Exec(query,
+		transfer.ID,
+		transfer.Status,
+		transfer.TransactionRef,
+		transfer.ExecutedAt,
+		transfer.ConfirmedAt,
+		transfer.FailureReason,
+		transfer.Attempt,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrTransferNotFound
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package http
 
 import (
+	"errors"
 	"net/http"
 	"time"
 
@@ -14,13 +15,15 @@ type FinanceHandler struct {
 	financeService    domain.FinanceService
 	allocationService domain.AllocationService
 	cashFlowService   domain.CashFlowService
+	ledgerService     domain.LedgerService
 }
 
-func NewRouter(financeService domain.FinanceService, allocationService domain.AllocationService, cashFlowService domain.CashFlowService) *gin.Engine {
+func NewRouter(financeService domain.FinanceService, allocationService domain.AllocationService, cashFlowService domain.CashFlowService, ledgerService domain.LedgerService) *gin.Engine {
 	handler := &FinanceHandler{
 		financeService:    financeService,
 		allocationService: allocationService,
 		cashFlowService:   cashFlowService,
+		ledgerService:     ledgerService,
 	}
 
 	router := gin.Default()
@@ -44,15 +47,26 @@ func NewRouter(financeService domain.FinanceService, allocationService domain.Al
 		// Cash transfers
 		api.POST("/transfer-batches", handler.CreateTransferBatch)
 		api.POST("/transfer-batches/:id/execute", handler.ExecuteTransferBatch)
+		api.GET("/transfer-batches/:id", handler.GetTransferBatch)
+		api.POST("/transfers/:id/confirm", handler.ConfirmTransfer)
+		api.POST("/transfers/:id/reject", handler.RejectTransfer)
 		
 		// Cash status
 		api.GET("/cash-status", handler.GetCashStatus)
 		api.GET("/entities/:type/:id/cash-flow", handler.GetEntityCashFlow)
+		api.POST("/entities/:type/:id/cash-flow", handler.RecordCashFlow)
 		api.GET("/entities/:type/:id/balance", handler.GetCurrentBalance)
 		
 		// Profit allocations
 		api.GET("/allocation-rules", handler.GetAllocationRule)
 		api.PUT("/allocation-rules", handler.UpdateAllocationRule)
+
+		// General ledger
+		api.GET("/ledger/accounts", handler.GetLedgerAccounts)
+		api.GET("/ledger/accounts/:code/statement", handler.GetAccountStatement)
+		api.GET("/ledger/trial-balance", handler.GetTrialBalance)
+		api.POST("/ledger/journal-entries", handler.PostJournalEntry)
+		api.POST("/ledger/periods/close", handler.ClosePeriod)
 	}
 
 	return router
@@ -80,7 +94,7 @@ func (h *FinanceHandler) ProcessEndOfDay(c *gin.Context) {
 
 	summary, err := h.financeService.ProcessEndOfDay(date, req.BranchID, req.VehicleID, req.Sales, req.CODCollections)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -108,7 +122,7 @@ func (h *FinanceHandler) AddExpense(c *gin.Context) {
 	}
 
 	if err := h.financeService.AddExpenseEntry(summaryID, req.Category, req.Description, req.Amount, req.EnteredBy); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -134,7 +148,7 @@ func (h *FinanceHandler) ReconcileCash(c *gin.Context) {
 	}
 
 	if err := h.financeService.ReconcileCash(summaryID, req.ActualCash, req.ReconciledBy); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -172,16 +186,125 @@ func (h *FinanceHandler) ExecuteTransferBatch(c *gin.Context) {
 	}
 
 	if err := h.financeService.ExecuteTransferBatch(batchID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	h.writeTransferBatch(c, batchID)
+}
+
+func (h *FinanceHandler) GetTransferBatch(c *gin.Context) {
+	batchID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid batch id"})
+		return
+	}
+
+	h.writeTransferBatch(c, batchID)
+}
+
+func (h *FinanceHandler) ConfirmTransfer(c *gin.Context) {
+	transferID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer id"})
+		return
+	}
+
+	var req struct {
+		TransactionRef string `json:"transaction_ref"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.financeService.ConfirmTransfer(transferID, req.TransactionRef); err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "transfer confirmed"})
+}
+
+func (h *FinanceHandler) RejectTransfer(c *gin.Context) {
+	transferID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid transfer id"})
+		return
+	}
+
+	var req struct {
+		FailureReason string `json:"failure_reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.financeService.RejectTransfer(transferID, req.FailureReason); err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "transfer batch executed"})
+	c.JSON(http.StatusOK, gin.H{"message": "transfer rejected"})
+}
+
+func (h *FinanceHandler) writeTransferBatch(c *gin.Context, batchID uuid.UUID) {
+	batch, transfers, err := h.financeService.GetTransferBatch(batchID)
+	if err != nil {
+		c.JSON(transferErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"batch":     batch,
+		"transfers": transfers,
+	})
+}
+
+// transferErrorStatus maps transfer errors to HTTP status codes
+func transferErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrTransferBatchNotFound), errors.Is(err, domain.ErrTransferNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrTransferInProgress), errors.Is(err, domain.ErrBatchNotRetryable),
+		errors.Is(err, domain.ErrTransferNotSubmitted):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
 }
 
 func (h *FinanceHandler) GetCashStatus(c *gin.Context) {
-	status, err := h.financeService.GetCashStatus()
+	var asOf time.Time
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		date, err := time.ParseInLocation("2006-01-02", asOfStr, time.Local)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid as_of date format"})
+			return
+		}
+		asOf = date
+	}
+
+	branchID, err := optionalUUIDQuery(c, "branch_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid branch_id"})
+		return
+	}
+	vehicleID, err := optionalUUIDQuery(c, "vehicle_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle_id"})
+		return
+	}
+
+	status, err := h.financeService.GetCashStatus(asOf, branchID, vehicleID)
 	if err != nil {
+		if errors.Is(err, domain.ErrInvalidEntity) || errors.Is(err, domain.ErrInvalidDate) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
@@ -208,6 +331,47 @@ func (h *FinanceHandler) GetEntityCashFlow(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"cash_flow": cashFlow})
 }
 
+// RecordCashFlow records a cash movement that happened outside end of day processing, such as
+// a customer refund. A repeated reference returns the record that is already there.
+func (h *FinanceHandler) RecordCashFlow(c *gin.Context) {
+	entityType := c.Param("type")
+	entityID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entity id"})
+		return
+	}
+
+	var req struct {
+		TransactionType domain.CashFlowType `json:"transaction_type" binding:"required,oneof=inflow outflow"`
+		Amount          float64             `json:"amount" binding:"required,gt=0"`
+		Description     string              `json:"description" binding:"required"`
+		Reference       string              `json:"reference" binding:"required,max=100"`
+		CreatedBy       *uuid.UUID          `json:"created_by,omitempty"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	record, created, err := h.cashFlowService.RecordTransactionOnce(entityType, entityID, req.TransactionType, req.Amount, req.Description, req.Reference, req.CreatedBy)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidEntity) || errors.Is(err, domain.ErrInvalidAmount) ||
+			errors.Is(err, domain.ErrMissingDescription) || errors.Is(err, domain.ErrMissingReference) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	c.JSON(status, record)
+}
+
 func (h *FinanceHandler) GetCurrentBalance(c *gin.Context) {
 	entityType := c.Param("type")
 	entityIDStr := c.Param("id")
@@ -268,3 +432,155 @@ func (h *FinanceHandler) UpdateAllocationRule(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"message": "allocation rule updated"})
 }
+
+func (h *FinanceHandler) GetLedgerAccounts(c *gin.Context) {
+	accounts, err := h.ledgerService.GetAccounts()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"accounts": accounts})
+}
+
+func (h *FinanceHandler) GetTrialBalance(c *gin.Context) {
+	asOf := time.Now()
+	if asOfStr := c.Query("as_of"); asOfStr != "" {
+		date, err := time.Parse("2006-01-02", asOfStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid as_of date format"})
+			return
+		}
+		asOf = date
+	}
+
+	trialBalance, err := h.ledgerService.GetTrialBalance(asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, trialBalance)
+}
+
+func (h *FinanceHandler) GetAccountStatement(c *gin.Context) {
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from date format"})
+		return
+	}
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to date format"})
+		return
+	}
+
+	branchID, err := optionalUUIDQuery(c, "branch_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid branch_id"})
+		return
+	}
+	vehicleID, err := optionalUUIDQuery(c, "vehicle_id")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid vehicle_id"})
+		return
+	}
+
+	statement, err := h.ledgerService.GetAccountStatement(c.Param("code"), from, to, branchID, vehicleID)
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, statement)
+}
+
+func (h *FinanceHandler) PostJournalEntry(c *gin.Context) {
+	var req struct {
+		EntryDate   string            `json:"entry_date" binding:"required"`
+		Description string            `json:"description" binding:"required"`
+		BranchID    *uuid.UUID        `json:"branch_id,omitempty"`
+		VehicleID   *uuid.UUID        `json:"vehicle_id,omitempty"`
+		Postings    []*domain.Posting `json:"postings" binding:"required"`
+		CreatedBy   uuid.UUID         `json:"created_by" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entryDate, err := time.Parse("2006-01-02", req.EntryDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid entry_date format"})
+		return
+	}
+
+	entry := &domain.JournalEntry{
+		EntryDate:   entryDate,
+		Description: req.Description,
+		SourceType:  domain.JournalSourceManual,
+		BranchID:    req.BranchID,
+		VehicleID:   req.VehicleID,
+		Postings:    req.Postings,
+		CreatedBy:   &req.CreatedBy,
+	}
+
+	if err := h.ledgerService.PostJournal(entry); err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, entry)
+}
+
+func (h *FinanceHandler) ClosePeriod(c *gin.Context) {
+	var req struct {
+		Year     int       `json:"year" binding:"required"`
+		Month    int       `json:"month" binding:"required,min=1,max=12"`
+		ClosedBy uuid.UUID `json:"closed_by" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	period, err := h.ledgerService.ClosePeriod(req.Year, time.Month(req.Month), req.ClosedBy)
+	if err != nil {
+		c.JSON(ledgerErrorStatus(err), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, period)
+}
+
+// ledgerErrorStatus maps ledger errors, including those of operations that post journals, to
+// HTTP status codes
+func ledgerErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrLedgerAccountNotFound), errors.Is(err, domain.ErrCashSummaryNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrUnbalancedEntry), errors.Is(err, domain.ErrInvalidPosting),
+		errors.Is(err, domain.ErrInactiveLedgerAccount), errors.Is(err, domain.ErrMissingDescription),
+		errors.Is(err, domain.ErrInvalidDateRange):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrPeriodClosed), errors.Is(err, domain.ErrDuplicateJournalEntry),
+		errors.Is(err, domain.ErrCannotModifyReconciled):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func optionalUUIDQuery(c *gin.Context, name string) (*uuid.UUID, error) {
+	value := c.Query(name)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, err
+	}
+	return &id, nil
+}
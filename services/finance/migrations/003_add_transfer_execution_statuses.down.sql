@@ -0,0 +1,17 @@
+-- Remove transfer execution statuses for Finance Service
+-- Migration: 003_add_transfer_execution_statuses.down.sql
+
+DROP INDEX IF EXISTS idx_transfers_transaction_ref;
+
+UPDATE cash_transfers SET status = 'processing' WHERE status = 'submitted';
+UPDATE cash_transfer_batches SET status = 'processing' WHERE status IN ('submitted', 'partial');
+
+ALTER TABLE cash_transfers DROP CONSTRAINT check_transfer_status;
+ALTER TABLE cash_transfers ADD CONSTRAINT check_transfer_status CHECK (
+    status IN ('pending', 'processing', 'completed', 'failed', 'cancelled')
+);
+
+ALTER TABLE cash_transfer_batches DROP CONSTRAINT check_batch_status;
+ALTER TABLE cash_transfer_batches ADD CONSTRAINT check_batch_status CHECK (
+    status IN ('pending', 'processing', 'completed', 'failed', 'cancelled')
+);
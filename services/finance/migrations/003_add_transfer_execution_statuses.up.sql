@@ -0,0 +1,16 @@
+-- Transfer execution statuses for Finance Service
+-- Migration: 003_add_transfer_execution_statuses.up.sql
+
+-- Bank file transfers stay submitted until the bank confirms them, and a batch with
+-- failed transfers is partial until they are retried
+ALTER TABLE cash_transfer_batches DROP CONSTRAINT check_batch_status;
+ALTER TABLE cash_transfer_batches ADD CONSTRAINT check_batch_status CHECK (
+    status IN ('pending', 'processing', 'submitted', 'partial', 'completed', 'failed', 'cancelled')
+);
+
+ALTER TABLE cash_transfers DROP CONSTRAINT check_transfer_status;
+ALTER TABLE cash_transfers ADD CONSTRAINT check_transfer_status CHECK (
+    status IN ('pending', 'processing', 'submitted', 'completed', 'failed', 'cancelled')
+);
+
+CREATE INDEX idx_transfers_transaction_ref ON cash_transfers(transaction_ref) WHERE transaction_ref IS NOT NULL;
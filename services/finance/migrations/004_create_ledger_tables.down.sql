@@ -0,0 +1,10 @@
+-- Remove double-entry general ledger for Finance Service
+-- Migration: 004_create_ledger_tables.down.sql
+
+DROP TRIGGER IF EXISTS journal_postings_balanced ON journal_postings;
+DROP FUNCTION IF EXISTS check_journal_entry_balanced();
+
+DROP TABLE IF EXISTS accounting_periods;
+DROP TABLE IF EXISTS journal_postings;
+DROP TABLE IF EXISTS journal_entries;
+DROP TABLE IF EXISTS ledger_accounts;
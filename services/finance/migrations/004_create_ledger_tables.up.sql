@@ -0,0 +1,111 @@
+-- Double-entry general ledger for Finance Service
+-- Migration: 004_create_ledger_tables.up.sql
+
+-- Chart of Accounts Table
+CREATE TABLE ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    code VARCHAR(20) UNIQUE NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    account_type VARCHAR(20), -- Profit First account the ledger account represents
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT check_ledger_account_type CHECK (
+        type IN ('asset', 'liability', 'equity', 'revenue', 'expense')
+    )
+);
+
+-- Journal Entries Table
+CREATE TABLE journal_entries (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entry_date DATE NOT NULL,
+    description TEXT NOT NULL,
+    source_type VARCHAR(30) NOT NULL,
+    source_id UUID NOT NULL,
+    branch_id UUID,
+    vehicle_id UUID,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- Constraints
+    CONSTRAINT unique_journal_source UNIQUE (source_type, source_id),
+    CONSTRAINT check_journal_entity CHECK (
+        (branch_id IS NOT NULL AND vehicle_id IS NULL) OR
+        (branch_id IS NULL AND vehicle_id IS NOT NULL) OR
+        (branch_id IS NULL AND vehicle_id IS NULL)
+    )
+);
+
+-- Journal Postings Table
+CREATE TABLE journal_postings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    entry_id UUID NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_code VARCHAR(20) NOT NULL REFERENCES ledger_accounts(code),
+    debit DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    credit DECIMAL(15,2) NOT NULL DEFAULT 0.00,
+    description TEXT,
+
+    -- Constraints
+    CONSTRAINT check_posting_side CHECK (
+        debit >= 0 AND credit >= 0 AND ((debit > 0) <> (credit > 0))
+    )
+);
+
+-- Accounting Periods Table
+CREATE TABLE accounting_periods (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    period_start DATE UNIQUE NOT NULL,
+    period_end DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open',
+    closing_entry_id UUID REFERENCES journal_entries(id),
+    closed_by UUID,
+    closed_at TIMESTAMP WITH TIME ZONE,
+
+    -- Constraints
+    CONSTRAINT check_period_status CHECK (status IN ('open', 'closed')),
+    CONSTRAINT check_period_dates CHECK (period_end >= period_start)
+);
+
+CREATE INDEX idx_journal_entries_date ON journal_entries(entry_date);
+CREATE INDEX idx_journal_entries_branch ON journal_entries(branch_id);
+CREATE INDEX idx_journal_entries_vehicle ON journal_entries(vehicle_id);
+CREATE INDEX idx_journal_postings_entry ON journal_postings(entry_id);
+CREATE INDEX idx_journal_postings_account ON journal_postings(account_code);
+
+-- Every journal entry must balance once its transaction commits
+CREATE OR REPLACE FUNCTION check_journal_entry_balanced()
+RETURNS TRIGGER AS $$
+DECLARE
+    difference DECIMAL(15,2);
+BEGIN
+    SELECT COALESCE(SUM(debit), 0) - COALESCE(SUM(credit), 0) INTO difference
+    FROM journal_postings
+    WHERE entry_id = NEW.entry_id;
+
+    IF difference <> 0 THEN
+        RAISE EXCEPTION 'journal entry % is unbalanced by %', NEW.entry_id, difference;
+    END IF;
+    RETURN NULL;
+END;
+$$ language 'plpgsql';
+
+CREATE CONSTRAINT TRIGGER journal_postings_balanced
+    AFTER INSERT OR UPDATE ON journal_postings
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW EXECUTE FUNCTION check_journal_entry_balanced();
+
+-- Chart of accounts
+INSERT INTO ledger_accounts (code, name, type, account_type) VALUES
+    ('1000', 'Operating Cash', 'asset', 'operating'),
+    ('1110', 'Profit Account', 'asset', 'profit'),
+    ('1120', 'Owner Pay Account', 'asset', 'owner_pay'),
+    ('1130', 'Tax Account', 'asset', 'tax'),
+    ('1300', 'Central Clearing', 'asset', NULL),
+    ('3100', 'Retained Earnings', 'equity', NULL),
+    ('4000', 'Sales Revenue', 'revenue', 'revenue'),
+    ('5000', 'Operating Expenses', 'expense', NULL),
+    ('5100', 'Cash Over and Short', 'expense', NULL),
+    ('5200', 'Supplier Payments', 'expense', NULL)
+ON CONFLICT (code) DO NOTHING;
@@ -0,0 +1,4 @@
+-- Remove cash flow reference lookups for Finance Service
+-- Migration: 005_add_cash_flow_reference_index.down.sql
+
+DROP INDEX IF EXISTS idx_cash_flow_reference;
@@ -0,0 +1,7 @@
+-- Cash flow reference lookups for Finance Service
+-- Migration: 005_add_cash_flow_reference_index.up.sql
+
+-- Cash flows recorded by other services are unique per entity and reference so retries, even
+-- concurrent ones, are not recorded twice
+CREATE UNIQUE INDEX idx_cash_flow_reference ON cash_flow_records(entity_type, entity_id, reference)
+    WHERE reference <> '';
@@ -0,0 +1,4 @@
+-- Remove transfer attempts for Finance Service
+-- Migration: 006_add_transfer_attempts.down.sql
+
+ALTER TABLE cash_transfers DROP COLUMN IF EXISTS attempt;
@@ -0,0 +1,6 @@
+-- Transfer attempts for Finance Service
+-- Migration: 006_add_transfer_attempts.up.sql
+
+-- A transfer the bank rejected is sent again as a new attempt with its own idempotency key, so
+-- the bank does not answer the retry with the stored rejection
+ALTER TABLE cash_transfers ADD COLUMN attempt INTEGER NOT NULL DEFAULT 1;
@@ -82,8 +82,21 @@ func main() {
 		eventPublisher.Close()
 	}()
 
+	// Demand forecasting refreshes reorder suggestions in the background
+	forecastService := application.NewForecastService(
+		database.NewInventoryRepository(dbConn),
+		database.NewAnalyticsRepository(dbConn),
+		database.NewForecastRepository(dbConn),
+		logger,
+	)
+	forecastCtx, stopForecasting := context.WithCancel(context.Background())
+	defer stopForecasting()
+	if cfg.Forecast.Enabled {
+		go forecastService.Start(forecastCtx, cfg.Forecast.Interval)
+	}
+
 	// Initialize HTTP router with custom routes
-	router := routes.SetupRoutes(redisClient, dbConn, kafkaConsumer, logger)
+	router := routes.SetupRoutes(redisClient, dbConn, kafkaConsumer, eventPublisher, forecastService, logger)
 	
 	// Add direct product upsert endpoint (bypassing Kafka)
 	router.POST("/api/v1/products/upsert", gin.HandlerFunc(func(c *gin.Context) {
@@ -0,0 +1,302 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"inventory/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultLeadTimeDays is the lead time assumed for products without a known supplier
+	defaultLeadTimeDays = 3
+	// coverDays is how many days of demand a reorder should cover after it arrives
+	coverDays = 7
+)
+
+type AnalyticsService struct {
+	repo      domain.AnalyticsRepository
+	inventory domain.InventoryRepository
+	forecasts domain.ForecastRepository
+	logger    *logrus.Logger
+}
+
+func NewAnalyticsService(repo domain.AnalyticsRepository, inventory domain.InventoryRepository, forecasts domain.ForecastRepository, logger *logrus.Logger) *AnalyticsService {
+	return &AnalyticsService{
+		repo:      repo,
+		inventory: inventory,
+		forecasts: forecasts,
+		logger:    logger,
+	}
+}
+
+// GetDashboard returns the stock summary with the last 30 days' top products and categories,
+// the last week's daily movements and the last four weeks' trends
+func (s *AnalyticsService) GetDashboard(ctx context.Context) (*domain.Analytics, error) {
+	now := time.Now()
+	today := startOfDay(now)
+
+	analytics, err := s.repo.GetStockSummary(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if analytics.TopSellingProducts, err = s.GetProductPerformance(ctx, today.AddDate(0, 0, -29), now, 5); err != nil {
+		return nil, err
+	}
+	categories, err := s.GetCategoryPerformance(ctx, today.AddDate(0, 0, -29), now)
+	if err != nil {
+		return nil, err
+	}
+	if len(categories) > 5 {
+		categories = categories[:5]
+	}
+	analytics.TopCategories = categories
+
+	if analytics.DailyMovements, err = s.GetDailyTrends(ctx, today.AddDate(0, 0, -6), now); err != nil {
+		return nil, err
+	}
+	if analytics.WeeklyTrends, err = s.GetWeeklyTrends(ctx, 4); err != nil {
+		return nil, err
+	}
+
+	analytics.GeneratedAt = now
+	return analytics, nil
+}
+
+// GetProductPerformance ranks the best selling products between from and to. Turnover is the
+// quantity sold over the stock on hand.
+func (s *AnalyticsService) GetProductPerformance(ctx context.Context, from, to time.Time, limit int) ([]domain.ProductPerformance, error) {
+	if to.Before(from) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	performance, err := s.repo.GetProductPerformance(ctx, from, to, pageSize(limit))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range performance {
+		performance[i].Rank = i + 1
+		if performance[i].CurrentStock > 0 {
+			performance[i].TurnoverRate = round2(performance[i].TotalSold / performance[i].CurrentStock)
+		}
+	}
+
+	return performance, nil
+}
+
+// GetCategoryPerformance ranks categories by revenue between from and to
+func (s *AnalyticsService) GetCategoryPerformance(ctx context.Context, from, to time.Time) ([]domain.CategoryPerformance, error) {
+	if to.Before(from) {
+		return nil, domain.ErrInvalidDateRange
+	}
+
+	performance, err := s.repo.GetCategoryPerformance(ctx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(performance, func(i, j int) bool {
+		if performance[i].Revenue != performance[j].Revenue {
+			return performance[i].Revenue > performance[j].Revenue
+		}
+		return performance[i].CategoryName < performance[j].CategoryName
+	})
+	for i := range performance {
+		performance[i].Rank = i + 1
+		performance[i].AvgTurnover = round2(performance[i].AvgTurnover)
+	}
+
+	return performance, nil
+}
+
+// GetDailyTrends returns movement totals per day between from and to, newest first
+func (s *AnalyticsService) GetDailyTrends(ctx context.Context, from, to time.Time) ([]domain.DailyMovement, error) {
+	if to.Before(from) {
+		return nil, domain.ErrInvalidDateRange
+	}
+	return s.repo.GetDailyMovements(ctx, from, to)
+}
+
+// GetWeeklyTrends returns sales of the last weeks, this week included, newest first, with growth
+// over the week before in percent
+func (s *AnalyticsService) GetWeeklyTrends(ctx context.Context, weeks int) ([]domain.WeeklyTrend, error) {
+	if weeks <= 0 {
+		weeks = 4
+	}
+
+	now := time.Now()
+	thisWeek := startOfWeek(now)
+	// Fetch one extra week so the oldest week returned has a growth rate
+	trends, err := s.repo.GetWeeklyTrends(ctx, thisWeek.AddDate(0, 0, -7*weeks), now)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 1; i < len(trends); i++ {
+		previous := trends[i-1]
+		if previous.Revenue > 0 && trends[i].WeekStart.Sub(previous.WeekStart) <= 7*24*time.Hour+time.Hour {
+			trends[i].GrowthRate = round2((trends[i].Revenue - previous.Revenue) / previous.Revenue * 100)
+		}
+	}
+
+	var result []domain.WeeklyTrend
+	for i := len(trends) - 1; i >= 0; i-- {
+		if trends[i].WeekStart.Before(thisWeek.AddDate(0, 0, -7*(weeks-1))) {
+			break
+		}
+		result = append(result, trends[i])
+	}
+
+	return result, nil
+}
+
+// GetReorderSuggestions returns the ranked suggestions of the latest forecast run, optionally
+// for one store
+func (s *AnalyticsService) GetReorderSuggestions(ctx context.Context, storeID string) ([]domain.ReorderSuggestion, error) {
+	if storeID != "" {
+		if _, err := s.inventory.GetStore(ctx, storeID); err != nil {
+			return nil, err
+		}
+	}
+	return s.forecasts.ListReorderSuggestions(ctx, storeID)
+}
+
+// GetStockAlerts lists stock that is out, low or forecast to run out within its supplier's lead
+// time, with the days of stock left at the forecast demand
+func (s *AnalyticsService) GetStockAlerts(ctx context.Context, storeID string) ([]domain.StockAlert, error) {
+	if storeID != "" {
+		if _, err := s.inventory.GetStore(ctx, storeID); err != nil {
+			return nil, err
+		}
+	}
+
+	positions, err := s.inventory.GetStockPositions(ctx, storeID, false)
+	if err != nil {
+		return nil, err
+	}
+	forecasts, err := s.forecasts.ListForecasts(ctx, storeID)
+	if err != nil {
+		return nil, err
+	}
+	leadTimes, err := s.forecasts.GetLeadTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]*domain.DemandForecast, len(forecasts))
+	for i := range forecasts {
+		byKey[demandKey(forecasts[i].ProductID, forecasts[i].StoreID)] = &forecasts[i]
+	}
+
+	now := time.Now()
+	today := startOfDay(now)
+	var alerts []domain.StockAlert
+	for _, position := range positions {
+		leadTime := defaultLeadTimeDays
+		if days, ok := leadTimes[position.ProductID]; ok {
+			leadTime = days
+		}
+
+		alert := domain.StockAlert{
+			ID:             demandKey(position.ProductID, position.StoreID),
+			ProductID:      position.ProductID,
+			ProductName:    position.ProductName,
+			StoreID:        position.StoreID,
+			StoreName:      position.StoreName,
+			CurrentStock:   position.QuantityOnHand,
+			ReorderLevel:   position.ReorderLevel,
+			DaysToStockout: -1,
+			CreatedAt:      now,
+		}
+		if position.QuantityOnHand <= 0 {
+			alert.DaysToStockout = 0
+		}
+		if forecast, ok := byKey[alert.ID]; ok {
+			alert.DaysToStockout = forecast.DaysToStockout(position.QuantityOnHand, today)
+			alert.SuggestedOrder = math.Max(math.Ceil(forecast.Demand(today, leadTime+coverDays)-position.QuantityOnHand), 0)
+			alert.LastSaleDate = forecast.LastSaleDate
+		}
+		days := alert.DaysToStockout
+
+		switch {
+		case position.QuantityOnHand <= 0:
+			alert.Type, alert.Severity = domain.AlertOutOfStock, domain.SeverityHigh
+			alert.Message = fmt.Sprintf("%s is out of stock at %s", position.ProductName, position.StoreName)
+		case position.QuantityOnHand <= position.ReorderLevel:
+			alert.Type, alert.Severity = domain.AlertLowStock, domain.SeverityMedium
+			if days >= 0 && days <= leadTime {
+				alert.Severity = domain.SeverityHigh
+			}
+			alert.Message = fmt.Sprintf("%s is low at %s: %.2f left", position.ProductName, position.StoreName, position.QuantityOnHand)
+		case days >= 0 && days <= leadTime:
+			alert.Type, alert.Severity = "REORDER_SUGGESTION", domain.SeverityLow
+			alert.Message = fmt.Sprintf("%s will run out at %s in about %d days, within the %d day lead time",
+				position.ProductName, position.StoreName, days, leadTime)
+		default:
+			continue
+		}
+
+		alerts = append(alerts, alert)
+	}
+
+	sort.SliceStable(alerts, func(i, j int) bool {
+		return severityRank(alerts[i].Severity) < severityRank(alerts[j].Severity)
+	})
+
+	return alerts, nil
+}
+
+// reorderPriority ranks urgency from 1 (runs out within the lead time) to 5; days is -1 when
+// no demand is forecast
+func reorderPriority(days, leadTime int) int {
+	switch {
+	case days < 0:
+		return 5
+	case days <= leadTime:
+		return 1
+	case days <= leadTime+2:
+		return 2
+	case days <= leadTime+7:
+		return 3
+	case days <= leadTime+14:
+		return 4
+	default:
+		return 5
+	}
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case domain.SeverityHigh:
+		return 1
+	case domain.SeverityMedium:
+		return 2
+	default:
+		return 3
+	}
+}
+
+func demandKey(productID, storeID string) string {
+	return productID + ":" + storeID
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// startOfWeek returns the Monday starting the week of t, matching date_trunc('week')
+func startOfWeek(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return startOfDay(t).AddDate(0, 0, -offset)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
@@ -0,0 +1,281 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"inventory/internal/domain"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// forecastHistoryDays is the sales history demand models are fitted on
+	forecastHistoryDays = 56
+	// forecastHorizonDays is how many days ahead demand is forecast
+	forecastHorizonDays = 28
+	// serviceLevelZ sizes safety stock for a 95% chance of not running out during the lead time
+	serviceLevelZ = 1.65
+	// maeToStdDev converts a mean absolute error to a standard deviation for normal errors
+	maeToStdDev = 1.25
+	// trendUpWeekly is the weekly growth, relative to current demand, that counts as a rising trend
+	trendUpWeekly = 0.1
+	// seasonalPeak is how far demand over the lead time must exceed an average week's to count
+	// as a seasonal peak
+	seasonalPeak = 1.15
+)
+
+// ForecastRun summarizes a forecasting run
+type ForecastRun struct {
+	Forecasts   int       `json:"forecasts"`
+	Suggestions int       `json:"suggestions"`
+	GeneratedAt time.Time `json:"generated_at"`
+}
+
+// ForecastService fits a demand model to the sales of every product at every store and turns
+// the forecasts into ranked reorder suggestions
+type ForecastService struct {
+	inventory domain.InventoryRepository
+	analytics domain.AnalyticsRepository
+	forecasts domain.ForecastRepository
+	logger    *logrus.Logger
+	now       func() time.Time
+}
+
+func NewForecastService(inventory domain.InventoryRepository, analytics domain.AnalyticsRepository, forecasts domain.ForecastRepository, logger *logrus.Logger) *ForecastService {
+	return &ForecastService{
+		inventory: inventory,
+		analytics: analytics,
+		forecasts: forecasts,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Start runs a forecast straight away and then every interval until the context is cancelled
+func (s *ForecastService) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	s.logger.WithField("interval", interval).Info("Demand forecasting started")
+
+	for {
+		if _, err := s.Run(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).Error("Demand forecast run failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Demand forecasting stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Run forecasts demand from today's start using the sales up to yesterday and replaces the
+// stored forecasts and reorder suggestions
+func (s *ForecastService) Run(ctx context.Context) (*ForecastRun, error) {
+	now := s.now()
+	today := startOfDay(now)
+	from := today.AddDate(0, 0, -forecastHistoryDays)
+
+	positions, err := s.inventory.GetStockPositions(ctx, "", false)
+	if err != nil {
+		return nil, err
+	}
+	sales, err := s.analytics.GetDailySales(ctx, from, today)
+	if err != nil {
+		return nil, err
+	}
+	leadTimes, err := s.forecasts.GetLeadTimes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	history := salesHistory(sales, from)
+
+	var forecasts []domain.DemandForecast
+	var suggestions []domain.ReorderSuggestion
+	for _, position := range positions {
+		var forecast *domain.DemandForecast
+		if series, ok := history[demandKey(position.ProductID, position.StoreID)]; ok {
+			forecast = series.forecast(position, today, now)
+			forecasts = append(forecasts, *forecast)
+		}
+
+		leadTime := defaultLeadTimeDays
+		if days, ok := leadTimes[position.ProductID]; ok {
+			leadTime = days
+		}
+		if suggestion := reorderSuggestion(position, forecast, leadTime, today, now); suggestion != nil {
+			suggestions = append(suggestions, *suggestion)
+		}
+	}
+	rankSuggestions(suggestions)
+
+	if err := s.forecasts.SaveForecastRun(ctx, forecasts, suggestions); err != nil {
+		return nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"forecasts":   len(forecasts),
+		"suggestions": len(suggestions),
+	}).Info("Demand forecast run completed")
+
+	return &ForecastRun{
+		Forecasts:   len(forecasts),
+		Suggestions: len(suggestions),
+		GeneratedAt: now,
+	}, nil
+}
+
+// UpsertSupplier creates or updates a supplier and its lead time
+func (s *ForecastService) UpsertSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	if supplier.ID == "" {
+		return fmt.Errorf("%w: id is required", domain.ErrInvalidSupplier)
+	}
+	if supplier.LeadTimeDays <= 0 {
+		return fmt.Errorf("%w: lead time must be at least one day", domain.ErrInvalidSupplier)
+	}
+
+	supplier.UpdatedAt = s.now()
+	return s.forecasts.UpsertSupplier(ctx, supplier)
+}
+
+// salesSeries is the daily sales of a product at a store over the forecast history
+type salesSeries struct {
+	daily    []float64
+	first    int
+	lastSale *time.Time
+}
+
+// salesHistory turns daily sales into a series per product and store, with zero for days
+// without sales
+func salesHistory(sales []domain.DailySales, from time.Time) map[string]*salesSeries {
+	history := make(map[string]*salesSeries)
+	for _, day := range sales {
+		index := int(math.Round(startOfDay(day.Date).Sub(from).Hours() / 24))
+		if index < 0 || index >= forecastHistoryDays {
+			continue
+		}
+
+		key := demandKey(day.ProductID, day.StoreID)
+		series, ok := history[key]
+		if !ok {
+			series = &salesSeries{daily: make([]float64, forecastHistoryDays), first: index}
+			history[key] = series
+		}
+
+		series.daily[index] += day.Quantity
+		if index < series.first {
+			series.first = index
+		}
+		if day.Quantity > 0 && (series.lastSale == nil || day.Date.After(*series.lastSale)) {
+			date := day.Date
+			series.lastSale = &date
+		}
+	}
+	return history
+}
+
+// forecast fits the series from its first sale, so products that started selling recently
+// are not averaged down by the days before they were stocked
+func (s *salesSeries) forecast(position domain.StockPosition, today, now time.Time) *domain.DemandForecast {
+	model := fitDemandModel(s.daily[s.first:])
+
+	return &domain.DemandForecast{
+		ProductID:    position.ProductID,
+		StoreID:      position.StoreID,
+		StartDate:    today,
+		Daily:        model.forecast(forecastHorizonDays),
+		TrendPerDay:  model.trend,
+		MeanAbsError: model.meanAbsError,
+		Confidence:   model.confidence(),
+		LastSaleDate: s.lastSale,
+		GeneratedAt:  now,
+	}
+}
+
+// reorderSuggestion suggests an order when stock is at its reorder level or will not cover the
+// forecast demand over the lead time plus safety stock. The order covers the lead time and
+// coverDays more. It returns nil when no order is needed.
+func reorderSuggestion(position domain.StockPosition, forecast *domain.DemandForecast, leadTime int, today, now time.Time) *domain.ReorderSuggestion {
+	onHand := position.QuantityOnHand
+	needsOrder := onHand <= position.ReorderLevel
+
+	estimatedDemand, safetyStock, confidence := 0.0, 0.0, 0.5
+	days := -1
+	if forecast != nil {
+		safetyStock = serviceLevelZ * maeToStdDev * forecast.MeanAbsError * math.Sqrt(float64(leadTime))
+		if onHand <= forecast.Demand(today, leadTime)+safetyStock {
+			needsOrder = true
+		}
+		estimatedDemand = forecast.Demand(today, leadTime+coverDays)
+		confidence = forecast.Confidence
+		days = forecast.DaysToStockout(onHand, today)
+	} else if onHand <= 0 {
+		days = 0
+	}
+	if !needsOrder {
+		return nil
+	}
+
+	target := math.Max(estimatedDemand+safetyStock, position.ReorderLevel*2)
+	if position.MaxStock > 0 && target > position.MaxStock {
+		target = position.MaxStock
+	}
+	quantity := math.Ceil(target - onHand)
+	if quantity <= 0 {
+		return nil
+	}
+
+	return &domain.ReorderSuggestion{
+		ProductID:       position.ProductID,
+		ProductName:     position.ProductName,
+		StoreID:         position.StoreID,
+		StoreName:       position.StoreName,
+		CurrentStock:    onHand,
+		SuggestedQty:    quantity,
+		ReasonCode:      reorderReason(position, forecast, leadTime, today),
+		Confidence:      confidence,
+		EstimatedCost:   round2(quantity * position.CostPrice),
+		EstimatedDemand: round2(estimatedDemand),
+		LeadTimeDays:    leadTime,
+		Priority:        reorderPriority(days, leadTime),
+		CreatedAt:       now,
+	}
+}
+
+// reorderReason explains a suggestion. Stock above its reorder level is ordered because demand
+// is rising or the lead time falls on the busy days of the week.
+func reorderReason(position domain.StockPosition, forecast *domain.DemandForecast, leadTime int, today time.Time) string {
+	if forecast == nil || position.QuantityOnHand <= position.ReorderLevel {
+		return domain.ReasonLowStock
+	}
+
+	weeklyAverage := forecast.Demand(today, 7) / 7
+	if weeklyAverage <= 0 {
+		return domain.ReasonLowStock
+	}
+	if forecast.TrendPerDay*7 >= trendUpWeekly*weeklyAverage {
+		return domain.ReasonTrendUp
+	}
+	if leadTime < 7 && forecast.Demand(today, leadTime)/float64(leadTime) >= seasonalPeak*weeklyAverage {
+		return domain.ReasonSeasonal
+	}
+	return domain.ReasonLowStock
+}
+
+// rankSuggestions orders suggestions by priority and then by what is at stake
+func rankSuggestions(suggestions []domain.ReorderSuggestion) {
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		if suggestions[i].Priority != suggestions[j].Priority {
+			return suggestions[i].Priority < suggestions[j].Priority
+		}
+		return suggestions[i].EstimatedCost > suggestions[j].EstimatedCost
+	})
+}
@@ -0,0 +1,131 @@
+package application
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"inventory/internal/domain"
+)
+
+// weeklySeries repeats the weekly pattern for the given weeks, adding slope per day
+func weeklySeries(pattern []float64, weeks int, slope float64) []float64 {
+	series := make([]float64, 0, weeks*len(pattern))
+	for day := 0; day < weeks*len(pattern); day++ {
+		series = append(series, pattern[day%len(pattern)]+slope*float64(day))
+	}
+	return series
+}
+
+func forecastFrom(series []float64, today time.Time) *domain.DemandForecast {
+	model := fitDemandModel(series)
+	return &domain.DemandForecast{
+		ProductID:    "P1",
+		StoreID:      "S1",
+		StartDate:    today,
+		Daily:        model.forecast(forecastHorizonDays),
+		TrendPerDay:  model.trend,
+		MeanAbsError: model.meanAbsError,
+		Confidence:   model.confidence(),
+	}
+}
+
+func TestFitDemandModelLearnsDayOfWeekSeasonality(t *testing.T) {
+	// Weekends (the last two days of the pattern) sell three times as much
+	pattern := []float64{10, 10, 10, 10, 10, 30, 30}
+	model := fitDemandModel(weeklySeries(pattern, 8, 0))
+
+	forecast := model.forecast(14)
+	for day, demand := range forecast {
+		if math.Abs(demand-pattern[day%7]) > 0.01 {
+			t.Fatalf("day %d: expected %v, got %v", day, pattern[day%7], demand)
+		}
+	}
+	if model.confidence() < 0.99 {
+		t.Fatalf("expected full confidence on a clean pattern, got %v", model.confidence())
+	}
+}
+
+func TestFitDemandModelFollowsTrend(t *testing.T) {
+	model := fitDemandModel(weeklySeries([]float64{5}, 56, 1))
+
+	if math.Abs(model.trend-1) > 0.01 {
+		t.Fatalf("expected a trend of 1 per day, got %v", model.trend)
+	}
+	// The series ended at 60, so the next day is 61
+	if forecast := model.forecast(1)[0]; math.Abs(forecast-61) > 0.01 {
+		t.Fatalf("expected 61 tomorrow, got %v", forecast)
+	}
+}
+
+func TestFitDemandModelFallsBackToAverageOnShortHistory(t *testing.T) {
+	model := fitDemandModel([]float64{2, 4, 6})
+
+	for _, demand := range model.forecast(7) {
+		if demand != 4 {
+			t.Fatalf("expected the average of 4, got %v", demand)
+		}
+	}
+	if model.seasonalFit || model.confidence() >= 0.6 {
+		t.Fatalf("expected reduced confidence without seasonal history, got %v", model.confidence())
+	}
+}
+
+func TestReorderReasons(t *testing.T) {
+	today := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	position := domain.StockPosition{
+		StockLevel: domain.StockLevel{ProductID: "P1", StoreID: "S1", QuantityOnHand: 50, ReorderLevel: 5},
+		CostPrice:  10,
+	}
+
+	tests := []struct {
+		name     string
+		series   []float64
+		leadTime int
+		want     string
+	}{
+		// 56 days end on a season boundary, so the next two days are the peak days
+		{"seasonal peak during lead time", weeklySeries([]float64{30, 30, 10, 10, 10, 10, 10}, 8, 0), 2, domain.ReasonSeasonal},
+		{"rising demand", weeklySeries([]float64{5}, 56, 1), 3, domain.ReasonTrendUp},
+		{"steady demand", weeklySeries([]float64{20}, 56, 0), 3, domain.ReasonLowStock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			forecast := forecastFrom(tt.series, today)
+			suggestion := reorderSuggestion(position, forecast, tt.leadTime, today, today)
+			if suggestion == nil {
+				t.Fatalf("expected a suggestion for 50 on hand")
+			}
+			if suggestion.ReasonCode != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, suggestion.ReasonCode)
+			}
+			if suggestion.LeadTimeDays != tt.leadTime {
+				t.Fatalf("expected lead time %d, got %d", tt.leadTime, suggestion.LeadTimeDays)
+			}
+		})
+	}
+}
+
+func TestReorderSuggestionSkipsCoveredStock(t *testing.T) {
+	today := time.Date(2024, 6, 3, 0, 0, 0, 0, time.UTC)
+	position := domain.StockPosition{
+		StockLevel: domain.StockLevel{ProductID: "P1", StoreID: "S1", QuantityOnHand: 100, ReorderLevel: 5},
+	}
+	forecast := forecastFrom(weeklySeries([]float64{4}, 56, 0), today)
+
+	if suggestion := reorderSuggestion(position, forecast, 3, today, today); suggestion != nil {
+		t.Fatalf("expected no suggestion with 25 days of stock, got %+v", suggestion)
+	}
+	if days := forecast.DaysToStockout(100, today); days != 24 {
+		t.Fatalf("expected stock out after 24 full days, got %d", days)
+	}
+	// A day after the forecast was made there is one day less to go
+	if days := forecast.DaysToStockout(96, today.AddDate(0, 0, 1)); days != 23 {
+		t.Fatalf("expected 23 days from tomorrow, got %d", days)
+	}
+	// Past the horizon the last forecast week carries on
+	if days := forecast.DaysToStockout(400, today); days != 99 {
+		t.Fatalf("expected 99 days past the horizon, got %d", days)
+	}
+}
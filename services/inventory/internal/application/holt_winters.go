@@ -0,0 +1,144 @@
+package application
+
+import "math"
+
+// seasonLength is the number of days in a demand season; sales follow the day of the week
+const seasonLength = 7
+
+// Smoothing parameters tried when fitting a model. The combination with the smallest
+// one-step-ahead squared error over the history wins.
+var (
+	levelSmoothing    = []float64{0.1, 0.2, 0.4, 0.6}
+	trendSmoothing    = []float64{0.0, 0.05, 0.15}
+	seasonalSmoothing = []float64{0.05, 0.2, 0.4}
+)
+
+// holtWinters is an additive Holt-Winters model: a level, a linear trend per day and an offset
+// for each day of the season
+type holtWinters struct {
+	level    float64
+	trend    float64
+	seasonal []float64
+	// observed is the number of days fitted; the next forecast is for day observed
+	observed int
+	// meanAbsError is the mean one-step-ahead forecast error over the history
+	meanAbsError float64
+	// mean is the average daily demand over the history
+	mean float64
+	// seasonalFit reports whether there was enough history to fit seasonality
+	seasonalFit bool
+}
+
+// fitDemandModel fits daily demand. With less than two seasons of history it falls back to
+// the average without trend or seasonality.
+func fitDemandModel(series []float64) *holtWinters {
+	if len(series) < 2*seasonLength {
+		return fitFlat(series)
+	}
+
+	var best *holtWinters
+	bestSSE := math.Inf(1)
+	for _, alpha := range levelSmoothing {
+		for _, beta := range trendSmoothing {
+			for _, gamma := range seasonalSmoothing {
+				model, sse := fitHoltWinters(series, alpha, beta, gamma)
+				if sse < bestSSE {
+					best, bestSSE = model, sse
+				}
+			}
+		}
+	}
+	return best
+}
+
+// fitHoltWinters runs the smoothing equations over the series and returns the model with its
+// one-step-ahead sum of squared errors. The first two seasons initialize the components.
+func fitHoltWinters(series []float64, alpha, beta, gamma float64) (*holtWinters, float64) {
+	first := average(series[:seasonLength])
+	second := average(series[seasonLength : 2*seasonLength])
+
+	model := &holtWinters{
+		trend:       (second - first) / seasonLength,
+		seasonal:    make([]float64, seasonLength),
+		observed:    len(series),
+		mean:        average(series),
+		seasonalFit: true,
+	}
+	// The first season's average sits at its middle day; move the level to its last day
+	model.level = first + model.trend*(seasonLength-1)/2
+	// Seasonal offsets are measured against the trend line so the slope within a season is
+	// not mistaken for seasonality
+	for i := 0; i < seasonLength; i++ {
+		drift := model.trend * (float64(i) - float64(seasonLength-1)/2)
+		model.seasonal[i] = ((series[i] - first - drift) + (series[i+seasonLength] - second - drift)) / 2
+	}
+
+	sse, absErr := 0.0, 0.0
+	for t := seasonLength; t < len(series); t++ {
+		season := t % seasonLength
+		forecast := model.level + model.trend + model.seasonal[season]
+		err := series[t] - forecast
+		sse += err * err
+		absErr += math.Abs(err)
+
+		level := alpha*(series[t]-model.seasonal[season]) + (1-alpha)*(model.level+model.trend)
+		model.trend = beta*(level-model.level) + (1-beta)*model.trend
+		model.seasonal[season] = gamma*(series[t]-level) + (1-gamma)*model.seasonal[season]
+		model.level = level
+	}
+	model.meanAbsError = absErr / float64(len(series)-seasonLength)
+
+	return model, sse
+}
+
+func fitFlat(series []float64) *holtWinters {
+	model := &holtWinters{
+		seasonal: make([]float64, seasonLength),
+		observed: len(series),
+		mean:     average(series),
+	}
+	model.level = model.mean
+
+	absErr := 0.0
+	for _, demand := range series {
+		absErr += math.Abs(demand - model.mean)
+	}
+	if len(series) > 0 {
+		model.meanAbsError = absErr / float64(len(series))
+	}
+	return model
+}
+
+// forecast returns the demand for each of the next days; demand never goes below zero
+func (m *holtWinters) forecast(days int) []float64 {
+	demand := make([]float64, days)
+	for h := 1; h <= days; h++ {
+		value := m.level + float64(h)*m.trend + m.seasonal[(m.observed+h-1)%seasonLength]
+		demand[h-1] = math.Max(value, 0)
+	}
+	return demand
+}
+
+// confidence falls as the forecast error grows relative to demand and is lower without
+// seasonal history
+func (m *holtWinters) confidence() float64 {
+	if m.mean <= 0 {
+		return 0
+	}
+	confidence := 1 / (1 + m.meanAbsError/m.mean)
+	if !m.seasonalFit {
+		confidence *= 0.6
+	}
+	return round2(confidence)
+}
+
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	total := 0.0
+	for _, value := range values {
+		total += value
+	}
+	return total / float64(len(values))
+}
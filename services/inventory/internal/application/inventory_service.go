@@ -0,0 +1,243 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"inventory/internal/domain"
+	"inventory/internal/infrastructure/events"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 500
+)
+
+// MovementRequest is a stock movement to record. Quantity is positive for sales and purchases;
+// adjustments and transfers are signed.
+type MovementRequest struct {
+	ProductID    string
+	StoreID      string
+	MovementType string
+	Quantity     float64
+	Reference    string
+	Notes        string
+}
+
+type InventoryService struct {
+	repo      domain.InventoryRepository
+	publisher events.Publisher
+	logger    *logrus.Logger
+}
+
+func NewInventoryService(repo domain.InventoryRepository, publisher events.Publisher, logger *logrus.Logger) *InventoryService {
+	return &InventoryService{
+		repo:      repo,
+		publisher: publisher,
+		logger:    logger,
+	}
+}
+
+// ListProducts returns a page of products and the total matching the filter
+func (s *InventoryService) ListProducts(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, int, error) {
+	filter.Limit = pageSize(filter.Limit)
+	return s.repo.ListProducts(ctx, filter)
+}
+
+// GetProduct returns a product with its stock at every store
+func (s *InventoryService) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	product, err := s.repo.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	product.StockLevels, err = s.repo.GetProductStock(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	return product, nil
+}
+
+func (s *InventoryService) ListStores(ctx context.Context) ([]domain.Store, error) {
+	return s.repo.ListStores(ctx)
+}
+
+func (s *InventoryService) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	return s.repo.ListCategories(ctx)
+}
+
+// GetProductStock returns the stock of a product at every store
+func (s *InventoryService) GetProductStock(ctx context.Context, productID string) ([]domain.StockLevel, error) {
+	if _, err := s.repo.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetProductStock(ctx, productID)
+}
+
+// GetStoreStock returns the stock of every product at a store
+func (s *InventoryService) GetStoreStock(ctx context.Context, storeID string) ([]domain.StockLevel, error) {
+	if _, err := s.repo.GetStore(ctx, storeID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetStoreStock(ctx, storeID)
+}
+
+// GetLowStockItems returns the stock at or below its reorder level, optionally for one store
+func (s *InventoryService) GetLowStockItems(ctx context.Context, storeID string) ([]domain.StockPosition, error) {
+	if storeID != "" {
+		if _, err := s.repo.GetStore(ctx, storeID); err != nil {
+			return nil, err
+		}
+	}
+	return s.repo.GetStockPositions(ctx, storeID, true)
+}
+
+// RecordMovement applies a movement to the store's stock, raises or resolves alerts for the
+// new level and publishes the stock change
+func (s *InventoryService) RecordMovement(ctx context.Context, req MovementRequest) (*domain.StockMovement, *domain.StockLevel, error) {
+	delta, err := domain.MovementDelta(req.MovementType, req.Quantity)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	movement := &domain.StockMovement{
+		ProductID:    req.ProductID,
+		StoreID:      req.StoreID,
+		MovementType: req.MovementType,
+		Quantity:     delta,
+		Reference:    req.Reference,
+		Notes:        req.Notes,
+	}
+
+	level, err := s.repo.ApplyMovement(ctx, movement)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.logger.WithFields(logrus.Fields{
+		"product_id":    movement.ProductID,
+		"store_id":      movement.StoreID,
+		"movement_type": movement.MovementType,
+		"quantity":      movement.Quantity,
+	}).Info("Stock movement recorded")
+
+	// The movement is committed; alert and event failures are logged rather than returned
+	if err := s.publisher.PublishStockUpdated(ctx, movement.ProductID, roundQty(movement.QuantityBefore),
+		roundQty(movement.QuantityAfter), movement.MovementType, movement.Reference); err != nil {
+		s.logger.WithError(err).WithField("product_id", movement.ProductID).Warn("Failed to publish stock update")
+	}
+	if err := s.refreshAlerts(ctx, level); err != nil {
+		s.logger.WithError(err).WithField("product_id", movement.ProductID).Warn("Failed to refresh inventory alerts")
+	}
+
+	return movement, level, nil
+}
+
+// GetMovements returns movement history, newest first
+func (s *InventoryService) GetMovements(ctx context.Context, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	if !filter.From.IsZero() && !filter.To.IsZero() && filter.To.Before(filter.From) {
+		return nil, domain.ErrInvalidDateRange
+	}
+	filter.Limit = pageSize(filter.Limit)
+	return s.repo.ListMovements(ctx, filter)
+}
+
+func (s *InventoryService) ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]domain.InventoryAlert, error) {
+	return s.repo.ListAlerts(ctx, filter)
+}
+
+// ResolveAlert marks an open alert resolved and returns it
+func (s *InventoryService) ResolveAlert(ctx context.Context, alertID string) (*domain.InventoryAlert, error) {
+	if err := s.repo.ResolveAlert(ctx, alertID, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.repo.GetAlert(ctx, alertID)
+}
+
+// refreshAlerts keeps one open alert matching the stock level and resolves the others
+func (s *InventoryService) refreshAlerts(ctx context.Context, level *domain.StockLevel) error {
+	wanted := alertForLevel(level)
+
+	open, err := s.repo.ListAlerts(ctx, domain.AlertFilter{ProductID: level.ProductID, StoreID: level.StoreID})
+	if err != nil {
+		return err
+	}
+
+	alreadyOpen := false
+	for _, alert := range open {
+		if wanted != nil && alert.AlertType == wanted.AlertType {
+			alreadyOpen = true
+			continue
+		}
+		if err := s.repo.ResolveAlert(ctx, alert.ID, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if wanted == nil {
+		return nil
+	}
+	if err := s.repo.CreateAlert(ctx, wanted); err != nil {
+		return err
+	}
+
+	if !alreadyOpen && wanted.AlertType != domain.AlertOverstocked {
+		message := fmt.Sprintf("%s at store %s: %.3f on hand", wanted.AlertType, level.StoreID, level.QuantityOnHand)
+		if err := s.publisher.PublishStockLevelLow(ctx, level.ProductID, roundQty(wanted.CurrentQty),
+			roundQty(wanted.ThresholdQty), wanted.Severity, message); err != nil {
+			s.logger.WithError(err).WithField("product_id", level.ProductID).Warn("Failed to publish stock alert")
+		}
+	}
+
+	return nil
+}
+
+// alertForLevel returns the alert a stock level calls for, or nil when it is within bounds
+func alertForLevel(level *domain.StockLevel) *domain.InventoryAlert {
+	alert := &domain.InventoryAlert{
+		ProductID:  level.ProductID,
+		StoreID:    level.StoreID,
+		StoreName:  level.StoreName,
+		CurrentQty: level.QuantityOnHand,
+		CreatedAt:  time.Now(),
+	}
+
+	switch {
+	case level.QuantityOnHand <= 0:
+		alert.AlertType = domain.AlertOutOfStock
+		alert.Severity = domain.SeverityHigh
+		alert.ThresholdQty = level.ReorderLevel
+	case level.ReorderLevel > 0 && level.QuantityOnHand <= level.ReorderLevel:
+		alert.AlertType = domain.AlertLowStock
+		alert.Severity = domain.SeverityMedium
+		alert.ThresholdQty = level.ReorderLevel
+	case level.MaxStock > 0 && level.QuantityOnHand > level.MaxStock:
+		alert.AlertType = domain.AlertOverstocked
+		alert.Severity = domain.SeverityLow
+		alert.ThresholdQty = level.MaxStock
+	default:
+		return nil
+	}
+
+	return alert
+}
+
+func pageSize(limit int) int {
+	if limit <= 0 {
+		return defaultPageSize
+	}
+	if limit > maxPageSize {
+		return maxPageSize
+	}
+	return limit
+}
+
+// roundQty converts a quantity to the whole units carried by inventory events
+func roundQty(quantity float64) int {
+	return int(math.Round(quantity))
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Config holds the application configuration
@@ -12,6 +13,7 @@ type Config struct {
 	Redis    RedisConfig
 	Kafka    KafkaConfig
 	External ExternalConfig
+	Forecast ForecastConfig
 	Logging  LoggingConfig
 }
 
@@ -64,6 +66,12 @@ type ExternalConfig struct {
 	AdminToken          string
 }
 
+// ForecastConfig holds demand forecasting configuration
+type ForecastConfig struct {
+	Enabled  bool
+	Interval time.Duration
+}
+
 // LoggingConfig holds logging configuration
 type LoggingConfig struct {
 	Level  string
@@ -76,6 +84,11 @@ func Load() *Config {
 	maxRetries, _ := strconv.Atoi(getEnv("REDIS_MAX_RETRIES", "3"))
 	poolSize, _ := strconv.Atoi(getEnv("REDIS_POOL_SIZE", "10"))
 	minIdleConns, _ := strconv.Atoi(getEnv("REDIS_MIN_IDLE_CONNS", "5"))
+	forecastEnabled, _ := strconv.ParseBool(getEnv("FORECAST_ENABLED", "true"))
+	forecastInterval, err := time.ParseDuration(getEnv("FORECAST_INTERVAL", "6h"))
+	if err != nil || forecastInterval <= 0 {
+		forecastInterval = 6 * time.Hour
+	}
 
 	return &Config{
 		Server: ServerConfig{
@@ -114,6 +127,10 @@ func Load() *Config {
 			LoyverseAPIToken: getEnv("LOYVERSE_API_TOKEN", ""),
 			AdminToken:       getEnv("ADMIN_TOKEN", ""),
 		},
+		Forecast: ForecastConfig{
+			Enabled:  forecastEnabled,
+			Interval: forecastInterval,
+		},
 		Logging: LoggingConfig{
 			Level:  getEnv("LOG_LEVEL", "info"),
 			Format: getEnv("LOG_FORMAT", "json"),
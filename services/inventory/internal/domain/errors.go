@@ -0,0 +1,14 @@
+package domain
+
+import "errors"
+
+var (
+	ErrProductNotFound   = errors.New("product not found")
+	ErrStoreNotFound     = errors.New("store not found")
+	ErrAlertNotFound     = errors.New("inventory alert not found")
+	ErrAlertResolved     = errors.New("inventory alert is already resolved")
+	ErrInvalidMovement   = errors.New("invalid stock movement")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInvalidDateRange  = errors.New("invalid date range")
+	ErrInvalidSupplier   = errors.New("invalid supplier")
+)
@@ -0,0 +1,103 @@
+package domain
+
+import (
+	"math"
+	"time"
+)
+
+// Reorder reason codes
+const (
+	ReasonLowStock = "LOW_STOCK"
+	ReasonSeasonal = "SEASONAL"
+	ReasonTrendUp  = "TREND_UP"
+)
+
+// Supplier supplies products and takes LeadTimeDays to deliver an order
+type Supplier struct {
+	ID           string    `json:"id" db:"id"`
+	Name         string    `json:"name" db:"name"`
+	LeadTimeDays int       `json:"lead_time_days" db:"lead_time_days"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// DemandForecast is the forecast daily demand of a product at a store. Daily[0] is the demand
+// on StartDate.
+type DemandForecast struct {
+	ProductID    string     `json:"product_id" db:"product_id"`
+	StoreID      string     `json:"store_id" db:"store_id"`
+	StartDate    time.Time  `json:"start_date" db:"start_date"`
+	Daily        []float64  `json:"daily" db:"daily_demand"`
+	TrendPerDay  float64    `json:"trend_per_day" db:"trend_per_day"`
+	MeanAbsError float64    `json:"mean_abs_error" db:"mean_abs_error"`
+	Confidence   float64    `json:"confidence" db:"confidence"`
+	LastSaleDate *time.Time `json:"last_sale_date,omitempty" db:"last_sale_date"`
+	GeneratedAt  time.Time  `json:"generated_at" db:"generated_at"`
+}
+
+// Demand returns the forecast demand over the days starting on the given day. Days past the
+// forecast horizon are assumed to sell at the average of the last forecast week.
+func (f *DemandForecast) Demand(from time.Time, days int) float64 {
+	offset := f.offset(from)
+	total := 0.0
+	for i := 0; i < days; i++ {
+		total += f.dailyAt(offset + i)
+	}
+	return total
+}
+
+// DaysToStockout returns the whole days of stock left from the given day: the number of days
+// that end with stock remaining at the forecast demand, or -1 when no demand is forecast
+func (f *DemandForecast) DaysToStockout(onHand float64, from time.Time) int {
+	if onHand <= 0 {
+		return 0
+	}
+
+	offset := f.offset(from)
+	remaining := onHand
+	for day := 0; day < len(f.Daily); day++ {
+		demand := f.dailyAt(offset + day)
+		if demand >= remaining {
+			return day
+		}
+		remaining -= demand
+	}
+
+	tail := f.tailAverage()
+	if tail <= 0 {
+		return -1
+	}
+	// Counted the same way as above: the day that sells the last unit is the answer
+	return len(f.Daily) + int(math.Ceil(remaining/tail)) - 1
+}
+
+// offset is the index in Daily of the given day
+func (f *DemandForecast) offset(day time.Time) int {
+	offset := int(math.Floor(day.Sub(f.StartDate).Hours() / 24))
+	if offset < 0 {
+		return 0
+	}
+	return offset
+}
+
+func (f *DemandForecast) dailyAt(index int) float64 {
+	if index < len(f.Daily) {
+		return f.Daily[index]
+	}
+	return f.tailAverage()
+}
+
+func (f *DemandForecast) tailAverage() float64 {
+	n := len(f.Daily)
+	if n == 0 {
+		return 0
+	}
+	start := n - 7
+	if start < 0 {
+		start = 0
+	}
+	total := 0.0
+	for _, demand := range f.Daily[start:] {
+		total += demand
+	}
+	return total / float64(n-start)
+}
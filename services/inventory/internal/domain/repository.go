@@ -0,0 +1,146 @@
+package domain
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Movement types
+const (
+	MovementSale       = "SALE"
+	MovementPurchase   = "PURCHASE"
+	MovementAdjustment = "ADJUSTMENT"
+	MovementTransfer   = "TRANSFER"
+)
+
+// Alert types
+const (
+	AlertLowStock    = "LOW_STOCK"
+	AlertOutOfStock  = "OUT_OF_STOCK"
+	AlertOverstocked = "OVERSTOCKED"
+)
+
+// Alert severities
+const (
+	SeverityHigh   = "HIGH"
+	SeverityMedium = "MEDIUM"
+	SeverityLow    = "LOW"
+)
+
+// MovementDelta returns the signed change in stock for a movement. Sales always take stock
+// out and purchases always bring it in; adjustments and transfers carry their own sign.
+func MovementDelta(movementType string, quantity float64) (float64, error) {
+	if quantity == 0 {
+		return 0, fmt.Errorf("%w: quantity must not be zero", ErrInvalidMovement)
+	}
+
+	switch movementType {
+	case MovementSale:
+		if quantity > 0 {
+			return -quantity, nil
+		}
+		return quantity, nil
+	case MovementPurchase:
+		if quantity < 0 {
+			return -quantity, nil
+		}
+		return quantity, nil
+	case MovementAdjustment, MovementTransfer:
+		return quantity, nil
+	default:
+		return 0, fmt.Errorf("%w: unknown movement type %q", ErrInvalidMovement, movementType)
+	}
+}
+
+// ProductFilter narrows product listings
+type ProductFilter struct {
+	Query      string
+	CategoryID string
+	Limit      int
+	Offset     int
+}
+
+// MovementFilter narrows movement history; zero values match everything
+type MovementFilter struct {
+	ProductID    string
+	StoreID      string
+	MovementType string
+	From         time.Time
+	To           time.Time
+	Limit        int
+	Offset       int
+}
+
+// AlertFilter narrows alert listings
+type AlertFilter struct {
+	StoreID         string
+	ProductID       string
+	AlertType       string
+	IncludeResolved bool
+}
+
+// StockPosition is a stock level together with the product details needed to value and reorder it
+type StockPosition struct {
+	StockLevel
+	ProductName  string  `json:"product_name"`
+	CategoryName string  `json:"category_name"`
+	CostPrice    float64 `json:"cost_price"`
+}
+
+// DailySales is the quantity of a product sold at a store on one day
+type DailySales struct {
+	ProductID string
+	StoreID   string
+	Date      time.Time
+	Quantity  float64
+}
+
+// InventoryRepository stores products, stores, stock levels, movements and alerts
+type InventoryRepository interface {
+	ListProducts(ctx context.Context, filter ProductFilter) ([]Product, int, error)
+	GetProduct(ctx context.Context, productID string) (*Product, error)
+	ListStores(ctx context.Context) ([]Store, error)
+	GetStore(ctx context.Context, storeID string) (*Store, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+
+	GetProductStock(ctx context.Context, productID string) ([]StockLevel, error)
+	GetStoreStock(ctx context.Context, storeID string) ([]StockLevel, error)
+	GetStockPositions(ctx context.Context, storeID string, lowOnly bool) ([]StockPosition, error)
+
+	// ApplyMovement locks the stock level, applies movement.Quantity as a signed delta and stores
+	// the movement with its before and after quantities. It fails with ErrInsufficientStock when
+	// stock would go negative.
+	ApplyMovement(ctx context.Context, movement *StockMovement) (*StockLevel, error)
+	ListMovements(ctx context.Context, filter MovementFilter) ([]StockMovement, error)
+
+	ListAlerts(ctx context.Context, filter AlertFilter) ([]InventoryAlert, error)
+	GetAlert(ctx context.Context, alertID string) (*InventoryAlert, error)
+	CreateAlert(ctx context.Context, alert *InventoryAlert) error
+	ResolveAlert(ctx context.Context, alertID string, resolvedAt time.Time) error
+}
+
+// AnalyticsRepository aggregates stock and movement data for reporting
+type AnalyticsRepository interface {
+	// GetStockSummary fills the dashboard counts and stock value of an Analytics
+	GetStockSummary(ctx context.Context) (*Analytics, error)
+	GetProductPerformance(ctx context.Context, from, to time.Time, limit int) ([]ProductPerformance, error)
+	GetCategoryPerformance(ctx context.Context, from, to time.Time) ([]CategoryPerformance, error)
+	GetDailyMovements(ctx context.Context, from, to time.Time) ([]DailyMovement, error)
+	GetWeeklyTrends(ctx context.Context, from, to time.Time) ([]WeeklyTrend, error)
+	GetDailySales(ctx context.Context, from, to time.Time) ([]DailySales, error)
+}
+
+// ForecastRepository stores supplier lead times and the output of forecasting runs
+type ForecastRepository interface {
+	UpsertSupplier(ctx context.Context, supplier *Supplier) error
+	// GetLeadTimes returns the lead time in days of each product's supplier, by product id.
+	// Products without a known supplier are left out.
+	GetLeadTimes(ctx context.Context) (map[string]int, error)
+
+	ListForecasts(ctx context.Context, storeID string) ([]DemandForecast, error)
+	// ListReorderSuggestions returns the suggestions of the latest run in rank order
+	ListReorderSuggestions(ctx context.Context, storeID string) ([]ReorderSuggestion, error)
+	// SaveForecastRun replaces the stored forecasts and suggestions with those of a run
+	SaveForecastRun(ctx context.Context, forecasts []DemandForecast, suggestions []ReorderSuggestion) error
+}
@@ -0,0 +1,250 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"inventory/internal/domain"
+)
+
+// AnalyticsRepository implements domain.AnalyticsRepository on PostgreSQL
+type AnalyticsRepository struct {
+	db *sql.DB
+}
+
+// NewAnalyticsRepository creates an analytics repository over the service database
+func NewAnalyticsRepository(conn *Connection) *AnalyticsRepository {
+	return &AnalyticsRepository{db: conn.DB}
+}
+
+// GetStockSummary counts products, stores and stock statuses and values stock at cost.
+// A product is out of stock when it has none at any store and low when any store is at or
+// below its reorder level.
+func (r *AnalyticsRepository) GetStockSummary(ctx context.Context) (*domain.Analytics, error) {
+	query := `
+		WITH product_stock AS (
+			SELECT p.id,
+				COALESCE(SUM(sl.quantity_on_hand), 0) AS quantity,
+				COALESCE(SUM(sl.quantity_on_hand * p.cost_price), 0) AS value,
+				COALESCE(BOOL_OR(sl.quantity_on_hand <= sl.reorder_level), false) AS is_low
+			FROM products p
+			LEFT JOIN stock_levels sl ON sl.product_id = p.id
+			WHERE p.is_active = true
+			GROUP BY p.id
+		)
+		SELECT
+			COUNT(*),
+			(SELECT COUNT(*) FROM stores WHERE is_active = true),
+			COALESCE(SUM(value), 0),
+			COUNT(*) FILTER (WHERE quantity > 0 AND NOT is_low),
+			COUNT(*) FILTER (WHERE quantity > 0 AND is_low),
+			COUNT(*) FILTER (WHERE quantity <= 0)
+		FROM product_stock`
+
+	analytics := &domain.Analytics{}
+	err := r.db.QueryRowContext(ctx, query).Scan(
+		&analytics.TotalProducts,
+		&analytics.TotalStores,
+		&analytics.TotalStockValue,
+		&analytics.InStock,
+		&analytics.LowStock,
+		&analytics.OutOfStock,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock summary: %w", err)
+	}
+	analytics.LowStockItems = analytics.LowStock + analytics.OutOfStock
+
+	return analytics, nil
+}
+
+// GetProductPerformance ranks products by quantity sold between from and to
+func (r *AnalyticsRepository) GetProductPerformance(ctx context.Context, from, to time.Time, limit int) ([]domain.ProductPerformance, error) {
+	query := `
+		WITH sales AS (
+			SELECT product_id, SUM(-quantity) AS sold
+			FROM stock_movements
+			WHERE movement_type = 'SALE' AND created_at >= $1 AND created_at < $2
+			GROUP BY product_id
+		),
+		stock AS (
+			SELECT product_id, SUM(quantity_on_hand) AS on_hand
+			FROM stock_levels
+			GROUP BY product_id
+		)
+		SELECT p.id, p.name, COALESCE(p.category_name, ''), sales.sold, sales.sold * p.sell_price,
+			COALESCE(stock.on_hand, 0)
+		FROM sales
+		JOIN products p ON p.id = sales.product_id
+		LEFT JOIN stock ON stock.product_id = sales.product_id
+		ORDER BY sales.sold DESC, p.name ASC
+		LIMIT $3`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product performance: %w", err)
+	}
+	defer rows.Close()
+
+	var performance []domain.ProductPerformance
+	for rows.Next() {
+		var item domain.ProductPerformance
+		if err := rows.Scan(&item.ProductID, &item.ProductName, &item.CategoryName, &item.TotalSold, &item.Revenue, &item.CurrentStock); err != nil {
+			return nil, fmt.Errorf("failed to scan product performance: %w", err)
+		}
+		performance = append(performance, item)
+	}
+
+	return performance, rows.Err()
+}
+
+// GetCategoryPerformance sums sales per category between from and to
+func (r *AnalyticsRepository) GetCategoryPerformance(ctx context.Context, from, to time.Time) ([]domain.CategoryPerformance, error) {
+	query := `
+		WITH sales AS (
+			SELECT product_id, SUM(-quantity) AS sold
+			FROM stock_movements
+			WHERE movement_type = 'SALE' AND created_at >= $1 AND created_at < $2
+			GROUP BY product_id
+		),
+		stock AS (
+			SELECT product_id, SUM(quantity_on_hand) AS on_hand
+			FROM stock_levels
+			GROUP BY product_id
+		)
+		SELECT COALESCE(p.category_id, ''), COALESCE(NULLIF(p.category_name, ''), 'Uncategorized'),
+			COUNT(p.id), COALESCE(SUM(sales.sold), 0), COALESCE(SUM(sales.sold * p.sell_price), 0),
+			COALESCE(SUM(stock.on_hand), 0)
+		FROM products p
+		LEFT JOIN sales ON sales.product_id = p.id
+		LEFT JOIN stock ON stock.product_id = p.id
+		WHERE p.is_active = true
+		GROUP BY 1, 2`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category performance: %w", err)
+	}
+	defer rows.Close()
+
+	var performance []domain.CategoryPerformance
+	for rows.Next() {
+		var item domain.CategoryPerformance
+		var onHand float64
+		if err := rows.Scan(&item.CategoryID, &item.CategoryName, &item.ProductCount, &item.TotalSold, &item.Revenue, &onHand); err != nil {
+			return nil, fmt.Errorf("failed to scan category performance: %w", err)
+		}
+		if onHand > 0 {
+			item.AvgTurnover = item.TotalSold / onHand
+		}
+		performance = append(performance, item)
+	}
+
+	return performance, rows.Err()
+}
+
+// GetDailyMovements sums movements per day between from and to. Value moved is at cost.
+func (r *AnalyticsRepository) GetDailyMovements(ctx context.Context, from, to time.Time) ([]domain.DailyMovement, error) {
+	query := `
+		SELECT date_trunc('day', m.created_at) AS day,
+			COALESCE(SUM(-m.quantity) FILTER (WHERE m.movement_type = 'SALE'), 0),
+			COALESCE(SUM(m.quantity) FILTER (WHERE m.movement_type = 'PURCHASE'), 0),
+			COALESCE(SUM(m.quantity) FILTER (WHERE m.movement_type = 'ADJUSTMENT'), 0),
+			SUM(m.quantity),
+			SUM(ABS(m.quantity) * p.cost_price)
+		FROM stock_movements m
+		JOIN products p ON p.id = m.product_id
+		WHERE m.created_at >= $1 AND m.created_at < $2
+		GROUP BY day
+		ORDER BY day DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []domain.DailyMovement
+	for rows.Next() {
+		var day domain.DailyMovement
+		if err := rows.Scan(&day.Date, &day.TotalSales, &day.TotalPurchases, &day.TotalAdjustments, &day.NetMovement, &day.ValueMoved); err != nil {
+			return nil, fmt.Errorf("failed to scan daily movement: %w", err)
+		}
+		movements = append(movements, day)
+	}
+
+	return movements, rows.Err()
+}
+
+// GetWeeklyTrends sums sales per ISO week between from and to with each week's best selling
+// category, oldest week first. Growth rates are left to the caller.
+func (r *AnalyticsRepository) GetWeeklyTrends(ctx context.Context, from, to time.Time) ([]domain.WeeklyTrend, error) {
+	query := `
+		WITH sales AS (
+			SELECT date_trunc('week', m.created_at) AS week,
+				COALESCE(NULLIF(p.category_name, ''), 'Uncategorized') AS category,
+				SUM(-m.quantity) AS sold,
+				SUM(-m.quantity * p.sell_price) AS revenue
+			FROM stock_movements m
+			JOIN products p ON p.id = m.product_id
+			WHERE m.movement_type = 'SALE' AND m.created_at >= $1 AND m.created_at < $2
+			GROUP BY week, category
+		),
+		top_categories AS (
+			SELECT DISTINCT ON (week) week, category
+			FROM sales
+			ORDER BY week, revenue DESC, category
+		)
+		SELECT sales.week, SUM(sales.sold), SUM(sales.revenue), top_categories.category
+		FROM sales
+		JOIN top_categories ON top_categories.week = sales.week
+		GROUP BY sales.week, top_categories.category
+		ORDER BY sales.week ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get weekly trends: %w", err)
+	}
+	defer rows.Close()
+
+	var trends []domain.WeeklyTrend
+	for rows.Next() {
+		var week domain.WeeklyTrend
+		if err := rows.Scan(&week.WeekStart, &week.TotalSales, &week.Revenue, &week.TopCategory); err != nil {
+			return nil, fmt.Errorf("failed to scan weekly trend: %w", err)
+		}
+		week.WeekEnd = week.WeekStart.AddDate(0, 0, 6)
+		trends = append(trends, week)
+	}
+
+	return trends, rows.Err()
+}
+
+// GetDailySales returns the quantity sold per product, store and day between from and to
+func (r *AnalyticsRepository) GetDailySales(ctx context.Context, from, to time.Time) ([]domain.DailySales, error) {
+	query := `
+		SELECT product_id, store_id, date_trunc('day', created_at) AS day, SUM(-quantity)
+		FROM stock_movements
+		WHERE movement_type = 'SALE' AND created_at >= $1 AND created_at < $2
+		GROUP BY product_id, store_id, day
+		ORDER BY product_id, store_id, day`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get daily sales: %w", err)
+	}
+	defer rows.Close()
+
+	var sales []domain.DailySales
+	for rows.Next() {
+		var day domain.DailySales
+		if err := rows.Scan(&day.ProductID, &day.StoreID, &day.Date, &day.Quantity); err != nil {
+			return nil, fmt.Errorf("failed to scan daily sales: %w", err)
+		}
+		sales = append(sales, day)
+	}
+
+	return sales, rows.Err()
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"inventory/internal/domain"
+
+	"github.com/lib/pq"
+)
+
+// ForecastRepository implements domain.ForecastRepository on PostgreSQL
+type ForecastRepository struct {
+	db *sql.DB
+}
+
+// NewForecastRepository creates a forecast repository over the service database
+func NewForecastRepository(conn *Connection) *ForecastRepository {
+	return &ForecastRepository{db: conn.DB}
+}
+
+func (r *ForecastRepository) UpsertSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO suppliers (id, name, lead_time_days, updated_at)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (id) DO UPDATE SET
+			name = EXCLUDED.name,
+			lead_time_days = EXCLUDED.lead_time_days,
+			updated_at = EXCLUDED.updated_at`,
+		supplier.ID, supplier.Name, supplier.LeadTimeDays, supplier.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert supplier: %w", err)
+	}
+	return nil
+}
+
+func (r *ForecastRepository) GetLeadTimes(ctx context.Context) (map[string]int, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT p.id, s.lead_time_days
+		FROM products p
+		JOIN suppliers s ON s.id = p.supplier_id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lead times: %w", err)
+	}
+	defer rows.Close()
+
+	leadTimes := make(map[string]int)
+	for rows.Next() {
+		var productID string
+		var days int
+		if err := rows.Scan(&productID, &days); err != nil {
+			return nil, fmt.Errorf("failed to scan lead time: %w", err)
+		}
+		leadTimes[productID] = days
+	}
+
+	return leadTimes, rows.Err()
+}
+
+func (r *ForecastRepository) ListForecasts(ctx context.Context, storeID string) ([]domain.DemandForecast, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT product_id, store_id, start_date, daily_demand, trend_per_day, mean_abs_error,
+			confidence, last_sale_date, generated_at
+		FROM demand_forecasts
+		WHERE ($1 = '' OR store_id = $1)
+		ORDER BY product_id, store_id`,
+		storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list demand forecasts: %w", err)
+	}
+	defer rows.Close()
+
+	var forecasts []domain.DemandForecast
+	for rows.Next() {
+		var forecast domain.DemandForecast
+		var daily pq.Float64Array
+		var lastSale sql.NullTime
+		err := rows.Scan(
+			&forecast.ProductID,
+			&forecast.StoreID,
+			&forecast.StartDate,
+			&daily,
+			&forecast.TrendPerDay,
+			&forecast.MeanAbsError,
+			&forecast.Confidence,
+			&lastSale,
+			&forecast.GeneratedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan demand forecast: %w", err)
+		}
+		forecast.Daily = daily
+		if lastSale.Valid {
+			forecast.LastSaleDate = &lastSale.Time
+		}
+		forecasts = append(forecasts, forecast)
+	}
+
+	return forecasts, rows.Err()
+}
+
+func (r *ForecastRepository) ListReorderSuggestions(ctx context.Context, storeID string) ([]domain.ReorderSuggestion, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT rs.product_id, p.name, rs.store_id, s.name, rs.current_stock, rs.suggested_qty,
+			rs.reason_code, rs.confidence, rs.estimated_cost, rs.estimated_demand,
+			rs.lead_time_days, rs.priority, rs.created_at
+		FROM reorder_suggestions rs
+		JOIN products p ON p.id = rs.product_id
+		JOIN stores s ON s.id = rs.store_id
+		WHERE ($1 = '' OR rs.store_id = $1)
+		ORDER BY rs.rank`,
+		storeID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reorder suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var suggestions []domain.ReorderSuggestion
+	for rows.Next() {
+		var suggestion domain.ReorderSuggestion
+		err := rows.Scan(
+			&suggestion.ProductID,
+			&suggestion.ProductName,
+			&suggestion.StoreID,
+			&suggestion.StoreName,
+			&suggestion.CurrentStock,
+			&suggestion.SuggestedQty,
+			&suggestion.ReasonCode,
+			&suggestion.Confidence,
+			&suggestion.EstimatedCost,
+			&suggestion.EstimatedDemand,
+			&suggestion.LeadTimeDays,
+			&suggestion.Priority,
+			&suggestion.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reorder suggestion: %w", err)
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+
+	return suggestions, rows.Err()
+}
+
+func (r *ForecastRepository) SaveForecastRun(ctx context.Context, forecasts []domain.DemandForecast, suggestions []domain.ReorderSuggestion) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM demand_forecasts`); err != nil {
+		return fmt.Errorf("failed to clear demand forecasts: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM reorder_suggestions`); err != nil {
+		return fmt.Errorf("failed to clear reorder suggestions: %w", err)
+	}
+
+	for _, forecast := range forecasts {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO demand_forecasts (
+				product_id, store_id, start_date, daily_demand, trend_per_day, mean_abs_error,
+				confidence, last_sale_date, generated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
+			forecast.ProductID,
+			forecast.StoreID,
+			forecast.StartDate,
+			pq.Float64Array(forecast.Daily),
+			forecast.TrendPerDay,
+			forecast.MeanAbsError,
+			forecast.Confidence,
+			forecast.LastSaleDate,
+			forecast.GeneratedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save demand forecast: %w", err)
+		}
+	}
+
+	for rank, suggestion := range suggestions {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO reorder_suggestions (
+				rank, product_id, store_id, current_stock, suggested_qty, reason_code, confidence,
+				estimated_cost, estimated_demand, lead_time_days, priority, created_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`,
+			rank+1,
+			suggestion.ProductID,
+			suggestion.StoreID,
+			suggestion.CurrentStock,
+			suggestion.SuggestedQty,
+			suggestion.ReasonCode,
+			suggestion.Confidence,
+			suggestion.EstimatedCost,
+			suggestion.EstimatedDemand,
+			suggestion.LeadTimeDays,
+			suggestion.Priority,
+			suggestion.CreatedAt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save reorder suggestion: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit forecast run: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,557 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"inventory/internal/domain"
+)
+
+// InventoryRepository implements domain.InventoryRepository on PostgreSQL
+type InventoryRepository struct {
+	db *sql.DB
+}
+
+// NewInventoryRepository creates a repository over the service database
+func NewInventoryRepository(conn *Connection) *InventoryRepository {
+	return &InventoryRepository{db: conn.DB}
+}
+
+const productColumns = `
+	id, name, COALESCE(sku, ''), COALESCE(barcode, ''), COALESCE(category_id, ''),
+	COALESCE(category_name, ''), COALESCE(supplier_id, ''), COALESCE(supplier_name, ''),
+	cost_price, sell_price, unit, COALESCE(description, ''), is_active, last_updated`
+
+// ListProducts returns a page of active products and the total number matching the filter
+func (r *InventoryRepository) ListProducts(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, int, error) {
+	where := []string{"is_active = true"}
+	var args []interface{}
+	if filter.Query != "" {
+		args = append(args, "%"+filter.Query+"%")
+		n := len(args)
+		where = append(where, fmt.Sprintf("(name ILIKE $%d OR sku ILIKE $%d OR barcode ILIKE $%d)", n, n, n))
+	}
+	if filter.CategoryID != "" {
+		args = append(args, filter.CategoryID)
+		where = append(where, fmt.Sprintf("category_id = $%d", len(args)))
+	}
+	whereClause := " WHERE " + strings.Join(where, " AND ")
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM products"+whereClause, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count products: %w", err)
+	}
+
+	query := "SELECT " + productColumns + " FROM products" + whereClause + " ORDER BY name ASC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []domain.Product
+	for rows.Next() {
+		product, err := scanProduct(rows)
+		if err != nil {
+			return nil, 0, err
+		}
+		products = append(products, *product)
+	}
+
+	return products, total, rows.Err()
+}
+
+// GetProduct returns a product by id
+func (r *InventoryRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	row := r.db.QueryRowContext(ctx, "SELECT "+productColumns+" FROM products WHERE id = $1", productID)
+	product, err := scanProduct(row)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrProductNotFound
+	}
+	return product, err
+}
+
+// ListStores returns the active stores
+func (r *InventoryRepository) ListStores(ctx context.Context) ([]domain.Store, error) {
+	query := `
+		SELECT id, name, COALESCE(address, ''), COALESCE(phone, ''), is_active, created_at, updated_at
+		FROM stores
+		WHERE is_active = true
+		ORDER BY name ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stores: %w", err)
+	}
+	defer rows.Close()
+
+	var stores []domain.Store
+	for rows.Next() {
+		var store domain.Store
+		if err := rows.Scan(&store.ID, &store.Name, &store.Address, &store.Phone, &store.IsActive, &store.CreatedAt, &store.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan store: %w", err)
+		}
+		stores = append(stores, store)
+	}
+
+	return stores, rows.Err()
+}
+
+// GetStore returns a store by id
+func (r *InventoryRepository) GetStore(ctx context.Context, storeID string) (*domain.Store, error) {
+	query := `
+		SELECT id, name, COALESCE(address, ''), COALESCE(phone, ''), is_active, created_at, updated_at
+		FROM stores
+		WHERE id = $1`
+
+	var store domain.Store
+	err := r.db.QueryRowContext(ctx, query, storeID).Scan(
+		&store.ID, &store.Name, &store.Address, &store.Phone, &store.IsActive, &store.CreatedAt, &store.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get store: %w", err)
+	}
+
+	return &store, nil
+}
+
+// ListCategories returns the active categories
+func (r *InventoryRepository) ListCategories(ctx context.Context) ([]domain.Category, error) {
+	query := `
+		SELECT id, name, COALESCE(color, ''), is_active, created_at, updated_at
+		FROM categories
+		WHERE is_active = true
+		ORDER BY name ASC`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	var categories []domain.Category
+	for rows.Next() {
+		var category domain.Category
+		if err := rows.Scan(&category.ID, &category.Name, &category.Color, &category.IsActive, &category.CreatedAt, &category.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+
+	return categories, rows.Err()
+}
+
+const stockLevelQuery = `
+	SELECT sl.product_id, sl.store_id, s.name, sl.quantity_on_hand, sl.reorder_level, sl.max_stock,
+		sl.quantity_on_hand <= sl.reorder_level, sl.last_updated
+	FROM stock_levels sl
+	JOIN stores s ON s.id = sl.store_id`
+
+// GetProductStock returns the stock of a product at every store
+func (r *InventoryRepository) GetProductStock(ctx context.Context, productID string) ([]domain.StockLevel, error) {
+	return r.queryStockLevels(ctx, stockLevelQuery+" WHERE sl.product_id = $1 ORDER BY s.name ASC", productID)
+}
+
+// GetStoreStock returns the stock of every product at a store
+func (r *InventoryRepository) GetStoreStock(ctx context.Context, storeID string) ([]domain.StockLevel, error) {
+	return r.queryStockLevels(ctx, stockLevelQuery+" WHERE sl.store_id = $1 ORDER BY sl.product_id ASC", storeID)
+}
+
+func (r *InventoryRepository) queryStockLevels(ctx context.Context, query string, args ...interface{}) ([]domain.StockLevel, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock levels: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []domain.StockLevel
+	for rows.Next() {
+		level, err := scanStockLevel(rows)
+		if err != nil {
+			return nil, err
+		}
+		levels = append(levels, *level)
+	}
+
+	return levels, rows.Err()
+}
+
+// GetStockPositions returns stock levels with product names and cost, optionally for one store
+// and only those at or below their reorder level
+func (r *InventoryRepository) GetStockPositions(ctx context.Context, storeID string, lowOnly bool) ([]domain.StockPosition, error) {
+	query := `
+		SELECT sl.product_id, sl.store_id, s.name, sl.quantity_on_hand, sl.reorder_level, sl.max_stock,
+			sl.quantity_on_hand <= sl.reorder_level, sl.last_updated,
+			p.name, COALESCE(p.category_name, ''), p.cost_price
+		FROM stock_levels sl
+		JOIN stores s ON s.id = sl.store_id
+		JOIN products p ON p.id = sl.product_id
+		WHERE p.is_active = true
+		AND ($1 = '' OR sl.store_id = $1)
+		AND (NOT $2 OR sl.quantity_on_hand <= sl.reorder_level)
+		ORDER BY s.name ASC, p.name ASC`
+
+	rows, err := r.db.QueryContext(ctx, query, storeID, lowOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query stock positions: %w", err)
+	}
+	defer rows.Close()
+
+	var positions []domain.StockPosition
+	for rows.Next() {
+		var position domain.StockPosition
+		if err := rows.Scan(
+			&position.ProductID,
+			&position.StoreID,
+			&position.StoreName,
+			&position.QuantityOnHand,
+			&position.ReorderLevel,
+			&position.MaxStock,
+			&position.IsLowStock,
+			&position.LastUpdated,
+			&position.ProductName,
+			&position.CategoryName,
+			&position.CostPrice,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stock position: %w", err)
+		}
+		positions = append(positions, position)
+	}
+
+	return positions, rows.Err()
+}
+
+// ApplyMovement applies the signed movement quantity to the stock level under a row lock and
+// records the movement in the same transaction
+func (r *InventoryRepository) ApplyMovement(ctx context.Context, movement *domain.StockMovement) (*domain.StockLevel, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Products and stores are synced from Loyverse, so a first movement creates the stock row
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO stock_levels (product_id, store_id, quantity_on_hand)
+		SELECT p.id, s.id, 0 FROM products p, stores s WHERE p.id = $1 AND s.id = $2
+		ON CONFLICT (product_id, store_id) DO NOTHING`,
+		movement.ProductID, movement.StoreID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stock level: %w", err)
+	}
+
+	level, err := scanStockLevel(tx.QueryRowContext(ctx, `
+		SELECT sl.product_id, sl.store_id, s.name, sl.quantity_on_hand, sl.reorder_level, sl.max_stock,
+			sl.quantity_on_hand <= sl.reorder_level, sl.last_updated
+		FROM stock_levels sl
+		JOIN stores s ON s.id = sl.store_id
+		WHERE sl.product_id = $1 AND sl.store_id = $2
+		FOR UPDATE OF sl`,
+		movement.ProductID, movement.StoreID,
+	))
+	if err == sql.ErrNoRows {
+		if _, err := r.GetProduct(ctx, movement.ProductID); err != nil {
+			return nil, err
+		}
+		return nil, domain.ErrStoreNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	movement.QuantityBefore = level.QuantityOnHand
+	movement.QuantityAfter = level.QuantityOnHand + movement.Quantity
+	if movement.QuantityAfter < 0 {
+		return nil, fmt.Errorf("%w: %.3f on hand, %.3f requested", domain.ErrInsufficientStock, level.QuantityOnHand, -movement.Quantity)
+	}
+
+	now := time.Now()
+	_, err = tx.ExecContext(ctx, `
+		UPDATE stock_levels SET quantity_on_hand = $3, last_updated = $4
+		WHERE product_id = $1 AND store_id = $2`,
+		movement.ProductID, movement.StoreID, movement.QuantityAfter, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update stock level: %w", err)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO stock_movements (
+			product_id, store_id, movement_type, quantity, quantity_before, quantity_after,
+			reference, notes, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id`,
+		movement.ProductID,
+		movement.StoreID,
+		movement.MovementType,
+		movement.Quantity,
+		movement.QuantityBefore,
+		movement.QuantityAfter,
+		movement.Reference,
+		movement.Notes,
+		now,
+	).Scan(&movement.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record stock movement: %w", err)
+	}
+	movement.CreatedAt = now
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit stock movement: %w", err)
+	}
+
+	level.QuantityOnHand = movement.QuantityAfter
+	level.IsLowStock = level.QuantityOnHand <= level.ReorderLevel
+	level.LastUpdated = now
+	return level, nil
+}
+
+// ListMovements returns movements matching the filter, newest first
+func (r *InventoryRepository) ListMovements(ctx context.Context, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	var where []string
+	var args []interface{}
+	addCondition := func(condition string, value interface{}) {
+		args = append(args, value)
+		where = append(where, fmt.Sprintf(condition, len(args)))
+	}
+
+	if filter.ProductID != "" {
+		addCondition("product_id = $%d", filter.ProductID)
+	}
+	if filter.StoreID != "" {
+		addCondition("store_id = $%d", filter.StoreID)
+	}
+	if filter.MovementType != "" {
+		addCondition("movement_type = $%d", filter.MovementType)
+	}
+	if !filter.From.IsZero() {
+		addCondition("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		addCondition("created_at < $%d", filter.To)
+	}
+
+	query := `
+		SELECT id, product_id, store_id, movement_type, quantity, quantity_before, quantity_after,
+			COALESCE(reference, ''), COALESCE(notes, ''), created_at
+		FROM stock_movements`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY created_at DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit, filter.Offset)
+		query += fmt.Sprintf(" LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stock movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []domain.StockMovement
+	for rows.Next() {
+		var movement domain.StockMovement
+		if err := rows.Scan(
+			&movement.ID,
+			&movement.ProductID,
+			&movement.StoreID,
+			&movement.MovementType,
+			&movement.Quantity,
+			&movement.QuantityBefore,
+			&movement.QuantityAfter,
+			&movement.Reference,
+			&movement.Notes,
+			&movement.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan stock movement: %w", err)
+		}
+		movements = append(movements, movement)
+	}
+
+	return movements, rows.Err()
+}
+
+const alertQuery = `
+	SELECT a.id, a.product_id, p.name, a.store_id, s.name, a.alert_type, a.current_qty, a.threshold_qty,
+		a.severity, a.is_resolved, a.created_at, a.resolved_at
+	FROM inventory_alerts a
+	JOIN products p ON p.id = a.product_id
+	JOIN stores s ON s.id = a.store_id`
+
+// ListAlerts returns alerts matching the filter, most severe and newest first
+func (r *InventoryRepository) ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]domain.InventoryAlert, error) {
+	query := alertQuery + `
+		WHERE ($1 = '' OR a.store_id = $1)
+		AND ($2 = '' OR a.product_id = $2)
+		AND ($3 = '' OR a.alert_type = $3)
+		AND ($4 OR a.is_resolved = false)
+		ORDER BY CASE a.severity WHEN 'HIGH' THEN 1 WHEN 'MEDIUM' THEN 2 ELSE 3 END, a.created_at DESC`
+
+	rows, err := r.db.QueryContext(ctx, query, filter.StoreID, filter.ProductID, filter.AlertType, filter.IncludeResolved)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list alerts: %w", err)
+	}
+	defer rows.Close()
+
+	var alerts []domain.InventoryAlert
+	for rows.Next() {
+		alert, err := scanAlert(rows)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, *alert)
+	}
+
+	return alerts, rows.Err()
+}
+
+// GetAlert returns an alert by id
+func (r *InventoryRepository) GetAlert(ctx context.Context, alertID string) (*domain.InventoryAlert, error) {
+	alert, err := scanAlert(r.db.QueryRowContext(ctx, alertQuery+" WHERE a.id::text = $1", alertID))
+	if err == sql.ErrNoRows {
+		return nil, domain.ErrAlertNotFound
+	}
+	return alert, err
+}
+
+// CreateAlert stores an open alert. An open alert of the same type for the product and store
+// is refreshed with the new quantity instead of duplicated.
+func (r *InventoryRepository) CreateAlert(ctx context.Context, alert *domain.InventoryAlert) error {
+	query := `
+		INSERT INTO inventory_alerts (
+			product_id, store_id, alert_type, current_qty, threshold_qty, severity, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (product_id, store_id, alert_type) WHERE is_resolved = false
+		DO UPDATE SET current_qty = EXCLUDED.current_qty, threshold_qty = EXCLUDED.threshold_qty,
+			severity = EXCLUDED.severity
+		RETURNING id, created_at`
+
+	err := r.db.QueryRowContext(ctx, query,
+		alert.ProductID,
+		alert.StoreID,
+		alert.AlertType,
+		alert.CurrentQty,
+		alert.ThresholdQty,
+		alert.Severity,
+		alert.CreatedAt,
+	).Scan(&alert.ID, &alert.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create alert: %w", err)
+	}
+
+	return nil
+}
+
+// ResolveAlert marks an open alert resolved
+func (r *InventoryRepository) ResolveAlert(ctx context.Context, alertID string, resolvedAt time.Time) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE inventory_alerts SET is_resolved = true, resolved_at = $2
+		WHERE id::text = $1 AND is_resolved = false`,
+		alertID, resolvedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to resolve alert: %w", err)
+	}
+
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		if _, err := r.GetAlert(ctx, alertID); err != nil {
+			return err
+		}
+		return domain.ErrAlertResolved
+	}
+
+	return nil
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanProduct(row rowScanner) (*domain.Product, error) {
+	var product domain.Product
+	err := row.Scan(
+		&product.ID,
+		&product.Name,
+		&product.SKU,
+		&product.Barcode,
+		&product.CategoryID,
+		&product.CategoryName,
+		&product.SupplierID,
+		&product.SupplierName,
+		&product.CostPrice,
+		&product.SellPrice,
+		&product.Unit,
+		&product.Description,
+		&product.IsActive,
+		&product.LastUpdated,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan product: %w", err)
+	}
+	return &product, nil
+}
+
+func scanStockLevel(row rowScanner) (*domain.StockLevel, error) {
+	var level domain.StockLevel
+	err := row.Scan(
+		&level.ProductID,
+		&level.StoreID,
+		&level.StoreName,
+		&level.QuantityOnHand,
+		&level.ReorderLevel,
+		&level.MaxStock,
+		&level.IsLowStock,
+		&level.LastUpdated,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan stock level: %w", err)
+	}
+	return &level, nil
+}
+
+func scanAlert(row rowScanner) (*domain.InventoryAlert, error) {
+	var alert domain.InventoryAlert
+	err := row.Scan(
+		&alert.ID,
+		&alert.ProductID,
+		&alert.ProductName,
+		&alert.StoreID,
+		&alert.StoreName,
+		&alert.AlertType,
+		&alert.CurrentQty,
+		&alert.ThresholdQty,
+		&alert.Severity,
+		&alert.IsResolved,
+		&alert.CreatedAt,
+		&alert.ResolvedAt,
+	)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan alert: %w", err)
+	}
+	return &alert, nil
+}
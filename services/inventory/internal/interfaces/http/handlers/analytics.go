@@ -0,0 +1,197 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"inventory/internal/application"
+	"inventory/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type AnalyticsHandler struct {
+	service *application.AnalyticsService
+	logger  *logrus.Logger
+}
+
+func NewAnalyticsHandler(service *application.AnalyticsService, logger *logrus.Logger) *AnalyticsHandler {
+	return &AnalyticsHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// GetDashboard returns analytics dashboard data
+func (h *AnalyticsHandler) GetDashboard(c *gin.Context) {
+	dashboard, err := h.service.GetDashboard(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to build dashboard")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    dashboard,
+	})
+}
+
+// GetProductPerformance returns the best selling products, over the last 30 days by default
+func (h *AnalyticsHandler) GetProductPerformance(c *gin.Context) {
+	from, to, ok := h.dateRange(c, 30)
+	if !ok {
+		return
+	}
+
+	performance, err := h.service.GetProductPerformance(c.Request.Context(), from, to, queryInt(c, "limit", 20))
+	if err != nil {
+		h.respondError(c, err, "Failed to get product performance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"performance": performance,
+			"count":       len(performance),
+		},
+	})
+}
+
+// GetCategoryPerformance returns category performance, over the last 30 days by default
+func (h *AnalyticsHandler) GetCategoryPerformance(c *gin.Context) {
+	from, to, ok := h.dateRange(c, 30)
+	if !ok {
+		return
+	}
+
+	performance, err := h.service.GetCategoryPerformance(c.Request.Context(), from, to)
+	if err != nil {
+		h.respondError(c, err, "Failed to get category performance")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"performance": performance,
+			"count":       len(performance),
+		},
+	})
+}
+
+// GetDailyTrends returns daily movement totals, over the last 14 days by default
+func (h *AnalyticsHandler) GetDailyTrends(c *gin.Context) {
+	from, to, ok := h.dateRange(c, 14)
+	if !ok {
+		return
+	}
+
+	trends, err := h.service.GetDailyTrends(c.Request.Context(), from, to)
+	if err != nil {
+		h.respondError(c, err, "Failed to get daily trends")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"trends": trends,
+			"count":  len(trends),
+		},
+	})
+}
+
+// GetWeeklyTrends returns weekly sales with growth over the previous week
+func (h *AnalyticsHandler) GetWeeklyTrends(c *gin.Context) {
+	trends, err := h.service.GetWeeklyTrends(c.Request.Context(), queryInt(c, "weeks", 4))
+	if err != nil {
+		h.respondError(c, err, "Failed to get weekly trends")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"trends": trends,
+			"count":  len(trends),
+		},
+	})
+}
+
+// GetReorderSuggestions returns reorder suggestions, optionally for one store
+func (h *AnalyticsHandler) GetReorderSuggestions(c *gin.Context) {
+	suggestions, err := h.service.GetReorderSuggestions(c.Request.Context(), c.Query("store_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get reorder suggestions")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"suggestions": suggestions,
+			"count":       len(suggestions),
+		},
+	})
+}
+
+// GetStockAlerts returns stock that is out, low or about to run out, optionally for one store
+func (h *AnalyticsHandler) GetStockAlerts(c *gin.Context) {
+	alerts, err := h.service.GetStockAlerts(c.Request.Context(), c.Query("store_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to get stock alerts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"alerts": alerts,
+			"count":  len(alerts),
+		},
+	})
+}
+
+// dateRange reads the from and to query dates, defaulting to the last days up to now. The
+// response is written and ok is false when a date or the range is invalid.
+func (h *AnalyticsHandler) dateRange(c *gin.Context, days int) (time.Time, time.Time, bool) {
+	from, err := queryDate(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date", "message": err.Error()})
+		return time.Time{}, time.Time{}, false
+	}
+	to, err := queryDate(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date", "message": err.Error()})
+		return time.Time{}, time.Time{}, false
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		h.respondError(c, domain.ErrInvalidDateRange, "Invalid date range")
+		return time.Time{}, time.Time{}, false
+	}
+
+	if to.IsZero() {
+		to = time.Now()
+	} else {
+		to = to.AddDate(0, 0, 1) // Include the whole end day
+	}
+	if from.IsZero() {
+		from = to.AddDate(0, 0, -days)
+	}
+
+	return from, to, true
+}
+
+func (h *AnalyticsHandler) respondError(c *gin.Context, err error, message string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		h.logger.WithError(err).WithField("path", c.FullPath()).Error(message)
+	}
+
+	c.JSON(status, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"inventory/internal/application"
+	"inventory/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// memoryAnalyticsRepository serves fixed aggregates and daily sales
+type memoryAnalyticsRepository struct {
+	domain.AnalyticsRepository
+	products   []domain.ProductPerformance
+	categories []domain.CategoryPerformance
+	sales      []domain.DailySales
+}
+
+func (r *memoryAnalyticsRepository) GetStockSummary(ctx context.Context) (*domain.Analytics, error) {
+	return &domain.Analytics{TotalProducts: 2, TotalStores: 2, LowStockItems: 2}, nil
+}
+
+func (r *memoryAnalyticsRepository) GetProductPerformance(ctx context.Context, from, to time.Time, limit int) ([]domain.ProductPerformance, error) {
+	return r.products, nil
+}
+
+func (r *memoryAnalyticsRepository) GetCategoryPerformance(ctx context.Context, from, to time.Time) ([]domain.CategoryPerformance, error) {
+	return r.categories, nil
+}
+
+func (r *memoryAnalyticsRepository) GetDailyMovements(ctx context.Context, from, to time.Time) ([]domain.DailyMovement, error) {
+	return nil, nil
+}
+
+func (r *memoryAnalyticsRepository) GetWeeklyTrends(ctx context.Context, from, to time.Time) ([]domain.WeeklyTrend, error) {
+	return nil, nil
+}
+
+func (r *memoryAnalyticsRepository) GetDailySales(ctx context.Context, from, to time.Time) ([]domain.DailySales, error) {
+	var sales []domain.DailySales
+	for _, day := range r.sales {
+		if !day.Date.Before(from) && day.Date.Before(to) {
+			sales = append(sales, day)
+		}
+	}
+	return sales, nil
+}
+
+// memoryForecastRepository keeps suppliers and the latest forecast run in memory
+type memoryForecastRepository struct {
+	products    map[string]*domain.Product
+	suppliers   map[string]*domain.Supplier
+	forecasts   []domain.DemandForecast
+	suggestions []domain.ReorderSuggestion
+}
+
+func newMemoryForecastRepository(inventory *memoryInventoryRepository) *memoryForecastRepository {
+	return &memoryForecastRepository{products: inventory.products, suppliers: make(map[string]*domain.Supplier)}
+}
+
+func (r *memoryForecastRepository) UpsertSupplier(ctx context.Context, supplier *domain.Supplier) error {
+	copied := *supplier
+	r.suppliers[supplier.ID] = &copied
+	return nil
+}
+
+func (r *memoryForecastRepository) GetLeadTimes(ctx context.Context) (map[string]int, error) {
+	leadTimes := make(map[string]int)
+	for id, product := range r.products {
+		if supplier, ok := r.suppliers[product.SupplierID]; ok {
+			leadTimes[id] = supplier.LeadTimeDays
+		}
+	}
+	return leadTimes, nil
+}
+
+func (r *memoryForecastRepository) ListForecasts(ctx context.Context, storeID string) ([]domain.DemandForecast, error) {
+	var forecasts []domain.DemandForecast
+	for _, forecast := range r.forecasts {
+		if storeID == "" || forecast.StoreID == storeID {
+			forecasts = append(forecasts, forecast)
+		}
+	}
+	return forecasts, nil
+}
+
+func (r *memoryForecastRepository) ListReorderSuggestions(ctx context.Context, storeID string) ([]domain.ReorderSuggestion, error) {
+	var suggestions []domain.ReorderSuggestion
+	for _, suggestion := range r.suggestions {
+		if storeID == "" || suggestion.StoreID == storeID {
+			suggestions = append(suggestions, suggestion)
+		}
+	}
+	return suggestions, nil
+}
+
+func (r *memoryForecastRepository) SaveForecastRun(ctx context.Context, forecasts []domain.DemandForecast, suggestions []domain.ReorderSuggestion) error {
+	r.forecasts, r.suggestions = forecasts, suggestions
+	return nil
+}
+
+func newAnalyticsRouter(repo *memoryAnalyticsRepository, inventory *memoryInventoryRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := newTestLogger()
+	forecasts := newMemoryForecastRepository(inventory)
+	handler := NewAnalyticsHandler(application.NewAnalyticsService(repo, inventory, forecasts, logger), logger)
+	forecastHandler := NewForecastHandler(application.NewForecastService(inventory, repo, forecasts, logger), logger)
+
+	router := gin.New()
+	analytics := router.Group("/api/v1/analytics")
+	analytics.GET("/dashboard", handler.GetDashboard)
+	analytics.GET("/performance/products", handler.GetProductPerformance)
+	analytics.GET("/performance/categories", handler.GetCategoryPerformance)
+	analytics.GET("/suggestions/reorder", handler.GetReorderSuggestions)
+	analytics.GET("/suggestions/alerts", handler.GetStockAlerts)
+
+	admin := router.Group("/api/v1/admin")
+	admin.POST("/forecast/run", forecastHandler.RunForecast)
+	admin.PUT("/suppliers/:id", forecastHandler.UpsertSupplier)
+	return router
+}
+
+func TestAnalyticsPerformanceRanking(t *testing.T) {
+	repo := &memoryAnalyticsRepository{
+		products: []domain.ProductPerformance{
+			{ProductID: "P1", TotalSold: 40, Revenue: 7560, CurrentStock: 20},
+			{ProductID: "P2", TotalSold: 10, Revenue: 350, CurrentStock: 0},
+		},
+		categories: []domain.CategoryPerformance{
+			{CategoryID: "C2", Revenue: 350},
+			{CategoryID: "C1", Revenue: 7560},
+		},
+	}
+	router := newAnalyticsRouter(repo, newMemoryInventoryRepository())
+
+	var products struct {
+		Performance []domain.ProductPerformance `json:"performance"`
+	}
+	if code := serve(t, router, http.MethodGet, "/api/v1/analytics/performance/products?from=2024-05-01&to=2024-05-31", nil, &products); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if products.Performance[0].Rank != 1 || products.Performance[0].TurnoverRate != 2 {
+		t.Fatalf("unexpected top product %+v", products.Performance[0])
+	}
+
+	var categories struct {
+		Performance []domain.CategoryPerformance `json:"performance"`
+	}
+	serve(t, router, http.MethodGet, "/api/v1/analytics/performance/categories", nil, &categories)
+	if categories.Performance[0].CategoryID != "C1" || categories.Performance[0].Rank != 1 {
+		t.Fatalf("expected C1 ranked first by revenue, got %+v", categories.Performance)
+	}
+
+	if code := serve(t, router, http.MethodGet, "/api/v1/analytics/performance/products?from=2024-05-31&to=2024-05-01", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for reversed range, got %d", code)
+	}
+
+	var dashboard domain.Analytics
+	if code := serve(t, router, http.MethodGet, "/api/v1/analytics/dashboard", nil, &dashboard); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if dashboard.TotalProducts != 2 || len(dashboard.TopSellingProducts) != 2 {
+		t.Fatalf("unexpected dashboard %+v", dashboard)
+	}
+}
+
+func TestForecastRunRanksSuggestionsAndFillsDaysToStockout(t *testing.T) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	repo := &memoryAnalyticsRepository{}
+	// P1 sells 4 a day at S1, so its 20 units run out on the fifth day
+	for day := 1; day <= 56; day++ {
+		repo.sales = append(repo.sales, domain.DailySales{ProductID: "P1", StoreID: "S1", Date: today.AddDate(0, 0, -day), Quantity: 4})
+	}
+	inventory := newMemoryInventoryRepository()
+	inventory.products["P1"].SupplierID = "SUP1"
+	router := newAnalyticsRouter(repo, inventory)
+
+	if code := serve(t, router, http.MethodPut, "/api/v1/admin/suppliers/SUP1", map[string]interface{}{"lead_time_days": -2}, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for negative lead time, got %d", code)
+	}
+	if code := serve(t, router, http.MethodPut, "/api/v1/admin/suppliers/SUP1", map[string]interface{}{"name": "Rice Mill", "lead_time_days": 5}, nil); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+
+	var run application.ForecastRun
+	if code := serve(t, router, http.MethodPost, "/api/v1/admin/forecast/run", nil, &run); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if run.Forecasts != 1 || run.Suggestions != 3 {
+		t.Fatalf("expected 1 forecast and 3 suggestions, got %+v", run)
+	}
+
+	var suggestions struct {
+		Suggestions []domain.ReorderSuggestion `json:"suggestions"`
+	}
+	if code := serve(t, router, http.MethodGet, "/api/v1/analytics/suggestions/reorder?store_id=S1", nil, &suggestions); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(suggestions.Suggestions) != 2 {
+		t.Fatalf("expected 2 suggestions at S1, got %+v", suggestions.Suggestions)
+	}
+
+	// Both run out within their lead time; the larger order ranks first
+	rice := suggestions.Suggestions[0]
+	if rice.ProductID != "P1" || rice.Priority != 1 || rice.LeadTimeDays != 5 {
+		t.Fatalf("expected P1 ranked first with the supplier lead time, got %+v", rice)
+	}
+	// 12 days of demand at 4 a day less the 20 on hand
+	if rice.EstimatedDemand != 48 || rice.SuggestedQty != 28 || rice.ReasonCode != domain.ReasonLowStock {
+		t.Fatalf("unexpected P1 suggestion %+v", rice)
+	}
+	if sauce := suggestions.Suggestions[1]; sauce.ProductID != "P2" || sauce.SuggestedQty != 20 || sauce.LeadTimeDays != 3 {
+		t.Fatalf("unexpected P2 suggestion %+v", sauce)
+	}
+
+	var alerts struct {
+		Alerts []domain.StockAlert `json:"alerts"`
+	}
+	serve(t, router, http.MethodGet, "/api/v1/analytics/suggestions/alerts", nil, &alerts)
+	if len(alerts.Alerts) != 3 {
+		t.Fatalf("expected alerts for P2 at S1, P1 at S2 and P1 at S1, got %+v", alerts.Alerts)
+	}
+	if alerts.Alerts[0].Type != domain.AlertOutOfStock || alerts.Alerts[0].Severity != domain.SeverityHigh {
+		t.Fatalf("expected out of stock first, got %+v", alerts.Alerts[0])
+	}
+	forecastAlert := alerts.Alerts[2]
+	if forecastAlert.ProductID != "P1" || forecastAlert.StoreID != "S1" || forecastAlert.DaysToStockout != 4 {
+		t.Fatalf("expected P1 at S1 to run out in 4 days, got %+v", forecastAlert)
+	}
+	if forecastAlert.LastSaleDate == nil {
+		t.Fatalf("expected last sale date from the forecast")
+	}
+	if alerts.Alerts[1].DaysToStockout != -1 {
+		t.Fatalf("expected no stockout estimate without sales, got %d", alerts.Alerts[1].DaysToStockout)
+	}
+
+	if code := serve(t, router, http.MethodGet, "/api/v1/analytics/suggestions/alerts?store_id=S9", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown store, got %d", code)
+	}
+}
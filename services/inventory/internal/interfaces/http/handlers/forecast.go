@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"inventory/internal/application"
+	"inventory/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type ForecastHandler struct {
+	service *application.ForecastService
+	logger  *logrus.Logger
+}
+
+func NewForecastHandler(service *application.ForecastService, logger *logrus.Logger) *ForecastHandler {
+	return &ForecastHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// RunForecast forecasts demand and refreshes reorder suggestions now instead of waiting for
+// the scheduled run
+func (h *ForecastHandler) RunForecast(c *gin.Context) {
+	run, err := h.service.Run(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to run demand forecast")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    run,
+	})
+}
+
+// UpsertSupplier creates or updates a supplier's lead time
+func (h *ForecastHandler) UpsertSupplier(c *gin.Context) {
+	var req struct {
+		Name         string `json:"name"`
+		LeadTimeDays int    `json:"lead_time_days" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	supplier := &domain.Supplier{
+		ID:           c.Param("id"),
+		Name:         req.Name,
+		LeadTimeDays: req.LeadTimeDays,
+	}
+	if err := h.service.UpsertSupplier(c.Request.Context(), supplier); err != nil {
+		h.respondError(c, err, "Failed to save supplier")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    supplier,
+	})
+}
+
+func (h *ForecastHandler) respondError(c *gin.Context, err error, message string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		h.logger.WithError(err).WithField("path", c.FullPath()).Error(message)
+	}
+
+	c.JSON(status, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
@@ -3,20 +3,20 @@ package handlers
 import (
 	"net/http"
 
-	"services/inventory/internal/infrastructure/postgres"
-	"services/inventory/internal/infrastructure/redis"
+	"inventory/internal/infrastructure/cache"
+	"inventory/internal/infrastructure/database"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
 type HealthHandler struct {
-	redisClient *redis.Client
-	dbConn      *postgres.Connection
+	redisClient *cache.RedisClient
+	dbConn      *database.Connection
 	logger      *logrus.Logger
 }
 
-func NewHealthHandler(redisClient *redis.Client, dbConn *postgres.Connection, logger *logrus.Logger) *HealthHandler {
+func NewHealthHandler(redisClient *cache.RedisClient, dbConn *database.Connection, logger *logrus.Logger) *HealthHandler {
 	return &HealthHandler{
 		redisClient: redisClient,
 		dbConn:      dbConn,
@@ -27,9 +27,8 @@ func NewHealthHandler(redisClient *redis.Client, dbConn *postgres.Connection, lo
 // HealthCheck performs basic health check
 func (h *HealthHandler) HealthCheck(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{
-		"status":  "healthy",
-		"service": "inventory-service",
-		"version": "1.0.0",
+		"status":  "ok",
+		"service": "inventory",
 	})
 }
 
@@ -50,18 +49,16 @@ func (h *HealthHandler) ReadinessCheck(c *gin.Context) {
 	}
 
 	statusCode := http.StatusOK
+	status := "ready"
 	if !allHealthy {
 		statusCode = http.StatusServiceUnavailable
+		status = "not_ready"
 	}
 
 	c.JSON(statusCode, gin.H{
-		"status": func() string {
-			if allHealthy {
-				return "ready"
-			}
-			return "not_ready"
-		}(),
-		"checks": checks,
+		"status":  status,
+		"service": "inventory",
+		"checks":  checks,
 	})
 }
 
@@ -74,7 +71,7 @@ func (h *HealthHandler) checkRedis(c *gin.Context) string {
 }
 
 func (h *HealthHandler) checkDatabase() string {
-	if err := h.dbConn.DB.Ping(); err != nil {
+	if err := h.dbConn.Health(); err != nil {
 		h.logger.WithError(err).Error("Database health check failed")
 		return "unhealthy"
 	}
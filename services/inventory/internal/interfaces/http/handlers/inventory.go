@@ -0,0 +1,375 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"inventory/internal/application"
+	"inventory/internal/domain"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+type InventoryHandler struct {
+	service *application.InventoryService
+	logger  *logrus.Logger
+}
+
+func NewInventoryHandler(service *application.InventoryService, logger *logrus.Logger) *InventoryHandler {
+	return &InventoryHandler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// ===== PRODUCT ENDPOINTS =====
+
+// GetAllProducts retrieves a page of products
+func (h *InventoryHandler) GetAllProducts(c *gin.Context) {
+	limit := queryInt(c, "limit", 50)
+	page := queryInt(c, "page", 1)
+
+	products, total, err := h.service.ListProducts(c.Request.Context(), domain.ProductFilter{
+		CategoryID: c.Query("category_id"),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve products")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"products": products,
+			"pagination": gin.H{
+				"page":        page,
+				"limit":       limit,
+				"total":       total,
+				"total_pages": (total + limit - 1) / limit,
+			},
+		},
+	})
+}
+
+// GetProduct retrieves a single product by ID with its stock levels
+func (h *InventoryHandler) GetProduct(c *gin.Context) {
+	productID := c.Param("id")
+
+	product, err := h.service.GetProduct(c.Request.Context(), productID)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve product")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    product,
+	})
+}
+
+// GetProductStock retrieves stock levels for a specific product
+func (h *InventoryHandler) GetProductStock(c *gin.Context) {
+	productID := c.Param("id")
+
+	stockLevels, err := h.service.GetProductStock(c.Request.Context(), productID)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve stock levels")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"product_id":   productID,
+			"stock_levels": stockLevels,
+		},
+	})
+}
+
+// SearchProducts searches for products by name, SKU or barcode
+func (h *InventoryHandler) SearchProducts(c *gin.Context) {
+	query := c.Query("q")
+	if query == "" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Missing search query",
+			"message": "Query parameter 'q' is required",
+		})
+		return
+	}
+
+	products, _, err := h.service.ListProducts(c.Request.Context(), domain.ProductFilter{
+		Query: query,
+		Limit: queryInt(c, "limit", 20),
+	})
+	if err != nil {
+		h.respondError(c, err, "Search failed")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"query":    query,
+			"products": products,
+			"count":    len(products),
+		},
+	})
+}
+
+// ===== STORE ENDPOINTS =====
+
+// GetAllStores retrieves all stores
+func (h *InventoryHandler) GetAllStores(c *gin.Context) {
+	stores, err := h.service.ListStores(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve stores")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"stores": stores,
+			"count":  len(stores),
+		},
+	})
+}
+
+// GetStoreStock retrieves stock levels for a specific store
+func (h *InventoryHandler) GetStoreStock(c *gin.Context) {
+	storeID := c.Param("id")
+
+	stockLevels, err := h.service.GetStoreStock(c.Request.Context(), storeID)
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve store stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"store_id":     storeID,
+			"stock_levels": stockLevels,
+			"count":        len(stockLevels),
+		},
+	})
+}
+
+// ===== CATEGORY ENDPOINTS =====
+
+// GetAllCategories retrieves all categories
+func (h *InventoryHandler) GetAllCategories(c *gin.Context) {
+	categories, err := h.service.ListCategories(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve categories")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"categories": categories,
+			"count":      len(categories),
+		},
+	})
+}
+
+// ===== STOCK ENDPOINTS =====
+
+// GetLowStockItems retrieves stock at or below its reorder level, optionally for one store
+func (h *InventoryHandler) GetLowStockItems(c *gin.Context) {
+	lowStockItems, err := h.service.GetLowStockItems(c.Request.Context(), c.Query("store_id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve low stock items")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"low_stock_items": lowStockItems,
+			"count":           len(lowStockItems),
+		},
+	})
+}
+
+// RecordMovement applies a sale, purchase, adjustment or transfer to a store's stock
+func (h *InventoryHandler) RecordMovement(c *gin.Context) {
+	var req struct {
+		ProductID    string  `json:"product_id" binding:"required"`
+		StoreID      string  `json:"store_id" binding:"required"`
+		MovementType string  `json:"movement_type" binding:"required"`
+		Quantity     float64 `json:"quantity" binding:"required"`
+		Reference    string  `json:"reference"`
+		Notes        string  `json:"notes"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid request format",
+			"message": err.Error(),
+		})
+		return
+	}
+
+	movement, stockLevel, err := h.service.RecordMovement(c.Request.Context(), application.MovementRequest{
+		ProductID:    req.ProductID,
+		StoreID:      req.StoreID,
+		MovementType: req.MovementType,
+		Quantity:     req.Quantity,
+		Reference:    req.Reference,
+		Notes:        req.Notes,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to record stock movement")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data": gin.H{
+			"movement":    movement,
+			"stock_level": stockLevel,
+		},
+	})
+}
+
+// GetMovements retrieves movement history filtered by product, store, type and date range
+func (h *InventoryHandler) GetMovements(c *gin.Context) {
+	from, err := queryDate(c, "from")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from date", "message": err.Error()})
+		return
+	}
+	to, err := queryDate(c, "to")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to date", "message": err.Error()})
+		return
+	}
+	if !from.IsZero() && !to.IsZero() && to.Before(from) {
+		h.respondError(c, domain.ErrInvalidDateRange, "Invalid date range")
+		return
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1) // Include the whole end day
+	}
+
+	limit := queryInt(c, "limit", 100)
+	page := queryInt(c, "page", 1)
+
+	movements, err := h.service.GetMovements(c.Request.Context(), domain.MovementFilter{
+		ProductID:    c.Query("product_id"),
+		StoreID:      c.Query("store_id"),
+		MovementType: c.Query("type"),
+		From:         from,
+		To:           to,
+		Limit:        limit,
+		Offset:       (page - 1) * limit,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve stock movements")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"movements": movements,
+			"count":     len(movements),
+			"page":      page,
+			"limit":     limit,
+		},
+	})
+}
+
+// ===== ALERT ENDPOINTS =====
+
+// GetInventoryAlerts retrieves open alerts, or all alerts with include_resolved=true
+func (h *InventoryHandler) GetInventoryAlerts(c *gin.Context) {
+	includeResolved, _ := strconv.ParseBool(c.Query("include_resolved"))
+
+	alerts, err := h.service.ListAlerts(c.Request.Context(), domain.AlertFilter{
+		StoreID:         c.Query("store_id"),
+		ProductID:       c.Query("product_id"),
+		AlertType:       c.Query("type"),
+		IncludeResolved: includeResolved,
+	})
+	if err != nil {
+		h.respondError(c, err, "Failed to retrieve inventory alerts")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"alerts": alerts,
+			"count":  len(alerts),
+		},
+	})
+}
+
+// ResolveAlert marks an alert resolved
+func (h *InventoryHandler) ResolveAlert(c *gin.Context) {
+	alert, err := h.service.ResolveAlert(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.respondError(c, err, "Failed to resolve alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    alert,
+	})
+}
+
+// respondError maps domain errors to HTTP status codes and logs unexpected failures
+func (h *InventoryHandler) respondError(c *gin.Context, err error, message string) {
+	status := errorStatus(err)
+	if status == http.StatusInternalServerError {
+		h.logger.WithError(err).WithField("path", c.FullPath()).Error(message)
+	}
+
+	c.JSON(status, gin.H{
+		"error":   message,
+		"message": err.Error(),
+	})
+}
+
+func errorStatus(err error) int {
+	switch {
+	case errors.Is(err, domain.ErrProductNotFound), errors.Is(err, domain.ErrStoreNotFound),
+		errors.Is(err, domain.ErrAlertNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, domain.ErrInvalidMovement), errors.Is(err, domain.ErrInvalidDateRange),
+		errors.Is(err, domain.ErrInvalidSupplier):
+		return http.StatusBadRequest
+	case errors.Is(err, domain.ErrInsufficientStock), errors.Is(err, domain.ErrAlertResolved):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// queryInt returns a positive integer query parameter or the default
+func queryInt(c *gin.Context, name string, defaultValue int) int {
+	if value := c.Query(name); value != "" {
+		if parsed, err := strconv.Atoi(value); err == nil && parsed > 0 {
+			return parsed
+		}
+	}
+	return defaultValue
+}
+
+// queryDate parses an optional YYYY-MM-DD query parameter in local time
+func queryDate(c *gin.Context, name string) (time.Time, error) {
+	value := c.Query(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.ParseInLocation("2006-01-02", value, time.Local)
+}
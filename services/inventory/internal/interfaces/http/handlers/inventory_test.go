@@ -0,0 +1,457 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"inventory/internal/application"
+	"inventory/internal/domain"
+	"inventory/internal/infrastructure/events"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// memoryInventoryRepository keeps products, stores, stock, movements and alerts in memory
+type memoryInventoryRepository struct {
+	domain.InventoryRepository
+	products  map[string]*domain.Product
+	stores    map[string]*domain.Store
+	levels    map[string]*domain.StockLevel
+	movements []domain.StockMovement
+	alerts    []*domain.InventoryAlert
+}
+
+func newMemoryInventoryRepository() *memoryInventoryRepository {
+	repo := &memoryInventoryRepository{
+		products: map[string]*domain.Product{
+			"P1": {ID: "P1", Name: "Jasmine Rice 5kg", CategoryName: "Rice", CostPrice: 150, SellPrice: 189, IsActive: true},
+			"P2": {ID: "P2", Name: "Fish Sauce", CategoryName: "Sauces", CostPrice: 25, SellPrice: 35, IsActive: true},
+		},
+		stores: map[string]*domain.Store{
+			"S1": {ID: "S1", Name: "Bangkok", IsActive: true},
+			"S2": {ID: "S2", Name: "Chiang Mai", IsActive: true},
+		},
+		levels: make(map[string]*domain.StockLevel),
+	}
+
+	repo.setLevel("P1", "S1", 20, 5, 100)
+	repo.setLevel("P1", "S2", 3, 5, 100)
+	repo.setLevel("P2", "S1", 0, 10, 0)
+	return repo
+}
+
+func (r *memoryInventoryRepository) setLevel(productID, storeID string, onHand, reorder, max float64) {
+	r.levels[productID+"/"+storeID] = &domain.StockLevel{
+		ProductID:      productID,
+		StoreID:        storeID,
+		StoreName:      r.stores[storeID].Name,
+		QuantityOnHand: onHand,
+		ReorderLevel:   reorder,
+		MaxStock:       max,
+		IsLowStock:     onHand <= reorder,
+	}
+}
+
+func (r *memoryInventoryRepository) ListProducts(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, int, error) {
+	var products []domain.Product
+	for _, id := range []string{"P1", "P2"} {
+		product := r.products[id]
+		if filter.Query != "" && !bytes.Contains([]byte(product.Name), []byte(filter.Query)) {
+			continue
+		}
+		products = append(products, *product)
+	}
+	return products, len(products), nil
+}
+
+func (r *memoryInventoryRepository) GetProduct(ctx context.Context, productID string) (*domain.Product, error) {
+	product, ok := r.products[productID]
+	if !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	copied := *product
+	return &copied, nil
+}
+
+func (r *memoryInventoryRepository) GetStore(ctx context.Context, storeID string) (*domain.Store, error) {
+	store, ok := r.stores[storeID]
+	if !ok {
+		return nil, domain.ErrStoreNotFound
+	}
+	return store, nil
+}
+
+func (r *memoryInventoryRepository) GetProductStock(ctx context.Context, productID string) ([]domain.StockLevel, error) {
+	var levels []domain.StockLevel
+	for _, storeID := range []string{"S1", "S2"} {
+		if level, ok := r.levels[productID+"/"+storeID]; ok {
+			levels = append(levels, *level)
+		}
+	}
+	return levels, nil
+}
+
+func (r *memoryInventoryRepository) GetStoreStock(ctx context.Context, storeID string) ([]domain.StockLevel, error) {
+	var levels []domain.StockLevel
+	for _, productID := range []string{"P1", "P2"} {
+		if level, ok := r.levels[productID+"/"+storeID]; ok {
+			levels = append(levels, *level)
+		}
+	}
+	return levels, nil
+}
+
+func (r *memoryInventoryRepository) GetStockPositions(ctx context.Context, storeID string, lowOnly bool) ([]domain.StockPosition, error) {
+	var positions []domain.StockPosition
+	for _, productID := range []string{"P1", "P2"} {
+		for _, id := range []string{"S1", "S2"} {
+			level, ok := r.levels[productID+"/"+id]
+			if !ok || (storeID != "" && id != storeID) || (lowOnly && level.QuantityOnHand > level.ReorderLevel) {
+				continue
+			}
+			product := r.products[productID]
+			positions = append(positions, domain.StockPosition{
+				StockLevel:   *level,
+				ProductName:  product.Name,
+				CategoryName: product.CategoryName,
+				CostPrice:    product.CostPrice,
+			})
+		}
+	}
+	return positions, nil
+}
+
+func (r *memoryInventoryRepository) ApplyMovement(ctx context.Context, movement *domain.StockMovement) (*domain.StockLevel, error) {
+	if _, ok := r.products[movement.ProductID]; !ok {
+		return nil, domain.ErrProductNotFound
+	}
+	if _, ok := r.stores[movement.StoreID]; !ok {
+		return nil, domain.ErrStoreNotFound
+	}
+
+	key := movement.ProductID + "/" + movement.StoreID
+	if _, ok := r.levels[key]; !ok {
+		r.setLevel(movement.ProductID, movement.StoreID, 0, 0, 0)
+	}
+	level := r.levels[key]
+
+	after := level.QuantityOnHand + movement.Quantity
+	if after < 0 {
+		return nil, domain.ErrInsufficientStock
+	}
+
+	movement.ID = fmt.Sprintf("M%d", len(r.movements)+1)
+	movement.QuantityBefore = level.QuantityOnHand
+	movement.QuantityAfter = after
+	movement.CreatedAt = time.Now()
+	r.movements = append(r.movements, *movement)
+
+	level.QuantityOnHand = after
+	level.IsLowStock = after <= level.ReorderLevel
+	copied := *level
+	return &copied, nil
+}
+
+func (r *memoryInventoryRepository) ListMovements(ctx context.Context, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	var movements []domain.StockMovement
+	for i := len(r.movements) - 1; i >= 0; i-- {
+		movement := r.movements[i]
+		if (filter.ProductID != "" && movement.ProductID != filter.ProductID) ||
+			(filter.StoreID != "" && movement.StoreID != filter.StoreID) ||
+			(filter.MovementType != "" && movement.MovementType != filter.MovementType) {
+			continue
+		}
+		movements = append(movements, movement)
+	}
+	return movements, nil
+}
+
+func (r *memoryInventoryRepository) ListAlerts(ctx context.Context, filter domain.AlertFilter) ([]domain.InventoryAlert, error) {
+	var alerts []domain.InventoryAlert
+	for _, alert := range r.alerts {
+		if (!filter.IncludeResolved && alert.IsResolved) ||
+			(filter.ProductID != "" && alert.ProductID != filter.ProductID) ||
+			(filter.StoreID != "" && alert.StoreID != filter.StoreID) ||
+			(filter.AlertType != "" && alert.AlertType != filter.AlertType) {
+			continue
+		}
+		alerts = append(alerts, *alert)
+	}
+	return alerts, nil
+}
+
+func (r *memoryInventoryRepository) GetAlert(ctx context.Context, alertID string) (*domain.InventoryAlert, error) {
+	for _, alert := range r.alerts {
+		if alert.ID == alertID {
+			copied := *alert
+			return &copied, nil
+		}
+	}
+	return nil, domain.ErrAlertNotFound
+}
+
+func (r *memoryInventoryRepository) CreateAlert(ctx context.Context, alert *domain.InventoryAlert) error {
+	for _, existing := range r.alerts {
+		if !existing.IsResolved && existing.ProductID == alert.ProductID &&
+			existing.StoreID == alert.StoreID && existing.AlertType == alert.AlertType {
+			existing.CurrentQty = alert.CurrentQty
+			existing.ThresholdQty = alert.ThresholdQty
+			alert.ID = existing.ID
+			return nil
+		}
+	}
+
+	alert.ID = fmt.Sprintf("A%d", len(r.alerts)+1)
+	alert.ProductName = r.products[alert.ProductID].Name
+	copied := *alert
+	r.alerts = append(r.alerts, &copied)
+	return nil
+}
+
+func (r *memoryInventoryRepository) ResolveAlert(ctx context.Context, alertID string, resolvedAt time.Time) error {
+	for _, alert := range r.alerts {
+		if alert.ID == alertID {
+			if alert.IsResolved {
+				return domain.ErrAlertResolved
+			}
+			alert.IsResolved = true
+			alert.ResolvedAt = &resolvedAt
+			return nil
+		}
+	}
+	return domain.ErrAlertNotFound
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func newInventoryRouter(repo *memoryInventoryRepository) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	logger := newTestLogger()
+	handler := NewInventoryHandler(application.NewInventoryService(repo, events.NewNoopPublisher(logger), logger), logger)
+
+	router := gin.New()
+	inventory := router.Group("/api/v1/inventory")
+	inventory.GET("/products", handler.GetAllProducts)
+	inventory.GET("/search", handler.SearchProducts)
+	inventory.GET("/products/:id", handler.GetProduct)
+	inventory.GET("/products/:id/stock", handler.GetProductStock)
+	inventory.GET("/stores/:id/stock", handler.GetStoreStock)
+	inventory.GET("/stock/low", handler.GetLowStockItems)
+	inventory.GET("/movements", handler.GetMovements)
+	inventory.POST("/movements", handler.RecordMovement)
+	inventory.GET("/alerts", handler.GetInventoryAlerts)
+	inventory.POST("/alerts/:id/resolve", handler.ResolveAlert)
+	return router
+}
+
+// serve performs the request and decodes the data field of the response into out
+func serve(t *testing.T, router *gin.Engine, method, path string, body interface{}, out interface{}) int {
+	t.Helper()
+
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal request: %v", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+
+	if out != nil && recorder.Code < http.StatusBadRequest {
+		var envelope struct {
+			Success bool            `json:"success"`
+			Data    json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &envelope); err != nil {
+			t.Fatalf("decode response: %v", err)
+		}
+		if !envelope.Success {
+			t.Fatalf("expected success response, got %s", recorder.Body.String())
+		}
+		if err := json.Unmarshal(envelope.Data, out); err != nil {
+			t.Fatalf("decode data: %v", err)
+		}
+	}
+
+	return recorder.Code
+}
+
+func TestGetProductIncludesStockPerStore(t *testing.T) {
+	router := newInventoryRouter(newMemoryInventoryRepository())
+
+	var product domain.Product
+	if code := serve(t, router, http.MethodGet, "/api/v1/inventory/products/P1", nil, &product); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(product.StockLevels) != 2 {
+		t.Fatalf("expected stock at 2 stores, got %d", len(product.StockLevels))
+	}
+
+	if code := serve(t, router, http.MethodGet, "/api/v1/inventory/products/missing/stock", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown product, got %d", code)
+	}
+
+	var storeStock struct {
+		StockLevels []domain.StockLevel `json:"stock_levels"`
+	}
+	if code := serve(t, router, http.MethodGet, "/api/v1/inventory/stores/S1/stock", nil, &storeStock); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(storeStock.StockLevels) != 2 {
+		t.Fatalf("expected 2 products at S1, got %d", len(storeStock.StockLevels))
+	}
+
+	var lowStock struct {
+		Items []domain.StockPosition `json:"low_stock_items"`
+	}
+	serve(t, router, http.MethodGet, "/api/v1/inventory/stock/low?store_id=S1", nil, &lowStock)
+	if len(lowStock.Items) != 1 || lowStock.Items[0].ProductID != "P2" {
+		t.Fatalf("expected only P2 low at S1, got %+v", lowStock.Items)
+	}
+}
+
+func TestRecordMovementUpdatesStockAndHistory(t *testing.T) {
+	repo := newMemoryInventoryRepository()
+	router := newInventoryRouter(repo)
+
+	var recorded struct {
+		Movement   domain.StockMovement `json:"movement"`
+		StockLevel domain.StockLevel    `json:"stock_level"`
+	}
+	code := serve(t, router, http.MethodPost, "/api/v1/inventory/movements", map[string]interface{}{
+		"product_id":    "P1",
+		"store_id":      "S1",
+		"movement_type": domain.MovementSale,
+		"quantity":      4,
+		"reference":     "RCPT-1",
+	}, &recorded)
+	if code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d", code)
+	}
+	if recorded.Movement.Quantity != -4 || recorded.Movement.QuantityBefore != 20 || recorded.Movement.QuantityAfter != 16 {
+		t.Fatalf("unexpected movement %+v", recorded.Movement)
+	}
+	if recorded.StockLevel.QuantityOnHand != 16 {
+		t.Fatalf("expected 16 on hand, got %v", recorded.StockLevel.QuantityOnHand)
+	}
+
+	serve(t, router, http.MethodPost, "/api/v1/inventory/movements", map[string]interface{}{
+		"product_id": "P1", "store_id": "S1", "movement_type": domain.MovementPurchase, "quantity": 10,
+	}, nil)
+
+	var history struct {
+		Movements []domain.StockMovement `json:"movements"`
+	}
+	serve(t, router, http.MethodGet, "/api/v1/inventory/movements?product_id=P1&type=SALE", nil, &history)
+	if len(history.Movements) != 1 || history.Movements[0].Reference != "RCPT-1" {
+		t.Fatalf("expected the sale in history, got %+v", history.Movements)
+	}
+
+	if repo.levels["P1/S1"].QuantityOnHand != 26 {
+		t.Fatalf("expected 26 on hand after purchase, got %v", repo.levels["P1/S1"].QuantityOnHand)
+	}
+}
+
+func TestRecordMovementRejectsInvalidRequests(t *testing.T) {
+	router := newInventoryRouter(newMemoryInventoryRepository())
+
+	tests := []struct {
+		name string
+		body map[string]interface{}
+		want int
+	}{
+		{"missing store", map[string]interface{}{"product_id": "P1", "movement_type": "SALE", "quantity": 1}, http.StatusBadRequest},
+		{"unknown type", map[string]interface{}{"product_id": "P1", "store_id": "S1", "movement_type": "GIFT", "quantity": 1}, http.StatusBadRequest},
+		{"unknown store", map[string]interface{}{"product_id": "P1", "store_id": "S9", "movement_type": "SALE", "quantity": 1}, http.StatusNotFound},
+		{"oversold", map[string]interface{}{"product_id": "P1", "store_id": "S2", "movement_type": "SALE", "quantity": 4}, http.StatusConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := serve(t, router, http.MethodPost, "/api/v1/inventory/movements", tt.body, nil); code != tt.want {
+				t.Fatalf("expected %d, got %d", tt.want, code)
+			}
+		})
+	}
+
+	if code := serve(t, router, http.MethodGet, "/api/v1/inventory/movements?from=2024-13-01", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for invalid date, got %d", code)
+	}
+	if code := serve(t, router, http.MethodGet, "/api/v1/inventory/movements?from=2024-05-02&to=2024-05-01", nil, nil); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for reversed range, got %d", code)
+	}
+}
+
+func TestMovementAlertsAndResolution(t *testing.T) {
+	repo := newMemoryInventoryRepository()
+	router := newInventoryRouter(repo)
+
+	// Selling 16 of 20 leaves 4, under the reorder level of 5
+	serve(t, router, http.MethodPost, "/api/v1/inventory/movements", map[string]interface{}{
+		"product_id": "P1", "store_id": "S1", "movement_type": domain.MovementSale, "quantity": 16,
+	}, nil)
+
+	var open struct {
+		Alerts []domain.InventoryAlert `json:"alerts"`
+	}
+	serve(t, router, http.MethodGet, "/api/v1/inventory/alerts?store_id=S1", nil, &open)
+	if len(open.Alerts) != 1 || open.Alerts[0].AlertType != domain.AlertLowStock {
+		t.Fatalf("expected one low stock alert, got %+v", open.Alerts)
+	}
+	alertID := open.Alerts[0].ID
+
+	// Selling out escalates the alert and resolves the low stock one
+	serve(t, router, http.MethodPost, "/api/v1/inventory/movements", map[string]interface{}{
+		"product_id": "P1", "store_id": "S1", "movement_type": domain.MovementSale, "quantity": 4,
+	}, nil)
+	serve(t, router, http.MethodGet, "/api/v1/inventory/alerts?store_id=S1", nil, &open)
+	if len(open.Alerts) != 1 || open.Alerts[0].AlertType != domain.AlertOutOfStock || open.Alerts[0].Severity != domain.SeverityHigh {
+		t.Fatalf("expected one out of stock alert, got %+v", open.Alerts)
+	}
+
+	var resolved domain.InventoryAlert
+	path := "/api/v1/inventory/alerts/" + open.Alerts[0].ID + "/resolve"
+	if code := serve(t, router, http.MethodPost, path, nil, &resolved); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if !resolved.IsResolved || resolved.ResolvedAt == nil {
+		t.Fatalf("expected resolved alert, got %+v", resolved)
+	}
+
+	if code := serve(t, router, http.MethodPost, path, nil, nil); code != http.StatusConflict {
+		t.Fatalf("expected 409 resolving twice, got %d", code)
+	}
+	if code := serve(t, router, http.MethodPost, "/api/v1/inventory/alerts/missing/resolve", nil, nil); code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown alert, got %d", code)
+	}
+
+	var all struct {
+		Alerts []domain.InventoryAlert `json:"alerts"`
+	}
+	serve(t, router, http.MethodGet, "/api/v1/inventory/alerts?store_id=S1&include_resolved=true", nil, &all)
+	if len(all.Alerts) != 2 {
+		t.Fatalf("expected both alerts in history, got %d", len(all.Alerts))
+	}
+	for _, alert := range all.Alerts {
+		if alert.ID == alertID && !alert.IsResolved {
+			t.Fatalf("expected low stock alert %s to be resolved", alertID)
+		}
+	}
+}
@@ -2,9 +2,12 @@ package routes
 
 import (
 	"net/http"
+
+	"inventory/internal/application"
 	"inventory/internal/infrastructure/cache"
 	"inventory/internal/infrastructure/database"
 	"inventory/internal/infrastructure/events"
+	"inventory/internal/interfaces/http/handlers"
 	"inventory/internal/interfaces/http/middleware"
 
 	"github.com/gin-gonic/gin"
@@ -15,6 +18,8 @@ func SetupRoutes(
 	redisClient *cache.RedisClient,
 	dbConn *database.Connection,
 	kafkaConsumer *events.Consumer,
+	eventPublisher events.Publisher,
+	forecastService *application.ForecastService,
 	logger *logrus.Logger,
 ) *gin.Engine {
 	// Initialize Gin router
@@ -26,24 +31,68 @@ func SetupRoutes(
 	router.Use(middleware.Logger(logger))
 	router.Use(middleware.RequestID())
 
-	// Basic health check endpoints
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ok", "service": "inventory"})
-	})
+	// Repositories and services
+	inventoryRepo := database.NewInventoryRepository(dbConn)
+	analyticsRepo := database.NewAnalyticsRepository(dbConn)
+	forecastRepo := database.NewForecastRepository(dbConn)
+	inventoryService := application.NewInventoryService(inventoryRepo, eventPublisher, logger)
+	analyticsService := application.NewAnalyticsService(analyticsRepo, inventoryRepo, forecastRepo, logger)
+
+	// Handlers
+	healthHandler := handlers.NewHealthHandler(redisClient, dbConn, logger)
+	inventoryHandler := handlers.NewInventoryHandler(inventoryService, logger)
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, logger)
+	forecastHandler := handlers.NewForecastHandler(forecastService, logger)
 
-	router.GET("/ready", func(c *gin.Context) {
-		c.JSON(http.StatusOK, gin.H{"status": "ready", "service": "inventory"})
-	})
+	// Health check endpoints
+	router.GET("/health", healthHandler.HealthCheck)
+	router.GET("/ready", healthHandler.ReadinessCheck)
 
-	// Basic API routes (simplified for now)
 	api := router.Group("/api/v1")
 	{
-		// Basic inventory endpoints
 		inventory := api.Group("/inventory")
 		{
 			inventory.GET("/status", func(c *gin.Context) {
 				c.JSON(http.StatusOK, gin.H{"message": "Inventory service running with new infrastructure"})
 			})
+
+			// Products
+			inventory.GET("/products", inventoryHandler.GetAllProducts)
+			inventory.GET("/search", inventoryHandler.SearchProducts)
+			inventory.GET("/products/:id", inventoryHandler.GetProduct)
+			inventory.GET("/products/:id/stock", inventoryHandler.GetProductStock)
+
+			// Stores and categories
+			inventory.GET("/stores", inventoryHandler.GetAllStores)
+			inventory.GET("/stores/:id/stock", inventoryHandler.GetStoreStock)
+			inventory.GET("/categories", inventoryHandler.GetAllCategories)
+
+			// Stock and movements
+			inventory.GET("/stock/low", inventoryHandler.GetLowStockItems)
+			inventory.GET("/movements", inventoryHandler.GetMovements)
+			inventory.POST("/movements", inventoryHandler.RecordMovement)
+
+			// Alerts
+			inventory.GET("/alerts", inventoryHandler.GetInventoryAlerts)
+			inventory.POST("/alerts/:id/resolve", inventoryHandler.ResolveAlert)
+		}
+
+		analytics := api.Group("/analytics")
+		{
+			analytics.GET("/dashboard", analyticsHandler.GetDashboard)
+			analytics.GET("/performance/products", analyticsHandler.GetProductPerformance)
+			analytics.GET("/performance/categories", analyticsHandler.GetCategoryPerformance)
+			analytics.GET("/trends/daily", analyticsHandler.GetDailyTrends)
+			analytics.GET("/trends/weekly", analyticsHandler.GetWeeklyTrends)
+			analytics.GET("/suggestions/reorder", analyticsHandler.GetReorderSuggestions)
+			analytics.GET("/suggestions/alerts", analyticsHandler.GetStockAlerts)
+		}
+
+		admin := api.Group("/admin")
+		admin.Use(middleware.AdminAuth())
+		{
+			admin.POST("/forecast/run", forecastHandler.RunForecast)
+			admin.PUT("/suppliers/:id", forecastHandler.UpsertSupplier)
 		}
 	}
 
@@ -0,0 +1,9 @@
+-- Drop Inventory Service Database Schema
+-- Migration: 001_create_inventory_tables.down.sql
+
+DROP TABLE IF EXISTS inventory_alerts;
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS stock_levels;
+DROP TABLE IF EXISTS categories;
+DROP TABLE IF EXISTS stores;
+DROP TABLE IF EXISTS products;
@@ -0,0 +1,100 @@
+-- Create Inventory Service Database Schema
+-- Migration: 001_create_inventory_tables.up.sql
+-- Product, store and category ids are the Loyverse ids, so they are stored as text.
+
+-- Products Table (kept up to date by the product.updated consumer)
+CREATE TABLE IF NOT EXISTS products (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    sku VARCHAR(100) DEFAULT '',
+    barcode VARCHAR(100) DEFAULT '',
+    category_id VARCHAR(100) DEFAULT '',
+    category_name VARCHAR(255) DEFAULT '',
+    supplier_id VARCHAR(100) DEFAULT '',
+    supplier_name VARCHAR(255) DEFAULT '',
+    cost_price DECIMAL(12,2) NOT NULL DEFAULT 0.00,
+    sell_price DECIMAL(12,2) NOT NULL DEFAULT 0.00,
+    unit VARCHAR(20) NOT NULL DEFAULT 'pcs',
+    description TEXT DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    last_updated TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Stores Table
+CREATE TABLE IF NOT EXISTS stores (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    address TEXT DEFAULT '',
+    phone VARCHAR(50) DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Categories Table
+CREATE TABLE IF NOT EXISTS categories (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    color VARCHAR(20) DEFAULT '',
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+);
+
+-- Stock Levels Table (one row per product and store)
+CREATE TABLE stock_levels (
+    product_id VARCHAR(100) NOT NULL REFERENCES products(id),
+    store_id VARCHAR(100) NOT NULL REFERENCES stores(id),
+    quantity_on_hand DECIMAL(12,3) NOT NULL DEFAULT 0,
+    reorder_level DECIMAL(12,3) NOT NULL DEFAULT 0,
+    max_stock DECIMAL(12,3) NOT NULL DEFAULT 0,
+    last_updated TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (product_id, store_id),
+    CONSTRAINT check_quantity_non_negative CHECK (quantity_on_hand >= 0)
+);
+
+-- Stock Movements Table (quantity is the signed change in stock)
+CREATE TABLE stock_movements (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id VARCHAR(100) NOT NULL REFERENCES products(id),
+    store_id VARCHAR(100) NOT NULL REFERENCES stores(id),
+    movement_type VARCHAR(20) NOT NULL,
+    quantity DECIMAL(12,3) NOT NULL,
+    quantity_before DECIMAL(12,3) NOT NULL,
+    quantity_after DECIMAL(12,3) NOT NULL,
+    reference VARCHAR(100) DEFAULT '',
+    notes TEXT DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT check_movement_type CHECK (movement_type IN ('SALE', 'PURCHASE', 'ADJUSTMENT', 'TRANSFER'))
+);
+
+-- Inventory Alerts Table
+CREATE TABLE inventory_alerts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id VARCHAR(100) NOT NULL REFERENCES products(id),
+    store_id VARCHAR(100) NOT NULL REFERENCES stores(id),
+    alert_type VARCHAR(20) NOT NULL,
+    current_qty DECIMAL(12,3) NOT NULL,
+    threshold_qty DECIMAL(12,3) NOT NULL,
+    severity VARCHAR(10) NOT NULL,
+    is_resolved BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    resolved_at TIMESTAMP WITH TIME ZONE,
+
+    CONSTRAINT check_alert_type CHECK (alert_type IN ('LOW_STOCK', 'OUT_OF_STOCK', 'OVERSTOCKED')),
+    CONSTRAINT check_alert_severity CHECK (severity IN ('HIGH', 'MEDIUM', 'LOW'))
+);
+
+-- Indexes
+CREATE INDEX idx_products_name ON products(name);
+CREATE INDEX idx_products_category ON products(category_id);
+CREATE INDEX idx_stock_levels_store ON stock_levels(store_id);
+CREATE INDEX idx_stock_movements_product_store ON stock_movements(product_id, store_id, created_at);
+CREATE INDEX idx_stock_movements_created ON stock_movements(created_at);
+CREATE INDEX idx_inventory_alerts_store ON inventory_alerts(store_id, is_resolved);
+
+-- Only one open alert of each type per product and store
+CREATE UNIQUE INDEX idx_inventory_alerts_open ON inventory_alerts(product_id, store_id, alert_type)
+    WHERE is_resolved = false;
@@ -0,0 +1,7 @@
+-- Drop Demand Forecasting Schema
+-- Migration: 002_create_forecast_tables.down.sql
+
+DROP INDEX IF EXISTS idx_products_supplier;
+DROP TABLE IF EXISTS reorder_suggestions;
+DROP TABLE IF EXISTS demand_forecasts;
+DROP TABLE IF EXISTS suppliers;
@@ -0,0 +1,52 @@
+-- Demand Forecasting Schema
+-- Migration: 002_create_forecast_tables.up.sql
+
+-- Suppliers Table (products.supplier_id refers to these ids)
+CREATE TABLE IF NOT EXISTS suppliers (
+    id VARCHAR(100) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    lead_time_days INTEGER NOT NULL DEFAULT 3,
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT check_lead_time_positive CHECK (lead_time_days > 0)
+);
+
+-- Demand Forecasts Table (latest forecast per product and store; daily_demand[1] is start_date)
+CREATE TABLE demand_forecasts (
+    product_id VARCHAR(100) NOT NULL REFERENCES products(id),
+    store_id VARCHAR(100) NOT NULL REFERENCES stores(id),
+    start_date DATE NOT NULL,
+    daily_demand DECIMAL(12,3)[] NOT NULL,
+    trend_per_day DECIMAL(12,4) NOT NULL DEFAULT 0,
+    mean_abs_error DECIMAL(12,4) NOT NULL DEFAULT 0,
+    confidence DECIMAL(4,2) NOT NULL DEFAULT 0,
+    last_sale_date DATE,
+    generated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (product_id, store_id)
+);
+
+-- Reorder Suggestions Table (suggestions of the latest forecast run in rank order)
+CREATE TABLE reorder_suggestions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    rank INTEGER NOT NULL,
+    product_id VARCHAR(100) NOT NULL REFERENCES products(id),
+    store_id VARCHAR(100) NOT NULL REFERENCES stores(id),
+    current_stock DECIMAL(12,3) NOT NULL,
+    suggested_qty DECIMAL(12,3) NOT NULL,
+    reason_code VARCHAR(20) NOT NULL,
+    confidence DECIMAL(4,2) NOT NULL,
+    estimated_cost DECIMAL(12,2) NOT NULL DEFAULT 0,
+    estimated_demand DECIMAL(12,3) NOT NULL DEFAULT 0,
+    lead_time_days INTEGER NOT NULL,
+    priority INTEGER NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+
+    CONSTRAINT check_reason_code CHECK (reason_code IN ('LOW_STOCK', 'SEASONAL', 'TREND_UP')),
+    CONSTRAINT check_priority CHECK (priority BETWEEN 1 AND 5)
+);
+
+-- Indexes
+CREATE INDEX idx_demand_forecasts_store ON demand_forecasts(store_id);
+CREATE INDEX idx_reorder_suggestions_store ON reorder_suggestions(store_id, rank);
+CREATE INDEX idx_products_supplier ON products(supplier_id);
@@ -10,12 +10,17 @@ import (
 	"time"
 
 	"order/internal/application"
+	"order/internal/domain"
 	"order/internal/infrastructure/cache"
+	"order/internal/infrastructure/client"
 	"order/internal/infrastructure/config"
 	"order/internal/infrastructure/database"
+	"order/internal/infrastructure/document"
 	"order/internal/infrastructure/events"
 	"order/internal/infrastructure/repository"
 	httpTransport "order/internal/transport/http"
+	"order/internal/transport/http/middleware"
+	pkglogger "order/pkg/logger"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
@@ -37,6 +42,12 @@ func main() {
 	
 	logger.Info("Starting Order Service...")
 	
+	// Tokens are always verified against the user service's JWKS, so their issuer and audience
+	// must be known or tokens issued for other services would be accepted
+	if cfg.JWT.Issuer == "" || cfg.JWT.Audience == "" {
+		logger.Fatal("JWT_ISSUER and JWT_AUDIENCE are required to verify tokens")
+	}
+	
 	// Initialize database
 	db, err := database.NewConnection(cfg.Database, logger)
 	if err != nil {
@@ -74,12 +85,87 @@ func main() {
 	orderItemRepo := repository.NewOrderItemRepository(db)
 	auditRepo := repository.NewAuditRepository(db)
 	orderEventRepo := repository.NewEventRepository(db)
+	shipmentRepo := repository.NewShipmentRepository(db)
+	returnRepo := repository.NewReturnRepository(db)
+	statsRepo := repository.NewOrderStatsRepository(db)
+	promotionRepo := repository.NewPromotionRepository(db)
+	invoiceRepo := repository.NewInvoiceRepository(db)
+	recurringRepo := repository.NewRecurringOrderRepository(db)
+	
+	// Stock is reserved in the product service for the lifetime of an order
+	reservationClient := client.NewHTTPStockReservationClient(cfg.External.ProductServiceURL)
+	
+	// Every shipment of an order becomes a delivery order in the shipping service
+	deliveryClient := client.NewHTTPDeliveryClient(cfg.External.ShippingServiceURL)
+	
+	// Refunding a return touches payments, finance and the customer's loyalty points
+	refundClient := client.NewHTTPRefundClient(cfg.External.PaymentServiceURL, cfg.External.FinanceServiceURL, cfg.External.CustomerServiceURL)
+	
+	// Promo codes with VIP tier rules look up the customer
+	promoLookupClient := client.NewHTTPPromotionLookupClient(cfg.External.CustomerServiceURL)
+	
+	// VAT exemptions, promotion categories and invoice lines come from the product catalog
+	catalogClient := client.NewHTTPCatalogClient(cfg.External.ProductServiceURL)
+	
+	// Order items are priced by the product service with the customer's tier, group and VIP pricing
+	pricingClient := client.NewHTTPPricingClient(cfg.External.ProductServiceURL)
+	
+	// Recurring order reminders and results are sent to the customer's chat
+	notificationClient := client.NewHTTPNotificationClient(cfg.External.NotificationServiceURL)
+	
+	// Invoices are rendered as PDF and as e-Tax Invoice XML
+	documentRenderer, err := document.NewRenderer(cfg.Tax.FontPath)
+	if err != nil {
+		logger.Fatalf("Failed to load invoice font: %v", err)
+	}
+	taxSettings := application.TaxSettings{
+		VATRate:     cfg.Tax.VATRate,
+		DefaultMode: domain.TaxMode(cfg.Tax.Mode),
+		Seller: domain.InvoiceParty{
+			Name:       cfg.Tax.SellerName,
+			TaxID:      cfg.Tax.SellerTaxID,
+			BranchCode: cfg.Tax.SellerBranchCode,
+			Address:    cfg.Tax.SellerAddress,
+		},
+	}
+	if !taxSettings.DefaultMode.IsValid() {
+		logger.Fatalf("Invalid TAX_MODE %q, expected exclusive or inclusive", cfg.Tax.Mode)
+	}
 	
 	// Initialize service
-	orderService := application.NewService(orderRepo, orderItemRepo, auditRepo, orderEventRepo, eventPublisher, redisCache, logger)
+	orderService := application.NewService(orderRepo, orderItemRepo, auditRepo, orderEventRepo, shipmentRepo, returnRepo, promotionRepo, invoiceRepo, recurringRepo, db, redisCache, reservationClient, deliveryClient, refundClient, promoLookupClient, catalogClient, pricingClient, documentRenderer, taxSettings, cfg.Reservation.TTL, logger)
+	
+	// Statistics are read from rollups the database keeps up to date
+	statsLogger := pkglogger.NewLogger(cfg.Logging.Level, cfg.Logging.Format)
+	statsService := application.NewOrderStatsService(orderRepo, orderItemRepo, statsRepo, db, statsLogger)
+	
+	// Start outbox relay; it owns publishing of everything written to the outbox
+	relayCtx, stopRelay := context.WithCancel(context.Background())
+	defer stopRelay()
+	outboxRelay := events.NewOutboxRelay(orderEventRepo, db, eventPublisher, cfg.Outbox, logger)
+	go outboxRelay.Run(relayCtx)
+	
+	// Start recurring order scheduler; orders are generated through the order service
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	recurringScheduler := application.NewRecurringOrderScheduler(orderService, notificationClient, cfg.Recurring, logger)
+	go recurringScheduler.Run(schedulerCtx)
+	
+	// Tokens are verified against the signing keys the user service publishes
+	authConfig := &middleware.AuthConfig{
+		AuthServiceURL:     cfg.External.UserServiceURL,
+		JWTSecret:          cfg.JWT.Secret,
+		Logger:             pkglogger.NewLogger(cfg.Logging.Level, cfg.Logging.Format),
+		JWKSURL:            cfg.JWT.JWKSURL,
+		Issuer:             cfg.JWT.Issuer,
+		Audience:           cfg.JWT.Audience,
+		CacheTTL:           cfg.JWT.CacheTTL,
+		KeyRefreshInterval: cfg.JWT.KeyRefreshInterval,
+	}
 	
 	// Setup routes
-	router := httpTransport.SetupRoutes(orderService, logger)
+	statsHandler := httpTransport.NewStatsHandler(statsService, statsLogger)
+	router := httpTransport.SetupRoutes(orderService, statsHandler, outboxRelay, authConfig, logger)
 	
 	// Create HTTP server
 	server := &http.Server{
@@ -100,6 +186,8 @@ func main() {
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 	logger.Info("Shutting down server...")
+	stopScheduler()
+	stopRelay()
 	
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -48,10 +48,10 @@ func TestRBACMiddleware(t *testing.T) {
 			expectedMessage: "Invalid authorization header format",
 		},
 		{
-			name:           "Valid Bearer Token Format",
+			name:           "Unsigned Bearer Token",
 			token:          "Bearer valid-jwt-token",
 			requiredRoles:  []middleware.Role{middleware.RoleSales},
-			expectedStatus: http.StatusOK, // Will fail at auth service verification in real scenario
+			expectedStatus: http.StatusUnauthorized, // Tokens must be signed with the JWT secret
 		},
 	}
 
@@ -98,6 +98,7 @@ func TestRolePermissions(t *testing.T) {
 			expectedPermissions: []string{
 				"orders:create",
 				"orders:view",
+				"orders:override_price",
 				"customers:view",
 			},
 		},
@@ -110,6 +111,8 @@ func TestRolePermissions(t *testing.T) {
 				"orders:confirm",
 				"orders:cancel",
 				"orders:override_stock",
+				"orders:override_price",
+				"orders:approve_below_margin",
 				"customers:view",
 				"customers:update",
 			},
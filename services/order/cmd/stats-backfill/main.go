@@ -0,0 +1,71 @@
+// Command stats-backfill rebuilds the daily order statistics rollups of a date range from the
+// orders. Run it once after migrating to the rollups, or whenever a range needs correcting.
+//
+//	stats-backfill -from 2025-01-01 -to 2025-06-30
+package main
+
+import (
+	"context"
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order/internal/application"
+	"order/internal/infrastructure/config"
+	"order/internal/infrastructure/database"
+	"order/internal/infrastructure/repository"
+	pkglogger "order/pkg/logger"
+)
+
+func main() {
+	from := flag.String("from", "", "first day to rebuild (YYYY-MM-DD, UTC)")
+	to := flag.String("to", time.Now().UTC().Format("2006-01-02"), "last day to rebuild (YYYY-MM-DD, UTC)")
+	flag.Parse()
+
+	logger := logrus.New()
+
+	if *from == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	start, err := time.Parse("2006-01-02", *from)
+	if err != nil {
+		logger.Fatalf("Invalid -from date %q: %v", *from, err)
+	}
+	end, err := time.Parse("2006-01-02", *to)
+	if err != nil {
+		logger.Fatalf("Invalid -to date %q: %v", *to, err)
+	}
+
+	cfg := config.LoadConfig()
+
+	db, err := database.NewConnection(cfg.Database, logger)
+	if err != nil {
+		logger.Fatalf("Failed to connect to database: %v", err)
+	}
+	defer db.Close()
+
+	statsService := application.NewOrderStatsService(
+		repository.NewOrderRepository(db),
+		repository.NewOrderItemRepository(db),
+		repository.NewOrderStatsRepository(db),
+		db,
+		pkglogger.NewLogger(cfg.Logging.Level, cfg.Logging.Format),
+	)
+
+	// Stop after the day being rebuilt when interrupted
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err = statsService.RebuildStats(ctx, start, end, func(day time.Time) {
+		logger.Infof("Rebuilt order stats of %s", day.Format("2006-01-02"))
+	})
+	if err != nil {
+		logger.Fatalf("Failed to rebuild order stats: %v", err)
+	}
+
+	logger.Infof("Rebuilt order stats from %s to %s", start.Format("2006-01-02"), end.Format("2006-01-02"))
+}
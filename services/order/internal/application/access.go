@@ -0,0 +1,17 @@
+package application
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+)
+
+// RecordAccessDenied writes a request that was turned away to the order audit log. orderID is
+// uuid.Nil when the request was not about an order; failures are only logged.
+func (s *Service) RecordAccessDenied(ctx context.Context, orderID uuid.UUID, userID *string, details map[string]interface{}) {
+	audit := domain.NewAuditLog(orderID, userID, domain.AuditActionAccessDenied, details)
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Warn("Failed to create access denied audit record")
+	}
+}
@@ -0,0 +1,96 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+)
+
+// IssueInvoiceRequest represents the request to issue a tax invoice or receipt for an order.
+// A full tax invoice needs the buyer's name, address, tax ID and branch.
+type IssueInvoiceRequest struct {
+	Type  domain.InvoiceType `json:"type" validate:"required,oneof=tax_invoice receipt"`
+	Buyer InvoicePartyRequest `json:"buyer"`
+	// BranchCode is the seller branch issuing the invoice; the configured branch when empty
+	BranchCode string `json:"branch_code"`
+}
+
+// InvoicePartyRequest represents the buyer named on an invoice
+type InvoicePartyRequest struct {
+	Name       string `json:"name"`
+	TaxID      string `json:"tax_id"`
+	BranchCode string `json:"branch_code"`
+	Address    string `json:"address"`
+}
+
+// InvoiceResponse represents a tax invoice, receipt or credit note in the response
+type InvoiceResponse struct {
+	ID                 uuid.UUID             `json:"id"`
+	OrderID            uuid.UUID             `json:"order_id"`
+	ReturnID           *uuid.UUID            `json:"return_id,omitempty"`
+	Type               domain.InvoiceType    `json:"type"`
+	Number             string                `json:"number"`
+	BranchCode         string                `json:"branch_code"`
+	IssuedAt           time.Time             `json:"issued_at"`
+	Seller             domain.InvoiceParty   `json:"seller"`
+	Buyer              domain.InvoiceParty   `json:"buyer"`
+	TaxMode            domain.TaxMode        `json:"tax_mode"`
+	VATRate            float64               `json:"vat_rate"`
+	Subtotal           float64               `json:"subtotal"`
+	Discount           float64               `json:"discount"`
+	TaxableAmount      float64               `json:"taxable_amount"`
+	ExemptAmount       float64               `json:"exempt_amount"`
+	VAT                float64               `json:"vat"`
+	Total              float64               `json:"total"`
+	ReferenceInvoiceID *uuid.UUID            `json:"reference_invoice_id,omitempty"`
+	ReferenceNumber    *string               `json:"reference_number,omitempty"`
+	OriginalAmount     float64               `json:"original_amount,omitempty"`
+	CorrectedAmount    float64               `json:"corrected_amount,omitempty"`
+	Reason             string                `json:"reason,omitempty"`
+	Lines              []InvoiceLineResponse `json:"lines"`
+}
+
+// InvoiceLineResponse represents a line of an invoice in the response
+type InvoiceLineResponse struct {
+	LineNo      int        `json:"line_no"`
+	ProductID   *uuid.UUID `json:"product_id,omitempty"`
+	Description string     `json:"description"`
+	Quantity    int        `json:"quantity"`
+	UnitPrice   float64    `json:"unit_price"`
+	Amount      float64    `json:"amount"`
+	VATExempt   bool       `json:"vat_exempt"`
+}
+
+// ToInvoiceResponse converts a domain invoice to a response DTO
+func ToInvoiceResponse(invoice *domain.Invoice) *InvoiceResponse {
+	response := &InvoiceResponse{
+		ID:                 invoice.ID,
+		OrderID:            invoice.OrderID,
+		ReturnID:           invoice.ReturnID,
+		Type:               invoice.Type,
+		Number:             invoice.Number,
+		BranchCode:         invoice.BranchCode,
+		IssuedAt:           invoice.IssuedAt,
+		Seller:             invoice.Seller,
+		Buyer:              invoice.Buyer,
+		TaxMode:            invoice.TaxMode,
+		VATRate:            invoice.VATRate,
+		Subtotal:           invoice.Subtotal,
+		Discount:           invoice.Discount,
+		TaxableAmount:      invoice.TaxableAmount,
+		ExemptAmount:       invoice.ExemptAmount,
+		VAT:                invoice.VAT,
+		Total:              invoice.Total,
+		ReferenceInvoiceID: invoice.ReferenceInvoiceID,
+		ReferenceNumber:    invoice.ReferenceNumber,
+		OriginalAmount:     invoice.OriginalAmount,
+		CorrectedAmount:    invoice.CorrectedAmount,
+		Reason:             invoice.Reason,
+		Lines:              make([]InvoiceLineResponse, len(invoice.Lines)),
+	}
+	for i, line := range invoice.Lines {
+		response.Lines[i] = InvoiceLineResponse(line)
+	}
+	return response
+}
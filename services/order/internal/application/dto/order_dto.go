@@ -15,16 +15,41 @@ type CreateOrderRequest struct {
 	BillingAddress  string                  `json:"billing_address" validate:"required"`
 	PaymentMethod   *domain.PaymentMethod   `json:"payment_method,omitempty"`
 	PromoCode       *string                 `json:"promo_code,omitempty"`
+	// PromoCodes stacks several codes; PromoCode is added to them
+	PromoCodes      []string                `json:"promo_codes,omitempty" validate:"max=3"`
+	ShippingFee     float64                 `json:"shipping_fee" validate:"min=0"`
 	Notes           string                  `json:"notes"`
 	TaxEnabled      *bool                   `json:"tax_enabled,omitempty"`
+	// TaxMode overrides the default pricing mode: exclusive adds VAT on top, inclusive prices contain it
+	TaxMode         *domain.TaxMode         `json:"tax_mode,omitempty"`
+	// CustomerGroup prices the items for a customer group such as wholesale. Setting it needs the
+	// orders:override_price permission.
+	CustomerGroup   string                  `json:"customer_group,omitempty" validate:"max=50"`
 	Items           []CreateOrderItemRequest `json:"items" validate:"required,min=1"`
+	// MarginApprovedBy is the manager approving items sold below cost or the minimum margin,
+	// set from the authenticated user
+	MarginApprovedBy *string `json:"-"`
 }
 
-// CreateOrderItemRequest represents an item in the create order request
+// CreateOrderItemRequest represents an item in the create order request. Items are priced by
+// the product service; a unit price sent by the client is ignored. Staff allowed to override
+// prices can set the unit price with price_override and a reason instead.
 type CreateOrderItemRequest struct {
-	ProductID uuid.UUID `json:"product_id" validate:"required"`
-	Quantity  int       `json:"quantity" validate:"required,min=1"`
-	UnitPrice float64   `json:"unit_price" validate:"required,min=0"`
+	ProductID           uuid.UUID `json:"product_id" validate:"required"`
+	Quantity            int       `json:"quantity" validate:"required,min=1"`
+	UnitPrice           float64   `json:"unit_price,omitempty" validate:"min=0"`
+	PriceOverride       *float64  `json:"price_override,omitempty" validate:"omitempty,min=0"`
+	PriceOverrideReason string    `json:"price_override_reason,omitempty" validate:"max=500"`
+}
+
+// HasPriceOverride reports whether any item overrides its price
+func HasPriceOverride(items []CreateOrderItemRequest) bool {
+	for _, item := range items {
+		if item.PriceOverride != nil {
+			return true
+		}
+	}
+	return false
 }
 
 // UpdateOrderRequest represents the request to update an order
@@ -39,6 +64,27 @@ type UpdateOrderRequest struct {
 	TaxEnabled      *bool                  `json:"tax_enabled,omitempty"`
 }
 
+// EditOrderRequest represents an edit to the items, address or discount of an order
+type EditOrderRequest struct {
+	Revision        *int                     `json:"revision,omitempty"`
+	AddItems        []CreateOrderItemRequest `json:"add_items,omitempty" validate:"dive"`
+	RemoveItemIDs   []uuid.UUID              `json:"remove_item_ids,omitempty"`
+	UpdateItems     []EditOrderItemRequest   `json:"update_items,omitempty" validate:"dive"`
+	ShippingAddress *string                  `json:"shipping_address,omitempty"`
+	Discount        *float64                 `json:"discount,omitempty" validate:"omitempty,min=0"`
+	Reason          string                   `json:"reason"`
+	EditedBy        *string                  `json:"edited_by,omitempty"`
+	// MarginApprovedBy is the manager approving added items sold below cost or the minimum
+	// margin, set from the authenticated user
+	MarginApprovedBy *string `json:"-"`
+}
+
+// EditOrderItemRequest sets the quantity of an order item; zero removes the item
+type EditOrderItemRequest struct {
+	ItemID   uuid.UUID `json:"item_id" validate:"required"`
+	Quantity int       `json:"quantity" validate:"min=0"`
+}
+
 // UpdateOrderStatusRequest represents the request to update order status
 type UpdateOrderStatusRequest struct {
 	Status domain.OrderStatus `json:"status" validate:"required"`
@@ -69,11 +115,6 @@ type SetShippingFeeRequest struct {
 	Fee float64 `json:"fee" validate:"required,min=0"`
 }
 
-// CalculateTaxRequest represents the request to calculate tax
-type CalculateTaxRequest struct {
-	TaxRate float64 `json:"tax_rate" validate:"required,min=0,max=1"`
-}
-
 // OrderResponse represents an order in the response
 type OrderResponse struct {
 	ID               uuid.UUID              `json:"id"`
@@ -87,11 +128,17 @@ type OrderResponse struct {
 	ShippingFee      float64                `json:"shipping_fee"`
 	Tax              float64                `json:"tax"`
 	TaxEnabled       bool                   `json:"tax_enabled"`
+	TaxMode          domain.TaxMode         `json:"tax_mode"`
+	VATRate          float64                `json:"vat_rate"`
+	CustomerGroup    string                 `json:"customer_group,omitempty"`
 	ShippingAddress  string                 `json:"shipping_address"`
 	BillingAddress   string                 `json:"billing_address"`
 	PaymentMethod    *domain.PaymentMethod  `json:"payment_method,omitempty"`
 	PromoCode        *string                `json:"promo_code,omitempty"`
 	Notes            string                 `json:"notes"`
+	// Promotions is the discount breakdown of the promo codes, returned when the order is created
+	Promotions       []domain.AppliedPromotion `json:"promotions,omitempty"`
+	Revision         int                    `json:"revision"`
 	ConfirmedAt      *time.Time             `json:"confirmed_at,omitempty"`
 	CancelledAt      *time.Time             `json:"cancelled_at,omitempty"`
 	CancelledReason  *string                `json:"cancelled_reason,omitempty"`
@@ -102,15 +149,68 @@ type OrderResponse struct {
 
 // OrderItemResponse represents an order item in the response
 type OrderItemResponse struct {
-	ID             uuid.UUID `json:"id"`
-	ProductID      uuid.UUID `json:"product_id"`
-	Quantity       int       `json:"quantity"`
-	UnitPrice      float64   `json:"unit_price"`
-	TotalPrice     float64   `json:"total_price"`
-	IsOverride     bool      `json:"is_override"`
-	OverrideReason *string   `json:"override_reason,omitempty"`
-	CreatedAt      time.Time `json:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"`
+	ID                  uuid.UUID `json:"id"`
+	ProductID           uuid.UUID `json:"product_id"`
+	Quantity            int       `json:"quantity"`
+	UnitPrice           float64   `json:"unit_price"`
+	TotalPrice          float64   `json:"total_price"`
+	IsOverride          bool      `json:"is_override"`
+	OverrideReason      *string   `json:"override_reason,omitempty"`
+	ShippedQuantity     int       `json:"shipped_quantity"`
+	FulfilledQuantity   int       `json:"fulfilled_quantity"`
+	BackorderedQuantity int       `json:"backordered_quantity"`
+	ReturnedQuantity    int       `json:"returned_quantity"`
+	VATExempt           bool      `json:"vat_exempt"`
+	PriceOverridden     bool      `json:"price_overridden"`
+	PriceOverrideReason *string   `json:"price_override_reason,omitempty"`
+	MarginApprovedBy    *string   `json:"margin_approved_by,omitempty"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+// CreateShipmentRequest represents the request to ship some or all items of an order
+type CreateShipmentRequest struct {
+	Items        []ShipmentItemRequest `json:"items" validate:"required,min=1"`
+	ServiceType  string                `json:"service_type"`
+	Instructions string                `json:"instructions"`
+}
+
+// ShipmentItemRequest represents a quantity of an order item to ship
+type ShipmentItemRequest struct {
+	ItemID   uuid.UUID `json:"item_id" validate:"required"`
+	Quantity int       `json:"quantity" validate:"required,min=1"`
+}
+
+// ShipmentResponse represents a shipment in the response
+type ShipmentResponse struct {
+	ID             uuid.UUID              `json:"id"`
+	OrderID        uuid.UUID              `json:"order_id"`
+	DeliveryID     *uuid.UUID             `json:"delivery_id,omitempty"`
+	TrackingNumber *string                `json:"tracking_number,omitempty"`
+	Carrier        *string                `json:"carrier,omitempty"`
+	Status         domain.ShipmentStatus  `json:"status"`
+	OrderStatus    domain.OrderStatus     `json:"order_status,omitempty"`
+	ShippedAt      time.Time              `json:"shipped_at"`
+	DeliveredAt    *time.Time             `json:"delivered_at,omitempty"`
+	Items          []ShipmentItemResponse `json:"items"`
+}
+
+// ShipmentItemResponse represents a shipped order item in the response
+type ShipmentItemResponse struct {
+	OrderItemID uuid.UUID `json:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	Quantity    int       `json:"quantity"`
+}
+
+// BackorderResponse represents an order item waiting for stock
+type BackorderResponse struct {
+	OrderID             uuid.UUID `json:"order_id"`
+	ItemID              uuid.UUID `json:"item_id"`
+	ProductID           uuid.UUID `json:"product_id"`
+	Quantity            int       `json:"quantity"`
+	ShippedQuantity     int       `json:"shipped_quantity"`
+	BackorderedQuantity int       `json:"backordered_quantity"`
+	CreatedAt           time.Time `json:"created_at"`
 }
 
 // OrderListResponse represents a paginated list of orders
@@ -171,6 +271,9 @@ func ToOrderResponse(order *domain.Order) *OrderResponse {
 		ShippingFee:      order.ShippingFee,
 		Tax:              order.Tax,
 		TaxEnabled:       order.TaxEnabled,
+		TaxMode:          order.TaxMode,
+		VATRate:          order.VATRate,
+		CustomerGroup:    order.CustomerGroup,
 		ShippingAddress:  order.ShippingAddress,
 		BillingAddress:   order.BillingAddress,
 		PaymentMethod:    order.PaymentMethod,
@@ -0,0 +1,38 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+)
+
+// CreatePromotionRequest represents the request to create a promo code
+type CreatePromotionRequest struct {
+	Code        string                     `json:"code" validate:"required"`
+	Description string                     `json:"description"`
+	RewardType  domain.PromotionRewardType `json:"reward_type" validate:"required"`
+	// Value is a percentage for percent rewards and an amount for fixed rewards
+	Value            float64             `json:"value"`
+	MaxDiscount      *float64            `json:"max_discount,omitempty"`
+	MinSpend         float64             `json:"min_spend"`
+	StartsAt         *time.Time          `json:"starts_at,omitempty"`
+	EndsAt           *time.Time          `json:"ends_at,omitempty"`
+	UsageLimit       *int                `json:"usage_limit,omitempty"`
+	PerCustomerLimit *int                `json:"per_customer_limit,omitempty"`
+	ProductIDs       []uuid.UUID         `json:"product_ids,omitempty"`
+	CategoryIDs      []uuid.UUID         `json:"category_ids,omitempty"`
+	MinTier          domain.CustomerTier `json:"min_tier,omitempty"`
+	Stackable        bool                `json:"stackable"`
+}
+
+// UpdatePromotionRequest represents the request to change a promo code. Fields left out keep
+// their value; the reward and scopes cannot change once orders may have used the code.
+type UpdatePromotionRequest struct {
+	Description      *string    `json:"description,omitempty"`
+	StartsAt         *time.Time `json:"starts_at,omitempty"`
+	EndsAt           *time.Time `json:"ends_at,omitempty"`
+	UsageLimit       *int       `json:"usage_limit,omitempty"`
+	PerCustomerLimit *int       `json:"per_customer_limit,omitempty"`
+	IsActive         *bool      `json:"is_active,omitempty"`
+}
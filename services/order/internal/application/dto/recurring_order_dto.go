@@ -0,0 +1,112 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+)
+
+// CreateRecurringOrderRequest represents the request to create a recurring order
+type CreateRecurringOrderRequest struct {
+	CustomerID uuid.UUID `json:"customer_id" validate:"required"`
+	// Schedule is a five-field cron expression in Thai time, e.g. "0 9 * * 1" for Mondays at 09:00
+	Schedule string `json:"schedule" validate:"required"`
+	// ChatID is the chat reminders are sent to; no reminders are sent without it
+	ChatID          string              `json:"chat_id"`
+	Source          *domain.OrderSource `json:"source,omitempty"`
+	ShippingAddress string              `json:"shipping_address" validate:"required"`
+	BillingAddress  string              `json:"billing_address" validate:"required"`
+	ShippingFee     float64             `json:"shipping_fee" validate:"min=0"`
+	Notes           string              `json:"notes"`
+	// CustomerGroup prices every order for a customer group such as wholesale. Setting it needs
+	// the orders:override_price permission.
+	CustomerGroup string                   `json:"customer_group,omitempty" validate:"max=50"`
+	Items         []CreateOrderItemRequest `json:"items" validate:"required,min=1,dive"`
+}
+
+// PauseRecurringOrderRequest represents the request to pause a recurring order, until a time or
+// until it is resumed
+type PauseRecurringOrderRequest struct {
+	Until *time.Time `json:"until,omitempty"`
+}
+
+// ModifyNextOccurrenceRequest changes the next order of a recurring order only. Omitted fields
+// keep the standing values.
+type ModifyNextOccurrenceRequest struct {
+	Items           []CreateOrderItemRequest `json:"items,omitempty" validate:"dive"`
+	ShippingAddress *string                  `json:"shipping_address,omitempty"`
+	Notes           *string                  `json:"notes,omitempty"`
+}
+
+// RecurringOrderResponse represents a recurring order in the response
+type RecurringOrderResponse struct {
+	ID              uuid.UUID                      `json:"id"`
+	CustomerID      uuid.UUID                      `json:"customer_id"`
+	ChatID          string                         `json:"chat_id,omitempty"`
+	Source          domain.OrderSource             `json:"source"`
+	Schedule        string                         `json:"schedule"`
+	ShippingAddress string                         `json:"shipping_address"`
+	BillingAddress  string                         `json:"billing_address"`
+	ShippingFee     float64                        `json:"shipping_fee"`
+	CustomerGroup   string                         `json:"customer_group,omitempty"`
+	Notes           string                         `json:"notes"`
+	Status          domain.RecurringOrderStatus    `json:"status"`
+	NextRunAt       time.Time                      `json:"next_run_at"`
+	PausedUntil     *time.Time                     `json:"paused_until,omitempty"`
+	NextOverride    *domain.RecurringOrderOverride `json:"next_override,omitempty"`
+	ReminderSentFor *time.Time                     `json:"reminder_sent_for,omitempty"`
+	Revision        int                            `json:"revision"`
+	Items           []domain.RecurringOrderItem    `json:"items"`
+	CreatedAt       time.Time                      `json:"created_at"`
+	UpdatedAt       time.Time                      `json:"updated_at"`
+}
+
+// RecurringOrderRunResponse represents an occurrence of a recurring order in the response
+type RecurringOrderRunResponse struct {
+	ID           uuid.UUID                      `json:"id"`
+	ScheduledFor time.Time                      `json:"scheduled_for"`
+	Status       domain.RecurringOrderRunStatus `json:"status"`
+	OrderID      *uuid.UUID                     `json:"order_id,omitempty"`
+	Error        *string                        `json:"error,omitempty"`
+	CreatedAt    time.Time                      `json:"created_at"`
+	UpdatedAt    time.Time                      `json:"updated_at"`
+}
+
+// ToRecurringOrderResponse converts a domain recurring order to a response DTO
+func ToRecurringOrderResponse(order *domain.RecurringOrder) *RecurringOrderResponse {
+	return &RecurringOrderResponse{
+		ID:              order.ID,
+		CustomerID:      order.CustomerID,
+		ChatID:          order.ChatID,
+		Source:          order.Source,
+		Schedule:        order.Schedule,
+		ShippingAddress: order.ShippingAddress,
+		BillingAddress:  order.BillingAddress,
+		ShippingFee:     order.ShippingFee,
+		CustomerGroup:   order.CustomerGroup,
+		Notes:           order.Notes,
+		Status:          order.Status,
+		NextRunAt:       order.NextRunAt,
+		PausedUntil:     order.PausedUntil,
+		NextOverride:    order.NextOverride,
+		ReminderSentFor: order.ReminderSentFor,
+		Revision:        order.Revision,
+		Items:           order.Items,
+		CreatedAt:       order.CreatedAt,
+		UpdatedAt:       order.UpdatedAt,
+	}
+}
+
+// ToRecurringOrderRunResponse converts a domain recurring order run to a response DTO
+func ToRecurringOrderRunResponse(run *domain.RecurringOrderRun) *RecurringOrderRunResponse {
+	return &RecurringOrderRunResponse{
+		ID:           run.ID,
+		ScheduledFor: run.ScheduledFor,
+		Status:       run.Status,
+		OrderID:      run.OrderID,
+		Error:        run.Error,
+		CreatedAt:    run.CreatedAt,
+		UpdatedAt:    run.UpdatedAt,
+	}
+}
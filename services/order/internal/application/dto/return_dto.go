@@ -0,0 +1,76 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+)
+
+// CreateReturnRequest represents the request to return delivered items of an order
+type CreateReturnRequest struct {
+	Items []ReturnItemRequest `json:"items" validate:"required,min=1,dive"`
+	Notes string              `json:"notes"`
+}
+
+// ReturnItemRequest represents a quantity of an order item to return and why
+type ReturnItemRequest struct {
+	ItemID   uuid.UUID           `json:"item_id" validate:"required"`
+	Quantity int                 `json:"quantity" validate:"required,min=1"`
+	Reason   domain.ReturnReason `json:"reason" validate:"required"`
+}
+
+// ApproveReturnRequest represents the request to approve a return and book its pickup
+type ApproveReturnRequest struct {
+	ServiceType  string `json:"service_type"`
+	Instructions string `json:"instructions"`
+}
+
+// RejectReturnRequest represents the request to reject a return
+type RejectReturnRequest struct {
+	Reason string `json:"reason" validate:"required"`
+}
+
+// ReceiveReturnRequest represents the request to record a returned parcel at the warehouse.
+// Items left out are restocked unless they were returned damaged or defective.
+type ReceiveReturnRequest struct {
+	Items []RestockDecisionRequest `json:"items" validate:"dive"`
+}
+
+// RestockDecisionRequest decides whether a returned item goes back into stock
+type RestockDecisionRequest struct {
+	ReturnItemID uuid.UUID `json:"return_item_id" validate:"required"`
+	Restock      bool      `json:"restock"`
+}
+
+// ReturnResponse represents a return request in the response
+type ReturnResponse struct {
+	ID                   uuid.UUID            `json:"id"`
+	OrderID              uuid.UUID            `json:"order_id"`
+	CustomerID           uuid.UUID            `json:"customer_id"`
+	Status               domain.ReturnStatus  `json:"status"`
+	OrderStatus          domain.OrderStatus   `json:"order_status,omitempty"`
+	Notes                string               `json:"notes"`
+	RefundAmount         float64              `json:"refund_amount"`
+	PickupDeliveryID     *uuid.UUID           `json:"pickup_delivery_id,omitempty"`
+	PickupTrackingNumber *string              `json:"pickup_tracking_number,omitempty"`
+	RejectionReason      *string              `json:"rejection_reason,omitempty"`
+	PaymentTransactionID *uuid.UUID           `json:"payment_transaction_id,omitempty"`
+	PointsClawedBack     int                  `json:"points_clawed_back"`
+	ApprovedAt           *time.Time           `json:"approved_at,omitempty"`
+	ReceivedAt           *time.Time           `json:"received_at,omitempty"`
+	RefundedAt           *time.Time           `json:"refunded_at,omitempty"`
+	CreatedAt            time.Time            `json:"created_at"`
+	Items                []ReturnItemResponse `json:"items"`
+}
+
+// ReturnItemResponse represents a returned order item in the response
+type ReturnItemResponse struct {
+	ID           uuid.UUID           `json:"id"`
+	OrderItemID  uuid.UUID           `json:"order_item_id"`
+	ProductID    uuid.UUID           `json:"product_id"`
+	Quantity     int                 `json:"quantity"`
+	Reason       domain.ReturnReason `json:"reason"`
+	RefundAmount float64             `json:"refund_amount"`
+	Restock      *bool               `json:"restock,omitempty"`
+}
@@ -0,0 +1,35 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+)
+
+// SearchOrdersRequest represents the filters, sort and page of an order search
+type SearchOrdersRequest struct {
+	Statuses       []domain.OrderStatus   `json:"status,omitempty"`
+	Sources        []domain.OrderSource   `json:"source,omitempty"`
+	PaidStatuses   []domain.PaidStatus    `json:"paid_status,omitempty"`
+	PaymentMethods []domain.PaymentMethod `json:"payment_method,omitempty"`
+	CustomerID     *uuid.UUID             `json:"customer_id,omitempty"`
+	// CreatedFrom is inclusive, CreatedTo exclusive
+	CreatedFrom *time.Time `json:"created_from,omitempty"`
+	CreatedTo   *time.Time `json:"created_to,omitempty"`
+	// Query matches part of the order code or notes
+	Query     string   `json:"q,omitempty"`
+	MinAmount *float64 `json:"min_amount,omitempty"`
+	MaxAmount *float64 `json:"max_amount,omitempty"`
+	// Sort is created_at, updated_at or total_amount; a leading "-" sorts descending.
+	// The default is -created_at.
+	Sort   string `json:"sort,omitempty"`
+	Cursor string `json:"cursor,omitempty"`
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// OrderSearchResponse represents a page of an order search. NextCursor is empty on the last page.
+type OrderSearchResponse struct {
+	Orders     []*OrderResponse `json:"orders"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
@@ -3,6 +3,7 @@ package dto
 import (
 	"time"
 	"github.com/google/uuid"
+	"order/internal/domain"
 )
 
 // DailyStats represents daily order statistics
@@ -14,6 +15,10 @@ type DailyStats struct {
 	PendingOrders  int       `json:"pending_orders"`
 	CompletedOrders int      `json:"completed_orders"`
 	CancelledOrders int      `json:"cancelled_orders"`
+	OrdersByStatus  map[domain.OrderStatus]int `json:"orders_by_status,omitempty"`
+	HourlyBreakdown []HourlyStats     `json:"hourly_breakdown,omitempty"`
+	ChannelBreakdown []ChannelStats   `json:"channel_breakdown,omitempty"`
+	PaymentMethodBreakdown []PaymentMethodStats `json:"payment_method_breakdown,omitempty"`
 }
 
 // MonthlyStats represents monthly order statistics with trend data
@@ -26,6 +31,35 @@ type MonthlyStats struct {
 	DailyBreakdown  []DailyStats `json:"daily_breakdown"`
 	GrowthRate      float64     `json:"growth_rate"`      // Compared to previous month
 	ComparisonData  *MonthlyComparison `json:"comparison_data,omitempty"`
+	OrdersByStatus  map[domain.OrderStatus]int `json:"orders_by_status,omitempty"`
+	HourlyBreakdown []HourlyStats     `json:"hourly_breakdown,omitempty"`
+	ChannelBreakdown []ChannelStats   `json:"channel_breakdown,omitempty"`
+	PaymentMethodBreakdown []PaymentMethodStats `json:"payment_method_breakdown,omitempty"`
+}
+
+// HourlyStats represents the orders placed in an hour of the day (UTC)
+type HourlyStats struct {
+	Hour          int     `json:"hour"`
+	OrdersCount   int     `json:"orders_count"`
+	Revenue       float64 `json:"revenue"`
+	AvgOrderValue float64 `json:"avg_order_value"`
+}
+
+// ChannelStats represents the orders placed through a sales channel
+type ChannelStats struct {
+	Source        domain.OrderSource `json:"source"`
+	OrdersCount   int                `json:"orders_count"`
+	Revenue       float64            `json:"revenue"`
+	AvgOrderValue float64            `json:"avg_order_value"`
+}
+
+// PaymentMethodStats represents the orders paid with a payment method. Orders without a
+// payment method have an empty payment_method.
+type PaymentMethodStats struct {
+	PaymentMethod string  `json:"payment_method"`
+	OrdersCount   int     `json:"orders_count"`
+	Revenue       float64 `json:"revenue"`
+	AvgOrderValue float64 `json:"avg_order_value"`
 }
 
 // MonthlyComparison represents comparison with previous month
@@ -0,0 +1,312 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+	"order/internal/infrastructure/client"
+)
+
+// defaultServiceType is used for deliveries when the request does not name one
+const defaultServiceType = "standard"
+
+// CreateShipment ships some or all of an order's items as a new delivery order in the shipping
+// service. Items left behind are backordered and the order status follows the item quantities.
+func (s *Service) CreateShipment(ctx context.Context, orderID uuid.UUID, req *dto.CreateShipmentRequest) (*dto.ShipmentResponse, error) {
+	order, err := s.getOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousShipped := make(map[uuid.UUID]int, len(order.Items))
+	for _, item := range order.Items {
+		previousShipped[item.ID] = item.ShippedQuantity
+	}
+	oldStatus := order.Status
+
+	lines := make([]domain.ShipmentLine, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = domain.ShipmentLine{ItemID: item.ItemID, Quantity: item.Quantity}
+	}
+	shipment, err := order.Ship(lines)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceType := req.ServiceType
+	if serviceType == "" {
+		serviceType = defaultServiceType
+	}
+	delivery, err := s.deliveries.CreateDelivery(ctx, &client.CreateDeliveryRequest{
+		OrderID:        order.ID,
+		Destination:    client.AddressInfo{AddressLine1: order.ShippingAddress},
+		ServiceType:    serviceType,
+		Instructions:   req.Instructions,
+		IdempotencyKey: shipmentDeliveryKey(shipment.ID),
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to create delivery for shipment")
+		return nil, fmt.Errorf("%w: %v", domain.ErrDeliveryNotCreated, err)
+	}
+	shipment.DeliveryID = &delivery.ID
+	if delivery.TrackingNumber != "" {
+		shipment.TrackingNumber = &delivery.TrackingNumber
+	}
+	if delivery.Carrier != "" {
+		shipment.Carrier = &delivery.Carrier
+	}
+
+	// Save shipment, item quantities, status and outbox event atomically
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderShipped, map[string]interface{}{
+		"customer_id":     order.CustomerID.String(),
+		"old_status":      string(oldStatus),
+		"new_status":      string(order.Status),
+		"shipment_id":     shipment.ID.String(),
+		"delivery_id":     delivery.ID.String(),
+		"tracking_number": delivery.TrackingNumber,
+		"items":           convertShipmentItemsToEventData(shipment.Items),
+		"backorders":      convertBackordersToEventData(order.Items),
+	})
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.shipmentRepo.Create(ctx, shipment); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to create shipment")
+			return err
+		}
+		for i := range order.Items {
+			if err := s.orderItemRepo.UpdateFulfilment(ctx, &order.Items[i], previousShipped[order.Items[i].ID]); err != nil {
+				s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to update shipped quantities")
+				return err
+			}
+		}
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to update order status")
+			return err
+		}
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to store order shipped event")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		// Cancel the delivery so shipping the items again does not leave a second one behind
+		if cancelErr := s.deliveries.CancelDelivery(ctx, delivery.ID, fmt.Sprintf("Shipment %s could not be saved", shipment.ID)); cancelErr != nil {
+			s.logger.WithError(cancelErr).WithFields(logrus.Fields{
+				"order_id":    orderID,
+				"delivery_id": delivery.ID,
+			}).Error("Delivery was created but the shipment could not be saved, and the delivery could not be cancelled")
+		}
+		return nil, err
+	}
+
+	if err := s.cache.DeleteOrder(ctx, orderID.String()); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate order cache")
+	}
+
+	audit := domain.NewAuditLog(order.ID, nil, domain.AuditActionShip, map[string]interface{}{
+		"shipment_id": shipment.ID.String(),
+		"delivery_id": delivery.ID.String(),
+		"old_status":  string(oldStatus),
+		"new_status":  string(order.Status),
+		"items":       convertShipmentItemsToEventData(shipment.Items),
+	})
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		s.logger.WithError(err).Warn("Failed to create audit record")
+	}
+
+	return shipmentToResponse(shipment, order.Status), nil
+}
+
+// shipmentDeliveryKey is the idempotency key of the delivery created for a shipment, so a
+// retried request never creates a second delivery for it
+func shipmentDeliveryKey(shipmentID uuid.UUID) string {
+	return fmt.Sprintf("shipment:%s:delivery", shipmentID)
+}
+
+// MarkShipmentDelivered records that a shipment reached the customer and moves the order to
+// partially delivered or delivered
+func (s *Service) MarkShipmentDelivered(ctx context.Context, orderID, shipmentID uuid.UUID) (*dto.ShipmentResponse, error) {
+	order, err := s.getOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	shipment, err := s.shipmentRepo.GetByID(ctx, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldStatus := order.Status
+	if err := order.DeliverShipment(shipment); err != nil {
+		return nil, err
+	}
+
+	eventType := domain.EventOrderUpdated
+	if order.Status == domain.OrderStatusDelivered {
+		eventType = domain.EventOrderDelivered
+	}
+	event := domain.NewOrderEvent(order.ID, eventType, map[string]interface{}{
+		"customer_id": order.CustomerID.String(),
+		"old_status":  string(oldStatus),
+		"new_status":  string(order.Status),
+		"shipment_id": shipment.ID.String(),
+		"items":       convertShipmentItemsToEventData(shipment.Items),
+	})
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.shipmentRepo.MarkDelivered(ctx, shipment); err != nil {
+			s.logger.WithError(err).WithField("shipment_id", shipmentID).Error("Failed to mark shipment delivered")
+			return err
+		}
+		for i := range order.Items {
+			item := &order.Items[i]
+			if err := s.orderItemRepo.UpdateFulfilment(ctx, item, item.ShippedQuantity); err != nil {
+				s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to update fulfilled quantities")
+				return err
+			}
+		}
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to update order status")
+			return err
+		}
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to store shipment delivered event")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.DeleteOrder(ctx, orderID.String()); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate order cache")
+	}
+
+	audit := domain.NewAuditLog(order.ID, nil, domain.AuditActionDeliver, map[string]interface{}{
+		"shipment_id": shipment.ID.String(),
+		"old_status":  string(oldStatus),
+		"new_status":  string(order.Status),
+	})
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		s.logger.WithError(err).Warn("Failed to create audit record")
+	}
+
+	return shipmentToResponse(shipment, order.Status), nil
+}
+
+// GetShipments retrieves the shipments of an order
+func (s *Service) GetShipments(ctx context.Context, orderID uuid.UUID) ([]*dto.ShipmentResponse, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	shipments, err := s.shipmentRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to get shipments")
+		return nil, err
+	}
+
+	responses := make([]*dto.ShipmentResponse, len(shipments))
+	for i, shipment := range shipments {
+		responses[i] = shipmentToResponse(shipment, "")
+	}
+	return responses, nil
+}
+
+// GetBackorders lists order items waiting for stock, oldest first
+func (s *Service) GetBackorders(ctx context.Context, limit int) ([]*dto.BackorderResponse, error) {
+	items, err := s.orderItemRepo.GetBackordered(ctx, limit)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get backordered items")
+		return nil, err
+	}
+
+	responses := make([]*dto.BackorderResponse, len(items))
+	for i, item := range items {
+		responses[i] = &dto.BackorderResponse{
+			OrderID:             item.OrderID,
+			ItemID:              item.ID,
+			ProductID:           item.ProductID,
+			Quantity:            item.Quantity,
+			ShippedQuantity:     item.ShippedQuantity,
+			BackorderedQuantity: item.BackorderedQuantity,
+			CreatedAt:           item.CreatedAt,
+		}
+	}
+	return responses, nil
+}
+
+// getOrderWithItems retrieves an order together with its items
+func (s *Service) getOrderWithItems(ctx context.Context, orderID uuid.UUID) (*domain.Order, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to get order")
+		return nil, err
+	}
+
+	items, err := s.orderItemRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to get order items")
+		return nil, err
+	}
+	order.Items = make([]domain.OrderItem, len(items))
+	for i, item := range items {
+		order.Items[i] = *item
+	}
+	return order, nil
+}
+
+func shipmentToResponse(shipment *domain.Shipment, orderStatus domain.OrderStatus) *dto.ShipmentResponse {
+	items := make([]dto.ShipmentItemResponse, len(shipment.Items))
+	for i, item := range shipment.Items {
+		items[i] = dto.ShipmentItemResponse{
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			Quantity:    item.Quantity,
+		}
+	}
+
+	return &dto.ShipmentResponse{
+		ID:             shipment.ID,
+		OrderID:        shipment.OrderID,
+		DeliveryID:     shipment.DeliveryID,
+		TrackingNumber: shipment.TrackingNumber,
+		Carrier:        shipment.Carrier,
+		Status:         shipment.Status,
+		OrderStatus:    orderStatus,
+		ShippedAt:      shipment.ShippedAt,
+		DeliveredAt:    shipment.DeliveredAt,
+		Items:          items,
+	}
+}
+
+func convertShipmentItemsToEventData(items []domain.ShipmentItem) []map[string]interface{} {
+	eventItems := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		eventItems[i] = map[string]interface{}{
+			"order_item_id": item.OrderItemID.String(),
+			"product_id":    item.ProductID.String(),
+			"quantity":      item.Quantity,
+		}
+	}
+	return eventItems
+}
+
+func convertBackordersToEventData(items []domain.OrderItem) []map[string]interface{} {
+	backorders := []map[string]interface{}{}
+	for _, item := range items {
+		if item.BackorderedQuantity == 0 {
+			continue
+		}
+		backorders = append(backorders, map[string]interface{}{
+			"order_item_id": item.ID.String(),
+			"product_id":    item.ProductID.String(),
+			"quantity":      item.BackorderedQuantity,
+		})
+	}
+	return backorders
+}
@@ -0,0 +1,202 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// DocumentFormat is a format an invoice can be rendered in
+type DocumentFormat string
+
+const (
+	DocumentFormatPDF DocumentFormat = "pdf"
+	// DocumentFormatXML is the e-Tax Invoice XML sent to the Revenue Department
+	DocumentFormatXML DocumentFormat = "xml"
+)
+
+// IssueInvoice issues a tax invoice or receipt for the order. The invoice is numbered in the
+// sequence of the issuing branch, and an order gets at most one of each.
+func (s *Service) IssueInvoice(ctx context.Context, orderID uuid.UUID, req *dto.IssueInvoiceRequest) (*dto.InvoiceResponse, error) {
+	order, err := s.getOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	seller := s.tax.Seller
+	if req.BranchCode != "" {
+		if !domain.ValidBranchCode(req.BranchCode) {
+			return nil, domain.ErrInvalidBranchCode
+		}
+		seller.BranchCode = req.BranchCode
+	}
+	buyer := domain.InvoiceParty{
+		Name:       strings.TrimSpace(req.Buyer.Name),
+		TaxID:      strings.TrimSpace(req.Buyer.TaxID),
+		BranchCode: strings.TrimSpace(req.Buyer.BranchCode),
+		Address:    strings.TrimSpace(req.Buyer.Address),
+	}
+
+	// Check the request before looking up product names for the lines
+	if _, err := domain.NewInvoice(order, req.Type, seller, buyer, nil); err != nil {
+		return nil, err
+	}
+	products, err := s.newProductCatalog(order.Items).get(ctx)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to look up invoiced products")
+		return nil, fmt.Errorf("%w: %v", domain.ErrProductLookupFailed, err)
+	}
+	descriptions := make(map[uuid.UUID]string, len(products))
+	for productID, product := range products {
+		descriptions[productID] = product.Name
+	}
+
+	invoice, err := domain.NewInvoice(order, req.Type, seller, buyer, descriptions)
+	if err != nil {
+		return nil, err
+	}
+
+	// Number and save the invoice with its outbox event atomically
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.invoiceRepo.Create(ctx, invoice); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to create invoice")
+			return err
+		}
+		if err := s.eventRepo.Create(ctx, invoiceIssuedEvent(order, invoice)); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to store invoice issued event")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.auditInvoice(ctx, invoice)
+
+	return dto.ToInvoiceResponse(invoice), nil
+}
+
+// GetInvoices retrieves the invoices, receipts and credit notes of an order
+func (s *Service) GetInvoices(ctx context.Context, orderID uuid.UUID) ([]*dto.InvoiceResponse, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	invoices, err := s.invoiceRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to get invoices")
+		return nil, err
+	}
+
+	responses := make([]*dto.InvoiceResponse, len(invoices))
+	for i, invoice := range invoices {
+		responses[i] = dto.ToInvoiceResponse(invoice)
+	}
+	return responses, nil
+}
+
+// GetInvoice retrieves an invoice, receipt or credit note
+func (s *Service) GetInvoice(ctx context.Context, id uuid.UUID) (*dto.InvoiceResponse, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToInvoiceResponse(invoice), nil
+}
+
+// RenderInvoice renders an invoice as a PDF or as e-Tax Invoice XML and returns it with its file
+// name
+func (s *Service) RenderInvoice(ctx context.Context, id uuid.UUID, format DocumentFormat) ([]byte, string, error) {
+	invoice, err := s.invoiceRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var document []byte
+	switch format {
+	case DocumentFormatPDF:
+		document, err = s.documents.PDF(invoice)
+	case DocumentFormatXML:
+		document, err = s.documents.XML(invoice)
+	default:
+		return nil, "", fmt.Errorf("unknown document format %q", format)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("invoice_id", id).Error("Failed to render invoice")
+		return nil, "", err
+	}
+	return document, fmt.Sprintf("%s.%s", invoice.Number, format), nil
+}
+
+// prepareCreditNote prepares the credit note for a refunded return against the latest tax
+// invoice or receipt of the order. Orders that were never invoiced get no credit note.
+func (s *Service) prepareCreditNote(ctx context.Context, order *domain.Order, request *domain.ReturnRequest) (*domain.Invoice, error) {
+	if request.RefundAmount <= 0 {
+		return nil, nil
+	}
+
+	invoices, err := s.invoiceRepo.GetByOrderID(ctx, order.ID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to get order invoices")
+		return nil, err
+	}
+
+	// A tax invoice is what the buyer claims input VAT with, so it is credited over a receipt
+	var original *domain.Invoice
+	for _, invoice := range invoices {
+		if invoice.Type == domain.InvoiceTypeCreditNote {
+			continue
+		}
+		if original == nil || original.Type != domain.InvoiceTypeTaxInvoice || invoice.Type == domain.InvoiceTypeTaxInvoice {
+			original = invoice
+		}
+	}
+	if original == nil {
+		return nil, nil
+	}
+
+	previouslyCredited := 0.0
+	for _, invoice := range invoices {
+		if invoice.ReferenceInvoiceID != nil && *invoice.ReferenceInvoiceID == original.ID {
+			previouslyCredited += invoice.Difference()
+		}
+	}
+	return domain.NewCreditNote(original, order, request, previouslyCredited)
+}
+
+func invoiceIssuedEvent(order *domain.Order, invoice *domain.Invoice) *domain.OrderEventOutbox {
+	return domain.NewOrderEvent(order.ID, domain.EventInvoiceIssued, map[string]interface{}{
+		"customer_id":    order.CustomerID.String(),
+		"invoice_id":     invoice.ID.String(),
+		"invoice_type":   string(invoice.Type),
+		"number":         invoice.Number,
+		"branch_code":    invoice.BranchCode,
+		"vat":            invoice.VAT,
+		"total":          invoice.Total,
+		"return_id":      uuidString(invoice.ReturnID),
+		"reference_id":   uuidString(invoice.ReferenceInvoiceID),
+		"buyer_tax_id":   invoice.Buyer.TaxID,
+		"buyer_branch":   invoice.Buyer.BranchCode,
+		"issued_at":      invoice.IssuedAt,
+		"tax_mode":       string(invoice.TaxMode),
+		"taxable_amount": invoice.TaxableAmount,
+		"exempt_amount":  invoice.ExemptAmount,
+	})
+}
+
+func (s *Service) auditInvoice(ctx context.Context, invoice *domain.Invoice) {
+	audit := domain.NewAuditLog(invoice.OrderID, nil, domain.AuditActionInvoice, map[string]interface{}{
+		"invoice_id":   invoice.ID.String(),
+		"invoice_type": string(invoice.Type),
+		"number":       invoice.Number,
+		"total":        invoice.Total,
+	})
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		s.logger.WithError(err).Warn("Failed to create audit record")
+	}
+}
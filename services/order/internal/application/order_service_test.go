@@ -2,17 +2,20 @@ package application
 
 import (
 	"context"
+	"errors"
+	"io"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 
 	"order/internal/application/dto"
 	"order/internal/domain"
-	"order/pkg/logger"
+	"order/internal/infrastructure/client"
 )
 
 // Mock implementations for testing
@@ -35,6 +38,16 @@ func (m *MockOrderRepository) Update(ctx context.Context, order *domain.Order) e
 	return args.Error(0)
 }
 
+func (m *MockOrderRepository) LockRevision(ctx context.Context, id uuid.UUID) (int, error) {
+	args := m.Called(ctx, id)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockOrderRepository) UpdateRevision(ctx context.Context, order *domain.Order, previousRevision int) error {
+	args := m.Called(ctx, order, previousRevision)
+	return args.Error(0)
+}
+
 func (m *MockOrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID) ([]*domain.Order, error) {
 	args := m.Called(ctx, customerID)
 	return args.Get(0).([]*domain.Order), args.Error(1)
@@ -65,6 +78,11 @@ func (m *MockOrderRepository) List(ctx context.Context, limit, offset int) ([]*d
 	return args.Get(0).([]*domain.Order), args.Error(1)
 }
 
+func (m *MockOrderRepository) Search(ctx context.Context, search *domain.OrderSearch) ([]*domain.Order, error) {
+	args := m.Called(ctx, search)
+	return args.Get(0).([]*domain.Order), args.Error(1)
+}
+
 type MockOrderItemRepository struct {
 	mock.Mock
 }
@@ -79,21 +97,36 @@ func (m *MockOrderItemRepository) GetByOrderID(ctx context.Context, orderID uuid
 	return args.Get(0).([]*domain.OrderItem), args.Error(1)
 }
 
+func (m *MockOrderItemRepository) GetByOrderIDs(ctx context.Context, orderIDs []uuid.UUID) ([]*domain.OrderItem, error) {
+	args := m.Called(ctx, orderIDs)
+	return args.Get(0).([]*domain.OrderItem), args.Error(1)
+}
+
 func (m *MockOrderItemRepository) Update(ctx context.Context, orderItem *domain.OrderItem) error {
 	args := m.Called(ctx, orderItem)
 	return args.Error(0)
 }
 
-func (m *MockOrderItemRepository) Delete(ctx context.Context, id uuid.UUID) error {
-	args := m.Called(ctx, id)
+func (m *MockOrderItemRepository) UpdateFulfilment(ctx context.Context, orderItem *domain.OrderItem, previousShipped int) error {
+	args := m.Called(ctx, orderItem, previousShipped)
+	return args.Error(0)
+}
+
+func (m *MockOrderItemRepository) UpdateReturned(ctx context.Context, orderItem *domain.OrderItem, previousReturned int) error {
+	args := m.Called(ctx, orderItem, previousReturned)
 	return args.Error(0)
 }
 
-func (m *MockOrderItemRepository) GetByProductID(ctx context.Context, productID uuid.UUID) ([]*domain.OrderItem, error) {
-	args := m.Called(ctx, productID)
+func (m *MockOrderItemRepository) GetBackordered(ctx context.Context, limit int) ([]*domain.OrderItem, error) {
+	args := m.Called(ctx, limit)
 	return args.Get(0).([]*domain.OrderItem), args.Error(1)
 }
 
+func (m *MockOrderItemRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
 func (m *MockOrderItemRepository) GetAllOrderItems(ctx context.Context) ([]*domain.OrderItem, error) {
 	args := m.Called(ctx)
 	return args.Get(0).([]*domain.OrderItem), args.Error(1)
@@ -137,13 +170,13 @@ func (m *MockOrderEventRepository) Create(ctx context.Context, event *domain.Ord
 	return args.Error(0)
 }
 
-func (m *MockOrderEventRepository) GetPendingEvents(ctx context.Context, limit int) ([]*domain.OrderEventOutbox, error) {
-	args := m.Called(ctx, limit)
+func (m *MockOrderEventRepository) ClaimPendingEvents(ctx context.Context, limit int, claimUntil time.Time) ([]*domain.OrderEventOutbox, error) {
+	args := m.Called(ctx, limit, claimUntil)
 	return args.Get(0).([]*domain.OrderEventOutbox), args.Error(1)
 }
 
-func (m *MockOrderEventRepository) GetFailedEvents(ctx context.Context, maxRetries int, limit int) ([]*domain.OrderEventOutbox, error) {
-	args := m.Called(ctx, maxRetries, limit)
+func (m *MockOrderEventRepository) ClaimFailedEvents(ctx context.Context, maxRetries int, limit int, claimUntil time.Time) ([]*domain.OrderEventOutbox, error) {
+	args := m.Called(ctx, maxRetries, limit, claimUntil)
 	return args.Get(0).([]*domain.OrderEventOutbox), args.Error(1)
 }
 
@@ -157,11 +190,21 @@ func (m *MockOrderEventRepository) MarkAsSent(ctx context.Context, eventID uuid.
 	return args.Error(0)
 }
 
-func (m *MockOrderEventRepository) MarkAsFailed(ctx context.Context, eventID uuid.UUID) error {
-	args := m.Called(ctx, eventID)
+func (m *MockOrderEventRepository) MarkAsFailed(ctx context.Context, eventID uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	args := m.Called(ctx, eventID, nextAttemptAt, lastError)
+	return args.Error(0)
+}
+
+func (m *MockOrderEventRepository) MarkAsDeadLetter(ctx context.Context, eventID uuid.UUID, lastError string) error {
+	args := m.Called(ctx, eventID, lastError)
 	return args.Error(0)
 }
 
+func (m *MockOrderEventRepository) GetStats(ctx context.Context) (*domain.OutboxStats, error) {
+	args := m.Called(ctx)
+	return args.Get(0).(*domain.OutboxStats), args.Error(1)
+}
+
 func (m *MockOrderEventRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.OrderEventOutbox, error) {
 	args := m.Called(ctx, orderID)
 	return args.Get(0).([]*domain.OrderEventOutbox), args.Error(1)
@@ -172,80 +215,199 @@ func (m *MockOrderEventRepository) Delete(ctx context.Context, eventID uuid.UUID
 	return args.Error(0)
 }
 
-type MockEventPublisher struct {
+type MockPromotionRepository struct {
 	mock.Mock
 }
 
-func (m *MockEventPublisher) PublishEvent(ctx context.Context, event *domain.OrderEvent) error {
-	args := m.Called(ctx, event)
+func (m *MockPromotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	args := m.Called(ctx, promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) GetByCode(ctx context.Context, code string) (*domain.Promotion, error) {
+	args := m.Called(ctx, code)
+	return args.Get(0).(*domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) GetByCodes(ctx context.Context, codes []string) ([]*domain.Promotion, error) {
+	args := m.Called(ctx, codes)
+	return args.Get(0).([]*domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) List(ctx context.Context, activeOnly bool, limit, offset int) ([]*domain.Promotion, error) {
+	args := m.Called(ctx, activeOnly, limit, offset)
+	return args.Get(0).([]*domain.Promotion), args.Error(1)
+}
+
+func (m *MockPromotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	args := m.Called(ctx, promotion)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) Redeem(ctx context.Context, redemption *domain.PromotionRedemption) error {
+	args := m.Called(ctx, redemption)
+	return args.Error(0)
+}
+
+func (m *MockPromotionRepository) ReleaseByOrderID(ctx context.Context, orderID uuid.UUID) error {
+	args := m.Called(ctx, orderID)
 	return args.Error(0)
 }
 
-func (m *MockEventPublisher) Close() error {
-	args := m.Called()
+type MockStockReservationClient struct {
+	mock.Mock
+}
+
+func (m *MockStockReservationClient) ReserveStock(ctx context.Context, orderID uuid.UUID, idempotencyKey, vipLevel string, items []client.StockReservationItem, ttl time.Duration) ([]client.StockReservation, error) {
+	args := m.Called(ctx, orderID, idempotencyKey, vipLevel, items, ttl)
+	return nil, args.Error(0)
+}
+
+func (m *MockStockReservationClient) ConsumeStock(ctx context.Context, orderID uuid.UUID) error {
+	args := m.Called(ctx, orderID)
 	return args.Error(0)
 }
 
-type MockLogger struct{}
+func (m *MockStockReservationClient) ReleaseStock(ctx context.Context, orderID uuid.UUID, reason string) error {
+	args := m.Called(ctx, orderID, reason)
+	return args.Error(0)
+}
 
-func (l *MockLogger) Debug(args ...interface{})                             {}
-func (l *MockLogger) Info(args ...interface{})                              {}
-func (l *MockLogger) Warn(args ...interface{})                              {}
-func (l *MockLogger) Error(args ...interface{})                             {}
-func (l *MockLogger) Fatal(args ...interface{})                             {}
-func (l *MockLogger) Debugf(format string, args ...interface{})             {}
-func (l *MockLogger) Infof(format string, args ...interface{})              {}
-func (l *MockLogger) Warnf(format string, args ...interface{})              {}
-func (l *MockLogger) Errorf(format string, args ...interface{})             {}
-func (l *MockLogger) Fatalf(format string, args ...interface{})             {}
-func (l *MockLogger) WithField(key string, value interface{}) logger.Logger { return l }
-func (l *MockLogger) WithFields(fields map[string]interface{}) logger.Logger { return l }
+func (m *MockStockReservationClient) ReleaseReservation(ctx context.Context, orderID uuid.UUID, idempotencyKey, reason string) error {
+	args := m.Called(ctx, orderID, idempotencyKey, reason)
+	return args.Error(0)
+}
+
+func (m *MockStockReservationClient) RestockStock(ctx context.Context, orderID uuid.UUID, idempotencyKey, reason string, items []client.StockReservationItem) error {
+	args := m.Called(ctx, orderID, idempotencyKey, reason, items)
+	return args.Error(0)
+}
+
+type MockPromotionLookupClient struct {
+	mock.Mock
+}
+
+func (m *MockPromotionLookupClient) GetCustomerTier(ctx context.Context, customerID uuid.UUID) (int, error) {
+	args := m.Called(ctx, customerID)
+	return args.Int(0), args.Error(1)
+}
+
+type MockPricingClient struct {
+	mock.Mock
+}
+
+func (m *MockPricingClient) PriceCart(ctx context.Context, req *client.CartPricingRequest) (*client.PricedCart, error) {
+	args := m.Called(ctx, req)
+	if price, ok := args.Get(0).(func(*client.CartPricingRequest) *client.PricedCart); ok {
+		return price(req), args.Error(1)
+	}
+	return args.Get(0).(*client.PricedCart), args.Error(1)
+}
+
+func (m *MockPricingClient) CheckMargins(ctx context.Context, lines []client.MarginCheckLine) ([]client.MarginCheck, error) {
+	args := m.Called(ctx, lines)
+	return args.Get(0).([]client.MarginCheck), args.Error(1)
+}
+
+// inlineTransactionManager runs the unit of work without a database
+type inlineTransactionManager struct{}
+
+func (inlineTransactionManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
 
 // Test Suite
 type OrderServiceTestSuite struct {
 	suite.Suite
-	orderService         *OrderService
-	mockOrderRepo        *MockOrderRepository
-	mockOrderItemRepo    *MockOrderItemRepository
-	mockAuditRepo        *MockOrderAuditRepository
-	mockEventRepo        *MockOrderEventRepository
-	mockEventPublisher   *MockEventPublisher
-	logger               logger.Logger
+	orderService     *Service
+	mockOrderRepo    *MockOrderRepository
+	mockItemRepo     *MockOrderItemRepository
+	mockAuditRepo    *MockOrderAuditRepository
+	mockEventRepo    *MockOrderEventRepository
+	mockPromoRepo    *MockPromotionRepository
+	mockReservations *MockStockReservationClient
+	mockCustomers    *MockPromotionLookupClient
+	mockPricing      *MockPricingClient
 }
 
 func (suite *OrderServiceTestSuite) SetupTest() {
 	suite.mockOrderRepo = new(MockOrderRepository)
-	suite.mockOrderItemRepo = new(MockOrderItemRepository)
+	suite.mockItemRepo = new(MockOrderItemRepository)
 	suite.mockAuditRepo = new(MockOrderAuditRepository)
 	suite.mockEventRepo = new(MockOrderEventRepository)
-	suite.mockEventPublisher = new(MockEventPublisher)
-	suite.logger = &MockLogger{}
+	suite.mockPromoRepo = new(MockPromotionRepository)
+	suite.mockReservations = new(MockStockReservationClient)
+	suite.mockCustomers = new(MockPromotionLookupClient)
+	suite.mockPricing = new(MockPricingClient)
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
 
-	suite.orderService = NewOrderService(
+	suite.orderService = NewService(
 		suite.mockOrderRepo,
-		suite.mockOrderItemRepo,
+		suite.mockItemRepo,
 		suite.mockAuditRepo,
 		suite.mockEventRepo,
-		suite.mockEventPublisher,
-		suite.logger,
+		nil, nil,
+		suite.mockPromoRepo,
+		nil, nil,
+		inlineTransactionManager{},
+		nil,
+		suite.mockReservations,
+		nil, nil,
+		suite.mockCustomers,
+		nil,
+		suite.mockPricing,
+		nil,
+		TaxSettings{DefaultMode: domain.TaxModeExclusive},
+		30*time.Minute,
+		logger,
 	)
 }
 
 func (suite *OrderServiceTestSuite) TearDownTest() {
 	suite.mockOrderRepo.AssertExpectations(suite.T())
-	suite.mockOrderItemRepo.AssertExpectations(suite.T())
+	suite.mockItemRepo.AssertExpectations(suite.T())
 	suite.mockAuditRepo.AssertExpectations(suite.T())
 	suite.mockEventRepo.AssertExpectations(suite.T())
-	suite.mockEventPublisher.AssertExpectations(suite.T())
-}
-
-// Test Order Creation Flow
-func (suite *OrderServiceTestSuite) TestCreateOrder_Success() {
-	// Arrange
-	ctx := context.Background()
-	customerID := uuid.New()
-	createOrderRequest := &dto.CreateOrderRequest{
-		CustomerID:      customerID,
+	suite.mockPromoRepo.AssertExpectations(suite.T())
+	suite.mockReservations.AssertExpectations(suite.T())
+	suite.mockPricing.AssertExpectations(suite.T())
+}
+
+// priceAt makes the product service price every item at the given unit price
+func (suite *OrderServiceTestSuite) priceAt(unitPrice float64) {
+	suite.mockPricing.On("PriceCart", mock.Anything, mock.AnythingOfType("*client.CartPricingRequest")).
+		Return(func(req *client.CartPricingRequest) *client.PricedCart {
+			cart := &client.PricedCart{}
+			for _, item := range req.Items {
+				cart.Lines = append(cart.Lines, client.PricedCartLine{
+					ProductID: item.ProductID,
+					Quantity:  item.Quantity,
+					BasePrice: unitPrice,
+					UnitPrice: unitPrice,
+					LineTotal: unitPrice * float64(item.Quantity),
+				})
+			}
+			return cart
+		}, nil)
+}
+
+// existingOrder returns an order at the given status with one item
+func (suite *OrderServiceTestSuite) existingOrder(status domain.OrderStatus) (*domain.Order, *domain.OrderItem) {
+	order := domain.NewOrder(uuid.New(), "123 Main St", "123 Main St", "")
+	order.Status = status
+	order.AddItem(uuid.New(), 2, 50)
+	item := order.Items[0]
+
+	suite.mockOrderRepo.On("GetByID", mock.Anything, order.ID).Return(order, nil)
+	suite.mockItemRepo.On("GetByOrderID", mock.Anything, order.ID).Return([]*domain.OrderItem{&item}, nil)
+	return order, &item
+}
+
+func (suite *OrderServiceTestSuite) createOrderRequest() *dto.CreateOrderRequest {
+	return &dto.CreateOrderRequest{
+		CustomerID:      uuid.New(),
 		ShippingAddress: "123 Main St",
 		BillingAddress:  "123 Main St",
 		Notes:           "Test order",
@@ -253,216 +415,271 @@ func (suite *OrderServiceTestSuite) TestCreateOrder_Success() {
 			{
 				ProductID: uuid.New(),
 				Quantity:  2,
-				UnitPrice: 10.99,
+				UnitPrice: 1.00, // ignored, the product service prices the item
 			},
 		},
 	}
-	// Setup mocks
-	suite.mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
-	suite.mockOrderItemRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderItem")).Return(nil)
-	suite.mockAuditRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
-	suite.mockEventRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderEventOutbox")).Return(nil)
-	suite.mockEventPublisher.On("PublishEvent", ctx, mock.AnythingOfType("*domain.OrderEvent")).Return(nil)
+}
+
+// Test Order Creation Flow
+func (suite *OrderServiceTestSuite) TestCreateOrder_Success() {
+	// Arrange
+	ctx := context.Background()
+	req := suite.createOrderRequest()
+
+	suite.mockCustomers.On("GetCustomerTier", mock.Anything, req.CustomerID).Return(3, nil)
+	suite.priceAt(10.99)
+	suite.mockReservations.On("ReserveStock", mock.Anything, mock.AnythingOfType("uuid.UUID"), mock.AnythingOfType("string"), "gold",
+		[]client.StockReservationItem{{ProductID: req.Items[0].ProductID, Quantity: 2}}, 30*time.Minute).Return(nil)
+	suite.mockOrderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+	suite.mockItemRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderItem")).Return(nil)
+	suite.mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
+	suite.mockEventRepo.On("Create", mock.Anything, mock.MatchedBy(func(event *domain.OrderEventOutbox) bool {
+		return event.EventType == domain.EventOrderCreated
+	})).Return(nil)
 
 	// Act
-	result, err := suite.orderService.CreateOrder(ctx, createOrderRequest)
+	result, err := suite.orderService.CreateOrder(ctx, req)
 
 	// Assert
-	assert.NoError(suite.T(), err)
-	assert.NotNil(suite.T(), result)
-	assert.Equal(suite.T(), customerID, result.CustomerID)
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), req.CustomerID, result.CustomerID)
 	assert.Equal(suite.T(), domain.OrderStatusPending, result.Status)
-	assert.Equal(suite.T(), float64(21.98), result.TotalAmount)
+	assert.Equal(suite.T(), 21.98, result.TotalAmount)
+
+	key := suite.mockReservations.Calls[0].Arguments.String(2)
+	assert.Equal(suite.T(), reservationKey(result.ID), key)
 }
 
 func (suite *OrderServiceTestSuite) TestCreateOrder_EmptyItems() {
 	// Arrange
 	ctx := context.Background()
-	createOrderRequest := &dto.CreateOrderRequest{
-		CustomerID:      uuid.New(),
-		ShippingAddress: "123 Main St",
-		BillingAddress:  "123 Main St",
-		Notes:           "Test order",
-		Items:           []dto.CreateOrderItemRequest{}, // Empty items
-	}
+	req := suite.createOrderRequest()
+	req.Items = []dto.CreateOrderItemRequest{}
 
 	// Act
-	result, err := suite.orderService.CreateOrder(ctx, createOrderRequest)
+	result, err := suite.orderService.CreateOrder(ctx, req)
 
 	// Assert
-	assert.Error(suite.T(), err)
+	assert.ErrorIs(suite.T(), err, domain.ErrInvalidOrderData)
 	assert.Nil(suite.T(), result)
-	assert.Contains(suite.T(), err.Error(), "order must have at least one item")
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrder_InsufficientStockSavesNothing() {
+	// Arrange
+	ctx := context.Background()
+	req := suite.createOrderRequest()
+
+	suite.mockCustomers.On("GetCustomerTier", mock.Anything, req.CustomerID).Return(1, nil)
+	suite.priceAt(10)
+	suite.mockReservations.On("ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return(client.ErrInsufficientStock)
+
+	// Act
+	result, err := suite.orderService.CreateOrder(ctx, req)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrInsufficientStock)
+	assert.Nil(suite.T(), result)
+}
+
+func (suite *OrderServiceTestSuite) TestCreateOrder_ReleasesStockWhenSavingFails() {
+	// Arrange
+	ctx := context.Background()
+	req := suite.createOrderRequest()
+	saveErr := errors.New("connection reset")
+
+	suite.mockCustomers.On("GetCustomerTier", mock.Anything, req.CustomerID).Return(1, nil)
+	suite.priceAt(10)
+	suite.mockReservations.On("ReserveStock", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
+	suite.mockOrderRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.Order")).Return(nil)
+	suite.mockItemRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderItem")).Return(saveErr)
+	suite.mockReservations.On("ReleaseStock", mock.Anything, mock.AnythingOfType("uuid.UUID"), "order creation failed").Return(nil)
+
+	// Act
+	result, err := suite.orderService.CreateOrder(ctx, req)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, saveErr)
+	assert.Nil(suite.T(), result)
+
+	reserved := suite.mockReservations.Calls[0].Arguments.Get(1).(uuid.UUID)
+	released := suite.mockReservations.Calls[1].Arguments.Get(1).(uuid.UUID)
+	assert.Equal(suite.T(), reserved, released, "the stock of the order that was not saved is released")
 }
 
 // Test Status Transitions
-func (suite *OrderServiceTestSuite) TestUpdateOrderStatus_ValidTransition() {
+func (suite *OrderServiceTestSuite) TestUpdateOrderStatus_ConfirmConsumesStock() {
 	// Arrange
 	ctx := context.Background()
-	orderID := uuid.New()
-	existingOrder := &domain.Order{
-		ID:          orderID,
-		CustomerID:  uuid.New(),
-		Status:      domain.OrderStatusPending,
-		TotalAmount: 100.0,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+	order, _ := suite.existingOrder(domain.OrderStatusPending)
 
-	// Setup mocks
-	suite.mockOrderRepo.On("GetByID", ctx, orderID).Return(existingOrder, nil)
-	suite.mockOrderRepo.On("Update", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
-	suite.mockAuditRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
-	suite.mockEventRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderEventOutbox")).Return(nil)
-	suite.mockEventPublisher.On("PublishEvent", ctx, mock.AnythingOfType("*domain.OrderEvent")).Return(nil)
+	suite.mockReservations.On("ConsumeStock", mock.Anything, order.ID).Return(nil)
+	suite.mockOrderRepo.On("Update", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+		return o.Status == domain.OrderStatusConfirmed && o.ConfirmedAt != nil
+	})).Return(nil)
+	suite.mockEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderEventOutbox")).Return(nil)
+	suite.mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
 
 	// Act
-	_, err := suite.orderService.UpdateOrderStatus(ctx, orderID, &dto.UpdateOrderStatusRequest{
-		Status: domain.OrderStatusConfirmed,
-	})
+	err := suite.orderService.UpdateOrderStatus(ctx, order.ID, domain.OrderStatusConfirmed)
 
 	// Assert
 	assert.NoError(suite.T(), err)
 }
 
-func (suite *OrderServiceTestSuite) TestUpdateOrderStatus_InvalidTransition() {
+func (suite *OrderServiceTestSuite) TestUpdateOrderStatus_ConfirmAfterReservationExpired() {
 	// Arrange
 	ctx := context.Background()
-	orderID := uuid.New()
-	existingOrder := &domain.Order{
-		ID:          orderID,
-		CustomerID:  uuid.New(),
-		Status:      domain.OrderStatusDelivered, // Already delivered
-		TotalAmount: 100.0,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+	order, _ := suite.existingOrder(domain.OrderStatusPending)
 
-	// Setup mocks
-	suite.mockOrderRepo.On("GetByID", ctx, orderID).Return(existingOrder, nil)
+	// The product service released the reservation when it expired
+	suite.mockReservations.On("ConsumeStock", mock.Anything, order.ID).Return(client.ErrReservationNotActive)
 
 	// Act
-	_, err := suite.orderService.UpdateOrderStatus(ctx, orderID, &dto.UpdateOrderStatusRequest{
-		Status: domain.OrderStatusPending,
-	})
+	err := suite.orderService.UpdateOrderStatus(ctx, order.ID, domain.OrderStatusConfirmed)
 
 	// Assert
-	assert.Error(suite.T(), err)
-	assert.Contains(suite.T(), err.Error(), "invalid status transition")
+	assert.ErrorIs(suite.T(), err, domain.ErrStockReservationExpired)
+	suite.mockOrderRepo.AssertNotCalled(suite.T(), "Update", mock.Anything, mock.Anything)
 }
 
-// Test Stock Override
-func (suite *OrderServiceTestSuite) TestCreateOrderWithStockOverride_Success() {
+func (suite *OrderServiceTestSuite) TestUpdateOrderStatus_CancelRestocksAndReleases() {
 	// Arrange
 	ctx := context.Background()
-	customerID := uuid.New()
-	createOrderRequest := &dto.CreateOrderRequest{
-		CustomerID:      customerID,
-		ShippingAddress: "123 Main St",
-		BillingAddress:  "123 Main St",
-		Notes:           "Test order with stock override",
-		Items: []dto.CreateOrderItemRequest{
-			{
-				ProductID: uuid.New(),
-				Quantity:  10,
-				UnitPrice: 15.99,
-			},
-		},
-	}
+	order, item := suite.existingOrder(domain.OrderStatusConfirmed)
 
-	// Setup mocks
-	suite.mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
-	suite.mockOrderItemRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderItem")).Return(nil)
-	suite.mockAuditRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
-	suite.mockEventRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderEventOutbox")).Return(nil)
-	suite.mockEventPublisher.On("PublishEvent", ctx, mock.AnythingOfType("*domain.OrderEvent")).Return(nil)
+	suite.mockReservations.On("RestockStock", mock.Anything, order.ID, cancelRestockKey(order.ID), "order cancelled",
+		[]client.StockReservationItem{{ProductID: item.ProductID, Quantity: 2}}).Return(nil)
+	suite.mockOrderRepo.On("Update", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+		return o.Status == domain.OrderStatusCancelled
+	})).Return(nil)
+	suite.mockPromoRepo.On("ReleaseByOrderID", mock.Anything, order.ID).Return(nil)
+	suite.mockEventRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderEventOutbox")).Return(nil)
+	suite.mockReservations.On("ReleaseStock", mock.Anything, order.ID, "order cancelled").Return(nil)
+	suite.mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
 
 	// Act
-	result, err := suite.orderService.CreateOrder(ctx, createOrderRequest)
+	err := suite.orderService.UpdateOrderStatus(ctx, order.ID, domain.OrderStatusCancelled)
 
 	// Assert
 	assert.NoError(suite.T(), err)
-	assert.NotNil(suite.T(), result)
-	assert.Equal(suite.T(), float64(159.9), result.TotalAmount)
 }
 
-// Test Event Publishing
-func (suite *OrderServiceTestSuite) TestCreateOrder_EventPublishing() {
+func (suite *OrderServiceTestSuite) TestCancelOrder_ReleasesPendingStockAndPromoCodes() {
 	// Arrange
 	ctx := context.Background()
-	customerID := uuid.New()
-	createOrderRequest := &dto.CreateOrderRequest{
-		CustomerID:      customerID,
-		ShippingAddress: "123 Main St",
-		BillingAddress:  "123 Main St",
-		Notes:           "Test order",
-		Items: []dto.CreateOrderItemRequest{
-			{
-				ProductID: uuid.New(),
-				Quantity:  1,
-				UnitPrice: 19.99,
-			},
-		},
-	}
+	order, _ := suite.existingOrder(domain.OrderStatusPending)
 
-	// Setup mocks
-	suite.mockOrderRepo.On("Create", ctx, mock.AnythingOfType("*domain.Order")).Return(nil)
-	suite.mockOrderItemRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderItem")).Return(nil)
-	suite.mockAuditRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
-	suite.mockEventRepo.On("Create", ctx, mock.AnythingOfType("*domain.OrderEventOutbox")).Return(nil)
-	
-	// Verify event publishing is called with correct event type
-	suite.mockEventPublisher.On("PublishEvent", ctx, mock.MatchedBy(func(event *domain.OrderEvent) bool {
-		return event.EventType == "order.created"
+	suite.mockOrderRepo.On("Update", mock.Anything, mock.MatchedBy(func(o *domain.Order) bool {
+		return o.Status == domain.OrderStatusCancelled && *o.CancelledReason == "changed my mind"
+	})).Return(nil)
+	suite.mockPromoRepo.On("ReleaseByOrderID", mock.Anything, order.ID).Return(nil)
+	suite.mockEventRepo.On("Create", mock.Anything, mock.MatchedBy(func(event *domain.OrderEventOutbox) bool {
+		return event.EventType == domain.EventOrderCancelled
 	})).Return(nil)
+	suite.mockReservations.On("ReleaseStock", mock.Anything, order.ID, "changed my mind").Return(nil)
+	suite.mockAuditRepo.On("Create", mock.Anything, mock.AnythingOfType("*domain.OrderAuditLog")).Return(nil)
 
 	// Act
-	result, err := suite.orderService.CreateOrder(ctx, createOrderRequest)
+	err := suite.orderService.CancelOrder(ctx, order.ID, "changed my mind", false)
 
-	// Assert
+	// Assert: a pending order consumed nothing, so nothing is restocked
 	assert.NoError(suite.T(), err)
-	assert.NotNil(suite.T(), result)
 }
 
-func (suite *OrderServiceTestSuite) TestGetOrderByID_Success() {
+func (suite *OrderServiceTestSuite) TestCancelOrder_ConfirmedNeedsPermission() {
 	// Arrange
 	ctx := context.Background()
-	orderID := uuid.New()
-	expectedOrder := &domain.Order{
-		ID:          orderID,
-		CustomerID:  uuid.New(),
-		Status:      domain.OrderStatusPending,
-		TotalAmount: 50.0,
-		CreatedAt:   time.Now(),
-		UpdatedAt:   time.Now(),
-	}
+	order, _ := suite.existingOrder(domain.OrderStatusConfirmed)
 
-	// Setup mocks
-	suite.mockOrderRepo.On("GetByID", ctx, orderID).Return(expectedOrder, nil)
+	// Act
+	err := suite.orderService.CancelOrder(ctx, order.ID, "changed my mind", false)
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrCancelConfirmedNotPermitted)
+}
+
+// Test Order Edits
+func (suite *OrderServiceTestSuite) TestEditOrder_RevertsOnlyItsOwnReservation() {
+	// Arrange
+	ctx := context.Background()
+	order, item := suite.existingOrder(domain.OrderStatusPending)
+	saveErr := errors.New("connection reset")
+
+	suite.mockCustomers.On("GetCustomerTier", mock.Anything, order.CustomerID).Return(1, nil)
+	suite.mockOrderRepo.On("LockRevision", mock.Anything, order.ID).Return(1, nil)
+	suite.mockReservations.On("ReleaseStock", mock.Anything, order.ID, "order edited").Return(nil)
+	suite.mockReservations.On("ReserveStock", mock.Anything, order.ID, mock.AnythingOfType("string"), "bronze",
+		[]client.StockReservationItem{{ProductID: item.ProductID, Quantity: 3}}, 30*time.Minute).Return(nil).Once()
+	suite.mockOrderRepo.On("UpdateRevision", mock.Anything, mock.AnythingOfType("*domain.Order"), 1).Return(saveErr)
+	suite.mockReservations.On("ReleaseReservation", mock.Anything, order.ID, mock.AnythingOfType("string"), "order edit failed").Return(nil)
+	suite.mockReservations.On("ReserveStock", mock.Anything, order.ID, mock.AnythingOfType("string"), "bronze",
+		[]client.StockReservationItem{{ProductID: item.ProductID, Quantity: 2}}, 30*time.Minute).Return(nil).Once()
 
 	// Act
-	result, err := suite.orderService.GetOrderByID(ctx, orderID)
+	result, err := suite.orderService.EditOrder(ctx, order.ID, &dto.EditOrderRequest{
+		UpdateItems: []dto.EditOrderItemRequest{{ItemID: item.ID, Quantity: 3}},
+	})
 
 	// Assert
-	assert.NoError(suite.T(), err)
-	assert.NotNil(suite.T(), result)
-	assert.Equal(suite.T(), orderID, result.ID)
-	assert.Equal(suite.T(), expectedOrder.Status, result.Status)
+	assert.ErrorIs(suite.T(), err, saveErr)
+	assert.Nil(suite.T(), result)
+
+	editKey := suite.mockReservations.Calls[1].Arguments.String(2)
+	releasedKey := suite.mockReservations.Calls[2].Arguments.String(2)
+	restoreKey := suite.mockReservations.Calls[3].Arguments.String(2)
+	assert.Contains(suite.T(), editKey, ":edit:")
+	assert.Equal(suite.T(), editKey, releasedKey, "only the edit's own reservation is released")
+	assert.Equal(suite.T(), editKey+":restore", restoreKey)
+}
+
+func (suite *OrderServiceTestSuite) TestEditOrder_ConcurrentEditLeavesStockAlone() {
+	// Arrange
+	ctx := context.Background()
+	order, item := suite.existingOrder(domain.OrderStatusPending)
+
+	// Another edit was saved between reading the order and locking it
+	suite.mockOrderRepo.On("LockRevision", mock.Anything, order.ID).Return(2, nil)
+
+	// Act
+	result, err := suite.orderService.EditOrder(ctx, order.ID, &dto.EditOrderRequest{
+		UpdateItems: []dto.EditOrderItemRequest{{ItemID: item.ID, Quantity: 3}},
+	})
+
+	// Assert
+	assert.ErrorIs(suite.T(), err, domain.ErrOrderRevisionConflict)
+	assert.Nil(suite.T(), result)
+	suite.mockReservations.AssertNotCalled(suite.T(), "ReleaseStock", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (suite *OrderServiceTestSuite) TestGetOrder_Success() {
+	// Arrange
+	ctx := context.Background()
+	order, item := suite.existingOrder(domain.OrderStatusPending)
+
+	// Act
+	result, err := suite.orderService.GetOrder(ctx, order.ID)
+
+	// Assert
+	suite.Require().NoError(err)
+	assert.Equal(suite.T(), order.ID, result.ID)
+	assert.Equal(suite.T(), order.Status, result.Status)
+	assert.Len(suite.T(), result.Items, 1)
+	assert.Equal(suite.T(), item.ProductID, result.Items[0].ProductID)
 }
 
-func (suite *OrderServiceTestSuite) TestGetOrderByID_NotFound() {
+func (suite *OrderServiceTestSuite) TestGetOrder_NotFound() {
 	// Arrange
 	ctx := context.Background()
 	orderID := uuid.New()
 
-	// Setup mocks
-	suite.mockOrderRepo.On("GetByID", ctx, orderID).Return((*domain.Order)(nil), domain.ErrOrderNotFound)
+	suite.mockOrderRepo.On("GetByID", mock.Anything, orderID).Return((*domain.Order)(nil), domain.ErrOrderNotFound)
 
 	// Act
-	result, err := suite.orderService.GetOrderByID(ctx, orderID)
+	result, err := suite.orderService.GetOrder(ctx, orderID)
 
 	// Assert
-	assert.Error(suite.T(), err)
 	assert.Nil(suite.T(), result)
 	assert.Equal(suite.T(), domain.ErrOrderNotFound, err)
 }
@@ -0,0 +1,201 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+	"order/internal/infrastructure/client"
+)
+
+// vipLevels are the VIP levels the product service prices each customer tier at
+var vipLevels = map[domain.CustomerTier]string{
+	domain.TierBronze:   "bronze",
+	domain.TierSilver:   "silver",
+	domain.TierGold:     "gold",
+	domain.TierPlatinum: "platinum",
+	domain.TierDiamond:  "diamond",
+}
+
+// customerVIP looks up the VIP level of an order's customer the first time pricing or a stock
+// reservation needs it, so both share one lookup. Customers who are not VIP members have an
+// empty level.
+type customerVIP struct {
+	lookup     client.PromotionLookupClient
+	customerID uuid.UUID
+	level      *string
+}
+
+func (s *Service) newCustomerVIP(customerID uuid.UUID) *customerVIP {
+	return &customerVIP{lookup: s.promoLookup, customerID: customerID}
+}
+
+func (v *customerVIP) get(ctx context.Context) (string, error) {
+	if v.level == nil {
+		tier, err := v.lookup.GetCustomerTier(ctx, v.customerID)
+		if err != nil {
+			return "", err
+		}
+		level := vipLevels[domain.CustomerTier(tier)]
+		v.level = &level
+	}
+	return *v.level, nil
+}
+
+// itemPrice is the unit price of an order item and how it was set
+type itemPrice struct {
+	unitPrice        float64
+	belowCost        bool
+	overridden       bool
+	overrideReason   *string
+	marginApprovedBy *string
+}
+
+// priceItems prices order items with the product service, which applies the quantity tier,
+// customer group and VIP pricing the customer is entitled to. Products only sold to higher VIP
+// levels than the customer's are refused with ErrVIPAccessRequired. Prices sent by clients are
+// never used. The prices are returned in item order.
+func (s *Service) priceItems(ctx context.Context, vip *customerVIP, customerGroup string, items []domain.OrderItem) ([]itemPrice, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+
+	customerID := vip.customerID
+	vipLevel, err := vip.get(ctx)
+	if err != nil {
+		s.logger.WithError(err).WithField("customer_id", customerID).Error("Failed to look up customer tier for pricing")
+		return nil, fmt.Errorf("%w: %v", domain.ErrPricingFailed, err)
+	}
+
+	req := &client.CartPricingRequest{
+		CustomerID:    customerID,
+		CustomerGroup: customerGroup,
+		VIPLevel:      vipLevel,
+		Items:         make([]client.CartPricingItem, len(items)),
+	}
+	for i, item := range items {
+		req.Items[i] = client.CartPricingItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+
+	cart, err := s.pricing.PriceCart(ctx, req)
+	if errors.Is(err, client.ErrProductUnavailable) {
+		return nil, fmt.Errorf("%w: %v", domain.ErrProductUnavailable, err)
+	}
+	if errors.Is(err, client.ErrVIPAccessRequired) {
+		return nil, fmt.Errorf("%w: %v", domain.ErrVIPAccessRequired, err)
+	}
+	if err != nil {
+		s.logger.WithError(err).WithField("customer_id", customerID).Error("Failed to price order items")
+		return nil, fmt.Errorf("%w: %v", domain.ErrPricingFailed, err)
+	}
+	if len(cart.Lines) != len(items) {
+		return nil, fmt.Errorf("%w: %d lines priced for %d items", domain.ErrPricingFailed, len(cart.Lines), len(items))
+	}
+
+	prices := make([]itemPrice, len(items))
+	for i, line := range cart.Lines {
+		if line.ProductID != items[i].ProductID {
+			return nil, fmt.Errorf("%w: line %d priced product %s instead of %s", domain.ErrPricingFailed, i+1, line.ProductID, items[i].ProductID)
+		}
+		prices[i] = itemPrice{unitPrice: line.UnitPrice, belowCost: line.BelowCost}
+
+		if len(line.AppliedRules) > 0 {
+			s.logger.WithFields(logrus.Fields{
+				"customer_id": customerID,
+				"product_id":  line.ProductID,
+				"base_price":  line.BasePrice,
+				"unit_price":  line.UnitPrice,
+				"rules":       line.AppliedRules,
+			}).Debug("Pricing rules applied")
+		}
+	}
+	return prices, nil
+}
+
+// applyPriceOverrides sets the unit prices staff chose instead of the priced ones, which are
+// checked against the cost and minimum margin of their products by the product service. Every
+// item then sold below cost or the minimum margin needs a manager's approval; without one the
+// items are refused with ErrMarginApprovalRequired. The requests are in item order.
+func (s *Service) applyPriceOverrides(ctx context.Context, reqs []dto.CreateOrderItemRequest, prices []itemPrice, approvedBy *string) error {
+	var lines []client.MarginCheckLine
+	var overridden []int
+	for i, req := range reqs {
+		if req.PriceOverride == nil {
+			continue
+		}
+		reason := strings.TrimSpace(req.PriceOverrideReason)
+		if reason == "" {
+			return domain.ErrPriceOverrideReasonRequired
+		}
+		prices[i] = itemPrice{unitPrice: *req.PriceOverride, overridden: true, overrideReason: &reason}
+		lines = append(lines, client.MarginCheckLine{ProductID: req.ProductID, UnitPrice: *req.PriceOverride})
+		overridden = append(overridden, i)
+	}
+
+	needsApproval := make([]bool, len(prices))
+	for i, price := range prices {
+		needsApproval[i] = price.belowCost
+	}
+	if len(lines) > 0 {
+		checks, err := s.pricing.CheckMargins(ctx, lines)
+		if errors.Is(err, client.ErrProductUnavailable) {
+			return fmt.Errorf("%w: %v", domain.ErrProductUnavailable, err)
+		}
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to check margins of price overrides")
+			return fmt.Errorf("%w: %v", domain.ErrPricingFailed, err)
+		}
+		if len(checks) != len(lines) {
+			return fmt.Errorf("%w: %d margin checks for %d price overrides", domain.ErrPricingFailed, len(checks), len(lines))
+		}
+		for j, i := range overridden {
+			needsApproval[i] = checks[j].NeedsApproval()
+		}
+	}
+
+	var refused []string
+	for i := range prices {
+		if !needsApproval[i] {
+			continue
+		}
+		if approvedBy == nil {
+			refused = append(refused, reqs[i].ProductID.String())
+			continue
+		}
+		prices[i].marginApprovedBy = approvedBy
+		s.logger.WithFields(logrus.Fields{
+			"product_id":  reqs[i].ProductID,
+			"unit_price":  prices[i].unitPrice,
+			"overridden":  prices[i].overridden,
+			"approved_by": *approvedBy,
+		}).Warn("Item sold below cost or minimum margin with approval")
+	}
+	if len(refused) > 0 {
+		return fmt.Errorf("%w: products %s", domain.ErrMarginApprovalRequired, strings.Join(refused, ", "))
+	}
+	return nil
+}
+
+// unitPrices lists the unit prices of priced items
+func unitPrices(prices []itemPrice) []float64 {
+	unit := make([]float64, len(prices))
+	for i, price := range prices {
+		unit[i] = price.unitPrice
+	}
+	return unit
+}
+
+// markPriceOverrides records on the items which prices were overridden and who approved a price
+// below cost or the minimum margin
+func markPriceOverrides(items []domain.OrderItem, prices []itemPrice) {
+	for i, price := range prices {
+		items[i].PriceOverridden = price.overridden
+		items[i].PriceOverrideReason = price.overrideReason
+		items[i].MarginApprovedBy = price.marginApprovedBy
+	}
+}
@@ -0,0 +1,202 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"order/internal/application/dto"
+	"order/internal/domain"
+	"order/internal/infrastructure/client"
+)
+
+// CreatePromotion creates a promo code
+func (s *Service) CreatePromotion(ctx context.Context, req *dto.CreatePromotionRequest) (*domain.Promotion, error) {
+	promotion := domain.NewPromotion(req.Code, req.RewardType, req.Value)
+	promotion.Description = req.Description
+	promotion.MaxDiscount = req.MaxDiscount
+	promotion.MinSpend = req.MinSpend
+	promotion.StartsAt = req.StartsAt
+	promotion.EndsAt = req.EndsAt
+	promotion.UsageLimit = req.UsageLimit
+	promotion.PerCustomerLimit = req.PerCustomerLimit
+	promotion.ProductIDs = req.ProductIDs
+	promotion.CategoryIDs = req.CategoryIDs
+	promotion.MinTier = req.MinTier
+	promotion.Stackable = req.Stackable
+
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+
+	err := s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		return s.promotionRepo.Create(ctx, promotion)
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("code", promotion.Code).Error("Failed to create promotion")
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// GetPromotion retrieves a promo code
+func (s *Service) GetPromotion(ctx context.Context, code string) (*domain.Promotion, error) {
+	return s.promotionRepo.GetByCode(ctx, domain.NormalizePromoCode(code))
+}
+
+// ListPromotions retrieves promo codes, newest first
+func (s *Service) ListPromotions(ctx context.Context, activeOnly bool, limit, offset int) ([]*domain.Promotion, error) {
+	promotions, err := s.promotionRepo.List(ctx, activeOnly, limit, offset)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to list promotions")
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// UpdatePromotion changes the description, window, limits or active flag of a promo code
+func (s *Service) UpdatePromotion(ctx context.Context, code string, req *dto.UpdatePromotionRequest) (*domain.Promotion, error) {
+	promotion, err := s.promotionRepo.GetByCode(ctx, domain.NormalizePromoCode(code))
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Description != nil {
+		promotion.Description = *req.Description
+	}
+	if req.StartsAt != nil {
+		promotion.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		promotion.EndsAt = req.EndsAt
+	}
+	if req.UsageLimit != nil {
+		promotion.UsageLimit = req.UsageLimit
+	}
+	if req.PerCustomerLimit != nil {
+		promotion.PerCustomerLimit = req.PerCustomerLimit
+	}
+	if req.IsActive != nil {
+		promotion.IsActive = *req.IsActive
+	}
+	promotion.UpdatedAt = time.Now()
+
+	if err := promotion.Validate(); err != nil {
+		return nil, err
+	}
+	if err := s.promotionRepo.Update(ctx, promotion); err != nil {
+		s.logger.WithError(err).WithField("code", promotion.Code).Error("Failed to update promotion")
+		return nil, err
+	}
+
+	return promotion, nil
+}
+
+// applyPromotions checks the promo codes against the order and applies their discount. The
+// customer tier and product categories are only looked up when a code has rules that need them.
+func (s *Service) applyPromotions(ctx context.Context, order *domain.Order, codes []string, catalog *productCatalog) (*domain.PromotionResult, error) {
+	codes = normalizePromoCodes(codes)
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	promotions, err := s.promotionRepo.GetByCodes(ctx, codes)
+	if err != nil {
+		return nil, err
+	}
+	byCode := make(map[string]*domain.Promotion, len(promotions))
+	for _, promotion := range promotions {
+		byCode[promotion.Code] = promotion
+	}
+
+	// Keep the order the customer entered the codes in
+	ordered := make([]*domain.Promotion, 0, len(codes))
+	needsTier, needsCategories := false, false
+	for _, code := range codes {
+		promotion, ok := byCode[code]
+		if !ok {
+			return nil, &domain.PromotionError{Code: code, Err: domain.ErrPromotionNotFound}
+		}
+		ordered = append(ordered, promotion)
+		needsTier = needsTier || promotion.MinTier > 0
+		needsCategories = needsCategories || len(promotion.CategoryIDs) > 0
+	}
+
+	cart := &domain.PromotionCart{
+		CustomerID:  order.CustomerID,
+		ShippingFee: order.ShippingFee,
+		Now:         time.Now(),
+	}
+
+	if needsTier {
+		tier, err := s.promoLookup.GetCustomerTier(ctx, order.CustomerID)
+		if err != nil {
+			s.logger.WithError(err).WithField("customer_id", order.CustomerID).Error("Failed to look up customer tier")
+			return nil, fmt.Errorf("%w: %v", domain.ErrPromotionLookupFailed, err)
+		}
+		cart.CustomerTier = domain.CustomerTier(tier)
+	}
+
+	var products map[uuid.UUID]*client.CatalogProduct
+	if needsCategories {
+		products, err = catalog.get(ctx)
+		if err != nil {
+			s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to look up product categories")
+			return nil, fmt.Errorf("%w: %v", domain.ErrPromotionLookupFailed, err)
+		}
+	}
+
+	for _, item := range order.Items {
+		line := domain.PromotionLine{ProductID: item.ProductID, Amount: item.TotalPrice}
+		if product, ok := products[item.ProductID]; ok {
+			line.CategoryID = product.CategoryID
+		}
+		cart.Lines = append(cart.Lines, line)
+	}
+
+	result, err := domain.ApplyPromotions(ordered, cart)
+	if err != nil {
+		return nil, err
+	}
+	if err := order.ApplyPromotions(result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// redeemPromotions records the redemptions of the order's promo codes. It runs in the
+// transaction that creates the order, so an order over a usage limit is never saved.
+func (s *Service) redeemPromotions(ctx context.Context, order *domain.Order, result *domain.PromotionResult) error {
+	if result == nil {
+		return nil
+	}
+
+	for _, redemption := range result.Redemptions(order) {
+		err := s.promotionRepo.Redeem(ctx, redemption)
+		if err == domain.ErrPromotionUsageLimitReached || err == domain.ErrPromotionCustomerLimitReached {
+			return &domain.PromotionError{Code: redemption.Code, Err: err}
+		}
+		if err != nil {
+			s.logger.WithError(err).WithField("code", redemption.Code).Error("Failed to redeem promo code")
+			return err
+		}
+	}
+	return nil
+}
+
+// normalizePromoCodes normalizes the codes and drops blanks and repeats
+func normalizePromoCodes(codes []string) []string {
+	var normalized []string
+	seen := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		code = domain.NormalizePromoCode(code)
+		if code == "" || seen[code] {
+			continue
+		}
+		seen[code] = true
+		normalized = append(normalized, code)
+	}
+	return normalized
+}
@@ -0,0 +1,237 @@
+package application
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// recurringRunHistory is how many of the latest occurrences are listed for a recurring order
+const recurringRunHistory = 50
+
+// CreateRecurringOrder creates a recurring order. Its first order is generated at the next
+// occurrence of the schedule.
+func (s *Service) CreateRecurringOrder(ctx context.Context, req *dto.CreateRecurringOrderRequest) (*dto.RecurringOrderResponse, error) {
+	order, err := domain.NewRecurringOrder(req.CustomerID, req.Schedule, req.ShippingAddress, req.BillingAddress, req.Notes, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	order.ChatID = req.ChatID
+	order.ShippingFee = req.ShippingFee
+	order.CustomerGroup = req.CustomerGroup
+	if req.Source != nil {
+		order.Source = *req.Source
+	}
+	for _, item := range req.Items {
+		order.AddItem(item.ProductID, item.Quantity, item.UnitPrice)
+	}
+
+	if err := order.Validate(); err != nil {
+		s.logger.WithError(err).Error("Recurring order validation failed")
+		return nil, err
+	}
+
+	if err := s.recurringRepo.Create(ctx, order); err != nil {
+		s.logger.WithError(err).WithField("customer_id", order.CustomerID).Error("Failed to create recurring order")
+		return nil, err
+	}
+
+	return dto.ToRecurringOrderResponse(order), nil
+}
+
+// GetRecurringOrder retrieves a recurring order
+func (s *Service) GetRecurringOrder(ctx context.Context, id uuid.UUID) (*dto.RecurringOrderResponse, error) {
+	order, err := s.recurringRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return dto.ToRecurringOrderResponse(order), nil
+}
+
+// GetCustomerRecurringOrders retrieves the recurring orders of a customer
+func (s *Service) GetCustomerRecurringOrders(ctx context.Context, customerID uuid.UUID) ([]*dto.RecurringOrderResponse, error) {
+	orders, err := s.recurringRepo.GetByCustomerID(ctx, customerID)
+	if err != nil {
+		s.logger.WithError(err).WithField("customer_id", customerID).Error("Failed to get recurring orders")
+		return nil, err
+	}
+
+	responses := make([]*dto.RecurringOrderResponse, len(orders))
+	for i, order := range orders {
+		responses[i] = dto.ToRecurringOrderResponse(order)
+	}
+	return responses, nil
+}
+
+// GetRecurringOrderRuns retrieves the latest occurrences of a recurring order and what became of them
+func (s *Service) GetRecurringOrderRuns(ctx context.Context, id uuid.UUID) ([]*dto.RecurringOrderRunResponse, error) {
+	if _, err := s.recurringRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	runs, err := s.recurringRepo.GetRuns(ctx, id, recurringRunHistory)
+	if err != nil {
+		s.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to get recurring order runs")
+		return nil, err
+	}
+
+	responses := make([]*dto.RecurringOrderRunResponse, len(runs))
+	for i, run := range runs {
+		responses[i] = dto.ToRecurringOrderRunResponse(run)
+	}
+	return responses, nil
+}
+
+// SkipRecurringOrder skips the next occurrence of a recurring order. The skip is recorded as a
+// run so the occurrence is never ordered afterwards.
+func (s *Service) SkipRecurringOrder(ctx context.Context, id uuid.UUID) (*dto.RecurringOrderResponse, error) {
+	return s.changeRecurringOrder(ctx, id, func(order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, error) {
+		skipped, err := order.Skip(now)
+		if err != nil {
+			return nil, err
+		}
+		return domain.NewRecurringOrderRun(order.ID, skipped, domain.RecurringOrderRunSkipped, now), nil
+	})
+}
+
+// PauseRecurringOrder pauses a recurring order until the given time, or until it is resumed
+func (s *Service) PauseRecurringOrder(ctx context.Context, id uuid.UUID, req *dto.PauseRecurringOrderRequest) (*dto.RecurringOrderResponse, error) {
+	return s.changeRecurringOrder(ctx, id, func(order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, error) {
+		return nil, order.Pause(req.Until, now)
+	})
+}
+
+// ResumeRecurringOrder resumes a paused recurring order from its next occurrence
+func (s *Service) ResumeRecurringOrder(ctx context.Context, id uuid.UUID) (*dto.RecurringOrderResponse, error) {
+	return s.changeRecurringOrder(ctx, id, func(order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, error) {
+		return nil, order.Resume(now)
+	})
+}
+
+// CancelRecurringOrder stops a recurring order for good
+func (s *Service) CancelRecurringOrder(ctx context.Context, id uuid.UUID) (*dto.RecurringOrderResponse, error) {
+	return s.changeRecurringOrder(ctx, id, func(order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, error) {
+		return nil, order.Cancel(now)
+	})
+}
+
+// ModifyNextOccurrence changes the items, shipping address or notes of the next order of a
+// recurring order only. A nil request drops an earlier change.
+func (s *Service) ModifyNextOccurrence(ctx context.Context, id uuid.UUID, req *dto.ModifyNextOccurrenceRequest) (*dto.RecurringOrderResponse, error) {
+	var override *domain.RecurringOrderOverride
+	if req != nil {
+		override = &domain.RecurringOrderOverride{
+			ShippingAddress: req.ShippingAddress,
+			Notes:           req.Notes,
+		}
+		for _, item := range req.Items {
+			override.Items = append(override.Items, domain.RecurringOrderItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				UnitPrice: item.UnitPrice,
+			})
+		}
+	}
+
+	return s.changeRecurringOrder(ctx, id, func(order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, error) {
+		return nil, order.ModifyNext(override, now)
+	})
+}
+
+// changeRecurringOrder applies a change to a recurring order and saves it with the run the change
+// records, if any. A change that races the scheduler returns ErrRecurringOrderConflict.
+func (s *Service) changeRecurringOrder(ctx context.Context, id uuid.UUID, change func(order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, error)) (*dto.RecurringOrderResponse, error) {
+	order, err := s.recurringRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	previousRevision := order.Revision
+	run, err := change(order, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.recurringRepo.Update(ctx, order, previousRevision); err != nil {
+			return err
+		}
+		if run != nil {
+			return s.recurringRepo.CreateRun(ctx, run)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to update recurring order")
+		return nil, err
+	}
+
+	return dto.ToRecurringOrderResponse(order), nil
+}
+
+// generateRecurringOrder creates the order of the due occurrence of a recurring order through
+// CreateOrder. The occurrence is claimed before the order is created, so it is ordered at most
+// once even with several schedulers; a crash in between leaves its run pending.
+func (s *Service) generateRecurringOrder(ctx context.Context, order *domain.RecurringOrder, now time.Time) (*domain.RecurringOrderRun, *dto.OrderResponse, error) {
+	previousRevision := order.Revision
+	occurrence, err := order.TakeDue(now)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	run := domain.NewRecurringOrderRun(order.ID, occurrence.ScheduledFor, domain.RecurringOrderRunPending, now)
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.recurringRepo.Update(ctx, order, previousRevision); err != nil {
+			return err
+		}
+		return s.recurringRepo.CreateRun(ctx, run)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	req := &dto.CreateOrderRequest{
+		CustomerID:      order.CustomerID,
+		Source:          &order.Source,
+		ShippingAddress: occurrence.ShippingAddress,
+		BillingAddress:  occurrence.BillingAddress,
+		ShippingFee:     order.ShippingFee,
+		Notes:           occurrence.Notes,
+		CustomerGroup:   order.CustomerGroup,
+		Items:           make([]dto.CreateOrderItemRequest, len(occurrence.Items)),
+	}
+	for i, item := range occurrence.Items {
+		req.Items[i] = dto.CreateOrderItemRequest{ProductID: item.ProductID, Quantity: item.Quantity, UnitPrice: item.UnitPrice}
+	}
+
+	log := s.logger.WithFields(logrus.Fields{
+		"recurring_order_id": order.ID,
+		"scheduled_for":      occurrence.ScheduledFor,
+	})
+	created, orderErr := s.CreateOrder(ctx, req)
+	if orderErr != nil {
+		log.WithError(orderErr).Warn("Failed to create recurring order occurrence")
+		run.Fail(orderErr, time.Now())
+	} else {
+		log.WithField("order_id", created.ID).Info("Recurring order occurrence created")
+		run.Complete(created.ID, time.Now())
+	}
+
+	if err := s.recurringRepo.UpdateRun(ctx, run); err != nil {
+		log.WithError(err).Error("Failed to record recurring order run")
+	}
+	return run, created, orderErr
+}
+
+// resumeRecurringOrder resumes a recurring order whose pause has ended
+func (s *Service) resumeRecurringOrder(ctx context.Context, order *domain.RecurringOrder, now time.Time) error {
+	previousRevision := order.Revision
+	if err := order.Resume(now); err != nil {
+		return err
+	}
+	return s.recurringRepo.Update(ctx, order, previousRevision)
+}
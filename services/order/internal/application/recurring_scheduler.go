@@ -0,0 +1,262 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/domain"
+	"order/internal/infrastructure/client"
+	"order/internal/infrastructure/config"
+)
+
+// chatLocation is the time zone dates are written in for customers
+var chatLocation = time.FixedZone("ICT", 7*60*60)
+
+// RecurringOrderScheduler generates the orders of recurring orders as they fall due and reminds
+// customers in chat ahead of each one, so they can confirm, skip or change it
+type RecurringOrderScheduler struct {
+	service  *Service
+	notifier client.NotificationClient
+	config   config.RecurringOrderConfig
+	logger   *logrus.Logger
+	now      func() time.Time
+}
+
+// NewRecurringOrderScheduler creates a new recurring order scheduler
+func NewRecurringOrderScheduler(service *Service, notifier client.NotificationClient, cfg config.RecurringOrderConfig, logger *logrus.Logger) *RecurringOrderScheduler {
+	return &RecurringOrderScheduler{
+		service:  service,
+		notifier: notifier,
+		config:   cfg,
+		logger:   logger,
+		now:      time.Now,
+	}
+}
+
+// Run polls for due recurring orders and reminders until the context is cancelled
+func (s *RecurringOrderScheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+
+	s.logger.WithFields(logrus.Fields{
+		"poll_interval": s.config.PollInterval,
+		"reminder_lead": s.config.ReminderLead,
+	}).Info("Recurring order scheduler started")
+
+	for {
+		if _, err := s.ProcessDue(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).Error("Recurring order batch failed")
+		}
+		if _, err := s.SendReminders(ctx); err != nil && ctx.Err() == nil {
+			s.logger.WithError(err).Error("Recurring order reminders failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Recurring order scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessDue resumes recurring orders whose pause has ended and generates the orders that are
+// due. It returns the number of orders created.
+func (s *RecurringOrderScheduler) ProcessDue(ctx context.Context) (int, error) {
+	now := s.now()
+	due, err := s.service.recurringRepo.GetDue(ctx, now, s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	created := 0
+	for _, order := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		log := s.logger.WithField("recurring_order_id", order.ID)
+
+		if order.PauseEnded(now) {
+			if err := s.service.resumeRecurringOrder(ctx, order, now); err != nil {
+				log.WithError(err).Warn("Failed to resume recurring order")
+				continue
+			}
+			log.WithField("next_run_at", order.NextRunAt).Info("Recurring order resumed")
+			continue
+		}
+
+		run, response, err := s.service.generateRecurringOrder(ctx, order, now)
+		if run == nil {
+			// The customer changed the recurring order or another scheduler claimed the
+			// occurrence; it is picked up again on the next poll if still due
+			if err != nil {
+				log.WithError(err).Debug("Recurring order occurrence not claimed")
+			}
+			continue
+		}
+
+		if err != nil {
+			s.notify(ctx, order, failedOrderMessage(run, err))
+			continue
+		}
+		created++
+		s.notify(ctx, order, createdOrderMessage(response.ID, response.TotalAmount))
+	}
+
+	return created, nil
+}
+
+// SendReminders reminds customers in chat of recurring orders due within the reminder lead. A
+// reminder is claimed before it is sent so it goes out once, and released again if sending
+// fails. It returns the number of reminders sent.
+func (s *RecurringOrderScheduler) SendReminders(ctx context.Context) (int, error) {
+	now := s.now()
+	orders, err := s.service.recurringRepo.GetUnreminded(ctx, now, now.Add(s.config.ReminderLead), s.config.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, order := range orders {
+		if ctx.Err() != nil {
+			break
+		}
+		if !order.ReminderDue(now, s.config.ReminderLead) {
+			continue
+		}
+		log := s.logger.WithFields(logrus.Fields{
+			"recurring_order_id": order.ID,
+			"next_run_at":        order.NextRunAt,
+		})
+
+		previousRevision := order.Revision
+		order.MarkReminded(now)
+		if err := s.service.recurringRepo.Update(ctx, order, previousRevision); err != nil {
+			log.WithError(err).Debug("Recurring order reminder not claimed")
+			continue
+		}
+
+		if err := s.notifier.SendChatMessage(ctx, order.ChatID, s.reminderMessage(ctx, order)); err != nil {
+			log.WithError(err).Warn("Failed to send recurring order reminder")
+			previousRevision = order.Revision
+			order.ClearReminder(s.now())
+			if err := s.service.recurringRepo.Update(ctx, order, previousRevision); err != nil {
+				log.WithError(err).Warn("Failed to release recurring order reminder")
+			}
+			continue
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// notify sends a chat message about a recurring order; failures are only logged
+func (s *RecurringOrderScheduler) notify(ctx context.Context, order *domain.RecurringOrder, message string) {
+	if order.ChatID == "" {
+		return
+	}
+	if err := s.notifier.SendChatMessage(ctx, order.ChatID, message); err != nil {
+		s.logger.WithError(err).WithField("recurring_order_id", order.ID).Warn("Failed to send recurring order message")
+	}
+}
+
+// reminderMessage lists what the next order will contain. Product names are looked up in the
+// catalog; product IDs are shown when the lookup fails. Items are priced the way the order will
+// be, with the customer's current prices; amounts are left out when pricing fails.
+func (s *RecurringOrderScheduler) reminderMessage(ctx context.Context, order *domain.RecurringOrder) string {
+	occurrence := order.Occurrence()
+	log := s.logger.WithField("recurring_order_id", order.ID)
+
+	items := make([]domain.OrderItem, len(occurrence.Items))
+	for i, item := range occurrence.Items {
+		items[i] = domain.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	products, err := s.service.newProductCatalog(items).get(ctx)
+	if err != nil {
+		log.WithError(err).Warn("Failed to look up reminder product names")
+	}
+
+	names := make(map[uuid.UUID]string, len(products))
+	for productID, product := range products {
+		names[productID] = product.Name
+	}
+
+	var unitPrices []float64
+	prices, err := s.service.priceItems(ctx, s.service.newCustomerVIP(order.CustomerID), order.CustomerGroup, items)
+	if err != nil {
+		log.WithError(err).Warn("Failed to price reminder items")
+	} else {
+		unitPrices = make([]float64, len(prices))
+		for i, price := range prices {
+			unitPrices[i] = price.unitPrice
+		}
+	}
+	return reminderMessage(occurrence, names, unitPrices)
+}
+
+// reminderMessage formats a reminder. unitPrices are in item order, or nil to leave amounts out.
+func reminderMessage(occurrence *domain.RecurringOrderOccurrence, names map[uuid.UUID]string, unitPrices []float64) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔔 แจ้งเตือนออร์เดอร์ประจำ\n\n📅 จะสร้างออร์เดอร์ให้อัตโนมัติในวันที่ %s\n\n", formatChatTime(occurrence.ScheduledFor))
+
+	total := 0.0
+	for i, item := range occurrence.Items {
+		name, ok := names[item.ProductID]
+		if !ok {
+			name = item.ProductID.String()
+		}
+		if unitPrices == nil {
+			fmt.Fprintf(&b, "• %s x%d\n", name, item.Quantity)
+			continue
+		}
+		amount := float64(item.Quantity) * unitPrices[i]
+		total += amount
+		fmt.Fprintf(&b, "• %s x%d = ฿%.2f\n", name, item.Quantity, amount)
+	}
+	if unitPrices != nil {
+		fmt.Fprintf(&b, "\n💰 ยอดรวมสินค้าโดยประมาณ: ฿%.2f (ราคา ณ วันที่สร้างออร์เดอร์อาจเปลี่ยนแปลง)\n", total)
+	}
+	fmt.Fprintf(&b, "\n📦 จัดส่งที่: %s\n\n", occurrence.ShippingAddress)
+	b.WriteString("หากต้องการเปลี่ยนรายการ ข้ามรอบนี้ หรือหยุดชั่วคราว กรุณาตอบกลับข้อความนี้ก่อนถึงเวลาสั่งซื้อครับ 🙏")
+	return b.String()
+}
+
+func createdOrderMessage(orderID uuid.UUID, total float64) string {
+	return fmt.Sprintf(`✅ สร้างออร์เดอร์ประจำเรียบร้อยแล้ว!
+
+🆔 หมายเลขออร์เดอร์: %s
+💰 ยอดรวม: ฿%.2f
+
+เราจะแจ้งข้อมูลการจัดส่งให้อีกครั้ง ขอบคุณที่ใช้บริการครับ! 🙏`, orderID.String(), total)
+}
+
+func failedOrderMessage(run *domain.RecurringOrderRun, err error) string {
+	reason := "ระบบขัดข้อง"
+	switch {
+	case errors.Is(err, domain.ErrInsufficientStock):
+		reason = "สินค้าบางรายการมีไม่เพียงพอ"
+	case errors.Is(err, domain.ErrProductUnavailable):
+		reason = "สินค้าบางรายการงดจำหน่ายแล้ว"
+	case errors.Is(err, domain.ErrVIPAccessRequired):
+		reason = "สินค้าบางรายการจำหน่ายเฉพาะสมาชิก VIP ระดับที่สูงกว่า"
+	case errors.Is(err, domain.ErrLaunchAllocationExhausted):
+		reason = "สินค้าบางรายการหมดโควตาสำหรับสมาชิก VIP ระดับของคุณ"
+	case errors.Is(err, domain.ErrMarginApprovalRequired):
+		reason = "ราคาสินค้าบางรายการต่ำกว่าทุน ต้องรอผู้จัดการอนุมัติ"
+	}
+	return fmt.Sprintf(`❌ ไม่สามารถสร้างออร์เดอร์ประจำรอบวันที่ %s ได้
+
+📝 เหตุผล: %s
+
+กรุณาติดต่อทีมงานเพื่อสั่งซื้อรอบนี้ครับ 🙏`, formatChatTime(run.ScheduledFor), reason)
+}
+
+func formatChatTime(t time.Time) string {
+	return t.In(chatLocation).Format("02/01/2006 15:04") + " น."
+}
@@ -0,0 +1,478 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+	"order/internal/infrastructure/client"
+)
+
+// refundCurrency is the currency orders are paid and refunded in
+const refundCurrency = "THB"
+
+// RequestReturn opens a return for delivered items of an order. The returned quantities are held
+// against the order items until the return is rejected.
+func (s *Service) RequestReturn(ctx context.Context, orderID uuid.UUID, req *dto.CreateReturnRequest) (*dto.ReturnResponse, error) {
+	order, err := s.getOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousReturned := make(map[uuid.UUID]int, len(order.Items))
+	for _, item := range order.Items {
+		previousReturned[item.ID] = item.ReturnedQuantity
+	}
+
+	lines := make([]domain.ReturnLine, len(req.Items))
+	for i, item := range req.Items {
+		lines[i] = domain.ReturnLine{ItemID: item.ItemID, Quantity: item.Quantity, Reason: item.Reason}
+	}
+	request, err := order.RequestReturn(lines, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save the return, held quantities and outbox event atomically
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderUpdated, map[string]interface{}{
+		"customer_id":   order.CustomerID.String(),
+		"status":        string(order.Status),
+		"return_id":     request.ID.String(),
+		"return_status": string(request.Status),
+		"refund_amount": request.RefundAmount,
+		"items":         convertReturnItemsToEventData(request.Items),
+	})
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.returnRepo.Create(ctx, request); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to create return request")
+			return err
+		}
+		if err := s.updateReturnedQuantities(ctx, order, previousReturned); err != nil {
+			return err
+		}
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to store return requested event")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.DeleteOrder(ctx, orderID.String()); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate order cache")
+	}
+
+	s.auditReturn(ctx, request, domain.AuditActionReturn, map[string]interface{}{
+		"refund_amount": request.RefundAmount,
+		"items":         convertReturnItemsToEventData(request.Items),
+	})
+
+	return returnToResponse(request, ""), nil
+}
+
+// ApproveReturn approves a requested return and books the pickup of the items from the
+// customer's shipping address with the shipping service
+func (s *Service) ApproveReturn(ctx context.Context, orderID, returnID uuid.UUID, req *dto.ApproveReturnRequest) (*dto.ReturnResponse, error) {
+	order, err := s.orderRepo.GetByID(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	request, err := s.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != domain.ReturnStatusRequested {
+		return nil, domain.ErrInvalidReturnStatus
+	}
+
+	serviceType := req.ServiceType
+	if serviceType == "" {
+		serviceType = defaultServiceType
+	}
+	instructions := req.Instructions
+	if instructions == "" {
+		instructions = fmt.Sprintf("Return pickup for return %s", request.ID)
+	}
+	delivery, err := s.deliveries.CreateDelivery(ctx, &client.CreateDeliveryRequest{
+		OrderID:      order.ID,
+		Origin:       client.AddressInfo{AddressLine1: order.ShippingAddress},
+		ServiceType:  serviceType,
+		Instructions: instructions,
+	})
+	if err != nil {
+		s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to create pickup for return")
+		return nil, fmt.Errorf("%w: %v", domain.ErrDeliveryNotCreated, err)
+	}
+
+	if err := request.ApproveReturn(delivery.ID, delivery.TrackingNumber); err != nil {
+		return nil, err
+	}
+	if err := s.returnRepo.Update(ctx, request, domain.ReturnStatusRequested); err != nil {
+		// Cancel the pickup so the return can be approved again without leaving a second one behind
+		if cancelErr := s.deliveries.CancelDelivery(ctx, delivery.ID, fmt.Sprintf("Return %s could not be approved", request.ID)); cancelErr != nil {
+			s.logger.WithError(cancelErr).WithFields(logrus.Fields{
+				"return_id":   returnID,
+				"delivery_id": delivery.ID,
+			}).Error("Pickup was created but the return could not be saved, and the pickup could not be cancelled")
+		}
+		return nil, err
+	}
+
+	s.auditReturn(ctx, request, domain.AuditActionReturn, map[string]interface{}{
+		"pickup_delivery_id": delivery.ID.String(),
+	})
+
+	return returnToResponse(request, ""), nil
+}
+
+// RejectReturn rejects a requested return and releases its quantities
+func (s *Service) RejectReturn(ctx context.Context, orderID, returnID uuid.UUID, req *dto.RejectReturnRequest) (*dto.ReturnResponse, error) {
+	order, err := s.getOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	request, err := s.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	previousReturned := make(map[uuid.UUID]int, len(order.Items))
+	for _, item := range order.Items {
+		previousReturned[item.ID] = item.ReturnedQuantity
+	}
+	if err := order.RejectReturn(request, req.Reason); err != nil {
+		return nil, err
+	}
+
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.returnRepo.Update(ctx, request, domain.ReturnStatusRequested); err != nil {
+			s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to reject return")
+			return err
+		}
+		return s.updateReturnedQuantities(ctx, order, previousReturned)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.DeleteOrder(ctx, orderID.String()); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate order cache")
+	}
+
+	s.auditReturn(ctx, request, domain.AuditActionReturn, map[string]interface{}{
+		"rejection_reason": req.Reason,
+	})
+
+	return returnToResponse(request, ""), nil
+}
+
+// ReceiveReturn records that the picked up items reached the warehouse and puts the items
+// chosen for restocking back into stock in the product service
+func (s *Service) ReceiveReturn(ctx context.Context, orderID, returnID uuid.UUID, req *dto.ReceiveReturnRequest) (*dto.ReturnResponse, error) {
+	request, err := s.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+
+	decisions := make(map[uuid.UUID]bool, len(req.Items))
+	for _, item := range req.Items {
+		decisions[item.ReturnItemID] = item.Restock
+	}
+	if err := request.ReceiveReturn(decisions); err != nil {
+		return nil, err
+	}
+
+	// Restocking is idempotent by key, so a retry after a failed save does not add stock twice
+	if restock := request.RestockItems(); len(restock) > 0 {
+		quantities := make(map[uuid.UUID]int)
+		var products []uuid.UUID
+		for _, item := range restock {
+			if _, seen := quantities[item.ProductID]; !seen {
+				products = append(products, item.ProductID)
+			}
+			quantities[item.ProductID] += item.Quantity
+		}
+		items := make([]client.StockReservationItem, len(products))
+		for i, productID := range products {
+			items[i] = client.StockReservationItem{ProductID: productID, Quantity: quantities[productID]}
+		}
+
+		key := fmt.Sprintf("return:%s:restock", request.ID)
+		reason := fmt.Sprintf("return %s", request.ID)
+		if err := s.reservations.RestockStock(ctx, orderID, key, reason, items); err != nil {
+			s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to restock returned items")
+			return nil, fmt.Errorf("%w: %v", domain.ErrRestockFailed, err)
+		}
+	}
+
+	if err := s.returnRepo.Update(ctx, request, domain.ReturnStatusApproved); err != nil {
+		s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to save received return")
+		return nil, err
+	}
+
+	s.auditReturn(ctx, request, domain.AuditActionReturn, map[string]interface{}{
+		"restocked_items": convertReturnItemsToEventData(request.RestockItems()),
+	})
+
+	return returnToResponse(request, ""), nil
+}
+
+// RefundReturn refunds a received return: the payment service records a refunded transaction,
+// finance records the cash outflow and the loyalty points earned on the refunded share are
+// clawed back. Each call is idempotent by return, so a refund that fails half way can simply be
+// retried. Once refunded returns cover the whole order, the order becomes refunded. An invoiced
+// order gets a credit note against its tax invoice or receipt.
+func (s *Service) RefundReturn(ctx context.Context, orderID, returnID uuid.UUID) (*dto.ReturnResponse, error) {
+	order, err := s.getOrderWithItems(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	request, err := s.getReturn(ctx, orderID, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != domain.ReturnStatusReceived {
+		return nil, domain.ErrInvalidReturnStatus
+	}
+
+	var paymentTransactionID *uuid.UUID
+	pointsClawedBack := 0
+	if request.RefundAmount > 0 {
+		reference := fmt.Sprintf("return:%s", request.ID)
+		transaction, err := s.refunds.RefundPayment(ctx, &client.RefundPaymentRequest{
+			OrderID:    order.ID,
+			CustomerID: order.CustomerID,
+			Reference:  reference,
+			Amount:     request.RefundAmount,
+			Currency:   refundCurrency,
+			Reason:     request.Reasons(),
+		})
+		if err != nil {
+			s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to refund payment")
+			if errors.Is(err, client.ErrRefundAmountExceeded) {
+				return nil, domain.ErrRefundExceedsPaid
+			}
+			return nil, fmt.Errorf("%w: %v", domain.ErrRefundFailed, err)
+		}
+		paymentTransactionID = &transaction.ID
+
+		description := fmt.Sprintf("Refund for return %s of order %s", request.ID, order.ID)
+		if err := s.refunds.RecordCashOutflow(ctx, reference, request.RefundAmount, description); err != nil {
+			s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to record refund cash outflow")
+			return nil, fmt.Errorf("%w: %v", domain.ErrRefundFailed, err)
+		}
+
+		pointsClawedBack, err = s.refunds.ClawBackPoints(ctx, order.CustomerID, &client.ClawBackPointsRequest{
+			OrderID:      order.ID,
+			ReturnID:     request.ID,
+			RefundAmount: request.RefundAmount,
+			OrderAmount:  order.TotalAmount,
+		})
+		if err != nil {
+			s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to claw back loyalty points")
+			return nil, fmt.Errorf("%w: %v", domain.ErrRefundFailed, err)
+		}
+	}
+
+	if err := request.MarkRefunded(paymentTransactionID, pointsClawedBack); err != nil {
+		return nil, err
+	}
+
+	returns, err := s.returnRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to get order returns")
+		return nil, err
+	}
+	for i, other := range returns {
+		if other.ID == request.ID {
+			returns[i] = request
+		}
+	}
+	oldStatus, oldPaidStatus := order.Status, order.PaidStatus
+	fullyRefunded := order.ApplyRefunds(returns)
+
+	creditNote, err := s.prepareCreditNote(ctx, order, request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Save the refund, the order status, the credit note and the outbox events atomically
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderRefunded, map[string]interface{}{
+		"customer_id":            order.CustomerID.String(),
+		"old_status":             string(oldStatus),
+		"new_status":             string(order.Status),
+		"paid_status":            string(order.PaidStatus),
+		"return_id":              request.ID.String(),
+		"refund_amount":          request.RefundAmount,
+		"currency":               refundCurrency,
+		"points_clawed_back":     pointsClawedBack,
+		"fully_refunded":         fullyRefunded,
+		"items":                  convertReturnItemsToEventData(request.Items),
+		"payment_transaction_id": uuidString(paymentTransactionID),
+	})
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.returnRepo.Update(ctx, request, domain.ReturnStatusReceived); err != nil {
+			s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to save refunded return")
+			return err
+		}
+		if order.Status != oldStatus || order.PaidStatus != oldPaidStatus {
+			if err := s.orderRepo.Update(ctx, order); err != nil {
+				s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to update order status")
+				return err
+			}
+		}
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to store order refunded event")
+			return err
+		}
+		if creditNote != nil {
+			if err := s.invoiceRepo.Create(ctx, creditNote); err != nil {
+				s.logger.WithError(err).WithField("return_id", returnID).Error("Failed to create credit note")
+				return err
+			}
+			if err := s.eventRepo.Create(ctx, invoiceIssuedEvent(order, creditNote)); err != nil {
+				s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to store invoice issued event")
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.cache.DeleteOrder(ctx, orderID.String()); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate order cache")
+	}
+
+	s.auditReturn(ctx, request, domain.AuditActionRefund, map[string]interface{}{
+		"refund_amount":          request.RefundAmount,
+		"points_clawed_back":     pointsClawedBack,
+		"payment_transaction_id": uuidString(paymentTransactionID),
+		"old_status":             string(oldStatus),
+		"new_status":             string(order.Status),
+	})
+	if creditNote != nil {
+		s.auditInvoice(ctx, creditNote)
+	}
+
+	return returnToResponse(request, order.Status), nil
+}
+
+// GetReturns retrieves the return requests of an order
+func (s *Service) GetReturns(ctx context.Context, orderID uuid.UUID) ([]*dto.ReturnResponse, error) {
+	if _, err := s.orderRepo.GetByID(ctx, orderID); err != nil {
+		return nil, err
+	}
+
+	returns, err := s.returnRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to get returns")
+		return nil, err
+	}
+
+	responses := make([]*dto.ReturnResponse, len(returns))
+	for i, request := range returns {
+		responses[i] = returnToResponse(request, "")
+	}
+	return responses, nil
+}
+
+// getReturn retrieves a return request of the order
+func (s *Service) getReturn(ctx context.Context, orderID, returnID uuid.UUID) (*domain.ReturnRequest, error) {
+	request, err := s.returnRepo.GetByID(ctx, returnID)
+	if err != nil {
+		return nil, err
+	}
+	if request.OrderID != orderID {
+		return nil, domain.ErrReturnNotFound
+	}
+	return request, nil
+}
+
+// updateReturnedQuantities saves the returned quantities of the items that changed
+func (s *Service) updateReturnedQuantities(ctx context.Context, order *domain.Order, previousReturned map[uuid.UUID]int) error {
+	for i := range order.Items {
+		item := &order.Items[i]
+		if item.ReturnedQuantity == previousReturned[item.ID] {
+			continue
+		}
+		if err := s.orderItemRepo.UpdateReturned(ctx, item, previousReturned[item.ID]); err != nil {
+			s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to update returned quantities")
+			return err
+		}
+	}
+	return nil
+}
+
+// auditReturn records a step of a return in the order audit log
+func (s *Service) auditReturn(ctx context.Context, request *domain.ReturnRequest, action domain.AuditAction, details map[string]interface{}) {
+	details["return_id"] = request.ID.String()
+	details["return_status"] = string(request.Status)
+	audit := domain.NewAuditLog(request.OrderID, nil, action, details)
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		s.logger.WithError(err).Warn("Failed to create audit record")
+	}
+}
+
+func uuidString(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
+
+func returnToResponse(request *domain.ReturnRequest, orderStatus domain.OrderStatus) *dto.ReturnResponse {
+	items := make([]dto.ReturnItemResponse, len(request.Items))
+	for i, item := range request.Items {
+		items[i] = dto.ReturnItemResponse{
+			ID:           item.ID,
+			OrderItemID:  item.OrderItemID,
+			ProductID:    item.ProductID,
+			Quantity:     item.Quantity,
+			Reason:       item.Reason,
+			RefundAmount: item.RefundAmount,
+			Restock:      item.Restock,
+		}
+	}
+
+	return &dto.ReturnResponse{
+		ID:                   request.ID,
+		OrderID:              request.OrderID,
+		CustomerID:           request.CustomerID,
+		Status:               request.Status,
+		OrderStatus:          orderStatus,
+		Notes:                request.Notes,
+		RefundAmount:         request.RefundAmount,
+		PickupDeliveryID:     request.PickupDeliveryID,
+		PickupTrackingNumber: request.PickupTrackingNumber,
+		RejectionReason:      request.RejectionReason,
+		PaymentTransactionID: request.PaymentTransactionID,
+		PointsClawedBack:     request.PointsClawedBack,
+		ApprovedAt:           request.ApprovedAt,
+		ReceivedAt:           request.ReceivedAt,
+		RefundedAt:           request.RefundedAt,
+		CreatedAt:            request.CreatedAt,
+		Items:                items,
+	}
+}
+
+func convertReturnItemsToEventData(items []domain.ReturnItem) []map[string]interface{} {
+	eventItems := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		eventItems[i] = map[string]interface{}{
+			"order_item_id": item.OrderItemID.String(),
+			"product_id":    item.ProductID.String(),
+			"quantity":      item.Quantity,
+			"reason":        string(item.Reason),
+			"refund_amount": item.RefundAmount,
+		}
+	}
+	return eventItems
+}
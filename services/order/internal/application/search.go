@@ -0,0 +1,149 @@
+package application
+
+import (
+	"context"
+	"strings"
+
+	"github.com/google/uuid"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// exportBatchSize is the number of orders read per query while exporting
+const exportBatchSize = 500
+
+// SearchOrders retrieves a page of orders matching the search together with the cursor of the
+// next page
+func (s *Service) SearchOrders(ctx context.Context, req *dto.SearchOrdersRequest) (*dto.OrderSearchResponse, error) {
+	search, err := orderSearchFromRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Read one order more than asked for to know whether another page follows
+	page := *search
+	page.Limit++
+	orders, err := s.orderRepo.Search(ctx, &page)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to search orders")
+		return nil, err
+	}
+
+	response := &dto.OrderSearchResponse{Orders: make([]*dto.OrderResponse, 0, len(orders))}
+	if len(orders) > search.Limit {
+		orders = orders[:search.Limit]
+		response.NextCursor = search.CursorAfter(orders[len(orders)-1]).Encode()
+	}
+
+	if err := s.loadItems(ctx, orders); err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		response.Orders = append(response.Orders, s.orderToResponse(order))
+	}
+
+	return response, nil
+}
+
+// ExportOrders walks every order matching the search, without their items, and hands them to
+// fn in batches. The cursor and limit of the request are ignored.
+func (s *Service) ExportOrders(ctx context.Context, req *dto.SearchOrdersRequest, fn func(orders []*dto.OrderResponse) error) error {
+	exportReq := *req
+	exportReq.Cursor = ""
+	exportReq.Limit = 0
+
+	search, err := orderSearchFromRequest(&exportReq)
+	if err != nil {
+		return err
+	}
+	search.Limit = exportBatchSize
+
+	for {
+		orders, err := s.orderRepo.Search(ctx, search)
+		if err != nil {
+			s.logger.WithError(err).Error("Failed to export orders")
+			return err
+		}
+		if len(orders) == 0 {
+			return nil
+		}
+
+		batch := make([]*dto.OrderResponse, len(orders))
+		for i, order := range orders {
+			batch[i] = s.orderToResponse(order)
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+
+		if len(orders) < search.Limit {
+			return nil
+		}
+		search.After = search.CursorAfter(orders[len(orders)-1])
+	}
+}
+
+// loadItems fills in the items of the orders with one query
+func (s *Service) loadItems(ctx context.Context, orders []*domain.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(orders))
+	byID := make(map[uuid.UUID]*domain.Order, len(orders))
+	for i, order := range orders {
+		ids[i] = order.ID
+		byID[order.ID] = order
+		order.Items = []domain.OrderItem{}
+	}
+
+	items, err := s.orderItemRepo.GetByOrderIDs(ctx, ids)
+	if err != nil {
+		s.logger.WithError(err).Error("Failed to get order items")
+		return err
+	}
+	for _, item := range items {
+		if order, ok := byID[item.OrderID]; ok {
+			order.Items = append(order.Items, *item)
+		}
+	}
+
+	return nil
+}
+
+// orderSearchFromRequest converts and validates a search request
+func orderSearchFromRequest(req *dto.SearchOrdersRequest) (*domain.OrderSearch, error) {
+	search := &domain.OrderSearch{
+		Filter: domain.OrderFilter{
+			Statuses:       req.Statuses,
+			Sources:        req.Sources,
+			PaidStatuses:   req.PaidStatuses,
+			PaymentMethods: req.PaymentMethods,
+			CustomerID:     req.CustomerID,
+			CreatedFrom:    req.CreatedFrom,
+			CreatedTo:      req.CreatedTo,
+			Text:           req.Query,
+			MinAmount:      req.MinAmount,
+			MaxAmount:      req.MaxAmount,
+		},
+		Limit: req.Limit,
+	}
+
+	if req.Sort != "" {
+		search.Descending = strings.HasPrefix(req.Sort, "-")
+		search.SortBy = domain.OrderSortField(strings.TrimPrefix(req.Sort, "-"))
+	}
+
+	if req.Cursor != "" {
+		cursor, err := domain.DecodeOrderCursor(req.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		search.After = cursor
+	}
+
+	if err := search.Validate(); err != nil {
+		return nil, err
+	}
+	return search, nil
+}
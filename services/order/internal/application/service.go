@@ -2,13 +2,16 @@ package application
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
 	"order/internal/domain"
 	"order/internal/application/dto"
 	"order/internal/infrastructure/cache"
-	"order/internal/infrastructure/events"
+	"order/internal/infrastructure/client"
+	"order/internal/infrastructure/document"
 	"github.com/sirupsen/logrus"
 )
 
@@ -18,8 +21,22 @@ type Service struct {
 	orderItemRepo  domain.OrderItemRepository
 	auditRepo      domain.OrderAuditRepository
 	eventRepo      domain.OrderEventRepository
-	eventPublisher events.Publisher
+	shipmentRepo   domain.ShipmentRepository
+	returnRepo     domain.ReturnRepository
+	promotionRepo  domain.PromotionRepository
+	invoiceRepo    domain.InvoiceRepository
+	recurringRepo  domain.RecurringOrderRepository
+	txManager      domain.TransactionManager
 	cache          *cache.RedisClient
+	reservations   client.StockReservationClient
+	deliveries     client.DeliveryClient
+	refunds        client.RefundClient
+	promoLookup    client.PromotionLookupClient
+	catalog        client.CatalogClient
+	pricing        client.PricingClient
+	documents      *document.Renderer
+	tax            TaxSettings
+	reservationTTL time.Duration
 	logger         *logrus.Logger
 }
 
@@ -29,8 +46,22 @@ func NewService(
 	orderItemRepo domain.OrderItemRepository,
 	auditRepo domain.OrderAuditRepository,
 	eventRepo domain.OrderEventRepository,
-	eventPublisher events.Publisher,
+	shipmentRepo domain.ShipmentRepository,
+	returnRepo domain.ReturnRepository,
+	promotionRepo domain.PromotionRepository,
+	invoiceRepo domain.InvoiceRepository,
+	recurringRepo domain.RecurringOrderRepository,
+	txManager domain.TransactionManager,
 	cache *cache.RedisClient,
+	reservations client.StockReservationClient,
+	deliveries client.DeliveryClient,
+	refunds client.RefundClient,
+	promoLookup client.PromotionLookupClient,
+	catalog client.CatalogClient,
+	pricing client.PricingClient,
+	documents *document.Renderer,
+	tax TaxSettings,
+	reservationTTL time.Duration,
 	logger *logrus.Logger,
 ) *Service {
 	return &Service{
@@ -38,20 +69,51 @@ func NewService(
 		orderItemRepo:  orderItemRepo,
 		auditRepo:      auditRepo,
 		eventRepo:      eventRepo,
-		eventPublisher: eventPublisher,
+		shipmentRepo:   shipmentRepo,
+		returnRepo:     returnRepo,
+		promotionRepo:  promotionRepo,
+		invoiceRepo:    invoiceRepo,
+		recurringRepo:  recurringRepo,
+		txManager:      txManager,
 		cache:          cache,
+		reservations:   reservations,
+		deliveries:     deliveries,
+		refunds:        refunds,
+		promoLookup:    promoLookup,
+		catalog:        catalog,
+		pricing:        pricing,
+		documents:      documents,
+		tax:            tax,
+		reservationTTL: reservationTTL,
 		logger:         logger,
 	}
 }
 
-// CreateOrder creates a new order with items
+// CreateOrder creates a new order with items. Items are priced by the product service, and VAT
+// and promo codes are worked out before stock is reserved; promo code redemptions are recorded
+// with the order so usage limits hold under concurrent orders. Stock is released again if saving
+// fails.
 func (s *Service) CreateOrder(ctx context.Context, req *dto.CreateOrderRequest) (*dto.OrderResponse, error) {
 	// Create new order
 	order := domain.NewOrder(req.CustomerID, req.ShippingAddress, req.BillingAddress, req.Notes)
+	if req.Source != nil {
+		if !req.Source.IsValid() {
+			return nil, domain.ErrInvalidOrderSource
+		}
+		order.Source = *req.Source
+	}
+	
+	order.CustomerGroup = req.CustomerGroup
 	
-	// Add items to the order
+	// Add items to the order; they are priced by the product service once the order is valid
 	for _, itemReq := range req.Items {
-		order.AddItem(itemReq.ProductID, itemReq.Quantity, itemReq.UnitPrice)
+		order.AddItem(itemReq.ProductID, itemReq.Quantity, 0)
+	}
+	if err := order.SetShippingFee(req.ShippingFee); err != nil {
+		return nil, err
+	}
+	if req.TaxEnabled != nil {
+		order.TaxEnabled = *req.TaxEnabled
 	}
 
 	// Validate the order
@@ -60,48 +122,101 @@ func (s *Service) CreateOrder(ctx context.Context, req *dto.CreateOrderRequest)
 		return nil, err
 	}
 
-	// Save order to database
-	if err := s.orderRepo.Create(ctx, order); err != nil {
-		s.logger.WithError(err).Error("Failed to create order")
+	vip := s.newCustomerVIP(order.CustomerID)
+	prices, err := s.priceItems(ctx, vip, order.CustomerGroup, order.Items)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPriceOverrides(ctx, req.Items, prices, req.MarginApprovedBy); err != nil {
 		return nil, err
 	}
+	if err := order.SetItemPrices(unitPrices(prices)); err != nil {
+		return nil, err
+	}
+	markPriceOverrides(order.Items, prices)
 
-	// Save order items
-	for i := range order.Items {
-		if err := s.orderItemRepo.Create(ctx, &order.Items[i]); err != nil {
-			s.logger.WithError(err).Error("Failed to create order item")
-			return nil, err
-		}
+	catalog := s.newProductCatalog(order.Items)
+	if err := s.applyVAT(ctx, order, catalog, req.TaxMode); err != nil {
+		return nil, err
 	}
 
-	// Cache the order (simplified - just add to customer orders)
-	if err := s.cache.AddCustomerOrder(ctx, order.CustomerID.String(), order.ID.String(), 24*time.Hour); err != nil {
-		s.logger.WithError(err).Warn("Failed to cache customer order")
-		// Don't fail the operation for cache errors
+	promoCodes := req.PromoCodes
+	if req.PromoCode != nil {
+		promoCodes = append([]string{*req.PromoCode}, promoCodes...)
+	}
+	promotions, err := s.applyPromotions(ctx, order, promoCodes, catalog)
+	if err != nil {
+		s.logger.WithError(err).WithField("customer_id", order.CustomerID).Warn("Promo codes rejected")
+		return nil, err
+	}
+
+	if err := s.reserveStock(ctx, order, vip); err != nil {
+		return nil, err
 	}
 
-	// Create and publish order created event
-	orderData := map[string]interface{}{
-		"total_amount":      order.TotalAmount,
+	// Order created event, delivered to the broker by the outbox relay
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderCreated, map[string]interface{}{
+		"customer_id":      order.CustomerID.String(),
+		"total_amount":     order.TotalAmount,
+		"discount":         order.Discount,
+		"shipping_fee":     order.ShippingFee,
+		"tax":              order.Tax,
+		"tax_mode":         string(order.TaxMode),
+		"promo_code":       order.PromoCode,
 		"status":           string(order.Status),
 		"shipping_address": order.ShippingAddress,
 		"billing_address":  order.BillingAddress,
 		"items":            convertItemsToEventData(order.Items),
-	}
+	})
+
+	// Save order, items, promo code redemptions, audit record and outbox event atomically
+	audit := domain.NewAuditLog(order.ID, nil, domain.AuditActionCreate, nil)
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orderRepo.Create(ctx, order); err != nil {
+			s.logger.WithError(err).Error("Failed to create order")
+			return err
+		}
+
+		if err := s.redeemPromotions(ctx, order, promotions); err != nil {
+			return err
+		}
+
+		for i := range order.Items {
+			if err := s.orderItemRepo.Create(ctx, &order.Items[i]); err != nil {
+				s.logger.WithError(err).Error("Failed to create order item")
+				return err
+			}
+		}
 
-	if err := s.eventPublisher.PublishOrderCreated(ctx, order.ID.String(), order.CustomerID.String(), orderData); err != nil {
-		s.logger.WithError(err).Error("Failed to publish order created event")
-		// Don't fail the operation for event publishing errors
+		if err := s.auditRepo.Create(ctx, audit); err != nil {
+			s.logger.WithError(err).Error("Failed to create audit record")
+			return err
+		}
+
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).Error("Failed to store order created event")
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		// Compensate: the order does not exist, so nothing will ever settle its reservation
+		s.releaseStock(ctx, order.ID, "order creation failed")
+		return nil, err
 	}
 
-	// Create audit record
-	audit := domain.NewAuditLog(order.ID, nil, domain.AuditActionCreate, nil)
-	if err := s.auditRepo.Create(ctx, audit); err != nil {
-		s.logger.WithError(err).Warn("Failed to create audit record")
-		// Don't fail the operation for audit errors
+	// Cache the order (simplified - just add to customer orders)
+	if err := s.cache.AddCustomerOrder(ctx, order.CustomerID.String(), order.ID.String(), 24*time.Hour); err != nil {
+		s.logger.WithError(err).Warn("Failed to cache customer order")
+		// Don't fail the operation for cache errors
 	}
 
-	return s.orderToResponse(order), nil
+	response := s.orderToResponse(order)
+	if promotions != nil {
+		response.Promotions = promotions.Applied
+	}
+	return response, nil
 }
 
 // GetOrder retrieves an order by ID
@@ -129,15 +244,30 @@ func (s *Service) GetOrder(ctx context.Context, id uuid.UUID) (*dto.OrderRespons
 	return s.orderToResponse(order), nil
 }
 
-// UpdateOrderStatus updates the status of an order
+// UpdateOrderStatus updates the status of an order. Confirming consumes the stock reserved for
+// the order and cancelling releases it, putting back what a confirmed order already consumed,
+// and gives its promo code uses back.
 func (s *Service) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status domain.OrderStatus) error {
-	order, err := s.orderRepo.GetByID(ctx, id)
+	order, err := s.getOrderWithItems(ctx, id)
 	if err != nil {
-		s.logger.WithError(err).WithField("order_id", id).Error("Failed to get order for status update")
 		return err
 	}
 
 	oldStatus := order.Status
+
+	// Consume before saving: if saving fails the confirmation is retried, and consuming an
+	// already consumed reservation is a no-op
+	if status == domain.OrderStatusConfirmed && oldStatus != domain.OrderStatusConfirmed {
+		if err := s.consumeStock(ctx, order.ID); err != nil {
+			return err
+		}
+	}
+	if status == domain.OrderStatusCancelled && oldStatus != domain.OrderStatusCancelled {
+		if err := s.restockCancelledOrder(ctx, order, "order cancelled"); err != nil {
+			return err
+		}
+	}
+
 	order.Status = status
 	order.UpdatedAt = time.Now()
 
@@ -151,26 +281,43 @@ func (s *Service) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status do
 		order.CancelledAt = &now
 	}
 
-	// Save to database
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		s.logger.WithError(err).WithField("order_id", id).Error("Failed to update order status")
+	// Save status change and outbox event atomically
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderUpdated, map[string]interface{}{
+		"customer_id": order.CustomerID.String(),
+		"old_status":  string(oldStatus),
+		"new_status":  string(status),
+	})
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			s.logger.WithError(err).WithField("order_id", id).Error("Failed to update order status")
+			return err
+		}
+		// A cancelled order gives its promo code uses back, as it does through CancelOrder
+		if status == domain.OrderStatusCancelled && oldStatus != domain.OrderStatusCancelled {
+			if err := s.promotionRepo.ReleaseByOrderID(ctx, order.ID); err != nil {
+				s.logger.WithError(err).WithField("order_id", id).Error("Failed to release promo code redemptions")
+				return err
+			}
+		}
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).WithField("order_id", id).Error("Failed to store order status changed event")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
+	if status == domain.OrderStatusCancelled && oldStatus != domain.OrderStatusCancelled {
+		s.releaseStock(ctx, order.ID, "order cancelled")
+	}
+
 	// Invalidate cache (simplified)
 	if err := s.cache.DeleteOrder(ctx, id.String()); err != nil {
 		s.logger.WithError(err).Warn("Failed to invalidate order cache")
 	}
 
-	// Publish status change event using the correct method signature
-	changes := map[string]interface{}{
-		"old_status": string(oldStatus),
-		"new_status": string(status),
-	}
-	if err := s.eventPublisher.PublishOrderUpdated(ctx, order.ID.String(), order.CustomerID.String(), changes); err != nil {
-		s.logger.WithError(err).Error("Failed to publish order status changed event")
-	}
-
 	// Create audit record
 	auditChanges := map[string]interface{}{
 		"old_status": string(oldStatus),
@@ -184,35 +331,6 @@ func (s *Service) UpdateOrderStatus(ctx context.Context, id uuid.UUID, status do
 	return nil
 }
 
-// ListOrders retrieves orders with pagination
-func (s *Service) ListOrders(ctx context.Context, limit, offset int) ([]*dto.OrderResponse, error) {
-	orders, err := s.orderRepo.List(ctx, limit, offset)
-	if err != nil {
-		s.logger.WithError(err).Error("Failed to list orders")
-		return nil, err
-	}
-
-	responses := make([]*dto.OrderResponse, len(orders))
-	for i, order := range orders {
-		// Get order items for each order
-		items, err := s.orderItemRepo.GetByOrderID(ctx, order.ID)
-		if err != nil {
-			s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to get order items")
-			return nil, err
-		}
-		
-		// Convert items to slice
-		order.Items = make([]domain.OrderItem, len(items))
-		for j, item := range items {
-			order.Items[j] = *item
-		}
-		
-		responses[i] = s.orderToResponse(order)
-	}
-
-	return responses, nil
-}
-
 // GetOrdersByCustomer retrieves all orders for a customer
 func (s *Service) GetOrdersByCustomer(ctx context.Context, customerID uuid.UUID) ([]*dto.OrderResponse, error) {
 	// Get from database (skip cache complexity for now)
@@ -245,11 +363,12 @@ func (s *Service) GetOrdersByCustomer(ctx context.Context, customerID uuid.UUID)
 	return responses, nil
 }
 
-// CancelOrder cancels an order
-func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID, reason string) error {
-	order, err := s.orderRepo.GetByID(ctx, id)
+// CancelOrder cancels an order. Orders past pending are only cancelled when allowConfirmed is
+// set, otherwise ErrCancelConfirmedNotPermitted is returned. Reserved stock is released and the
+// stock a confirmed order already consumed is put back.
+func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID, reason string, allowConfirmed bool) error {
+	order, err := s.getOrderWithItems(ctx, id)
 	if err != nil {
-		s.logger.WithError(err).WithField("order_id", id).Error("Failed to get order for cancellation")
 		return err
 	}
 
@@ -257,9 +376,18 @@ func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID, reason string)
 		return domain.ErrOrderAlreadyCancelled
 	}
 
-	if order.Status == domain.OrderStatusDelivered {
+	// Orders with items already on their way cannot be cancelled
+	switch order.Status {
+	case domain.OrderStatusPartiallyShipped, domain.OrderStatusShipped,
+		domain.OrderStatusPartiallyDelivered, domain.OrderStatusDelivered:
 		return domain.ErrOrderCannotBeCancelled
 	}
+	if order.Status != domain.OrderStatusPending && !allowConfirmed {
+		return domain.ErrCancelConfirmedNotPermitted
+	}
+	if err := s.restockCancelledOrder(ctx, order, reason); err != nil {
+		return err
+	}
 
 	oldStatus := order.Status
 	order.Status = domain.OrderStatusCancelled
@@ -268,22 +396,39 @@ func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID, reason string)
 	order.CancelledAt = &now
 	order.UpdatedAt = now
 
-	// Save to database
-	if err := s.orderRepo.Update(ctx, order); err != nil {
-		s.logger.WithError(err).WithField("order_id", id).Error("Failed to cancel order")
+	// Save cancellation and outbox event atomically
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderCancelled, map[string]interface{}{
+		"customer_id": order.CustomerID.String(),
+		"old_status":  string(oldStatus),
+		"reason":      reason,
+	})
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.orderRepo.Update(ctx, order); err != nil {
+			s.logger.WithError(err).WithField("order_id", id).Error("Failed to cancel order")
+			return err
+		}
+		// A cancelled order gives its promo code uses back
+		if err := s.promotionRepo.ReleaseByOrderID(ctx, order.ID); err != nil {
+			s.logger.WithError(err).WithField("order_id", id).Error("Failed to release promo code redemptions")
+			return err
+		}
+		if err := s.eventRepo.Create(ctx, event); err != nil {
+			s.logger.WithError(err).WithField("order_id", id).Error("Failed to store order cancelled event")
+			return err
+		}
+		return nil
+	})
+	if err != nil {
 		return err
 	}
 
+	s.releaseStock(ctx, order.ID, reason)
+
 	// Invalidate cache (simplified)
 	if err := s.cache.DeleteOrder(ctx, id.String()); err != nil {
 		s.logger.WithError(err).Warn("Failed to invalidate order cache")
 	}
 
-	// Publish cancellation event using correct method signature
-	if err := s.eventPublisher.PublishOrderCancelled(ctx, order.ID.String(), order.CustomerID.String(), reason); err != nil {
-		s.logger.WithError(err).Error("Failed to publish order cancelled event")
-	}
-
 	// Create audit record
 	changes := map[string]interface{}{
 		"old_status": string(oldStatus),
@@ -298,21 +443,469 @@ func (s *Service) CancelOrder(ctx context.Context, id uuid.UUID, reason string)
 	return nil
 }
 
+// EditOrder adds, removes and changes items, the shipping address or the discount of a pending
+// or confirmed order. Each edit is a new revision: its diff is stored in the audit log and
+// published as an order updated event.
+func (s *Service) EditOrder(ctx context.Context, id uuid.UUID, req *dto.EditOrderRequest) (*dto.OrderResponse, error) {
+	order, err := s.orderRepo.GetByID(ctx, id)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", id).Error("Failed to get order for edit")
+		return nil, err
+	}
+	if req.Revision != nil && *req.Revision != order.Revision {
+		return nil, domain.ErrOrderRevisionConflict
+	}
+
+	items, err := s.orderItemRepo.GetByOrderID(ctx, id)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", id).Error("Failed to get order items")
+		return nil, err
+	}
+	order.Items = make([]domain.OrderItem, len(items))
+	for i, item := range items {
+		order.Items[i] = *item
+	}
+	previousItems := order.Items
+	previousRevision := order.Revision
+
+	edit := domain.OrderEdit{
+		RemoveItemIDs:   req.RemoveItemIDs,
+		ShippingAddress: req.ShippingAddress,
+		Discount:        req.Discount,
+	}
+	addedProductIDs := make([]uuid.UUID, len(req.AddItems))
+	added := make([]domain.OrderItem, len(req.AddItems))
+	for i, item := range req.AddItems {
+		addedProductIDs[i] = item.ProductID
+		added[i] = domain.OrderItem{ProductID: item.ProductID, Quantity: item.Quantity}
+	}
+	// Added items are priced like the items the order was created with
+	vip := s.newCustomerVIP(order.CustomerID)
+	prices, err := s.priceItems(ctx, vip, order.CustomerGroup, added)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyPriceOverrides(ctx, req.AddItems, prices, req.MarginApprovedBy); err != nil {
+		return nil, err
+	}
+	exempt, err := s.vatExemptions(ctx, order, addedProductIDs)
+	if err != nil {
+		return nil, err
+	}
+	for i, item := range req.AddItems {
+		edit.AddItems = append(edit.AddItems, domain.NewOrderItem{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			UnitPrice: prices[i].unitPrice,
+			VATExempt: exempt[item.ProductID],
+
+			PriceOverridden:     prices[i].overridden,
+			PriceOverrideReason: prices[i].overrideReason,
+			MarginApprovedBy:    prices[i].marginApprovedBy,
+		})
+	}
+	for _, item := range req.UpdateItems {
+		edit.UpdateItems = append(edit.UpdateItems, domain.OrderItemQuantity{ItemID: item.ItemID, Quantity: item.Quantity})
+	}
+
+	diff, err := order.ApplyEdit(edit)
+	if err != nil {
+		return nil, err
+	}
+	if diff.IsEmpty() {
+		return s.orderToResponse(order), nil
+	}
+	order.UpdatedAt = time.Now()
+
+	details := map[string]interface{}{
+		"revision": order.Revision,
+		"changes":  diff.ToMap(),
+	}
+	if req.Reason != "" {
+		details["reason"] = req.Reason
+	}
+	audit := domain.NewAuditLog(order.ID, req.EditedBy, domain.AuditActionUpdate, details)
+	event := domain.NewOrderEvent(order.ID, domain.EventOrderUpdated, map[string]interface{}{
+		"customer_id":  order.CustomerID.String(),
+		"status":       string(order.Status),
+		"revision":     order.Revision,
+		"total_amount": order.TotalAmount,
+		"tax":          order.Tax,
+		"changes":      diff.ToMap(),
+	})
+
+	// The order stays locked while its stock moves and the edit is saved, so edits of one order
+	// run one at a time and the stock held always matches the saved revision
+	reserved := false
+	err = s.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		revision, err := s.orderRepo.LockRevision(ctx, id)
+		if err != nil {
+			s.logger.WithError(err).WithField("order_id", id).Error("Failed to lock order for edit")
+			return err
+		}
+		if revision != previousRevision {
+			return domain.ErrOrderRevisionConflict
+		}
+
+		// Adjust stock before saving so an edit never promises stock that is not there
+		if err := s.reserveEdit(ctx, order, vip, previousItems, diff, audit.ID); err != nil {
+			return err
+		}
+		reserved = true
+
+		if err := s.saveEdit(ctx, order, previousRevision, diff, audit, event); err != nil {
+			// Undo the stock moves while no other edit can touch the order
+			s.revertEditReservation(ctx, order, vip, previousItems, diff, audit.ID)
+			reserved = false
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		if reserved {
+			// The edit was saved but could not be committed
+			s.revertEditReservation(ctx, order, vip, previousItems, diff, audit.ID)
+		}
+		return nil, err
+	}
+
+	if err := s.cache.DeleteOrder(ctx, id.String()); err != nil {
+		s.logger.WithError(err).Warn("Failed to invalidate order cache")
+	}
+
+	return s.orderToResponse(order), nil
+}
+
+// GetOrderRevisions lists the edits made to an order, newest first
+func (s *Service) GetOrderRevisions(ctx context.Context, id uuid.UUID) ([]*dto.AuditLogResponse, error) {
+	if _, err := s.orderRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+
+	logs, err := s.auditRepo.GetByOrderID(ctx, id)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", id).Error("Failed to get order audit logs")
+		return nil, err
+	}
+
+	revisions := make([]*dto.AuditLogResponse, 0, len(logs))
+	for _, log := range logs {
+		if log.Action != domain.AuditActionUpdate {
+			continue
+		}
+		revisions = append(revisions, &dto.AuditLogResponse{
+			ID:        log.ID,
+			OrderID:   log.OrderID,
+			UserID:    log.UserID,
+			Action:    log.Action,
+			Details:   log.Details,
+			Timestamp: log.Timestamp,
+		})
+	}
+	return revisions, nil
+}
+
+// saveEdit writes the revision, its items, audit record and outbox event of an edit. It runs in
+// the edit's transaction.
+func (s *Service) saveEdit(ctx context.Context, order *domain.Order, previousRevision int, diff *domain.OrderDiff, audit *domain.OrderAuditLog, event *domain.OrderEventOutbox) error {
+	log := s.logger.WithField("order_id", order.ID)
+	if err := s.orderRepo.UpdateRevision(ctx, order, previousRevision); err != nil {
+		log.WithError(err).Error("Failed to save order revision")
+		return err
+	}
+	if err := s.saveEditedItems(ctx, order, diff); err != nil {
+		log.WithError(err).Error("Failed to save edited order items")
+		return err
+	}
+	if err := s.auditRepo.Create(ctx, audit); err != nil {
+		log.WithError(err).Error("Failed to store order revision")
+		return err
+	}
+	if err := s.eventRepo.Create(ctx, event); err != nil {
+		log.WithError(err).Error("Failed to store order updated event")
+		return err
+	}
+	return nil
+}
+
+// saveEditedItems writes the item changes of an edit
+func (s *Service) saveEditedItems(ctx context.Context, order *domain.Order, diff *domain.OrderDiff) error {
+	for _, removed := range diff.ItemsRemoved {
+		if err := s.orderItemRepo.Delete(ctx, removed.ItemID); err != nil {
+			return err
+		}
+	}
+
+	changed := make(map[uuid.UUID]bool, len(diff.ItemsChanged))
+	for _, change := range diff.ItemsChanged {
+		changed[change.ItemID] = true
+	}
+	added := make(map[uuid.UUID]bool, len(diff.ItemsAdded))
+	for _, add := range diff.ItemsAdded {
+		added[add.ItemID] = true
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		switch {
+		case added[item.ID]:
+			if err := s.orderItemRepo.Create(ctx, item); err != nil {
+				return err
+			}
+		case changed[item.ID]:
+			if err := s.orderItemRepo.Update(ctx, item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reserveEdit makes the stock reservation follow an edit. A pending order's reservation is
+// replaced by one for its new items. A confirmed order's stock was already consumed, so what
+// the edit adds is reserved and consumed, and what it removes or reduces is restocked. The
+// stock moves before the edit is saved, so a failure fails the edit.
+func (s *Service) reserveEdit(ctx context.Context, order *domain.Order, vip *customerVIP, previousItems []domain.OrderItem, diff *domain.OrderDiff, editID uuid.UUID) error {
+	if !diff.HasItemChanges() {
+		return nil
+	}
+	vipLevel, err := vip.get(ctx)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to look up customer tier for order edit")
+		return fmt.Errorf("failed to look up customer tier: %w", err)
+	}
+
+	switch order.Status {
+	case domain.OrderStatusPending:
+		if err := s.reservations.ReleaseStock(ctx, order.ID, "order edited"); err != nil {
+			s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to release stock for order edit")
+			return err
+		}
+		if err := s.reserveItems(ctx, order.ID, editReservationKey(order.ID, editID), vipLevel, order.Items); err != nil {
+			// Compensate: hold the stock of the unedited order again
+			if restoreErr := s.reserveItems(ctx, order.ID, editReservationKey(order.ID, editID)+":restore", vipLevel, previousItems); restoreErr != nil {
+				s.logger.WithError(restoreErr).WithField("order_id", order.ID).Error("Failed to restore stock reservation after failed edit")
+			}
+			return err
+		}
+	case domain.OrderStatusConfirmed:
+		added, reduced := stockChanges(diff.StockDeltas(order.Items))
+		if err := s.reserveItems(ctx, order.ID, editReservationKey(order.ID, editID), vipLevel, added); err != nil {
+			return err
+		}
+		if len(added) > 0 {
+			if err := s.consumeStock(ctx, order.ID); err != nil {
+				s.releaseReservation(ctx, order.ID, editReservationKey(order.ID, editID), "order edit failed")
+				return err
+			}
+		}
+		if err := s.restockItems(ctx, order.ID, editReservationKey(order.ID, editID)+":restock", "order edited", reduced); err != nil {
+			// Compensate: put back what the edit added, which was already consumed
+			if revertErr := s.restockItems(ctx, order.ID, editReservationKey(order.ID, editID)+":revert", "order edit failed", added); revertErr != nil {
+				s.logger.WithError(revertErr).WithField("order_id", order.ID).Error("Failed to put back stock added by failed edit")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// revertEditReservation undoes reserveEdit when the edit could not be saved. It only touches the
+// stock moved under the edit's idempotency keys.
+func (s *Service) revertEditReservation(ctx context.Context, order *domain.Order, vip *customerVIP, previousItems []domain.OrderItem, diff *domain.OrderDiff, editID uuid.UUID) {
+	if !diff.HasItemChanges() {
+		return
+	}
+	// reserveEdit already looked the level up
+	vipLevel, _ := vip.get(ctx)
+	log := s.logger.WithField("order_id", order.ID)
+
+	switch order.Status {
+	case domain.OrderStatusPending:
+		// Only what this edit reserved goes back, then the unedited order's stock is held again
+		s.releaseReservation(ctx, order.ID, editReservationKey(order.ID, editID), "order edit failed")
+		if err := s.reserveItems(ctx, order.ID, editReservationKey(order.ID, editID)+":restore", vipLevel, previousItems); err != nil {
+			log.WithError(err).Error("Failed to restore stock reservation after failed edit")
+		}
+	case domain.OrderStatusConfirmed:
+		added, reduced := stockChanges(diff.StockDeltas(order.Items))
+		if err := s.restockItems(ctx, order.ID, editReservationKey(order.ID, editID)+":revert", "order edit failed", added); err != nil {
+			log.WithError(err).Error("Failed to put back stock added by failed edit")
+		}
+		if err := s.reserveItems(ctx, order.ID, editReservationKey(order.ID, editID)+":restore", vipLevel, reduced); err != nil {
+			log.WithError(err).Error("Failed to take back stock restocked by failed edit")
+			return
+		}
+		if len(reduced) > 0 {
+			if err := s.consumeStock(ctx, order.ID); err != nil {
+				log.WithError(err).Error("Failed to take back stock restocked by failed edit")
+			}
+		}
+	}
+}
+
+// stockChanges splits the change in ordered quantity per product into the quantities an edit
+// adds and the quantities it removes
+func stockChanges(deltas map[uuid.UUID]int) (added, reduced []domain.OrderItem) {
+	for productID, delta := range deltas {
+		if delta > 0 {
+			added = append(added, domain.OrderItem{ProductID: productID, Quantity: delta})
+		} else {
+			reduced = append(reduced, domain.OrderItem{ProductID: productID, Quantity: -delta})
+		}
+	}
+	return added, reduced
+}
+
 // Helper functions
 
+// reservationKey is the idempotency key of an order's stock reservation. It only depends on the
+// order, so a retried reservation can never take the stock twice.
+func reservationKey(orderID uuid.UUID) string {
+	return fmt.Sprintf("order:%s:reserve", orderID)
+}
+
+// editReservationKey is the idempotency key of the stock reserved by one edit of an order. Each
+// edit attempt has its own key, so an edit retried after a failure reserves again.
+func editReservationKey(orderID, editID uuid.UUID) string {
+	return fmt.Sprintf("order:%s:edit:%s", orderID, editID)
+}
+
+// cancelRestockKey is the idempotency key of the consumed stock a cancelled order puts back
+func cancelRestockKey(orderID uuid.UUID) string {
+	return fmt.Sprintf("order:%s:cancel:restock", orderID)
+}
+
+// reserveStock reserves the stock of every item that is not a stock override
+func (s *Service) reserveStock(ctx context.Context, order *domain.Order, vip *customerVIP) error {
+	vipLevel, err := vip.get(ctx)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to look up customer tier for stock reservation")
+		return fmt.Errorf("failed to look up customer tier: %w", err)
+	}
+	return s.reserveItems(ctx, order.ID, reservationKey(order.ID), vipLevel, order.Items)
+}
+
+// reserveItems reserves the stock of the given items under the idempotency key. Products in early
+// access are checked against the customer's VIP level and the quota of that level.
+func (s *Service) reserveItems(ctx context.Context, orderID uuid.UUID, key, vipLevel string, orderItems []domain.OrderItem) error {
+	items := stockItems(orderItems)
+	if len(items) == 0 {
+		return nil
+	}
+
+	_, err := s.reservations.ReserveStock(ctx, orderID, key, vipLevel, items, s.reservationTTL)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to reserve stock")
+		switch {
+		case errors.Is(err, client.ErrInsufficientStock):
+			return fmt.Errorf("%w: %v", domain.ErrInsufficientStock, err)
+		case errors.Is(err, client.ErrLaunchAllocationExhausted):
+			return fmt.Errorf("%w: %v", domain.ErrLaunchAllocationExhausted, err)
+		case errors.Is(err, client.ErrVIPAccessRequired):
+			return fmt.Errorf("%w: %v", domain.ErrVIPAccessRequired, err)
+		case errors.Is(err, client.ErrProductUnavailable):
+			return fmt.Errorf("%w: %v", domain.ErrProductUnavailable, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// consumeStock consumes the stock reserved for an order. Orders placed without a reservation
+// have nothing to consume.
+func (s *Service) consumeStock(ctx context.Context, orderID uuid.UUID) error {
+	err := s.reservations.ConsumeStock(ctx, orderID)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, client.ErrReservationNotFound):
+		s.logger.WithField("order_id", orderID).Warn("No stock reservation to consume for order")
+		return nil
+	case errors.Is(err, client.ErrReservationNotActive):
+		return domain.ErrStockReservationExpired
+	default:
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to consume reserved stock")
+		return err
+	}
+}
+
+// restockCancelledOrder puts back the stock a cancelled order already consumed, which also gives
+// back the early access quota it claimed. It runs before the cancellation is saved: if saving
+// fails the cancellation is retried, and restocking again under the same key is a no-op.
+func (s *Service) restockCancelledOrder(ctx context.Context, order *domain.Order, reason string) error {
+	err := s.restockItems(ctx, order.ID, cancelRestockKey(order.ID), reason, order.ConsumedStockItems())
+	if errors.Is(err, client.ErrReturnExceedsConsumed) {
+		// Orders confirmed without a reservation consumed nothing to put back
+		s.logger.WithError(err).WithField("order_id", order.ID).Warn("No consumed stock to put back for cancelled order")
+		return nil
+	}
+	return err
+}
+
+// restockItems puts the consumed stock of the given items back under the idempotency key
+func (s *Service) restockItems(ctx context.Context, orderID uuid.UUID, key, reason string, orderItems []domain.OrderItem) error {
+	items := stockItems(orderItems)
+	if len(items) == 0 {
+		return nil
+	}
+
+	if err := s.reservations.RestockStock(ctx, orderID, key, reason, items); err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Error("Failed to restock consumed stock")
+		return err
+	}
+	return nil
+}
+
+// stockItems returns the product quantities of the items that are not stock overrides
+func stockItems(orderItems []domain.OrderItem) []client.StockReservationItem {
+	var items []client.StockReservationItem
+	for _, item := range orderItems {
+		if item.IsOverride {
+			continue
+		}
+		items = append(items, client.StockReservationItem{ProductID: item.ProductID, Quantity: item.Quantity})
+	}
+	return items
+}
+
+// releaseStock releases the stock reserved for an order. A failed release is only logged: the
+// reservation expires on its own.
+func (s *Service) releaseStock(ctx context.Context, orderID uuid.UUID, reason string) {
+	if err := s.reservations.ReleaseStock(ctx, orderID, reason); err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Warn("Failed to release reserved stock, it will expire instead")
+	}
+}
+
+// releaseReservation releases only the stock reserved for an order under an idempotency key. A
+// failed release is only logged: the reservation expires on its own.
+func (s *Service) releaseReservation(ctx context.Context, orderID uuid.UUID, key, reason string) {
+	if err := s.reservations.ReleaseReservation(ctx, orderID, key, reason); err != nil {
+		s.logger.WithError(err).WithField("order_id", orderID).Warn("Failed to release reserved stock, it will expire instead")
+	}
+}
+
 func (s *Service) orderToResponse(order *domain.Order) *dto.OrderResponse {
 	items := make([]dto.OrderItemResponse, len(order.Items))
 	for i, item := range order.Items {
 		items[i] = dto.OrderItemResponse{
-			ID:             item.ID,
-			ProductID:      item.ProductID,
-			Quantity:       item.Quantity,
-			UnitPrice:      item.UnitPrice,
-			TotalPrice:     item.TotalPrice,
-			IsOverride:     item.IsOverride,
-			OverrideReason: item.OverrideReason,
-			CreatedAt:      item.CreatedAt,
-			UpdatedAt:      item.UpdatedAt,
+			ID:                  item.ID,
+			ProductID:           item.ProductID,
+			Quantity:            item.Quantity,
+			UnitPrice:           item.UnitPrice,
+			TotalPrice:          item.TotalPrice,
+			IsOverride:          item.IsOverride,
+			OverrideReason:      item.OverrideReason,
+			ShippedQuantity:     item.ShippedQuantity,
+			FulfilledQuantity:   item.FulfilledQuantity,
+			BackorderedQuantity: item.BackorderedQuantity,
+			ReturnedQuantity:    item.ReturnedQuantity,
+			VATExempt:           item.VATExempt,
+			PriceOverridden:     item.PriceOverridden,
+			PriceOverrideReason: item.PriceOverrideReason,
+			MarginApprovedBy:    item.MarginApprovedBy,
+			CreatedAt:           item.CreatedAt,
+			UpdatedAt:           item.UpdatedAt,
 		}
 	}
 
@@ -328,11 +921,15 @@ func (s *Service) orderToResponse(order *domain.Order) *dto.OrderResponse {
 		ShippingFee:     order.ShippingFee,
 		Tax:             order.Tax,
 		TaxEnabled:      order.TaxEnabled,
+		TaxMode:         order.TaxMode,
+		VATRate:         order.VATRate,
+		CustomerGroup:   order.CustomerGroup,
 		ShippingAddress: order.ShippingAddress,
 		BillingAddress:  order.BillingAddress,
 		PaymentMethod:   order.PaymentMethod,
 		PromoCode:       order.PromoCode,
 		Notes:           order.Notes,
+		Revision:        order.Revision,
 		Items:           items,
 		ConfirmedAt:     order.ConfirmedAt,
 		CancelledAt:     order.CancelledAt,
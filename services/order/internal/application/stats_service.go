@@ -11,10 +11,13 @@ import (
 	"order/pkg/logger"
 )
 
-// OrderStatsService handles order statistics and analytics
+// OrderStatsService handles order statistics and analytics. Daily, monthly and product
+// statistics are read from rollups the database maintains as orders change.
 type OrderStatsService struct {
 	orderRepo     domain.OrderRepository
 	orderItemRepo domain.OrderItemRepository
+	statsRepo     domain.OrderStatsRepository
+	txManager     domain.TransactionManager
 	logger        logger.Logger
 }
 
@@ -22,11 +25,15 @@ type OrderStatsService struct {
 func NewOrderStatsService(
 	orderRepo domain.OrderRepository,
 	orderItemRepo domain.OrderItemRepository,
+	statsRepo domain.OrderStatsRepository,
+	txManager domain.TransactionManager,
 	logger logger.Logger,
 ) *OrderStatsService {
 	return &OrderStatsService{
 		orderRepo:     orderRepo,
 		orderItemRepo: orderItemRepo,
+		statsRepo:     statsRepo,
+		txManager:     txManager,
 		logger:        logger,
 	}
 }
@@ -35,42 +42,22 @@ func NewOrderStatsService(
 func (s *OrderStatsService) GetDailyStats(ctx context.Context, date time.Time) (*dto.DailyStats, error) {
 	s.logger.Info("Getting daily stats", "date", date.Format("2006-01-02"))
 
-	// Get start and end of the day
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
 
-	// Get orders for the day
-	orders, err := s.orderRepo.GetOrdersByDateRange(ctx, startOfDay, endOfDay)
+	rows, err := s.statsRepo.GetOrderStats(ctx, startOfDay, startOfDay.AddDate(0, 0, 1))
 	if err != nil {
-		s.logger.Error("Failed to get orders by date range", "error", err)
-		return nil, fmt.Errorf("failed to get orders: %w", err)
-	}
-
-	// Calculate statistics
-	stats := &dto.DailyStats{
-		Date: startOfDay,
-	}
-
-	var totalRevenue float64
-	ordersByStatus := make(map[domain.OrderStatus]int)
-
-	for _, order := range orders {
-		stats.OrdersCount++
-		totalRevenue += order.TotalAmount
-		ordersByStatus[order.Status]++
+		s.logger.Error("Failed to get order stats", "error", err)
+		return nil, fmt.Errorf("failed to get order stats: %w", err)
 	}
 
-	stats.Revenue = totalRevenue
-	if stats.OrdersCount > 0 {
-		stats.AvgOrderValue = totalRevenue / float64(stats.OrdersCount)
-	}
-
-	// Set status counts
-	stats.PendingOrders = ordersByStatus[domain.OrderStatusPending]
-	stats.CompletedOrders = ordersByStatus[domain.OrderStatusCompleted]
-	stats.CancelledOrders = ordersByStatus[domain.OrderStatusCancelled]
+	summary := domain.SummarizeOrderStats(rows)
+	stats := dailyStatsFromSummary(startOfDay, summary)
+	stats.OrdersByStatus = summary.ByStatus
+	stats.HourlyBreakdown = hourlyStats(summary)
+	stats.ChannelBreakdown = channelStats(summary)
+	stats.PaymentMethodBreakdown = paymentMethodStats(summary)
 
-	s.logger.Info("Daily stats calculated", 
+	s.logger.Info("Daily stats calculated",
 		"date", date.Format("2006-01-02"),
 		"orders_count", stats.OrdersCount,
 		"revenue", stats.Revenue)
@@ -82,99 +69,54 @@ func (s *OrderStatsService) GetDailyStats(ctx context.Context, date time.Time) (
 func (s *OrderStatsService) GetMonthlyStats(ctx context.Context, year, month int) (*dto.MonthlyStats, error) {
 	s.logger.Info("Getting monthly stats", "year", year, "month", month)
 
-	// Get start and end of the month
 	startOfMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
 	endOfMonth := startOfMonth.AddDate(0, 1, 0)
 
-	// Get orders for the month
-	orders, err := s.orderRepo.GetOrdersByDateRange(ctx, startOfMonth, endOfMonth)
+	rows, err := s.statsRepo.GetOrderStats(ctx, startOfMonth, endOfMonth)
 	if err != nil {
-		s.logger.Error("Failed to get orders by date range", "error", err)
-		return nil, fmt.Errorf("failed to get orders: %w", err)
+		s.logger.Error("Failed to get order stats", "error", err)
+		return nil, fmt.Errorf("failed to get order stats: %w", err)
 	}
 
-	// Calculate monthly statistics
+	summary := domain.SummarizeOrderStats(rows)
 	stats := &dto.MonthlyStats{
-		Year:  year,
-		Month: month,
-	}
-
-	var totalRevenue float64
-	dailyStatsMap := make(map[string]*dto.DailyStats)
-
-	for _, order := range orders {
-		stats.TotalOrders++
-		totalRevenue += order.TotalAmount
-
-		// Group by day for daily breakdown
-		orderDate := order.CreatedAt.Format("2006-01-02")
-		if dailyStats, exists := dailyStatsMap[orderDate]; exists {
-			dailyStats.OrdersCount++
-			dailyStats.Revenue += order.TotalAmount
-			// Update status counts
-			switch order.Status {
-			case domain.OrderStatusPending:
-				dailyStats.PendingOrders++
-			case domain.OrderStatusCompleted:
-				dailyStats.CompletedOrders++
-			case domain.OrderStatusCancelled:
-				dailyStats.CancelledOrders++
-			}
-		} else {
-			dailyStats := &dto.DailyStats{
-				Date:        order.CreatedAt.Truncate(24 * time.Hour),
-				OrdersCount: 1,
-				Revenue:     order.TotalAmount,
-			}
-			// Set initial status count
-			switch order.Status {
-			case domain.OrderStatusPending:
-				dailyStats.PendingOrders = 1
-			case domain.OrderStatusCompleted:
-				dailyStats.CompletedOrders = 1
-			case domain.OrderStatusCancelled:
-				dailyStats.CancelledOrders = 1
-			}
-			dailyStatsMap[orderDate] = dailyStats
-		}
-	}
-
-	stats.TotalRevenue = totalRevenue
-	if stats.TotalOrders > 0 {
-		stats.AvgOrderValue = totalRevenue / float64(stats.TotalOrders)
+		Year:                   year,
+		Month:                  month,
+		TotalOrders:            summary.OrdersCount,
+		TotalRevenue:           summary.Revenue,
+		AvgOrderValue:          summary.AvgOrderValue(),
+		OrdersByStatus:         summary.ByStatus,
+		HourlyBreakdown:        hourlyStats(summary),
+		ChannelBreakdown:       channelStats(summary),
+		PaymentMethodBreakdown: paymentMethodStats(summary),
 	}
 
-	// Convert daily stats map to slice and calculate avg order values
-	for _, dailyStats := range dailyStatsMap {
-		if dailyStats.OrdersCount > 0 {
-			dailyStats.AvgOrderValue = dailyStats.Revenue / float64(dailyStats.OrdersCount)
+	// Rows come ordered by day, so the daily breakdown is in date order
+	var dayRows []*domain.OrderStatsRow
+	for i, row := range rows {
+		dayRows = append(dayRows, row)
+		if i == len(rows)-1 || !rows[i+1].Date.Equal(row.Date) {
+			stats.DailyBreakdown = append(stats.DailyBreakdown, *dailyStatsFromSummary(row.Date, domain.SummarizeOrderStats(dayRows)))
+			dayRows = nil
 		}
-		stats.DailyBreakdown = append(stats.DailyBreakdown, *dailyStats)
 	}
 
-	// Calculate growth rate compared to previous month
-	prevMonth := month - 1
-	prevYear := year
-	if prevMonth < 1 {
-		prevMonth = 12
-		prevYear--
-	}
-
-	prevMonthStats, err := s.GetMonthlyStats(ctx, prevYear, prevMonth)
-	if err == nil && prevMonthStats.TotalOrders > 0 {
+	// Compare with the previous month
+	prevStart := startOfMonth.AddDate(0, -1, 0)
+	prevRows, err := s.statsRepo.GetOrderStats(ctx, prevStart, startOfMonth)
+	if err != nil {
+		s.logger.Warn("Failed to get previous month stats", "error", err)
+	} else if prev := domain.SummarizeOrderStats(prevRows); prev.OrdersCount > 0 {
 		stats.ComparisonData = &dto.MonthlyComparison{
-			PreviousMonth: prevMonth,
-			PreviousYear:  prevYear,
-			OrdersChange:  stats.TotalOrders - prevMonthStats.TotalOrders,
-			RevenueChange: stats.TotalRevenue - prevMonthStats.TotalRevenue,
+			PreviousMonth: int(prevStart.Month()),
+			PreviousYear:  prevStart.Year(),
+			OrdersChange:  stats.TotalOrders - prev.OrdersCount,
+			RevenueChange: stats.TotalRevenue - prev.Revenue,
 		}
 
-		// Calculate growth percentages
-		if prevMonthStats.TotalOrders > 0 {
-			stats.ComparisonData.OrdersGrowthPct = float64(stats.ComparisonData.OrdersChange) / float64(prevMonthStats.TotalOrders) * 100
-		}
-		if prevMonthStats.TotalRevenue > 0 {
-			stats.ComparisonData.RevenueGrowthPct = stats.ComparisonData.RevenueChange / prevMonthStats.TotalRevenue * 100
+		stats.ComparisonData.OrdersGrowthPct = float64(stats.ComparisonData.OrdersChange) / float64(prev.OrdersCount) * 100
+		if prev.Revenue > 0 {
+			stats.ComparisonData.RevenueGrowthPct = stats.ComparisonData.RevenueChange / prev.Revenue * 100
 		}
 
 		stats.GrowthRate = stats.ComparisonData.RevenueGrowthPct
@@ -189,7 +131,8 @@ func (s *OrderStatsService) GetMonthlyStats(ctx context.Context, year, month int
 	return stats, nil
 }
 
-// GetTopProducts retrieves top products by order count, revenue, or quantity
+// GetTopProducts retrieves top products by order count, revenue, or quantity. Both dates of
+// the range are inclusive.
 func (s *OrderStatsService) GetTopProducts(ctx context.Context, req *dto.TopProductsRequest) ([]dto.ProductStats, error) {
 	s.logger.Info("Getting top products", "limit", req.Limit, "sort_by", req.SortBy)
 
@@ -201,87 +144,123 @@ func (s *OrderStatsService) GetTopProducts(ctx context.Context, req *dto.TopProd
 		req.SortBy = "order_count"
 	}
 
-	// Get order items within date range if specified
-	var orderItems []*domain.OrderItem
-	var err error
+	var end *time.Time
+	if req.EndDate != nil {
+		dayAfter := req.EndDate.AddDate(0, 0, 1)
+		end = &dayAfter
+	}
 
-	if req.StartDate != nil && req.EndDate != nil {
-		// For now, we'll get all order items and filter by date
-		// In a real implementation, you might want to add date filtering to the repository
-		allOrderItems, err := s.orderItemRepo.GetAllOrderItems(ctx)
-		if err != nil {
-			s.logger.Error("Failed to get order items", "error", err)
-			return nil, fmt.Errorf("failed to get order items: %w", err)
-		}
+	rows, err := s.statsRepo.GetTopProducts(ctx, req.StartDate, end, req.SortBy, req.Limit)
+	if err != nil {
+		s.logger.Error("Failed to get top products", "error", err)
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
 
-		// Filter by date range by getting orders first
-		orders, err := s.orderRepo.GetOrdersByDateRange(ctx, *req.StartDate, *req.EndDate)
-		if err != nil {
-			s.logger.Error("Failed to get orders by date range", "error", err)
-			return nil, fmt.Errorf("failed to get orders: %w", err)
+	productStats := make([]dto.ProductStats, 0, len(rows))
+	for _, row := range rows {
+		stats := dto.ProductStats{
+			ProductID:     row.ProductID,
+			ProductName:   "", // Product name would need to be fetched from product service
+			OrderCount:    row.OrderCount,
+			TotalQuantity: row.Quantity,
+			Revenue:       row.Revenue,
 		}
-
-		orderIDSet := make(map[uuid.UUID]bool)
-		for _, order := range orders {
-			orderIDSet[order.ID] = true
+		if row.Quantity > 0 {
+			stats.AvgPrice = row.Revenue / float64(row.Quantity)
 		}
+		if row.LastOrderAt != nil {
+			stats.LastOrderDate = *row.LastOrderAt
+		}
+		productStats = append(productStats, stats)
+	}
 
-		for _, item := range allOrderItems {
-			if orderIDSet[item.OrderID] {
-				orderItems = append(orderItems, item)
-			}
+	s.logger.Info("Top products calculated", "count", len(productStats))
+	return productStats, nil
+}
+
+// RebuildStats recalculates the statistics rollups of the days from start to end, both
+// inclusive, from the orders. Each day is rebuilt in its own transaction and progress is
+// reported after every day. It is safe to run while orders are being written.
+func (s *OrderStatsService) RebuildStats(ctx context.Context, start, end time.Time, progress func(day time.Time)) error {
+	first := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	last := time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, time.UTC)
+	if last.Before(first) {
+		return fmt.Errorf("end date %s is before start date %s", last.Format("2006-01-02"), first.Format("2006-01-02"))
+	}
+
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if err := ctx.Err(); err != nil {
+			return err
 		}
-	} else {
-		orderItems, err = s.orderItemRepo.GetAllOrderItems(ctx)
+
+		err := s.txManager.WithTransaction(ctx, func(txCtx context.Context) error {
+			return s.statsRepo.RebuildDay(txCtx, day)
+		})
 		if err != nil {
-			s.logger.Error("Failed to get order items", "error", err)
-			return nil, fmt.Errorf("failed to get order items: %w", err)
+			s.logger.Error("Failed to rebuild order stats", "date", day.Format("2006-01-02"), "error", err)
+			return fmt.Errorf("failed to rebuild stats of %s: %w", day.Format("2006-01-02"), err)
 		}
-	}
 
-	// Aggregate product statistics
-	productStatsMap := make(map[uuid.UUID]*dto.ProductStats)
-
-	for _, item := range orderItems {
-		if stats, exists := productStatsMap[item.ProductID]; exists {
-			stats.OrderCount++
-			stats.TotalQuantity += item.Quantity
-			stats.Revenue += item.UnitPrice * float64(item.Quantity)
-		} else {
-			productStatsMap[item.ProductID] = &dto.ProductStats{
-				ProductID:     item.ProductID,
-				ProductName:   "", // Product name would need to be fetched from product service
-				OrderCount:    1,
-				TotalQuantity: item.Quantity,
-				Revenue:       item.UnitPrice * float64(item.Quantity),
-				LastOrderDate: time.Now(), // Would need to get from order date
-			}
+		if progress != nil {
+			progress(day)
 		}
 	}
 
-	// Calculate average prices
-	for _, stats := range productStatsMap {
-		if stats.TotalQuantity > 0 {
-			stats.AvgPrice = stats.Revenue / float64(stats.TotalQuantity)
-		}
+	s.logger.Info("Order stats rebuilt", "start_date", first.Format("2006-01-02"), "end_date", last.Format("2006-01-02"))
+	return nil
+}
+
+// dailyStatsFromSummary converts a rollup summary to daily totals without breakdowns
+func dailyStatsFromSummary(date time.Time, summary *domain.OrderStatsSummary) *dto.DailyStats {
+	return &dto.DailyStats{
+		Date:            date,
+		OrdersCount:     summary.OrdersCount,
+		Revenue:         summary.Revenue,
+		AvgOrderValue:   summary.AvgOrderValue(),
+		PendingOrders:   summary.ByStatus[domain.OrderStatusPending],
+		CompletedOrders: summary.ByStatus[domain.OrderStatusCompleted],
+		CancelledOrders: summary.ByStatus[domain.OrderStatusCancelled],
 	}
+}
 
-	// Convert map to slice
-	var productStats []dto.ProductStats
-	for _, stats := range productStatsMap {
-		productStats = append(productStats, *stats)
+// hourlyStats lists every hour of the day, including hours without orders
+func hourlyStats(summary *domain.OrderStatsSummary) []dto.HourlyStats {
+	hours := make([]dto.HourlyStats, len(summary.ByHour))
+	for hour, bucket := range summary.ByHour {
+		hours[hour] = dto.HourlyStats{
+			Hour:          hour,
+			OrdersCount:   bucket.OrdersCount,
+			Revenue:       bucket.Revenue,
+			AvgOrderValue: bucket.AvgOrderValue(),
+		}
 	}
+	return hours
+}
 
-	// Sort by requested criteria (simplified sorting)
-	// In a real implementation, you might want to use a more sophisticated sorting algorithm
-	
-	// Limit results
-	if len(productStats) > req.Limit {
-		productStats = productStats[:req.Limit]
+func channelStats(summary *domain.OrderStatsSummary) []dto.ChannelStats {
+	channels := make([]dto.ChannelStats, 0, len(summary.BySource))
+	for _, bucket := range summary.BySource {
+		channels = append(channels, dto.ChannelStats{
+			Source:        domain.OrderSource(bucket.Key),
+			OrdersCount:   bucket.OrdersCount,
+			Revenue:       bucket.Revenue,
+			AvgOrderValue: bucket.AvgOrderValue(),
+		})
 	}
+	return channels
+}
 
-	s.logger.Info("Top products calculated", "count", len(productStats))
-	return productStats, nil
+func paymentMethodStats(summary *domain.OrderStatsSummary) []dto.PaymentMethodStats {
+	methods := make([]dto.PaymentMethodStats, 0, len(summary.ByPaymentMethod))
+	for _, bucket := range summary.ByPaymentMethod {
+		methods = append(methods, dto.PaymentMethodStats{
+			PaymentMethod: bucket.Key,
+			OrdersCount:   bucket.OrdersCount,
+			Revenue:       bucket.Revenue,
+			AvgOrderValue: bucket.AvgOrderValue(),
+		})
+	}
+	return methods
 }
 
 // GetCustomerStats retrieves statistics for a specific customer
@@ -0,0 +1,88 @@
+package application
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+	"order/internal/domain"
+	"order/internal/infrastructure/client"
+)
+
+// TaxSettings are the VAT rate, the default pricing mode and the seller details orders are
+// taxed and invoiced with
+type TaxSettings struct {
+	VATRate     float64
+	DefaultMode domain.TaxMode
+	Seller      domain.InvoiceParty
+}
+
+// productCatalog looks up the products of an order in the product service the first time a
+// rule needs them, so VAT exemptions and promotion categories share one lookup
+type productCatalog struct {
+	lookup     client.CatalogClient
+	productIDs []uuid.UUID
+	products   map[uuid.UUID]*client.CatalogProduct
+}
+
+func (s *Service) newProductCatalog(items []domain.OrderItem) *productCatalog {
+	productIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+	}
+	return &productCatalog{lookup: s.catalog, productIDs: productIDs}
+}
+
+func (c *productCatalog) get(ctx context.Context) (map[uuid.UUID]*client.CatalogProduct, error) {
+	if c.products == nil {
+		products, err := c.lookup.GetProducts(ctx, c.productIDs)
+		if err != nil {
+			return nil, err
+		}
+		c.products = products
+	}
+	return c.products, nil
+}
+
+// applyVAT marks the VAT exempt items of a new order and sets its VAT rate and pricing mode.
+// Exemptions are only looked up when the order is taxed.
+func (s *Service) applyVAT(ctx context.Context, order *domain.Order, catalog *productCatalog, mode *domain.TaxMode) error {
+	taxMode := s.tax.DefaultMode
+	if mode != nil {
+		taxMode = *mode
+	}
+
+	if order.TaxEnabled && s.tax.VATRate > 0 {
+		products, err := catalog.get(ctx)
+		if err != nil {
+			s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to look up VAT exemptions")
+			return fmt.Errorf("%w: %v", domain.ErrProductLookupFailed, err)
+		}
+		for i := range order.Items {
+			if product, ok := products[order.Items[i].ProductID]; ok {
+				order.Items[i].VATExempt = product.IsVATExempt
+			}
+		}
+	}
+
+	return order.SetVAT(s.tax.VATRate, taxMode)
+}
+
+// vatExemptions looks up which of the products are sold without VAT, for items added to a taxed
+// order by an edit
+func (s *Service) vatExemptions(ctx context.Context, order *domain.Order, productIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	exempt := make(map[uuid.UUID]bool, len(productIDs))
+	if len(productIDs) == 0 || !order.TaxEnabled || order.VATRate <= 0 {
+		return exempt, nil
+	}
+
+	products, err := s.catalog.GetProducts(ctx, productIDs)
+	if err != nil {
+		s.logger.WithError(err).WithField("order_id", order.ID).Error("Failed to look up VAT exemptions")
+		return nil, fmt.Errorf("%w: %v", domain.ErrProductLookupFailed, err)
+	}
+	for productID, product := range products {
+		exempt[productID] = product.IsVATExempt
+	}
+	return exempt, nil
+}
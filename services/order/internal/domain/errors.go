@@ -12,7 +12,10 @@ var (
 	ErrInvalidStatusTransition = errors.New("invalid status transition")
 	ErrOrderCannotBeModified   = errors.New("order cannot be modified in current status")
 	ErrInvalidOrderStatus      = errors.New("invalid order status for this operation")
+	ErrOrderRevisionConflict   = errors.New("order was changed by someone else")
 	ErrUnauthorizedStockOverride = errors.New("unauthorized to perform stock override")
+	ErrCancelConfirmedNotPermitted = errors.New("not permitted to cancel a confirmed order")
+	ErrInvalidOrderSource      = errors.New("invalid order source")
 	
 	// Order item errors
 	ErrOrderItemNotFound     = errors.New("order item not found")
@@ -21,6 +24,77 @@ var (
 	ErrInvalidPrice          = errors.New("invalid price")
 	ErrInvalidAmount         = errors.New("invalid amount")
 	
+	// Shipment errors
+	ErrShipmentNotFound       = errors.New("shipment not found")
+	ErrOrderCannotBeShipped   = errors.New("order cannot be shipped in current status")
+	ErrShipmentExceedsOrder   = errors.New("shipment quantity exceeds the quantity left to ship")
+	ErrShipmentNotInTransit   = errors.New("shipment is not in transit")
+	ErrDeliveryNotCreated     = errors.New("delivery could not be created")
+	
+	// Return errors
+	ErrReturnNotFound         = errors.New("return request not found")
+	ErrReturnItemNotFound     = errors.New("return item not found")
+	ErrOrderCannotBeReturned  = errors.New("order cannot be returned in current status")
+	ErrReturnExceedsFulfilled = errors.New("return quantity exceeds the delivered quantity left to return")
+	ErrInvalidReturnReason    = errors.New("invalid return reason")
+	ErrInvalidReturnStatus    = errors.New("return request is not in a status that allows this operation")
+	ErrRestockFailed          = errors.New("returned stock could not be put back")
+	ErrRefundExceedsPaid      = errors.New("refund amount exceeds the amount paid")
+	ErrRefundFailed           = errors.New("refund could not be completed")
+	
+	// Search errors
+	ErrInvalidOrderSearch = errors.New("invalid order search")
+	ErrInvalidCursor      = errors.New("invalid or expired cursor")
+	
+	// Promotion errors
+	ErrPromotionNotFound             = errors.New("promo code not found")
+	ErrPromotionAlreadyExists        = errors.New("promo code already exists")
+	ErrInvalidPromotion              = errors.New("invalid promotion")
+	ErrPromotionNotStarted           = errors.New("promo code is not valid yet")
+	ErrPromotionExpired              = errors.New("promo code has expired")
+	ErrPromotionUsageLimitReached    = errors.New("promo code usage limit reached")
+	ErrPromotionCustomerLimitReached = errors.New("promo code usage limit reached for this customer")
+	ErrPromotionTierRequired         = errors.New("promo code requires a higher customer tier")
+	ErrPromotionMinSpendNotMet       = errors.New("order does not reach the promo code minimum spend")
+	ErrPromotionNotApplicable        = errors.New("promo code does not apply to this order")
+	ErrPromotionNotStackable         = errors.New("promo codes cannot be combined")
+	ErrPromotionLookupFailed         = errors.New("promotion rules could not be checked")
+
+	// Tax invoice errors
+	ErrInvalidTaxSettings    = errors.New("invalid VAT rate or tax mode")
+	ErrInvoiceNotFound       = errors.New("invoice not found")
+	ErrInvalidInvoiceType    = errors.New("invalid invoice type")
+	ErrInvalidBuyerTaxID     = errors.New("buyer tax ID must be 13 digits with a valid check digit")
+	ErrInvalidBranchCode     = errors.New("branch code must be 5 digits, 00000 for the head office")
+	ErrBuyerDetailsRequired  = errors.New("a full tax invoice needs the buyer name, address, tax ID and branch")
+	ErrOrderCannotBeInvoiced = errors.New("order cannot be invoiced in current status")
+	ErrInvoiceAlreadyIssued  = errors.New("order already has an invoice of this type")
+	ErrInvoiceNotTaxed       = errors.New("order is not taxed, no tax invoice can be issued")
+	ErrProductLookupFailed   = errors.New("products could not be looked up")
+
+	// Recurring order errors
+	ErrRecurringOrderNotFound  = errors.New("recurring order not found")
+	ErrInvalidSchedule         = errors.New("schedule must be a five-field cron expression such as \"0 9 * * 1\"")
+	ErrRecurringOrderNotActive = errors.New("recurring order is not active")
+	ErrRecurringOrderNotPaused = errors.New("recurring order is not paused")
+	ErrRecurringOrderCancelled = errors.New("recurring order is cancelled")
+	ErrRecurringOrderNotDue    = errors.New("recurring order is not due")
+	ErrRecurringOrderConflict  = errors.New("recurring order was changed by someone else")
+	ErrRecurringOrderRunExists = errors.New("occurrence of the recurring order was already handled")
+	ErrInvalidPauseUntil       = errors.New("pause must end in the future")
+
+	// Pricing errors
+	ErrPricingFailed               = errors.New("items could not be priced")
+	ErrProductUnavailable          = errors.New("product is unknown or not for sale")
+	ErrPriceOverrideReasonRequired = errors.New("a reason is required to override an item price")
+	ErrMarginApprovalRequired      = errors.New("item price is below cost or the minimum margin and needs a manager's approval")
+	ErrVIPAccessRequired           = errors.New("product is only sold to higher VIP levels")
+
+	// Stock reservation errors
+	ErrInsufficientStock         = errors.New("insufficient stock")
+	ErrStockReservationExpired   = errors.New("stock reservation expired or released")
+	ErrLaunchAllocationExhausted = errors.New("early access allocation of the customer's VIP level is exhausted")
+	
 	// Event errors
 	ErrEventNotFound = errors.New("event not found")
 	
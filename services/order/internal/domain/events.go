@@ -17,6 +17,12 @@ const (
 	AuditActionStatusChange  AuditAction = "CHANGE_STATUS"
 	AuditActionOverrideStock AuditAction = "OVERRIDE_STOCK"
 	AuditActionCancel        AuditAction = "CANCEL"
+	AuditActionShip          AuditAction = "SHIP"
+	AuditActionDeliver       AuditAction = "DELIVER"
+	AuditActionReturn        AuditAction = "RETURN"
+	AuditActionRefund        AuditAction = "REFUND"
+	AuditActionInvoice       AuditAction = "INVOICE"
+	AuditActionAccessDenied  AuditAction = "ACCESS_DENIED"
 )
 
 // OrderAuditLog represents an audit log entry for order changes
@@ -52,6 +58,8 @@ const (
 	EventStatusSent      EventStatus = "sent"
 	EventStatusFailed    EventStatus = "failed"
 	EventStatusCancelled EventStatus = "cancelled"
+	// EventStatusDeadLetter marks events that exhausted their retries and need manual attention
+	EventStatusDeadLetter EventStatus = "dead_letter"
 )
 
 // EventType represents the type of event being published
@@ -70,6 +78,7 @@ const (
 	EventPaymentUpdated    EventType = "payment_updated"
 	EventInventoryReserved EventType = "inventory_reserved"
 	EventInventoryReleased EventType = "inventory_released"
+	EventInvoiceIssued     EventType = "invoice_issued"
 )
 
 // OrderEventOutbox represents an event in the outbox pattern for reliable event publishing
@@ -82,6 +91,9 @@ type OrderEventOutbox struct {
 	CreatedAt  time.Time              `json:"created_at" db:"created_at"`
 	SentAt     *time.Time             `json:"sent_at,omitempty" db:"sent_at"`
 	RetryCount int                    `json:"retry_count" db:"retry_count"`
+	// NextAttemptAt is the earliest time a failed event may be retried
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+	LastError     *string    `json:"last_error,omitempty" db:"last_error"`
 }
 
 // NewOrderEvent creates a new order event for the outbox
@@ -110,6 +122,12 @@ func (e *OrderEventOutbox) MarkAsFailed() {
 	e.RetryCount++
 }
 
+// MarkAsDeadLetter parks the event after it exhausted its retries
+func (e *OrderEventOutbox) MarkAsDeadLetter(reason string) {
+	e.Status = EventStatusDeadLetter
+	e.LastError = &reason
+}
+
 // MarkAsCancelled marks the event as cancelled
 func (e *OrderEventOutbox) MarkAsCancelled() {
 	e.Status = EventStatusCancelled
@@ -130,6 +148,22 @@ func (e *OrderEventOutbox) SetPayloadFromJSON(data []byte) error {
 	return json.Unmarshal(data, &e.Payload)
 }
 
+// OutboxStats summarizes the backlog of the event outbox for health reporting
+type OutboxStats struct {
+	PendingCount    int        `json:"pending_count"`
+	FailedCount     int        `json:"failed_count"`
+	DeadLetterCount int        `json:"dead_letter_count"`
+	OldestPendingAt *time.Time `json:"oldest_pending_at,omitempty"`
+}
+
+// Lag returns how long the oldest undelivered event has been waiting
+func (s *OutboxStats) Lag(now time.Time) time.Duration {
+	if s.OldestPendingAt == nil {
+		return 0
+	}
+	return now.Sub(*s.OldestPendingAt)
+}
+
 // OrderEvent is an alias for OrderEventOutbox to match the task specification
 type OrderEvent = OrderEventOutbox
 
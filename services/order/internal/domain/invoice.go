@@ -0,0 +1,322 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// InvoiceType is the kind of tax document issued for an order
+type InvoiceType string
+
+const (
+	// InvoiceTypeTaxInvoice is a full tax invoice, which names the buyer so they can claim input VAT
+	InvoiceTypeTaxInvoice InvoiceType = "tax_invoice"
+	// InvoiceTypeReceipt is a receipt for buyers who do not need a full tax invoice
+	InvoiceTypeReceipt InvoiceType = "receipt"
+	// InvoiceTypeCreditNote reduces the value of an earlier invoice, e.g. after a refund
+	InvoiceTypeCreditNote InvoiceType = "credit_note"
+)
+
+// HeadOfficeBranch is the branch code of a head office
+const HeadOfficeBranch = "00000"
+
+// CreditNotePurposeReturn is the e-Tax purpose code of a credit note for returned goods
+const CreditNotePurposeReturn = "CDNG05"
+
+// businessLocation is Thai time, which invoice numbers and dates and order schedules follow
+var businessLocation = time.FixedZone("ICT", 7*60*60)
+
+// IsValid reports whether the type is a known invoice type
+func (t InvoiceType) IsValid() bool {
+	switch t {
+	case InvoiceTypeTaxInvoice, InvoiceTypeReceipt, InvoiceTypeCreditNote:
+		return true
+	}
+	return false
+}
+
+// NumberPrefix is the prefix of the document numbers of the type; each type is numbered on its own
+func (t InvoiceType) NumberPrefix() string {
+	switch t {
+	case InvoiceTypeTaxInvoice:
+		return "INV"
+	case InvoiceTypeCreditNote:
+		return "CN"
+	}
+	return "RCT"
+}
+
+// InvoiceParty is the seller or buyer named on an invoice
+type InvoiceParty struct {
+	Name       string `json:"name"`
+	TaxID      string `json:"tax_id,omitempty"`
+	BranchCode string `json:"branch_code,omitempty"`
+	Address    string `json:"address,omitempty"`
+}
+
+// IsHeadOffice reports whether the party is the head office of its business
+func (p InvoiceParty) IsHeadOffice() bool {
+	return p.BranchCode == HeadOfficeBranch
+}
+
+// validateSeller checks the seller has what every tax document must show
+func (p InvoiceParty) validateSeller() error {
+	if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.Address) == "" {
+		return ErrInvalidTaxSettings
+	}
+	if !ValidTaxID(p.TaxID) {
+		return ErrInvalidTaxSettings
+	}
+	if !ValidBranchCode(p.BranchCode) {
+		return ErrInvalidBranchCode
+	}
+	return nil
+}
+
+// validateBuyer checks the buyer of a full tax invoice. Receipts only check the tax ID and
+// branch when they are given.
+func (p InvoiceParty) validateBuyer(invoiceType InvoiceType) error {
+	if invoiceType == InvoiceTypeTaxInvoice {
+		if strings.TrimSpace(p.Name) == "" || strings.TrimSpace(p.Address) == "" ||
+			p.TaxID == "" || p.BranchCode == "" {
+			return ErrBuyerDetailsRequired
+		}
+	}
+	if p.TaxID != "" && !ValidTaxID(p.TaxID) {
+		return ErrInvalidBuyerTaxID
+	}
+	if p.BranchCode != "" && !ValidBranchCode(p.BranchCode) {
+		return ErrInvalidBranchCode
+	}
+	return nil
+}
+
+// InvoiceLine is a line of an invoice. Shipping is a line without a product.
+type InvoiceLine struct {
+	LineNo      int        `json:"line_no" db:"line_no"`
+	ProductID   *uuid.UUID `json:"product_id,omitempty" db:"product_id"`
+	Description string     `json:"description" db:"description"`
+	Quantity    int        `json:"quantity" db:"quantity"`
+	UnitPrice   float64    `json:"unit_price" db:"unit_price"`
+	Amount      float64    `json:"amount" db:"amount"`
+	VATExempt   bool       `json:"vat_exempt" db:"vat_exempt"`
+}
+
+// Invoice is a tax invoice, receipt or credit note issued for an order. Amounts are copied from
+// the order when it is issued, so later edits never change an issued document.
+type Invoice struct {
+	ID         uuid.UUID    `json:"id"`
+	OrderID    uuid.UUID    `json:"order_id"`
+	ReturnID   *uuid.UUID   `json:"return_id,omitempty"`
+	Type       InvoiceType  `json:"type"`
+	Number     string       `json:"number"`
+	Sequence   int64        `json:"sequence"`
+	BranchCode string       `json:"branch_code"`
+	IssuedAt   time.Time    `json:"issued_at"`
+	Seller     InvoiceParty `json:"seller"`
+	Buyer      InvoiceParty `json:"buyer"`
+	TaxMode    TaxMode      `json:"tax_mode"`
+	VATRate    float64      `json:"vat_rate"`
+	// Subtotal is the sum of the lines as priced, before the discount
+	Subtotal      float64 `json:"subtotal"`
+	Discount      float64 `json:"discount"`
+	TaxableAmount float64 `json:"taxable_amount"`
+	ExemptAmount  float64 `json:"exempt_amount"`
+	VAT           float64 `json:"vat"`
+	Total         float64 `json:"total"`
+	// A credit note refers to the invoice it corrects and shows the value of the goods before
+	// VAT as originally invoiced and as corrected; the difference is what it credits
+	ReferenceInvoiceID *uuid.UUID    `json:"reference_invoice_id,omitempty"`
+	ReferenceNumber    *string       `json:"reference_number,omitempty"`
+	ReferenceIssuedAt  *time.Time    `json:"reference_issued_at,omitempty"`
+	ReferenceType      *InvoiceType  `json:"reference_type,omitempty"`
+	OriginalAmount     float64       `json:"original_amount,omitempty"`
+	CorrectedAmount    float64       `json:"corrected_amount,omitempty"`
+	PurposeCode        string        `json:"purpose_code,omitempty"`
+	Reason             string        `json:"reason,omitempty"`
+	CreatedAt          time.Time     `json:"created_at"`
+	Lines              []InvoiceLine `json:"lines"`
+}
+
+// CanBeInvoiced reports whether the order has reached the point where VAT is due: confirmed and
+// neither cancelled nor refunded
+func (o *Order) CanBeInvoiced() bool {
+	switch o.Status {
+	case OrderStatusPending, OrderStatusCancelled, OrderStatusRefunded:
+		return false
+	}
+	return true
+}
+
+// NewInvoice prepares a tax invoice or receipt for the order. descriptions names the ordered
+// products; a product without a name is shown by its ID. The invoice gets its number when it is
+// saved.
+func NewInvoice(order *Order, invoiceType InvoiceType, seller, buyer InvoiceParty, descriptions map[uuid.UUID]string) (*Invoice, error) {
+	if invoiceType != InvoiceTypeTaxInvoice && invoiceType != InvoiceTypeReceipt {
+		return nil, ErrInvalidInvoiceType
+	}
+	if !order.CanBeInvoiced() {
+		return nil, ErrOrderCannotBeInvoiced
+	}
+	if invoiceType == InvoiceTypeTaxInvoice && (!order.TaxEnabled || order.VATRate <= 0) {
+		return nil, ErrInvoiceNotTaxed
+	}
+	if err := seller.validateSeller(); err != nil {
+		return nil, err
+	}
+	if err := buyer.validateBuyer(invoiceType); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	breakdown := order.VATBreakdown()
+	invoice := &Invoice{
+		ID:            uuid.New(),
+		OrderID:       order.ID,
+		Type:          invoiceType,
+		BranchCode:    seller.BranchCode,
+		IssuedAt:      now,
+		Seller:        seller,
+		Buyer:         buyer,
+		TaxMode:       order.TaxMode,
+		VATRate:       order.VATRate,
+		Discount:      order.Discount,
+		TaxableAmount: breakdown.TaxableAmount,
+		ExemptAmount:  breakdown.ExemptAmount,
+		VAT:           breakdown.VAT,
+		Total:         order.TotalAmount,
+		CreatedAt:     now,
+	}
+	if !order.TaxEnabled {
+		invoice.VATRate = 0
+	}
+
+	for _, item := range order.Items {
+		productID := item.ProductID
+		description := descriptions[item.ProductID]
+		if description == "" {
+			description = item.ProductID.String()
+		}
+		invoice.addLine(InvoiceLine{
+			ProductID:   &productID,
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   item.UnitPrice,
+			Amount:      item.TotalPrice,
+			VATExempt:   item.VATExempt,
+		})
+	}
+	if order.ShippingFee > 0 {
+		invoice.addLine(InvoiceLine{
+			Description: "Shipping",
+			Quantity:    1,
+			UnitPrice:   order.ShippingFee,
+			Amount:      order.ShippingFee,
+		})
+	}
+
+	return invoice, nil
+}
+
+// NewCreditNote prepares the credit note for a refunded return against the invoice of the order.
+// previouslyCredited is the value before VAT of earlier credit notes against the same invoice.
+// Refunds are what the customer paid, so the amounts of a credit note always include VAT.
+func NewCreditNote(original *Invoice, order *Order, request *ReturnRequest, previouslyCredited float64) (*Invoice, error) {
+	if original.Type == InvoiceTypeCreditNote {
+		return nil, ErrInvalidInvoiceType
+	}
+	if request.Status != ReturnStatusRefunded || request.RefundAmount <= 0 {
+		return nil, ErrInvalidReturnStatus
+	}
+
+	exempt := make(map[uuid.UUID]bool, len(order.Items))
+	for _, item := range order.Items {
+		exempt[item.ID] = item.VATExempt
+	}
+	descriptions := make(map[uuid.UUID]string, len(original.Lines))
+	for _, line := range original.Lines {
+		if line.ProductID != nil {
+			descriptions[*line.ProductID] = line.Description
+		}
+	}
+
+	now := time.Now()
+	returnID := request.ID
+	referenceNumber := original.Number
+	referenceIssuedAt := original.IssuedAt
+	referenceType := original.Type
+	note := &Invoice{
+		ID:                 uuid.New(),
+		OrderID:            order.ID,
+		ReturnID:           &returnID,
+		Type:               InvoiceTypeCreditNote,
+		BranchCode:         original.BranchCode,
+		IssuedAt:           now,
+		Seller:             original.Seller,
+		Buyer:              original.Buyer,
+		TaxMode:            TaxModeInclusive,
+		VATRate:            original.VATRate,
+		Total:              request.RefundAmount,
+		ReferenceInvoiceID: &original.ID,
+		ReferenceNumber:    &referenceNumber,
+		ReferenceIssuedAt:  &referenceIssuedAt,
+		ReferenceType:      &referenceType,
+		PurposeCode:        CreditNotePurposeReturn,
+		Reason:             request.Reasons(),
+		CreatedAt:          now,
+	}
+
+	exemptRefund := 0.0
+	for _, item := range request.Items {
+		productID := item.ProductID
+		description := descriptions[item.ProductID]
+		if description == "" {
+			description = item.ProductID.String()
+		}
+		note.addLine(InvoiceLine{
+			ProductID:   &productID,
+			Description: description,
+			Quantity:    item.Quantity,
+			UnitPrice:   roundMoney(item.RefundAmount / float64(item.Quantity)),
+			Amount:      item.RefundAmount,
+			VATExempt:   exempt[item.OrderItemID],
+		})
+		if exempt[item.OrderItemID] {
+			exemptRefund += item.RefundAmount
+		}
+	}
+
+	taxable := roundMoney(request.RefundAmount - exemptRefund)
+	note.VAT = roundMoney(taxable * note.VATRate / (1 + note.VATRate))
+	note.TaxableAmount = roundMoney(taxable - note.VAT)
+	note.ExemptAmount = roundMoney(exemptRefund)
+	note.OriginalAmount = roundMoney(original.TaxableAmount + original.ExemptAmount - previouslyCredited)
+	note.CorrectedAmount = roundMoney(note.OriginalAmount - note.Difference())
+	return note, nil
+}
+
+// Difference is the value before VAT that a credit note takes off its invoice
+func (i *Invoice) Difference() float64 {
+	return roundMoney(i.TaxableAmount + i.ExemptAmount)
+}
+
+// SequenceYear is the year the document is numbered in; numbering restarts every year
+func (i *Invoice) SequenceYear() int {
+	return i.IssuedAt.In(businessLocation).Year()
+}
+
+// AssignNumber gives the invoice the next number of its type, branch and year, e.g.
+// INV-00000-2025-000042
+func (i *Invoice) AssignNumber(sequence int64) {
+	i.Sequence = sequence
+	i.Number = fmt.Sprintf("%s-%s-%d-%06d", i.Type.NumberPrefix(), i.BranchCode, i.SequenceYear(), sequence)
+}
+
+func (i *Invoice) addLine(line InvoiceLine) {
+	line.LineNo = len(i.Lines) + 1
+	i.Lines = append(i.Lines, line)
+	i.Subtotal = roundMoney(i.Subtotal + line.Amount)
+}
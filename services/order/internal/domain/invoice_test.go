@@ -0,0 +1,190 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSeller = InvoiceParty{
+		Name:       "Saan Trading Co., Ltd.",
+		TaxID:      "0105536000313",
+		BranchCode: HeadOfficeBranch,
+		Address:    "1 Sukhumvit Rd, Khlong Toei, Bangkok 10110",
+	}
+	testBuyer = InvoiceParty{
+		Name:       "Buyer Co., Ltd.",
+		TaxID:      "1234567890121",
+		BranchCode: "00001",
+		Address:    "99 Silom Rd, Bang Rak, Bangkok 10500",
+	}
+)
+
+func newTaxedOrder(t *testing.T) *Order {
+	order := newConfirmedOrder()
+	order.Items[1].VATExempt = true
+	require.NoError(t, order.SetShippingFee(50))
+	require.NoError(t, order.SetVAT(DefaultVATRate, TaxModeExclusive))
+	return order
+}
+
+func TestNewInvoiceCopiesOrderAmounts(t *testing.T) {
+	order := newTaxedOrder(t)
+	names := map[uuid.UUID]string{order.Items[0].ProductID: "Jasmine rice 5kg"}
+
+	invoice, err := NewInvoice(order, InvoiceTypeTaxInvoice, testSeller, testBuyer, names)
+	require.NoError(t, err)
+
+	// 200 of taxable items and 50 shipping, 150 of exempt items
+	assert.Equal(t, 250.0, invoice.TaxableAmount)
+	assert.Equal(t, 150.0, invoice.ExemptAmount)
+	assert.Equal(t, 17.5, invoice.VAT)
+	assert.Equal(t, order.TotalAmount, invoice.Total)
+	assert.Equal(t, 400.0, invoice.Subtotal)
+	assert.Equal(t, HeadOfficeBranch, invoice.BranchCode)
+
+	require.Len(t, invoice.Lines, 3)
+	assert.Equal(t, "Jasmine rice 5kg", invoice.Lines[0].Description)
+	assert.Equal(t, order.Items[1].ProductID.String(), invoice.Lines[1].Description)
+	assert.True(t, invoice.Lines[1].VATExempt)
+	assert.Nil(t, invoice.Lines[2].ProductID, "shipping has no product")
+	assert.Equal(t, 3, invoice.Lines[2].LineNo)
+}
+
+func TestNewInvoiceRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		invoiceType InvoiceType
+		modify      func(order *Order, seller, buyer *InvoiceParty)
+		err         error
+	}{
+		{name: "tax invoice", invoiceType: InvoiceTypeTaxInvoice, modify: func(*Order, *InvoiceParty, *InvoiceParty) {}},
+		{
+			name:        "receipt without buyer",
+			invoiceType: InvoiceTypeReceipt,
+			modify:      func(_ *Order, _, buyer *InvoiceParty) { *buyer = InvoiceParty{} },
+		},
+		{
+			name:        "tax invoice without buyer tax ID",
+			invoiceType: InvoiceTypeTaxInvoice,
+			modify:      func(_ *Order, _, buyer *InvoiceParty) { buyer.TaxID = "" },
+			err:         ErrBuyerDetailsRequired,
+		},
+		{
+			name:        "tax invoice without buyer branch",
+			invoiceType: InvoiceTypeTaxInvoice,
+			modify:      func(_ *Order, _, buyer *InvoiceParty) { buyer.BranchCode = "" },
+			err:         ErrBuyerDetailsRequired,
+		},
+		{
+			name:        "wrong buyer check digit",
+			invoiceType: InvoiceTypeReceipt,
+			modify:      func(_ *Order, _, buyer *InvoiceParty) { buyer.TaxID = "1234567890122" },
+			err:         ErrInvalidBuyerTaxID,
+		},
+		{
+			name:        "bad buyer branch",
+			invoiceType: InvoiceTypeTaxInvoice,
+			modify:      func(_ *Order, _, buyer *InvoiceParty) { buyer.BranchCode = "1" },
+			err:         ErrInvalidBranchCode,
+		},
+		{
+			name:        "seller without tax ID",
+			invoiceType: InvoiceTypeReceipt,
+			modify:      func(_ *Order, seller, _ *InvoiceParty) { seller.TaxID = "" },
+			err:         ErrInvalidTaxSettings,
+		},
+		{
+			name:        "pending order",
+			invoiceType: InvoiceTypeReceipt,
+			modify:      func(order *Order, _, _ *InvoiceParty) { order.Status = OrderStatusPending },
+			err:         ErrOrderCannotBeInvoiced,
+		},
+		{
+			name:        "untaxed order",
+			invoiceType: InvoiceTypeTaxInvoice,
+			modify:      func(order *Order, _, _ *InvoiceParty) { order.TaxEnabled = false },
+			err:         ErrInvoiceNotTaxed,
+		},
+		{
+			name:        "credit note",
+			invoiceType: InvoiceTypeCreditNote,
+			modify:      func(*Order, *InvoiceParty, *InvoiceParty) {},
+			err:         ErrInvalidInvoiceType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newTaxedOrder(t)
+			seller, buyer := testSeller, testBuyer
+			tt.modify(order, &seller, &buyer)
+
+			_, err := NewInvoice(order, tt.invoiceType, seller, buyer, nil)
+			if tt.err == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func TestInvoiceNumbering(t *testing.T) {
+	order := newTaxedOrder(t)
+	invoice, err := NewInvoice(order, InvoiceTypeReceipt, testSeller, InvoiceParty{}, nil)
+	require.NoError(t, err)
+
+	// Just after midnight on New Year in Bangkok is still the old year in UTC
+	invoice.IssuedAt = time.Date(2024, 12, 31, 17, 30, 0, 0, time.UTC)
+	invoice.AssignNumber(42)
+
+	assert.Equal(t, 2025, invoice.SequenceYear())
+	assert.Equal(t, "RCT-00000-2025-000042", invoice.Number)
+	assert.Equal(t, int64(42), invoice.Sequence)
+}
+
+func TestNewCreditNoteForRefundedReturn(t *testing.T) {
+	order := newDeliveredOrder(t)
+	order.Items[1].VATExempt = true
+	require.NoError(t, order.SetVAT(DefaultVATRate, TaxModeExclusive))
+
+	invoice, err := NewInvoice(order, InvoiceTypeTaxInvoice, testSeller, testBuyer, nil)
+	require.NoError(t, err)
+	invoice.AssignNumber(7)
+
+	request, err := order.RequestReturn([]ReturnLine{
+		{ItemID: order.Items[0].ID, Quantity: 1, Reason: ReturnReasonDamaged},
+		{ItemID: order.Items[1].ID, Quantity: 2, Reason: ReturnReasonChangedMind},
+	}, "")
+	require.NoError(t, err)
+	request.Status = ReturnStatusRefunded
+
+	note, err := NewCreditNote(invoice, order, request, 50)
+	require.NoError(t, err)
+
+	assert.Equal(t, InvoiceTypeCreditNote, note.Type)
+	assert.Equal(t, &request.ID, note.ReturnID)
+	assert.Equal(t, invoice.Number, *note.ReferenceNumber)
+	assert.Equal(t, testBuyer, note.Buyer)
+	assert.Equal(t, CreditNotePurposeReturn, note.PurposeCode)
+	assert.Equal(t, "damaged, changed_mind", note.Reason)
+
+	// 107 refunded for the taxed item contains 7 of VAT; 100 for the exempt items has none
+	assert.Equal(t, 207.0, note.Total)
+	assert.Equal(t, 7.0, note.VAT)
+	assert.Equal(t, 100.0, note.TaxableAmount)
+	assert.Equal(t, 100.0, note.ExemptAmount)
+	assert.Equal(t, 200.0, note.Difference())
+	assert.Equal(t, 300.0, note.OriginalAmount, "350 invoiced less 50 credited before")
+	assert.Equal(t, 100.0, note.CorrectedAmount)
+	require.Len(t, note.Lines, 2)
+	assert.True(t, note.Lines[1].VATExempt)
+
+	_, err = NewCreditNote(note, order, request, 0)
+	assert.ErrorIs(t, err, ErrInvalidInvoiceType)
+}
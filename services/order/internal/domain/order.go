@@ -18,6 +18,10 @@ const (
 	OrderStatusCompleted  OrderStatus = "completed"
 	OrderStatusCancelled  OrderStatus = "cancelled"
 	OrderStatusRefunded   OrderStatus = "refunded"
+
+	// Partial statuses are derived from item quantities when an order goes out in several shipments
+	OrderStatusPartiallyShipped   OrderStatus = "partially_shipped"
+	OrderStatusPartiallyDelivered OrderStatus = "partially_delivered"
 )
 
 // OrderSource represents the source channel of an order
@@ -53,6 +57,45 @@ const (
 	PaymentMethodInstallment  PaymentMethod = "installment"
 )
 
+// IsValid reports whether the status is a known order status
+func (s OrderStatus) IsValid() bool {
+	switch s {
+	case OrderStatusPending, OrderStatusConfirmed, OrderStatusProcessing, OrderStatusShipped,
+		OrderStatusDelivered, OrderStatusCompleted, OrderStatusCancelled, OrderStatusRefunded,
+		OrderStatusPartiallyShipped, OrderStatusPartiallyDelivered:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the source is a known sales channel
+func (s OrderSource) IsValid() bool {
+	switch s {
+	case OrderSourceOnline, OrderSourcePOS, OrderSourceMarketplace, OrderSourceLINE, OrderSourceFacebook:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the paid status is a known payment status
+func (s PaidStatus) IsValid() bool {
+	switch s {
+	case PaidStatusUnpaid, PaidStatusPaid, PaidStatusPartialPaid, PaidStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// IsValid reports whether the payment method is a known payment method
+func (m PaymentMethod) IsValid() bool {
+	switch m {
+	case PaymentMethodCash, PaymentMethodCreditCard, PaymentMethodBankTransfer, PaymentMethodQRCode,
+		PaymentMethodWallet, PaymentMethodInstallment:
+		return true
+	}
+	return false
+}
+
 // OrderItem represents an item in an order
 type OrderItem struct {
 	ID             uuid.UUID `json:"id" db:"id"`
@@ -63,8 +106,21 @@ type OrderItem struct {
 	TotalPrice     float64   `json:"total_price" db:"total_price"`
 	IsOverride     bool      `json:"is_override" db:"is_override"`
 	OverrideReason *string   `json:"override_reason,omitempty" db:"override_reason"`
-	CreatedAt      time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at" db:"updated_at"`
+	VATExempt      bool      `json:"vat_exempt" db:"vat_exempt"`
+	// PriceOverridden items are sold at a unit price set by staff instead of the priced one
+	PriceOverridden     bool    `json:"price_overridden" db:"price_overridden"`
+	PriceOverrideReason *string `json:"price_override_reason,omitempty" db:"price_override_reason"`
+	// MarginApprovedBy is the manager who approved selling the item below cost or the minimum margin
+	MarginApprovedBy *string `json:"margin_approved_by,omitempty" db:"margin_approved_by"`
+	// ShippedQuantity has left in shipments, FulfilledQuantity has been delivered,
+	// BackorderedQuantity waits for stock while the rest of the order ships and
+	// ReturnedQuantity has been delivered and is being returned or was returned
+	ShippedQuantity     int       `json:"shipped_quantity" db:"shipped_quantity"`
+	FulfilledQuantity   int       `json:"fulfilled_quantity" db:"fulfilled_quantity"`
+	BackorderedQuantity int       `json:"backordered_quantity" db:"backordered_quantity"`
+	ReturnedQuantity    int       `json:"returned_quantity" db:"returned_quantity"`
+	CreatedAt           time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at" db:"updated_at"`
 }
 
 // Order represents an order in the system
@@ -80,6 +136,10 @@ type Order struct {
 	ShippingFee      float64        `json:"shipping_fee" db:"shipping_fee"`
 	Tax              float64        `json:"tax" db:"tax"`
 	TaxEnabled       bool           `json:"tax_enabled" db:"tax_enabled"`
+	TaxMode          TaxMode        `json:"tax_mode" db:"tax_mode"`
+	VATRate          float64        `json:"vat_rate" db:"vat_rate"`
+	// CustomerGroup is the pricing group the order's items are priced for, such as wholesale
+	CustomerGroup    string         `json:"customer_group,omitempty" db:"customer_group"`
 	ShippingAddress  string         `json:"shipping_address" db:"shipping_address"`
 	BillingAddress   string         `json:"billing_address" db:"billing_address"`
 	PaymentMethod    *PaymentMethod `json:"payment_method,omitempty" db:"payment_method"`
@@ -90,6 +150,8 @@ type Order struct {
 	CancelledReason  *string        `json:"cancelled_reason,omitempty" db:"cancelled_reason"`
 	CreatedAt        time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at" db:"updated_at"`
+	// Revision counts the edits made to the order, starting at 1
+	Revision         int            `json:"revision" db:"revision"`
 	Items            []OrderItem    `json:"items,omitempty"`
 }
 
@@ -107,11 +169,13 @@ func NewOrder(customerID uuid.UUID, shippingAddress, billingAddress, notes strin
 		ShippingFee:     0,
 		Tax:             0,
 		TaxEnabled:      true,
+		TaxMode:         TaxModeExclusive,
 		ShippingAddress: shippingAddress,
 		BillingAddress:  billingAddress,
 		Notes:           notes,
 		CreatedAt:       now,
 		UpdatedAt:       now,
+		Revision:        1,
 		Items:           []OrderItem{},
 	}
 }
@@ -154,16 +218,42 @@ func (o *Order) AddItemWithOverride(productID uuid.UUID, quantity int, unitPrice
 	o.UpdatedAt = time.Now()
 }
 
-// CalculateTotal recalculates the total amount of the order including shipping and tax
+// SetItemPrices sets the unit prices of the items, given in item order, and recalculates the total
+func (o *Order) SetItemPrices(unitPrices []float64) error {
+	if len(unitPrices) != len(o.Items) {
+		return ErrInvalidOrderData
+	}
+	for _, price := range unitPrices {
+		if price < 0 {
+			return ErrInvalidPrice
+		}
+	}
+
+	for i := range o.Items {
+		o.Items[i].UnitPrice = unitPrices[i]
+		o.Items[i].TotalPrice = float64(o.Items[i].Quantity) * unitPrices[i]
+	}
+	o.CalculateTotal()
+	o.UpdatedAt = time.Now()
+	return nil
+}
+
+// CalculateTotal recalculates the VAT and the total amount of the order. Exclusive prices have
+// the VAT added on top; inclusive prices already contain it.
 func (o *Order) CalculateTotal() {
 	itemsTotal := 0.0
 	for _, item := range o.Items {
 		itemsTotal += item.TotalPrice
 	}
 	
-	// Calculate final total: (items - discount) + shipping + tax
+	o.Tax = o.VATBreakdown().VAT
+	
+	// Calculate final total: (items - discount) + shipping, plus tax when it is not included
 	subtotal := itemsTotal - o.Discount
-	o.TotalAmount = subtotal + o.ShippingFee + o.Tax
+	o.TotalAmount = subtotal + o.ShippingFee
+	if o.TaxMode != TaxModeInclusive {
+		o.TotalAmount += o.Tax
+	}
 	o.UpdatedAt = time.Now()
 }
 
@@ -180,13 +270,15 @@ func (o *Order) UpdateStatus(status OrderStatus) error {
 // IsValidStatusTransition checks if a status transition is valid
 func (o *Order) IsValidStatusTransition(from, to OrderStatus) bool {
 	validTransitions := map[OrderStatus][]OrderStatus{
-		OrderStatusPending:    {OrderStatusConfirmed, OrderStatusCancelled},
-		OrderStatusConfirmed:  {OrderStatusProcessing, OrderStatusCancelled},
-		OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled},
-		OrderStatusShipped:    {OrderStatusDelivered},
-		OrderStatusDelivered:  {OrderStatusRefunded},
-		OrderStatusCancelled:  {},
-		OrderStatusRefunded:   {},
+		OrderStatusPending:            {OrderStatusConfirmed, OrderStatusCancelled},
+		OrderStatusConfirmed:          {OrderStatusProcessing, OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusCancelled},
+		OrderStatusProcessing:         {OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusCancelled},
+		OrderStatusPartiallyShipped:   {OrderStatusShipped, OrderStatusPartiallyDelivered},
+		OrderStatusShipped:            {OrderStatusPartiallyDelivered, OrderStatusDelivered},
+		OrderStatusPartiallyDelivered: {OrderStatusDelivered},
+		OrderStatusDelivered:          {OrderStatusRefunded},
+		OrderStatusCancelled:          {},
+		OrderStatusRefunded:           {},
 	}
 	
 	allowed, exists := validTransitions[from]
@@ -244,15 +336,15 @@ func (o *Order) SetShippingFee(fee float64) error {
 	return nil
 }
 
-// CalculateTax calculates tax based on the subtotal
-func (o *Order) CalculateTax(taxRate float64) {
-	if o.TaxEnabled && taxRate > 0 {
-		subtotal := o.GetSubtotal()
-		o.Tax = subtotal * taxRate
-	} else {
-		o.Tax = 0
+// SetVAT sets the VAT rate and pricing mode of the order and recalculates its tax
+func (o *Order) SetVAT(rate float64, mode TaxMode) error {
+	if rate < 0 || rate >= 1 || !mode.IsValid() {
+		return ErrInvalidTaxSettings
 	}
+	o.VATRate = rate
+	o.TaxMode = mode
 	o.CalculateTotal()
+	return nil
 }
 
 // GetSubtotal returns the subtotal (items total - discount)
@@ -295,6 +387,22 @@ func (o *Order) CancelOrder(reason string) error {
 	return nil
 }
 
+// ConsumedStockItems returns the items whose stock was consumed when the order was confirmed and
+// has not left the warehouse yet, which cancelling the order puts back. Stock overrides never
+// consumed any stock.
+func (o *Order) ConsumedStockItems() []OrderItem {
+	if o.Status != OrderStatusConfirmed && o.Status != OrderStatusProcessing {
+		return nil
+	}
+	var items []OrderItem
+	for _, item := range o.Items {
+		if !item.IsOverride {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
 // UpdatePaidStatus updates the payment status of the order
 func (o *Order) UpdatePaidStatus(status PaidStatus) error {
 	// Validate payment status transitions
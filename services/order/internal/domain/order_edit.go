@@ -0,0 +1,267 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderEdit describes changes to an order. Nil and empty fields are left unchanged.
+type OrderEdit struct {
+	AddItems        []NewOrderItem
+	RemoveItemIDs   []uuid.UUID
+	UpdateItems     []OrderItemQuantity
+	ShippingAddress *string
+	Discount        *float64
+}
+
+// NewOrderItem is an item to add to an order
+type NewOrderItem struct {
+	ProductID uuid.UUID
+	Quantity  int
+	UnitPrice float64
+	VATExempt bool
+	// Price overrides and margin approvals, as on OrderItem
+	PriceOverridden     bool
+	PriceOverrideReason *string
+	MarginApprovedBy    *string
+}
+
+// OrderItemQuantity sets the quantity of an order item; zero removes the item
+type OrderItemQuantity struct {
+	ItemID   uuid.UUID
+	Quantity int
+}
+
+// OrderDiff records what an edit changed, as stored with each order revision
+type OrderDiff struct {
+	ItemsAdded      []OrderItemDiff `json:"items_added,omitempty"`
+	ItemsRemoved    []OrderItemDiff `json:"items_removed,omitempty"`
+	ItemsChanged    []OrderItemDiff `json:"items_changed,omitempty"`
+	ShippingAddress *FieldChange    `json:"shipping_address,omitempty"`
+	Discount        *FieldChange    `json:"discount,omitempty"`
+	Tax             *FieldChange    `json:"tax,omitempty"`
+	TotalAmount     *FieldChange    `json:"total_amount,omitempty"`
+}
+
+// OrderItemDiff is a change to one order item. Added items have an old quantity of zero and
+// removed items a new quantity of zero.
+type OrderItemDiff struct {
+	ItemID      uuid.UUID `json:"item_id"`
+	ProductID   uuid.UUID `json:"product_id"`
+	OldQuantity int       `json:"old_quantity"`
+	NewQuantity int       `json:"new_quantity"`
+	UnitPrice   float64   `json:"unit_price"`
+}
+
+// FieldChange is the old and new value of a changed field
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// IsEmpty reports whether the edit changed nothing
+func (d *OrderDiff) IsEmpty() bool {
+	return !d.HasItemChanges() && d.ShippingAddress == nil && d.Discount == nil
+}
+
+// HasItemChanges reports whether items were added, removed or changed
+func (d *OrderDiff) HasItemChanges() bool {
+	return len(d.ItemsAdded) > 0 || len(d.ItemsRemoved) > 0 || len(d.ItemsChanged) > 0
+}
+
+// StockDeltas returns the change in ordered quantity per product, skipping stock overrides
+func (d *OrderDiff) StockDeltas(items []OrderItem) map[uuid.UUID]int {
+	overrides := make(map[uuid.UUID]bool)
+	for _, item := range items {
+		if item.IsOverride {
+			overrides[item.ID] = true
+		}
+	}
+
+	deltas := make(map[uuid.UUID]int)
+	for _, changes := range [][]OrderItemDiff{d.ItemsAdded, d.ItemsRemoved, d.ItemsChanged} {
+		for _, change := range changes {
+			if overrides[change.ItemID] {
+				continue
+			}
+			deltas[change.ProductID] += change.NewQuantity - change.OldQuantity
+		}
+	}
+	for productID, delta := range deltas {
+		if delta == 0 {
+			delete(deltas, productID)
+		}
+	}
+	return deltas
+}
+
+// ToMap converts the diff to the generic form stored in audit logs and event payloads
+func (d *OrderDiff) ToMap() map[string]interface{} {
+	data, _ := json.Marshal(d)
+	result := make(map[string]interface{})
+	_ = json.Unmarshal(data, &result)
+	return result
+}
+
+// IsEditable reports whether the order's items, address and discount may still change
+func (o *Order) IsEditable() bool {
+	return o.Status == OrderStatusPending || o.Status == OrderStatusConfirmed
+}
+
+// ApplyEdit applies the edit, recalculates the VAT and total and moves the order to its next revision.
+// Nothing changes when the edit is invalid; an edit that changes nothing keeps the revision.
+func (o *Order) ApplyEdit(edit OrderEdit) (*OrderDiff, error) {
+	if !o.IsEditable() {
+		return nil, ErrOrderCannotBeModified
+	}
+
+	diff := &OrderDiff{}
+	now := time.Now()
+
+	items := make([]OrderItem, len(o.Items))
+	copy(items, o.Items)
+	index := make(map[uuid.UUID]int, len(items))
+	for i, item := range items {
+		index[item.ID] = i
+	}
+
+	removed := make(map[uuid.UUID]bool)
+	remove := func(itemID uuid.UUID) error {
+		i, ok := index[itemID]
+		if !ok || removed[itemID] {
+			return ErrOrderItemNotFound
+		}
+		removed[itemID] = true
+		item := items[i]
+		diff.ItemsRemoved = append(diff.ItemsRemoved, OrderItemDiff{
+			ItemID:      item.ID,
+			ProductID:   item.ProductID,
+			OldQuantity: item.Quantity,
+			UnitPrice:   item.UnitPrice,
+		})
+		return nil
+	}
+
+	for _, itemID := range edit.RemoveItemIDs {
+		if err := remove(itemID); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, update := range edit.UpdateItems {
+		if update.Quantity < 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if update.Quantity == 0 {
+			if err := remove(update.ItemID); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		i, ok := index[update.ItemID]
+		if !ok || removed[update.ItemID] {
+			return nil, ErrOrderItemNotFound
+		}
+		item := &items[i]
+		if item.Quantity == update.Quantity {
+			continue
+		}
+		diff.ItemsChanged = append(diff.ItemsChanged, OrderItemDiff{
+			ItemID:      item.ID,
+			ProductID:   item.ProductID,
+			OldQuantity: item.Quantity,
+			NewQuantity: update.Quantity,
+			UnitPrice:   item.UnitPrice,
+		})
+		item.Quantity = update.Quantity
+		item.TotalPrice = float64(item.Quantity) * item.UnitPrice
+		item.UpdatedAt = now
+	}
+
+	edited := make([]OrderItem, 0, len(items)+len(edit.AddItems))
+	for _, item := range items {
+		if !removed[item.ID] {
+			edited = append(edited, item)
+		}
+	}
+
+	for _, add := range edit.AddItems {
+		if add.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if add.UnitPrice < 0 {
+			return nil, ErrInvalidPrice
+		}
+		item := OrderItem{
+			ID:         uuid.New(),
+			OrderID:    o.ID,
+			ProductID:  add.ProductID,
+			Quantity:   add.Quantity,
+			UnitPrice:  add.UnitPrice,
+			TotalPrice: float64(add.Quantity) * add.UnitPrice,
+			VATExempt:  add.VATExempt,
+			CreatedAt:  now,
+			UpdatedAt:  now,
+
+			PriceOverridden:     add.PriceOverridden,
+			PriceOverrideReason: add.PriceOverrideReason,
+			MarginApprovedBy:    add.MarginApprovedBy,
+		}
+		edited = append(edited, item)
+		diff.ItemsAdded = append(diff.ItemsAdded, OrderItemDiff{
+			ItemID:      item.ID,
+			ProductID:   item.ProductID,
+			NewQuantity: item.Quantity,
+			UnitPrice:   item.UnitPrice,
+		})
+	}
+
+	// An order without items should be cancelled instead
+	if len(edited) == 0 {
+		return nil, ErrInvalidOrderData
+	}
+
+	shippingAddress := o.ShippingAddress
+	if edit.ShippingAddress != nil && *edit.ShippingAddress != o.ShippingAddress {
+		if *edit.ShippingAddress == "" {
+			return nil, ErrInvalidOrderData
+		}
+		diff.ShippingAddress = &FieldChange{Old: o.ShippingAddress, New: *edit.ShippingAddress}
+		shippingAddress = *edit.ShippingAddress
+	}
+
+	discount := o.Discount
+	if edit.Discount != nil && *edit.Discount != o.Discount {
+		diff.Discount = &FieldChange{Old: o.Discount, New: *edit.Discount}
+		discount = *edit.Discount
+	}
+	itemsTotal := 0.0
+	for _, item := range edited {
+		itemsTotal += item.TotalPrice
+	}
+	if discount < 0 || discount > itemsTotal {
+		return nil, ErrInvalidAmount
+	}
+
+	if diff.IsEmpty() {
+		return diff, nil
+	}
+
+	oldTotal, oldTax := o.TotalAmount, o.Tax
+	o.Items = edited
+	o.ShippingAddress = shippingAddress
+	o.Discount = discount
+	o.CalculateTotal()
+	if o.Tax != oldTax {
+		diff.Tax = &FieldChange{Old: oldTax, New: o.Tax}
+	}
+	if o.TotalAmount != oldTotal {
+		diff.TotalAmount = &FieldChange{Old: oldTotal, New: o.TotalAmount}
+	}
+	o.Revision++
+
+	return diff, nil
+}
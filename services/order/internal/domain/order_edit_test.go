@@ -0,0 +1,166 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newEditableOrder() *Order {
+	order := NewOrder(uuid.New(), "1 Old Road", "1 Old Road", "")
+	order.AddItem(uuid.New(), 2, 100)
+	order.AddItem(uuid.New(), 1, 50)
+	return order
+}
+
+func TestApplyEditChangesItemsAndRecalculatesTotal(t *testing.T) {
+	order := newEditableOrder()
+	kept, dropped := order.Items[0], order.Items[1]
+	added := uuid.New()
+	address := "2 New Road"
+	discount := 30.0
+
+	diff, err := order.ApplyEdit(OrderEdit{
+		AddItems:        []NewOrderItem{{ProductID: added, Quantity: 3, UnitPrice: 10}},
+		RemoveItemIDs:   []uuid.UUID{dropped.ID},
+		UpdateItems:     []OrderItemQuantity{{ItemID: kept.ID, Quantity: 5}},
+		ShippingAddress: &address,
+		Discount:        &discount,
+	})
+	require.NoError(t, err)
+
+	require.Len(t, order.Items, 2)
+	assert.Equal(t, 5, order.Items[0].Quantity)
+	assert.Equal(t, 500.0, order.Items[0].TotalPrice)
+	assert.Equal(t, added, order.Items[1].ProductID)
+	assert.Equal(t, 500.0, order.TotalAmount)
+	assert.Equal(t, address, order.ShippingAddress)
+	assert.Equal(t, 2, order.Revision)
+
+	require.Len(t, diff.ItemsAdded, 1)
+	require.Len(t, diff.ItemsRemoved, 1)
+	require.Len(t, diff.ItemsChanged, 1)
+	assert.Equal(t, dropped.ID, diff.ItemsRemoved[0].ItemID)
+	assert.Equal(t, 2, diff.ItemsChanged[0].OldQuantity)
+	assert.Equal(t, 5, diff.ItemsChanged[0].NewQuantity)
+	assert.Equal(t, &FieldChange{Old: "1 Old Road", New: address}, diff.ShippingAddress)
+	assert.Equal(t, &FieldChange{Old: 250.0, New: 500.0}, diff.TotalAmount)
+
+	assert.Equal(t, map[uuid.UUID]int{
+		kept.ProductID:    3,
+		dropped.ProductID: -1,
+		added:             3,
+	}, diff.StockDeltas(order.Items))
+}
+
+func TestApplyEditZeroQuantityRemovesItem(t *testing.T) {
+	order := newEditableOrder()
+	itemID := order.Items[1].ID
+
+	diff, err := order.ApplyEdit(OrderEdit{UpdateItems: []OrderItemQuantity{{ItemID: itemID, Quantity: 0}}})
+	require.NoError(t, err)
+
+	require.Len(t, order.Items, 1)
+	require.Len(t, diff.ItemsRemoved, 1)
+	assert.Equal(t, itemID, diff.ItemsRemoved[0].ItemID)
+	assert.Equal(t, 200.0, order.TotalAmount)
+}
+
+func TestApplyEditWithoutChangesKeepsRevision(t *testing.T) {
+	order := newEditableOrder()
+	address := order.ShippingAddress
+
+	diff, err := order.ApplyEdit(OrderEdit{
+		ShippingAddress: &address,
+		UpdateItems:     []OrderItemQuantity{{ItemID: order.Items[0].ID, Quantity: 2}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, diff.IsEmpty())
+	assert.Equal(t, 1, order.Revision)
+}
+
+func TestApplyEditRejectsInvalidEditsWithoutChangingOrder(t *testing.T) {
+	negative := -1.0
+	tooLarge := 1000.0
+	empty := ""
+
+	tests := []struct {
+		name   string
+		status OrderStatus
+		edit   func(order *Order) OrderEdit
+		err    error
+	}{
+		{
+			name:   "shipped order",
+			status: OrderStatusShipped,
+			edit:   func(order *Order) OrderEdit { return OrderEdit{} },
+			err:    ErrOrderCannotBeModified,
+		},
+		{
+			name: "unknown item",
+			edit: func(order *Order) OrderEdit {
+				return OrderEdit{RemoveItemIDs: []uuid.UUID{uuid.New()}}
+			},
+			err: ErrOrderItemNotFound,
+		},
+		{
+			name: "item removed twice",
+			edit: func(order *Order) OrderEdit {
+				return OrderEdit{
+					RemoveItemIDs: []uuid.UUID{order.Items[0].ID},
+					UpdateItems:   []OrderItemQuantity{{ItemID: order.Items[0].ID, Quantity: 4}},
+				}
+			},
+			err: ErrOrderItemNotFound,
+		},
+		{
+			name: "negative quantity",
+			edit: func(order *Order) OrderEdit {
+				return OrderEdit{UpdateItems: []OrderItemQuantity{{ItemID: order.Items[0].ID, Quantity: -1}}}
+			},
+			err: ErrInvalidQuantity,
+		},
+		{
+			name: "all items removed",
+			edit: func(order *Order) OrderEdit {
+				return OrderEdit{RemoveItemIDs: []uuid.UUID{order.Items[0].ID, order.Items[1].ID}}
+			},
+			err: ErrInvalidOrderData,
+		},
+		{
+			name: "empty shipping address",
+			edit: func(order *Order) OrderEdit { return OrderEdit{ShippingAddress: &empty} },
+			err:  ErrInvalidOrderData,
+		},
+		{
+			name: "negative discount",
+			edit: func(order *Order) OrderEdit { return OrderEdit{Discount: &negative} },
+			err:  ErrInvalidAmount,
+		},
+		{
+			name: "discount above items total",
+			edit: func(order *Order) OrderEdit { return OrderEdit{Discount: &tooLarge} },
+			err:  ErrInvalidAmount,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newEditableOrder()
+			if tt.status != "" {
+				order.Status = tt.status
+			}
+			items := append([]OrderItem(nil), order.Items...)
+
+			_, err := order.ApplyEdit(tt.edit(order))
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, items, order.Items)
+			assert.Equal(t, 250.0, order.TotalAmount)
+			assert.Equal(t, 1, order.Revision)
+		})
+	}
+}
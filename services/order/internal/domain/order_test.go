@@ -0,0 +1,41 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancellingConfirmedOrderPutsBackConsumedStock(t *testing.T) {
+	order := NewOrder(uuid.New(), "1 Old Road", "1 Old Road", "")
+	order.AddItem(uuid.New(), 2, 100)
+	reason := "out of stock at the supplier"
+	order.AddItemWithOverride(uuid.New(), 1, 50, true, &reason)
+	order.AddItem(uuid.New(), 3, 10)
+
+	// A pending order only holds a reservation, which is released
+	assert.Empty(t, order.ConsumedStockItems())
+
+	require.NoError(t, order.ConfirmOrder())
+	items := order.ConsumedStockItems()
+	require.Len(t, items, 2)
+	assert.Equal(t, order.Items[0].ProductID, items[0].ProductID)
+	assert.Equal(t, 2, items[0].Quantity)
+	assert.Equal(t, order.Items[2].ProductID, items[1].ProductID)
+	assert.Equal(t, 3, items[1].Quantity)
+
+	order.Status = OrderStatusProcessing
+	assert.Len(t, order.ConsumedStockItems(), 2)
+}
+
+func TestShippedOrderHasNoStockToPutBack(t *testing.T) {
+	order := NewOrder(uuid.New(), "1 Old Road", "1 Old Road", "")
+	order.AddItem(uuid.New(), 2, 100)
+
+	for _, status := range []OrderStatus{OrderStatusPartiallyShipped, OrderStatusShipped, OrderStatusDelivered, OrderStatusCancelled} {
+		order.Status = status
+		assert.Empty(t, order.ConsumedStockItems(), status)
+	}
+}
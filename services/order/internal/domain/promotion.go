@@ -0,0 +1,379 @@
+package domain
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionRewardType is what a promotion gives the customer
+type PromotionRewardType string
+
+const (
+	// PromotionRewardPercent takes Value percent off the eligible items
+	PromotionRewardPercent PromotionRewardType = "percent"
+	// PromotionRewardFixed takes Value baht off the eligible items
+	PromotionRewardFixed PromotionRewardType = "fixed"
+	// PromotionRewardFreeShipping waives the shipping fee
+	PromotionRewardFreeShipping PromotionRewardType = "free_shipping"
+)
+
+// IsValid reports whether the reward type is one of the known types
+func (t PromotionRewardType) IsValid() bool {
+	switch t {
+	case PromotionRewardPercent, PromotionRewardFixed, PromotionRewardFreeShipping:
+		return true
+	}
+	return false
+}
+
+// CustomerTier is a loyalty tier of the customer service, from 1 (Bronze) to 5 (Diamond)
+type CustomerTier int
+
+const (
+	TierBronze   CustomerTier = 1
+	TierSilver   CustomerTier = 2
+	TierGold     CustomerTier = 3
+	TierPlatinum CustomerTier = 4
+	TierDiamond  CustomerTier = 5
+)
+
+// MaxPromotionsPerOrder is how many codes can be stacked on one order
+const MaxPromotionsPerOrder = 3
+
+var promoCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,20}$`)
+
+// NormalizePromoCode returns the code as it is stored: trimmed and upper case
+func NormalizePromoCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// Promotion is a promo code with its reward and the rules an order must meet to use it.
+// A promotion scoped to products or categories only discounts the matching items; an unscoped
+// one discounts the whole order.
+type Promotion struct {
+	ID          uuid.UUID           `json:"id" db:"id"`
+	Code        string              `json:"code" db:"code"`
+	Description string              `json:"description" db:"description"`
+	RewardType  PromotionRewardType `json:"reward_type" db:"reward_type"`
+	// Value is a percentage for percent rewards and an amount for fixed rewards
+	Value float64 `json:"value" db:"value"`
+	// MaxDiscount caps a percent reward
+	MaxDiscount *float64 `json:"max_discount,omitempty" db:"max_discount"`
+	// MinSpend is compared with the items total of the order before any discount
+	MinSpend float64    `json:"min_spend" db:"min_spend"`
+	StartsAt *time.Time `json:"starts_at,omitempty" db:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at,omitempty" db:"ends_at"`
+	// UsageLimit and PerCustomerLimit count the redemptions of orders that were not cancelled
+	UsageLimit       *int        `json:"usage_limit,omitempty" db:"usage_limit"`
+	PerCustomerLimit *int        `json:"per_customer_limit,omitempty" db:"per_customer_limit"`
+	UsedCount        int         `json:"used_count" db:"used_count"`
+	ProductIDs       []uuid.UUID `json:"product_ids,omitempty" db:"-"`
+	CategoryIDs      []uuid.UUID `json:"category_ids,omitempty" db:"-"`
+	// MinTier makes the code VIP only; zero means every customer
+	MinTier CustomerTier `json:"min_tier,omitempty" db:"min_tier"`
+	// Stackable codes can be combined with other stackable codes on one order
+	Stackable bool      `json:"stackable" db:"stackable"`
+	IsActive  bool      `json:"is_active" db:"is_active"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// NewPromotion creates an active promotion with a generated ID
+func NewPromotion(code string, rewardType PromotionRewardType, value float64) *Promotion {
+	now := time.Now()
+	return &Promotion{
+		ID:         uuid.New(),
+		Code:       NormalizePromoCode(code),
+		RewardType: rewardType,
+		Value:      value,
+		IsActive:   true,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+}
+
+// Validate checks that the promotion can be saved
+func (p *Promotion) Validate() error {
+	if !promoCodePattern.MatchString(p.Code) {
+		return ErrInvalidPromotion
+	}
+
+	switch p.RewardType {
+	case PromotionRewardPercent:
+		if p.Value <= 0 || p.Value > 100 {
+			return ErrInvalidPromotion
+		}
+	case PromotionRewardFixed:
+		if p.Value <= 0 || p.MaxDiscount != nil {
+			return ErrInvalidPromotion
+		}
+	case PromotionRewardFreeShipping:
+		if p.Value != 0 || p.MaxDiscount != nil {
+			return ErrInvalidPromotion
+		}
+	default:
+		return ErrInvalidPromotion
+	}
+
+	if p.MaxDiscount != nil && *p.MaxDiscount <= 0 {
+		return ErrInvalidPromotion
+	}
+	if p.MinSpend < 0 {
+		return ErrInvalidPromotion
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return ErrInvalidPromotion
+	}
+	if p.UsageLimit != nil && *p.UsageLimit <= 0 {
+		return ErrInvalidPromotion
+	}
+	if p.PerCustomerLimit != nil && *p.PerCustomerLimit <= 0 {
+		return ErrInvalidPromotion
+	}
+	if p.MinTier < 0 || p.MinTier > TierDiamond {
+		return ErrInvalidPromotion
+	}
+	return nil
+}
+
+// IsScoped reports whether the promotion only applies to some products or categories
+func (p *Promotion) IsScoped() bool {
+	return len(p.ProductIDs) > 0 || len(p.CategoryIDs) > 0
+}
+
+// PromotionLine is an order line as seen by promotion rules
+type PromotionLine struct {
+	ProductID  uuid.UUID
+	CategoryID *uuid.UUID
+	Amount     float64
+}
+
+// PromotionCart is what an order looks like to promotion rules when it is placed
+type PromotionCart struct {
+	CustomerID   uuid.UUID
+	CustomerTier CustomerTier
+	Lines        []PromotionLine
+	ShippingFee  float64
+	Now          time.Time
+}
+
+// ItemsTotal is the amount of every line before discounts
+func (c *PromotionCart) ItemsTotal() float64 {
+	total := 0.0
+	for _, line := range c.Lines {
+		total += line.Amount
+	}
+	return total
+}
+
+// eligibleTotal is the amount of the lines the promotion applies to
+func (p *Promotion) eligibleTotal(cart *PromotionCart) float64 {
+	if !p.IsScoped() {
+		return cart.ItemsTotal()
+	}
+
+	total := 0.0
+	for _, line := range cart.Lines {
+		if containsID(p.ProductIDs, line.ProductID) ||
+			(line.CategoryID != nil && containsID(p.CategoryIDs, *line.CategoryID)) {
+			total += line.Amount
+		}
+	}
+	return total
+}
+
+// Check reports why the cart cannot use the promotion, or nil if it can. Usage limits are
+// enforced when the redemption is recorded.
+func (p *Promotion) Check(cart *PromotionCart) error {
+	if !p.IsActive {
+		return ErrPromotionNotFound
+	}
+	if p.StartsAt != nil && cart.Now.Before(*p.StartsAt) {
+		return ErrPromotionNotStarted
+	}
+	if p.EndsAt != nil && !cart.Now.Before(*p.EndsAt) {
+		return ErrPromotionExpired
+	}
+	if p.UsageLimit != nil && p.UsedCount >= *p.UsageLimit {
+		return ErrPromotionUsageLimitReached
+	}
+	if cart.CustomerTier < p.MinTier {
+		return ErrPromotionTierRequired
+	}
+	if cart.ItemsTotal() < p.MinSpend {
+		return ErrPromotionMinSpendNotMet
+	}
+	if p.RewardType == PromotionRewardFreeShipping {
+		if cart.ShippingFee <= 0 {
+			return ErrPromotionNotApplicable
+		}
+	} else if p.eligibleTotal(cart) <= 0 {
+		return ErrPromotionNotApplicable
+	}
+	return nil
+}
+
+// AppliedPromotion is the share of an order's discount given by one promotion
+type AppliedPromotion struct {
+	PromotionID uuid.UUID           `json:"promotion_id"`
+	Code        string              `json:"code"`
+	RewardType  PromotionRewardType `json:"reward_type"`
+	Amount      float64             `json:"amount"`
+}
+
+// PromotionResult is the outcome of applying promo codes to a cart
+type PromotionResult struct {
+	// Discount comes off the items; free shipping is not part of it
+	Discount     float64            `json:"discount"`
+	FreeShipping bool               `json:"free_shipping"`
+	Applied      []AppliedPromotion `json:"applied"`
+}
+
+// Codes returns the applied codes joined as they are stored on the order
+func (r *PromotionResult) Codes() string {
+	codes := make([]string, len(r.Applied))
+	for i, applied := range r.Applied {
+		codes[i] = applied.Code
+	}
+	return strings.Join(codes, ",")
+}
+
+// ApplyPromotions checks every promotion against the cart and works out the discount.
+//
+// Stacking policy: a code that is not stackable must be the only code on the order, and at most
+// MaxPromotionsPerOrder stackable codes can be combined. Each reward is worked out on the full
+// amount of its eligible items, percent rewards first and then fixed ones, and the discount never
+// exceeds the items total. Free shipping waives the shipping fee once however many codes give it.
+func ApplyPromotions(promotions []*Promotion, cart *PromotionCart) (*PromotionResult, error) {
+	if len(promotions) > MaxPromotionsPerOrder {
+		return nil, ErrPromotionNotStackable
+	}
+	for _, promotion := range promotions {
+		if len(promotions) > 1 && !promotion.Stackable {
+			return nil, ErrPromotionNotStackable
+		}
+		if err := promotion.Check(cart); err != nil {
+			return nil, &PromotionError{Code: promotion.Code, Err: err}
+		}
+	}
+
+	ordered := make([]*Promotion, len(promotions))
+	copy(ordered, promotions)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return rewardOrder(ordered[i].RewardType) < rewardOrder(ordered[j].RewardType)
+	})
+
+	result := &PromotionResult{}
+	remaining := cart.ItemsTotal()
+	for _, promotion := range ordered {
+		applied := AppliedPromotion{PromotionID: promotion.ID, Code: promotion.Code, RewardType: promotion.RewardType}
+
+		switch promotion.RewardType {
+		case PromotionRewardPercent:
+			applied.Amount = promotion.eligibleTotal(cart) * promotion.Value / 100
+			if promotion.MaxDiscount != nil {
+				applied.Amount = math.Min(applied.Amount, *promotion.MaxDiscount)
+			}
+		case PromotionRewardFixed:
+			applied.Amount = math.Min(promotion.Value, promotion.eligibleTotal(cart))
+		case PromotionRewardFreeShipping:
+			if !result.FreeShipping {
+				applied.Amount = cart.ShippingFee
+				result.FreeShipping = true
+			}
+		}
+
+		if promotion.RewardType != PromotionRewardFreeShipping {
+			applied.Amount = roundMoney(math.Min(applied.Amount, remaining))
+			remaining -= applied.Amount
+			result.Discount += applied.Amount
+		}
+		result.Applied = append(result.Applied, applied)
+	}
+
+	result.Discount = roundMoney(result.Discount)
+	return result, nil
+}
+
+// ApplyPromotions sets the discount, shipping fee and promo codes of the order from the result
+func (o *Order) ApplyPromotions(result *PromotionResult) error {
+	if len(result.Applied) == 0 {
+		return nil
+	}
+	if result.FreeShipping {
+		if err := o.SetShippingFee(0); err != nil {
+			return err
+		}
+	}
+	o.SetPromoCode(result.Codes())
+	return o.ApplyDiscount(result.Discount)
+}
+
+// PromotionRedemption records that an order used a promotion. Cancelling the order releases it
+// so it no longer counts towards the usage limits.
+type PromotionRedemption struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	PromotionID uuid.UUID  `json:"promotion_id" db:"promotion_id"`
+	OrderID     uuid.UUID  `json:"order_id" db:"order_id"`
+	CustomerID  uuid.UUID  `json:"customer_id" db:"customer_id"`
+	Code        string     `json:"code" db:"code"`
+	Amount      float64    `json:"amount" db:"amount"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ReleasedAt  *time.Time `json:"released_at,omitempty" db:"released_at"`
+}
+
+// Redemptions returns a redemption of every applied promotion for the order
+func (r *PromotionResult) Redemptions(order *Order) []*PromotionRedemption {
+	now := time.Now()
+	redemptions := make([]*PromotionRedemption, len(r.Applied))
+	for i, applied := range r.Applied {
+		redemptions[i] = &PromotionRedemption{
+			ID:          uuid.New(),
+			PromotionID: applied.PromotionID,
+			OrderID:     order.ID,
+			CustomerID:  order.CustomerID,
+			Code:        applied.Code,
+			Amount:      applied.Amount,
+			CreatedAt:   now,
+		}
+	}
+	return redemptions
+}
+
+// PromotionError tells which code an order could not use
+type PromotionError struct {
+	Code string
+	Err  error
+}
+
+func (e *PromotionError) Error() string {
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *PromotionError) Unwrap() error {
+	return e.Err
+}
+
+func rewardOrder(rewardType PromotionRewardType) int {
+	switch rewardType {
+	case PromotionRewardPercent:
+		return 0
+	case PromotionRewardFixed:
+		return 1
+	}
+	return 2
+}
+
+func containsID(ids []uuid.UUID, id uuid.UUID) bool {
+	for _, candidate := range ids {
+		if candidate == id {
+			return true
+		}
+	}
+	return false
+}
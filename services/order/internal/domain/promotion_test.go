@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var promotionNow = time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+func promotionCart(lines ...PromotionLine) *PromotionCart {
+	return &PromotionCart{
+		CustomerID:   uuid.New(),
+		CustomerTier: TierBronze,
+		Lines:        lines,
+		ShippingFee:  50,
+		Now:          promotionNow,
+	}
+}
+
+func TestPromotionValidate(t *testing.T) {
+	start := promotionNow
+	end := promotionNow.Add(-time.Hour)
+	zero := 0
+	maxDiscount := 100.0
+
+	valid := NewPromotion(" summer10 ", PromotionRewardPercent, 10)
+	require.NoError(t, valid.Validate())
+	assert.Equal(t, "SUMMER10", valid.Code)
+
+	tests := []struct {
+		name   string
+		modify func(p *Promotion)
+	}{
+		{name: "short code", modify: func(p *Promotion) { p.Code = "AB" }},
+		{name: "code with spaces", modify: func(p *Promotion) { p.Code = "SUMMER 10" }},
+		{name: "percent over 100", modify: func(p *Promotion) { p.Value = 150 }},
+		{name: "unknown reward", modify: func(p *Promotion) { p.RewardType = "cashback" }},
+		{name: "fixed with cap", modify: func(p *Promotion) { p.RewardType = PromotionRewardFixed; p.MaxDiscount = &maxDiscount }},
+		{name: "free shipping with value", modify: func(p *Promotion) { p.RewardType = PromotionRewardFreeShipping }},
+		{name: "window ends before it starts", modify: func(p *Promotion) { p.StartsAt = &start; p.EndsAt = &end }},
+		{name: "zero usage limit", modify: func(p *Promotion) { p.UsageLimit = &zero }},
+		{name: "zero per customer limit", modify: func(p *Promotion) { p.PerCustomerLimit = &zero }},
+		{name: "unknown tier", modify: func(p *Promotion) { p.MinTier = 6 }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promotion := NewPromotion("SUMMER10", PromotionRewardPercent, 10)
+			tt.modify(promotion)
+			assert.ErrorIs(t, promotion.Validate(), ErrInvalidPromotion)
+		})
+	}
+}
+
+func TestPromotionCheckRules(t *testing.T) {
+	before := promotionNow.Add(-time.Hour)
+	after := promotionNow.Add(time.Hour)
+	limit := 5
+	shoes, bags := uuid.New(), uuid.New()
+	lines := []PromotionLine{
+		{ProductID: uuid.New(), CategoryID: &shoes, Amount: 600},
+		{ProductID: uuid.New(), Amount: 400},
+	}
+
+	tests := []struct {
+		name   string
+		modify func(p *Promotion, cart *PromotionCart)
+		err    error
+	}{
+		{name: "usable", modify: func(p *Promotion, cart *PromotionCart) {}},
+		{name: "inactive", modify: func(p *Promotion, cart *PromotionCart) { p.IsActive = false }, err: ErrPromotionNotFound},
+		{name: "not started", modify: func(p *Promotion, cart *PromotionCart) { p.StartsAt = &after }, err: ErrPromotionNotStarted},
+		{name: "expired", modify: func(p *Promotion, cart *PromotionCart) { p.EndsAt = &before }, err: ErrPromotionExpired},
+		{name: "ends now", modify: func(p *Promotion, cart *PromotionCart) { p.EndsAt = &promotionNow }, err: ErrPromotionExpired},
+		{name: "used up", modify: func(p *Promotion, cart *PromotionCart) { p.UsageLimit = &limit; p.UsedCount = 5 }, err: ErrPromotionUsageLimitReached},
+		{name: "below VIP tier", modify: func(p *Promotion, cart *PromotionCart) { p.MinTier = TierGold; cart.CustomerTier = TierSilver }, err: ErrPromotionTierRequired},
+		{name: "VIP tier", modify: func(p *Promotion, cart *PromotionCart) { p.MinTier = TierGold; cart.CustomerTier = TierDiamond }},
+		{name: "below min spend", modify: func(p *Promotion, cart *PromotionCart) { p.MinSpend = 1000.01 }, err: ErrPromotionMinSpendNotMet},
+		{name: "at min spend", modify: func(p *Promotion, cart *PromotionCart) { p.MinSpend = 1000 }},
+		{name: "category in cart", modify: func(p *Promotion, cart *PromotionCart) { p.CategoryIDs = []uuid.UUID{shoes} }},
+		{name: "category not in cart", modify: func(p *Promotion, cart *PromotionCart) { p.CategoryIDs = []uuid.UUID{bags} }, err: ErrPromotionNotApplicable},
+		{name: "product not in cart", modify: func(p *Promotion, cart *PromotionCart) { p.ProductIDs = []uuid.UUID{uuid.New()} }, err: ErrPromotionNotApplicable},
+		{
+			name: "free shipping without a fee",
+			modify: func(p *Promotion, cart *PromotionCart) {
+				p.RewardType, p.Value = PromotionRewardFreeShipping, 0
+				cart.ShippingFee = 0
+			},
+			err: ErrPromotionNotApplicable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			promotion := NewPromotion("SUMMER10", PromotionRewardPercent, 10)
+			cart := promotionCart(lines...)
+			tt.modify(promotion, cart)
+
+			if tt.err == nil {
+				assert.NoError(t, promotion.Check(cart))
+			} else {
+				assert.ErrorIs(t, promotion.Check(cart), tt.err)
+			}
+		})
+	}
+}
+
+func TestApplyPromotionsScopesAndCaps(t *testing.T) {
+	shoes := uuid.New()
+	sneaker := uuid.New()
+	cart := promotionCart(
+		PromotionLine{ProductID: sneaker, CategoryID: &shoes, Amount: 1200},
+		PromotionLine{ProductID: uuid.New(), Amount: 300},
+	)
+
+	// 20% off shoes only, capped at 200
+	percent := NewPromotion("SHOES20", PromotionRewardPercent, 20)
+	percent.CategoryIDs = []uuid.UUID{shoes}
+	maxDiscount := 200.0
+	percent.MaxDiscount = &maxDiscount
+
+	result, err := ApplyPromotions([]*Promotion{percent}, cart)
+	require.NoError(t, err)
+	assert.Equal(t, 200.0, result.Discount)
+	assert.False(t, result.FreeShipping)
+
+	// A fixed reward never exceeds the eligible items
+	fixed := NewPromotion("SNEAKER", PromotionRewardFixed, 5000)
+	fixed.ProductIDs = []uuid.UUID{sneaker}
+
+	result, err = ApplyPromotions([]*Promotion{fixed}, cart)
+	require.NoError(t, err)
+	assert.Equal(t, 1200.0, result.Discount)
+}
+
+func TestApplyPromotionsStacking(t *testing.T) {
+	cart := promotionCart(PromotionLine{ProductID: uuid.New(), Amount: 1000})
+
+	fixed := NewPromotion("WELCOME900", PromotionRewardFixed, 900)
+	fixed.Stackable = true
+	percent := NewPromotion("VIP15", PromotionRewardPercent, 15)
+	percent.Stackable = true
+	shipping := NewPromotion("FREESHIP", PromotionRewardFreeShipping, 0)
+	shipping.Stackable = true
+
+	result, err := ApplyPromotions([]*Promotion{fixed, shipping, percent}, cart)
+	require.NoError(t, err)
+
+	// Percent first on the full amount, then the fixed reward up to what is left
+	require.Len(t, result.Applied, 3)
+	assert.Equal(t, "VIP15", result.Applied[0].Code)
+	assert.Equal(t, 150.0, result.Applied[0].Amount)
+	assert.Equal(t, "WELCOME900", result.Applied[1].Code)
+	assert.Equal(t, 850.0, result.Applied[1].Amount)
+	assert.Equal(t, "FREESHIP", result.Applied[2].Code)
+	assert.Equal(t, 50.0, result.Applied[2].Amount)
+	assert.Equal(t, 1000.0, result.Discount)
+	assert.True(t, result.FreeShipping)
+	assert.Equal(t, "VIP15,WELCOME900,FREESHIP", result.Codes())
+
+	exclusive := NewPromotion("FLASH50", PromotionRewardPercent, 50)
+	_, err = ApplyPromotions([]*Promotion{exclusive, shipping}, cart)
+	assert.ErrorIs(t, err, ErrPromotionNotStackable)
+
+	fourth := NewPromotion("EXTRA5", PromotionRewardFixed, 5)
+	fourth.Stackable = true
+	_, err = ApplyPromotions([]*Promotion{fixed, shipping, percent, fourth}, cart)
+	assert.ErrorIs(t, err, ErrPromotionNotStackable)
+
+	// The code that failed is named
+	expired := NewPromotion("OLD10", PromotionRewardFixed, 10)
+	expired.EndsAt = &promotionNow
+	_, err = ApplyPromotions([]*Promotion{expired}, cart)
+	var promotionErr *PromotionError
+	require.ErrorAs(t, err, &promotionErr)
+	assert.Equal(t, "OLD10", promotionErr.Code)
+	assert.ErrorIs(t, err, ErrPromotionExpired)
+}
+
+func TestOrderApplyPromotions(t *testing.T) {
+	order := NewOrder(uuid.New(), "1 Sukhumvit Rd", "1 Sukhumvit Rd", "")
+	order.AddItem(uuid.New(), 2, 250)
+	require.NoError(t, order.SetShippingFee(50))
+
+	percent := NewPromotion("SAVE10", PromotionRewardPercent, 10)
+	percent.Stackable = true
+	shipping := NewPromotion("FREESHIP", PromotionRewardFreeShipping, 0)
+	shipping.Stackable = true
+
+	cart := promotionCart(PromotionLine{ProductID: order.Items[0].ProductID, Amount: 500})
+	result, err := ApplyPromotions([]*Promotion{percent, shipping}, cart)
+	require.NoError(t, err)
+	require.NoError(t, order.ApplyPromotions(result))
+
+	assert.Equal(t, 50.0, order.Discount)
+	assert.Equal(t, 0.0, order.ShippingFee)
+	assert.Equal(t, 450.0, order.TotalAmount)
+	assert.Equal(t, "SAVE10,FREESHIP", *order.PromoCode)
+
+	redemptions := result.Redemptions(order)
+	require.Len(t, redemptions, 2)
+	assert.Equal(t, order.ID, redemptions[0].OrderID)
+	assert.Equal(t, order.CustomerID, redemptions[0].CustomerID)
+	assert.Equal(t, percent.ID, redemptions[0].PromotionID)
+	assert.Equal(t, 50.0, redemptions[1].Amount)
+}
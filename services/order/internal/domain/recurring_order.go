@@ -0,0 +1,376 @@
+package domain
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
+)
+
+// RecurringOrderStatus represents the status of a recurring order
+type RecurringOrderStatus string
+
+const (
+	RecurringOrderStatusActive RecurringOrderStatus = "active"
+	// RecurringOrderStatusPaused generates no orders until resumed, or until PausedUntil when set
+	RecurringOrderStatusPaused    RecurringOrderStatus = "paused"
+	RecurringOrderStatusCancelled RecurringOrderStatus = "cancelled"
+)
+
+// RecurringOrderRunStatus represents the outcome of one occurrence of a recurring order
+type RecurringOrderRunStatus string
+
+const (
+	// RecurringOrderRunPending means the occurrence was claimed and its order is being created
+	RecurringOrderRunPending RecurringOrderRunStatus = "pending"
+	RecurringOrderRunCreated RecurringOrderRunStatus = "created"
+	RecurringOrderRunFailed  RecurringOrderRunStatus = "failed"
+	RecurringOrderRunSkipped RecurringOrderRunStatus = "skipped"
+)
+
+// RecurringOrder is a customer's standing order. It generates an order on a five-field cron
+// schedule evaluated in Thai time, and the customer is reminded in chat before each order.
+type RecurringOrder struct {
+	ID              uuid.UUID   `json:"id" db:"id"`
+	CustomerID      uuid.UUID   `json:"customer_id" db:"customer_id"`
+	ChatID          string      `json:"chat_id" db:"chat_id"`
+	Source          OrderSource `json:"source" db:"source"`
+	Schedule        string      `json:"schedule" db:"schedule"`
+	ShippingAddress string      `json:"shipping_address" db:"shipping_address"`
+	BillingAddress  string      `json:"billing_address" db:"billing_address"`
+	ShippingFee     float64     `json:"shipping_fee" db:"shipping_fee"`
+	// CustomerGroup is the pricing group the orders are priced for, such as wholesale
+	CustomerGroup string               `json:"customer_group,omitempty" db:"customer_group"`
+	Notes         string               `json:"notes" db:"notes"`
+	Status        RecurringOrderStatus `json:"status" db:"status"`
+	NextRunAt     time.Time            `json:"next_run_at" db:"next_run_at"`
+	PausedUntil   *time.Time           `json:"paused_until,omitempty" db:"paused_until"`
+	// NextOverride changes the next order only and is dropped once that order is generated or skipped
+	NextOverride *RecurringOrderOverride `json:"next_override,omitempty" db:"-"`
+	// ReminderSentFor is the occurrence the customer was last reminded of
+	ReminderSentFor *time.Time           `json:"reminder_sent_for,omitempty" db:"reminder_sent_for"`
+	Revision        int                  `json:"revision" db:"revision"`
+	CreatedAt       time.Time            `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at" db:"updated_at"`
+	Items           []RecurringOrderItem `json:"items"`
+}
+
+// RecurringOrderItem is a product ordered on every occurrence
+type RecurringOrderItem struct {
+	ProductID uuid.UUID `json:"product_id" db:"product_id"`
+	Quantity  int       `json:"quantity" db:"quantity"`
+	UnitPrice float64   `json:"unit_price" db:"unit_price"`
+}
+
+// RecurringOrderOverride changes the next occurrence of a recurring order. Nil and empty fields
+// keep the standing values.
+type RecurringOrderOverride struct {
+	Items           []RecurringOrderItem `json:"items,omitempty"`
+	ShippingAddress *string              `json:"shipping_address,omitempty"`
+	Notes           *string              `json:"notes,omitempty"`
+}
+
+// RecurringOrderOccurrence is what the order of one occurrence is created from
+type RecurringOrderOccurrence struct {
+	ScheduledFor    time.Time
+	Items           []RecurringOrderItem
+	ShippingAddress string
+	BillingAddress  string
+	Notes           string
+}
+
+// RecurringOrderRun records what happened to one occurrence of a recurring order. There is at
+// most one run per occurrence, so an occurrence is never ordered twice.
+type RecurringOrderRun struct {
+	ID               uuid.UUID               `json:"id" db:"id"`
+	RecurringOrderID uuid.UUID               `json:"recurring_order_id" db:"recurring_order_id"`
+	ScheduledFor     time.Time               `json:"scheduled_for" db:"scheduled_for"`
+	Status           RecurringOrderRunStatus `json:"status" db:"status"`
+	OrderID          *uuid.UUID              `json:"order_id,omitempty" db:"order_id"`
+	Error            *string                 `json:"error,omitempty" db:"error"`
+	CreatedAt        time.Time               `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time               `json:"updated_at" db:"updated_at"`
+}
+
+// ParseSchedule parses a five-field cron expression such as "0 9 * * 1" (Mondays at 09:00) or a
+// descriptor such as "@weekly". Schedules always run in Thai time, so time zone prefixes are
+// rejected.
+func ParseSchedule(spec string) (cron.Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || strings.HasPrefix(spec, "TZ=") || strings.HasPrefix(spec, "CRON_TZ=") {
+		return nil, ErrInvalidSchedule
+	}
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil, ErrInvalidSchedule
+	}
+	return schedule, nil
+}
+
+// NewRecurringOrder creates an active recurring order whose first order is due at the next
+// occurrence of the schedule after now
+func NewRecurringOrder(customerID uuid.UUID, schedule, shippingAddress, billingAddress, notes string, now time.Time) (*RecurringOrder, error) {
+	parsed, err := ParseSchedule(schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return &RecurringOrder{
+		ID:              uuid.New(),
+		CustomerID:      customerID,
+		Source:          OrderSourceOnline,
+		Schedule:        strings.TrimSpace(schedule),
+		ShippingAddress: shippingAddress,
+		BillingAddress:  billingAddress,
+		Notes:           notes,
+		Status:          RecurringOrderStatusActive,
+		NextRunAt:       parsed.Next(now.In(businessLocation)),
+		Revision:        1,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}, nil
+}
+
+// AddItem adds a product to every order of the recurring order
+func (r *RecurringOrder) AddItem(productID uuid.UUID, quantity int, unitPrice float64) {
+	r.Items = append(r.Items, RecurringOrderItem{ProductID: productID, Quantity: quantity, UnitPrice: unitPrice})
+}
+
+// Validate validates the recurring order
+func (r *RecurringOrder) Validate() error {
+	if r.CustomerID == uuid.Nil {
+		return ErrInvalidCustomerID
+	}
+	if !r.Source.IsValid() {
+		return ErrInvalidOrderSource
+	}
+	if _, err := ParseSchedule(r.Schedule); err != nil {
+		return err
+	}
+	if r.ShippingAddress == "" || r.BillingAddress == "" {
+		return ErrInvalidOrderData
+	}
+	if r.ShippingFee < 0 {
+		return ErrInvalidAmount
+	}
+	if len(r.Items) == 0 {
+		return ErrInvalidOrderData
+	}
+	return validateRecurringItems(r.Items)
+}
+
+func validateRecurringItems(items []RecurringOrderItem) error {
+	for _, item := range items {
+		if item.ProductID == uuid.Nil {
+			return ErrInvalidOrderItemData
+		}
+		if item.Quantity <= 0 {
+			return ErrInvalidQuantity
+		}
+		if item.UnitPrice < 0 {
+			return ErrInvalidPrice
+		}
+	}
+	return nil
+}
+
+// Occurrence returns what the next order will be created from, with the override applied
+func (r *RecurringOrder) Occurrence() *RecurringOrderOccurrence {
+	occurrence := &RecurringOrderOccurrence{
+		ScheduledFor:    r.NextRunAt,
+		Items:           r.Items,
+		ShippingAddress: r.ShippingAddress,
+		BillingAddress:  r.BillingAddress,
+		Notes:           r.Notes,
+	}
+	if override := r.NextOverride; override != nil {
+		if len(override.Items) > 0 {
+			occurrence.Items = override.Items
+		}
+		if override.ShippingAddress != nil {
+			occurrence.ShippingAddress = *override.ShippingAddress
+		}
+		if override.Notes != nil {
+			occurrence.Notes = *override.Notes
+		}
+	}
+	return occurrence
+}
+
+// TakeDue claims the occurrence that is due and moves the recurring order on to the next one.
+// Occurrences missed while the service was down are not made up: the next one is after now.
+func (r *RecurringOrder) TakeDue(now time.Time) (*RecurringOrderOccurrence, error) {
+	if r.Status != RecurringOrderStatusActive || r.NextRunAt.After(now) {
+		return nil, ErrRecurringOrderNotDue
+	}
+
+	occurrence := r.Occurrence()
+	r.advance(now)
+	return occurrence, nil
+}
+
+// Skip skips the next occurrence and returns when it was due
+func (r *RecurringOrder) Skip(now time.Time) (time.Time, error) {
+	if r.Status != RecurringOrderStatusActive {
+		return time.Time{}, ErrRecurringOrderNotActive
+	}
+
+	skipped := r.NextRunAt
+	r.advance(now)
+	return skipped, nil
+}
+
+// advance moves the recurring order past its next occurrence, dropping the override and the
+// reminder that belonged to it
+func (r *RecurringOrder) advance(now time.Time) {
+	after := r.NextRunAt
+	if now.After(after) {
+		after = now
+	}
+	r.NextRunAt = r.next(after)
+	r.NextOverride = nil
+	r.ReminderSentFor = nil
+	r.touch(now)
+}
+
+// Pause stops the recurring order from generating orders. It resumes by itself at until when
+// set; occurrences that fall in the pause are not ordered.
+func (r *RecurringOrder) Pause(until *time.Time, now time.Time) error {
+	if r.Status == RecurringOrderStatusCancelled {
+		return ErrRecurringOrderCancelled
+	}
+	if until != nil && !until.After(now) {
+		return ErrInvalidPauseUntil
+	}
+
+	r.Status = RecurringOrderStatusPaused
+	r.PausedUntil = until
+	r.touch(now)
+	return nil
+}
+
+// Resume starts a paused recurring order again from the next occurrence after now
+func (r *RecurringOrder) Resume(now time.Time) error {
+	if r.Status != RecurringOrderStatusPaused {
+		return ErrRecurringOrderNotPaused
+	}
+
+	r.Status = RecurringOrderStatusActive
+	r.PausedUntil = nil
+	if !r.NextRunAt.After(now) {
+		r.NextRunAt = r.next(now)
+		r.NextOverride = nil
+		r.ReminderSentFor = nil
+	}
+	r.touch(now)
+	return nil
+}
+
+// PauseEnded reports whether the recurring order is paused until a time that has passed
+func (r *RecurringOrder) PauseEnded(now time.Time) bool {
+	return r.Status == RecurringOrderStatusPaused && r.PausedUntil != nil && !r.PausedUntil.After(now)
+}
+
+// Cancel stops the recurring order for good
+func (r *RecurringOrder) Cancel(now time.Time) error {
+	if r.Status == RecurringOrderStatusCancelled {
+		return ErrRecurringOrderCancelled
+	}
+
+	r.Status = RecurringOrderStatusCancelled
+	r.PausedUntil = nil
+	r.NextOverride = nil
+	r.touch(now)
+	return nil
+}
+
+// ModifyNext changes the items, shipping address or notes of the next order only. A nil
+// override drops an earlier change.
+func (r *RecurringOrder) ModifyNext(override *RecurringOrderOverride, now time.Time) error {
+	if r.Status == RecurringOrderStatusCancelled {
+		return ErrRecurringOrderCancelled
+	}
+	if override != nil {
+		if override.ShippingAddress != nil && *override.ShippingAddress == "" {
+			return ErrInvalidOrderData
+		}
+		if err := validateRecurringItems(override.Items); err != nil {
+			return err
+		}
+		if len(override.Items) == 0 && override.ShippingAddress == nil && override.Notes == nil {
+			override = nil
+		}
+	}
+
+	r.NextOverride = override
+	r.touch(now)
+	return nil
+}
+
+// ReminderDue reports whether the customer should be reminded of the next occurrence: it is due
+// within lead and they have not been reminded of it yet
+func (r *RecurringOrder) ReminderDue(now time.Time, lead time.Duration) bool {
+	if r.Status != RecurringOrderStatusActive || r.ChatID == "" || !r.NextRunAt.After(now) {
+		return false
+	}
+	if r.ReminderSentFor != nil && r.ReminderSentFor.Equal(r.NextRunAt) {
+		return false
+	}
+	return !r.NextRunAt.After(now.Add(lead))
+}
+
+// MarkReminded records that the customer was reminded of the next occurrence
+func (r *RecurringOrder) MarkReminded(now time.Time) {
+	sentFor := r.NextRunAt
+	r.ReminderSentFor = &sentFor
+	r.touch(now)
+}
+
+// ClearReminder forgets a reminder that could not be delivered so it is sent again
+func (r *RecurringOrder) ClearReminder(now time.Time) {
+	r.ReminderSentFor = nil
+	r.touch(now)
+}
+
+func (r *RecurringOrder) next(after time.Time) time.Time {
+	schedule, err := ParseSchedule(r.Schedule)
+	if err != nil {
+		// Schedules are checked when the recurring order is created
+		return time.Time{}
+	}
+	return schedule.Next(after.In(businessLocation))
+}
+
+func (r *RecurringOrder) touch(now time.Time) {
+	r.Revision++
+	r.UpdatedAt = now
+}
+
+// NewRecurringOrderRun records an occurrence of a recurring order with the given status
+func NewRecurringOrderRun(recurringOrderID uuid.UUID, scheduledFor time.Time, status RecurringOrderRunStatus, now time.Time) *RecurringOrderRun {
+	return &RecurringOrderRun{
+		ID:               uuid.New(),
+		RecurringOrderID: recurringOrderID,
+		ScheduledFor:     scheduledFor,
+		Status:           status,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}
+}
+
+// Complete records the order created for the run
+func (r *RecurringOrderRun) Complete(orderID uuid.UUID, now time.Time) {
+	r.Status = RecurringOrderRunCreated
+	r.OrderID = &orderID
+	r.Error = nil
+	r.UpdatedAt = now
+}
+
+// Fail records why the order of the run could not be created
+func (r *RecurringOrderRun) Fail(err error, now time.Time) {
+	message := err.Error()
+	r.Status = RecurringOrderRunFailed
+	r.Error = &message
+	r.UpdatedAt = now
+}
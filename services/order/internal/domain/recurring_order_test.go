@@ -0,0 +1,210 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bangkok builds a time in Thai time
+func bangkok(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, businessLocation)
+}
+
+// newWeeklyOrder creates a recurring order for Mondays at 09:00, created on Wednesday 4 June 2025
+func newWeeklyOrder(t *testing.T) *RecurringOrder {
+	order, err := NewRecurringOrder(uuid.New(), "0 9 * * 1", "12 Rama IV Rd, Bangkok", "12 Rama IV Rd, Bangkok", "", bangkok(2025, 6, 4, 15, 0))
+	require.NoError(t, err)
+	order.ChatID = "U1234"
+	order.AddItem(uuid.New(), 10, 150)
+	require.NoError(t, order.Validate())
+	return order
+}
+
+func TestNewRecurringOrderRunsOnScheduleInThaiTime(t *testing.T) {
+	order := newWeeklyOrder(t)
+
+	assert.Equal(t, RecurringOrderStatusActive, order.Status)
+	assert.True(t, order.NextRunAt.Equal(bangkok(2025, 6, 9, 9, 0)))
+	// 09:00 in Bangkok is 02:00 UTC
+	assert.Equal(t, 2, order.NextRunAt.UTC().Hour())
+}
+
+func TestParseScheduleRejectsInvalidSchedules(t *testing.T) {
+	for _, spec := range []string{"", "every monday", "0 9 * *", "0 0 9 * * 1", "CRON_TZ=UTC 0 9 * * 1", "TZ=UTC 0 9 * * 1"} {
+		_, err := ParseSchedule(spec)
+		assert.ErrorIs(t, err, ErrInvalidSchedule, spec)
+	}
+
+	_, err := ParseSchedule("@weekly")
+	assert.NoError(t, err)
+}
+
+func TestRecurringOrderValidate(t *testing.T) {
+	order := newWeeklyOrder(t)
+	order.Items[0].Quantity = 0
+	assert.ErrorIs(t, order.Validate(), ErrInvalidQuantity)
+
+	order = newWeeklyOrder(t)
+	order.Source = "fax"
+	assert.ErrorIs(t, order.Validate(), ErrInvalidOrderSource)
+
+	order = newWeeklyOrder(t)
+	order.Items = nil
+	assert.ErrorIs(t, order.Validate(), ErrInvalidOrderData)
+}
+
+func TestTakeDueClaimsOccurrenceAndMovesOn(t *testing.T) {
+	order := newWeeklyOrder(t)
+	address := "Warehouse 2, Samut Prakan"
+	productID := uuid.New()
+	require.NoError(t, order.ModifyNext(&RecurringOrderOverride{
+		Items:           []RecurringOrderItem{{ProductID: productID, Quantity: 20, UnitPrice: 140}},
+		ShippingAddress: &address,
+	}, bangkok(2025, 6, 5, 10, 0)))
+
+	_, err := order.TakeDue(bangkok(2025, 6, 9, 8, 59))
+	assert.ErrorIs(t, err, ErrRecurringOrderNotDue)
+
+	revision := order.Revision
+	occurrence, err := order.TakeDue(bangkok(2025, 6, 9, 9, 0))
+	require.NoError(t, err)
+
+	// The override applies to this occurrence only
+	assert.True(t, occurrence.ScheduledFor.Equal(bangkok(2025, 6, 9, 9, 0)))
+	assert.Equal(t, address, occurrence.ShippingAddress)
+	assert.Equal(t, "12 Rama IV Rd, Bangkok", occurrence.BillingAddress)
+	require.Len(t, occurrence.Items, 1)
+	assert.Equal(t, productID, occurrence.Items[0].ProductID)
+
+	assert.Nil(t, order.NextOverride)
+	assert.True(t, order.NextRunAt.Equal(bangkok(2025, 6, 16, 9, 0)))
+	assert.Equal(t, revision+1, order.Revision)
+	assert.Equal(t, 10, order.Occurrence().Items[0].Quantity)
+}
+
+func TestTakeDueDoesNotMakeUpMissedOccurrences(t *testing.T) {
+	order := newWeeklyOrder(t)
+
+	// The scheduler was down for over two weeks
+	_, err := order.TakeDue(bangkok(2025, 6, 25, 12, 0))
+	require.NoError(t, err)
+	assert.True(t, order.NextRunAt.Equal(bangkok(2025, 6, 30, 9, 0)))
+}
+
+func TestSkipMovesToFollowingOccurrence(t *testing.T) {
+	order := newWeeklyOrder(t)
+	order.MarkReminded(bangkok(2025, 6, 8, 9, 0))
+
+	skipped, err := order.Skip(bangkok(2025, 6, 8, 10, 0))
+	require.NoError(t, err)
+	assert.True(t, skipped.Equal(bangkok(2025, 6, 9, 9, 0)))
+	assert.True(t, order.NextRunAt.Equal(bangkok(2025, 6, 16, 9, 0)))
+	assert.Nil(t, order.ReminderSentFor)
+
+	require.NoError(t, order.Pause(nil, bangkok(2025, 6, 8, 11, 0)))
+	_, err = order.Skip(bangkok(2025, 6, 8, 12, 0))
+	assert.ErrorIs(t, err, ErrRecurringOrderNotActive)
+}
+
+func TestPauseAndResume(t *testing.T) {
+	order := newWeeklyOrder(t)
+	now := bangkok(2025, 6, 5, 10, 0)
+
+	past := now.Add(-time.Hour)
+	assert.ErrorIs(t, order.Pause(&past, now), ErrInvalidPauseUntil)
+	assert.ErrorIs(t, order.Resume(now), ErrRecurringOrderNotPaused)
+
+	until := bangkok(2025, 6, 20, 0, 0)
+	require.NoError(t, order.Pause(&until, now))
+	assert.Equal(t, RecurringOrderStatusPaused, order.Status)
+	_, err := order.TakeDue(bangkok(2025, 6, 9, 9, 0))
+	assert.ErrorIs(t, err, ErrRecurringOrderNotDue)
+
+	assert.False(t, order.PauseEnded(bangkok(2025, 6, 19, 23, 59)))
+	assert.True(t, order.PauseEnded(until))
+
+	// Occurrences that fell in the pause are not ordered
+	require.NoError(t, order.Resume(until))
+	assert.Equal(t, RecurringOrderStatusActive, order.Status)
+	assert.Nil(t, order.PausedUntil)
+	assert.True(t, order.NextRunAt.Equal(bangkok(2025, 6, 23, 9, 0)))
+}
+
+func TestResumeKeepsUpcomingOccurrence(t *testing.T) {
+	order := newWeeklyOrder(t)
+	require.NoError(t, order.Pause(nil, bangkok(2025, 6, 5, 10, 0)))
+	require.NoError(t, order.Resume(bangkok(2025, 6, 6, 10, 0)))
+	assert.True(t, order.NextRunAt.Equal(bangkok(2025, 6, 9, 9, 0)))
+}
+
+func TestCancelledRecurringOrderCannotChange(t *testing.T) {
+	order := newWeeklyOrder(t)
+	now := bangkok(2025, 6, 5, 10, 0)
+	require.NoError(t, order.Cancel(now))
+
+	assert.ErrorIs(t, order.Cancel(now), ErrRecurringOrderCancelled)
+	assert.ErrorIs(t, order.Pause(nil, now), ErrRecurringOrderCancelled)
+	assert.ErrorIs(t, order.ModifyNext(nil, now), ErrRecurringOrderCancelled)
+	_, err := order.Skip(now)
+	assert.ErrorIs(t, err, ErrRecurringOrderNotActive)
+	assert.False(t, order.ReminderDue(bangkok(2025, 6, 8, 10, 0), 24*time.Hour))
+}
+
+func TestModifyNextValidatesAndClears(t *testing.T) {
+	order := newWeeklyOrder(t)
+	now := bangkok(2025, 6, 5, 10, 0)
+
+	empty := ""
+	assert.ErrorIs(t, order.ModifyNext(&RecurringOrderOverride{ShippingAddress: &empty}, now), ErrInvalidOrderData)
+	assert.ErrorIs(t, order.ModifyNext(&RecurringOrderOverride{
+		Items: []RecurringOrderItem{{ProductID: uuid.New(), Quantity: -1, UnitPrice: 10}},
+	}, now), ErrInvalidQuantity)
+	assert.Nil(t, order.NextOverride)
+
+	notes := "Leave at the back door"
+	require.NoError(t, order.ModifyNext(&RecurringOrderOverride{Notes: &notes}, now))
+	assert.Equal(t, notes, order.Occurrence().Notes)
+	assert.Len(t, order.Occurrence().Items, 1)
+
+	// An override that changes nothing drops the earlier one
+	require.NoError(t, order.ModifyNext(&RecurringOrderOverride{}, now))
+	assert.Nil(t, order.NextOverride)
+}
+
+func TestReminderDueOncePerOccurrence(t *testing.T) {
+	order := newWeeklyOrder(t)
+	lead := 24 * time.Hour
+
+	assert.False(t, order.ReminderDue(bangkok(2025, 6, 8, 8, 59), lead))
+	assert.True(t, order.ReminderDue(bangkok(2025, 6, 8, 9, 0), lead))
+
+	order.MarkReminded(bangkok(2025, 6, 8, 9, 0))
+	assert.False(t, order.ReminderDue(bangkok(2025, 6, 8, 9, 1), lead))
+
+	order.ClearReminder(bangkok(2025, 6, 8, 9, 2))
+	assert.True(t, order.ReminderDue(bangkok(2025, 6, 8, 9, 3), lead))
+
+	// Nothing is sent without a chat
+	order.ChatID = ""
+	assert.False(t, order.ReminderDue(bangkok(2025, 6, 8, 9, 3), lead))
+}
+
+func TestRecurringOrderRunOutcome(t *testing.T) {
+	now := bangkok(2025, 6, 9, 9, 0)
+	run := NewRecurringOrderRun(uuid.New(), now, RecurringOrderRunPending, now)
+
+	run.Fail(ErrInsufficientStock, now.Add(time.Second))
+	assert.Equal(t, RecurringOrderRunFailed, run.Status)
+	require.NotNil(t, run.Error)
+	assert.Equal(t, "insufficient stock", *run.Error)
+
+	orderID := uuid.New()
+	run.Complete(orderID, now.Add(time.Minute))
+	assert.Equal(t, RecurringOrderRunCreated, run.Status)
+	assert.Equal(t, &orderID, run.OrderID)
+	assert.Nil(t, run.Error)
+}
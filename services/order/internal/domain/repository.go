@@ -7,6 +7,12 @@ import (
 	"github.com/google/uuid"
 )
 
+// TransactionManager runs a unit of work atomically. Repository calls made
+// with the context passed to fn take part in the same transaction.
+type TransactionManager interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // OrderRepository defines the interface for order data operations
 type OrderRepository interface {
 	// Create creates a new order
@@ -21,12 +27,23 @@ type OrderRepository interface {
 	// Update updates an existing order
 	Update(ctx context.Context, order *Order) error
 
+	// LockRevision locks an order until the surrounding transaction ends and returns its
+	// current revision
+	LockRevision(ctx context.Context, id uuid.UUID) (int, error)
+
+	// UpdateRevision saves an edited order if it is still at previousRevision, otherwise it
+	// returns ErrOrderRevisionConflict
+	UpdateRevision(ctx context.Context, order *Order, previousRevision int) error
+
 	// Delete deletes an order by ID
 	Delete(ctx context.Context, id uuid.UUID) error
 
 	// List retrieves orders with pagination
 	List(ctx context.Context, limit, offset int) ([]*Order, error)
 
+	// Search retrieves a page of orders matching a validated search
+	Search(ctx context.Context, search *OrderSearch) ([]*Order, error)
+
 	// GetByStatus retrieves orders by status
 	GetByStatus(ctx context.Context, status OrderStatus) ([]*Order, error)
 
@@ -45,9 +62,23 @@ type OrderItemRepository interface {
 	// GetByOrderID retrieves all items for an order
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*OrderItem, error)
 
+	// GetByOrderIDs retrieves the items of several orders
+	GetByOrderIDs(ctx context.Context, orderIDs []uuid.UUID) ([]*OrderItem, error)
+
 	// Update updates an existing order item
 	Update(ctx context.Context, item *OrderItem) error
 
+	// UpdateFulfilment saves the shipped, fulfilled and backordered quantities of an item if
+	// its shipped quantity is still previousShipped, otherwise it returns ErrOrderRevisionConflict
+	UpdateFulfilment(ctx context.Context, item *OrderItem, previousShipped int) error
+
+	// UpdateReturned saves the returned quantity of an item if it is still previousReturned,
+	// otherwise it returns ErrOrderRevisionConflict
+	UpdateReturned(ctx context.Context, item *OrderItem, previousReturned int) error
+
+	// GetBackordered retrieves items waiting for stock on orders still being fulfilled
+	GetBackordered(ctx context.Context, limit int) ([]*OrderItem, error)
+
 	// Delete deletes an order item by ID
 	Delete(ctx context.Context, id uuid.UUID) error
 
@@ -55,6 +86,130 @@ type OrderItemRepository interface {
 	GetAllOrderItems(ctx context.Context) ([]*OrderItem, error)
 }
 
+// ShipmentRepository defines the interface for order shipment data operations
+type ShipmentRepository interface {
+	// Create creates a shipment with its items
+	Create(ctx context.Context, shipment *Shipment) error
+
+	// GetByID retrieves a shipment with its items
+	GetByID(ctx context.Context, id uuid.UUID) (*Shipment, error)
+
+	// GetByOrderID retrieves all shipments of an order with their items
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*Shipment, error)
+
+	// MarkDelivered saves the delivery of an in transit shipment, otherwise it returns
+	// ErrShipmentNotInTransit
+	MarkDelivered(ctx context.Context, shipment *Shipment) error
+}
+
+// ReturnRepository defines the interface for order return request data operations
+type ReturnRepository interface {
+	// Create creates a return request with its items
+	Create(ctx context.Context, request *ReturnRequest) error
+
+	// GetByID retrieves a return request with its items
+	GetByID(ctx context.Context, id uuid.UUID) (*ReturnRequest, error)
+
+	// GetByOrderID retrieves all return requests of an order with their items
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*ReturnRequest, error)
+
+	// Update saves a return request and the restock decisions of its items if its status is
+	// still previousStatus, otherwise it returns ErrInvalidReturnStatus
+	Update(ctx context.Context, request *ReturnRequest, previousStatus ReturnStatus) error
+}
+
+// PromotionRepository defines the interface for promo code data operations
+type PromotionRepository interface {
+	// Create creates a promotion with its product and category scopes, or returns
+	// ErrPromotionAlreadyExists if the code is taken
+	Create(ctx context.Context, promotion *Promotion) error
+
+	// GetByCode retrieves a promotion with its scopes by its normalized code
+	GetByCode(ctx context.Context, code string) (*Promotion, error)
+
+	// GetByCodes retrieves the promotions of several normalized codes; unknown codes are left out
+	GetByCodes(ctx context.Context, codes []string) ([]*Promotion, error)
+
+	// List retrieves promotions with their scopes, newest first
+	List(ctx context.Context, activeOnly bool, limit, offset int) ([]*Promotion, error)
+
+	// Update saves the description, window, limits and active flag of a promotion
+	Update(ctx context.Context, promotion *Promotion) error
+
+	// Redeem records a redemption if the promotion still has uses left overall and for the
+	// customer, otherwise it returns ErrPromotionUsageLimitReached or
+	// ErrPromotionCustomerLimitReached. It must run in a transaction: the promotion row stays
+	// locked until commit so concurrent redemptions of a code are counted one after another.
+	Redeem(ctx context.Context, redemption *PromotionRedemption) error
+
+	// ReleaseByOrderID releases the redemptions of an order so they no longer count towards
+	// the usage limits
+	ReleaseByOrderID(ctx context.Context, orderID uuid.UUID) error
+}
+
+// InvoiceRepository defines the interface for tax invoice, receipt and credit note data operations
+type InvoiceRepository interface {
+	// Create numbers the invoice with the next number of its type, branch and year and saves it
+	// with its lines. It must run in a transaction so a failed save leaves no gap in the numbers.
+	// It returns ErrInvoiceAlreadyIssued if the order already has an invoice of the type, or a
+	// credit note for the return.
+	Create(ctx context.Context, invoice *Invoice) error
+
+	// GetByID retrieves an invoice with its lines
+	GetByID(ctx context.Context, id uuid.UUID) (*Invoice, error)
+
+	// GetByOrderID retrieves the invoices and credit notes of an order with their lines, oldest first
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*Invoice, error)
+}
+
+// RecurringOrderRepository defines the interface for recurring order data operations
+type RecurringOrderRepository interface {
+	// Create creates a recurring order with its items
+	Create(ctx context.Context, order *RecurringOrder) error
+
+	// GetByID retrieves a recurring order with its items
+	GetByID(ctx context.Context, id uuid.UUID) (*RecurringOrder, error)
+
+	// GetByCustomerID retrieves the recurring orders of a customer with their items, oldest first
+	GetByCustomerID(ctx context.Context, customerID uuid.UUID) ([]*RecurringOrder, error)
+
+	// Update saves the status, schedule position, next order override and reminder of a
+	// recurring order if it is still at previousRevision, otherwise it returns
+	// ErrRecurringOrderConflict
+	Update(ctx context.Context, order *RecurringOrder, previousRevision int) error
+
+	// GetDue retrieves active recurring orders whose next order is due by now and paused ones
+	// whose pause has ended, earliest first
+	GetDue(ctx context.Context, now time.Time, limit int) ([]*RecurringOrder, error)
+
+	// GetUnreminded retrieves active recurring orders with a chat whose next order is due after
+	// now and by before and whose customer was not reminded of it yet, earliest first
+	GetUnreminded(ctx context.Context, now, before time.Time, limit int) ([]*RecurringOrder, error)
+
+	// CreateRun records an occurrence, or returns ErrRecurringOrderRunExists if it already has a run
+	CreateRun(ctx context.Context, run *RecurringOrderRun) error
+
+	// UpdateRun saves the outcome of a run
+	UpdateRun(ctx context.Context, run *RecurringOrderRun) error
+
+	// GetRuns retrieves the latest runs of a recurring order, newest first
+	GetRuns(ctx context.Context, recurringOrderID uuid.UUID, limit int) ([]*RecurringOrderRun, error)
+}
+
+// OrderStatsRepository defines the interface for the daily order statistics rollups.
+// The rollups are kept up to date by database triggers as orders and items change.
+type OrderStatsRepository interface {
+	// GetOrderStats retrieves the order rollup rows of the days from start up to, not including, end
+	GetOrderStats(ctx context.Context, start, end time.Time) ([]*OrderStatsRow, error)
+
+	// GetTopProducts sums the product rollup of the days from start up to, not including, end
+	// and returns the top products by order_count, revenue or quantity. A nil bound is open.
+	GetTopProducts(ctx context.Context, start, end *time.Time, sortBy string, limit int) ([]*ProductStatsRow, error)
+
+	// RebuildDay recalculates the rollups of a day from the orders. It must run in a transaction.
+	RebuildDay(ctx context.Context, day time.Time) error
+}
+
 // OrderAuditRepository defines the interface for order audit log operations
 type OrderAuditRepository interface {
 	// Create creates a new audit log entry
@@ -78,11 +233,13 @@ type OrderEventRepository interface {
 	// Create creates a new event in the outbox
 	Create(ctx context.Context, event *OrderEventOutbox) error
 
-	// GetPendingEvents retrieves all pending events for processing
-	GetPendingEvents(ctx context.Context, limit int) ([]*OrderEventOutbox, error)
+	// ClaimPendingEvents claims pending events for publishing until claimUntil by setting their
+	// next attempt to it. Events claimed by another relay are skipped until their claim expires.
+	ClaimPendingEvents(ctx context.Context, limit int, claimUntil time.Time) ([]*OrderEventOutbox, error)
 
-	// GetFailedEvents retrieves failed events that can be retried
-	GetFailedEvents(ctx context.Context, maxRetries int, limit int) ([]*OrderEventOutbox, error)
+	// ClaimFailedEvents claims failed events that can be retried and whose backoff has elapsed,
+	// like ClaimPendingEvents
+	ClaimFailedEvents(ctx context.Context, maxRetries int, limit int, claimUntil time.Time) ([]*OrderEventOutbox, error)
 
 	// UpdateStatus updates the status of an event
 	UpdateStatus(ctx context.Context, eventID uuid.UUID, status EventStatus) error
@@ -90,8 +247,14 @@ type OrderEventRepository interface {
 	// MarkAsSent marks an event as successfully sent
 	MarkAsSent(ctx context.Context, eventID uuid.UUID) error
 
-	// MarkAsFailed marks an event as failed and increments retry count
-	MarkAsFailed(ctx context.Context, eventID uuid.UUID) error
+	// MarkAsFailed marks an event as failed, increments retry count and schedules the next attempt
+	MarkAsFailed(ctx context.Context, eventID uuid.UUID, nextAttemptAt time.Time, lastError string) error
+
+	// MarkAsDeadLetter parks an event that exhausted its retries
+	MarkAsDeadLetter(ctx context.Context, eventID uuid.UUID, lastError string) error
+
+	// GetStats summarizes the outbox backlog
+	GetStats(ctx context.Context) (*OutboxStats, error)
 
 	// GetByOrderID retrieves all events for an order
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*OrderEventOutbox, error)
@@ -0,0 +1,359 @@
+package domain
+
+import (
+	"math"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReturnStatus represents the status of a return request
+type ReturnStatus string
+
+const (
+	ReturnStatusRequested ReturnStatus = "requested"
+	// ReturnStatusApproved means a pickup has been booked with the shipping service
+	ReturnStatusApproved ReturnStatus = "approved"
+	ReturnStatusRejected ReturnStatus = "rejected"
+	ReturnStatusReceived ReturnStatus = "received"
+	ReturnStatusRefunded ReturnStatus = "refunded"
+)
+
+// ReturnReason is why the customer sends an item back
+type ReturnReason string
+
+const (
+	ReturnReasonDamaged        ReturnReason = "damaged"
+	ReturnReasonDefective      ReturnReason = "defective"
+	ReturnReasonWrongItem      ReturnReason = "wrong_item"
+	ReturnReasonNotAsDescribed ReturnReason = "not_as_described"
+	ReturnReasonChangedMind    ReturnReason = "changed_mind"
+	ReturnReasonOther          ReturnReason = "other"
+)
+
+// IsValid reports whether the reason is one of the known reason codes
+func (r ReturnReason) IsValid() bool {
+	switch r {
+	case ReturnReasonDamaged, ReturnReasonDefective, ReturnReasonWrongItem,
+		ReturnReasonNotAsDescribed, ReturnReasonChangedMind, ReturnReasonOther:
+		return true
+	}
+	return false
+}
+
+// Restockable reports whether items returned for this reason go back into stock unless the
+// warehouse decides otherwise
+func (r ReturnReason) Restockable() bool {
+	return r != ReturnReasonDamaged && r != ReturnReasonDefective
+}
+
+// ReturnRequest is a customer's request to send delivered items of an order back for a refund
+type ReturnRequest struct {
+	ID                   uuid.UUID    `json:"id" db:"id"`
+	OrderID              uuid.UUID    `json:"order_id" db:"order_id"`
+	CustomerID           uuid.UUID    `json:"customer_id" db:"customer_id"`
+	Status               ReturnStatus `json:"status" db:"status"`
+	Notes                string       `json:"notes" db:"notes"`
+	RefundAmount         float64      `json:"refund_amount" db:"refund_amount"`
+	PickupDeliveryID     *uuid.UUID   `json:"pickup_delivery_id,omitempty" db:"pickup_delivery_id"`
+	PickupTrackingNumber *string      `json:"pickup_tracking_number,omitempty" db:"pickup_tracking_number"`
+	RejectionReason      *string      `json:"rejection_reason,omitempty" db:"rejection_reason"`
+	PaymentTransactionID *uuid.UUID   `json:"payment_transaction_id,omitempty" db:"payment_transaction_id"`
+	PointsClawedBack     int          `json:"points_clawed_back" db:"points_clawed_back"`
+	ApprovedAt           *time.Time   `json:"approved_at,omitempty" db:"approved_at"`
+	ReceivedAt           *time.Time   `json:"received_at,omitempty" db:"received_at"`
+	RefundedAt           *time.Time   `json:"refunded_at,omitempty" db:"refunded_at"`
+	CreatedAt            time.Time    `json:"created_at" db:"created_at"`
+	UpdatedAt            time.Time    `json:"updated_at" db:"updated_at"`
+	Items                []ReturnItem `json:"items"`
+}
+
+// ReturnItem is the quantity of an order item sent back in a return. Restock is decided when
+// the return is received.
+type ReturnItem struct {
+	ID           uuid.UUID    `json:"id" db:"id"`
+	ReturnID     uuid.UUID    `json:"return_id" db:"return_id"`
+	OrderItemID  uuid.UUID    `json:"order_item_id" db:"order_item_id"`
+	ProductID    uuid.UUID    `json:"product_id" db:"product_id"`
+	Quantity     int          `json:"quantity" db:"quantity"`
+	Reason       ReturnReason `json:"reason" db:"reason"`
+	RefundAmount float64      `json:"refund_amount" db:"refund_amount"`
+	Restock      *bool        `json:"restock,omitempty" db:"restock"`
+}
+
+// ReturnLine asks for a quantity of an order item to be returned
+type ReturnLine struct {
+	ItemID   uuid.UUID
+	Quantity int
+	Reason   ReturnReason
+}
+
+// ReturnableQuantity is the delivered quantity of the item that is not already being returned
+func (i *OrderItem) ReturnableQuantity() int {
+	return i.FulfilledQuantity - i.ReturnedQuantity
+}
+
+// CanReturn reports whether delivered items of the order may be returned
+func (o *Order) CanReturn() bool {
+	return o.Status == OrderStatusDelivered || o.Status == OrderStatusPartiallyDelivered
+}
+
+// RequestReturn opens a return for the given lines. The quantities are held against the items
+// until the return is rejected, so the same delivered item cannot be returned twice.
+func (o *Order) RequestReturn(lines []ReturnLine, notes string) (*ReturnRequest, error) {
+	if !o.CanReturn() {
+		return nil, ErrOrderCannotBeReturned
+	}
+	if len(lines) == 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	index := make(map[uuid.UUID]int, len(o.Items))
+	for i, item := range o.Items {
+		index[item.ID] = i
+	}
+
+	// Validate every line first so a bad line leaves the order untouched. Lines for the same
+	// item and reason are merged.
+	type lineKey struct {
+		itemID uuid.UUID
+		reason ReturnReason
+	}
+	quantities := make(map[lineKey]int)
+	perItem := make(map[uuid.UUID]int)
+	var order []lineKey
+	for _, line := range lines {
+		i, ok := index[line.ItemID]
+		if !ok {
+			return nil, ErrOrderItemNotFound
+		}
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if !line.Reason.IsValid() {
+			return nil, ErrInvalidReturnReason
+		}
+		key := lineKey{itemID: line.ItemID, reason: line.Reason}
+		if _, seen := quantities[key]; !seen {
+			order = append(order, key)
+		}
+		quantities[key] += line.Quantity
+		perItem[line.ItemID] += line.Quantity
+		if perItem[line.ItemID] > o.Items[i].ReturnableQuantity() {
+			return nil, ErrReturnExceedsFulfilled
+		}
+	}
+
+	now := time.Now()
+	request := &ReturnRequest{
+		ID:         uuid.New(),
+		OrderID:    o.ID,
+		CustomerID: o.CustomerID,
+		Status:     ReturnStatusRequested,
+		Notes:      notes,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+	}
+	for _, key := range order {
+		item := &o.Items[index[key.itemID]]
+		refund := o.RefundValue(item, quantities[key])
+		request.Items = append(request.Items, ReturnItem{
+			ID:           uuid.New(),
+			ReturnID:     request.ID,
+			OrderItemID:  item.ID,
+			ProductID:    item.ProductID,
+			Quantity:     quantities[key],
+			Reason:       key.reason,
+			RefundAmount: refund,
+		})
+		request.RefundAmount += refund
+	}
+	request.RefundAmount = roundMoney(request.RefundAmount)
+
+	for itemID, quantity := range perItem {
+		item := &o.Items[index[itemID]]
+		item.ReturnedQuantity += quantity
+		item.UpdatedAt = now
+	}
+
+	return request, nil
+}
+
+// RefundValue is what the customer paid for a quantity of an item. The order discount is spread
+// over the items in proportion to their price and VAT added on top of taxable items is refunded
+// with them; the shipping fee is not refunded.
+func (o *Order) RefundValue(item *OrderItem, quantity int) float64 {
+	itemsTotal := 0.0
+	for _, orderItem := range o.Items {
+		itemsTotal += orderItem.TotalPrice
+	}
+	if itemsTotal <= 0 {
+		return 0
+	}
+
+	paidForItems := itemsTotal - o.Discount
+	value := float64(quantity) * item.UnitPrice * paidForItems / itemsTotal
+	if o.TaxEnabled && o.TaxMode != TaxModeInclusive && !item.VATExempt {
+		value *= 1 + o.VATRate
+	}
+	return roundMoney(value)
+}
+
+// ApproveReturn records the pickup booked for a requested return
+func (r *ReturnRequest) ApproveReturn(deliveryID uuid.UUID, trackingNumber string) error {
+	if r.Status != ReturnStatusRequested {
+		return ErrInvalidReturnStatus
+	}
+
+	now := time.Now()
+	r.Status = ReturnStatusApproved
+	r.PickupDeliveryID = &deliveryID
+	if trackingNumber != "" {
+		r.PickupTrackingNumber = &trackingNumber
+	}
+	r.ApprovedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+// RejectReturn rejects a requested return and releases its quantities so the items can be
+// returned again
+func (o *Order) RejectReturn(r *ReturnRequest, reason string) error {
+	if r.OrderID != o.ID {
+		return ErrReturnNotFound
+	}
+	if r.Status != ReturnStatusRequested {
+		return ErrInvalidReturnStatus
+	}
+
+	index := make(map[uuid.UUID]int, len(o.Items))
+	for i, item := range o.Items {
+		index[item.ID] = i
+	}
+	for _, line := range r.Items {
+		if _, ok := index[line.OrderItemID]; !ok {
+			return ErrOrderItemNotFound
+		}
+	}
+
+	now := time.Now()
+	for _, line := range r.Items {
+		item := &o.Items[index[line.OrderItemID]]
+		item.ReturnedQuantity -= line.Quantity
+		item.UpdatedAt = now
+	}
+	r.Status = ReturnStatusRejected
+	r.RejectionReason = &reason
+	r.UpdatedAt = now
+	return nil
+}
+
+// ReceiveReturn records that the picked up items reached the warehouse together with the
+// restock decision for each of them. Items without a decision are restocked unless they were
+// returned damaged or defective.
+func (r *ReturnRequest) ReceiveReturn(restock map[uuid.UUID]bool) error {
+	if r.Status != ReturnStatusApproved {
+		return ErrInvalidReturnStatus
+	}
+	for itemID := range restock {
+		if !r.hasItem(itemID) {
+			return ErrReturnItemNotFound
+		}
+	}
+
+	for i := range r.Items {
+		item := &r.Items[i]
+		decision, ok := restock[item.ID]
+		if !ok {
+			decision = item.Reason.Restockable()
+		}
+		item.Restock = &decision
+	}
+
+	now := time.Now()
+	r.Status = ReturnStatusReceived
+	r.ReceivedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+// MarkRefunded records the refund of a received return
+func (r *ReturnRequest) MarkRefunded(paymentTransactionID *uuid.UUID, pointsClawedBack int) error {
+	if r.Status != ReturnStatusReceived {
+		return ErrInvalidReturnStatus
+	}
+
+	now := time.Now()
+	r.Status = ReturnStatusRefunded
+	r.PaymentTransactionID = paymentTransactionID
+	r.PointsClawedBack = pointsClawedBack
+	r.RefundedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+// RestockItems returns the items that go back into stock
+func (r *ReturnRequest) RestockItems() []ReturnItem {
+	var items []ReturnItem
+	for _, item := range r.Items {
+		if item.Restock != nil && *item.Restock {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// ApplyRefunds moves the order to refunded once refunded returns cover every item it holds.
+// It reports whether the order is now fully refunded.
+func (o *Order) ApplyRefunds(returns []*ReturnRequest) bool {
+	refunded := make(map[uuid.UUID]int)
+	for _, r := range returns {
+		if r.OrderID != o.ID || r.Status != ReturnStatusRefunded {
+			continue
+		}
+		for _, item := range r.Items {
+			refunded[item.OrderItemID] += item.Quantity
+		}
+	}
+
+	for _, item := range o.Items {
+		if refunded[item.ID] < item.Quantity {
+			return false
+		}
+	}
+	if len(o.Items) == 0 || o.UpdateStatus(OrderStatusRefunded) != nil {
+		return o.Status == OrderStatusRefunded
+	}
+	if o.IsValidPaidStatusTransition(o.PaidStatus, PaidStatusRefunded) {
+		o.PaidStatus = PaidStatusRefunded
+	}
+	return true
+}
+
+// Reasons lists the distinct reason codes of the returned items
+func (r *ReturnRequest) Reasons() string {
+	var reasons []string
+	seen := make(map[ReturnReason]bool)
+	for _, item := range r.Items {
+		if !seen[item.Reason] {
+			seen[item.Reason] = true
+			reasons = append(reasons, string(item.Reason))
+		}
+	}
+	return strings.Join(reasons, ", ")
+}
+
+func (r *ReturnRequest) hasItem(itemID uuid.UUID) bool {
+	for _, item := range r.Items {
+		if item.ID == itemID {
+			return true
+		}
+	}
+	return false
+}
+
+// roundMoney rounds an amount to satang
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,172 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeliveredOrder(t *testing.T) *Order {
+	order := newConfirmedOrder()
+	shipment, err := order.Ship([]ShipmentLine{
+		{ItemID: order.Items[0].ID, Quantity: 2},
+		{ItemID: order.Items[1].ID, Quantity: 3},
+	})
+	require.NoError(t, err)
+	require.NoError(t, order.DeliverShipment(shipment))
+	order.PaidStatus = PaidStatusPaid
+	return order
+}
+
+func TestRequestReturnHoldsQuantitiesAndPricesRefund(t *testing.T) {
+	order := newDeliveredOrder(t)
+	require.NoError(t, order.ApplyDiscount(35))
+	require.NoError(t, order.SetShippingFee(40))
+
+	request, err := order.RequestReturn([]ReturnLine{
+		{ItemID: order.Items[0].ID, Quantity: 1, Reason: ReturnReasonDamaged},
+		{ItemID: order.Items[1].ID, Quantity: 1, Reason: ReturnReasonChangedMind},
+		{ItemID: order.Items[1].ID, Quantity: 1, Reason: ReturnReasonChangedMind},
+	}, "box was crushed")
+	require.NoError(t, err)
+
+	assert.Equal(t, ReturnStatusRequested, request.Status)
+	assert.Equal(t, order.CustomerID, request.CustomerID)
+	require.Len(t, request.Items, 2)
+	assert.Equal(t, 2, request.Items[1].Quantity)
+
+	// The 35 discount on 350 of items is spread over the lines; shipping is not refunded
+	assert.Equal(t, 90.0, request.Items[0].RefundAmount)
+	assert.Equal(t, 90.0, request.Items[1].RefundAmount)
+	assert.Equal(t, 180.0, request.RefundAmount)
+
+	assert.Equal(t, 1, order.Items[0].ReturnedQuantity)
+	assert.Equal(t, 2, order.Items[1].ReturnedQuantity)
+	assert.Equal(t, 1, order.Items[1].ReturnableQuantity())
+}
+
+func TestRequestReturnRejectsInvalidReturnsWithoutChangingOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		status OrderStatus
+		lines  func(order *Order) []ReturnLine
+		err    error
+	}{
+		{
+			name:   "order not delivered",
+			status: OrderStatusShipped,
+			lines: func(order *Order) []ReturnLine {
+				return []ReturnLine{{ItemID: order.Items[0].ID, Quantity: 1, Reason: ReturnReasonOther}}
+			},
+			err: ErrOrderCannotBeReturned,
+		},
+		{
+			name:  "no lines",
+			lines: func(order *Order) []ReturnLine { return nil },
+			err:   ErrInvalidQuantity,
+		},
+		{
+			name: "unknown item",
+			lines: func(order *Order) []ReturnLine {
+				return []ReturnLine{{ItemID: uuid.New(), Quantity: 1, Reason: ReturnReasonOther}}
+			},
+			err: ErrOrderItemNotFound,
+		},
+		{
+			name: "unknown reason",
+			lines: func(order *Order) []ReturnLine {
+				return []ReturnLine{{ItemID: order.Items[0].ID, Quantity: 1, Reason: "too_expensive"}}
+			},
+			err: ErrInvalidReturnReason,
+		},
+		{
+			name: "more than delivered",
+			lines: func(order *Order) []ReturnLine {
+				return []ReturnLine{
+					{ItemID: order.Items[0].ID, Quantity: 1, Reason: ReturnReasonDamaged},
+					{ItemID: order.Items[0].ID, Quantity: 2, Reason: ReturnReasonOther},
+				}
+			},
+			err: ErrReturnExceedsFulfilled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newDeliveredOrder(t)
+			if tt.status != "" {
+				order.Status = tt.status
+			}
+			items := append([]OrderItem(nil), order.Items...)
+
+			_, err := order.RequestReturn(tt.lines(order), "")
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, items, order.Items)
+		})
+	}
+}
+
+func TestRejectReturnReleasesQuantities(t *testing.T) {
+	order := newDeliveredOrder(t)
+	request, err := order.RequestReturn([]ReturnLine{{ItemID: order.Items[0].ID, Quantity: 2, Reason: ReturnReasonChangedMind}}, "")
+	require.NoError(t, err)
+
+	require.NoError(t, order.RejectReturn(request, "outside the return window"))
+
+	assert.Equal(t, ReturnStatusRejected, request.Status)
+	assert.Equal(t, 0, order.Items[0].ReturnedQuantity)
+	assert.ErrorIs(t, order.RejectReturn(request, "again"), ErrInvalidReturnStatus)
+
+	// The released items can be returned again
+	_, err = order.RequestReturn([]ReturnLine{{ItemID: order.Items[0].ID, Quantity: 2, Reason: ReturnReasonOther}}, "")
+	assert.NoError(t, err)
+}
+
+func TestReceiveReturnDecidesRestock(t *testing.T) {
+	order := newDeliveredOrder(t)
+	request, err := order.RequestReturn([]ReturnLine{
+		{ItemID: order.Items[0].ID, Quantity: 1, Reason: ReturnReasonDefective},
+		{ItemID: order.Items[1].ID, Quantity: 1, Reason: ReturnReasonWrongItem},
+		{ItemID: order.Items[1].ID, Quantity: 1, Reason: ReturnReasonDamaged},
+	}, "")
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, request.ReceiveReturn(nil), ErrInvalidReturnStatus)
+	require.NoError(t, request.ApproveReturn(uuid.New(), "TRK-1"))
+	assert.ErrorIs(t, request.ReceiveReturn(map[uuid.UUID]bool{uuid.New(): true}), ErrReturnItemNotFound)
+
+	// The warehouse finds the damaged box fine; the others follow their reason
+	require.NoError(t, request.ReceiveReturn(map[uuid.UUID]bool{request.Items[2].ID: true}))
+
+	assert.Equal(t, ReturnStatusReceived, request.Status)
+	assert.False(t, *request.Items[0].Restock)
+	assert.True(t, *request.Items[1].Restock)
+	assert.True(t, *request.Items[2].Restock)
+	assert.Len(t, request.RestockItems(), 2)
+}
+
+func TestApplyRefundsRefundsOrderOnceEveryItemIsReturned(t *testing.T) {
+	order := newDeliveredOrder(t)
+
+	refund := func(lines []ReturnLine) *ReturnRequest {
+		request, err := order.RequestReturn(lines, "")
+		require.NoError(t, err)
+		require.NoError(t, request.ApproveReturn(uuid.New(), ""))
+		require.NoError(t, request.ReceiveReturn(nil))
+		require.NoError(t, request.MarkRefunded(nil, 0))
+		return request
+	}
+
+	first := refund([]ReturnLine{{ItemID: order.Items[0].ID, Quantity: 2, Reason: ReturnReasonOther}})
+	assert.False(t, order.ApplyRefunds([]*ReturnRequest{first}))
+	assert.Equal(t, OrderStatusDelivered, order.Status)
+	assert.Equal(t, PaidStatusPaid, order.PaidStatus)
+
+	second := refund([]ReturnLine{{ItemID: order.Items[1].ID, Quantity: 3, Reason: ReturnReasonOther}})
+	assert.True(t, order.ApplyRefunds([]*ReturnRequest{first, second}))
+	assert.Equal(t, OrderStatusRefunded, order.Status)
+	assert.Equal(t, PaidStatusRefunded, order.PaidStatus)
+}
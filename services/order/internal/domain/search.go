@@ -0,0 +1,171 @@
+package domain
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderSortField is a column an order search can be sorted by
+type OrderSortField string
+
+const (
+	OrderSortCreatedAt   OrderSortField = "created_at"
+	OrderSortUpdatedAt   OrderSortField = "updated_at"
+	OrderSortTotalAmount OrderSortField = "total_amount"
+)
+
+const (
+	// DefaultOrderSearchLimit is the page size when none is given
+	DefaultOrderSearchLimit = 20
+	// MaxOrderSearchLimit is the largest page an order search returns
+	MaxOrderSearchLimit = 100
+	// maxOrderSearchText bounds the free-text search on code and notes
+	maxOrderSearchText = 100
+)
+
+// OrderFilter narrows an order search. Empty fields match every order, values within one field
+// match any of them.
+type OrderFilter struct {
+	Statuses       []OrderStatus
+	Sources        []OrderSource
+	PaidStatuses   []PaidStatus
+	PaymentMethods []PaymentMethod
+	CustomerID     *uuid.UUID
+	// CreatedFrom is inclusive, CreatedTo exclusive
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	// Text matches part of the order code or notes, ignoring case
+	Text      string
+	MinAmount *float64
+	MaxAmount *float64
+}
+
+// OrderSearch asks for a page of orders. Orders are sorted by SortBy with the order ID breaking
+// ties, so a page continues exactly where the cursor of the previous one left off however many
+// orders come before it.
+type OrderSearch struct {
+	Filter     OrderFilter
+	SortBy     OrderSortField
+	Descending bool
+	After      *OrderCursor
+	Limit      int
+}
+
+// Validate checks the search and fills in the default sort and page size
+func (s *OrderSearch) Validate() error {
+	if s.SortBy == "" {
+		s.SortBy = OrderSortCreatedAt
+		s.Descending = true
+	}
+	switch s.SortBy {
+	case OrderSortCreatedAt, OrderSortUpdatedAt, OrderSortTotalAmount:
+	default:
+		return fmt.Errorf("%w: cannot sort by %q", ErrInvalidOrderSearch, s.SortBy)
+	}
+
+	if s.Limit <= 0 {
+		s.Limit = DefaultOrderSearchLimit
+	}
+	if s.Limit > MaxOrderSearchLimit {
+		return fmt.Errorf("%w: limit cannot exceed %d", ErrInvalidOrderSearch, MaxOrderSearchLimit)
+	}
+
+	f := &s.Filter
+	for _, status := range f.Statuses {
+		if !status.IsValid() {
+			return fmt.Errorf("%w: unknown status %q", ErrInvalidOrderSearch, status)
+		}
+	}
+	for _, source := range f.Sources {
+		if !source.IsValid() {
+			return fmt.Errorf("%w: unknown source %q", ErrInvalidOrderSearch, source)
+		}
+	}
+	for _, paidStatus := range f.PaidStatuses {
+		if !paidStatus.IsValid() {
+			return fmt.Errorf("%w: unknown paid status %q", ErrInvalidOrderSearch, paidStatus)
+		}
+	}
+	for _, method := range f.PaymentMethods {
+		if !method.IsValid() {
+			return fmt.Errorf("%w: unknown payment method %q", ErrInvalidOrderSearch, method)
+		}
+	}
+	if f.CreatedFrom != nil && f.CreatedTo != nil && !f.CreatedTo.After(*f.CreatedFrom) {
+		return fmt.Errorf("%w: the date range is empty", ErrInvalidOrderSearch)
+	}
+	if f.MinAmount != nil && f.MaxAmount != nil && *f.MinAmount > *f.MaxAmount {
+		return fmt.Errorf("%w: the minimum amount is above the maximum", ErrInvalidOrderSearch)
+	}
+	f.Text = strings.TrimSpace(f.Text)
+	if len(f.Text) > maxOrderSearchText {
+		return fmt.Errorf("%w: search text cannot exceed %d characters", ErrInvalidOrderSearch, maxOrderSearchText)
+	}
+
+	// A cursor only continues the sort it was made for
+	if s.After != nil && (s.After.SortBy != s.SortBy || s.After.Descending != s.Descending) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// CursorAfter returns the cursor of the page that starts after order
+func (s *OrderSearch) CursorAfter(order *Order) *OrderCursor {
+	cursor := &OrderCursor{SortBy: s.SortBy, Descending: s.Descending, ID: order.ID}
+	switch s.SortBy {
+	case OrderSortUpdatedAt:
+		cursor.Value = order.UpdatedAt.UTC().Format(time.RFC3339Nano)
+	case OrderSortTotalAmount:
+		cursor.Value = strconv.FormatFloat(order.TotalAmount, 'f', -1, 64)
+	default:
+		cursor.Value = order.CreatedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return cursor
+}
+
+// OrderCursor marks the last order of a page. Value is that order's sort value.
+type OrderCursor struct {
+	SortBy     OrderSortField `json:"s"`
+	Descending bool           `json:"d,omitempty"`
+	Value      string         `json:"v"`
+	ID         uuid.UUID      `json:"id"`
+}
+
+// Encode returns the cursor as an opaque token for clients
+func (c *OrderCursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeOrderCursor parses a token made by Encode
+func DecodeOrderCursor(token string) (*OrderCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	cursor := &OrderCursor{}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID == uuid.Nil {
+		return nil, ErrInvalidCursor
+	}
+
+	switch cursor.SortBy {
+	case OrderSortCreatedAt, OrderSortUpdatedAt:
+		_, err = time.Parse(time.RFC3339Nano, cursor.Value)
+	case OrderSortTotalAmount:
+		_, err = strconv.ParseFloat(cursor.Value, 64)
+	default:
+		err = ErrInvalidCursor
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
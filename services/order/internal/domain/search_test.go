@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderSearchValidateFillsDefaults(t *testing.T) {
+	search := &OrderSearch{Filter: OrderFilter{Text: "  ORD2025  "}}
+
+	require.NoError(t, search.Validate())
+
+	assert.Equal(t, OrderSortCreatedAt, search.SortBy)
+	assert.True(t, search.Descending)
+	assert.Equal(t, DefaultOrderSearchLimit, search.Limit)
+	assert.Equal(t, "ORD2025", search.Filter.Text)
+}
+
+func TestOrderSearchValidateRejectsInvalidSearches(t *testing.T) {
+	from := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC)
+	min, max := 500.0, 100.0
+
+	tests := []struct {
+		name   string
+		search OrderSearch
+		err    error
+	}{
+		{name: "unknown sort", search: OrderSearch{SortBy: "customer_id"}, err: ErrInvalidOrderSearch},
+		{name: "page too large", search: OrderSearch{Limit: MaxOrderSearchLimit + 1}, err: ErrInvalidOrderSearch},
+		{name: "unknown status", search: OrderSearch{Filter: OrderFilter{Statuses: []OrderStatus{"lost"}}}, err: ErrInvalidOrderSearch},
+		{name: "unknown source", search: OrderSearch{Filter: OrderFilter{Sources: []OrderSource{"fax"}}}, err: ErrInvalidOrderSearch},
+		{name: "unknown paid status", search: OrderSearch{Filter: OrderFilter{PaidStatuses: []PaidStatus{"owed"}}}, err: ErrInvalidOrderSearch},
+		{name: "unknown payment method", search: OrderSearch{Filter: OrderFilter{PaymentMethods: []PaymentMethod{"cheque"}}}, err: ErrInvalidOrderSearch},
+		{name: "empty date range", search: OrderSearch{Filter: OrderFilter{CreatedFrom: &from, CreatedTo: &from}}, err: ErrInvalidOrderSearch},
+		{name: "inverted amount range", search: OrderSearch{Filter: OrderFilter{MinAmount: &min, MaxAmount: &max}}, err: ErrInvalidOrderSearch},
+		{
+			name: "cursor of another sort",
+			search: OrderSearch{
+				SortBy: OrderSortTotalAmount,
+				After:  &OrderCursor{SortBy: OrderSortCreatedAt, Descending: true, Value: "2025-06-01T00:00:00Z", ID: uuid.New()},
+			},
+			err: ErrInvalidCursor,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			search := tt.search
+			assert.ErrorIs(t, search.Validate(), tt.err)
+		})
+	}
+}
+
+func TestOrderCursorRoundTrip(t *testing.T) {
+	order := NewOrder(uuid.New(), "1 Sukhumvit Rd", "1 Sukhumvit Rd", "")
+	order.CreatedAt = time.Date(2025, 6, 29, 14, 3, 11, 123456000, time.FixedZone("ICT", 7*60*60))
+	order.TotalAmount = 1234.5
+
+	for _, sortBy := range []OrderSortField{OrderSortCreatedAt, OrderSortTotalAmount} {
+		search := &OrderSearch{SortBy: sortBy, Descending: true}
+		require.NoError(t, search.Validate())
+
+		cursor, err := DecodeOrderCursor(search.CursorAfter(order).Encode())
+		require.NoError(t, err)
+
+		assert.Equal(t, order.ID, cursor.ID)
+		assert.Equal(t, sortBy, cursor.SortBy)
+		assert.True(t, cursor.Descending)
+		search.After = cursor
+		assert.NoError(t, search.Validate())
+	}
+
+	search := &OrderSearch{SortBy: OrderSortCreatedAt}
+	assert.Equal(t, "2025-06-29T07:03:11.123456Z", search.CursorAfter(order).Value)
+	search.SortBy = OrderSortTotalAmount
+	assert.Equal(t, "1234.5", search.CursorAfter(order).Value)
+}
+
+func TestDecodeOrderCursorRejectsTamperedTokens(t *testing.T) {
+	valid := &OrderCursor{SortBy: OrderSortTotalAmount, Value: "99.5", ID: uuid.New()}
+
+	for _, token := range []string{
+		"not base64!",
+		"bm90IGpzb24",
+		(&OrderCursor{SortBy: OrderSortTotalAmount, Value: "99.5"}).Encode(),
+		(&OrderCursor{SortBy: OrderSortTotalAmount, Value: "lots", ID: valid.ID}).Encode(),
+		(&OrderCursor{SortBy: OrderSortCreatedAt, Value: "yesterday", ID: valid.ID}).Encode(),
+		(&OrderCursor{SortBy: "id", Value: "1", ID: valid.ID}).Encode(),
+	} {
+		_, err := DecodeOrderCursor(token)
+		assert.ErrorIs(t, err, ErrInvalidCursor, token)
+	}
+
+	cursor, err := DecodeOrderCursor(valid.Encode())
+	require.NoError(t, err)
+	assert.Equal(t, valid, cursor)
+}
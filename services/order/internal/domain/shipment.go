@@ -0,0 +1,193 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ShipmentStatus represents the status of a shipment
+type ShipmentStatus string
+
+const (
+	ShipmentStatusInTransit ShipmentStatus = "in_transit"
+	ShipmentStatusDelivered ShipmentStatus = "delivered"
+)
+
+// Shipment is one delivery of some or all of an order's items
+type Shipment struct {
+	ID             uuid.UUID      `json:"id" db:"id"`
+	OrderID        uuid.UUID      `json:"order_id" db:"order_id"`
+	DeliveryID     *uuid.UUID     `json:"delivery_id,omitempty" db:"delivery_id"`
+	TrackingNumber *string        `json:"tracking_number,omitempty" db:"tracking_number"`
+	Carrier        *string        `json:"carrier,omitempty" db:"carrier"`
+	Status         ShipmentStatus `json:"status" db:"status"`
+	ShippedAt      time.Time      `json:"shipped_at" db:"shipped_at"`
+	DeliveredAt    *time.Time     `json:"delivered_at,omitempty" db:"delivered_at"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+	Items          []ShipmentItem `json:"items"`
+}
+
+// ShipmentItem is the quantity of an order item sent in a shipment
+type ShipmentItem struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	ShipmentID  uuid.UUID `json:"shipment_id" db:"shipment_id"`
+	OrderItemID uuid.UUID `json:"order_item_id" db:"order_item_id"`
+	ProductID   uuid.UUID `json:"product_id" db:"product_id"`
+	Quantity    int       `json:"quantity" db:"quantity"`
+}
+
+// ShipmentLine asks for a quantity of an order item to be shipped
+type ShipmentLine struct {
+	ItemID   uuid.UUID
+	Quantity int
+}
+
+// RemainingQuantity is the quantity of the item that has not been shipped yet
+func (i *OrderItem) RemainingQuantity() int {
+	return i.Quantity - i.ShippedQuantity
+}
+
+// CanShip reports whether items of the order may be shipped
+func (o *Order) CanShip() bool {
+	switch o.Status {
+	case OrderStatusConfirmed, OrderStatusProcessing, OrderStatusPartiallyShipped, OrderStatusPartiallyDelivered:
+		return true
+	}
+	return false
+}
+
+// Ship sends the given lines in a new shipment. Whatever the order still has to ship afterwards
+// is backordered, and the order status follows the item quantities.
+func (o *Order) Ship(lines []ShipmentLine) (*Shipment, error) {
+	if !o.CanShip() {
+		return nil, ErrOrderCannotBeShipped
+	}
+	if len(lines) == 0 {
+		return nil, ErrInvalidQuantity
+	}
+
+	index := make(map[uuid.UUID]int, len(o.Items))
+	for i, item := range o.Items {
+		index[item.ID] = i
+	}
+
+	// Validate every line first so a bad line leaves the order untouched
+	quantities := make(map[uuid.UUID]int)
+	var order []uuid.UUID
+	for _, line := range lines {
+		i, ok := index[line.ItemID]
+		if !ok {
+			return nil, ErrOrderItemNotFound
+		}
+		if line.Quantity <= 0 {
+			return nil, ErrInvalidQuantity
+		}
+		if _, seen := quantities[line.ItemID]; !seen {
+			order = append(order, line.ItemID)
+		}
+		quantities[line.ItemID] += line.Quantity
+		if quantities[line.ItemID] > o.Items[i].RemainingQuantity() {
+			return nil, ErrShipmentExceedsOrder
+		}
+	}
+
+	now := time.Now()
+	shipment := &Shipment{
+		ID:        uuid.New(),
+		OrderID:   o.ID,
+		Status:    ShipmentStatusInTransit,
+		ShippedAt: now,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for _, itemID := range order {
+		item := &o.Items[index[itemID]]
+		item.ShippedQuantity += quantities[itemID]
+		shipment.Items = append(shipment.Items, ShipmentItem{
+			ID:          uuid.New(),
+			ShipmentID:  shipment.ID,
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    quantities[itemID],
+		})
+	}
+
+	for i := range o.Items {
+		item := &o.Items[i]
+		item.BackorderedQuantity = item.RemainingQuantity()
+		item.UpdatedAt = now
+	}
+
+	o.DeriveStatus()
+	return shipment, nil
+}
+
+// DeliverShipment records that the shipment reached the customer
+func (o *Order) DeliverShipment(shipment *Shipment) error {
+	if shipment.OrderID != o.ID {
+		return ErrShipmentNotFound
+	}
+	if shipment.Status != ShipmentStatusInTransit {
+		return ErrShipmentNotInTransit
+	}
+
+	index := make(map[uuid.UUID]int, len(o.Items))
+	for i, item := range o.Items {
+		index[item.ID] = i
+	}
+	for _, line := range shipment.Items {
+		if _, ok := index[line.OrderItemID]; !ok {
+			return ErrOrderItemNotFound
+		}
+	}
+
+	now := time.Now()
+	for _, line := range shipment.Items {
+		item := &o.Items[index[line.OrderItemID]]
+		item.FulfilledQuantity += line.Quantity
+		item.UpdatedAt = now
+	}
+	shipment.Status = ShipmentStatusDelivered
+	shipment.DeliveredAt = &now
+	shipment.UpdatedAt = now
+
+	o.DeriveStatus()
+	return nil
+}
+
+// DeriveStatus sets the status of an order that is being fulfilled from its item quantities.
+// Orders that have not started shipping, or are cancelled or refunded, keep their status.
+func (o *Order) DeriveStatus() {
+	switch o.Status {
+	case OrderStatusConfirmed, OrderStatusProcessing, OrderStatusPartiallyShipped,
+		OrderStatusShipped, OrderStatusPartiallyDelivered:
+	default:
+		return
+	}
+
+	var ordered, shipped, fulfilled int
+	for _, item := range o.Items {
+		ordered += item.Quantity
+		shipped += item.ShippedQuantity
+		fulfilled += item.FulfilledQuantity
+	}
+
+	status := o.Status
+	switch {
+	case ordered > 0 && fulfilled >= ordered:
+		status = OrderStatusDelivered
+	case fulfilled > 0:
+		status = OrderStatusPartiallyDelivered
+	case ordered > 0 && shipped >= ordered:
+		status = OrderStatusShipped
+	case shipped > 0:
+		status = OrderStatusPartiallyShipped
+	}
+
+	if status != o.Status {
+		o.Status = status
+		o.UpdatedAt = time.Now()
+	}
+}
@@ -0,0 +1,151 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfirmedOrder() *Order {
+	order := NewOrder(uuid.New(), "1 Old Road", "1 Old Road", "")
+	order.AddItem(uuid.New(), 2, 100)
+	order.AddItem(uuid.New(), 3, 50)
+	order.Status = OrderStatusConfirmed
+	return order
+}
+
+func TestShipPartOfOrderBackordersTheRest(t *testing.T) {
+	order := newConfirmedOrder()
+	inStock, outOfStock := order.Items[0], order.Items[1]
+
+	shipment, err := order.Ship([]ShipmentLine{{ItemID: inStock.ID, Quantity: 2}})
+	require.NoError(t, err)
+
+	assert.Equal(t, OrderStatusPartiallyShipped, order.Status)
+	assert.Equal(t, ShipmentStatusInTransit, shipment.Status)
+	require.Len(t, shipment.Items, 1)
+	assert.Equal(t, inStock.ID, shipment.Items[0].OrderItemID)
+	assert.Equal(t, 2, shipment.Items[0].Quantity)
+
+	assert.Equal(t, 2, order.Items[0].ShippedQuantity)
+	assert.Equal(t, 0, order.Items[0].BackorderedQuantity)
+	assert.Equal(t, 0, order.Items[1].ShippedQuantity)
+	assert.Equal(t, 3, order.Items[1].BackorderedQuantity)
+
+	// The backorder goes out in a second shipment once stock arrives
+	_, err = order.Ship([]ShipmentLine{{ItemID: outOfStock.ID, Quantity: 3}})
+	require.NoError(t, err)
+
+	assert.Equal(t, OrderStatusShipped, order.Status)
+	assert.Equal(t, 0, order.Items[1].BackorderedQuantity)
+}
+
+func TestDeliverShipmentsDerivesStatus(t *testing.T) {
+	order := newConfirmedOrder()
+
+	first, err := order.Ship([]ShipmentLine{{ItemID: order.Items[0].ID, Quantity: 2}})
+	require.NoError(t, err)
+	second, err := order.Ship([]ShipmentLine{{ItemID: order.Items[1].ID, Quantity: 3}})
+	require.NoError(t, err)
+
+	require.NoError(t, order.DeliverShipment(first))
+	assert.Equal(t, OrderStatusPartiallyDelivered, order.Status)
+	assert.Equal(t, ShipmentStatusDelivered, first.Status)
+	assert.NotNil(t, first.DeliveredAt)
+	assert.Equal(t, 2, order.Items[0].FulfilledQuantity)
+
+	assert.ErrorIs(t, order.DeliverShipment(first), ErrShipmentNotInTransit)
+
+	require.NoError(t, order.DeliverShipment(second))
+	assert.Equal(t, OrderStatusDelivered, order.Status)
+	assert.Equal(t, 3, order.Items[1].FulfilledQuantity)
+}
+
+func TestShipMergesLinesForTheSameItem(t *testing.T) {
+	order := newConfirmedOrder()
+	itemID := order.Items[1].ID
+
+	shipment, err := order.Ship([]ShipmentLine{{ItemID: itemID, Quantity: 1}, {ItemID: itemID, Quantity: 1}})
+	require.NoError(t, err)
+
+	require.Len(t, shipment.Items, 1)
+	assert.Equal(t, 2, shipment.Items[0].Quantity)
+	assert.Equal(t, 1, order.Items[1].BackorderedQuantity)
+}
+
+func TestShipRejectsInvalidShipmentsWithoutChangingOrder(t *testing.T) {
+	tests := []struct {
+		name   string
+		status OrderStatus
+		lines  func(order *Order) []ShipmentLine
+		err    error
+	}{
+		{
+			name:   "pending order",
+			status: OrderStatusPending,
+			lines: func(order *Order) []ShipmentLine {
+				return []ShipmentLine{{ItemID: order.Items[0].ID, Quantity: 1}}
+			},
+			err: ErrOrderCannotBeShipped,
+		},
+		{
+			name:  "no lines",
+			lines: func(order *Order) []ShipmentLine { return nil },
+			err:   ErrInvalidQuantity,
+		},
+		{
+			name: "unknown item",
+			lines: func(order *Order) []ShipmentLine {
+				return []ShipmentLine{{ItemID: uuid.New(), Quantity: 1}}
+			},
+			err: ErrOrderItemNotFound,
+		},
+		{
+			name: "zero quantity",
+			lines: func(order *Order) []ShipmentLine {
+				return []ShipmentLine{{ItemID: order.Items[0].ID, Quantity: 0}}
+			},
+			err: ErrInvalidQuantity,
+		},
+		{
+			name: "more than ordered",
+			lines: func(order *Order) []ShipmentLine {
+				return []ShipmentLine{
+					{ItemID: order.Items[1].ID, Quantity: 1},
+					{ItemID: order.Items[0].ID, Quantity: 2},
+					{ItemID: order.Items[0].ID, Quantity: 1},
+				}
+			},
+			err: ErrShipmentExceedsOrder,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := newConfirmedOrder()
+			if tt.status != "" {
+				order.Status = tt.status
+			}
+			status := order.Status
+			items := append([]OrderItem(nil), order.Items...)
+
+			_, err := order.Ship(tt.lines(order))
+
+			assert.ErrorIs(t, err, tt.err)
+			assert.Equal(t, items, order.Items)
+			assert.Equal(t, status, order.Status)
+		})
+	}
+}
+
+func TestDeriveStatusKeepsStatusOutsideFulfilment(t *testing.T) {
+	order := newConfirmedOrder()
+	order.Items[0].ShippedQuantity = 2
+	order.Status = OrderStatusCancelled
+
+	order.DeriveStatus()
+
+	assert.Equal(t, OrderStatusCancelled, order.Status)
+}
@@ -0,0 +1,121 @@
+package domain
+
+import (
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OrderStatsRow is a row of the daily order rollup: the orders placed in one hour of a day with
+// the same source, status and payment method. Days and hours are in UTC.
+type OrderStatsRow struct {
+	Date          time.Time   `db:"stat_date"`
+	Hour          int         `db:"hour"`
+	Source        OrderSource `db:"source"`
+	Status        OrderStatus `db:"status"`
+	PaymentMethod string      `db:"payment_method"`
+	OrdersCount   int         `db:"orders_count"`
+	Revenue       float64     `db:"revenue"`
+	Discount      float64     `db:"discount"`
+}
+
+// ProductStatsRow sums the items ordered of a product over a range of days
+type ProductStatsRow struct {
+	ProductID   uuid.UUID  `db:"product_id"`
+	OrderCount  int        `db:"order_count"`
+	Quantity    int        `db:"quantity"`
+	Revenue     float64    `db:"revenue"`
+	LastOrderAt *time.Time `db:"last_order_at"`
+}
+
+// StatsBucket counts the orders and revenue of one group in a breakdown
+type StatsBucket struct {
+	OrdersCount int
+	Revenue     float64
+}
+
+// AvgOrderValue is the average revenue per order of the bucket
+func (b StatsBucket) AvgOrderValue() float64 {
+	if b.OrdersCount == 0 {
+		return 0
+	}
+	return b.Revenue / float64(b.OrdersCount)
+}
+
+// KeyedStatsBucket is a bucket of a breakdown by source or payment method
+type KeyedStatsBucket struct {
+	Key string
+	StatsBucket
+}
+
+// OrderStatsSummary sums rollup rows with breakdowns by status, hour of day, source and
+// payment method
+type OrderStatsSummary struct {
+	StatsBucket
+	Discount        float64
+	ByStatus        map[OrderStatus]int
+	ByHour          [24]StatsBucket
+	BySource        []KeyedStatsBucket
+	ByPaymentMethod []KeyedStatsBucket
+}
+
+// SummarizeOrderStats sums rollup rows. The source and payment method breakdowns are sorted by
+// revenue, highest first; orders without a payment method are grouped under an empty key.
+func SummarizeOrderStats(rows []*OrderStatsRow) *OrderStatsSummary {
+	summary := &OrderStatsSummary{ByStatus: make(map[OrderStatus]int)}
+	sources := make(map[string]*StatsBucket)
+	methods := make(map[string]*StatsBucket)
+
+	for _, row := range rows {
+		summary.OrdersCount += row.OrdersCount
+		summary.Revenue += row.Revenue
+		summary.Discount += row.Discount
+		summary.ByStatus[row.Status] += row.OrdersCount
+		if row.Hour >= 0 && row.Hour < len(summary.ByHour) {
+			summary.ByHour[row.Hour].OrdersCount += row.OrdersCount
+			summary.ByHour[row.Hour].Revenue += row.Revenue
+		}
+		addToBucket(sources, string(row.Source), row)
+		addToBucket(methods, row.PaymentMethod, row)
+	}
+
+	summary.Revenue = roundMoney(summary.Revenue)
+	summary.Discount = roundMoney(summary.Discount)
+	for hour := range summary.ByHour {
+		summary.ByHour[hour].Revenue = roundMoney(summary.ByHour[hour].Revenue)
+	}
+	summary.BySource = sortedBuckets(sources)
+	summary.ByPaymentMethod = sortedBuckets(methods)
+	return summary
+}
+
+func addToBucket(buckets map[string]*StatsBucket, key string, row *OrderStatsRow) {
+	bucket, ok := buckets[key]
+	if !ok {
+		bucket = &StatsBucket{}
+		buckets[key] = bucket
+	}
+	bucket.OrdersCount += row.OrdersCount
+	bucket.Revenue += row.Revenue
+}
+
+func sortedBuckets(buckets map[string]*StatsBucket) []KeyedStatsBucket {
+	sorted := make([]KeyedStatsBucket, 0, len(buckets))
+	for key, bucket := range buckets {
+		if bucket.OrdersCount == 0 {
+			continue
+		}
+		sorted = append(sorted, KeyedStatsBucket{
+			Key:         key,
+			StatsBucket: StatsBucket{OrdersCount: bucket.OrdersCount, Revenue: roundMoney(bucket.Revenue)},
+		})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Revenue != sorted[j].Revenue {
+			return sorted[i].Revenue > sorted[j].Revenue
+		}
+		return sorted[i].Key < sorted[j].Key
+	})
+	return sorted
+}
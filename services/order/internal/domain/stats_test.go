@@ -0,0 +1,54 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummarizeOrderStatsBreaksDownRollupRows(t *testing.T) {
+	day := time.Date(2025, 6, 29, 0, 0, 0, 0, time.UTC)
+	rows := []*OrderStatsRow{
+		{Date: day, Hour: 9, Source: OrderSourceOnline, Status: OrderStatusPending, PaymentMethod: "qr_code", OrdersCount: 2, Revenue: 300.10, Discount: 10},
+		{Date: day, Hour: 9, Source: OrderSourceLINE, Status: OrderStatusConfirmed, PaymentMethod: "qr_code", OrdersCount: 1, Revenue: 500.20},
+		{Date: day, Hour: 20, Source: OrderSourceOnline, Status: OrderStatusCancelled, PaymentMethod: "", OrdersCount: 1, Revenue: 99.70, Discount: 5},
+		// Every order of this group was deleted after the rollup was written
+		{Date: day, Hour: 21, Source: OrderSourcePOS, Status: OrderStatusPending, PaymentMethod: "cash", OrdersCount: 0, Revenue: 0},
+	}
+
+	summary := SummarizeOrderStats(rows)
+
+	assert.Equal(t, 4, summary.OrdersCount)
+	assert.Equal(t, 900.0, summary.Revenue)
+	assert.Equal(t, 15.0, summary.Discount)
+	assert.Equal(t, 225.0, summary.AvgOrderValue())
+	assert.Equal(t, map[OrderStatus]int{
+		OrderStatusPending:   2,
+		OrderStatusConfirmed: 1,
+		OrderStatusCancelled: 1,
+	}, summary.ByStatus)
+
+	assert.Equal(t, StatsBucket{OrdersCount: 3, Revenue: 800.30}, summary.ByHour[9])
+	assert.Equal(t, StatsBucket{OrdersCount: 1, Revenue: 99.70}, summary.ByHour[20])
+	assert.Equal(t, StatsBucket{}, summary.ByHour[0])
+
+	// Highest revenue first, empty groups left out
+	assert.Equal(t, []KeyedStatsBucket{
+		{Key: string(OrderSourceLINE), StatsBucket: StatsBucket{OrdersCount: 1, Revenue: 500.20}},
+		{Key: string(OrderSourceOnline), StatsBucket: StatsBucket{OrdersCount: 3, Revenue: 399.80}},
+	}, summary.BySource)
+	assert.Equal(t, []KeyedStatsBucket{
+		{Key: "qr_code", StatsBucket: StatsBucket{OrdersCount: 3, Revenue: 800.30}},
+		{Key: "", StatsBucket: StatsBucket{OrdersCount: 1, Revenue: 99.70}},
+	}, summary.ByPaymentMethod)
+}
+
+func TestSummarizeOrderStatsWithoutRows(t *testing.T) {
+	summary := SummarizeOrderStats(nil)
+
+	assert.Zero(t, summary.OrdersCount)
+	assert.Zero(t, summary.AvgOrderValue())
+	assert.Empty(t, summary.BySource)
+	assert.Empty(t, summary.ByPaymentMethod)
+}
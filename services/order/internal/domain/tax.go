@@ -0,0 +1,84 @@
+package domain
+
+// TaxMode says whether the prices of an order include VAT
+type TaxMode string
+
+const (
+	// TaxModeExclusive adds VAT on top of the prices
+	TaxModeExclusive TaxMode = "exclusive"
+	// TaxModeInclusive means the prices already contain VAT, as on shelf prices in Thailand
+	TaxModeInclusive TaxMode = "inclusive"
+)
+
+// DefaultVATRate is the Thai VAT rate
+const DefaultVATRate = 0.07
+
+// IsValid reports whether the mode is a known tax mode
+func (m TaxMode) IsValid() bool {
+	return m == TaxModeExclusive || m == TaxModeInclusive
+}
+
+// VATBreakdown splits what an order charges into the amount VAT is charged on, the amount that
+// is exempt and the VAT itself
+type VATBreakdown struct {
+	// TaxableAmount is the value of the taxable goods and shipping, without VAT
+	TaxableAmount float64 `json:"taxable_amount"`
+	ExemptAmount  float64 `json:"exempt_amount"`
+	VAT           float64 `json:"vat"`
+}
+
+// VATBreakdown works out the VAT of the order. The discount is spread over the items in
+// proportion to their price so exempt items carry their share of it, and shipping is taxable.
+func (o *Order) VATBreakdown() VATBreakdown {
+	itemsTotal, exemptItems := 0.0, 0.0
+	for _, item := range o.Items {
+		itemsTotal += item.TotalPrice
+		if item.VATExempt {
+			exemptItems += item.TotalPrice
+		}
+	}
+
+	exemptDiscount := 0.0
+	if itemsTotal > 0 {
+		exemptDiscount = o.Discount * exemptItems / itemsTotal
+	}
+	exempt := roundMoney(exemptItems - exemptDiscount)
+	taxable := roundMoney(itemsTotal - o.Discount + o.ShippingFee - exempt)
+
+	if !o.TaxEnabled || o.VATRate <= 0 {
+		return VATBreakdown{TaxableAmount: taxable, ExemptAmount: exempt}
+	}
+	if o.TaxMode == TaxModeInclusive {
+		vat := roundMoney(taxable * o.VATRate / (1 + o.VATRate))
+		return VATBreakdown{TaxableAmount: roundMoney(taxable - vat), ExemptAmount: exempt, VAT: vat}
+	}
+	return VATBreakdown{TaxableAmount: taxable, ExemptAmount: exempt, VAT: roundMoney(taxable * o.VATRate)}
+}
+
+// ValidTaxID reports whether id is a 13 digit Thai tax identification number with a valid
+// check digit. Company and personal tax IDs use the same scheme.
+func ValidTaxID(id string) bool {
+	if len(id) != 13 || !allDigits(id) {
+		return false
+	}
+	sum := 0
+	for i := 0; i < 12; i++ {
+		sum += int(id[i]-'0') * (13 - i)
+	}
+	return (11-sum%11)%10 == int(id[12]-'0')
+}
+
+// ValidBranchCode reports whether code is a 5 digit Revenue Department branch number; the head
+// office is 00000
+func ValidBranchCode(code string) bool {
+	return len(code) == 5 && allDigits(code)
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
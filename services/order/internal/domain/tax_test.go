@@ -0,0 +1,143 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidTaxID(t *testing.T) {
+	assert.True(t, ValidTaxID("0105536000313"))
+	assert.True(t, ValidTaxID("1234567890121"))
+	assert.False(t, ValidTaxID("0105536000314"), "wrong check digit")
+	assert.False(t, ValidTaxID("010553600031"), "too short")
+	assert.False(t, ValidTaxID("01055360003A3"), "not a number")
+
+	assert.True(t, ValidBranchCode(HeadOfficeBranch))
+	assert.True(t, ValidBranchCode("00012"))
+	assert.False(t, ValidBranchCode("12"))
+}
+
+func TestVATBreakdown(t *testing.T) {
+	tests := []struct {
+		name       string
+		mode       TaxMode
+		taxEnabled bool
+		exempt     bool
+		discount   float64
+		shipping   float64
+		want       VATBreakdown
+		total      float64
+	}{
+		{
+			name:       "exclusive prices with taxable shipping",
+			mode:       TaxModeExclusive,
+			taxEnabled: true,
+			shipping:   100,
+			want:       VATBreakdown{TaxableAmount: 1100, VAT: 77},
+			total:      1177,
+		},
+		{
+			name:       "inclusive prices",
+			mode:       TaxModeInclusive,
+			taxEnabled: true,
+			want:       VATBreakdown{TaxableAmount: 934.58, VAT: 65.42},
+			total:      1000,
+		},
+		{
+			name:       "exempt item carries its share of the discount",
+			mode:       TaxModeExclusive,
+			taxEnabled: true,
+			exempt:     true,
+			discount:   100,
+			want:       VATBreakdown{TaxableAmount: 540, ExemptAmount: 360, VAT: 37.8},
+			total:      937.8,
+		},
+		{
+			name:     "tax disabled",
+			mode:     TaxModeExclusive,
+			shipping: 50,
+			want:     VATBreakdown{TaxableAmount: 1050},
+			total:    1050,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order := NewOrder(uuid.New(), "1 Sukhumvit Rd", "1 Sukhumvit Rd", "")
+			order.TaxEnabled = tt.taxEnabled
+			order.AddItem(uuid.New(), 2, 300)
+			order.AddItem(uuid.New(), 4, 100)
+			order.Items[1].VATExempt = tt.exempt
+			require.NoError(t, order.ApplyDiscount(tt.discount))
+			require.NoError(t, order.SetShippingFee(tt.shipping))
+			require.NoError(t, order.SetVAT(DefaultVATRate, tt.mode))
+
+			assert.Equal(t, tt.want, order.VATBreakdown())
+			assert.Equal(t, tt.want.VAT, order.Tax)
+			assert.InDelta(t, tt.total, order.TotalAmount, 0.001)
+		})
+	}
+}
+
+func TestSetVATRejectsInvalidSettings(t *testing.T) {
+	order := NewOrder(uuid.New(), "1 Sukhumvit Rd", "1 Sukhumvit Rd", "")
+	assert.ErrorIs(t, order.SetVAT(-0.07, TaxModeExclusive), ErrInvalidTaxSettings)
+	assert.ErrorIs(t, order.SetVAT(7, TaxModeExclusive), ErrInvalidTaxSettings)
+	assert.ErrorIs(t, order.SetVAT(DefaultVATRate, "gross"), ErrInvalidTaxSettings)
+}
+
+func TestEditRecalculatesVAT(t *testing.T) {
+	order := newConfirmedOrder()
+	require.NoError(t, order.SetVAT(DefaultVATRate, TaxModeExclusive))
+	assert.Equal(t, 24.5, order.Tax)
+
+	diff, err := order.ApplyEdit(OrderEdit{
+		AddItems: []NewOrderItem{{ProductID: uuid.New(), Quantity: 1, UnitPrice: 150, VATExempt: true}},
+	})
+	require.NoError(t, err)
+
+	assert.True(t, order.Items[2].VATExempt)
+	assert.Equal(t, 24.5, order.Tax, "the exempt item adds no VAT")
+	assert.Nil(t, diff.Tax)
+	assert.InDelta(t, 524.5, order.TotalAmount, 0.001)
+
+	diff, err = order.ApplyEdit(OrderEdit{UpdateItems: []OrderItemQuantity{{ItemID: order.Items[0].ID, Quantity: 4}}})
+	require.NoError(t, err)
+	require.NotNil(t, diff.Tax)
+	assert.Equal(t, 38.5, order.Tax)
+}
+
+func TestRefundValueIncludesVATOfTaxableItems(t *testing.T) {
+	order := newDeliveredOrder(t)
+	order.Items[1].VATExempt = true
+	require.NoError(t, order.SetShippingFee(100))
+	require.NoError(t, order.SetVAT(DefaultVATRate, TaxModeExclusive))
+
+	// 200 taxable and 150 exempt items, 100 shipping and 7% VAT on the taxable 300
+	assert.InDelta(t, 471, order.TotalAmount, 0.001)
+	assert.Equal(t, 214.0, order.RefundValue(&order.Items[0], 2))
+	assert.Equal(t, 100.0, order.RefundValue(&order.Items[1], 2))
+
+	// Inclusive prices already contain the VAT
+	require.NoError(t, order.SetVAT(DefaultVATRate, TaxModeInclusive))
+	assert.Equal(t, 200.0, order.RefundValue(&order.Items[0], 2))
+}
+
+func TestSetItemPricesRecalculatesTotal(t *testing.T) {
+	order := NewOrder(uuid.New(), "Bangkok", "Bangkok", "")
+	order.AddItem(uuid.New(), 2, 0)
+	order.AddItem(uuid.New(), 3, 0)
+	require.NoError(t, order.SetVAT(0.07, TaxModeExclusive))
+
+	require.NoError(t, order.SetItemPrices([]float64{100, 50}))
+	assert.Equal(t, 200.0, order.Items[0].TotalPrice)
+	assert.Equal(t, 150.0, order.Items[1].TotalPrice)
+	assert.Equal(t, 374.5, order.TotalAmount)
+
+	assert.ErrorIs(t, order.SetItemPrices([]float64{100}), ErrInvalidOrderData)
+	assert.ErrorIs(t, order.SetItemPrices([]float64{100, -1}), ErrInvalidPrice)
+	assert.Equal(t, 50.0, order.Items[1].UnitPrice)
+}
@@ -109,8 +109,11 @@ func (c *RedisClient) SetOrder(ctx context.Context, orderID string, data interfa
 	return nil
 }
 
-// DeleteOrder removes an order from cache
+// DeleteOrder removes an order from cache. Without Redis there is nothing to remove.
 func (c *RedisClient) DeleteOrder(ctx context.Context, orderID string) error {
+	if c == nil {
+		return nil
+	}
 	key := fmt.Sprintf(OrderKey, orderID)
 	
 	err := c.client.Del(ctx, key).Err()
@@ -140,8 +143,11 @@ func (c *RedisClient) GetCustomerOrders(ctx context.Context, customerID string)
 	return result, nil
 }
 
-// AddCustomerOrder adds an order to customer's order set
+// AddCustomerOrder adds an order to customer's order set. Without Redis nothing is cached.
 func (c *RedisClient) AddCustomerOrder(ctx context.Context, customerID, orderID string, ttl time.Duration) error {
+	if c == nil {
+		return nil
+	}
 	key := fmt.Sprintf(CustomerOrderKey, customerID)
 	
 	err := c.client.SAdd(ctx, key, orderID).Err()
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CatalogProduct is what orders need to know about a product: its name for invoices, its
+// category for promotion scopes and whether it is sold without VAT
+type CatalogProduct struct {
+	Name        string     `json:"name"`
+	CategoryID  *uuid.UUID `json:"category_id"`
+	IsVATExempt bool       `json:"is_vat_exempt"`
+}
+
+// CatalogClient interface for looking up ordered products in the product service
+type CatalogClient interface {
+	GetProducts(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]*CatalogProduct, error)
+}
+
+// HTTPCatalogClient implements CatalogClient using HTTP requests to the product service
+type HTTPCatalogClient struct {
+	baseURL    string
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewHTTPCatalogClient creates a new HTTP catalog client
+func NewHTTPCatalogClient(baseURL string) *HTTPCatalogClient {
+	return &HTTPCatalogClient{
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxRetries: 3,
+		backoff:    100 * time.Millisecond,
+	}
+}
+
+// GetProducts looks up each distinct product once
+func (c *HTTPCatalogClient) GetProducts(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID]*CatalogProduct, error) {
+	products := make(map[uuid.UUID]*CatalogProduct, len(productIDs))
+	for _, productID := range productIDs {
+		if _, ok := products[productID]; ok {
+			continue
+		}
+
+		product := &CatalogProduct{}
+		url := fmt.Sprintf("%s/api/v1/products/%s", c.baseURL, productID)
+		if err := getJSON(ctx, c.client, c.maxRetries, c.backoff, url, product, lookupError("product")); err != nil {
+			return nil, fmt.Errorf("failed to get product %s: %w", productID, err)
+		}
+		products[productID] = product
+	}
+	return products, nil
+}
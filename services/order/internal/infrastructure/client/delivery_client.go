@@ -41,6 +41,9 @@ type CreateDeliveryRequest struct {
 	Dimensions  Dimensions  `json:"dimensions"`
 	ServiceType string      `json:"service_type"`
 	Instructions string     `json:"instructions,omitempty"`
+	// IdempotencyKey makes the shipping service return the delivery already created under the
+	// key instead of creating another one when a request is retried
+	IdempotencyKey string `json:"idempotency_key,omitempty"`
 }
 
 // DeliveryResponse represents delivery order response
@@ -80,6 +83,8 @@ type Dimensions struct {
 type DeliveryClient interface {
 	GetQuote(ctx context.Context, req *DeliveryQuoteRequest) (*DeliveryQuoteResponse, error)
 	CreateDelivery(ctx context.Context, req *CreateDeliveryRequest) (*DeliveryResponse, error)
+	// CancelDelivery cancels a delivery that has not been dispatched; a cancelled one stays cancelled
+	CancelDelivery(ctx context.Context, deliveryID uuid.UUID, reason string) error
 	GetDeliveryStatus(ctx context.Context, deliveryID uuid.UUID) (*DeliveryResponse, error)
 	TrackDelivery(ctx context.Context, trackingNumber string) (*DeliveryResponse, error)
 }
@@ -88,6 +93,7 @@ type DeliveryClient interface {
 type HTTPDeliveryClient struct {
 	baseURL string
 	client  *http.Client
+	backoff time.Duration
 }
 
 // NewHTTPDeliveryClient creates a new HTTP delivery client
@@ -97,6 +103,7 @@ func NewHTTPDeliveryClient(baseURL string) *HTTPDeliveryClient {
 		client: &http.Client{
 			Timeout: 10 * time.Second,
 		},
+		backoff: 100 * time.Millisecond,
 	}
 }
 
@@ -141,39 +148,47 @@ func (c *HTTPDeliveryClient) GetQuote(ctx context.Context, req *DeliveryQuoteReq
 	return &quoteResponse, nil
 }
 
-// CreateDelivery creates a delivery order
+// CreateDelivery creates a delivery order. Every attempt sends the full request again, so retries carry the same idempotency key.
 func (c *HTTPDeliveryClient) CreateDelivery(ctx context.Context, req *CreateDeliveryRequest) (*DeliveryResponse, error) {
-	url := fmt.Sprintf("%s/api/delivery", c.baseURL)
-	
-	jsonData, err := json.Marshal(req)
+	var deliveryResponse DeliveryResponse
+	err := postJSON(ctx, c.client, 3, c.backoff, c.baseURL+"/api/delivery", req, &deliveryResponse, func(status int, data []byte) error {
+		return fmt.Errorf("shipping service returned status %d", status)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
+		return nil, fmt.Errorf("failed to execute create delivery request: %w", err)
 	}
-	
+
+	return &deliveryResponse, nil
+}
+
+// CancelDelivery cancels a delivery order
+func (c *HTTPDeliveryClient) CancelDelivery(ctx context.Context, deliveryID uuid.UUID, reason string) error {
+	url := fmt.Sprintf("%s/api/delivery/%s/cancel", c.baseURL, deliveryID.String())
+
+	jsonData, err := json.Marshal(map[string]string{"reason": reason})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
-	
+
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("User-Agent", "order-service/1.0")
-	
+
 	resp, err := c.executeWithRetry(httpReq, 3)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute create delivery request: %w", err)
+		return fmt.Errorf("failed to execute cancel delivery request: %w", err)
 	}
 	defer resp.Body.Close()
-	
-	if resp.StatusCode != http.StatusCreated {
-		return nil, fmt.Errorf("shipping service returned status %d", resp.StatusCode)
-	}
-	
-	var deliveryResponse DeliveryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&deliveryResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode delivery response: %w", err)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("shipping service returned status %d", resp.StatusCode)
 	}
-	
-	return &deliveryResponse, nil
+
+	return nil
 }
 
 // GetDeliveryStatus gets delivery status by delivery ID
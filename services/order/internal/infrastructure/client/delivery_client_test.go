@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateDeliveryRetriesWithSameIdempotencyKey(t *testing.T) {
+	orderID := uuid.New()
+	deliveryID := uuid.New()
+
+	var bodies []CreateDeliveryRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/delivery", r.URL.Path)
+
+		var body CreateDeliveryRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(DeliveryResponse{ID: deliveryID, OrderID: orderID, TrackingNumber: "TH123"})
+	}))
+	defer server.Close()
+	c := NewHTTPDeliveryClient(server.URL)
+	c.backoff = time.Millisecond
+
+	delivery, err := c.CreateDelivery(context.Background(), &CreateDeliveryRequest{
+		OrderID:        orderID,
+		ServiceType:    "standard",
+		IdempotencyKey: "shipment:key:delivery",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, deliveryID, delivery.ID)
+	assert.Equal(t, "TH123", delivery.TrackingNumber)
+
+	// The retry carries the full request again under the same key
+	require.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, "shipment:key:delivery", bodies[1].IdempotencyKey)
+}
@@ -0,0 +1,148 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrProductUnavailable is returned when a product of the cart is unknown or not for sale
+	ErrProductUnavailable = errors.New("product is unknown or not for sale")
+	// ErrVIPAccessRequired is returned when a product is only sold to higher VIP levels than the
+	// customer's
+	ErrVIPAccessRequired = errors.New("product requires a higher VIP level")
+)
+
+// CartPricingItem is a product quantity to price
+type CartPricingItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// CartPricingRequest is a cart to price for a customer. The VIP level and customer group are
+// left out when the customer has none.
+type CartPricingRequest struct {
+	CustomerID    uuid.UUID         `json:"customer_id"`
+	CustomerGroup string            `json:"customer_group,omitempty"`
+	VIPLevel      string            `json:"vip_level,omitempty"`
+	Items         []CartPricingItem `json:"items"`
+}
+
+// PricedCart is a cart priced by the product service. Amounts are sent as decimal strings.
+type PricedCart struct {
+	Lines    []PricedCartLine `json:"lines"`
+	Subtotal float64          `json:"subtotal,string"`
+	Discount float64          `json:"discount,string"`
+	Total    float64          `json:"total,string"`
+}
+
+// PricedCartLine is the price of a cart line, in the order of the request
+type PricedCartLine struct {
+	ProductID    uuid.UUID            `json:"product_id"`
+	Quantity     int                  `json:"quantity"`
+	BasePrice    float64              `json:"base_price,string"`
+	UnitPrice    float64              `json:"unit_price,string"`
+	LineTotal    float64              `json:"line_total,string"`
+	AppliedRules []AppliedPricingRule `json:"applied_rules"`
+	// BelowCost tells that the line is priced under the product's cost
+	BelowCost bool `json:"below_cost"`
+}
+
+// AppliedPricingRule explains a pricing rule that lowered the price of a line
+type AppliedPricingRule struct {
+	Rule        string `json:"rule"`
+	Description string `json:"description"`
+}
+
+// MarginCheckLine is a unit price to check against the cost of its product
+type MarginCheckLine struct {
+	ProductID uuid.UUID `json:"product_id"`
+	UnitPrice float64   `json:"unit_price"`
+}
+
+// MarginCheck compares a unit price with the cost and minimum margin of its product. Products
+// without a known cost are never below it.
+type MarginCheck struct {
+	ProductID        uuid.UUID `json:"product_id"`
+	Price            float64   `json:"price"`
+	MarginPercent    *float64  `json:"margin_percent,omitempty"`
+	MinMarginPercent float64   `json:"min_margin_percent"`
+	BelowCost        bool      `json:"below_cost"`
+	BelowMinimum     bool      `json:"below_minimum"`
+}
+
+// NeedsApproval tells whether the price may only be used with a manager's approval
+func (c MarginCheck) NeedsApproval() bool {
+	return c.BelowCost || c.BelowMinimum
+}
+
+// PricingClient interface for pricing carts in the product service
+type PricingClient interface {
+	PriceCart(ctx context.Context, req *CartPricingRequest) (*PricedCart, error)
+	// CheckMargins checks unit prices against their products' margins, in the order of the lines
+	CheckMargins(ctx context.Context, lines []MarginCheckLine) ([]MarginCheck, error)
+}
+
+// HTTPPricingClient implements PricingClient using HTTP requests to the product service
+type HTTPPricingClient struct {
+	baseURL    string
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewHTTPPricingClient creates a new HTTP pricing client
+func NewHTTPPricingClient(baseURL string) *HTTPPricingClient {
+	return &HTTPPricingClient{
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxRetries: 3,
+		backoff:    100 * time.Millisecond,
+	}
+}
+
+// PriceCart prices every line of the cart. Pricing changes nothing, so it is safe to retry.
+func (c *HTTPPricingClient) PriceCart(ctx context.Context, req *CartPricingRequest) (*PricedCart, error) {
+	var cart PricedCart
+	if err := postJSON(ctx, c.client, c.maxRetries, c.backoff, c.baseURL+"/api/v1/pricing/cart", req, &cart, pricingError); err != nil {
+		return nil, fmt.Errorf("failed to price cart: %w", err)
+	}
+	return &cart, nil
+}
+
+// CheckMargins checks unit prices against the cost and minimum margin of their products. It
+// changes nothing, so it is safe to retry.
+func (c *HTTPPricingClient) CheckMargins(ctx context.Context, lines []MarginCheckLine) ([]MarginCheck, error) {
+	req := struct {
+		Lines []MarginCheckLine `json:"lines"`
+	}{Lines: lines}
+	var response struct {
+		Checks []MarginCheck `json:"checks"`
+	}
+	if err := postJSON(ctx, c.client, c.maxRetries, c.backoff, c.baseURL+"/api/v1/pricing/margin-check", req, &response, pricingError); err != nil {
+		return nil, fmt.Errorf("failed to check margins: %w", err)
+	}
+	return response.Checks, nil
+}
+
+// pricingError maps a client error response of cart pricing to an error
+func pricingError(status int, data []byte) error {
+	var response serviceErrorResponse
+	_ = json.Unmarshal(data, &response)
+
+	switch response.Code {
+	case "PRODUCT_NOT_FOUND", "PRODUCT_NOT_AVAILABLE":
+		return fmt.Errorf("%w: %s", ErrProductUnavailable, response.Error)
+	case "VIP_ACCESS_REQUIRED":
+		return fmt.Errorf("%w: %s", ErrVIPAccessRequired, response.Error)
+	}
+	return lookupError("product")(status, data)
+}
@@ -0,0 +1,135 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestPricingClient(handler http.HandlerFunc) (*HTTPPricingClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	c := NewHTTPPricingClient(server.URL)
+	c.backoff = time.Millisecond
+	return c, server
+}
+
+func TestPriceCartDecodesDecimalAmounts(t *testing.T) {
+	productID := uuid.New()
+	customerID := uuid.New()
+
+	var body CartPricingRequest
+	c, server := newTestPricingClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pricing/cart", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		// The product service sends amounts as decimal strings
+		w.Write([]byte(`{
+			"lines": [{
+				"product_id": "` + productID.String() + `",
+				"quantity": 12,
+				"base_price": "100",
+				"unit_price": "76.95",
+				"line_total": "923.4",
+				"applied_rules": [
+					{"rule": "tier", "description": "tier 10+ at 90.00 for 10 or more"},
+					{"rule": "vip", "description": "gold VIP 10% off"}
+				]
+			}],
+			"subtotal": "1200",
+			"discount": "276.6",
+			"total": "923.4"
+		}`))
+	})
+	defer server.Close()
+
+	cart, err := c.PriceCart(context.Background(), &CartPricingRequest{
+		CustomerID:    customerID,
+		CustomerGroup: "wholesale",
+		VIPLevel:      "gold",
+		Items:         []CartPricingItem{{ProductID: productID, Quantity: 12}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, customerID, body.CustomerID)
+	assert.Equal(t, "wholesale", body.CustomerGroup)
+	assert.Equal(t, "gold", body.VIPLevel)
+
+	require.Len(t, cart.Lines, 1)
+	assert.Equal(t, 76.95, cart.Lines[0].UnitPrice)
+	assert.Equal(t, 100.0, cart.Lines[0].BasePrice)
+	assert.Len(t, cart.Lines[0].AppliedRules, 2)
+	assert.Equal(t, 923.4, cart.Total)
+}
+
+func TestPriceCartUnavailableProductIsNotRetried(t *testing.T) {
+	calls := 0
+	c, server := newTestPricingClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(serviceErrorResponse{Error: "product not available", Code: "PRODUCT_NOT_AVAILABLE"})
+	})
+	defer server.Close()
+
+	_, err := c.PriceCart(context.Background(), &CartPricingRequest{
+		CustomerID: uuid.New(),
+		Items:      []CartPricingItem{{ProductID: uuid.New(), Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, ErrProductUnavailable)
+	assert.Equal(t, 1, calls)
+}
+
+func TestPriceCartVIPAccessRequired(t *testing.T) {
+	c, server := newTestPricingClient(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		json.NewEncoder(w).Encode(serviceErrorResponse{Error: "product requires a higher VIP level", Code: "VIP_ACCESS_REQUIRED"})
+	})
+	defer server.Close()
+
+	_, err := c.PriceCart(context.Background(), &CartPricingRequest{
+		CustomerID: uuid.New(),
+		Items:      []CartPricingItem{{ProductID: uuid.New(), Quantity: 1}},
+	})
+	assert.ErrorIs(t, err, ErrVIPAccessRequired)
+	assert.NotErrorIs(t, err, ErrProductUnavailable)
+}
+
+func TestCheckMarginsFlagsPricesNeedingApproval(t *testing.T) {
+	costed := uuid.New()
+	uncosted := uuid.New()
+
+	var body struct {
+		Lines []MarginCheckLine `json:"lines"`
+	}
+	c, server := newTestPricingClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/pricing/margin-check", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		w.Write([]byte(`{"checks": [
+			{"product_id": "` + costed.String() + `", "price": 55, "cost_price": 60, "margin_percent": -9.09,
+			 "min_margin_percent": 20, "below_cost": true, "below_minimum": true, "below_target": true},
+			{"product_id": "` + uncosted.String() + `", "price": 10, "min_margin_percent": 20,
+			 "below_cost": false, "below_minimum": false, "below_target": false}
+		]}`))
+	})
+	defer server.Close()
+
+	checks, err := c.CheckMargins(context.Background(), []MarginCheckLine{
+		{ProductID: costed, UnitPrice: 55},
+		{ProductID: uncosted, UnitPrice: 10},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 55.0, body.Lines[0].UnitPrice)
+
+	require.Len(t, checks, 2)
+	assert.True(t, checks[0].NeedsApproval())
+	require.NotNil(t, checks[0].MarginPercent)
+	assert.Equal(t, -9.09, *checks[0].MarginPercent)
+	assert.False(t, checks[1].NeedsApproval())
+	assert.Nil(t, checks[1].MarginPercent)
+}
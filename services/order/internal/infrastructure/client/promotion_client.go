@@ -0,0 +1,63 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PromotionLookupClient interface for the facts VIP promotion rules need from the customer
+// service. Product categories come from the CatalogClient.
+type PromotionLookupClient interface {
+	GetCustomerTier(ctx context.Context, customerID uuid.UUID) (int, error)
+}
+
+// HTTPPromotionLookupClient implements PromotionLookupClient using HTTP requests to the customer
+// service
+type HTTPPromotionLookupClient struct {
+	customerURL string
+	client      *http.Client
+	maxRetries  int
+	backoff     time.Duration
+}
+
+// NewHTTPPromotionLookupClient creates a new HTTP promotion lookup client
+func NewHTTPPromotionLookupClient(customerURL string) *HTTPPromotionLookupClient {
+	return &HTTPPromotionLookupClient{
+		customerURL: customerURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxRetries: 3,
+		backoff:    100 * time.Millisecond,
+	}
+}
+
+// GetCustomerTier returns the loyalty tier of the customer, from 1 (Bronze) to 5 (Diamond)
+func (c *HTTPPromotionLookupClient) GetCustomerTier(ctx context.Context, customerID uuid.UUID) (int, error) {
+	var customer struct {
+		Tier int `json:"tier"`
+	}
+	url := fmt.Sprintf("%s/api/v1/customers/%s", c.customerURL, customerID)
+	if err := getJSON(ctx, c.client, c.maxRetries, c.backoff, url, &customer, lookupError("customer")); err != nil {
+		return 0, fmt.Errorf("failed to get customer tier: %w", err)
+	}
+	return customer.Tier, nil
+}
+
+// lookupError maps a client error response of the named service to an error
+func lookupError(service string) func(status int, data []byte) error {
+	return func(status int, data []byte) error {
+		var response serviceErrorResponse
+		_ = json.Unmarshal(data, &response)
+
+		if response.Error != "" {
+			return fmt.Errorf("%s service returned status %d: %s", service, status, response.Error)
+		}
+		return fmt.Errorf("%s service returned status %d", service, status)
+	}
+}
@@ -0,0 +1,140 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrRefundAmountExceeded is returned when a refund is larger than what the order paid
+var ErrRefundAmountExceeded = errors.New("refund amount exceeds the amount paid")
+
+// RefundPaymentRequest asks the payment service to refund part of an order's payment. The
+// reference identifies the refund, so repeating a request refunds once.
+type RefundPaymentRequest struct {
+	OrderID    uuid.UUID `json:"order_id"`
+	CustomerID uuid.UUID `json:"customer_id"`
+	Reference  string    `json:"reference"`
+	Amount     float64   `json:"amount"`
+	Currency   string    `json:"currency"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// RefundTransaction is the refunded payment transaction recorded by the payment service
+type RefundTransaction struct {
+	ID       uuid.UUID `json:"id"`
+	OrderID  uuid.UUID `json:"order_id"`
+	Amount   float64   `json:"amount"`
+	Currency string    `json:"currency"`
+	Status   string    `json:"status"`
+}
+
+// ClawBackPointsRequest asks the customer service to take back the points earned from the
+// refunded share of an order
+type ClawBackPointsRequest struct {
+	OrderID      uuid.UUID `json:"order_id"`
+	ReturnID     uuid.UUID `json:"return_id"`
+	RefundAmount float64   `json:"refund_amount"`
+	OrderAmount  float64   `json:"order_amount"`
+}
+
+// RefundClient interface for the money side of a return: the payment refund, the cash outflow in
+// finance and the loyalty points clawback. Every call is idempotent, so it can be retried.
+type RefundClient interface {
+	RefundPayment(ctx context.Context, req *RefundPaymentRequest) (*RefundTransaction, error)
+	RecordCashOutflow(ctx context.Context, reference string, amount float64, description string) error
+	ClawBackPoints(ctx context.Context, customerID uuid.UUID, req *ClawBackPointsRequest) (int, error)
+}
+
+// HTTPRefundClient implements RefundClient using HTTP requests to the payment, finance and
+// customer services
+type HTTPRefundClient struct {
+	paymentURL  string
+	financeURL  string
+	customerURL string
+	client      *http.Client
+	maxRetries  int
+	backoff     time.Duration
+}
+
+// NewHTTPRefundClient creates a new HTTP refund client
+func NewHTTPRefundClient(paymentURL, financeURL, customerURL string) *HTTPRefundClient {
+	return &HTTPRefundClient{
+		paymentURL:  paymentURL,
+		financeURL:  financeURL,
+		customerURL: customerURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxRetries: 3,
+		backoff:    100 * time.Millisecond,
+	}
+}
+
+type paymentRefundResponse struct {
+	Data RefundTransaction `json:"data"`
+}
+
+type serviceErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// RefundPayment records a refund against the order's payment
+func (c *HTTPRefundClient) RefundPayment(ctx context.Context, req *RefundPaymentRequest) (*RefundTransaction, error) {
+	var response paymentRefundResponse
+	url := c.paymentURL + "/api/v1/payments/refunds"
+	if err := postJSON(ctx, c.client, c.maxRetries, c.backoff, url, req, &response, refundError("payment")); err != nil {
+		return nil, fmt.Errorf("failed to refund payment: %w", err)
+	}
+	return &response.Data, nil
+}
+
+// RecordCashOutflow records refunded cash leaving the company's central account
+func (c *HTTPRefundClient) RecordCashOutflow(ctx context.Context, reference string, amount float64, description string) error {
+	url := fmt.Sprintf("%s/api/finance/entities/central/%s/cash-flow", c.financeURL, uuid.Nil)
+	body := map[string]interface{}{
+		"transaction_type": "outflow",
+		"amount":           amount,
+		"description":      description,
+		"reference":        reference,
+	}
+	if err := postJSON(ctx, c.client, c.maxRetries, c.backoff, url, body, nil, refundError("finance")); err != nil {
+		return fmt.Errorf("failed to record cash outflow: %w", err)
+	}
+	return nil
+}
+
+// ClawBackPoints takes back loyalty points earned from the refunded share of an order and
+// returns how many were taken
+func (c *HTTPRefundClient) ClawBackPoints(ctx context.Context, customerID uuid.UUID, req *ClawBackPointsRequest) (int, error) {
+	var response struct {
+		PointsClawedBack int `json:"points_clawed_back"`
+	}
+	url := fmt.Sprintf("%s/api/v1/customers/%s/points/clawback", c.customerURL, customerID)
+	if err := postJSON(ctx, c.client, c.maxRetries, c.backoff, url, req, &response, refundError("customer")); err != nil {
+		return 0, fmt.Errorf("failed to claw back points: %w", err)
+	}
+	return response.PointsClawedBack, nil
+}
+
+// refundError maps a client error response of the named service to an error
+func refundError(service string) func(status int, data []byte) error {
+	return func(status int, data []byte) error {
+		var response serviceErrorResponse
+		_ = json.Unmarshal(data, &response)
+
+		if response.Code == "REFUND_AMOUNT_EXCEEDED" {
+			return ErrRefundAmountExceeded
+		}
+		if response.Error != "" {
+			return fmt.Errorf("%s service returned status %d: %s", service, status, response.Error)
+		}
+		return fmt.Errorf("%s service returned status %d", service, status)
+	}
+}
@@ -0,0 +1,190 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrInsufficientStock is returned when stock cannot be reserved for every item
+	ErrInsufficientStock = errors.New("insufficient stock")
+	// ErrReservationNotActive is returned when a reservation was released or expired
+	ErrReservationNotActive = errors.New("stock reservation is no longer active")
+	// ErrReservationNotFound is returned when nothing was reserved for the order
+	ErrReservationNotFound = errors.New("stock reservation not found")
+	// ErrReturnExceedsConsumed is returned when more stock is put back than the order consumed
+	ErrReturnExceedsConsumed = errors.New("returned quantity exceeds the stock consumed by the order")
+	// ErrLaunchAllocationExhausted is returned when the early access quota of the customer's VIP
+	// level cannot cover an item
+	ErrLaunchAllocationExhausted = errors.New("early access allocation exhausted")
+)
+
+// StockReservationItem is a product quantity to reserve
+type StockReservationItem struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Quantity  int       `json:"quantity"`
+}
+
+// StockReservation represents a reservation held by the product service
+type StockReservation struct {
+	ID         uuid.UUID `json:"id"`
+	OrderID    uuid.UUID `json:"order_id"`
+	ProductID  uuid.UUID `json:"product_id"`
+	LocationID uuid.UUID `json:"location_id"`
+	Quantity   float64   `json:"quantity"`
+	Status     string    `json:"status"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// StockReservationClient interface for reserving stock in the product service. Every call is
+// idempotent, so it can be retried.
+type StockReservationClient interface {
+	ReserveStock(ctx context.Context, orderID uuid.UUID, idempotencyKey, vipLevel string, items []StockReservationItem, ttl time.Duration) ([]StockReservation, error)
+	ConsumeStock(ctx context.Context, orderID uuid.UUID) error
+	ReleaseStock(ctx context.Context, orderID uuid.UUID, reason string) error
+	ReleaseReservation(ctx context.Context, orderID uuid.UUID, idempotencyKey, reason string) error
+	RestockStock(ctx context.Context, orderID uuid.UUID, idempotencyKey, reason string, items []StockReservationItem) error
+}
+
+// HTTPStockReservationClient implements StockReservationClient using HTTP requests
+type HTTPStockReservationClient struct {
+	baseURL    string
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// NewHTTPStockReservationClient creates a new HTTP stock reservation client
+func NewHTTPStockReservationClient(baseURL string) *HTTPStockReservationClient {
+	return &HTTPStockReservationClient{
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		maxRetries: 3,
+		backoff:    100 * time.Millisecond,
+	}
+}
+
+type reserveStockRequest struct {
+	OrderID        uuid.UUID              `json:"order_id"`
+	IdempotencyKey string                 `json:"idempotency_key"`
+	TTLSeconds     int                    `json:"ttl_seconds"`
+	VIPLevel       string                 `json:"vip_level,omitempty"`
+	Items          []StockReservationItem `json:"items"`
+}
+
+type restockStockRequest struct {
+	IdempotencyKey string                 `json:"idempotency_key"`
+	Reason         string                 `json:"reason,omitempty"`
+	Items          []StockReservationItem `json:"items"`
+}
+
+type reservationsResponse struct {
+	Reservations []StockReservation `json:"reservations"`
+}
+
+type reservationErrorResponse struct {
+	Error string `json:"error"`
+	Code  string `json:"code"`
+}
+
+// ReserveStock reserves every item for the order or none of them. The VIP level of the customer,
+// empty for customers who are not VIP members, is checked against products in early access.
+func (c *HTTPStockReservationClient) ReserveStock(ctx context.Context, orderID uuid.UUID, idempotencyKey, vipLevel string, items []StockReservationItem, ttl time.Duration) ([]StockReservation, error) {
+	body := reserveStockRequest{
+		OrderID:        orderID,
+		IdempotencyKey: idempotencyKey,
+		TTLSeconds:     int(ttl.Seconds()),
+		VIPLevel:       vipLevel,
+		Items:          items,
+	}
+
+	var response reservationsResponse
+	if err := c.post(ctx, "/api/v1/reservations", body, &response); err != nil {
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+
+	return response.Reservations, nil
+}
+
+// ConsumeStock deducts the stock reserved for the order
+func (c *HTTPStockReservationClient) ConsumeStock(ctx context.Context, orderID uuid.UUID) error {
+	path := fmt.Sprintf("/api/v1/reservations/%s/consume", orderID)
+	if err := c.post(ctx, path, nil, nil); err != nil {
+		return fmt.Errorf("failed to consume reserved stock: %w", err)
+	}
+	return nil
+}
+
+// ReleaseStock returns the stock reserved for the order
+func (c *HTTPStockReservationClient) ReleaseStock(ctx context.Context, orderID uuid.UUID, reason string) error {
+	path := fmt.Sprintf("/api/v1/reservations/%s/release", orderID)
+	body := map[string]string{"reason": reason}
+	if err := c.post(ctx, path, body, nil); err != nil {
+		return fmt.Errorf("failed to release reserved stock: %w", err)
+	}
+	return nil
+}
+
+// ReleaseReservation returns only the stock reserved for the order under the idempotency key
+func (c *HTTPStockReservationClient) ReleaseReservation(ctx context.Context, orderID uuid.UUID, idempotencyKey, reason string) error {
+	path := fmt.Sprintf("/api/v1/reservations/%s/release", orderID)
+	body := map[string]string{"idempotency_key": idempotencyKey, "reason": reason}
+	if err := c.post(ctx, path, body, nil); err != nil {
+		return fmt.Errorf("failed to release reserved stock: %w", err)
+	}
+	return nil
+}
+
+// RestockStock puts returned items of a consumed order back in stock
+func (c *HTTPStockReservationClient) RestockStock(ctx context.Context, orderID uuid.UUID, idempotencyKey, reason string, items []StockReservationItem) error {
+	path := fmt.Sprintf("/api/v1/reservations/%s/restock", orderID)
+	body := restockStockRequest{
+		IdempotencyKey: idempotencyKey,
+		Reason:         reason,
+		Items:          items,
+	}
+	if err := c.post(ctx, path, body, nil); err != nil {
+		return fmt.Errorf("failed to restock returned stock: %w", err)
+	}
+	return nil
+}
+
+// post sends the request to the product service, retrying network and server errors
+func (c *HTTPStockReservationClient) post(ctx context.Context, path string, body interface{}, out interface{}) error {
+	return postJSON(ctx, c.client, c.maxRetries, c.backoff, c.baseURL+path, body, out, reservationError)
+}
+
+// reservationError maps a client error response to one of the reservation errors
+func reservationError(status int, data []byte) error {
+	var response reservationErrorResponse
+	_ = json.Unmarshal(data, &response)
+
+	switch response.Code {
+	case "INSUFFICIENT_STOCK":
+		return fmt.Errorf("%w: %s", ErrInsufficientStock, response.Error)
+	case "RESERVATION_NOT_ACTIVE":
+		return ErrReservationNotActive
+	case "RESERVATION_NOT_FOUND":
+		return ErrReservationNotFound
+	case "RETURN_EXCEEDS_CONSUMED":
+		return ErrReturnExceedsConsumed
+	case "LAUNCH_ALLOCATION_EXHAUSTED":
+		return fmt.Errorf("%w: %s", ErrLaunchAllocationExhausted, response.Error)
+	case "VIP_ACCESS_REQUIRED":
+		return fmt.Errorf("%w: %s", ErrVIPAccessRequired, response.Error)
+	case "PRODUCT_NOT_FOUND", "PRODUCT_NOT_AVAILABLE":
+		return fmt.Errorf("%w: %s", ErrProductUnavailable, response.Error)
+	}
+	if response.Error != "" {
+		return fmt.Errorf("product service returned status %d: %s", status, response.Error)
+	}
+	return fmt.Errorf("product service returned status %d", status)
+}
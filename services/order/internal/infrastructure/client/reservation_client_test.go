@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestReservationClient(handler http.HandlerFunc) (*HTTPStockReservationClient, *httptest.Server) {
+	server := httptest.NewServer(handler)
+	c := NewHTTPStockReservationClient(server.URL)
+	c.backoff = time.Millisecond
+	return c, server
+}
+
+func TestReserveStockRetriesWithSameIdempotencyKey(t *testing.T) {
+	orderID := uuid.New()
+	productID := uuid.New()
+
+	var bodies []reserveStockRequest
+	c, server := newTestReservationClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/reservations", r.URL.Path)
+
+		var body reserveStockRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+
+		if len(bodies) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(reservationsResponse{Reservations: []StockReservation{
+			{OrderID: orderID, ProductID: productID, Quantity: 2, Status: "active"},
+		}})
+	})
+	defer server.Close()
+
+	items := []StockReservationItem{{ProductID: productID, Quantity: 2}}
+	reservations, err := c.ReserveStock(context.Background(), orderID, "order:key", "gold", items, 15*time.Minute)
+
+	require.NoError(t, err)
+	require.Len(t, reservations, 1)
+	assert.Equal(t, productID, reservations[0].ProductID)
+
+	// The retry carries the full request again under the same key
+	require.Len(t, bodies, 2)
+	assert.Equal(t, bodies[0], bodies[1])
+	assert.Equal(t, "order:key", bodies[1].IdempotencyKey)
+	assert.Equal(t, 900, bodies[1].TTLSeconds)
+	assert.Equal(t, "gold", bodies[1].VIPLevel)
+	assert.Equal(t, items, bodies[1].Items)
+}
+
+func TestReserveStockInsufficientStockIsNotRetried(t *testing.T) {
+	calls := 0
+	c, server := newTestReservationClient(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(reservationErrorResponse{Error: "insufficient stock available", Code: "INSUFFICIENT_STOCK"})
+	})
+	defer server.Close()
+
+	items := []StockReservationItem{{ProductID: uuid.New(), Quantity: 5}}
+	_, err := c.ReserveStock(context.Background(), uuid.New(), "order:key", "", items, time.Minute)
+
+	assert.ErrorIs(t, err, ErrInsufficientStock)
+	assert.Equal(t, 1, calls)
+}
+
+func TestReserveStockLaunchErrors(t *testing.T) {
+	tests := []struct {
+		code   string
+		status int
+		want   error
+	}{
+		{code: "LAUNCH_ALLOCATION_EXHAUSTED", status: http.StatusConflict, want: ErrLaunchAllocationExhausted},
+		{code: "VIP_ACCESS_REQUIRED", status: http.StatusForbidden, want: ErrVIPAccessRequired},
+		{code: "PRODUCT_NOT_AVAILABLE", status: http.StatusConflict, want: ErrProductUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.code, func(t *testing.T) {
+			calls := 0
+			c, server := newTestReservationClient(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(reservationErrorResponse{Error: "early access", Code: tt.code})
+			})
+			defer server.Close()
+
+			items := []StockReservationItem{{ProductID: uuid.New(), Quantity: 1}}
+			_, err := c.ReserveStock(context.Background(), uuid.New(), "order:key", "silver", items, time.Minute)
+
+			assert.ErrorIs(t, err, tt.want)
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestConsumeAndReleaseStock(t *testing.T) {
+	orderID := uuid.New()
+
+	var releaseReason, releaseKey string
+	c, server := newTestReservationClient(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/reservations/" + orderID.String() + "/consume":
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(reservationErrorResponse{Error: "expired", Code: "RESERVATION_NOT_ACTIVE"})
+		case "/api/v1/reservations/" + orderID.String() + "/release":
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			releaseReason = body["reason"]
+			releaseKey = body["idempotency_key"]
+			json.NewEncoder(w).Encode(reservationsResponse{})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(reservationErrorResponse{Error: "not found", Code: "RESERVATION_NOT_FOUND"})
+		}
+	})
+	defer server.Close()
+
+	assert.ErrorIs(t, c.ConsumeStock(context.Background(), orderID), ErrReservationNotActive)
+	assert.ErrorIs(t, c.ConsumeStock(context.Background(), uuid.New()), ErrReservationNotFound)
+
+	require.NoError(t, c.ReleaseStock(context.Background(), orderID, "order cancelled"))
+	assert.Equal(t, "order cancelled", releaseReason)
+	assert.Empty(t, releaseKey)
+
+	require.NoError(t, c.ReleaseReservation(context.Background(), orderID, "order:key:edit:1", "order edit failed"))
+	assert.Equal(t, "order edit failed", releaseReason)
+	assert.Equal(t, "order:key:edit:1", releaseKey)
+}
+
+func TestRestockStockSendsReturnedItemsUnderKey(t *testing.T) {
+	orderID := uuid.New()
+	productID := uuid.New()
+
+	var body restockStockRequest
+	c, server := newTestReservationClient(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/api/v1/reservations/"+orderID.String()+"/restock", r.URL.Path)
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+
+		if body.Items[0].Quantity > 1 {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(reservationErrorResponse{Error: "too many", Code: "RETURN_EXCEEDS_CONSUMED"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"returns": []interface{}{}})
+	})
+	defer server.Close()
+
+	items := []StockReservationItem{{ProductID: productID, Quantity: 1}}
+	require.NoError(t, c.RestockStock(context.Background(), orderID, "return:1:restock", "return 1", items))
+	assert.Equal(t, "return:1:restock", body.IdempotencyKey)
+	assert.Equal(t, "return 1", body.Reason)
+	assert.Equal(t, items, body.Items)
+
+	items[0].Quantity = 2
+	err := c.RestockStock(context.Background(), orderID, "return:2:restock", "return 2", items)
+	assert.ErrorIs(t, err, ErrReturnExceedsConsumed)
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// postJSON sends a JSON POST request, retrying network and server errors with exponential
+// backoff. A new request is built for every attempt so the body is sent again. Client errors are
+// not retried and are turned into an error by clientError.
+func postJSON(ctx context.Context, client *http.Client, maxRetries int, backoff time.Duration, url string, body interface{}, out interface{}, clientError func(status int, data []byte) error) error {
+	return sendJSON(ctx, client, http.MethodPost, maxRetries, backoff, url, body, out, clientError)
+}
+
+// getJSON sends a GET request and decodes the JSON response, retrying like postJSON
+func getJSON(ctx context.Context, client *http.Client, maxRetries int, backoff time.Duration, url string, out interface{}, clientError func(status int, data []byte) error) error {
+	return sendJSON(ctx, client, http.MethodGet, maxRetries, backoff, url, nil, out, clientError)
+}
+
+func sendJSON(ctx context.Context, client *http.Client, method string, maxRetries int, backoff time.Duration, url string, body interface{}, out interface{}, clientError func(status int, data []byte) error) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff * time.Duration(1<<(attempt-1))):
+			}
+		}
+
+		var reader io.Reader
+		if payload != nil {
+			reader = bytes.NewReader(payload)
+		}
+		req, err := http.NewRequestWithContext(ctx, method, url, reader)
+		if err != nil {
+			return fmt.Errorf("failed to create request: %w", err)
+		}
+		if method != http.MethodGet {
+			req.Header.Set("Content-Type", "application/json")
+		}
+		req.Header.Set("User-Agent", "order-service/1.0")
+
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= 500 {
+			lastErr = fmt.Errorf("server error: status %d", resp.StatusCode)
+			continue
+		}
+		if resp.StatusCode >= 400 {
+			return clientError(resp.StatusCode, data)
+		}
+
+		if out != nil {
+			if err := json.Unmarshal(data, out); err != nil {
+				return fmt.Errorf("failed to decode response: %w", err)
+			}
+		}
+		return nil
+	}
+
+	return fmt.Errorf("request failed after %d retries: %w", maxRetries, lastErr)
+}
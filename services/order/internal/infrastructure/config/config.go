@@ -4,17 +4,22 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // Config holds the application configuration
 type Config struct {
-	Server   ServerConfig
-	Database DatabaseConfig
-	Redis    RedisConfig
-	Kafka    KafkaConfig
-	External ExternalConfig
-	Logging  LoggingConfig
-	JWT      JWTConfig
+	Server      ServerConfig
+	Database    DatabaseConfig
+	Redis       RedisConfig
+	Kafka       KafkaConfig
+	Outbox      OutboxConfig
+	Reservation ReservationConfig
+	Recurring   RecurringOrderConfig
+	Tax         TaxConfig
+	External    ExternalConfig
+	Logging     LoggingConfig
+	JWT         JWTConfig
 }
 
 // ServerConfig holds server configuration
@@ -61,11 +66,54 @@ type KafkaTopics struct {
 	NotificationEvents string
 }
 
+// OutboxConfig holds event outbox relay configuration
+type OutboxConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	MaxRetries   int
+	BaseBackoff  time.Duration
+	MaxBackoff   time.Duration
+	// ClaimTimeout is how long a claimed batch is left to one relay before another may claim
+	// the events again
+	ClaimTimeout time.Duration
+}
+
+// ReservationConfig holds stock reservation configuration
+type ReservationConfig struct {
+	// TTL is how long stock stays reserved for an order that is not yet confirmed
+	TTL time.Duration
+}
+
+// RecurringOrderConfig holds recurring order scheduler configuration
+type RecurringOrderConfig struct {
+	PollInterval time.Duration
+	// ReminderLead is how long before each order the customer is reminded in chat
+	ReminderLead time.Duration
+	BatchSize    int
+}
+
+// TaxConfig holds VAT and tax invoice configuration
+type TaxConfig struct {
+	VATRate float64
+	// Mode is the default pricing mode of new orders: exclusive adds VAT on top of the prices,
+	// inclusive means the prices already contain it
+	Mode             string
+	SellerName       string
+	SellerTaxID      string
+	SellerAddress    string
+	SellerBranchCode string
+	// FontPath is a TrueType font with Thai glyphs for invoice PDFs; Helvetica when empty
+	FontPath string
+}
+
 // ExternalConfig holds external service configuration
 type ExternalConfig struct {
 	InventoryServiceURL   string
+	ProductServiceURL     string
+	ShippingServiceURL    string
 	CustomerServiceURL    string
 	PaymentServiceURL     string
+	FinanceServiceURL     string
 	NotificationServiceURL string
 	UserServiceURL        string
 	DeliveryServiceURL    string
@@ -80,6 +128,17 @@ type LoggingConfig struct {
 // JWTConfig holds JWT configuration
 type JWTConfig struct {
 	Secret string
+	// JWKSURL is where the user service publishes its token signing keys; empty uses
+	// /.well-known/jwks.json on the user service
+	JWKSURL string
+	// Issuer and Audience must match the iss and aud claims of every token; the service does
+	// not start without them
+	Issuer   string
+	Audience string
+	// CacheTTL is how long a verified token is trusted before its signature is checked again
+	CacheTTL time.Duration
+	// KeyRefreshInterval is how often the signing keys are fetched again to pick up rotations
+	KeyRefreshInterval time.Duration
 }
 
 // LoadConfig loads configuration from environment variables
@@ -88,6 +147,13 @@ func LoadConfig() *Config {
 	maxRetries, _ := strconv.Atoi(getEnv("REDIS_MAX_RETRIES", "3"))
 	poolSize, _ := strconv.Atoi(getEnv("REDIS_POOL_SIZE", "10"))
 	minIdleConns, _ := strconv.Atoi(getEnv("REDIS_MIN_IDLE_CONNS", "5"))
+	outboxBatchSize, _ := strconv.Atoi(getEnv("OUTBOX_BATCH_SIZE", "100"))
+	outboxMaxRetries, _ := strconv.Atoi(getEnv("OUTBOX_MAX_RETRIES", "10"))
+	recurringBatchSize, _ := strconv.Atoi(getEnv("RECURRING_ORDER_BATCH_SIZE", "50"))
+	vatRate, err := strconv.ParseFloat(getEnv("VAT_RATE", "0.07"), 64)
+	if err != nil {
+		vatRate = 0.07
+	}
 
 	return &Config{
 		Server: ServerConfig{
@@ -123,10 +189,38 @@ func LoadConfig() *Config {
 				NotificationEvents: getEnv("NOTIFICATION_EVENT_TOPIC", "notification-events"),
 			},
 		},
+		Outbox: OutboxConfig{
+			PollInterval: getDurationEnv("OUTBOX_POLL_INTERVAL", 2*time.Second),
+			BatchSize:    outboxBatchSize,
+			MaxRetries:   outboxMaxRetries,
+			BaseBackoff:  getDurationEnv("OUTBOX_BASE_BACKOFF", 1*time.Second),
+			MaxBackoff:   getDurationEnv("OUTBOX_MAX_BACKOFF", 5*time.Minute),
+			ClaimTimeout: getDurationEnv("OUTBOX_CLAIM_TIMEOUT", 5*time.Minute),
+		},
+		Reservation: ReservationConfig{
+			TTL: getDurationEnv("STOCK_RESERVATION_TTL", 30*time.Minute),
+		},
+		Recurring: RecurringOrderConfig{
+			PollInterval: getDurationEnv("RECURRING_ORDER_POLL_INTERVAL", time.Minute),
+			ReminderLead: getDurationEnv("RECURRING_ORDER_REMINDER_LEAD", 24*time.Hour),
+			BatchSize:    recurringBatchSize,
+		},
+		Tax: TaxConfig{
+			VATRate:          vatRate,
+			Mode:             getEnv("TAX_MODE", "exclusive"),
+			SellerName:       getEnv("SELLER_NAME", ""),
+			SellerTaxID:      getEnv("SELLER_TAX_ID", ""),
+			SellerAddress:    getEnv("SELLER_ADDRESS", ""),
+			SellerBranchCode: getEnv("SELLER_BRANCH_CODE", "00000"),
+			FontPath:         getEnv("INVOICE_FONT_PATH", ""),
+		},
 		External: ExternalConfig{
 			InventoryServiceURL:    getEnv("INVENTORY_SERVICE_URL", "http://inventory-service:8082"),
+			ProductServiceURL:      getEnv("PRODUCT_SERVICE_URL", "http://product-service:8083"),
+			ShippingServiceURL:     getEnv("SHIPPING_SERVICE_URL", "http://shipping-service:8086"),
 			CustomerServiceURL:     getEnv("CUSTOMER_SERVICE_URL", "http://customer-service:8084"),
 			PaymentServiceURL:      getEnv("PAYMENT_SERVICE_URL", "http://payment-service:8085"),
+			FinanceServiceURL:      getEnv("FINANCE_SERVICE_URL", "http://finance-service:8085"),
 			NotificationServiceURL: getEnv("NOTIFICATION_SERVICE_URL", "http://notification-service:8092"),
 			UserServiceURL:         getEnv("USER_SERVICE_URL", "http://user-service:8088"),
 			DeliveryServiceURL:     getEnv("DELIVERY_SERVICE_URL", "http://delivery-service:8089"),
@@ -136,7 +230,12 @@ func LoadConfig() *Config {
 			Format: getEnv("LOG_FORMAT", "json"),
 		},
 		JWT: JWTConfig{
-			Secret: getEnv("JWT_SECRET", "default-secret-key-for-development"),
+			Secret:             getEnv("JWT_SECRET", "default-secret-key-for-development"),
+			JWKSURL:            getEnv("JWT_JWKS_URL", ""),
+			Issuer:             getEnv("JWT_ISSUER", ""),
+			Audience:           getEnv("JWT_AUDIENCE", ""),
+			CacheTTL:           getDurationEnv("JWT_CACHE_TTL", 5*time.Minute),
+			KeyRefreshInterval: getDurationEnv("JWT_KEY_REFRESH_INTERVAL", time.Hour),
 		},
 	}
 }
@@ -148,3 +247,13 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getDurationEnv gets a duration environment variable (e.g. "5s") with a fallback value
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
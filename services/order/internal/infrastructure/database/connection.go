@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -88,6 +89,53 @@ func (c *Connection) Health() error {
 	return c.DB.Ping()
 }
 
+// txKey is the context key under which an active transaction is stored
+type txKey struct{}
+
+// WithTransaction runs fn inside a database transaction. Repositories that
+// resolve their executor through Executor(ctx) join the transaction, so every
+// write made inside fn is committed or rolled back together.
+func (c *Connection) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Join an already running transaction instead of nesting
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := c.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			c.logger.WithError(rbErr).Error("Failed to rollback transaction")
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Executor returns the transaction bound to ctx, or the connection pool when
+// no transaction is active
+func (c *Connection) Executor(ctx context.Context) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return c.DB
+}
+
 // GetDB returns the underlying sqlx.DB for backward compatibility
 func (c *Connection) GetDB() *sqlx.DB {
 	return c.DB
@@ -0,0 +1,332 @@
+package document
+
+import (
+	"encoding/xml"
+	"fmt"
+	"math"
+
+	"order/internal/domain"
+)
+
+// e-Tax Invoice documents follow the ETDA standard ขมธอ. 3-2560 version 2.0, which is based on
+// the UN/CEFACT Cross Industry Invoice
+const (
+	etaxGuideline     = "ER3-2560"
+	etaxNamespaceBase = "urn:etda:uncefact:data:standard:"
+	etaxDateLayout    = "2006-01-02T15:04:05"
+	etaxCurrency      = "THB"
+)
+
+// etaxDocumentType is how the standard names each kind of document
+type etaxDocumentType struct {
+	root     string
+	name     string
+	typeCode string
+}
+
+var etaxDocumentTypes = map[domain.InvoiceType]etaxDocumentType{
+	domain.InvoiceTypeTaxInvoice: {root: "TaxInvoice_CrossIndustryInvoice", name: "ใบกำกับภาษี", typeCode: "388"},
+	domain.InvoiceTypeReceipt:    {root: "Receipt_CrossIndustryInvoice", name: "ใบเสร็จรับเงิน", typeCode: "T01"},
+	domain.InvoiceTypeCreditNote: {root: "DebitCreditNote_CrossIndustryInvoice", name: "ใบลดหนี้", typeCode: "81"},
+}
+
+type etaxInvoice struct {
+	XMLName     xml.Name
+	RSM         string                   `xml:"xmlns:rsm,attr"`
+	RAM         string                   `xml:"xmlns:ram,attr"`
+	Context     etaxContext              `xml:"rsm:ExchangedDocumentContext"`
+	Document    etaxExchangedDocument    `xml:"rsm:ExchangedDocument"`
+	Transaction etaxSupplyChainTradeTrxn `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type etaxContext struct {
+	Guideline etaxID `xml:"ram:GuidelineSpecifiedDocumentContextParameter>ram:ID"`
+}
+
+type etaxID struct {
+	SchemeID        string `xml:"schemeID,attr,omitempty"`
+	SchemeAgencyID  string `xml:"schemeAgencyID,attr,omitempty"`
+	SchemeVersionID string `xml:"schemeVersionID,attr,omitempty"`
+	Value           string `xml:",chardata"`
+}
+
+type etaxExchangedDocument struct {
+	ID               string `xml:"ram:ID"`
+	Name             string `xml:"ram:Name"`
+	TypeCode         string `xml:"ram:TypeCode"`
+	IssueDateTime    string `xml:"ram:IssueDateTime"`
+	Purpose          string `xml:"ram:Purpose,omitempty"`
+	PurposeCode      string `xml:"ram:PurposeCode,omitempty"`
+	CreationDateTime string `xml:"ram:CreationDateTime"`
+}
+
+type etaxSupplyChainTradeTrxn struct {
+	Agreement  etaxHeaderAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   struct{}             `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement etaxHeaderSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+	Lines      []etaxLineItem       `xml:"ram:IncludedSupplyChainTradeLineItem"`
+}
+
+type etaxHeaderAgreement struct {
+	Seller    etaxParty              `xml:"ram:SellerTradeParty"`
+	Buyer     etaxParty              `xml:"ram:BuyerTradeParty"`
+	Reference *etaxReferenceDocument `xml:"ram:AdditionalReferencedDocument,omitempty"`
+}
+
+type etaxParty struct {
+	Name            string       `xml:"ram:Name"`
+	TaxRegistration etaxID       `xml:"ram:SpecifiedTaxRegistration>ram:ID"`
+	Address         *etaxAddress `xml:"ram:PostalTradeAddress,omitempty"`
+}
+
+type etaxAddress struct {
+	LineOne   string `xml:"ram:LineOne"`
+	CountryID etaxID `xml:"ram:CountryID"`
+}
+
+type etaxReferenceDocument struct {
+	IssuerAssignedID  string `xml:"ram:IssuerAssignedID"`
+	IssueDateTime     string `xml:"ram:IssueDateTime"`
+	ReferenceTypeCode string `xml:"ram:ReferenceTypeCode"`
+}
+
+type etaxHeaderSettlement struct {
+	CurrencyCode etaxCode              `xml:"ram:InvoiceCurrencyCode"`
+	TradeTaxes   []etaxTradeTax        `xml:"ram:ApplicableTradeTax"`
+	Allowance    *etaxAllowance        `xml:"ram:SpecifiedTradeAllowanceCharge,omitempty"`
+	Summation    etaxMonetarySummation `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type etaxCode struct {
+	ListID string `xml:"listID,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type etaxTradeTax struct {
+	TypeCode         string      `xml:"ram:TypeCode"`
+	CalculatedRate   string      `xml:"ram:CalculatedRate"`
+	BasisAmount      *etaxAmount `xml:"ram:BasisAmount,omitempty"`
+	CalculatedAmount etaxAmount  `xml:"ram:CalculatedAmount"`
+}
+
+type etaxAllowance struct {
+	ChargeIndicator bool       `xml:"ram:ChargeIndicator"`
+	ActualAmount    etaxAmount `xml:"ram:ActualAmount"`
+}
+
+type etaxMonetarySummation struct {
+	OriginalInformationAmount   *etaxAmount `xml:"ram:OriginalInformationAmount,omitempty"`
+	LineTotalAmount             etaxAmount  `xml:"ram:LineTotalAmount"`
+	DifferenceInformationAmount *etaxAmount `xml:"ram:DifferenceInformationAmount,omitempty"`
+	AllowanceTotalAmount        etaxAmount  `xml:"ram:AllowanceTotalAmount"`
+	TaxBasisTotalAmount         etaxAmount  `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotalAmount              etaxAmount  `xml:"ram:TaxTotalAmount"`
+	GrandTotalAmount            etaxAmount  `xml:"ram:GrandTotalAmount"`
+}
+
+type etaxLineItem struct {
+	LineID     string             `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Product    etaxProduct        `xml:"ram:SpecifiedTradeProduct"`
+	Price      etaxAmount         `xml:"ram:SpecifiedLineTradeAgreement>ram:GrossPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity   etaxQuantity       `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Settlement etaxLineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type etaxProduct struct {
+	ID   string `xml:"ram:ID,omitempty"`
+	Name string `xml:"ram:Name"`
+}
+
+type etaxQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    int    `xml:",chardata"`
+}
+
+type etaxLineSettlement struct {
+	TradeTax  etaxTradeTax            `xml:"ram:ApplicableTradeTax"`
+	Summation etaxLineMonetarySummary `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation"`
+}
+
+type etaxLineMonetarySummary struct {
+	TaxTotalAmount                   etaxAmount `xml:"ram:TaxTotalAmount"`
+	NetLineTotalAmount               etaxAmount `xml:"ram:NetLineTotalAmount"`
+	NetIncludingTaxesLineTotalAmount etaxAmount `xml:"ram:NetIncludingTaxesLineTotalAmount"`
+}
+
+type etaxAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+func amount(value float64) etaxAmount {
+	return etaxAmount{CurrencyID: etaxCurrency, Value: fmt.Sprintf("%.2f", value)}
+}
+
+func amountRef(value float64) *etaxAmount {
+	a := amount(value)
+	return &a
+}
+
+func rate(vatRate float64) string {
+	return fmt.Sprintf("%.2f", vatRate*100)
+}
+
+func round2(value float64) float64 {
+	return math.Round(value*100) / 100
+}
+
+// XML renders the invoice as an e-Tax Invoice document, ready to be signed and sent to the
+// Revenue Department
+func (r *Renderer) XML(invoice *domain.Invoice) ([]byte, error) {
+	docType, ok := etaxDocumentTypes[invoice.Type]
+	if !ok {
+		return nil, fmt.Errorf("unknown invoice type %q", invoice.Type)
+	}
+
+	issuedAt := invoice.IssuedAt.In(ictLocation).Format(etaxDateLayout)
+	doc := etaxInvoice{
+		XMLName: xml.Name{Local: "rsm:" + docType.root},
+		RSM:     etaxNamespaceBase + docType.root + ":2",
+		RAM:     etaxNamespaceBase + "ReusableAggregateBusinessInformationEntity:2",
+		Context: etaxContext{
+			Guideline: etaxID{SchemeAgencyID: "ETDA", SchemeVersionID: "v2.0", Value: etaxGuideline},
+		},
+		Document: etaxExchangedDocument{
+			ID:               invoice.Number,
+			Name:             docType.name,
+			TypeCode:         docType.typeCode,
+			IssueDateTime:    issuedAt,
+			CreationDateTime: invoice.CreatedAt.In(ictLocation).Format(etaxDateLayout),
+		},
+	}
+
+	trxn := &doc.Transaction
+	trxn.Agreement.Seller = etaxPartyOf(invoice.Seller)
+	trxn.Agreement.Buyer = etaxPartyOf(invoice.Buyer)
+
+	if invoice.Type == domain.InvoiceTypeCreditNote {
+		doc.Document.Purpose = invoice.Reason
+		doc.Document.PurposeCode = invoice.PurposeCode
+		if invoice.ReferenceNumber != nil && invoice.ReferenceIssuedAt != nil && invoice.ReferenceType != nil {
+			trxn.Agreement.Reference = &etaxReferenceDocument{
+				IssuerAssignedID:  *invoice.ReferenceNumber,
+				IssueDateTime:     invoice.ReferenceIssuedAt.In(ictLocation).Format(etaxDateLayout),
+				ReferenceTypeCode: etaxDocumentTypes[*invoice.ReferenceType].typeCode,
+			}
+		}
+	}
+
+	lineTotal := 0.0
+	for _, line := range invoice.Lines {
+		item := etaxLineOf(invoice, line)
+		trxn.Lines = append(trxn.Lines, item)
+		lineTotal += netAmount(invoice, line)
+	}
+	lineTotal = round2(lineTotal)
+
+	taxBasis := round2(invoice.TaxableAmount + invoice.ExemptAmount)
+	allowance := round2(lineTotal - taxBasis)
+	if allowance < 0 {
+		allowance = 0
+	}
+
+	settlement := &trxn.Settlement
+	settlement.CurrencyCode = etaxCode{ListID: "ISO 4217 3A", Value: etaxCurrency}
+	settlement.TradeTaxes = []etaxTradeTax{{
+		TypeCode:         "VAT",
+		CalculatedRate:   rate(invoice.VATRate),
+		BasisAmount:      amountRef(invoice.TaxableAmount),
+		CalculatedAmount: amount(invoice.VAT),
+	}}
+	if invoice.ExemptAmount > 0 {
+		settlement.TradeTaxes = append(settlement.TradeTaxes, etaxTradeTax{
+			TypeCode:         "VAT",
+			CalculatedRate:   rate(0),
+			BasisAmount:      amountRef(invoice.ExemptAmount),
+			CalculatedAmount: amount(0),
+		})
+	}
+	if allowance > 0 {
+		settlement.Allowance = &etaxAllowance{ActualAmount: amount(allowance)}
+	}
+	settlement.Summation = etaxMonetarySummation{
+		LineTotalAmount:      amount(lineTotal),
+		AllowanceTotalAmount: amount(allowance),
+		TaxBasisTotalAmount:  amount(taxBasis),
+		TaxTotalAmount:       amount(invoice.VAT),
+		GrandTotalAmount:     amount(invoice.Total),
+	}
+	if invoice.Type == domain.InvoiceTypeCreditNote {
+		settlement.Summation.OriginalInformationAmount = amountRef(invoice.OriginalAmount)
+		settlement.Summation.DifferenceInformationAmount = amountRef(invoice.Difference())
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode e-Tax invoice: %w", err)
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// etaxPartyOf names a party by its tax ID and branch. A buyer without a tax ID is registered as
+// not available, as the standard requires for receipts to consumers.
+func etaxPartyOf(party domain.InvoiceParty) etaxParty {
+	p := etaxParty{
+		Name:            party.Name,
+		TaxRegistration: etaxID{SchemeID: "OTHR", Value: "N/A"},
+	}
+	if party.TaxID != "" {
+		branch := party.BranchCode
+		if branch == "" {
+			branch = domain.HeadOfficeBranch
+		}
+		p.TaxRegistration = etaxID{SchemeID: "TXID", Value: party.TaxID + branch}
+	}
+	if party.Address != "" {
+		p.Address = &etaxAddress{LineOne: party.Address, CountryID: etaxID{SchemeID: "3166-1 alpha-2", Value: "TH"}}
+	}
+	return p
+}
+
+func etaxLineOf(invoice *domain.Invoice, line domain.InvoiceLine) etaxLineItem {
+	lineRate := invoice.VATRate
+	if line.VATExempt {
+		lineRate = 0
+	}
+	net := netAmount(invoice, line)
+	vat := round2(net * lineRate)
+	if invoice.TaxMode == domain.TaxModeInclusive {
+		vat = round2(line.Amount - net)
+	}
+
+	item := etaxLineItem{
+		LineID:   fmt.Sprintf("%d", line.LineNo),
+		Product:  etaxProduct{Name: line.Description},
+		Price:    amount(line.UnitPrice),
+		Quantity: etaxQuantity{UnitCode: "AU", Value: line.Quantity},
+		Settlement: etaxLineSettlement{
+			TradeTax: etaxTradeTax{
+				TypeCode:         "VAT",
+				CalculatedRate:   rate(lineRate),
+				CalculatedAmount: amount(vat),
+			},
+			Summation: etaxLineMonetarySummary{
+				TaxTotalAmount:                   amount(vat),
+				NetLineTotalAmount:               amount(net),
+				NetIncludingTaxesLineTotalAmount: amount(round2(net + vat)),
+			},
+		},
+	}
+	if line.ProductID != nil {
+		item.Product.ID = line.ProductID.String()
+	}
+	return item
+}
+
+// netAmount is the value of a line without VAT
+func netAmount(invoice *domain.Invoice, line domain.InvoiceLine) float64 {
+	if invoice.TaxMode != domain.TaxModeInclusive || line.VATExempt {
+		return line.Amount
+	}
+	return round2(line.Amount / (1 + invoice.VATRate))
+}
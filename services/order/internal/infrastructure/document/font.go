@@ -0,0 +1,259 @@
+package document
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// errInvalidFont is returned for font files that are not TrueType fonts this package can embed
+var errInvalidFont = errors.New("invalid TrueType font")
+
+// trueTypeFont is the part of a TrueType font needed to embed it in a PDF: its metrics and the
+// glyph of every character. The whole file is embedded, so no glyph outlines are parsed.
+type trueTypeFont struct {
+	name       string
+	data       []byte
+	unitsPerEm int
+	ascent     int
+	descent    int
+	capHeight  int
+	bbox       [4]int
+	advances   []int
+	glyphs     map[rune]uint16
+}
+
+// loadTrueTypeFont reads a TrueType font file. A font with Thai glyphs, such as Sarabun or
+// Garuda, is needed for Thai names and addresses.
+func loadTrueTypeFont(path string) (*trueTypeFont, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read font: %w", err)
+	}
+	font, err := parseTrueType(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	font.name = fontName(path)
+	return font, nil
+}
+
+// parseTrueType reads the metrics and character map of a TrueType font
+func parseTrueType(data []byte) (*trueTypeFont, error) {
+	if len(data) < 12 {
+		return nil, errInvalidFont
+	}
+	// CFF based OpenType fonts cannot be embedded as CIDFontType2
+	if version := binary.BigEndian.Uint32(data); version != 0x00010000 && version != 0x74727565 {
+		return nil, fmt.Errorf("%w: only TrueType outlines are supported", errInvalidFont)
+	}
+
+	tables := make(map[string][]byte)
+	numTables := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < numTables; i++ {
+		record := 12 + i*16
+		if record+16 > len(data) {
+			return nil, errInvalidFont
+		}
+		tag := string(data[record : record+4])
+		offset := int(binary.BigEndian.Uint32(data[record+8:]))
+		length := int(binary.BigEndian.Uint32(data[record+12:]))
+		if offset < 0 || length < 0 || offset+length > len(data) {
+			return nil, fmt.Errorf("%w: table %s is out of bounds", errInvalidFont, tag)
+		}
+		tables[tag] = data[offset : offset+length]
+	}
+	for _, tag := range []string{"head", "hhea", "maxp", "hmtx", "cmap"} {
+		if tables[tag] == nil {
+			return nil, fmt.Errorf("%w: missing %s table", errInvalidFont, tag)
+		}
+	}
+
+	head, hhea, maxp := tables["head"], tables["hhea"], tables["maxp"]
+	if len(head) < 54 || len(hhea) < 36 || len(maxp) < 6 {
+		return nil, errInvalidFont
+	}
+	font := &trueTypeFont{
+		data:       data,
+		unitsPerEm: int(binary.BigEndian.Uint16(head[18:])),
+		ascent:     int(int16(binary.BigEndian.Uint16(hhea[4:]))),
+		descent:    int(int16(binary.BigEndian.Uint16(hhea[6:]))),
+	}
+	if font.unitsPerEm == 0 {
+		return nil, errInvalidFont
+	}
+	for i := range font.bbox {
+		font.bbox[i] = int(int16(binary.BigEndian.Uint16(head[36+2*i:])))
+	}
+	font.capHeight = font.ascent
+	if os2 := tables["OS/2"]; len(os2) >= 90 && binary.BigEndian.Uint16(os2) >= 2 {
+		font.capHeight = int(int16(binary.BigEndian.Uint16(os2[88:])))
+	}
+
+	numGlyphs := int(binary.BigEndian.Uint16(maxp[4:]))
+	numMetrics := int(binary.BigEndian.Uint16(hhea[34:]))
+	hmtx := tables["hmtx"]
+	if numMetrics == 0 || numMetrics > numGlyphs || len(hmtx) < numMetrics*4 {
+		return nil, fmt.Errorf("%w: bad horizontal metrics", errInvalidFont)
+	}
+	font.advances = make([]int, numGlyphs)
+	for i := range font.advances {
+		if i < numMetrics {
+			font.advances[i] = int(binary.BigEndian.Uint16(hmtx[i*4:]))
+		} else {
+			font.advances[i] = font.advances[numMetrics-1]
+		}
+	}
+
+	glyphs, err := parseCmap(tables["cmap"])
+	if err != nil {
+		return nil, err
+	}
+	font.glyphs = glyphs
+	return font, nil
+}
+
+// parseCmap reads the Unicode character map, preferring the full repertoire table (format 12)
+// over the Basic Multilingual Plane one (format 4)
+func parseCmap(cmap []byte) (map[rune]uint16, error) {
+	if len(cmap) < 4 {
+		return nil, errInvalidFont
+	}
+
+	var bmp, full []byte
+	numTables := int(binary.BigEndian.Uint16(cmap[2:]))
+	for i := 0; i < numTables; i++ {
+		record := 4 + i*8
+		if record+8 > len(cmap) {
+			return nil, errInvalidFont
+		}
+		platform := binary.BigEndian.Uint16(cmap[record:])
+		encoding := binary.BigEndian.Uint16(cmap[record+2:])
+		offset := int(binary.BigEndian.Uint32(cmap[record+4:]))
+		if offset+2 > len(cmap) {
+			return nil, errInvalidFont
+		}
+		unicode := platform == 0 || (platform == 3 && (encoding == 1 || encoding == 10))
+		if !unicode {
+			continue
+		}
+		switch binary.BigEndian.Uint16(cmap[offset:]) {
+		case 4:
+			bmp = cmap[offset:]
+		case 12:
+			full = cmap[offset:]
+		}
+	}
+
+	switch {
+	case full != nil:
+		return parseCmapFormat12(full)
+	case bmp != nil:
+		return parseCmapFormat4(bmp)
+	}
+	return nil, fmt.Errorf("%w: no Unicode character map", errInvalidFont)
+}
+
+func parseCmapFormat4(table []byte) (map[rune]uint16, error) {
+	if len(table) < 14 {
+		return nil, errInvalidFont
+	}
+	segCount := int(binary.BigEndian.Uint16(table[6:])) / 2
+	endCodes := 14
+	startCodes := endCodes + segCount*2 + 2
+	idDeltas := startCodes + segCount*2
+	idRangeOffsets := idDeltas + segCount*2
+	if idRangeOffsets+segCount*2 > len(table) {
+		return nil, errInvalidFont
+	}
+
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < segCount; i++ {
+		end := int(binary.BigEndian.Uint16(table[endCodes+i*2:]))
+		start := int(binary.BigEndian.Uint16(table[startCodes+i*2:]))
+		delta := binary.BigEndian.Uint16(table[idDeltas+i*2:])
+		rangeOffsetAt := idRangeOffsets + i*2
+		rangeOffset := int(binary.BigEndian.Uint16(table[rangeOffsetAt:]))
+
+		for c := start; c <= end && c != 0xFFFF; c++ {
+			var glyph uint16
+			if rangeOffset == 0 {
+				glyph = uint16(c) + delta
+			} else {
+				at := rangeOffsetAt + rangeOffset + (c-start)*2
+				if at+2 > len(table) {
+					return nil, errInvalidFont
+				}
+				if glyph = binary.BigEndian.Uint16(table[at:]); glyph != 0 {
+					glyph += delta
+				}
+			}
+			if glyph != 0 {
+				glyphs[rune(c)] = glyph
+			}
+		}
+	}
+	return glyphs, nil
+}
+
+func parseCmapFormat12(table []byte) (map[rune]uint16, error) {
+	if len(table) < 16 {
+		return nil, errInvalidFont
+	}
+	numGroups := int(binary.BigEndian.Uint32(table[12:]))
+	if 16+numGroups*12 > len(table) {
+		return nil, errInvalidFont
+	}
+
+	glyphs := make(map[rune]uint16)
+	for i := 0; i < numGroups; i++ {
+		group := table[16+i*12:]
+		start := binary.BigEndian.Uint32(group)
+		end := binary.BigEndian.Uint32(group[4:])
+		glyph := binary.BigEndian.Uint32(group[8:])
+		if end < start || end > 0x10FFFF {
+			return nil, errInvalidFont
+		}
+		for c := start; c <= end; c++ {
+			glyphs[rune(c)] = uint16(glyph + c - start)
+		}
+	}
+	return glyphs, nil
+}
+
+// glyph returns the glyph of a character and whether the font has one
+func (f *trueTypeFont) glyph(r rune) (uint16, bool) {
+	glyph, ok := f.glyphs[r]
+	return glyph, ok
+}
+
+// advance is the width of a glyph in thousandths of the font size
+func (f *trueTypeFont) advance(glyph uint16) int {
+	if int(glyph) >= len(f.advances) {
+		return 0
+	}
+	return f.scale(f.advances[glyph])
+}
+
+// scale converts font units to thousandths of the font size, as PDF font metrics are given
+func (f *trueTypeFont) scale(units int) int {
+	return units * 1000 / f.unitsPerEm
+}
+
+// fontName makes a PDF font name from the file name
+func fontName(path string) string {
+	base := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	name := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' {
+			return r
+		}
+		return -1
+	}, base)
+	if name == "" {
+		return "EmbeddedFont"
+	}
+	return name
+}
@@ -0,0 +1,358 @@
+package document
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A4 page size and margins in points
+const (
+	pageWidth    = 595.28
+	pageHeight   = 841.89
+	pageMargin   = 40.0
+	contentWidth = pageWidth - 2*pageMargin
+)
+
+// pdfFile collects the objects of a PDF file. Objects are numbered from 1 in the order they are
+// reserved, so an object can be referred to before it is written.
+type pdfFile struct {
+	objects [][]byte
+}
+
+// reserve returns the number of a new, still empty object
+func (f *pdfFile) reserve() int {
+	f.objects = append(f.objects, nil)
+	return len(f.objects)
+}
+
+// set writes the body of a reserved object
+func (f *pdfFile) set(id int, body string) {
+	f.objects[id-1] = []byte(body)
+}
+
+// add writes a new object and returns its number
+func (f *pdfFile) add(body string) int {
+	id := f.reserve()
+	f.set(id, body)
+	return id
+}
+
+// addStream writes a new compressed stream object; dict holds any entries besides the filter
+// and length
+func (f *pdfFile) addStream(dict string, data []byte) (int, error) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	if _, err := zw.Write(data); err != nil {
+		return 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return 0, err
+	}
+
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "<< %s /Filter /FlateDecode /Length %d >>\nstream\n", dict, compressed.Len())
+	body.Write(compressed.Bytes())
+	body.WriteString("\nendstream")
+
+	id := f.reserve()
+	f.objects[id-1] = body.Bytes()
+	return id, nil
+}
+
+// bytes lays the objects out with their cross-reference table; root is the document catalog
+func (f *pdfFile) bytes(root, info int) []byte {
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+
+	offsets := make([]int, len(f.objects))
+	for i, object := range f.objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n", i+1)
+		out.Write(object)
+		out.WriteString("\nendobj\n")
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(f.objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R >>\nstartxref\n%d\n%%%%EOF\n",
+		len(f.objects)+1, root, info, xref)
+	return out.Bytes()
+}
+
+// pdfFont is a font text is set in. It encodes text for a content stream, measures it and
+// writes its own objects once the document is laid out.
+type pdfFont interface {
+	// encode returns text as a PDF string, replacing characters the font has no glyph for
+	encode(text string) string
+	// width is the width of text in points
+	width(text string, size float64) float64
+	// write adds the objects of the font and returns the number of its font dictionary
+	write(f *pdfFile) (int, error)
+	// unicode reports whether the font covers more than ASCII, e.g. Thai
+	unicode() bool
+}
+
+// helvetica is the standard Helvetica font every PDF reader has. It covers ASCII only.
+type helvetica struct{}
+
+// helveticaWidths are the advance widths of the printable ASCII characters from the Helvetica AFM
+var helveticaWidths = [95]int{
+	278, 278, 355, 556, 556, 889, 667, 191, 333, 333, 389, 584, 278, 333, 278, 278,
+	556, 556, 556, 556, 556, 556, 556, 556, 556, 556, 278, 278, 584, 584, 584, 556,
+	1015, 667, 667, 722, 722, 667, 611, 778, 722, 278, 500, 667, 556, 833, 722, 778,
+	667, 778, 722, 667, 611, 722, 667, 944, 667, 667, 611, 278, 278, 278, 469, 556,
+	333, 556, 556, 500, 556, 556, 278, 556, 556, 222, 222, 500, 222, 833, 556, 556,
+	556, 556, 333, 500, 278, 556, 500, 722, 500, 500, 500, 334, 260, 334, 584,
+}
+
+func asciiOnly(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < ' ' || r > '~' {
+			return '?'
+		}
+		return r
+	}, text)
+}
+
+func (helvetica) encode(text string) string {
+	var out strings.Builder
+	out.WriteByte('(')
+	for _, c := range []byte(asciiOnly(text)) {
+		if c == '(' || c == ')' || c == '\\' {
+			out.WriteByte('\\')
+		}
+		out.WriteByte(c)
+	}
+	out.WriteByte(')')
+	return out.String()
+}
+
+func (helvetica) width(text string, size float64) float64 {
+	total := 0
+	for _, c := range []byte(asciiOnly(text)) {
+		total += helveticaWidths[c-' ']
+	}
+	return float64(total) * size / 1000
+}
+
+func (helvetica) write(f *pdfFile) (int, error) {
+	return f.add("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>"), nil
+}
+
+func (helvetica) unicode() bool {
+	return false
+}
+
+// embeddedFont embeds a TrueType font as a composite font whose character codes are glyph IDs,
+// which is how PDF shows text in scripts like Thai. It remembers the glyphs used so the width
+// table and text mapping only list those.
+type embeddedFont struct {
+	font *trueTypeFont
+	used map[uint16]rune
+}
+
+func newEmbeddedFont(font *trueTypeFont) *embeddedFont {
+	return &embeddedFont{font: font, used: make(map[uint16]rune)}
+}
+
+// glyphs maps text to glyphs, using the glyph of '?' for characters the font does not have
+func (e *embeddedFont) glyphs(text string) []uint16 {
+	glyphs := make([]uint16, 0, len(text))
+	for _, r := range text {
+		glyph, ok := e.font.glyph(r)
+		if !ok {
+			r = '?'
+			glyph, _ = e.font.glyph(r)
+		}
+		glyphs = append(glyphs, glyph)
+		if _, seen := e.used[glyph]; !seen {
+			e.used[glyph] = r
+		}
+	}
+	return glyphs
+}
+
+func (e *embeddedFont) encode(text string) string {
+	var out strings.Builder
+	out.WriteByte('<')
+	for _, glyph := range e.glyphs(text) {
+		fmt.Fprintf(&out, "%04X", glyph)
+	}
+	out.WriteByte('>')
+	return out.String()
+}
+
+func (e *embeddedFont) width(text string, size float64) float64 {
+	total := 0
+	for _, glyph := range e.glyphs(text) {
+		total += e.font.advance(glyph)
+	}
+	return float64(total) * size / 1000
+}
+
+func (e *embeddedFont) write(f *pdfFile) (int, error) {
+	font := e.font
+	fontFile, err := f.addStream(fmt.Sprintf("/Length1 %d", len(font.data)), font.data)
+	if err != nil {
+		return 0, err
+	}
+	toUnicode, err := f.addStream("", e.toUnicode())
+	if err != nil {
+		return 0, err
+	}
+
+	descriptor := f.add(fmt.Sprintf(
+		"<< /Type /FontDescriptor /FontName /%s /Flags 32 /FontBBox [%d %d %d %d] /ItalicAngle 0 "+
+			"/Ascent %d /Descent %d /CapHeight %d /StemV 80 /FontFile2 %d 0 R >>",
+		font.name, font.scale(font.bbox[0]), font.scale(font.bbox[1]), font.scale(font.bbox[2]),
+		font.scale(font.bbox[3]), font.scale(font.ascent), font.scale(font.descent),
+		font.scale(font.capHeight), fontFile,
+	))
+	cidFont := f.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /CIDFontType2 /BaseFont /%s "+
+			"/CIDSystemInfo << /Registry (Adobe) /Ordering (Identity) /Supplement 0 >> "+
+			"/FontDescriptor %d 0 R /CIDToGIDMap /Identity /DW 1000 /W [%s] >>",
+		font.name, descriptor, e.widths(),
+	))
+	return f.add(fmt.Sprintf(
+		"<< /Type /Font /Subtype /Type0 /BaseFont /%s /Encoding /Identity-H "+
+			"/DescendantFonts [%d 0 R] /ToUnicode %d 0 R >>",
+		font.name, cidFont, toUnicode,
+	)), nil
+}
+
+func (e *embeddedFont) unicode() bool {
+	return true
+}
+
+func (e *embeddedFont) usedGlyphs() []uint16 {
+	glyphs := make([]uint16, 0, len(e.used))
+	for glyph := range e.used {
+		glyphs = append(glyphs, glyph)
+	}
+	sort.Slice(glyphs, func(i, j int) bool { return glyphs[i] < glyphs[j] })
+	return glyphs
+}
+
+// widths is the W array of the glyphs used
+func (e *embeddedFont) widths() string {
+	var out strings.Builder
+	for _, glyph := range e.usedGlyphs() {
+		fmt.Fprintf(&out, "%d [%d] ", glyph, e.font.advance(glyph))
+	}
+	return strings.TrimSpace(out.String())
+}
+
+// toUnicode is the CMap that maps the glyphs used back to text, so the document can be searched
+// and copied from
+func (e *embeddedFont) toUnicode() []byte {
+	var out bytes.Buffer
+	out.WriteString("/CIDInit /ProcSet findresource begin\n12 dict begin\nbegincmap\n" +
+		"/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n" +
+		"/CMapName /Adobe-Identity-UCS def\n/CMapType 2 def\n" +
+		"1 begincodespacerange\n<0000> <FFFF>\nendcodespacerange\n")
+
+	glyphs := e.usedGlyphs()
+	// A bfchar block may hold at most 100 entries
+	for start := 0; start < len(glyphs); start += 100 {
+		end := start + 100
+		if end > len(glyphs) {
+			end = len(glyphs)
+		}
+		fmt.Fprintf(&out, "%d beginbfchar\n", end-start)
+		for _, glyph := range glyphs[start:end] {
+			fmt.Fprintf(&out, "<%04X> <", glyph)
+			for _, unit := range utf16Units(e.used[glyph]) {
+				fmt.Fprintf(&out, "%04X", unit)
+			}
+			out.WriteString(">\n")
+		}
+		out.WriteString("endbfchar\n")
+	}
+
+	out.WriteString("endcmap\nCMapName currentdict /CMap defineresource pop\nend\nend")
+	return out.Bytes()
+}
+
+func utf16Units(r rune) []uint16 {
+	if r < 0x10000 {
+		return []uint16{uint16(r)}
+	}
+	r -= 0x10000
+	return []uint16{uint16(0xD800 + (r >> 10)), uint16(0xDC00 + (r & 0x3FF))}
+}
+
+// page is the content stream of one page
+type page struct {
+	content bytes.Buffer
+	font    pdfFont
+}
+
+// text sets text with its baseline starting at x, y
+func (p *page) text(x, y, size float64, text string) {
+	if text == "" {
+		return
+	}
+	fmt.Fprintf(&p.content, "BT /F1 %.2f Tf %.2f %.2f Td %s Tj ET\n", size, x, y, p.font.encode(text))
+}
+
+// textRight sets text so that it ends at x
+func (p *page) textRight(x, y, size float64, text string) {
+	p.text(x-p.font.width(text, size), y, size, text)
+}
+
+// line draws a thin line from x1, y1 to x2, y2
+func (p *page) line(x1, y1, x2, y2 float64) {
+	fmt.Fprintf(&p.content, "0.5 w %.2f %.2f m %.2f %.2f l S\n", x1, y1, x2, y2)
+}
+
+// writePDF lays out the pages with the font and returns the PDF file
+func writePDF(pages []*page, font pdfFont, title string) ([]byte, error) {
+	var f pdfFile
+	catalog := f.reserve()
+	pageTree := f.reserve()
+
+	fontID, err := font.write(&f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed font: %w", err)
+	}
+
+	kids := make([]string, len(pages))
+	for i, p := range pages {
+		content, err := f.addStream("", p.content.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to write page: %w", err)
+		}
+		pageID := f.add(fmt.Sprintf(
+			"<< /Type /Page /Parent %d 0 R /MediaBox [0 0 %.2f %.2f] "+
+				"/Resources << /Font << /F1 %d 0 R >> >> /Contents %d 0 R >>",
+			pageTree, pageWidth, pageHeight, fontID, content,
+		))
+		kids[i] = fmt.Sprintf("%d 0 R", pageID)
+	}
+
+	f.set(catalog, fmt.Sprintf("<< /Type /Catalog /Pages %d 0 R >>", pageTree))
+	f.set(pageTree, fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	info := f.add(fmt.Sprintf("<< /Title %s /Producer (order-service) >>", textString(title)))
+	return f.bytes(catalog, info), nil
+}
+
+// textString encodes text for the document information dictionary as UTF-16 with a byte order
+// mark, which any reader can show whatever the script
+func textString(text string) string {
+	var out strings.Builder
+	out.WriteString("<FEFF")
+	for _, r := range text {
+		for _, unit := range utf16Units(r) {
+			fmt.Fprintf(&out, "%04X", unit)
+		}
+	}
+	out.WriteByte('>')
+	return out.String()
+}
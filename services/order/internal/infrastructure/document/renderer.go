@@ -0,0 +1,332 @@
+package document
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"order/internal/domain"
+)
+
+// ictLocation is Indochina Time, the time zone invoice dates are shown in
+var ictLocation = time.FixedZone("ICT", 7*60*60)
+
+// Renderer renders invoices, receipts and credit notes as PDF and as e-Tax Invoice XML
+type Renderer struct {
+	font *trueTypeFont
+}
+
+// NewRenderer creates a renderer. PDFs embed the TrueType font at fontPath so Thai text can be
+// shown; without a font they are set in Helvetica, which covers English only.
+func NewRenderer(fontPath string) (*Renderer, error) {
+	if fontPath == "" {
+		return &Renderer{}, nil
+	}
+	font, err := loadTrueTypeFont(fontPath)
+	if err != nil {
+		return nil, err
+	}
+	return &Renderer{font: font}, nil
+}
+
+// label is a caption in Thai and English; documents show both when the font has Thai glyphs
+type label struct {
+	th string
+	en string
+}
+
+var (
+	labelTaxInvoice    = label{"ใบกำกับภาษี", "Tax Invoice"}
+	labelReceipt       = label{"ใบเสร็จรับเงิน", "Receipt"}
+	labelCreditNote    = label{"ใบลดหนี้", "Credit Note"}
+	labelNumber        = label{"เลขที่", "No."}
+	labelDate          = label{"วันที่", "Date"}
+	labelReference     = label{"อ้างถึง", "Reference"}
+	labelSeller        = label{"ผู้ขาย", "Seller"}
+	labelBuyer         = label{"ผู้ซื้อ", "Buyer"}
+	labelTaxID         = label{"เลขประจำตัวผู้เสียภาษี", "Tax ID"}
+	labelHeadOffice    = label{"สำนักงานใหญ่", "Head office"}
+	labelBranch        = label{"สาขา", "Branch"}
+	labelLineNo        = label{"ลำดับ", "No."}
+	labelDescription   = label{"รายการ", "Description"}
+	labelQuantity      = label{"จำนวน", "Qty"}
+	labelUnitPrice     = label{"ราคาต่อหน่วย", "Unit price"}
+	labelAmount        = label{"จำนวนเงิน", "Amount"}
+	labelSubtotal      = label{"รวมเป็นเงิน", "Subtotal"}
+	labelDiscount      = label{"ส่วนลด", "Discount"}
+	labelExempt        = label{"มูลค่าสินค้าที่ได้รับยกเว้นภาษี", "VAT exempt amount"}
+	labelTaxable       = label{"มูลค่าสินค้าที่ต้องเสียภาษี", "Taxable amount"}
+	labelVAT           = label{"ภาษีมูลค่าเพิ่ม", "VAT"}
+	labelTotal         = label{"จำนวนเงินรวมทั้งสิ้น", "Total"}
+	labelOriginal      = label{"มูลค่าตามใบกำกับภาษีเดิม", "Original value"}
+	labelCorrected     = label{"มูลค่าที่ถูกต้อง", "Corrected value"}
+	labelDifference    = label{"ผลต่าง", "Difference"}
+	labelReason        = label{"เหตุผล", "Reason"}
+	labelPricesInclVAT = label{"ราคาสินค้ารวมภาษีมูลค่าเพิ่มแล้ว", "Prices include VAT"}
+	labelExemptMark    = label{"* สินค้าได้รับยกเว้นภาษีมูลค่าเพิ่ม", "* VAT exempt"}
+	labelPage          = label{"หน้า", "Page"}
+)
+
+var documentTitles = map[domain.InvoiceType]label{
+	domain.InvoiceTypeTaxInvoice: labelTaxInvoice,
+	domain.InvoiceTypeReceipt:    labelReceipt,
+	domain.InvoiceTypeCreditNote: labelCreditNote,
+}
+
+// Columns of the line table, as the x position of their right edge except for the description
+var (
+	columnLineNo      = pageMargin
+	columnDescription = pageMargin + 40
+	columnQuantity    = pageMargin + 330.0
+	columnUnitPrice   = pageMargin + 420.0
+	columnAmount      = pageMargin + contentWidth
+)
+
+const (
+	bodySize   = 9.0
+	titleSize  = 16.0
+	lineHeight = 14.0
+	// footerSpace is kept free at the bottom of every page for the page number
+	footerSpace = 30.0
+)
+
+// invoiceLayout sets an invoice on as many pages as its lines need
+type invoiceLayout struct {
+	invoice *domain.Invoice
+	font    pdfFont
+	pages   []*page
+	current *page
+	y       float64
+}
+
+// PDF renders the invoice as a printable PDF document
+func (r *Renderer) PDF(invoice *domain.Invoice) ([]byte, error) {
+	if _, ok := documentTitles[invoice.Type]; !ok {
+		return nil, fmt.Errorf("unknown invoice type %q", invoice.Type)
+	}
+
+	var font pdfFont = helvetica{}
+	if r.font != nil {
+		font = newEmbeddedFont(r.font)
+	}
+	layout := &invoiceLayout{invoice: invoice, font: font}
+	layout.header()
+	layout.lines()
+	layout.totals()
+	layout.footers()
+
+	return writePDF(layout.pages, font, layout.caption(documentTitles[invoice.Type])+" "+invoice.Number)
+}
+
+// caption shows a label in both languages when the font can show Thai
+func (l *invoiceLayout) caption(lbl label) string {
+	if l.font.unicode() {
+		return lbl.th + " / " + lbl.en
+	}
+	return lbl.en
+}
+
+func (l *invoiceLayout) newPage() {
+	l.current = &page{font: l.font}
+	l.pages = append(l.pages, l.current)
+	l.y = pageHeight - pageMargin
+}
+
+// ensure starts a new page, repeating the table header, when less than height is left
+func (l *invoiceLayout) ensure(height float64) {
+	if l.y-height >= pageMargin+footerSpace {
+		return
+	}
+	l.newPage()
+	l.tableHeader()
+}
+
+func (l *invoiceLayout) header() {
+	l.newPage()
+	invoice := l.invoice
+	p := l.current
+
+	p.text(pageMargin, l.y-titleSize, titleSize, l.caption(documentTitles[invoice.Type]))
+	right := pageMargin + contentWidth
+	p.textRight(right, l.y-10, bodySize, l.caption(labelNumber)+": "+invoice.Number)
+	p.textRight(right, l.y-10-lineHeight, bodySize,
+		l.caption(labelDate)+": "+invoice.IssuedAt.In(ictLocation).Format("02/01/2006"))
+	l.y -= 2*lineHeight + 10
+
+	if invoice.ReferenceNumber != nil {
+		reference := *invoice.ReferenceNumber
+		if invoice.ReferenceIssuedAt != nil {
+			reference += " (" + invoice.ReferenceIssuedAt.In(ictLocation).Format("02/01/2006") + ")"
+		}
+		p.textRight(right, l.y, bodySize, l.caption(labelReference)+": "+reference)
+		l.y -= lineHeight
+	}
+	l.y -= lineHeight
+
+	l.party(labelSeller, invoice.Seller)
+	if invoice.Buyer.Name != "" {
+		l.party(labelBuyer, invoice.Buyer)
+	}
+	if invoice.Type == domain.InvoiceTypeCreditNote && invoice.Reason != "" {
+		p.text(pageMargin, l.y, bodySize, l.caption(labelReason)+": "+invoice.Reason)
+		l.y -= 2 * lineHeight
+	}
+
+	l.tableHeader()
+}
+
+// party shows the name, address, tax ID and branch of the seller or buyer
+func (l *invoiceLayout) party(lbl label, party domain.InvoiceParty) {
+	p := l.current
+	p.text(pageMargin, l.y, bodySize, l.caption(lbl)+": "+party.Name)
+	l.y -= lineHeight
+	if party.Address != "" {
+		p.text(pageMargin, l.y, bodySize, party.Address)
+		l.y -= lineHeight
+	}
+	if party.TaxID != "" {
+		branch := l.caption(labelHeadOffice)
+		if party.BranchCode != "" && !party.IsHeadOffice() {
+			branch = l.caption(labelBranch) + " " + party.BranchCode
+		}
+		p.text(pageMargin, l.y, bodySize, l.caption(labelTaxID)+": "+party.TaxID+"   "+branch)
+		l.y -= lineHeight
+	}
+	l.y -= lineHeight / 2
+}
+
+func (l *invoiceLayout) tableHeader() {
+	p := l.current
+	p.line(pageMargin, l.y+4, pageMargin+contentWidth, l.y+4)
+	l.y -= lineHeight - 4
+	p.text(columnLineNo, l.y, bodySize, l.caption(labelLineNo))
+	p.text(columnDescription, l.y, bodySize, l.caption(labelDescription))
+	p.textRight(columnQuantity, l.y, bodySize, l.caption(labelQuantity))
+	p.textRight(columnUnitPrice, l.y, bodySize, l.caption(labelUnitPrice))
+	p.textRight(columnAmount, l.y, bodySize, l.caption(labelAmount))
+	p.line(pageMargin, l.y-5, pageMargin+contentWidth, l.y-5)
+	l.y -= lineHeight + 4
+}
+
+func (l *invoiceLayout) lines() {
+	descriptionWidth := columnQuantity - columnDescription - 50
+	for _, line := range l.invoice.Lines {
+		l.ensure(lineHeight)
+		p := l.current
+		description := line.Description
+		if line.VATExempt {
+			description += " *"
+		}
+		p.text(columnLineNo, l.y, bodySize, fmt.Sprintf("%d", line.LineNo))
+		p.text(columnDescription, l.y, bodySize, l.fit(description, descriptionWidth))
+		p.textRight(columnQuantity, l.y, bodySize, fmt.Sprintf("%d", line.Quantity))
+		p.textRight(columnUnitPrice, l.y, bodySize, formatMoney(line.UnitPrice))
+		p.textRight(columnAmount, l.y, bodySize, formatMoney(line.Amount))
+		l.y -= lineHeight
+	}
+	l.current.line(pageMargin, l.y+lineHeight-5, pageMargin+contentWidth, l.y+lineHeight-5)
+	l.y -= lineHeight / 2
+}
+
+// fit shortens text to the width, ending it with an ellipsis
+func (l *invoiceLayout) fit(text string, width float64) string {
+	if l.font.width(text, bodySize) <= width {
+		return text
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && l.font.width(string(runes)+"...", bodySize) > width {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes) + "..."
+}
+
+func (l *invoiceLayout) totals() {
+	invoice := l.invoice
+	type row struct {
+		label label
+		value float64
+	}
+
+	var rows []row
+	if invoice.Type == domain.InvoiceTypeCreditNote {
+		rows = append(rows,
+			row{labelOriginal, invoice.OriginalAmount},
+			row{labelCorrected, invoice.CorrectedAmount},
+			row{labelDifference, invoice.Difference()},
+		)
+	} else {
+		rows = append(rows, row{labelSubtotal, invoice.Subtotal})
+		if invoice.Discount > 0 {
+			rows = append(rows, row{labelDiscount, invoice.Discount})
+		}
+		if invoice.ExemptAmount > 0 {
+			rows = append(rows, row{labelExempt, invoice.ExemptAmount})
+		}
+		rows = append(rows, row{labelTaxable, invoice.TaxableAmount})
+	}
+	vat := labelVAT
+	vat.th += fmt.Sprintf(" %s%%", formatRate(invoice.VATRate))
+	vat.en += fmt.Sprintf(" %s%%", formatRate(invoice.VATRate))
+	rows = append(rows, row{vat, invoice.VAT}, row{labelTotal, invoice.Total})
+
+	var notes []label
+	if hasExemptLines(invoice) {
+		notes = append(notes, labelExemptMark)
+	}
+	if invoice.TaxMode == domain.TaxModeInclusive && invoice.Type != domain.InvoiceTypeCreditNote {
+		notes = append(notes, labelPricesInclVAT)
+	}
+
+	l.ensure(float64(len(rows)+len(notes)) * lineHeight)
+	p := l.current
+	for i, r := range rows {
+		if i == len(rows)-1 {
+			p.line(columnUnitPrice-120, l.y+lineHeight-4, columnAmount, l.y+lineHeight-4)
+		}
+		p.textRight(columnUnitPrice, l.y, bodySize, l.caption(r.label))
+		p.textRight(columnAmount, l.y, bodySize, formatMoney(r.value))
+		l.y -= lineHeight
+	}
+	l.y -= lineHeight / 2
+	for _, note := range notes {
+		p.text(pageMargin, l.y, bodySize, l.caption(note))
+		l.y -= lineHeight
+	}
+}
+
+// footers numbers the pages once it is known how many there are
+func (l *invoiceLayout) footers() {
+	for i, p := range l.pages {
+		p.textRight(pageMargin+contentWidth, pageMargin, bodySize,
+			fmt.Sprintf("%s %d/%d", l.caption(labelPage), i+1, len(l.pages)))
+		p.text(pageMargin, pageMargin, bodySize, l.invoice.Number)
+	}
+}
+
+func hasExemptLines(invoice *domain.Invoice) bool {
+	for _, line := range invoice.Lines {
+		if line.VATExempt {
+			return true
+		}
+	}
+	return false
+}
+
+// formatMoney shows an amount with two decimals and thousands separators, e.g. 1,234.50
+func formatMoney(value float64) string {
+	text := fmt.Sprintf("%.2f", value)
+	sign := ""
+	if strings.HasPrefix(text, "-") {
+		sign, text = "-", text[1:]
+	}
+	whole, fraction := text[:len(text)-3], text[len(text)-3:]
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return sign + whole + fraction
+}
+
+// formatRate shows a VAT rate as a percentage without trailing zeros, e.g. 7
+func formatRate(vatRate float64) string {
+	return strings.TrimSuffix(strings.TrimRight(fmt.Sprintf("%.2f", vatRate*100), "0"), ".")
+}
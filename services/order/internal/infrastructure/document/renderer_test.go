@@ -0,0 +1,256 @@
+package document
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order/internal/domain"
+)
+
+// testFont builds a TrueType font with three glyphs: .notdef, '?' and the Thai letter ก
+func testFont() []byte {
+	be := binary.BigEndian
+
+	head := make([]byte, 54)
+	be.PutUint16(head[18:], 1000)
+	for i, v := range []int16{-50, -200, 900, 800} {
+		be.PutUint16(head[36+2*i:], uint16(v))
+	}
+	hhea := make([]byte, 36)
+	be.PutUint16(hhea[4:], 800)
+	be.PutUint16(hhea[6:], uint16(0xFFFF-199)) // -200
+	be.PutUint16(hhea[34:], 3)
+	maxp := []byte{0, 0, 0x50, 0, 0, 3}
+	hmtx := make([]byte, 12)
+	for i, advance := range []uint16{500, 600, 700} {
+		be.PutUint16(hmtx[i*4:], advance)
+	}
+
+	// Format 4 segments map '?' to glyph 1 and ก to glyph 2, closed by the 0xFFFF segment
+	ends := []uint16{'?', 0x0E01, 0xFFFF}
+	first, thai := uint16('?'), uint16(0x0E01)
+	deltas := []uint16{1 - first, 2 - thai, 1}
+	subtable := []byte{0, 4, 0, 0, 0, 0}
+	subtable = be.AppendUint16(subtable, uint16(len(ends)*2))
+	subtable = append(subtable, 0, 0, 0, 0, 0, 0)
+	for _, end := range ends {
+		subtable = be.AppendUint16(subtable, end)
+	}
+	subtable = append(subtable, 0, 0)
+	for _, start := range ends {
+		subtable = be.AppendUint16(subtable, start)
+	}
+	for _, delta := range deltas {
+		subtable = be.AppendUint16(subtable, delta)
+	}
+	subtable = append(subtable, make([]byte, len(ends)*2)...)
+	be.PutUint16(subtable[2:], uint16(len(subtable)))
+	cmap := append([]byte{0, 0, 0, 1, 0, 3, 0, 1, 0, 0, 0, 12}, subtable...)
+
+	tables := []struct {
+		tag  string
+		data []byte
+	}{{"cmap", cmap}, {"head", head}, {"hhea", hhea}, {"hmtx", hmtx}, {"maxp", maxp}}
+
+	font := []byte{0, 1, 0, 0}
+	font = be.AppendUint16(font, uint16(len(tables)))
+	font = append(font, make([]byte, 6)...)
+	offset := 12 + 16*len(tables)
+	for _, table := range tables {
+		font = append(font, table.tag...)
+		font = append(font, 0, 0, 0, 0)
+		font = be.AppendUint32(font, uint32(offset))
+		font = be.AppendUint32(font, uint32(len(table.data)))
+		offset += len(table.data)
+	}
+	for _, table := range tables {
+		font = append(font, table.data...)
+	}
+	return font
+}
+
+func testInvoice(lines int) *domain.Invoice {
+	issuedAt := time.Date(2025, 3, 31, 18, 30, 0, 0, time.UTC)
+	invoice := &domain.Invoice{
+		ID:         uuid.New(),
+		OrderID:    uuid.New(),
+		Type:       domain.InvoiceTypeTaxInvoice,
+		Number:     "INV-00000-2025-000042",
+		BranchCode: domain.HeadOfficeBranch,
+		IssuedAt:   issuedAt,
+		Seller: domain.InvoiceParty{
+			Name: "Siam Fresh Co., Ltd.", TaxID: "0105536000313", BranchCode: "00000", Address: "1 Sukhumvit Rd, Bangkok",
+		},
+		Buyer: domain.InvoiceParty{
+			Name: "บริษัท ลูกค้า จำกัด", TaxID: "1234567890121", BranchCode: "00002", Address: "9 Silom Rd, Bangkok",
+		},
+		TaxMode:       domain.TaxModeExclusive,
+		VATRate:       0.07,
+		CreatedAt:     issuedAt,
+		Subtotal:      0,
+		TaxableAmount: 0,
+	}
+	for i := 0; i < lines; i++ {
+		productID := uuid.New()
+		invoice.Lines = append(invoice.Lines, domain.InvoiceLine{
+			LineNo: i + 1, ProductID: &productID, Description: "Jasmine rice 5 kg", Quantity: 2, UnitPrice: 150, Amount: 300,
+		})
+		invoice.Subtotal += 300
+	}
+	invoice.TaxableAmount = invoice.Subtotal
+	invoice.VAT = invoice.Subtotal * 0.07
+	invoice.Total = invoice.Subtotal + invoice.VAT
+	return invoice
+}
+
+func TestParseTrueType(t *testing.T) {
+	font, err := parseTrueType(testFont())
+	require.NoError(t, err)
+
+	glyph, ok := font.glyph('ก')
+	assert.True(t, ok)
+	assert.Equal(t, uint16(2), glyph)
+	assert.Equal(t, 700, font.advance(glyph))
+	_, ok = font.glyph('A')
+	assert.False(t, ok)
+	assert.Equal(t, [4]int{-50, -200, 900, 800}, font.bbox)
+
+	_, err = parseTrueType([]byte("OTTO00000000"))
+	assert.ErrorIs(t, err, errInvalidFont)
+}
+
+func TestEmbeddedFontMapsThaiText(t *testing.T) {
+	font, err := parseTrueType(testFont())
+	require.NoError(t, err)
+	embedded := newEmbeddedFont(font)
+
+	// A has no glyph and is shown as ?
+	assert.Equal(t, "<00020001>", embedded.encode("กA"))
+	assert.Equal(t, 13.0, embedded.width("กA", 10))
+	assert.Equal(t, "1 [600] 2 [700]", embedded.widths())
+	assert.Contains(t, string(embedded.toUnicode()), "<0002> <0E01>")
+}
+
+func TestPDF(t *testing.T) {
+	renderer, err := NewRenderer("")
+	require.NoError(t, err)
+
+	pdf, err := renderer.PDF(testInvoice(3))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasPrefix(pdf, []byte("%PDF-1.4")))
+	assert.True(t, bytes.HasSuffix(pdf, []byte("%%EOF\n")))
+	assert.Contains(t, string(pdf), "/BaseFont /Helvetica")
+	assert.Contains(t, string(pdf), "/Count 1")
+
+	// Long invoices flow onto more pages
+	pdf, err = renderer.PDF(testInvoice(120))
+	require.NoError(t, err)
+	assert.Contains(t, string(pdf), "/Count 3")
+}
+
+func TestPDFEmbedsFont(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Test Sans.ttf")
+	require.NoError(t, os.WriteFile(path, testFont(), 0o600))
+
+	renderer, err := NewRenderer(path)
+	require.NoError(t, err)
+	pdf, err := renderer.PDF(testInvoice(1))
+	require.NoError(t, err)
+	assert.Contains(t, string(pdf), "/Subtype /Type0 /BaseFont /TestSans /Encoding /Identity-H")
+	assert.Contains(t, string(pdf), "/FontFile2")
+
+	_, err = NewRenderer(filepath.Join(t.TempDir(), "missing.ttf"))
+	assert.Error(t, err)
+}
+
+func TestXMLTaxInvoice(t *testing.T) {
+	renderer, err := NewRenderer("")
+	require.NoError(t, err)
+	invoice := testInvoice(2)
+	invoice.Discount = 100
+	invoice.TaxableAmount = 500
+	invoice.VAT = 35
+	invoice.Total = 535
+
+	out, err := renderer.XML(invoice)
+	require.NoError(t, err)
+	require.NoError(t, xml.Unmarshal(out, new(struct{})), "well formed")
+
+	doc := string(out)
+	assert.Contains(t, doc, `<rsm:TaxInvoice_CrossIndustryInvoice xmlns:rsm="urn:etda:uncefact:data:standard:TaxInvoice_CrossIndustryInvoice:2"`)
+	assert.Contains(t, doc, `<ram:ID schemeAgencyID="ETDA" schemeVersionID="v2.0">ER3-2560</ram:ID>`)
+	assert.Contains(t, doc, "<ram:TypeCode>388</ram:TypeCode>")
+	// Issued in Thai time, the next day
+	assert.Contains(t, doc, "<ram:IssueDateTime>2025-04-01T01:30:00</ram:IssueDateTime>")
+	assert.Contains(t, doc, `<ram:ID schemeID="TXID">123456789012100002</ram:ID>`)
+	assert.Contains(t, doc, `<ram:LineTotalAmount currencyID="THB">600.00</ram:LineTotalAmount>`)
+	assert.Contains(t, doc, `<ram:AllowanceTotalAmount currencyID="THB">100.00</ram:AllowanceTotalAmount>`)
+	assert.Contains(t, doc, `<ram:TaxBasisTotalAmount currencyID="THB">500.00</ram:TaxBasisTotalAmount>`)
+	assert.Contains(t, doc, `<ram:GrandTotalAmount currencyID="THB">535.00</ram:GrandTotalAmount>`)
+	assert.Equal(t, 2, strings.Count(doc, "<ram:IncludedSupplyChainTradeLineItem>"))
+}
+
+func TestXMLCreditNote(t *testing.T) {
+	renderer, err := NewRenderer("")
+	require.NoError(t, err)
+
+	referenceNumber := "RCT-00000-2025-000007"
+	referenceType := domain.InvoiceTypeReceipt
+	issuedAt := time.Date(2025, 3, 1, 3, 0, 0, 0, time.UTC)
+	productID := uuid.New()
+	note := &domain.Invoice{
+		Type:              domain.InvoiceTypeCreditNote,
+		Number:            "CN-00000-2025-000001",
+		IssuedAt:          issuedAt.Add(72 * time.Hour),
+		Seller:            testInvoice(0).Seller,
+		TaxMode:           domain.TaxModeInclusive,
+		VATRate:           0.07,
+		TaxableAmount:     200,
+		VAT:               14,
+		Total:             214,
+		ReferenceNumber:   &referenceNumber,
+		ReferenceIssuedAt: &issuedAt,
+		ReferenceType:     &referenceType,
+		OriginalAmount:    500,
+		CorrectedAmount:   300,
+		PurposeCode:       domain.CreditNotePurposeReturn,
+		Reason:            "damaged",
+		Lines: []domain.InvoiceLine{
+			{LineNo: 1, ProductID: &productID, Description: "Jasmine rice 5 kg", Quantity: 2, UnitPrice: 107, Amount: 214},
+		},
+	}
+
+	out, err := renderer.XML(note)
+	require.NoError(t, err)
+
+	doc := string(out)
+	assert.Contains(t, doc, "<rsm:DebitCreditNote_CrossIndustryInvoice")
+	assert.Contains(t, doc, "<ram:TypeCode>81</ram:TypeCode>")
+	assert.Contains(t, doc, "<ram:PurposeCode>CDNG05</ram:PurposeCode>")
+	assert.Contains(t, doc, "<ram:IssuerAssignedID>RCT-00000-2025-000007</ram:IssuerAssignedID>")
+	assert.Contains(t, doc, "<ram:ReferenceTypeCode>T01</ram:ReferenceTypeCode>")
+	// A buyer without a tax ID is registered as not available
+	assert.Contains(t, doc, `<ram:ID schemeID="OTHR">N/A</ram:ID>`)
+	assert.Contains(t, doc, `<ram:OriginalInformationAmount currencyID="THB">500.00</ram:OriginalInformationAmount>`)
+	assert.Contains(t, doc, `<ram:DifferenceInformationAmount currencyID="THB">200.00</ram:DifferenceInformationAmount>`)
+	assert.Contains(t, doc, `<ram:NetLineTotalAmount currencyID="THB">200.00</ram:NetLineTotalAmount>`)
+	assert.NotContains(t, doc, "SpecifiedTradeAllowanceCharge")
+}
+
+func TestFormatMoney(t *testing.T) {
+	assert.Equal(t, "0.00", formatMoney(0))
+	assert.Equal(t, "999.50", formatMoney(999.5))
+	assert.Equal(t, "1,234,567.89", formatMoney(1234567.89))
+	assert.Equal(t, "-1,000.00", formatMoney(-1000))
+	assert.Equal(t, "7", formatRate(0.07))
+	assert.Equal(t, "6.5", formatRate(0.065))
+}
@@ -34,19 +34,22 @@ func (a *DomainEventAdapter) PublishEvent(ctx context.Context, event *domain.Ord
 		"retry_count": event.RetryCount,
 	}
 
+	customerID, _ := event.Payload["customer_id"].(string)
+
 	// Map domain event types to our event types and publish accordingly
 	switch event.EventType {
 	case domain.EventOrderCreated:
-		return a.publisher.PublishOrderCreated(ctx, event.OrderID.String(), "", eventData)
-	case domain.EventOrderUpdated:
-		return a.publisher.PublishOrderUpdated(ctx, event.OrderID.String(), "", eventData)
+		return a.publisher.PublishOrderCreated(ctx, event.OrderID.String(), customerID, event.Payload)
+	case domain.EventOrderUpdated, domain.EventOrderShipped, domain.EventOrderRefunded, domain.EventInvoiceIssued:
+		return a.publisher.PublishOrderUpdated(ctx, event.OrderID.String(), customerID, event.Payload)
 	case domain.EventOrderCancelled:
-		return a.publisher.PublishOrderCancelled(ctx, event.OrderID.String(), "", "")
+		reason, _ := event.Payload["reason"].(string)
+		return a.publisher.PublishOrderCancelled(ctx, event.OrderID.String(), customerID, reason)
 	case domain.EventOrderDelivered:
-		return a.publisher.PublishOrderCompleted(ctx, event.OrderID.String(), "")
+		return a.publisher.PublishOrderCompleted(ctx, event.OrderID.String(), customerID)
 	default:
 		// For unknown event types, use order updated as fallback
 		a.logger.WithField("event_type", event.EventType).Warn("Unknown event type, using order updated as fallback")
-		return a.publisher.PublishOrderUpdated(ctx, event.OrderID.String(), "", eventData)
+		return a.publisher.PublishOrderUpdated(ctx, event.OrderID.String(), customerID, eventData)
 	}
 }
@@ -0,0 +1,261 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"order/internal/domain"
+	"order/internal/infrastructure/config"
+)
+
+// OutboxRelay drains the order event outbox and publishes the events to the broker.
+// Delivery is at-least-once: an event is only marked as sent after the publisher accepted it.
+// Each batch is claimed for ClaimTimeout, so relays running in several replicas never publish
+// the same event at the same time.
+type OutboxRelay struct {
+	repo      domain.OrderEventRepository
+	txManager domain.TransactionManager
+	publisher domain.EventPublisher
+	config    config.OutboxConfig
+	logger    *logrus.Logger
+	now       func() time.Time
+
+	mu           sync.RWMutex
+	lastRunAt    time.Time
+	lastError    error
+	published    int64
+	failed       int64
+	deadLettered int64
+}
+
+// RelayHealth describes the state of the outbox relay for health output
+type RelayHealth struct {
+	Status       string              `json:"status"`
+	Outbox       *domain.OutboxStats `json:"outbox,omitempty"`
+	LagSeconds   float64             `json:"lag_seconds"`
+	LastRunAt    *time.Time          `json:"last_run_at,omitempty"`
+	LastError    string              `json:"last_error,omitempty"`
+	Published    int64               `json:"published"`
+	Failed       int64               `json:"failed"`
+	DeadLettered int64               `json:"dead_lettered"`
+}
+
+// NewOutboxRelay creates a new outbox relay publishing through the given publisher
+func NewOutboxRelay(repo domain.OrderEventRepository, txManager domain.TransactionManager, publisher Publisher, cfg config.OutboxConfig, logger *logrus.Logger) *OutboxRelay {
+	return &OutboxRelay{
+		repo:      repo,
+		txManager: txManager,
+		publisher: NewDomainEventAdapter(publisher, logger),
+		config:    cfg,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// Run polls the outbox until the context is cancelled
+func (r *OutboxRelay) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.config.PollInterval)
+	defer ticker.Stop()
+
+	r.logger.WithField("poll_interval", r.config.PollInterval).Info("Outbox relay started")
+
+	for {
+		if _, err := r.ProcessBatch(ctx); err != nil && ctx.Err() == nil {
+			r.logger.WithError(err).Error("Outbox relay batch failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			r.logger.Info("Outbox relay stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ProcessBatch publishes one batch of pending events followed by failed events
+// whose backoff has elapsed. It returns the number of events published. The batch is claimed
+// in one short transaction and the outcomes are recorded in another, so no transaction stays
+// open while the broker is called. Other relays skip the batch until its claim expires.
+func (r *OutboxRelay) ProcessBatch(ctx context.Context) (int, error) {
+	claimUntil := r.now().Add(r.config.ClaimTimeout)
+	var events []*domain.OrderEventOutbox
+	err := r.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		pending, err := r.repo.ClaimPendingEvents(ctx, r.config.BatchSize, claimUntil)
+		if err != nil {
+			return err
+		}
+
+		retries, err := r.repo.ClaimFailedEvents(ctx, r.config.MaxRetries, r.config.BatchSize, claimUntil)
+		if err != nil {
+			return err
+		}
+
+		events = append(pending, retries...)
+		return nil
+	})
+	if err != nil {
+		r.recordRun(err)
+		return 0, err
+	}
+
+	// Events left when the relay stops or the claim runs out are published once it expires
+	var attempts []publishAttempt
+	for _, event := range events {
+		if ctx.Err() != nil || r.now().After(claimUntil) {
+			break
+		}
+		attempts = append(attempts, publishAttempt{event: event, err: r.publisher.PublishEvent(ctx, event)})
+	}
+	if len(attempts) == 0 {
+		r.recordRun(nil)
+		return 0, nil
+	}
+
+	// The outcomes are recorded even when the relay is stopping, so published events are not
+	// published again
+	var tally relayTally
+	err = r.txManager.WithTransaction(context.WithoutCancel(ctx), func(ctx context.Context) error {
+		tally = relayTally{}
+		for _, attempt := range attempts {
+			if err := r.record(ctx, attempt, &tally); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		// Outcomes that were not committed are published again once the claim expires
+		r.recordRun(err)
+		return 0, err
+	}
+
+	r.mu.Lock()
+	r.published += tally.published
+	r.failed += tally.failed
+	r.deadLettered += tally.deadLettered
+	r.mu.Unlock()
+	r.recordRun(nil)
+	return int(tally.published), nil
+}
+
+// publishAttempt is an event the relay tried to publish and the error of the attempt
+type publishAttempt struct {
+	event *domain.OrderEventOutbox
+	err   error
+}
+
+// relayTally counts the outcomes recorded for a batch
+type relayTally struct {
+	published    int64
+	failed       int64
+	deadLettered int64
+}
+
+// record saves the outcome of a publish attempt in the outbox. An event that was deleted in the
+// meantime is skipped.
+func (r *OutboxRelay) record(ctx context.Context, attempt publishAttempt, tally *relayTally) error {
+	event := attempt.event
+	log := r.logger.WithFields(logrus.Fields{
+		"event_id":    event.ID,
+		"order_id":    event.OrderID,
+		"event_type":  event.EventType,
+		"retry_count": event.RetryCount,
+	})
+
+	if attempt.err == nil {
+		err := r.repo.MarkAsSent(ctx, event.ID)
+		if errors.Is(err, domain.ErrEventNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark outbox event %s as sent: %w", event.ID, err)
+		}
+		tally.published++
+		return nil
+	}
+
+	// The retry count is incremented by the repository, so this attempt is retry_count+1
+	if event.RetryCount+1 >= r.config.MaxRetries {
+		err := r.repo.MarkAsDeadLetter(ctx, event.ID, attempt.err.Error())
+		if errors.Is(err, domain.ErrEventNotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to dead-letter outbox event %s: %w", event.ID, err)
+		}
+		tally.deadLettered++
+		log.WithError(attempt.err).Error("Outbox event exhausted retries and was dead-lettered")
+		return nil
+	}
+
+	nextAttemptAt := r.now().Add(r.backoff(event.RetryCount))
+	err := r.repo.MarkAsFailed(ctx, event.ID, nextAttemptAt, attempt.err.Error())
+	if errors.Is(err, domain.ErrEventNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event %s as failed: %w", event.ID, err)
+	}
+	tally.failed++
+	log.WithError(attempt.err).WithField("next_attempt_at", nextAttemptAt).Warn("Failed to publish outbox event, scheduled retry")
+	return nil
+}
+
+// backoff returns the exponential delay before the next attempt, capped at MaxBackoff
+func (r *OutboxRelay) backoff(retryCount int) time.Duration {
+	delay := r.config.BaseBackoff
+	for i := 0; i < retryCount; i++ {
+		delay *= 2
+		if delay >= r.config.MaxBackoff {
+			return r.config.MaxBackoff
+		}
+	}
+	return delay
+}
+
+func (r *OutboxRelay) recordRun(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastRunAt = r.now()
+	r.lastError = err
+}
+
+// Health reports the relay state together with the outbox backlog and lag
+func (r *OutboxRelay) Health(ctx context.Context) *RelayHealth {
+	r.mu.RLock()
+	health := &RelayHealth{
+		Status:       "healthy",
+		Published:    r.published,
+		Failed:       r.failed,
+		DeadLettered: r.deadLettered,
+	}
+	if !r.lastRunAt.IsZero() {
+		lastRunAt := r.lastRunAt
+		health.LastRunAt = &lastRunAt
+	}
+	if r.lastError != nil {
+		health.Status = "degraded"
+		health.LastError = r.lastError.Error()
+	}
+	r.mu.RUnlock()
+
+	stats, err := r.repo.GetStats(ctx)
+	if err != nil {
+		health.Status = "degraded"
+		health.LastError = err.Error()
+		return health
+	}
+
+	health.Outbox = stats
+	health.LagSeconds = stats.Lag(r.now()).Seconds()
+	if stats.DeadLetterCount > 0 {
+		health.Status = "degraded"
+	}
+
+	return health
+}
@@ -0,0 +1,289 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order/internal/domain"
+	"order/internal/infrastructure/config"
+)
+
+// fakeEventRepository is an in-memory outbox used to exercise the relay
+type fakeEventRepository struct {
+	events map[uuid.UUID]*domain.OrderEventOutbox
+	// unclaimedReads counts batches claimed outside a transaction
+	unclaimedReads int
+	now            func() time.Time
+}
+
+func newFakeEventRepository(events ...*domain.OrderEventOutbox) *fakeEventRepository {
+	repo := &fakeEventRepository{events: make(map[uuid.UUID]*domain.OrderEventOutbox), now: time.Now}
+	for _, e := range events {
+		repo.events[e.ID] = e
+	}
+	return repo
+}
+
+func (r *fakeEventRepository) Create(ctx context.Context, event *domain.OrderEventOutbox) error {
+	r.events[event.ID] = event
+	return nil
+}
+
+func (r *fakeEventRepository) ClaimPendingEvents(ctx context.Context, limit int, claimUntil time.Time) ([]*domain.OrderEventOutbox, error) {
+	return r.claim(ctx, claimUntil, func(e *domain.OrderEventOutbox) bool {
+		return e.Status == domain.EventStatusPending
+	}), nil
+}
+
+func (r *fakeEventRepository) ClaimFailedEvents(ctx context.Context, maxRetries int, limit int, claimUntil time.Time) ([]*domain.OrderEventOutbox, error) {
+	return r.claim(ctx, claimUntil, func(e *domain.OrderEventOutbox) bool {
+		return e.ShouldRetry(maxRetries)
+	}), nil
+}
+
+// claim claims the matching events whose next attempt is due until claimUntil
+func (r *fakeEventRepository) claim(ctx context.Context, claimUntil time.Time, match func(e *domain.OrderEventOutbox) bool) []*domain.OrderEventOutbox {
+	if ctx.Value(fakeTxKey{}) == nil {
+		r.unclaimedReads++
+	}
+	var out []*domain.OrderEventOutbox
+	for _, e := range r.events {
+		if match(e) && (e.NextAttemptAt == nil || !e.NextAttemptAt.After(r.now())) {
+			until := claimUntil
+			e.NextAttemptAt = &until
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+func (r *fakeEventRepository) UpdateStatus(ctx context.Context, eventID uuid.UUID, status domain.EventStatus) error {
+	r.events[eventID].Status = status
+	return nil
+}
+
+func (r *fakeEventRepository) MarkAsSent(ctx context.Context, eventID uuid.UUID) error {
+	r.events[eventID].MarkAsSent()
+	return nil
+}
+
+func (r *fakeEventRepository) MarkAsFailed(ctx context.Context, eventID uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	e := r.events[eventID]
+	e.MarkAsFailed()
+	e.NextAttemptAt = &nextAttemptAt
+	e.LastError = &lastError
+	return nil
+}
+
+func (r *fakeEventRepository) MarkAsDeadLetter(ctx context.Context, eventID uuid.UUID, lastError string) error {
+	e := r.events[eventID]
+	e.RetryCount++
+	e.MarkAsDeadLetter(lastError)
+	return nil
+}
+
+func (r *fakeEventRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.OrderEventOutbox, error) {
+	return nil, nil
+}
+
+func (r *fakeEventRepository) Delete(ctx context.Context, eventID uuid.UUID) error {
+	delete(r.events, eventID)
+	return nil
+}
+
+func (r *fakeEventRepository) GetStats(ctx context.Context) (*domain.OutboxStats, error) {
+	stats := &domain.OutboxStats{}
+	for _, e := range r.events {
+		switch e.Status {
+		case domain.EventStatusPending:
+			stats.PendingCount++
+		case domain.EventStatusFailed:
+			stats.FailedCount++
+		case domain.EventStatusDeadLetter:
+			stats.DeadLetterCount++
+			continue
+		default:
+			continue
+		}
+		if stats.OldestPendingAt == nil || e.CreatedAt.Before(*stats.OldestPendingAt) {
+			createdAt := e.CreatedAt
+			stats.OldestPendingAt = &createdAt
+		}
+	}
+	return stats, nil
+}
+
+type fakeTxKey struct{}
+
+// fakeTxManager runs work in a fake transaction its context carries
+type fakeTxManager struct {
+	calls int
+}
+
+func (m *fakeTxManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	m.calls++
+	return fn(context.WithValue(ctx, fakeTxKey{}, true))
+}
+
+// txCheckingPublisher records publishes made inside a transaction
+type txCheckingPublisher struct {
+	NoopPublisher
+	inTransaction int
+}
+
+func (p *txCheckingPublisher) PublishOrderCreated(ctx context.Context, orderID, customerID string, orderData map[string]interface{}) error {
+	if ctx.Value(fakeTxKey{}) != nil {
+		p.inTransaction++
+	}
+	return nil
+}
+
+// failingPublisher fails every order created publish
+type failingPublisher struct {
+	NoopPublisher
+	calls int
+}
+
+func (p *failingPublisher) PublishOrderCreated(ctx context.Context, orderID, customerID string, orderData map[string]interface{}) error {
+	p.calls++
+	return errors.New("broker unavailable")
+}
+
+func newTestLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func newTestRelay(repo domain.OrderEventRepository, publisher Publisher) *OutboxRelay {
+	cfg := config.OutboxConfig{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		MaxRetries:   3,
+		BaseBackoff:  time.Second,
+		MaxBackoff:   4 * time.Second,
+		ClaimTimeout: 30 * time.Second,
+	}
+	return NewOutboxRelay(repo, &fakeTxManager{}, publisher, cfg, newTestLogger())
+}
+
+func TestOutboxRelay_PublishesPendingEvents(t *testing.T) {
+	event := domain.NewOrderEvent(uuid.New(), domain.EventOrderCreated, map[string]interface{}{"customer_id": uuid.NewString()})
+	repo := newFakeEventRepository(event)
+	relay := newTestRelay(repo, NewNoopPublisher(newTestLogger()))
+
+	sent, err := relay.ProcessBatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, domain.EventStatusSent, event.Status)
+	assert.NotNil(t, event.SentAt)
+
+	health := relay.Health(context.Background())
+	assert.Equal(t, "healthy", health.Status)
+	assert.Equal(t, int64(1), health.Published)
+	assert.Zero(t, health.LagSeconds)
+}
+
+func TestOutboxRelay_PublishesOutsideTransactions(t *testing.T) {
+	pending := domain.NewOrderEvent(uuid.New(), domain.EventOrderCreated, nil)
+	failed := domain.NewOrderEvent(uuid.New(), domain.EventOrderCreated, nil)
+	failed.Status = domain.EventStatusFailed
+	repo := newFakeEventRepository(pending, failed)
+	txManager := &fakeTxManager{}
+	publisher := &txCheckingPublisher{}
+	relay := NewOutboxRelay(repo, txManager, publisher, newTestRelay(repo, nil).config, newTestLogger())
+
+	sent, err := relay.ProcessBatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	// One transaction claims the batch and another records the outcomes
+	assert.Equal(t, 2, txManager.calls)
+	assert.Zero(t, repo.unclaimedReads)
+	assert.Zero(t, publisher.inTransaction)
+	assert.Equal(t, domain.EventStatusSent, pending.Status)
+	assert.Equal(t, domain.EventStatusSent, failed.Status)
+}
+
+func TestOutboxRelay_SkipsClaimedEventsUntilClaimExpires(t *testing.T) {
+	event := domain.NewOrderEvent(uuid.New(), domain.EventOrderCreated, nil)
+	repo := newFakeEventRepository(event)
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	repo.now = func() time.Time { return now }
+	relay := newTestRelay(repo, NewNoopPublisher(newTestLogger()))
+	relay.now = repo.now
+
+	// Another relay claimed the event and has not recorded an outcome yet
+	_, err := repo.ClaimPendingEvents(context.WithValue(context.Background(), fakeTxKey{}, true), 10, now.Add(30*time.Second))
+	require.NoError(t, err)
+
+	sent, err := relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Equal(t, domain.EventStatusPending, event.Status)
+
+	// The other relay stopped, so the event is published once its claim expired
+	now = now.Add(31 * time.Second)
+	sent, err = relay.ProcessBatch(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Equal(t, domain.EventStatusSent, event.Status)
+}
+
+func TestOutboxRelay_SchedulesRetryWithBackoff(t *testing.T) {
+	event := domain.NewOrderEvent(uuid.New(), domain.EventOrderCreated, nil)
+	repo := newFakeEventRepository(event)
+	relay := newTestRelay(repo, &failingPublisher{})
+	now := time.Date(2025, 7, 1, 10, 0, 0, 0, time.UTC)
+	relay.now = func() time.Time { return now }
+
+	sent, err := relay.ProcessBatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Zero(t, sent)
+	assert.Equal(t, domain.EventStatusFailed, event.Status)
+	assert.Equal(t, 1, event.RetryCount)
+	require.NotNil(t, event.NextAttemptAt)
+	assert.Equal(t, now.Add(time.Second), *event.NextAttemptAt)
+	require.NotNil(t, event.LastError)
+	assert.Equal(t, "broker unavailable", *event.LastError)
+}
+
+func TestOutboxRelay_DeadLettersAfterMaxRetries(t *testing.T) {
+	event := domain.NewOrderEvent(uuid.New(), domain.EventOrderCreated, nil)
+	event.Status = domain.EventStatusFailed
+	event.RetryCount = 2
+	repo := newFakeEventRepository(event)
+	publisher := &failingPublisher{}
+	relay := newTestRelay(repo, publisher)
+
+	_, err := relay.ProcessBatch(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, publisher.calls)
+	assert.Equal(t, domain.EventStatusDeadLetter, event.Status)
+	assert.Equal(t, 3, event.RetryCount)
+
+	health := relay.Health(context.Background())
+	assert.Equal(t, "degraded", health.Status)
+	assert.Equal(t, 1, health.Outbox.DeadLetterCount)
+	assert.Equal(t, int64(1), health.DeadLettered)
+}
+
+func TestOutboxRelay_Backoff(t *testing.T) {
+	relay := newTestRelay(newFakeEventRepository(), NewNoopPublisher(newTestLogger()))
+
+	assert.Equal(t, time.Second, relay.backoff(0))
+	assert.Equal(t, 2*time.Second, relay.backoff(1))
+	assert.Equal(t, 4*time.Second, relay.backoff(2))
+	assert.Equal(t, 4*time.Second, relay.backoff(5))
+}
@@ -20,11 +20,12 @@ func NewAuditRepository(conn *database.Connection) domain.OrderAuditRepository {
 	return &AuditRepository{conn: conn}
 }
 
-// Create creates a new audit log entry
+// Create creates a new audit log entry. An entry about an order that does not exist, such as a
+// denied request for an unknown order ID, is kept without the order.
 func (r *AuditRepository) Create(ctx context.Context, auditLog *domain.OrderAuditLog) error {
 	query := `
 		INSERT INTO order_audit_logs (id, order_id, user_id, action, details, timestamp)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		VALUES ($1, (SELECT id FROM orders WHERE id = $2), $3, $4, $5, $6)
 	`
 
 	// Convert details map to JSON
@@ -33,7 +34,7 @@ func (r *AuditRepository) Create(ctx context.Context, auditLog *domain.OrderAudi
 		return fmt.Errorf("failed to marshal details: %w", err)
 	}
 
-	_, err = r.conn.DB.ExecContext(ctx, query,
+	_, err = r.conn.Executor(ctx).ExecContext(ctx, query,
 		auditLog.ID, auditLog.OrderID, auditLog.UserID, auditLog.Action, detailsJSON, auditLog.Timestamp,
 	)
 
@@ -53,7 +54,7 @@ func (r *AuditRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (
 		ORDER BY timestamp DESC
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, orderID)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs: %w", err)
 	}
@@ -84,7 +85,7 @@ func (r *AuditRepository) GetByUserID(ctx context.Context, userID string) ([]*do
 		ORDER BY timestamp DESC
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, userID)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, userID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs by user ID: %w", err)
 	}
@@ -115,7 +116,7 @@ func (r *AuditRepository) GetByAction(ctx context.Context, action domain.AuditAc
 		ORDER BY timestamp DESC
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, action)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, action)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs by action: %w", err)
 	}
@@ -146,7 +147,7 @@ func (r *AuditRepository) List(ctx context.Context, limit, offset int) ([]*domai
 		LIMIT $1 OFFSET $2
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, limit, offset)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query audit logs: %w", err)
 	}
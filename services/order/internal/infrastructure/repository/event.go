@@ -25,7 +25,7 @@ func NewEventRepository(conn *database.Connection) domain.OrderEventRepository {
 // Create creates a new event in the outbox
 func (r *EventRepository) Create(ctx context.Context, event *domain.OrderEventOutbox) error {
 	query := `
-		INSERT INTO order_events (id, order_id, event_type, payload, status, created_at, sent_at, retry_count)
+		INSERT INTO order_events_outbox (id, order_id, event_type, payload, status, created_at, sent_at, retry_count)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
 	`
 
@@ -35,7 +35,7 @@ func (r *EventRepository) Create(ctx context.Context, event *domain.OrderEventOu
 		return fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	_, err = r.conn.DB.ExecContext(ctx, query,
+	_, err = r.conn.Executor(ctx).ExecContext(ctx, query,
 		event.ID, event.OrderID, event.EventType, payloadJSON, event.Status,
 		event.CreatedAt, event.SentAt, event.RetryCount,
 	)
@@ -47,19 +47,31 @@ func (r *EventRepository) Create(ctx context.Context, event *domain.OrderEventOu
 	return nil
 }
 
-// GetPendingEvents retrieves all pending events for processing
-func (r *EventRepository) GetPendingEvents(ctx context.Context, limit int) ([]*domain.OrderEventOutbox, error) {
+// ClaimPendingEvents claims pending events for publishing until claimUntil. The claim is a next
+// attempt time, so it outlives the transaction: the relay publishes after committing it, and the
+// events are only claimed again by another relay once claimUntil has passed.
+func (r *EventRepository) ClaimPendingEvents(ctx context.Context, limit int, claimUntil time.Time) ([]*domain.OrderEventOutbox, error) {
 	query := `
-		SELECT id, order_id, event_type, payload, status, created_at, sent_at, retry_count
-		FROM order_events
-		WHERE status = $1
-		ORDER BY created_at ASC
-		LIMIT $2
+		WITH claimed AS (
+			UPDATE order_events_outbox
+			SET next_attempt_at = $3
+			WHERE id IN (
+				SELECT id
+				FROM order_events_outbox
+				WHERE status = $1
+				  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+				ORDER BY created_at ASC
+				LIMIT $2
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, order_id, event_type, payload, status, created_at, sent_at, retry_count, next_attempt_at, last_error
+		)
+		SELECT * FROM claimed ORDER BY created_at ASC
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, domain.EventStatusPending, limit)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, domain.EventStatusPending, limit, claimUntil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query pending events: %w", err)
+		return nil, fmt.Errorf("failed to claim pending events: %w", err)
 	}
 	defer rows.Close()
 
@@ -79,19 +91,29 @@ func (r *EventRepository) GetPendingEvents(ctx context.Context, limit int) ([]*d
 	return events, nil
 }
 
-// GetFailedEvents retrieves failed events that can be retried
-func (r *EventRepository) GetFailedEvents(ctx context.Context, maxRetries int, limit int) ([]*domain.OrderEventOutbox, error) {
+// ClaimFailedEvents claims failed events that can be retried, like ClaimPendingEvents
+func (r *EventRepository) ClaimFailedEvents(ctx context.Context, maxRetries int, limit int, claimUntil time.Time) ([]*domain.OrderEventOutbox, error) {
 	query := `
-		SELECT id, order_id, event_type, payload, status, created_at, sent_at, retry_count
-		FROM order_events
-		WHERE status = $1 AND retry_count < $2
-		ORDER BY created_at ASC
-		LIMIT $3
+		WITH claimed AS (
+			UPDATE order_events_outbox
+			SET next_attempt_at = $4
+			WHERE id IN (
+				SELECT id
+				FROM order_events_outbox
+				WHERE status = $1 AND retry_count < $2
+				  AND (next_attempt_at IS NULL OR next_attempt_at <= NOW())
+				ORDER BY created_at ASC
+				LIMIT $3
+				FOR UPDATE SKIP LOCKED
+			)
+			RETURNING id, order_id, event_type, payload, status, created_at, sent_at, retry_count, next_attempt_at, last_error
+		)
+		SELECT * FROM claimed ORDER BY created_at ASC
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, domain.EventStatusFailed, maxRetries, limit)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, domain.EventStatusFailed, maxRetries, limit, claimUntil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query failed events: %w", err)
+		return nil, fmt.Errorf("failed to claim failed events: %w", err)
 	}
 	defer rows.Close()
 
@@ -113,9 +135,9 @@ func (r *EventRepository) GetFailedEvents(ctx context.Context, maxRetries int, l
 
 // UpdateStatus updates the status of an event
 func (r *EventRepository) UpdateStatus(ctx context.Context, eventID uuid.UUID, status domain.EventStatus) error {
-	query := `UPDATE order_events SET status = $1 WHERE id = $2`
+	query := `UPDATE order_events_outbox SET status = $1 WHERE id = $2`
 
-	result, err := r.conn.DB.ExecContext(ctx, query, status, eventID)
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, status, eventID)
 	if err != nil {
 		return fmt.Errorf("failed to update event status: %w", err)
 	}
@@ -134,10 +156,10 @@ func (r *EventRepository) UpdateStatus(ctx context.Context, eventID uuid.UUID, s
 
 // MarkAsSent marks an event as successfully sent
 func (r *EventRepository) MarkAsSent(ctx context.Context, eventID uuid.UUID) error {
-	query := `UPDATE order_events SET status = $1, sent_at = $2 WHERE id = $3`
+	query := `UPDATE order_events_outbox SET status = $1, sent_at = $2 WHERE id = $3`
 
 	now := time.Now()
-	result, err := r.conn.DB.ExecContext(ctx, query, domain.EventStatusSent, now, eventID)
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, domain.EventStatusSent, now, eventID)
 	if err != nil {
 		return fmt.Errorf("failed to mark event as sent: %w", err)
 	}
@@ -154,11 +176,15 @@ func (r *EventRepository) MarkAsSent(ctx context.Context, eventID uuid.UUID) err
 	return nil
 }
 
-// MarkAsFailed marks an event as failed and increments retry count
-func (r *EventRepository) MarkAsFailed(ctx context.Context, eventID uuid.UUID) error {
-	query := `UPDATE order_events SET status = $1, retry_count = retry_count + 1 WHERE id = $2`
+// MarkAsFailed marks an event as failed, increments retry count and schedules the next attempt
+func (r *EventRepository) MarkAsFailed(ctx context.Context, eventID uuid.UUID, nextAttemptAt time.Time, lastError string) error {
+	query := `
+		UPDATE order_events_outbox
+		SET status = $1, retry_count = retry_count + 1, next_attempt_at = $2, last_error = $3
+		WHERE id = $4
+	`
 
-	result, err := r.conn.DB.ExecContext(ctx, query, domain.EventStatusFailed, eventID)
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, domain.EventStatusFailed, nextAttemptAt, lastError, eventID)
 	if err != nil {
 		return fmt.Errorf("failed to mark event as failed: %w", err)
 	}
@@ -175,16 +201,63 @@ func (r *EventRepository) MarkAsFailed(ctx context.Context, eventID uuid.UUID) e
 	return nil
 }
 
+// MarkAsDeadLetter parks an event that exhausted its retries
+func (r *EventRepository) MarkAsDeadLetter(ctx context.Context, eventID uuid.UUID, lastError string) error {
+	query := `
+		UPDATE order_events_outbox
+		SET status = $1, retry_count = retry_count + 1, next_attempt_at = NULL, last_error = $2
+		WHERE id = $3
+	`
+
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, domain.EventStatusDeadLetter, lastError, eventID)
+	if err != nil {
+		return fmt.Errorf("failed to dead-letter event: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrEventNotFound
+	}
+
+	return nil
+}
+
+// GetStats summarizes the outbox backlog
+func (r *EventRepository) GetStats(ctx context.Context) (*domain.OutboxStats, error) {
+	query := `
+		SELECT
+			COUNT(*) FILTER (WHERE status = $1),
+			COUNT(*) FILTER (WHERE status = $2),
+			COUNT(*) FILTER (WHERE status = $3),
+			MIN(created_at) FILTER (WHERE status IN ($1, $2))
+		FROM order_events_outbox
+	`
+
+	var stats domain.OutboxStats
+	err := r.conn.Executor(ctx).QueryRowxContext(ctx, query,
+		domain.EventStatusPending, domain.EventStatusFailed, domain.EventStatusDeadLetter,
+	).Scan(&stats.PendingCount, &stats.FailedCount, &stats.DeadLetterCount, &stats.OldestPendingAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get outbox stats: %w", err)
+	}
+
+	return &stats, nil
+}
+
 // GetByOrderID retrieves all events for an order
 func (r *EventRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.OrderEventOutbox, error) {
 	query := `
-		SELECT id, order_id, event_type, payload, status, created_at, sent_at, retry_count
-		FROM order_events
+		SELECT id, order_id, event_type, payload, status, created_at, sent_at, retry_count, next_attempt_at, last_error
+		FROM order_events_outbox
 		WHERE order_id = $1
 		ORDER BY created_at DESC
 	`
 
-	rows, err := r.conn.DB.QueryContext(ctx, query, orderID)
+	rows, err := r.conn.Executor(ctx).QueryContext(ctx, query, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query events by order ID: %w", err)
 	}
@@ -208,9 +281,9 @@ func (r *EventRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) (
 
 // Delete removes old processed events (for cleanup)
 func (r *EventRepository) Delete(ctx context.Context, eventID uuid.UUID) error {
-	query := `DELETE FROM order_events WHERE id = $1`
+	query := `DELETE FROM order_events_outbox WHERE id = $1`
 
-	result, err := r.conn.DB.ExecContext(ctx, query, eventID)
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, eventID)
 	if err != nil {
 		return fmt.Errorf("failed to delete event: %w", err)
 	}
@@ -242,6 +315,8 @@ func (r *EventRepository) scanEvent(rows *sql.Rows) (*domain.OrderEventOutbox, e
 		&event.CreatedAt,
 		&event.SentAt,
 		&event.RetryCount,
+		&event.NextAttemptAt,
+		&event.LastError,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan event: %w", err)
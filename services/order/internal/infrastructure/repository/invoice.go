@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"order/internal/domain"
+	"order/internal/infrastructure/database"
+)
+
+const invoiceColumns = `
+	i.id, i.order_id, i.return_id, i.invoice_type, i.number, i.sequence, i.branch_code, i.issued_at,
+	i.seller_name, i.seller_tax_id, i.seller_branch_code, i.seller_address,
+	i.buyer_name, i.buyer_tax_id, i.buyer_branch_code, i.buyer_address,
+	i.tax_mode, i.vat_rate, i.subtotal, i.discount, i.taxable_amount, i.exempt_amount, i.vat, i.total,
+	i.reference_invoice_id, ref.number AS reference_number, ref.issued_at AS reference_issued_at,
+	ref.invoice_type AS reference_type, i.original_amount, i.corrected_amount, i.purpose_code,
+	i.reason, i.created_at
+`
+
+// invoiceRow is an invoice as stored, with the seller and buyer flattened into columns
+type invoiceRow struct {
+	ID                 uuid.UUID           `db:"id"`
+	OrderID            uuid.UUID           `db:"order_id"`
+	ReturnID           *uuid.UUID          `db:"return_id"`
+	Type               domain.InvoiceType  `db:"invoice_type"`
+	Number             string              `db:"number"`
+	Sequence           int64               `db:"sequence"`
+	BranchCode         string              `db:"branch_code"`
+	IssuedAt           time.Time           `db:"issued_at"`
+	SellerName         string              `db:"seller_name"`
+	SellerTaxID        string              `db:"seller_tax_id"`
+	SellerBranchCode   string              `db:"seller_branch_code"`
+	SellerAddress      string              `db:"seller_address"`
+	BuyerName          string              `db:"buyer_name"`
+	BuyerTaxID         string              `db:"buyer_tax_id"`
+	BuyerBranchCode    string              `db:"buyer_branch_code"`
+	BuyerAddress       string              `db:"buyer_address"`
+	TaxMode            domain.TaxMode      `db:"tax_mode"`
+	VATRate            float64             `db:"vat_rate"`
+	Subtotal           float64             `db:"subtotal"`
+	Discount           float64             `db:"discount"`
+	TaxableAmount      float64             `db:"taxable_amount"`
+	ExemptAmount       float64             `db:"exempt_amount"`
+	VAT                float64             `db:"vat"`
+	Total              float64             `db:"total"`
+	ReferenceInvoiceID *uuid.UUID          `db:"reference_invoice_id"`
+	ReferenceNumber    *string             `db:"reference_number"`
+	ReferenceIssuedAt  *time.Time          `db:"reference_issued_at"`
+	ReferenceType      *domain.InvoiceType `db:"reference_type"`
+	OriginalAmount     float64             `db:"original_amount"`
+	CorrectedAmount    float64             `db:"corrected_amount"`
+	PurposeCode        string              `db:"purpose_code"`
+	Reason             string              `db:"reason"`
+	CreatedAt          time.Time           `db:"created_at"`
+}
+
+func (row *invoiceRow) toInvoice() *domain.Invoice {
+	return &domain.Invoice{
+		ID:         row.ID,
+		OrderID:    row.OrderID,
+		ReturnID:   row.ReturnID,
+		Type:       row.Type,
+		Number:     row.Number,
+		Sequence:   row.Sequence,
+		BranchCode: row.BranchCode,
+		IssuedAt:   row.IssuedAt,
+		Seller: domain.InvoiceParty{
+			Name:       row.SellerName,
+			TaxID:      row.SellerTaxID,
+			BranchCode: row.SellerBranchCode,
+			Address:    row.SellerAddress,
+		},
+		Buyer: domain.InvoiceParty{
+			Name:       row.BuyerName,
+			TaxID:      row.BuyerTaxID,
+			BranchCode: row.BuyerBranchCode,
+			Address:    row.BuyerAddress,
+		},
+		TaxMode:            row.TaxMode,
+		VATRate:            row.VATRate,
+		Subtotal:           row.Subtotal,
+		Discount:           row.Discount,
+		TaxableAmount:      row.TaxableAmount,
+		ExemptAmount:       row.ExemptAmount,
+		VAT:                row.VAT,
+		Total:              row.Total,
+		ReferenceInvoiceID: row.ReferenceInvoiceID,
+		ReferenceNumber:    row.ReferenceNumber,
+		ReferenceIssuedAt:  row.ReferenceIssuedAt,
+		ReferenceType:      row.ReferenceType,
+		OriginalAmount:     row.OriginalAmount,
+		CorrectedAmount:    row.CorrectedAmount,
+		PurposeCode:        row.PurposeCode,
+		Reason:             row.Reason,
+		CreatedAt:          row.CreatedAt,
+	}
+}
+
+// InvoiceRepository implements the InvoiceRepository interface using PostgreSQL
+type InvoiceRepository struct {
+	conn *database.Connection
+}
+
+// NewInvoiceRepository creates a new PostgreSQL invoice repository
+func NewInvoiceRepository(conn *database.Connection) domain.InvoiceRepository {
+	return &InvoiceRepository{conn: conn}
+}
+
+// Create numbers the invoice and saves it with its lines. The sequence row stays locked until
+// the transaction ends, so invoices of the same branch and type are numbered one after another
+// and a rolled back invoice leaves no gap.
+func (r *InvoiceRepository) Create(ctx context.Context, invoice *domain.Invoice) error {
+	var sequence int64
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), &sequence, `
+		INSERT INTO invoice_sequences (branch_code, invoice_type, year, last_number)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (branch_code, invoice_type, year)
+		DO UPDATE SET last_number = invoice_sequences.last_number + 1
+		RETURNING last_number
+	`, invoice.BranchCode, invoice.Type, invoice.SequenceYear())
+	if err != nil {
+		return fmt.Errorf("failed to take invoice number: %w", err)
+	}
+	invoice.AssignNumber(sequence)
+
+	query := `
+		INSERT INTO invoices (
+			id, order_id, return_id, invoice_type, number, sequence, branch_code, issued_at,
+			seller_name, seller_tax_id, seller_branch_code, seller_address,
+			buyer_name, buyer_tax_id, buyer_branch_code, buyer_address,
+			tax_mode, vat_rate, subtotal, discount, taxable_amount, exempt_amount, vat, total,
+			reference_invoice_id, original_amount, corrected_amount, purpose_code, reason, created_at
+		)
+		VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20,
+			$21, $22, $23, $24, $25, $26, $27, $28, $29, $30
+		)
+	`
+
+	_, err = r.conn.Executor(ctx).ExecContext(ctx, query,
+		invoice.ID, invoice.OrderID, invoice.ReturnID, invoice.Type, invoice.Number, invoice.Sequence,
+		invoice.BranchCode, invoice.IssuedAt,
+		invoice.Seller.Name, invoice.Seller.TaxID, invoice.Seller.BranchCode, invoice.Seller.Address,
+		invoice.Buyer.Name, invoice.Buyer.TaxID, invoice.Buyer.BranchCode, invoice.Buyer.Address,
+		invoice.TaxMode, invoice.VATRate, invoice.Subtotal, invoice.Discount, invoice.TaxableAmount,
+		invoice.ExemptAmount, invoice.VAT, invoice.Total,
+		invoice.ReferenceInvoiceID, invoice.OriginalAmount, invoice.CorrectedAmount,
+		invoice.PurposeCode, invoice.Reason, invoice.CreatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok &&
+			(pqErr.Constraint == "idx_invoices_order_type" || pqErr.Constraint == "idx_invoices_return") {
+			return domain.ErrInvoiceAlreadyIssued
+		}
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	for _, line := range invoice.Lines {
+		_, err := r.conn.Executor(ctx).ExecContext(ctx, `
+			INSERT INTO invoice_lines (invoice_id, line_no, product_id, description, quantity, unit_price, amount, vat_exempt)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`, invoice.ID, line.LineNo, line.ProductID, line.Description, line.Quantity, line.UnitPrice,
+			line.Amount, line.VATExempt,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create invoice line: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetByID retrieves an invoice with its lines
+func (r *InvoiceRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices i
+		LEFT JOIN invoices ref ON ref.id = i.reference_invoice_id
+		WHERE i.id = $1
+	`
+
+	var row invoiceRow
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), &row, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrInvoiceNotFound
+		}
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	invoices := []*domain.Invoice{row.toInvoice()}
+	if err := r.loadLines(ctx, invoices); err != nil {
+		return nil, err
+	}
+	return invoices[0], nil
+}
+
+// GetByOrderID retrieves the invoices and credit notes of an order with their lines, oldest first
+func (r *InvoiceRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.Invoice, error) {
+	query := `
+		SELECT ` + invoiceColumns + `
+		FROM invoices i
+		LEFT JOIN invoices ref ON ref.id = i.reference_invoice_id
+		WHERE i.order_id = $1
+		ORDER BY i.issued_at ASC, i.number ASC
+	`
+
+	var rows []invoiceRow
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &rows, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order invoices: %w", err)
+	}
+
+	invoices := make([]*domain.Invoice, len(rows))
+	for i := range rows {
+		invoices[i] = rows[i].toInvoice()
+	}
+	if err := r.loadLines(ctx, invoices); err != nil {
+		return nil, err
+	}
+	return invoices, nil
+}
+
+// loadLines fills in the lines of the invoices with one query
+func (r *InvoiceRepository) loadLines(ctx context.Context, invoices []*domain.Invoice) error {
+	if len(invoices) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(invoices))
+	byID := make(map[uuid.UUID]*domain.Invoice, len(invoices))
+	for i, invoice := range invoices {
+		ids[i] = invoice.ID
+		byID[invoice.ID] = invoice
+	}
+
+	var lines []struct {
+		InvoiceID uuid.UUID `db:"invoice_id"`
+		domain.InvoiceLine
+	}
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &lines, `
+		SELECT invoice_id, line_no, product_id, description, quantity, unit_price, amount, vat_exempt
+		FROM invoice_lines
+		WHERE invoice_id = ANY($1::uuid[])
+		ORDER BY invoice_id, line_no
+	`, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return fmt.Errorf("failed to get invoice lines: %w", err)
+	}
+
+	for _, line := range lines {
+		invoice := byID[line.InvoiceID]
+		invoice.Lines = append(invoice.Lines, line.InvoiceLine)
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	"order/internal/domain"
 	"order/internal/infrastructure/database"
 )
@@ -26,21 +27,22 @@ func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error
 	query := `
 		INSERT INTO orders (
 			id, customer_id, code, status, source, paid_status, total_amount, 
-			discount, shipping_fee, tax, tax_enabled, shipping_address, billing_address, 
+			discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address, 
 			payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
-			created_at, updated_at
+			created_at, updated_at, revision
 		)
 		VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25
 		)
 	`
 	
-	_, err := r.conn.DB.ExecContext(ctx, query,
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query,
 		order.ID, order.CustomerID, order.Code, order.Status, order.Source, order.PaidStatus,
 		order.TotalAmount, order.Discount, order.ShippingFee, order.Tax, order.TaxEnabled,
+		order.TaxMode, order.VATRate, order.CustomerGroup,
 		order.ShippingAddress, order.BillingAddress, order.PaymentMethod, order.PromoCode,
 		order.Notes, order.ConfirmedAt, order.CancelledAt, order.CancelledReason,
-		order.CreatedAt, order.UpdatedAt,
+		order.CreatedAt, order.UpdatedAt, order.Revision,
 	)
 	
 	if err != nil {
@@ -54,15 +56,15 @@ func (r *OrderRepository) Create(ctx context.Context, order *domain.Order) error
 func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Order, error) {
 	query := `
 		SELECT id, customer_id, code, status, source, paid_status, total_amount, 
-			   discount, shipping_fee, tax, tax_enabled, shipping_address, billing_address, 
+			   discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address, 
 			   payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
-			   created_at, updated_at
+			   created_at, updated_at, revision
 		FROM orders
 		WHERE id = $1
 	`
 	
 	order := &domain.Order{}
-	err := r.conn.DB.GetContext(ctx, order, query, id)
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), order, query, id)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, domain.ErrOrderNotFound
@@ -77,16 +79,16 @@ func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Or
 func (r *OrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID) ([]*domain.Order, error) {
 	query := `
 		SELECT id, customer_id, code, status, source, paid_status, total_amount, 
-			   discount, shipping_fee, tax, tax_enabled, shipping_address, billing_address, 
+			   discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address, 
 			   payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
-			   created_at, updated_at
+			   created_at, updated_at, revision
 		FROM orders
 		WHERE customer_id = $1
 		ORDER BY created_at DESC
 	`
 	
 	var orders []*domain.Order
-	err := r.conn.DB.SelectContext(ctx, &orders, query, customerID)
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &orders, query, customerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders by customer ID: %w", err)
 	}
@@ -101,15 +103,17 @@ func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error
 			customer_id = $2, code = $3, status = $4, source = $5, paid_status = $6,
 			total_amount = $7, discount = $8, shipping_fee = $9, tax = $10, tax_enabled = $11,
 			shipping_address = $12, billing_address = $13, payment_method = $14, promo_code = $15,
-			notes = $16, confirmed_at = $17, cancelled_at = $18, cancelled_reason = $19, updated_at = $20
+			notes = $16, confirmed_at = $17, cancelled_at = $18, cancelled_reason = $19, updated_at = $20,
+			tax_mode = $21, vat_rate = $22
 		WHERE id = $1
 	`
 	
-	result, err := r.conn.DB.ExecContext(ctx, query,
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
 		order.ID, order.CustomerID, order.Code, order.Status, order.Source, order.PaidStatus,
 		order.TotalAmount, order.Discount, order.ShippingFee, order.Tax, order.TaxEnabled,
 		order.ShippingAddress, order.BillingAddress, order.PaymentMethod, order.PromoCode,
 		order.Notes, order.ConfirmedAt, order.CancelledAt, order.CancelledReason, order.UpdatedAt,
+		order.TaxMode, order.VATRate,
 	)
 	
 	if err != nil {
@@ -128,11 +132,55 @@ func (r *OrderRepository) Update(ctx context.Context, order *domain.Order) error
 	return nil
 }
 
+// LockRevision locks an order row for the rest of the transaction, so edits of the order run
+// one at a time, and returns its current revision
+func (r *OrderRepository) LockRevision(ctx context.Context, id uuid.UUID) (int, error) {
+	var revision int
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), &revision, `SELECT revision FROM orders WHERE id = $1 FOR UPDATE`, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return 0, domain.ErrOrderNotFound
+		}
+		return 0, fmt.Errorf("failed to lock order: %w", err)
+	}
+	return revision, nil
+}
+
+// UpdateRevision saves an edited order, failing when another edit moved it past the previous revision
+func (r *OrderRepository) UpdateRevision(ctx context.Context, order *domain.Order, previousRevision int) error {
+	query := `
+		UPDATE orders SET
+			shipping_address = $2, discount = $3, total_amount = $4, revision = $5, updated_at = $6,
+			tax = $8
+		WHERE id = $1 AND revision = $7
+	`
+	
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		order.ID, order.ShippingAddress, order.Discount, order.TotalAmount, order.Revision,
+		order.UpdatedAt, previousRevision, order.Tax,
+	)
+	
+	if err != nil {
+		return fmt.Errorf("failed to update order revision: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return domain.ErrOrderRevisionConflict
+	}
+	
+	return nil
+}
+
 // Delete deletes an order by ID
 func (r *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM orders WHERE id = $1`
 	
-	result, err := r.conn.DB.ExecContext(ctx, query, id)
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete order: %w", err)
 	}
@@ -153,16 +201,16 @@ func (r *OrderRepository) Delete(ctx context.Context, id uuid.UUID) error {
 func (r *OrderRepository) List(ctx context.Context, limit, offset int) ([]*domain.Order, error) {
 	query := `
 		SELECT id, customer_id, code, status, source, paid_status, total_amount, 
-			   discount, shipping_fee, tax, tax_enabled, shipping_address, billing_address, 
+			   discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address, 
 			   payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
-			   created_at, updated_at
+			   created_at, updated_at, revision
 		FROM orders
 		ORDER BY created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 	
 	var orders []*domain.Order
-	err := r.conn.DB.SelectContext(ctx, &orders, query, limit, offset)
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &orders, query, limit, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to list orders: %w", err)
 	}
@@ -174,16 +222,16 @@ func (r *OrderRepository) List(ctx context.Context, limit, offset int) ([]*domai
 func (r *OrderRepository) GetByStatus(ctx context.Context, status domain.OrderStatus) ([]*domain.Order, error) {
 	query := `
 		SELECT id, customer_id, code, status, source, paid_status, total_amount, 
-			   discount, shipping_fee, tax, tax_enabled, shipping_address, billing_address, 
+			   discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address, 
 			   payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
-			   created_at, updated_at
+			   created_at, updated_at, revision
 		FROM orders
 		WHERE status = $1
 		ORDER BY created_at DESC
 	`
 	
 	var orders []*domain.Order
-	err := r.conn.DB.SelectContext(ctx, &orders, query, status)
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &orders, query, status)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders by status: %w", err)
 	}
@@ -195,16 +243,16 @@ func (r *OrderRepository) GetByStatus(ctx context.Context, status domain.OrderSt
 func (r *OrderRepository) GetOrdersByDateRange(ctx context.Context, startDate, endDate time.Time) ([]*domain.Order, error) {
 	query := `
 		SELECT id, customer_id, code, status, source, paid_status, total_amount, 
-			   discount, shipping_fee, tax, tax_enabled, shipping_address, billing_address, 
+			   discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address, 
 			   payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
-			   created_at, updated_at
+			   created_at, updated_at, revision
 		FROM orders
 		WHERE created_at >= $1 AND created_at <= $2
 		ORDER BY created_at DESC
 	`
 	
 	var orders []*domain.Order
-	err := r.conn.DB.SelectContext(ctx, &orders, query, startDate, endDate)
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &orders, query, startDate, endDate)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get orders by date range: %w", err)
 	}
@@ -230,13 +278,19 @@ func NewOrderItemRepository(conn *database.Connection) domain.OrderItemRepositor
 // Create creates a new order item
 func (r *OrderItemRepository) Create(ctx context.Context, item *domain.OrderItem) error {
 	query := `
-		INSERT INTO order_items (id, order_id, product_id, quantity, unit_price, total_price, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		INSERT INTO order_items (
+			id, order_id, product_id, quantity, unit_price, total_price,
+			shipped_quantity, fulfilled_quantity, backordered_quantity, returned_quantity, created_at, updated_at,
+			vat_exempt, price_overridden, price_override_reason, margin_approved_by
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`
 	
-	_, err := r.conn.DB.ExecContext(ctx, query,
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query,
 		item.ID, item.OrderID, item.ProductID, item.Quantity, item.UnitPrice, 
-		item.TotalPrice, item.CreatedAt, item.UpdatedAt,
+		item.TotalPrice, item.ShippedQuantity, item.FulfilledQuantity, item.BackorderedQuantity,
+		item.ReturnedQuantity, item.CreatedAt, item.UpdatedAt, item.VATExempt,
+		item.PriceOverridden, item.PriceOverrideReason, item.MarginApprovedBy,
 	)
 	
 	if err != nil {
@@ -249,14 +303,16 @@ func (r *OrderItemRepository) Create(ctx context.Context, item *domain.OrderItem
 // GetByOrderID retrieves all items for an order
 func (r *OrderItemRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.OrderItem, error) {
 	query := `
-		SELECT id, order_id, product_id, quantity, unit_price, total_price, created_at, updated_at
+		SELECT id, order_id, product_id, quantity, unit_price, total_price,
+			   shipped_quantity, fulfilled_quantity, backordered_quantity, returned_quantity, created_at, updated_at,
+			   vat_exempt, price_overridden, price_override_reason, margin_approved_by
 		FROM order_items
 		WHERE order_id = $1
 		ORDER BY created_at ASC
 	`
 	
 	var items []*domain.OrderItem
-	err := r.conn.DB.SelectContext(ctx, &items, query, orderID)
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &items, query, orderID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get order items: %w", err)
 	}
@@ -272,7 +328,7 @@ func (r *OrderItemRepository) Update(ctx context.Context, item *domain.OrderItem
 		WHERE id = $1
 	`
 	
-	result, err := r.conn.DB.ExecContext(ctx, query,
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
 		item.ID, item.ProductID, item.Quantity, item.UnitPrice, item.TotalPrice, item.UpdatedAt,
 	)
 	
@@ -292,11 +348,92 @@ func (r *OrderItemRepository) Update(ctx context.Context, item *domain.OrderItem
 	return nil
 }
 
+// UpdateFulfilment saves the shipped, fulfilled and backordered quantities of an item if its
+// shipped quantity is still previousShipped, otherwise it returns ErrOrderRevisionConflict
+func (r *OrderItemRepository) UpdateFulfilment(ctx context.Context, item *domain.OrderItem, previousShipped int) error {
+	query := `
+		UPDATE order_items SET
+			shipped_quantity = $2, fulfilled_quantity = $3, backordered_quantity = $4, updated_at = $5
+		WHERE id = $1 AND shipped_quantity = $6
+	`
+	
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		item.ID, item.ShippedQuantity, item.FulfilledQuantity, item.BackorderedQuantity, item.UpdatedAt,
+		previousShipped,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update order item fulfilment: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return domain.ErrOrderRevisionConflict
+	}
+	
+	return nil
+}
+
+// UpdateReturned saves the returned quantity of an item if it is still previousReturned,
+// otherwise it returns ErrOrderRevisionConflict
+func (r *OrderItemRepository) UpdateReturned(ctx context.Context, item *domain.OrderItem, previousReturned int) error {
+	query := `
+		UPDATE order_items SET
+			returned_quantity = $2, updated_at = $3
+		WHERE id = $1 AND returned_quantity = $4
+	`
+	
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		item.ID, item.ReturnedQuantity, item.UpdatedAt, previousReturned,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update order item returned quantity: %w", err)
+	}
+	
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	
+	if rowsAffected == 0 {
+		return domain.ErrOrderRevisionConflict
+	}
+	
+	return nil
+}
+
+// GetBackordered retrieves items waiting for stock on orders that are still being fulfilled,
+// oldest first
+func (r *OrderItemRepository) GetBackordered(ctx context.Context, limit int) ([]*domain.OrderItem, error) {
+	query := `
+		SELECT i.id, i.order_id, i.product_id, i.quantity, i.unit_price, i.total_price,
+			   i.shipped_quantity, i.fulfilled_quantity, i.backordered_quantity, i.returned_quantity, i.created_at, i.updated_at,
+			   i.vat_exempt, i.price_overridden, i.price_override_reason, i.margin_approved_by
+		FROM order_items i
+		JOIN orders o ON o.id = i.order_id
+		WHERE i.backordered_quantity > 0
+		  AND o.status IN ('confirmed', 'processing', 'partially_shipped', 'partially_delivered')
+		ORDER BY i.created_at ASC
+		LIMIT $1
+	`
+	
+	var items []*domain.OrderItem
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &items, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get backordered items: %w", err)
+	}
+	
+	return items, nil
+}
+
 // Delete deletes an order item by ID
 func (r *OrderItemRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM order_items WHERE id = $1`
 	
-	result, err := r.conn.DB.ExecContext(ctx, query, id)
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete order item: %w", err)
 	}
@@ -317,7 +454,7 @@ func (r *OrderItemRepository) Delete(ctx context.Context, id uuid.UUID) error {
 func (r *OrderItemRepository) DeleteByOrderID(ctx context.Context, orderID uuid.UUID) error {
 	query := `DELETE FROM order_items WHERE order_id = $1`
 	
-	_, err := r.conn.DB.ExecContext(ctx, query, orderID)
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query, orderID)
 	if err != nil {
 		return fmt.Errorf("failed to delete order items: %w", err)
 	}
@@ -328,13 +465,15 @@ func (r *OrderItemRepository) DeleteByOrderID(ctx context.Context, orderID uuid.
 // GetAllOrderItems retrieves all order items (for statistics)
 func (r *OrderItemRepository) GetAllOrderItems(ctx context.Context) ([]*domain.OrderItem, error) {
 	query := `
-		SELECT id, order_id, product_id, quantity, unit_price, total_price, created_at, updated_at
+		SELECT id, order_id, product_id, quantity, unit_price, total_price,
+			   shipped_quantity, fulfilled_quantity, backordered_quantity, returned_quantity, created_at, updated_at,
+			   vat_exempt, price_overridden, price_override_reason, margin_approved_by
 		FROM order_items
 		ORDER BY created_at DESC
 	`
 	
 	var items []*domain.OrderItem
-	err := r.conn.DB.SelectContext(ctx, &items, query)
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &items, query)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get all order items: %w", err)
 	}
@@ -0,0 +1,147 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"order/internal/domain"
+)
+
+// orderSortColumns maps the sort fields to their column and the type cursor values are cast to
+var orderSortColumns = map[domain.OrderSortField][2]string{
+	domain.OrderSortCreatedAt:   {"created_at", "timestamptz"},
+	domain.OrderSortUpdatedAt:   {"updated_at", "timestamptz"},
+	domain.OrderSortTotalAmount: {"total_amount", "numeric"},
+}
+
+// Search retrieves a page of orders matching the search. Pages are read with keyset
+// pagination on the sort column and ID, so deep pages cost the same as the first one.
+func (r *OrderRepository) Search(ctx context.Context, search *domain.OrderSearch) ([]*domain.Order, error) {
+	sort, ok := orderSortColumns[search.SortBy]
+	if !ok {
+		return nil, domain.ErrInvalidOrderSearch
+	}
+	column, castType := sort[0], sort[1]
+
+	var conditions []string
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+
+	f := search.Filter
+	if len(f.Statuses) > 0 {
+		conditions = append(conditions, "status = ANY("+arg(pq.Array(stringValues(f.Statuses)))+")")
+	}
+	if len(f.Sources) > 0 {
+		conditions = append(conditions, "source = ANY("+arg(pq.Array(stringValues(f.Sources)))+")")
+	}
+	if len(f.PaidStatuses) > 0 {
+		conditions = append(conditions, "paid_status = ANY("+arg(pq.Array(stringValues(f.PaidStatuses)))+")")
+	}
+	if len(f.PaymentMethods) > 0 {
+		conditions = append(conditions, "payment_method = ANY("+arg(pq.Array(stringValues(f.PaymentMethods)))+")")
+	}
+	if f.CustomerID != nil {
+		conditions = append(conditions, "customer_id = "+arg(*f.CustomerID))
+	}
+	if f.CreatedFrom != nil {
+		conditions = append(conditions, "created_at >= "+arg(*f.CreatedFrom))
+	}
+	if f.CreatedTo != nil {
+		conditions = append(conditions, "created_at < "+arg(*f.CreatedTo))
+	}
+	if f.Text != "" {
+		pattern := arg("%" + escapeLike(f.Text) + "%")
+		conditions = append(conditions, "(code ILIKE "+pattern+" OR notes ILIKE "+pattern+")")
+	}
+	if f.MinAmount != nil {
+		conditions = append(conditions, "total_amount >= "+arg(*f.MinAmount))
+	}
+	if f.MaxAmount != nil {
+		conditions = append(conditions, "total_amount <= "+arg(*f.MaxAmount))
+	}
+
+	direction, comparison := "ASC", ">"
+	if search.Descending {
+		direction, comparison = "DESC", "<"
+	}
+	if search.After != nil {
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s (%s::%s, %s)",
+			column, comparison, arg(search.After.Value), castType, arg(search.After.ID)))
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	query := fmt.Sprintf(`
+		SELECT id, customer_id, code, status, source, paid_status, total_amount,
+			   discount, shipping_fee, tax, tax_enabled, tax_mode, vat_rate, customer_group, shipping_address, billing_address,
+			   payment_method, promo_code, notes, confirmed_at, cancelled_at, cancelled_reason,
+			   created_at, updated_at, revision
+		FROM orders
+		%s
+		ORDER BY %s %s, id %s
+		LIMIT %s
+	`, where, column, direction, direction, arg(search.Limit))
+
+	var orders []*domain.Order
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &orders, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search orders: %w", err)
+	}
+
+	return orders, nil
+}
+
+// GetByOrderIDs retrieves the items of several orders with one query
+func (r *OrderItemRepository) GetByOrderIDs(ctx context.Context, orderIDs []uuid.UUID) ([]*domain.OrderItem, error) {
+	if len(orderIDs) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT id, order_id, product_id, quantity, unit_price, total_price,
+			   shipped_quantity, fulfilled_quantity, backordered_quantity, returned_quantity, created_at, updated_at,
+			   vat_exempt, price_overridden, price_override_reason, margin_approved_by
+		FROM order_items
+		WHERE order_id = ANY($1::uuid[])
+		ORDER BY created_at ASC
+	`
+
+	var items []*domain.OrderItem
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &items, query, pq.Array(uuidStrings(orderIDs)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order items: %w", err)
+	}
+
+	return items, nil
+}
+
+func stringValues[T ~string](values []T) []string {
+	strs := make([]string, len(values))
+	for i, value := range values {
+		strs[i] = string(value)
+	}
+	return strs
+}
+
+func uuidStrings(ids []uuid.UUID) []string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = id.String()
+	}
+	return strs
+}
+
+// escapeLike escapes the LIKE wildcards in s so it matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
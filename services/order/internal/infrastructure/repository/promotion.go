@@ -0,0 +1,286 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"order/internal/domain"
+	"order/internal/infrastructure/database"
+)
+
+const promotionColumns = `
+	id, code, description, reward_type, value, max_discount, min_spend, starts_at, ends_at,
+	usage_limit, per_customer_limit, used_count, min_tier, stackable, is_active, created_at, updated_at
+`
+
+// PromotionRepository implements the PromotionRepository interface using PostgreSQL
+type PromotionRepository struct {
+	conn *database.Connection
+}
+
+// NewPromotionRepository creates a new PostgreSQL promotion repository
+func NewPromotionRepository(conn *database.Connection) domain.PromotionRepository {
+	return &PromotionRepository{conn: conn}
+}
+
+// Create creates a promotion with its product and category scopes. It should run in a
+// transaction so a promotion is never saved without its scopes.
+func (r *PromotionRepository) Create(ctx context.Context, promotion *domain.Promotion) error {
+	query := `
+		INSERT INTO promotions (` + promotionColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+		ON CONFLICT (code) DO NOTHING
+	`
+
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		promotion.ID, promotion.Code, promotion.Description, promotion.RewardType, promotion.Value,
+		promotion.MaxDiscount, promotion.MinSpend, promotion.StartsAt, promotion.EndsAt,
+		promotion.UsageLimit, promotion.PerCustomerLimit, promotion.UsedCount, promotion.MinTier,
+		promotion.Stackable, promotion.IsActive, promotion.CreatedAt, promotion.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrPromotionAlreadyExists
+	}
+
+	for _, productID := range promotion.ProductIDs {
+		_, err := r.conn.Executor(ctx).ExecContext(ctx,
+			`INSERT INTO promotion_products (promotion_id, product_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			promotion.ID, productID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create promotion product: %w", err)
+		}
+	}
+	for _, categoryID := range promotion.CategoryIDs {
+		_, err := r.conn.Executor(ctx).ExecContext(ctx,
+			`INSERT INTO promotion_categories (promotion_id, category_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`,
+			promotion.ID, categoryID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create promotion category: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetByCode retrieves a promotion with its scopes by its normalized code
+func (r *PromotionRepository) GetByCode(ctx context.Context, code string) (*domain.Promotion, error) {
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = $1`
+
+	promotion := &domain.Promotion{}
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), promotion, query, code)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrPromotionNotFound
+		}
+		return nil, fmt.Errorf("failed to get promotion: %w", err)
+	}
+
+	if err := r.loadScopes(ctx, []*domain.Promotion{promotion}); err != nil {
+		return nil, err
+	}
+	return promotion, nil
+}
+
+// GetByCodes retrieves the promotions of several normalized codes; unknown codes are left out
+func (r *PromotionRepository) GetByCodes(ctx context.Context, codes []string) ([]*domain.Promotion, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	query := `SELECT ` + promotionColumns + ` FROM promotions WHERE code = ANY($1)`
+
+	var promotions []*domain.Promotion
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &promotions, query, pq.Array(codes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotions by codes: %w", err)
+	}
+
+	if err := r.loadScopes(ctx, promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// List retrieves promotions with their scopes, newest first
+func (r *PromotionRepository) List(ctx context.Context, activeOnly bool, limit, offset int) ([]*domain.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE NOT $1 OR is_active
+		ORDER BY created_at DESC, id
+		LIMIT $2 OFFSET $3
+	`
+
+	var promotions []*domain.Promotion
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &promotions, query, activeOnly, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list promotions: %w", err)
+	}
+
+	if err := r.loadScopes(ctx, promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// Update saves the description, window, limits and active flag of a promotion. used_count is
+// left alone, it only changes with redemptions.
+func (r *PromotionRepository) Update(ctx context.Context, promotion *domain.Promotion) error {
+	query := `
+		UPDATE promotions
+		SET description = $2, starts_at = $3, ends_at = $4, usage_limit = $5,
+			per_customer_limit = $6, is_active = $7, updated_at = $8
+		WHERE id = $1
+	`
+
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		promotion.ID, promotion.Description, promotion.StartsAt, promotion.EndsAt,
+		promotion.UsageLimit, promotion.PerCustomerLimit, promotion.IsActive, promotion.UpdatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == "chk_promotion_used_count" {
+			// The new usage limit is below the redemptions already made
+			return domain.ErrInvalidPromotion
+		}
+		return fmt.Errorf("failed to update promotion: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrPromotionNotFound
+	}
+
+	return nil
+}
+
+// Redeem records a redemption if the promotion still has uses left overall and for the customer.
+// The conditional increment locks the promotion row until commit, so a concurrent redemption of
+// the same code waits and then counts this one.
+func (r *PromotionRepository) Redeem(ctx context.Context, redemption *domain.PromotionRedemption) error {
+	query := `
+		UPDATE promotions
+		SET used_count = used_count + 1, updated_at = NOW()
+		WHERE id = $1 AND is_active AND (usage_limit IS NULL OR used_count < usage_limit)
+		RETURNING per_customer_limit
+	`
+
+	var perCustomerLimit sql.NullInt64
+	err := r.conn.Executor(ctx).QueryRowxContext(ctx, query, redemption.PromotionID).Scan(&perCustomerLimit)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return domain.ErrPromotionUsageLimitReached
+		}
+		return fmt.Errorf("failed to redeem promotion: %w", err)
+	}
+
+	if perCustomerLimit.Valid {
+		countQuery := `
+			SELECT COUNT(*)
+			FROM promotion_redemptions
+			WHERE promotion_id = $1 AND customer_id = $2 AND released_at IS NULL
+		`
+
+		var used int64
+		err := sqlx.GetContext(ctx, r.conn.Executor(ctx), &used, countQuery, redemption.PromotionID, redemption.CustomerID)
+		if err != nil {
+			return fmt.Errorf("failed to count customer redemptions: %w", err)
+		}
+		if used >= perCustomerLimit.Int64 {
+			return domain.ErrPromotionCustomerLimitReached
+		}
+	}
+
+	insertQuery := `
+		INSERT INTO promotion_redemptions (id, promotion_id, order_id, customer_id, code, amount, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`
+	_, err = r.conn.Executor(ctx).ExecContext(ctx, insertQuery,
+		redemption.ID, redemption.PromotionID, redemption.OrderID, redemption.CustomerID,
+		redemption.Code, redemption.Amount, redemption.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create promotion redemption: %w", err)
+	}
+
+	return nil
+}
+
+// ReleaseByOrderID releases the redemptions of an order and gives their uses back to the
+// promotions. Releasing an order twice is a no-op.
+func (r *PromotionRepository) ReleaseByOrderID(ctx context.Context, orderID uuid.UUID) error {
+	query := `
+		WITH released AS (
+			UPDATE promotion_redemptions
+			SET released_at = NOW()
+			WHERE order_id = $1 AND released_at IS NULL
+			RETURNING promotion_id
+		)
+		UPDATE promotions p
+		SET used_count = p.used_count - r.uses, updated_at = NOW()
+		FROM (SELECT promotion_id, COUNT(*) AS uses FROM released GROUP BY promotion_id) r
+		WHERE p.id = r.promotion_id
+	`
+
+	if _, err := r.conn.Executor(ctx).ExecContext(ctx, query, orderID); err != nil {
+		return fmt.Errorf("failed to release promotion redemptions: %w", err)
+	}
+	return nil
+}
+
+// loadScopes fills in the product and category scopes of the promotions
+func (r *PromotionRepository) loadScopes(ctx context.Context, promotions []*domain.Promotion) error {
+	if len(promotions) == 0 {
+		return nil
+	}
+
+	byID := make(map[uuid.UUID]*domain.Promotion, len(promotions))
+	ids := make([]uuid.UUID, len(promotions))
+	for i, promotion := range promotions {
+		byID[promotion.ID] = promotion
+		ids[i] = promotion.ID
+	}
+
+	var scopes []struct {
+		PromotionID uuid.UUID `db:"promotion_id"`
+		ScopeID     uuid.UUID `db:"scope_id"`
+		IsCategory  bool      `db:"is_category"`
+	}
+	query := `
+		SELECT promotion_id, product_id AS scope_id, FALSE AS is_category
+		FROM promotion_products WHERE promotion_id = ANY($1::uuid[])
+		UNION ALL
+		SELECT promotion_id, category_id, TRUE
+		FROM promotion_categories WHERE promotion_id = ANY($1::uuid[])
+	`
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &scopes, query, pq.Array(uuidStrings(ids)))
+	if err != nil {
+		return fmt.Errorf("failed to get promotion scopes: %w", err)
+	}
+
+	for _, scope := range scopes {
+		promotion := byID[scope.PromotionID]
+		if scope.IsCategory {
+			promotion.CategoryIDs = append(promotion.CategoryIDs, scope.ScopeID)
+		} else {
+			promotion.ProductIDs = append(promotion.ProductIDs, scope.ScopeID)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,303 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"order/internal/domain"
+	"order/internal/infrastructure/database"
+)
+
+const recurringOrderColumns = `
+	id, customer_id, chat_id, source, schedule, shipping_address, billing_address, shipping_fee,
+	notes, status, next_run_at, paused_until, next_override, reminder_sent_for, revision,
+	created_at, updated_at, customer_group
+`
+
+// recurringOrderRow is a recurring order as stored, with the next order override as JSON
+type recurringOrderRow struct {
+	domain.RecurringOrder
+	NextOverride []byte `db:"next_override"`
+}
+
+// RecurringOrderRepository implements the RecurringOrderRepository interface using PostgreSQL
+type RecurringOrderRepository struct {
+	conn *database.Connection
+}
+
+// NewRecurringOrderRepository creates a new PostgreSQL recurring order repository
+func NewRecurringOrderRepository(conn *database.Connection) domain.RecurringOrderRepository {
+	return &RecurringOrderRepository{conn: conn}
+}
+
+// Create creates a recurring order with its items
+func (r *RecurringOrderRepository) Create(ctx context.Context, order *domain.RecurringOrder) error {
+	override, err := marshalOverride(order.NextOverride)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO recurring_orders (` + recurringOrderColumns + `)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+	`
+
+	_, err = r.conn.Executor(ctx).ExecContext(ctx, query,
+		order.ID, order.CustomerID, order.ChatID, order.Source, order.Schedule, order.ShippingAddress,
+		order.BillingAddress, order.ShippingFee, order.Notes, order.Status, order.NextRunAt,
+		order.PausedUntil, override, order.ReminderSentFor, order.Revision, order.CreatedAt,
+		order.UpdatedAt, order.CustomerGroup,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create recurring order: %w", err)
+	}
+
+	itemQuery := `
+		INSERT INTO recurring_order_items (recurring_order_id, line_no, product_id, quantity, unit_price)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for i, item := range order.Items {
+		_, err := r.conn.Executor(ctx).ExecContext(ctx, itemQuery,
+			order.ID, i+1, item.ProductID, item.Quantity, item.UnitPrice,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create recurring order item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetByID retrieves a recurring order with its items
+func (r *RecurringOrderRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.RecurringOrder, error) {
+	query := `SELECT ` + recurringOrderColumns + ` FROM recurring_orders WHERE id = $1`
+
+	row := &recurringOrderRow{}
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), row, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrRecurringOrderNotFound
+		}
+		return nil, fmt.Errorf("failed to get recurring order: %w", err)
+	}
+
+	orders, err := r.load(ctx, []*recurringOrderRow{row})
+	if err != nil {
+		return nil, err
+	}
+	return orders[0], nil
+}
+
+// GetByCustomerID retrieves the recurring orders of a customer with their items, oldest first
+func (r *RecurringOrderRepository) GetByCustomerID(ctx context.Context, customerID uuid.UUID) ([]*domain.RecurringOrder, error) {
+	query := `
+		SELECT ` + recurringOrderColumns + `
+		FROM recurring_orders
+		WHERE customer_id = $1
+		ORDER BY created_at ASC
+	`
+	return r.selectOrders(ctx, query, customerID)
+}
+
+// Update saves the status, schedule position, next order override and reminder of a recurring
+// order. A recurring order that moved past previousRevision returns ErrRecurringOrderConflict,
+// so the scheduler and the customer never overwrite each other.
+func (r *RecurringOrderRepository) Update(ctx context.Context, order *domain.RecurringOrder, previousRevision int) error {
+	override, err := marshalOverride(order.NextOverride)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE recurring_orders SET
+			status = $2, next_run_at = $3, paused_until = $4, next_override = $5,
+			reminder_sent_for = $6, revision = $7, updated_at = $8
+		WHERE id = $1 AND revision = $9
+	`
+
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		order.ID, order.Status, order.NextRunAt, order.PausedUntil, override, order.ReminderSentFor,
+		order.Revision, order.UpdatedAt, previousRevision,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update recurring order: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrRecurringOrderConflict
+	}
+
+	return nil
+}
+
+// GetDue retrieves active recurring orders whose next order is due by now and paused ones whose
+// pause has ended, earliest first
+func (r *RecurringOrderRepository) GetDue(ctx context.Context, now time.Time, limit int) ([]*domain.RecurringOrder, error) {
+	query := `
+		SELECT ` + recurringOrderColumns + `
+		FROM recurring_orders
+		WHERE (status = 'active' AND next_run_at <= $1)
+		   OR (status = 'paused' AND paused_until <= $1)
+		ORDER BY next_run_at ASC
+		LIMIT $2
+	`
+	return r.selectOrders(ctx, query, now, limit)
+}
+
+// GetUnreminded retrieves active recurring orders with a chat whose next order is due after now
+// and by before and whose customer was not reminded of it yet, earliest first
+func (r *RecurringOrderRepository) GetUnreminded(ctx context.Context, now, before time.Time, limit int) ([]*domain.RecurringOrder, error) {
+	query := `
+		SELECT ` + recurringOrderColumns + `
+		FROM recurring_orders
+		WHERE status = 'active' AND chat_id <> ''
+		  AND next_run_at > $1 AND next_run_at <= $2
+		  AND reminder_sent_for IS DISTINCT FROM next_run_at
+		ORDER BY next_run_at ASC
+		LIMIT $3
+	`
+	return r.selectOrders(ctx, query, now, before, limit)
+}
+
+// CreateRun records an occurrence. A second run for the same occurrence returns
+// ErrRecurringOrderRunExists.
+func (r *RecurringOrderRepository) CreateRun(ctx context.Context, run *domain.RecurringOrderRun) error {
+	query := `
+		INSERT INTO recurring_order_runs (
+			id, recurring_order_id, scheduled_for, status, order_id, error, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		run.ID, run.RecurringOrderID, run.ScheduledFor, run.Status, run.OrderID, run.Error,
+		run.CreatedAt, run.UpdatedAt,
+	)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Constraint == "uq_recurring_order_run" {
+			return domain.ErrRecurringOrderRunExists
+		}
+		return fmt.Errorf("failed to create recurring order run: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRun saves the outcome of a run
+func (r *RecurringOrderRepository) UpdateRun(ctx context.Context, run *domain.RecurringOrderRun) error {
+	query := `
+		UPDATE recurring_order_runs SET status = $2, order_id = $3, error = $4, updated_at = $5
+		WHERE id = $1
+	`
+
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		run.ID, run.Status, run.OrderID, run.Error, run.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update recurring order run: %w", err)
+	}
+
+	return nil
+}
+
+// GetRuns retrieves the latest runs of a recurring order, newest first
+func (r *RecurringOrderRepository) GetRuns(ctx context.Context, recurringOrderID uuid.UUID, limit int) ([]*domain.RecurringOrderRun, error) {
+	query := `
+		SELECT id, recurring_order_id, scheduled_for, status, order_id, error, created_at, updated_at
+		FROM recurring_order_runs
+		WHERE recurring_order_id = $1
+		ORDER BY scheduled_for DESC
+		LIMIT $2
+	`
+
+	var runs []*domain.RecurringOrderRun
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &runs, query, recurringOrderID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring order runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+func (r *RecurringOrderRepository) selectOrders(ctx context.Context, query string, args ...interface{}) ([]*domain.RecurringOrder, error) {
+	var rows []*recurringOrderRow
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &rows, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring orders: %w", err)
+	}
+	return r.load(ctx, rows)
+}
+
+// load decodes the overrides of the rows and fills in their items with one query
+func (r *RecurringOrderRepository) load(ctx context.Context, rows []*recurringOrderRow) ([]*domain.RecurringOrder, error) {
+	orders := make([]*domain.RecurringOrder, len(rows))
+	if len(rows) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]uuid.UUID, len(rows))
+	byID := make(map[uuid.UUID]*domain.RecurringOrder, len(rows))
+	for i, row := range rows {
+		order := &row.RecurringOrder
+		if len(row.NextOverride) > 0 {
+			order.NextOverride = &domain.RecurringOrderOverride{}
+			if err := json.Unmarshal(row.NextOverride, order.NextOverride); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal next order override: %w", err)
+			}
+		}
+		orders[i] = order
+		ids[i] = order.ID
+		byID[order.ID] = order
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT recurring_order_id, product_id, quantity, unit_price
+		FROM recurring_order_items
+		WHERE recurring_order_id IN (?)
+		ORDER BY recurring_order_id, line_no
+	`, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build recurring order items query: %w", err)
+	}
+
+	executor := r.conn.Executor(ctx)
+	var items []struct {
+		RecurringOrderID uuid.UUID `db:"recurring_order_id"`
+		domain.RecurringOrderItem
+	}
+	err = sqlx.SelectContext(ctx, executor, &items, executor.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring order items: %w", err)
+	}
+
+	for _, item := range items {
+		order := byID[item.RecurringOrderID]
+		order.Items = append(order.Items, item.RecurringOrderItem)
+	}
+
+	return orders, nil
+}
+
+// marshalOverride encodes the next order override as JSON, or NULL when there is none
+func marshalOverride(override *domain.RecurringOrderOverride) (*string, error) {
+	if override == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(override)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal next order override: %w", err)
+	}
+	encoded := string(data)
+	return &encoded, nil
+}
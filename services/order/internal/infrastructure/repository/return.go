@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"order/internal/domain"
+	"order/internal/infrastructure/database"
+)
+
+// ReturnRepository implements the ReturnRepository interface using PostgreSQL
+type ReturnRepository struct {
+	conn *database.Connection
+}
+
+// NewReturnRepository creates a new PostgreSQL return repository
+func NewReturnRepository(conn *database.Connection) domain.ReturnRepository {
+	return &ReturnRepository{conn: conn}
+}
+
+// Create creates a return request with its items
+func (r *ReturnRepository) Create(ctx context.Context, request *domain.ReturnRequest) error {
+	query := `
+		INSERT INTO order_returns (
+			id, order_id, customer_id, status, notes, refund_amount, pickup_delivery_id,
+			pickup_tracking_number, rejection_reason, payment_transaction_id, points_clawed_back,
+			approved_at, received_at, refunded_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`
+
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		request.ID, request.OrderID, request.CustomerID, request.Status, request.Notes, request.RefundAmount,
+		request.PickupDeliveryID, request.PickupTrackingNumber, request.RejectionReason,
+		request.PaymentTransactionID, request.PointsClawedBack, request.ApprovedAt, request.ReceivedAt,
+		request.RefundedAt, request.CreatedAt, request.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create return request: %w", err)
+	}
+
+	itemQuery := `
+		INSERT INTO order_return_items (
+			id, return_id, order_item_id, product_id, quantity, reason, refund_amount, restock
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`
+	for _, item := range request.Items {
+		_, err := r.conn.Executor(ctx).ExecContext(ctx, itemQuery,
+			item.ID, item.ReturnID, item.OrderItemID, item.ProductID, item.Quantity, item.Reason,
+			item.RefundAmount, item.Restock,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create return item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetByID retrieves a return request with its items
+func (r *ReturnRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.ReturnRequest, error) {
+	query := `
+		SELECT id, order_id, customer_id, status, notes, refund_amount, pickup_delivery_id,
+			   pickup_tracking_number, rejection_reason, payment_transaction_id, points_clawed_back,
+			   approved_at, received_at, refunded_at, created_at, updated_at
+		FROM order_returns
+		WHERE id = $1
+	`
+
+	request := &domain.ReturnRequest{}
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), request, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrReturnNotFound
+		}
+		return nil, fmt.Errorf("failed to get return request: %w", err)
+	}
+
+	if err := r.loadItems(ctx, []*domain.ReturnRequest{request}); err != nil {
+		return nil, err
+	}
+
+	return request, nil
+}
+
+// GetByOrderID retrieves all return requests of an order with their items, oldest first
+func (r *ReturnRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.ReturnRequest, error) {
+	query := `
+		SELECT id, order_id, customer_id, status, notes, refund_amount, pickup_delivery_id,
+			   pickup_tracking_number, rejection_reason, payment_transaction_id, points_clawed_back,
+			   approved_at, received_at, refunded_at, created_at, updated_at
+		FROM order_returns
+		WHERE order_id = $1
+		ORDER BY created_at ASC
+	`
+
+	var requests []*domain.ReturnRequest
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &requests, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get return requests: %w", err)
+	}
+
+	if err := r.loadItems(ctx, requests); err != nil {
+		return nil, err
+	}
+
+	return requests, nil
+}
+
+// Update saves a return request and the restock decisions of its items. A request whose status
+// is no longer previousStatus returns ErrInvalidReturnStatus, so a step is never applied twice.
+func (r *ReturnRepository) Update(ctx context.Context, request *domain.ReturnRequest, previousStatus domain.ReturnStatus) error {
+	query := `
+		UPDATE order_returns SET
+			status = $2, pickup_delivery_id = $3, pickup_tracking_number = $4, rejection_reason = $5,
+			payment_transaction_id = $6, points_clawed_back = $7, approved_at = $8, received_at = $9,
+			refunded_at = $10, updated_at = $11
+		WHERE id = $1 AND status = $12
+	`
+
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		request.ID, request.Status, request.PickupDeliveryID, request.PickupTrackingNumber,
+		request.RejectionReason, request.PaymentTransactionID, request.PointsClawedBack,
+		request.ApprovedAt, request.ReceivedAt, request.RefundedAt, request.UpdatedAt, previousStatus,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update return request: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrInvalidReturnStatus
+	}
+
+	itemQuery := `UPDATE order_return_items SET restock = $2 WHERE id = $1`
+	for _, item := range request.Items {
+		if _, err := r.conn.Executor(ctx).ExecContext(ctx, itemQuery, item.ID, item.Restock); err != nil {
+			return fmt.Errorf("failed to update return item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// loadItems fills in the items of the return requests with one query
+func (r *ReturnRepository) loadItems(ctx context.Context, requests []*domain.ReturnRequest) error {
+	if len(requests) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(requests))
+	byID := make(map[uuid.UUID]*domain.ReturnRequest, len(requests))
+	for i, request := range requests {
+		ids[i] = request.ID
+		byID[request.ID] = request
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, return_id, order_item_id, product_id, quantity, reason, refund_amount, restock
+		FROM order_return_items
+		WHERE return_id IN (?)
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to build return items query: %w", err)
+	}
+
+	executor := r.conn.Executor(ctx)
+	var items []domain.ReturnItem
+	err = sqlx.SelectContext(ctx, executor, &items, executor.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to get return items: %w", err)
+	}
+
+	for _, item := range items {
+		request := byID[item.ReturnID]
+		request.Items = append(request.Items, item)
+	}
+
+	return nil
+}
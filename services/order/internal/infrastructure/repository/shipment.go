@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"order/internal/domain"
+	"order/internal/infrastructure/database"
+)
+
+// ShipmentRepository implements the ShipmentRepository interface using PostgreSQL
+type ShipmentRepository struct {
+	conn *database.Connection
+}
+
+// NewShipmentRepository creates a new PostgreSQL shipment repository
+func NewShipmentRepository(conn *database.Connection) domain.ShipmentRepository {
+	return &ShipmentRepository{conn: conn}
+}
+
+// Create creates a shipment with its items
+func (r *ShipmentRepository) Create(ctx context.Context, shipment *domain.Shipment) error {
+	query := `
+		INSERT INTO order_shipments (
+			id, order_id, delivery_id, tracking_number, carrier, status,
+			shipped_at, delivered_at, created_at, updated_at
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+
+	_, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		shipment.ID, shipment.OrderID, shipment.DeliveryID, shipment.TrackingNumber, shipment.Carrier,
+		shipment.Status, shipment.ShippedAt, shipment.DeliveredAt, shipment.CreatedAt, shipment.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create shipment: %w", err)
+	}
+
+	itemQuery := `
+		INSERT INTO order_shipment_items (id, shipment_id, order_item_id, product_id, quantity)
+		VALUES ($1, $2, $3, $4, $5)
+	`
+	for _, item := range shipment.Items {
+		_, err := r.conn.Executor(ctx).ExecContext(ctx, itemQuery,
+			item.ID, item.ShipmentID, item.OrderItemID, item.ProductID, item.Quantity,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to create shipment item: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetByID retrieves a shipment with its items
+func (r *ShipmentRepository) GetByID(ctx context.Context, id uuid.UUID) (*domain.Shipment, error) {
+	query := `
+		SELECT id, order_id, delivery_id, tracking_number, carrier, status,
+			   shipped_at, delivered_at, created_at, updated_at
+		FROM order_shipments
+		WHERE id = $1
+	`
+
+	shipment := &domain.Shipment{}
+	err := sqlx.GetContext(ctx, r.conn.Executor(ctx), shipment, query, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.ErrShipmentNotFound
+		}
+		return nil, fmt.Errorf("failed to get shipment: %w", err)
+	}
+
+	if err := r.loadItems(ctx, []*domain.Shipment{shipment}); err != nil {
+		return nil, err
+	}
+
+	return shipment, nil
+}
+
+// GetByOrderID retrieves all shipments of an order with their items, oldest first
+func (r *ShipmentRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*domain.Shipment, error) {
+	query := `
+		SELECT id, order_id, delivery_id, tracking_number, carrier, status,
+			   shipped_at, delivered_at, created_at, updated_at
+		FROM order_shipments
+		WHERE order_id = $1
+		ORDER BY shipped_at ASC
+	`
+
+	var shipments []*domain.Shipment
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &shipments, query, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shipments: %w", err)
+	}
+
+	if err := r.loadItems(ctx, shipments); err != nil {
+		return nil, err
+	}
+
+	return shipments, nil
+}
+
+// MarkDelivered saves the delivery of an in transit shipment. A shipment that is no longer in
+// transit returns ErrShipmentNotInTransit, so a delivery is never counted twice.
+func (r *ShipmentRepository) MarkDelivered(ctx context.Context, shipment *domain.Shipment) error {
+	query := `
+		UPDATE order_shipments SET
+			status = $2, delivered_at = $3, updated_at = $4
+		WHERE id = $1 AND status = $5
+	`
+
+	result, err := r.conn.Executor(ctx).ExecContext(ctx, query,
+		shipment.ID, shipment.Status, shipment.DeliveredAt, shipment.UpdatedAt, domain.ShipmentStatusInTransit,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to mark shipment delivered: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrShipmentNotInTransit
+	}
+
+	return nil
+}
+
+// loadItems fills in the items of the shipments with one query
+func (r *ShipmentRepository) loadItems(ctx context.Context, shipments []*domain.Shipment) error {
+	if len(shipments) == 0 {
+		return nil
+	}
+
+	ids := make([]uuid.UUID, len(shipments))
+	byID := make(map[uuid.UUID]*domain.Shipment, len(shipments))
+	for i, shipment := range shipments {
+		ids[i] = shipment.ID
+		byID[shipment.ID] = shipment
+	}
+
+	query, args, err := sqlx.In(`
+		SELECT id, shipment_id, order_item_id, product_id, quantity
+		FROM order_shipment_items
+		WHERE shipment_id IN (?)
+	`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to build shipment items query: %w", err)
+	}
+
+	executor := r.conn.Executor(ctx)
+	var items []domain.ShipmentItem
+	err = sqlx.SelectContext(ctx, executor, &items, executor.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to get shipment items: %w", err)
+	}
+
+	for _, item := range items {
+		shipment := byID[item.ShipmentID]
+		shipment.Items = append(shipment.Items, item)
+	}
+
+	return nil
+}
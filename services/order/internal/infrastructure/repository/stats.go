@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"order/internal/domain"
+	"order/internal/infrastructure/database"
+)
+
+// productStatsOrderBy maps the supported sort keys of the top products to their column
+var productStatsOrderBy = map[string]string{
+	"order_count": "order_count",
+	"revenue":     "revenue",
+	"quantity":    "quantity",
+}
+
+// statsLocation is Thai time, which the rollup days and hours follow
+var statsLocation = time.FixedZone("ICT", 7*60*60)
+
+// OrderStatsRepository implements the OrderStatsRepository interface using PostgreSQL
+type OrderStatsRepository struct {
+	conn *database.Connection
+}
+
+// NewOrderStatsRepository creates a new PostgreSQL order statistics repository
+func NewOrderStatsRepository(conn *database.Connection) domain.OrderStatsRepository {
+	return &OrderStatsRepository{conn: conn}
+}
+
+// GetOrderStats retrieves the order rollup rows of the days from start up to, not including, end
+func (r *OrderStatsRepository) GetOrderStats(ctx context.Context, start, end time.Time) ([]*domain.OrderStatsRow, error) {
+	query := `
+		SELECT stat_date, hour, source, status, payment_method, orders_count, revenue, discount
+		FROM order_stats_daily
+		WHERE stat_date >= $1::date AND stat_date < $2::date AND orders_count <> 0
+		ORDER BY stat_date, hour
+	`
+
+	var rows []*domain.OrderStatsRow
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &rows, query, statsDate(start), statsDate(end))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get order stats: %w", err)
+	}
+
+	return rows, nil
+}
+
+// GetTopProducts sums the product rollup of the days from start up to, not including, end
+func (r *OrderStatsRepository) GetTopProducts(ctx context.Context, start, end *time.Time, sortBy string, limit int) ([]*domain.ProductStatsRow, error) {
+	orderBy, ok := productStatsOrderBy[sortBy]
+	if !ok {
+		orderBy = productStatsOrderBy["order_count"]
+	}
+
+	var startDate, endDate *string
+	if start != nil {
+		date := statsDate(*start)
+		startDate = &date
+	}
+	if end != nil {
+		date := statsDate(*end)
+		endDate = &date
+	}
+
+	query := fmt.Sprintf(`
+		SELECT product_id, SUM(order_count) AS order_count, SUM(quantity) AS quantity,
+			   SUM(revenue) AS revenue, MAX(last_order_at) AS last_order_at
+		FROM order_product_stats_daily
+		WHERE ($1::date IS NULL OR stat_date >= $1::date)
+		  AND ($2::date IS NULL OR stat_date < $2::date)
+		GROUP BY product_id
+		HAVING SUM(order_count) > 0
+		ORDER BY %s DESC, product_id
+		LIMIT $3
+	`, orderBy)
+
+	var rows []*domain.ProductStatsRow
+	err := sqlx.SelectContext(ctx, r.conn.Executor(ctx), &rows, query, startDate, endDate, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top products: %w", err)
+	}
+
+	return rows, nil
+}
+
+// RebuildDay recalculates the rollups of a day from the orders. The rollup tables are locked
+// for the rest of the transaction so triggers of concurrent writes wait and apply on top.
+func (r *OrderStatsRepository) RebuildDay(ctx context.Context, day time.Time) error {
+	executor := r.conn.Executor(ctx)
+	date := statsDate(day)
+
+	if _, err := executor.ExecContext(ctx, `LOCK TABLE order_stats_daily, order_product_stats_daily IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return fmt.Errorf("failed to lock order stats: %w", err)
+	}
+
+	if _, err := executor.ExecContext(ctx, `DELETE FROM order_stats_daily WHERE stat_date = $1::date`, date); err != nil {
+		return fmt.Errorf("failed to clear order stats: %w", err)
+	}
+	if _, err := executor.ExecContext(ctx, `DELETE FROM order_product_stats_daily WHERE stat_date = $1::date`, date); err != nil {
+		return fmt.Errorf("failed to clear product stats: %w", err)
+	}
+
+	orderQuery := `
+		INSERT INTO order_stats_daily (stat_date, hour, source, status, payment_method, orders_count, revenue, discount)
+		SELECT $1::date, EXTRACT(HOUR FROM created_at AT TIME ZONE 'Asia/Bangkok'), COALESCE(source, 'online'), status,
+			   COALESCE(payment_method, ''), COUNT(*), SUM(total_amount), SUM(COALESCE(discount, 0))
+		FROM orders
+		WHERE created_at >= ($1::date::timestamp AT TIME ZONE 'Asia/Bangkok') AND created_at < (($1::date + 1)::timestamp AT TIME ZONE 'Asia/Bangkok')
+		GROUP BY 2, 3, 4, 5
+	`
+	if _, err := executor.ExecContext(ctx, orderQuery, date); err != nil {
+		return fmt.Errorf("failed to rebuild order stats: %w", err)
+	}
+
+	productQuery := `
+		INSERT INTO order_product_stats_daily (stat_date, product_id, source, status, order_count, quantity, revenue, last_order_at)
+		SELECT $1::date, oi.product_id, COALESCE(o.source, 'online'), o.status,
+			   COUNT(DISTINCT o.id), SUM(oi.quantity), SUM(oi.total_price), MAX(o.created_at)
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE o.created_at >= ($1::date::timestamp AT TIME ZONE 'Asia/Bangkok') AND o.created_at < (($1::date + 1)::timestamp AT TIME ZONE 'Asia/Bangkok')
+		GROUP BY 2, 3, 4
+	`
+	if _, err := executor.ExecContext(ctx, productQuery, date); err != nil {
+		return fmt.Errorf("failed to rebuild product stats: %w", err)
+	}
+
+	return nil
+}
+
+// statsDate formats the calendar day of t the way the rollups key it. Days given as midnight
+// UTC keep their date.
+func statsDate(t time.Time) string {
+	return t.In(statsLocation).Format("2006-01-02")
+}
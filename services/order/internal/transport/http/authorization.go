@@ -0,0 +1,88 @@
+package http
+
+import (
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/domain"
+	"order/internal/transport/http/middleware"
+)
+
+// Permissions checked on the order routes; the default permissions of each role are in
+// middleware.GetRolePermissions
+const (
+	permissionCreateOrders    = "orders:create"
+	permissionViewOrders      = "orders:view"
+	permissionUpdateOrders    = "orders:update"
+	permissionConfirmOrders   = "orders:confirm"
+	permissionCancelConfirmed = "orders:cancel"
+	// Setting an item price instead of the priced one, and approving prices below cost or the
+	// minimum margin of a product
+	permissionOverridePrice      = "orders:override_price"
+	permissionApproveBelowMargin = "orders:approve_below_margin"
+)
+
+// statusPermissions are the permissions needed to move an order to a status on top of updating it
+var statusPermissions = map[domain.OrderStatus]string{
+	domain.OrderStatusConfirmed: permissionConfirmOrders,
+	domain.OrderStatusCancelled: permissionCancelConfirmed,
+}
+
+// authorize checks a permission that depends on the request rather than the route. A request
+// without it is turned away and written to the audit log.
+func (h *Handler) authorize(c *gin.Context, permission string) bool {
+	user, _ := middleware.CurrentUser(c)
+	if middleware.HasPermission(user, permission) {
+		return true
+	}
+	fields := logrus.Fields{"required_permission": permission, "endpoint": c.FullPath()}
+	if user != nil {
+		fields["user_id"] = user.ID
+	}
+	h.logger.WithFields(fields).Warn("Insufficient permissions")
+	middleware.Forbid(c, h.auth, user, permission)
+	return false
+}
+
+// marginApprover is the user approving items of the request sold below cost or the minimum
+// margin, or nil when the user may not approve them
+func marginApprover(c *gin.Context) *string {
+	user, _ := middleware.CurrentUser(c)
+	if !middleware.HasPermission(user, permissionApproveBelowMargin) {
+		return nil
+	}
+	return &user.ID
+}
+
+// recordAccessDenied writes a request of an authenticated user the auth middleware turned away
+// to the order audit log. Requests on an order's routes are kept with the order. Missing and
+// invalid tokens are only logged by the middleware, so anonymous traffic cannot fill the log.
+func (h *Handler) recordAccessDenied(c *gin.Context, denial *middleware.Denial) {
+	if denial.User == nil {
+		return
+	}
+
+	details := map[string]interface{}{
+		"method":    c.Request.Method,
+		"route":     c.FullPath(),
+		"path":      c.Request.URL.Path,
+		"reason":    denial.Reason,
+		"client_ip": c.ClientIP(),
+	}
+	if len(denial.Required) > 0 {
+		details["required"] = denial.Required
+	}
+
+	details["role"] = string(denial.User.Role)
+
+	orderID := uuid.Nil
+	if strings.HasPrefix(c.FullPath(), "/api/v1/orders/:id") {
+		if id, err := uuid.Parse(c.Param("id")); err == nil {
+			orderID = id
+		}
+	}
+
+	h.service.RecordAccessDenied(c.Request.Context(), orderID, &denial.User.ID, details)
+}
@@ -1,8 +1,8 @@
 package http
 
 import (
+	"errors"
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -10,20 +10,23 @@ import (
 	"order/internal/application"
 	"order/internal/application/dto"
 	"order/internal/domain"
+	"order/internal/transport/http/middleware"
 	"github.com/sirupsen/logrus"
 )
 
 // Handler handles HTTP requests for orders using the new service
 type Handler struct {
 	service   *application.Service
+	auth      *middleware.AuthConfig
 	validator *validator.Validate
 	logger    *logrus.Logger
 }
 
 // NewHandler creates a new order handler
-func NewHandler(service *application.Service, logger *logrus.Logger) *Handler {
+func NewHandler(service *application.Service, auth *middleware.AuthConfig, logger *logrus.Logger) *Handler {
 	return &Handler{
 		service:   service,
+		auth:      auth,
 		validator: validator.New(),
 		logger:    logger,
 	}
@@ -44,13 +47,59 @@ func (h *Handler) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// A customer group prices the order at that group's prices, so it is an override as well
+	if (dto.HasPriceOverride(req.Items) || req.CustomerGroup != "") && !h.authorize(c, permissionOverridePrice) {
+		return
+	}
+	req.MarginApprovedBy = marginApprover(c)
+
 	order, err := h.service.CreateOrder(c.Request.Context(), &req)
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to create order")
-		if err == domain.ErrInvalidOrderData {
+		if err == domain.ErrInvalidOrderData || err == domain.ErrInvalidOrderSource {
 			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 			return
 		}
+		if errors.Is(err, domain.ErrInsufficientStock) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrLaunchAllocationExhausted) {
+			c.JSON(http.StatusConflict, gin.H{"error": "Early access allocation of the customer's VIP level is exhausted", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrVIPAccessRequired) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Product is only sold to higher VIP levels", "details": err.Error()})
+			return
+		}
+		if isPromotionError(err) {
+			h.respondPromotionError(c, err, "Failed to create order")
+			return
+		}
+		if err == domain.ErrInvalidTaxSettings {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrProductLookupFailed) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "VAT exemptions could not be checked, try again"})
+			return
+		}
+		if errors.Is(err, domain.ErrProductUnavailable) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is unknown or not for sale", "details": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrPricingFailed) {
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Items could not be priced, try again"})
+			return
+		}
+		if err == domain.ErrPriceOverrideReasonRequired {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if errors.Is(err, domain.ErrMarginApprovalRequired) {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Items below cost or the minimum margin need a manager's approval", "details": err.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
 		return
 	}
@@ -83,40 +132,6 @@ func (h *Handler) GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, order)
 }
 
-// ListOrders handles GET /orders
-func (h *Handler) ListOrders(c *gin.Context) {
-	limitStr := c.DefaultQuery("limit", "20")
-	offsetStr := c.DefaultQuery("offset", "0")
-
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 || limit > 100 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
-		return
-	}
-
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset parameter"})
-		return
-	}
-
-	orders, err := h.service.ListOrders(c.Request.Context(), limit, offset)
-	if err != nil {
-		h.logger.WithError(err).Error("Failed to list orders")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orders"})
-		return
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"orders": orders,
-		"pagination": gin.H{
-			"limit":  limit,
-			"offset": offset,
-			"count":  len(orders),
-		},
-	})
-}
-
 // GetOrdersByCustomer handles GET /customers/:customer_id/orders
 func (h *Handler) GetOrdersByCustomer(c *gin.Context) {
 	customerIDStr := c.Param("customer_id")
@@ -189,6 +204,11 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
+	// Confirming or cancelling through a status change needs the manager permission for it
+	if permission, ok := statusPermissions[status]; ok && !h.authorize(c, permission) {
+		return
+	}
+
 	err = h.service.UpdateOrderStatus(c.Request.Context(), id, status)
 	if err != nil {
 		h.logger.WithError(err).WithField("order_id", id).Error("Failed to update order status")
@@ -200,6 +220,10 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid status transition"})
 			return
 		}
+		if err == domain.ErrStockReservationExpired {
+			c.JSON(http.StatusConflict, gin.H{"error": "Stock reservation expired, the order must be placed again"})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
 		return
 	}
@@ -212,6 +236,102 @@ func (h *Handler) UpdateOrderStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"message": "Order status updated successfully"})
 }
 
+// EditOrder handles PATCH /orders/:id
+func (h *Handler) EditOrder(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req dto.EditOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.WithError(err).Error("Request validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	if dto.HasPriceOverride(req.AddItems) && !h.authorize(c, permissionOverridePrice) {
+		return
+	}
+	req.MarginApprovedBy = marginApprover(c)
+
+	order, err := h.service.EditOrder(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to edit order")
+		switch {
+		case err == domain.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case err == domain.ErrOrderItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+		case err == domain.ErrOrderCannotBeModified:
+			c.JSON(http.StatusConflict, gin.H{"error": "Only pending and confirmed orders can be edited"})
+		case err == domain.ErrOrderRevisionConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": "Order was changed by someone else, reload it and try again"})
+		case errors.Is(err, domain.ErrInsufficientStock):
+			c.JSON(http.StatusConflict, gin.H{"error": "Insufficient stock", "details": err.Error()})
+		case errors.Is(err, domain.ErrLaunchAllocationExhausted):
+			c.JSON(http.StatusConflict, gin.H{"error": "Early access allocation of the customer's VIP level is exhausted", "details": err.Error()})
+		case errors.Is(err, domain.ErrVIPAccessRequired):
+			c.JSON(http.StatusForbidden, gin.H{"error": "Product is only sold to higher VIP levels", "details": err.Error()})
+		case err == domain.ErrInvalidOrderData, err == domain.ErrInvalidQuantity,
+			err == domain.ErrInvalidPrice, err == domain.ErrInvalidAmount,
+			err == domain.ErrPriceOverrideReasonRequired:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrProductLookupFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "VAT exemptions could not be checked, try again"})
+		case errors.Is(err, domain.ErrProductUnavailable):
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is unknown or not for sale", "details": err.Error()})
+		case errors.Is(err, domain.ErrPricingFailed):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Items could not be priced, try again"})
+		case errors.Is(err, domain.ErrMarginApprovalRequired):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Items below cost or the minimum margin need a manager's approval", "details": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to edit order"})
+		}
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"order_id": id,
+		"revision": order.Revision,
+	}).Info("Order edited successfully")
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetOrderRevisions handles GET /orders/:id/revisions
+func (h *Handler) GetOrderRevisions(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	revisions, err := h.service.GetOrderRevisions(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to get order revisions")
+		if err == domain.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get order revisions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"revisions": revisions})
+}
+
 // CancelOrder handles POST /orders/:id/cancel
 func (h *Handler) CancelOrder(c *gin.Context) {
 	idStr := c.Param("id")
@@ -238,9 +358,14 @@ func (h *Handler) CancelOrder(c *gin.Context) {
 		return
 	}
 
-	err = h.service.CancelOrder(c.Request.Context(), id, req.Reason)
+	user, _ := middleware.CurrentUser(c)
+	err = h.service.CancelOrder(c.Request.Context(), id, req.Reason, middleware.HasPermission(user, permissionCancelConfirmed))
 	if err != nil {
 		h.logger.WithError(err).WithField("order_id", id).Error("Failed to cancel order")
+		if err == domain.ErrCancelConfirmedNotPermitted {
+			middleware.Forbid(c, h.auth, user, permissionCancelConfirmed)
+			return
+		}
 		if err == domain.ErrOrderNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 			return
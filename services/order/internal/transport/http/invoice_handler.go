@@ -0,0 +1,145 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// IssueInvoice handles POST /orders/:id/invoices
+func (h *Handler) IssueInvoice(c *gin.Context) {
+	id, ok := h.parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.IssueInvoiceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.WithError(err).Error("Request validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	invoice, err := h.service.IssueInvoice(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to issue invoice")
+		h.respondInvoiceError(c, err, "Failed to issue invoice")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"order_id":   id,
+		"invoice_id": invoice.ID,
+		"number":     invoice.Number,
+	}).Info("Invoice issued successfully")
+
+	c.JSON(http.StatusCreated, invoice)
+}
+
+// GetInvoices handles GET /orders/:id/invoices
+func (h *Handler) GetInvoices(c *gin.Context) {
+	id, ok := h.parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	invoices, err := h.service.GetInvoices(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to get invoices")
+		h.respondInvoiceError(c, err, "Failed to get invoices")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"invoices": invoices})
+}
+
+// GetInvoice handles GET /invoices/:id
+func (h *Handler) GetInvoice(c *gin.Context) {
+	id, ok := h.parseInvoiceID(c)
+	if !ok {
+		return
+	}
+
+	invoice, err := h.service.GetInvoice(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("invoice_id", id).Error("Failed to get invoice")
+		h.respondInvoiceError(c, err, "Failed to get invoice")
+		return
+	}
+
+	c.JSON(http.StatusOK, invoice)
+}
+
+// GetInvoicePDF handles GET /invoices/:id/pdf
+func (h *Handler) GetInvoicePDF(c *gin.Context) {
+	h.renderInvoice(c, application.DocumentFormatPDF, "application/pdf")
+}
+
+// GetInvoiceXML handles GET /invoices/:id/xml, the e-Tax Invoice document
+func (h *Handler) GetInvoiceXML(c *gin.Context) {
+	h.renderInvoice(c, application.DocumentFormatXML, "application/xml")
+}
+
+func (h *Handler) renderInvoice(c *gin.Context, format application.DocumentFormat, contentType string) {
+	id, ok := h.parseInvoiceID(c)
+	if !ok {
+		return
+	}
+
+	document, filename, err := h.service.RenderInvoice(c.Request.Context(), id, format)
+	if err != nil {
+		h.logger.WithError(err).WithField("invoice_id", id).Error("Failed to render invoice")
+		h.respondInvoiceError(c, err, "Failed to render invoice")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Data(http.StatusOK, contentType, document)
+}
+
+func (h *Handler) parseInvoiceID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid invoice ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid invoice ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) respondInvoiceError(c *gin.Context, err error, message string) {
+	switch {
+	case err == domain.ErrOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case err == domain.ErrInvoiceNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invoice not found"})
+	case err == domain.ErrInvoiceAlreadyIssued:
+		c.JSON(http.StatusConflict, gin.H{"error": "An invoice of this type was already issued for the order"})
+	case err == domain.ErrOrderCannotBeInvoiced:
+		c.JSON(http.StatusConflict, gin.H{"error": "Order cannot be invoiced in its current status"})
+	case err == domain.ErrInvoiceNotTaxed:
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "A tax invoice can only be issued for an order with VAT"})
+	case err == domain.ErrInvalidInvoiceType, err == domain.ErrInvalidBuyerTaxID,
+		err == domain.ErrInvalidBranchCode, err == domain.ErrBuyerDetailsRequired:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case err == domain.ErrInvalidTaxSettings:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Seller tax details are not configured"})
+	case errors.Is(err, domain.ErrProductLookupFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Product names could not be looked up, try again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
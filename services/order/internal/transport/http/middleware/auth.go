@@ -2,10 +2,10 @@ package middleware
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+	"crypto/sha256"
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -16,85 +16,88 @@ import (
 type Role string
 
 const (
-	RoleSales      Role = "sales"
-	RoleManager    Role = "manager"
-	RoleAdmin      Role = "admin"
+	RoleSales       Role = "sales"
+	RoleManager     Role = "manager"
+	RoleAdmin       Role = "admin"
 	RoleAIAssistant Role = "ai_assistant"
 )
 
 // User represents authenticated user information
 type User struct {
-	ID       string   `json:"id"`
-	Email    string   `json:"email"`
-	Name     string   `json:"name"`
-	Role     Role     `json:"role"`
+	ID          string   `json:"id"`
+	Email       string   `json:"email"`
+	Name        string   `json:"name"`
+	Role        Role     `json:"role"`
 	Permissions []string `json:"permissions"`
 }
 
+// Reasons a request is denied
+const (
+	DenialMissingToken            = "missing_token"
+	DenialInvalidToken            = "invalid_token"
+	DenialInsufficientPermissions = "insufficient_permissions"
+)
+
+// Denial describes a request that was turned away
+type Denial struct {
+	// User is nil when the request carried no valid token
+	User     *User
+	Reason   string
+	Required []string
+}
+
 // AuthConfig holds authentication configuration
 type AuthConfig struct {
 	AuthServiceURL string
-	JWTSecret     string
-	Logger        logger.Logger
+	JWTSecret      string
+	Logger         logger.Logger
+
+	// JWKSURL is where the user service publishes its token signing keys; it defaults to
+	// /.well-known/jwks.json on the auth service
+	JWKSURL string
+	// Issuer and Audience must match the iss and aud claims of every token
+	Issuer   string
+	Audience string
+	// CacheTTL is how long a verified token is trusted without checking it again, never past
+	// its expiry. Defaults to 5 minutes.
+	CacheTTL time.Duration
+	// KeyRefreshInterval is how often the signing keys are fetched again. Defaults to 1 hour.
+	KeyRefreshInterval time.Duration
+
+	// OnDenied is called for every request the middleware turns away
+	OnDenied func(c *gin.Context, denial *Denial)
+
+	once     sync.Once
+	verifier *tokenVerifier
 }
 
 // RequireRole creates middleware that requires specific roles
 func RequireRole(config *AuthConfig, allowedRoles ...Role) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// 1. Extract JWT token from Authorization header
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			config.Logger.Warn("Missing Authorization header")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
+		user, ok := authenticate(c, config)
+		if !ok {
 			return
 		}
 
-		// Expected format: "Bearer <token>"
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			config.Logger.Warn("Invalid Authorization header format")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
-			return
-		}
-
-		token := tokenParts[1]
-
-		// 2. Verify token with Auth Service
-		user, err := verifyTokenWithAuthService(c.Request.Context(), config, token)
-		if err != nil {
-			config.Logger.Error("Token verification failed", "error", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
-
-		// 3. Check role permissions
 		if !hasRequiredRole(user.Role, allowedRoles) {
-			config.Logger.Warn("Insufficient permissions", 
-				"user_id", user.ID, 
-				"user_role", user.Role, 
-				"required_roles", allowedRoles)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
+			required := make([]string, len(allowedRoles))
+			for i, role := range allowedRoles {
+				required[i] = string(role)
+			}
+			config.Logger.WithFields(map[string]interface{}{
+				"user_id":        user.ID,
+				"user_role":      user.Role,
 				"required_roles": allowedRoles,
-				"user_role": user.Role,
+				"endpoint":       c.FullPath(),
+			}).Warn("Insufficient permissions")
+			deny(c, config, http.StatusForbidden, &Denial{User: user, Reason: DenialInsufficientPermissions, Required: required}, gin.H{
+				"error":          "Insufficient permissions",
+				"required_roles": allowedRoles,
+				"user_role":      user.Role,
 			})
-			c.Abort()
 			return
 		}
 
-		// 4. Set user context for downstream handlers
-		c.Set("user", user)
-		c.Set("user_id", user.ID)
-		c.Set("user_role", user.Role)
-
-		config.Logger.Info("User authenticated successfully", 
-			"user_id", user.ID, 
-			"role", user.Role, 
-			"endpoint", c.Request.URL.Path)
-
 		c.Next()
 	})
 }
@@ -102,146 +105,238 @@ func RequireRole(config *AuthConfig, allowedRoles ...Role) gin.HandlerFunc {
 // RequirePermission creates middleware that requires specific permissions
 func RequirePermission(config *AuthConfig, requiredPermissions ...string) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		// Extract and verify token (same as above)
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			config.Logger.Warn("Missing Authorization header")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header required"})
-			c.Abort()
-			return
-		}
-
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			config.Logger.Warn("Invalid Authorization header format")
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format"})
-			c.Abort()
+		user, ok := authenticate(c, config)
+		if !ok {
 			return
 		}
 
-		token := tokenParts[1]
-		user, err := verifyTokenWithAuthService(c.Request.Context(), config, token)
-		if err != nil {
-			config.Logger.Error("Token verification failed", "error", err)
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
-			c.Abort()
-			return
-		}
-
-		// Check specific permissions
 		if !hasRequiredPermissions(user.Permissions, requiredPermissions) {
-			config.Logger.Warn("Insufficient permissions", 
-				"user_id", user.ID, 
-				"user_permissions", user.Permissions, 
-				"required_permissions", requiredPermissions)
-			c.JSON(http.StatusForbidden, gin.H{
-				"error": "Insufficient permissions",
+			config.Logger.WithFields(map[string]interface{}{
+				"user_id":              user.ID,
+				"user_role":            user.Role,
 				"required_permissions": requiredPermissions,
-				"user_permissions": user.Permissions,
-			})
-			c.Abort()
+				"endpoint":             c.FullPath(),
+			}).Warn("Insufficient permissions")
+			Forbid(c, config, user, requiredPermissions...)
 			return
 		}
 
-		// Set user context
-		c.Set("user", user)
-		c.Set("user_id", user.ID)
-		c.Set("user_role", user.Role)
-
 		c.Next()
 	})
 }
 
+// Forbid turns a request away for lacking permissions. Handlers use it for permissions that
+// depend on the resource, such as the status of the order being cancelled.
+func Forbid(c *gin.Context, config *AuthConfig, user *User, requiredPermissions ...string) {
+	deny(c, config, http.StatusForbidden, &Denial{User: user, Reason: DenialInsufficientPermissions, Required: requiredPermissions}, gin.H{
+		"error":                "Insufficient permissions",
+		"required_permissions": requiredPermissions,
+	})
+}
+
 // OptionalAuth provides optional authentication (sets user if token is valid, but doesn't require it)
 func OptionalAuth(config *AuthConfig) gin.HandlerFunc {
 	return gin.HandlerFunc(func(c *gin.Context) {
-		authHeader := c.GetHeader("Authorization")
-		if authHeader == "" {
-			// No auth header, continue without user context
-			c.Next()
-			return
-		}
-
-		tokenParts := strings.Split(authHeader, " ")
-		if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
-			// Invalid format, continue without user context
+		token, ok := bearerToken(c)
+		if !ok {
+			// No or malformed token, continue without user context
 			c.Next()
 			return
 		}
 
-		token := tokenParts[1]
-		user, err := verifyTokenWithAuthService(c.Request.Context(), config, token)
+		user, err := config.tokenVerifier().verify(c.Request.Context(), token)
 		if err != nil {
 			// Invalid token, continue without user context
-			config.Logger.Warn("Optional auth token verification failed", "error", err)
+			config.Logger.WithField("error", err).Warn("Optional auth token verification failed")
 			c.Next()
 			return
 		}
 
-		// Set user context if token is valid
-		c.Set("user", user)
-		c.Set("user_id", user.ID)
-		c.Set("user_role", user.Role)
-
+		setUser(c, user)
 		c.Next()
 	})
 }
 
-// verifyTokenWithAuthService verifies JWT token with the Auth Service
-func verifyTokenWithAuthService(ctx context.Context, config *AuthConfig, token string) (*User, error) {
-	// Create HTTP client with timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// CurrentUser returns the user authenticated for the request
+func CurrentUser(c *gin.Context) (*User, bool) {
+	value, ok := c.Get("user")
+	if !ok {
+		return nil, false
 	}
+	user, ok := value.(*User)
+	return user, ok
+}
 
-	// Prepare request to Auth Service
-	authServiceURL := config.AuthServiceURL
-	if authServiceURL == "" {
-		// Use service name as per PROJECT_RULES.md
-		authServiceURL = "http://user-service:8088"
+// HasPermission reports whether the user holds a permission, directly or through a wildcard
+func HasPermission(user *User, permission string) bool {
+	return user != nil && hasRequiredPermissions(user.Permissions, []string{permission})
+}
+
+// authenticate verifies the bearer token of a request, or reuses the user an earlier auth
+// middleware verified. It aborts the request and returns false when there is no valid token.
+func authenticate(c *gin.Context, config *AuthConfig) (*User, bool) {
+	if user, ok := CurrentUser(c); ok {
+		return user, true
 	}
 
-	url := fmt.Sprintf("%s/api/auth/verify", authServiceURL)
-	req, err := http.NewRequestWithContext(ctx, "POST", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	log := config.Logger.WithFields(map[string]interface{}{
+		"endpoint":  c.FullPath(),
+		"client_ip": c.ClientIP(),
+	})
+
+	authHeader := c.GetHeader("Authorization")
+	if authHeader == "" {
+		log.Warn("Missing Authorization header")
+		deny(c, config, http.StatusUnauthorized, &Denial{Reason: DenialMissingToken}, gin.H{"error": "Authorization header required"})
+		return nil, false
 	}
 
-	// Set Authorization header with the token
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Content-Type", "application/json")
+	// Expected format: "Bearer <token>"
+	token, ok := bearerToken(c)
+	if !ok {
+		log.Warn("Invalid Authorization header format")
+		deny(c, config, http.StatusUnauthorized, &Denial{Reason: DenialInvalidToken}, gin.H{"error": "Invalid authorization header format"})
+		return nil, false
+	}
 
-	// Make request to Auth Service
-	resp, err := client.Do(req)
+	user, err := config.tokenVerifier().verify(c.Request.Context(), token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to verify token with auth service: %w", err)
+		log.WithField("error", err).Warn("Token verification failed")
+		deny(c, config, http.StatusUnauthorized, &Denial{Reason: DenialInvalidToken}, gin.H{"error": "Invalid or expired token"})
+		return nil, false
 	}
-	defer resp.Body.Close()
 
-	// Check response status
-	if resp.StatusCode == http.StatusUnauthorized {
-		return nil, fmt.Errorf("invalid or expired token")
+	setUser(c, user)
+	return user, true
+}
+
+func bearerToken(c *gin.Context) (string, bool) {
+	tokenParts := strings.Split(c.GetHeader("Authorization"), " ")
+	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" || tokenParts[1] == "" {
+		return "", false
 	}
+	return tokenParts[1], true
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("auth service returned status %d", resp.StatusCode)
+// setUser sets the user context for downstream handlers
+func setUser(c *gin.Context, user *User) {
+	c.Set("user", user)
+	c.Set("user_id", user.ID)
+	c.Set("user_role", user.Role)
+}
+
+// deny aborts a request and reports it to OnDenied
+func deny(c *gin.Context, config *AuthConfig, status int, denial *Denial, body gin.H) {
+	if config.OnDenied != nil {
+		config.OnDenied(c, denial)
 	}
+	c.AbortWithStatusJSON(status, body)
+}
+
+// tokenVerifier returns the verifier of the config, creating it on first use
+func (config *AuthConfig) tokenVerifier() *tokenVerifier {
+	config.once.Do(func() {
+		jwksURL := config.JWKSURL
+		if jwksURL == "" {
+			authServiceURL := config.AuthServiceURL
+			if authServiceURL == "" {
+				// Use service name as per PROJECT_RULES.md
+				authServiceURL = "http://user-service:8088"
+			}
+			jwksURL = strings.TrimSuffix(authServiceURL, "/") + "/.well-known/jwks.json"
+		}
+		refreshInterval := config.KeyRefreshInterval
+		if refreshInterval <= 0 {
+			refreshInterval = time.Hour
+		}
+		cacheTTL := config.CacheTTL
+		if cacheTTL <= 0 {
+			cacheTTL = 5 * time.Minute
+		}
+
+		config.verifier = &tokenVerifier{
+			keys:     newKeySet(jwksURL, refreshInterval),
+			issuer:   config.Issuer,
+			audience: config.Audience,
+			cacheTTL: cacheTTL,
+			cache:    make(map[[sha256.Size]byte]cachedUser),
+			now:      time.Now,
+		}
+	})
+	return config.verifier
+}
+
+// maxCachedTokens bounds the verification cache; expired entries are swept when it fills up
+const maxCachedTokens = 10000
+
+// tokenVerifier verifies tokens against the signing keys of the user service and remembers
+// verified tokens, so a client sending the same token on every request is only checked once
+// per cache TTL
+type tokenVerifier struct {
+	keys     *keySet
+	issuer   string
+	audience string
+	cacheTTL time.Duration
+	now      func() time.Time
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedUser
+}
 
-	// Parse response
-	var authResponse struct {
-		User User `json:"user"`
+type cachedUser struct {
+	user      User
+	expiresAt time.Time
+}
+
+func (v *tokenVerifier) verify(ctx context.Context, token string) (*User, error) {
+	now := v.now()
+	hash := sha256.Sum256([]byte(token))
+
+	v.mu.Lock()
+	cached, ok := v.cache[hash]
+	v.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		user := cached.user
+		return &user, nil
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(&authResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode auth response: %w", err)
+	claims, err := parseToken(ctx, v.keys, token, v.issuer, v.audience, now)
+	if err != nil {
+		return nil, err
 	}
 
+	user := User{
+		ID:          claims.Subject,
+		Email:       claims.Email,
+		Name:        claims.Name,
+		Role:        claims.Role,
+		Permissions: claims.Permissions,
+	}
 	// Ensure user has default permissions based on role
-	if len(authResponse.User.Permissions) == 0 {
-		authResponse.User.Permissions = GetRolePermissions(authResponse.User.Role)
+	if len(user.Permissions) == 0 {
+		user.Permissions = GetRolePermissions(user.Role)
+	}
+
+	expiresAt := now.Add(v.cacheTTL)
+	if exp := time.Unix(claims.ExpiresAt, 0); exp.Before(expiresAt) {
+		expiresAt = exp
+	}
+
+	v.mu.Lock()
+	if len(v.cache) >= maxCachedTokens {
+		for key, entry := range v.cache {
+			if !now.Before(entry.expiresAt) {
+				delete(v.cache, key)
+			}
+		}
+		if len(v.cache) >= maxCachedTokens {
+			v.cache = make(map[[sha256.Size]byte]cachedUser)
+		}
 	}
+	v.cache[hash] = cachedUser{user: user, expiresAt: expiresAt}
+	v.mu.Unlock()
 
-	return &authResponse.User, nil
+	return &user, nil
 }
 
 // hasRequiredRole checks if user role is in allowed roles
@@ -254,7 +349,8 @@ func hasRequiredRole(userRole Role, allowedRoles []Role) bool {
 	return false
 }
 
-// hasRequiredPermissions checks if user has all required permissions
+// hasRequiredPermissions checks if user has all required permissions. A permission of the form
+// "orders:*" grants every orders permission and "*" grants everything.
 func hasRequiredPermissions(userPermissions []string, requiredPermissions []string) bool {
 	userPermSet := make(map[string]bool)
 	for _, perm := range userPermissions {
@@ -262,9 +358,13 @@ func hasRequiredPermissions(userPermissions []string, requiredPermissions []stri
 	}
 
 	for _, requiredPerm := range requiredPermissions {
-		if !userPermSet[requiredPerm] {
-			return false
+		if userPermSet[requiredPerm] || userPermSet["*"] {
+			continue
+		}
+		if resource, _, ok := strings.Cut(requiredPerm, ":"); ok && userPermSet[resource+":*"] {
+			continue
 		}
+		return false
 	}
 	return true
 }
@@ -276,6 +376,7 @@ func GetRolePermissions(role Role) []string {
 		return []string{
 			"orders:create",
 			"orders:view",
+			"orders:override_price",
 			"customers:view",
 		}
 	case RoleManager:
@@ -286,6 +387,8 @@ func GetRolePermissions(role Role) []string {
 			"orders:confirm",
 			"orders:cancel",
 			"orders:override_stock",
+			"orders:override_price",
+			"orders:approve_below_margin",
 			"customers:view",
 			"customers:update",
 		}
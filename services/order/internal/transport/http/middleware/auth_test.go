@@ -0,0 +1,283 @@
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"order/pkg/logger"
+)
+
+// jwksServer publishes a set of signing keys and counts how often it is fetched
+type jwksServer struct {
+	*httptest.Server
+	mu      sync.Mutex
+	keys    map[string]*rsa.PrivateKey
+	fetches int
+}
+
+func newJWKSServer(t *testing.T) *jwksServer {
+	s := &jwksServer{keys: make(map[string]*rsa.PrivateKey)}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.fetches++
+
+		keys := []map[string]string{}
+		for kid, key := range s.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *jwksServer) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	s.mu.Lock()
+	s.keys[kid] = key
+	s.mu.Unlock()
+	return key
+}
+
+func (s *jwksServer) fetchCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetches
+}
+
+func signToken(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func claimsFor(role Role, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"sub":  "user-" + string(role),
+		"role": role,
+		"iss":  "user-service",
+		"aud":  []string{"order-service"},
+		"exp":  now.Add(time.Hour).Unix(),
+	}
+}
+
+// testAuth builds an auth config against the JWKS server with a clock the test controls
+func testAuth(t *testing.T, server *jwksServer, now *time.Time) (*AuthConfig, *[]*Denial) {
+	denials := &[]*Denial{}
+	config := &AuthConfig{
+		Logger:   logger.NewLogger("error", "json"),
+		JWKSURL:  server.URL,
+		Issuer:   "user-service",
+		Audience: "order-service",
+		OnDenied: func(c *gin.Context, denial *Denial) {
+			*denials = append(*denials, denial)
+		},
+	}
+	verifier := config.tokenVerifier()
+	verifier.now = func() time.Time { return *now }
+	verifier.keys.now = func() time.Time { return *now }
+	return config, denials
+}
+
+func serve(config *AuthConfig, token string, permissions ...string) *httptest.ResponseRecorder {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.GET("/orders/:id", RequirePermission(config), RequirePermission(config, permissions...), func(c *gin.Context) {
+		user, _ := CurrentUser(c)
+		c.JSON(http.StatusOK, user)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/orders/123", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, req)
+	return recorder
+}
+
+func TestRequirePermissionVerifiesTokenLocally(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	now := time.Now()
+	config, denials := testAuth(t, server, &now)
+
+	recorder := serve(config, signToken(t, key, "key-1", claimsFor(RoleManager, now)), "orders:cancel")
+	require.Equal(t, http.StatusOK, recorder.Code)
+
+	var user User
+	require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &user))
+	assert.Equal(t, "user-manager", user.ID)
+	assert.Equal(t, GetRolePermissions(RoleManager), user.Permissions)
+	assert.Empty(t, *denials)
+}
+
+func TestRequirePermissionDeniesAndReports(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	now := time.Now()
+	config, denials := testAuth(t, server, &now)
+
+	recorder := serve(config, signToken(t, key, "key-1", claimsFor(RoleSales, now)), "orders:cancel")
+	assert.Equal(t, http.StatusForbidden, recorder.Code)
+	require.Len(t, *denials, 1)
+	assert.Equal(t, DenialInsufficientPermissions, (*denials)[0].Reason)
+	assert.Equal(t, "user-sales", (*denials)[0].User.ID)
+	assert.Equal(t, []string{"orders:cancel"}, (*denials)[0].Required)
+
+	recorder = serve(config, "", "orders:view")
+	assert.Equal(t, http.StatusUnauthorized, recorder.Code)
+	require.Len(t, *denials, 2)
+	assert.Equal(t, DenialMissingToken, (*denials)[1].Reason)
+	assert.Nil(t, (*denials)[1].User)
+}
+
+func TestTokenVerificationRejectsInvalidTokens(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	now := time.Now()
+	config, _ := testAuth(t, server, &now)
+
+	expired := claimsFor(RoleAdmin, now)
+	expired["exp"] = now.Add(-time.Hour).Unix()
+	wrongIssuer := claimsFor(RoleAdmin, now)
+	wrongIssuer["iss"] = "someone-else"
+	wrongAudience := claimsFor(RoleAdmin, now)
+	wrongAudience["aud"] = "chat-service"
+
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none","kid":"key-1"}`))
+	payload, _ := json.Marshal(claimsFor(RoleAdmin, now))
+	unsigned := header + "." + base64.RawURLEncoding.EncodeToString(payload) + "."
+
+	tokens := map[string]string{
+		"expired":        signToken(t, key, "key-1", expired),
+		"wrong issuer":   signToken(t, key, "key-1", wrongIssuer),
+		"wrong audience": signToken(t, key, "key-1", wrongAudience),
+		"wrong key":      signToken(t, otherKey, "key-1", claimsFor(RoleAdmin, now)),
+		"alg none":       unsigned,
+		"not a jwt":      "valid-jwt-token",
+	}
+	for name, token := range tokens {
+		recorder := serve(config, token)
+		assert.Equal(t, http.StatusUnauthorized, recorder.Code, name)
+	}
+}
+
+func TestKeyRotationIsPickedUp(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	now := time.Now()
+	config, _ := testAuth(t, server, &now)
+
+	require.Equal(t, http.StatusOK, serve(config, signToken(t, key, "key-1", claimsFor(RoleSales, now))).Code)
+	assert.Equal(t, 1, server.fetchCount())
+
+	// A token signed with an unknown key fetches the keys again, at most once a minute
+	rotated := server.addKey(t, "key-2")
+	now = now.Add(2 * time.Minute)
+	token := signToken(t, rotated, "key-2", claimsFor(RoleSales, now))
+	require.Equal(t, http.StatusOK, serve(config, token).Code)
+	assert.Equal(t, 2, server.fetchCount())
+
+	forged := signToken(t, rotated, "key-3", claimsFor(RoleSales, now))
+	assert.Equal(t, http.StatusUnauthorized, serve(config, forged).Code)
+	assert.Equal(t, http.StatusUnauthorized, serve(config, forged).Code)
+	assert.Equal(t, 2, server.fetchCount())
+}
+
+func TestKeySetBacksOffWhileJWKSIsDown(t *testing.T) {
+	fetches := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches++
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	keys := newKeySet(server.URL, time.Hour)
+	keys.now = func() time.Time { return now }
+
+	// Every unknown kid within the backoff gets the outcome of the one failed fetch
+	for _, kid := range []string{"a", "b", "c"} {
+		_, err := keys.key(context.Background(), kid)
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, errUnknownKey)
+	}
+	assert.Equal(t, 1, fetches)
+
+	now = now.Add(keys.minRefetch)
+	keys.key(context.Background(), "d")
+	assert.Equal(t, 2, fetches)
+}
+
+func TestVerifiedTokensAreCached(t *testing.T) {
+	server := newJWKSServer(t)
+	key := server.addKey(t, "key-1")
+	now := time.Now()
+	config, _ := testAuth(t, server, &now)
+
+	claims := claimsFor(RoleSales, now)
+	claims["exp"] = now.Add(3 * time.Minute).Unix()
+	token := signToken(t, key, "key-1", claims)
+	require.Equal(t, http.StatusOK, serve(config, token).Code)
+
+	// The signing key is no longer known, but the token was already verified
+	server.mu.Lock()
+	delete(server.keys, "key-1")
+	server.mu.Unlock()
+	config.tokenVerifier().keys = newKeySet(server.URL, time.Hour)
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, http.StatusOK, serve(config, token).Code)
+
+	// The cache never outlives the token
+	now = now.Add(2 * time.Minute)
+	assert.Equal(t, http.StatusUnauthorized, serve(config, token).Code)
+}
+
+func TestHasRequiredPermissionsWildcards(t *testing.T) {
+	assert.True(t, hasRequiredPermissions(GetRolePermissions(RoleAdmin), []string{"orders:cancel", "customers:update"}))
+	assert.True(t, hasRequiredPermissions([]string{"*"}, []string{"orders:override_stock"}))
+	assert.False(t, hasRequiredPermissions(GetRolePermissions(RoleSales), []string{"orders:update"}))
+	assert.False(t, hasRequiredPermissions([]string{"orders:*"}, []string{"customers:view"}))
+	assert.True(t, hasRequiredPermissions(nil, nil))
+}
+
+func TestOnlyManagersApproveBelowMarginPrices(t *testing.T) {
+	assert.True(t, HasPermission(&User{Permissions: GetRolePermissions(RoleSales)}, "orders:override_price"))
+	assert.False(t, HasPermission(&User{Permissions: GetRolePermissions(RoleSales)}, "orders:approve_below_margin"))
+	assert.True(t, HasPermission(&User{Permissions: GetRolePermissions(RoleManager)}, "orders:approve_below_margin"))
+	assert.True(t, HasPermission(&User{Permissions: GetRolePermissions(RoleAdmin)}, "orders:approve_below_margin"))
+	assert.False(t, HasPermission(&User{Permissions: GetRolePermissions(RoleAIAssistant)}, "orders:override_price"))
+}
@@ -0,0 +1,288 @@
+// Token verification is the same in the order and product services. They are separate
+// modules, so services/product/internal/transport/http/middleware/jwt.go holds a copy of
+// this file; change both together.
+
+package middleware
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// clockSkew is how far the clocks of the user service and this service may drift apart
+const clockSkew = 30 * time.Second
+
+var (
+	errMalformedToken   = errors.New("malformed token")
+	errUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	errUnknownKey       = errors.New("unknown signing key")
+	errInvalidSignature = errors.New("invalid token signature")
+	errTokenExpired     = errors.New("token expired")
+	errTokenNotYetValid = errors.New("token not yet valid")
+	errInvalidIssuer    = errors.New("invalid token issuer")
+	errInvalidAudience  = errors.New("invalid token audience")
+)
+
+// tokenHeader is the JOSE header of a signed token
+type tokenHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// tokenClaims are the claims the user service puts in its access tokens
+type tokenClaims struct {
+	Subject     string   `json:"sub"`
+	Email       string   `json:"email"`
+	Name        string   `json:"name"`
+	Role        Role     `json:"role"`
+	Permissions []string `json:"permissions"`
+	Issuer      string   `json:"iss"`
+	Audience    audience `json:"aud"`
+	ExpiresAt   int64    `json:"exp"`
+	NotBefore   int64    `json:"nbf"`
+}
+
+// audience is the aud claim, which is either a single string or a list
+type audience []string
+
+func (a *audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = audience{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*a = list
+	return nil
+}
+
+func (a audience) contains(value string) bool {
+	for _, aud := range a {
+		if aud == value {
+			return true
+		}
+	}
+	return false
+}
+
+// parseToken verifies an RS256 signed token with the key its kid names and returns its claims.
+// Only RS256 is accepted so a token cannot pick a weaker algorithm, or none, for itself.
+func parseToken(ctx context.Context, keys *keySet, token, issuer, aud string, now time.Time) (*tokenClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	var header tokenHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, errMalformedToken
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: %q", errUnsupportedAlg, header.Alg)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	key, err := keys.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, errInvalidSignature
+	}
+
+	var claims tokenClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, errMalformedToken
+	}
+	if claims.ExpiresAt == 0 || now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return nil, errTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return nil, errTokenNotYetValid
+	}
+	if claims.Issuer != issuer {
+		return nil, errInvalidIssuer
+	}
+	if !claims.Audience.contains(aud) {
+		return nil, errInvalidAudience
+	}
+	if claims.Subject == "" {
+		return nil, errMalformedToken
+	}
+
+	return &claims, nil
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// keySet holds the signing keys published by the user service as a JWKS. Keys are fetched again
+// once refreshInterval has passed, and straight away when a token names a key that is not known
+// yet, so a rotated key is picked up without a restart. Fetches are attempted at most once per
+// minRefetch, whether they succeed or fail, so tokens with made up key IDs cannot hammer the user
+// service and an outage of it is not met with a fetch per request.
+type keySet struct {
+	url             string
+	client          *http.Client
+	refreshInterval time.Duration
+	minRefetch      time.Duration
+	now             func() time.Time
+
+	mu          sync.RWMutex
+	keys        map[string]*rsa.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	lastErr     error
+
+	fetchMu sync.Mutex
+}
+
+func newKeySet(url string, refreshInterval time.Duration) *keySet {
+	return &keySet{
+		url:             url,
+		client:          &http.Client{Timeout: 10 * time.Second},
+		refreshInterval: refreshInterval,
+		minRefetch:      time.Minute,
+		now:             time.Now,
+	}
+}
+
+// key returns the public key with the given ID
+func (s *keySet) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	s.mu.RLock()
+	key, ok := s.keys[kid]
+	fetchedAt := s.fetchedAt
+	s.mu.RUnlock()
+
+	if ok && s.now().Sub(fetchedAt) < s.refreshInterval {
+		return key, nil
+	}
+
+	if err := s.refresh(ctx); err != nil {
+		// Keep using a known key while the user service cannot be reached
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if key, ok := s.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, errUnknownKey
+}
+
+// refresh fetches the key set unless a fetch was attempted within minRefetch, in which case the
+// outcome of that attempt stands
+func (s *keySet) refresh(ctx context.Context) error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+
+	s.mu.RLock()
+	attemptedAt, lastErr := s.attemptedAt, s.lastErr
+	s.mu.RUnlock()
+	if !attemptedAt.IsZero() && s.now().Sub(attemptedAt) < s.minRefetch {
+		return lastErr
+	}
+
+	keys, err := s.fetch(ctx)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attemptedAt = s.now()
+	s.lastErr = err
+	if err != nil {
+		return err
+	}
+	s.keys = keys
+	s.fetchedAt = s.attemptedAt
+	return nil
+}
+
+// jsonWebKey is an RSA key of a JWKS; keys of other types are skipped
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func (s *keySet) fetch(ctx context.Context) (map[string]*rsa.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch signing keys: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("user service returned status %d for signing keys", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, fmt.Errorf("failed to decode signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") || (jwk.Alg != "" && jwk.Alg != "RS256") {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			return nil, fmt.Errorf("invalid signing key %q: %w", jwk.Kid, err)
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+	exponent := new(big.Int).SetBytes(e)
+	if len(n) == 0 || !exponent.IsInt64() || exponent.Int64() < 3 || exponent.Int64() > 1<<31-1 {
+		return nil, errors.New("invalid modulus or exponent")
+	}
+	return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}, nil
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// CreatePromotion handles POST /promotions
+func (h *Handler) CreatePromotion(c *gin.Context) {
+	var req dto.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.WithError(err).Error("Request validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	promotion, err := h.service.CreatePromotion(c.Request.Context(), &req)
+	if err != nil {
+		h.respondPromotionError(c, err, "Failed to create promotion")
+		return
+	}
+
+	h.logger.WithField("code", promotion.Code).Info("Promotion created successfully")
+	c.JSON(http.StatusCreated, promotion)
+}
+
+// ListPromotions handles GET /promotions
+func (h *Handler) ListPromotions(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 100 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit, use 1-100"})
+		return
+	}
+	offset, err := strconv.Atoi(c.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid offset"})
+		return
+	}
+	activeOnly := c.Query("active") == "true"
+
+	promotions, err := h.service.ListPromotions(c.Request.Context(), activeOnly, limit, offset)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list promotions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"promotions": promotions,
+		"pagination": gin.H{
+			"limit":  limit,
+			"offset": offset,
+			"count":  len(promotions),
+		},
+	})
+}
+
+// GetPromotion handles GET /promotions/:code
+func (h *Handler) GetPromotion(c *gin.Context) {
+	promotion, err := h.service.GetPromotion(c.Request.Context(), c.Param("code"))
+	if err != nil {
+		h.logger.WithError(err).WithField("code", c.Param("code")).Error("Failed to get promotion")
+		h.respondPromotionError(c, err, "Failed to get promotion")
+		return
+	}
+
+	c.JSON(http.StatusOK, promotion)
+}
+
+// UpdatePromotion handles PATCH /promotions/:code
+func (h *Handler) UpdatePromotion(c *gin.Context) {
+	var req dto.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	promotion, err := h.service.UpdatePromotion(c.Request.Context(), c.Param("code"), &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("code", c.Param("code")).Error("Failed to update promotion")
+		h.respondPromotionError(c, err, "Failed to update promotion")
+		return
+	}
+
+	h.logger.WithField("code", promotion.Code).Info("Promotion updated successfully")
+	c.JSON(http.StatusOK, promotion)
+}
+
+// respondPromotionError maps promotion errors to a response. Errors about a code of an order name
+// the code, so the customer knows which one to remove.
+func (h *Handler) respondPromotionError(c *gin.Context, err error, message string) {
+	body := gin.H{"error": err.Error()}
+	var promotionErr *domain.PromotionError
+	orderCode := errors.As(err, &promotionErr)
+	if orderCode {
+		body = gin.H{"error": promotionErr.Err.Error(), "promo_code": promotionErr.Code}
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrPromotionNotFound) && !orderCode:
+		c.JSON(http.StatusNotFound, body)
+	case errors.Is(err, domain.ErrPromotionAlreadyExists),
+		errors.Is(err, domain.ErrPromotionUsageLimitReached),
+		errors.Is(err, domain.ErrPromotionCustomerLimitReached):
+		c.JSON(http.StatusConflict, body)
+	case errors.Is(err, domain.ErrInvalidPromotion),
+		errors.Is(err, domain.ErrPromotionNotFound),
+		errors.Is(err, domain.ErrPromotionNotStarted),
+		errors.Is(err, domain.ErrPromotionExpired),
+		errors.Is(err, domain.ErrPromotionTierRequired),
+		errors.Is(err, domain.ErrPromotionMinSpendNotMet),
+		errors.Is(err, domain.ErrPromotionNotApplicable),
+		errors.Is(err, domain.ErrPromotionNotStackable):
+		c.JSON(http.StatusUnprocessableEntity, body)
+	case errors.Is(err, domain.ErrPromotionLookupFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Promo code rules could not be checked, try again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// isPromotionError reports whether err is about the promo codes of an order
+func isPromotionError(err error) bool {
+	var promotionErr *domain.PromotionError
+	return errors.As(err, &promotionErr) ||
+		errors.Is(err, domain.ErrPromotionNotStackable) ||
+		errors.Is(err, domain.ErrPromotionLookupFailed)
+}
@@ -0,0 +1,252 @@
+package http
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// CreateRecurringOrder handles POST /recurring-orders
+func (h *Handler) CreateRecurringOrder(c *gin.Context) {
+	var req dto.CreateRecurringOrderRequest
+	if !h.bindRecurringOrderRequest(c, &req) {
+		return
+	}
+	// Every order of a recurring order with a customer group gets that group's prices
+	if req.CustomerGroup != "" && !h.authorize(c, permissionOverridePrice) {
+		return
+	}
+
+	order, err := h.service.CreateRecurringOrder(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("customer_id", req.CustomerID).Error("Failed to create recurring order")
+		h.respondRecurringOrderError(c, err, "Failed to create recurring order")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"recurring_order_id": order.ID,
+		"customer_id":        order.CustomerID,
+		"next_run_at":        order.NextRunAt,
+	}).Info("Recurring order created successfully")
+
+	c.JSON(http.StatusCreated, order)
+}
+
+// GetRecurringOrder handles GET /recurring-orders/:id
+func (h *Handler) GetRecurringOrder(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.GetRecurringOrder(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to get recurring order")
+		h.respondRecurringOrderError(c, err, "Failed to get recurring order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// GetRecurringOrderRuns handles GET /recurring-orders/:id/runs
+func (h *Handler) GetRecurringOrderRuns(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	runs, err := h.service.GetRecurringOrderRuns(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to get recurring order runs")
+		h.respondRecurringOrderError(c, err, "Failed to get recurring order runs")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// GetCustomerRecurringOrders handles GET /customers/:customer_id/recurring-orders
+func (h *Handler) GetCustomerRecurringOrders(c *gin.Context) {
+	customerIDStr := c.Param("customer_id")
+	customerID, err := uuid.Parse(customerIDStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("customer_id", customerIDStr).Error("Invalid customer ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid customer ID"})
+		return
+	}
+
+	orders, err := h.service.GetCustomerRecurringOrders(c.Request.Context(), customerID)
+	if err != nil {
+		h.logger.WithError(err).WithField("customer_id", customerID).Error("Failed to get customer recurring orders")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get customer recurring orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"recurring_orders": orders})
+}
+
+// SkipRecurringOrder handles POST /recurring-orders/:id/skip
+func (h *Handler) SkipRecurringOrder(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.SkipRecurringOrder(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to skip recurring order")
+		h.respondRecurringOrderError(c, err, "Failed to skip recurring order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// PauseRecurringOrder handles POST /recurring-orders/:id/pause. Without a body the recurring
+// order stays paused until it is resumed.
+func (h *Handler) PauseRecurringOrder(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.PauseRecurringOrderRequest
+	if c.Request.ContentLength != 0 && !h.bindRecurringOrderRequest(c, &req) {
+		return
+	}
+
+	order, err := h.service.PauseRecurringOrder(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to pause recurring order")
+		h.respondRecurringOrderError(c, err, "Failed to pause recurring order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ResumeRecurringOrder handles POST /recurring-orders/:id/resume
+func (h *Handler) ResumeRecurringOrder(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.ResumeRecurringOrder(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to resume recurring order")
+		h.respondRecurringOrderError(c, err, "Failed to resume recurring order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// CancelRecurringOrder handles POST /recurring-orders/:id/cancel
+func (h *Handler) CancelRecurringOrder(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.CancelRecurringOrder(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to cancel recurring order")
+		h.respondRecurringOrderError(c, err, "Failed to cancel recurring order")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ModifyNextOccurrence handles PUT /recurring-orders/:id/next
+func (h *Handler) ModifyNextOccurrence(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ModifyNextOccurrenceRequest
+	if !h.bindRecurringOrderRequest(c, &req) {
+		return
+	}
+
+	order, err := h.service.ModifyNextOccurrence(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to modify next occurrence")
+		h.respondRecurringOrderError(c, err, "Failed to modify next occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+// ResetNextOccurrence handles DELETE /recurring-orders/:id/next, dropping changes to the next order
+func (h *Handler) ResetNextOccurrence(c *gin.Context) {
+	id, ok := h.parseRecurringOrderID(c)
+	if !ok {
+		return
+	}
+
+	order, err := h.service.ModifyNextOccurrence(c.Request.Context(), id, nil)
+	if err != nil {
+		h.logger.WithError(err).WithField("recurring_order_id", id).Error("Failed to reset next occurrence")
+		h.respondRecurringOrderError(c, err, "Failed to reset next occurrence")
+		return
+	}
+
+	c.JSON(http.StatusOK, order)
+}
+
+func (h *Handler) parseRecurringOrderID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid recurring order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid recurring order ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) bindRecurringOrderRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.logger.WithError(err).Error("Request validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respondRecurringOrderError(c *gin.Context, err error, message string) {
+	switch {
+	case err == domain.ErrRecurringOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Recurring order not found"})
+	case err == domain.ErrRecurringOrderNotActive:
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring order is not active"})
+	case err == domain.ErrRecurringOrderNotPaused:
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring order is not paused"})
+	case err == domain.ErrRecurringOrderCancelled:
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring order is cancelled"})
+	case err == domain.ErrRecurringOrderConflict, err == domain.ErrRecurringOrderRunExists:
+		c.JSON(http.StatusConflict, gin.H{"error": "Recurring order was changed or its next order is being created, reload it and try again"})
+	case err == domain.ErrInvalidSchedule, err == domain.ErrInvalidPauseUntil, err == domain.ErrInvalidOrderData,
+		err == domain.ErrInvalidOrderSource, err == domain.ErrInvalidOrderItemData, err == domain.ErrInvalidQuantity,
+		err == domain.ErrInvalidPrice, err == domain.ErrInvalidAmount, err == domain.ErrInvalidCustomerID:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,223 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// CreateReturn handles POST /orders/:id/returns
+func (h *Handler) CreateReturn(c *gin.Context) {
+	id, ok := h.parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.CreateReturnRequest
+	if !h.bindReturnRequest(c, &req) {
+		return
+	}
+
+	request, err := h.service.RequestReturn(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to create return")
+		h.respondReturnError(c, err, "Failed to create return")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"order_id":  id,
+		"return_id": request.ID,
+	}).Info("Return requested successfully")
+
+	c.JSON(http.StatusCreated, request)
+}
+
+// GetReturns handles GET /orders/:id/returns
+func (h *Handler) GetReturns(c *gin.Context) {
+	id, ok := h.parseOrderID(c)
+	if !ok {
+		return
+	}
+
+	returns, err := h.service.GetReturns(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to get returns")
+		if err == domain.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get returns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+// ApproveReturn handles POST /orders/:id/returns/:return_id/approve
+func (h *Handler) ApproveReturn(c *gin.Context) {
+	id, returnID, ok := h.parseReturnIDs(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ApproveReturnRequest
+	if c.Request.ContentLength > 0 && !h.bindReturnRequest(c, &req) {
+		return
+	}
+
+	request, err := h.service.ApproveReturn(c.Request.Context(), id, returnID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("return_id", returnID).Error("Failed to approve return")
+		h.respondReturnError(c, err, "Failed to approve return")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// RejectReturn handles POST /orders/:id/returns/:return_id/reject
+func (h *Handler) RejectReturn(c *gin.Context) {
+	id, returnID, ok := h.parseReturnIDs(c)
+	if !ok {
+		return
+	}
+
+	var req dto.RejectReturnRequest
+	if !h.bindReturnRequest(c, &req) {
+		return
+	}
+
+	request, err := h.service.RejectReturn(c.Request.Context(), id, returnID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("return_id", returnID).Error("Failed to reject return")
+		h.respondReturnError(c, err, "Failed to reject return")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// ReceiveReturn handles POST /orders/:id/returns/:return_id/receive
+func (h *Handler) ReceiveReturn(c *gin.Context) {
+	id, returnID, ok := h.parseReturnIDs(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ReceiveReturnRequest
+	if c.Request.ContentLength > 0 && !h.bindReturnRequest(c, &req) {
+		return
+	}
+
+	request, err := h.service.ReceiveReturn(c.Request.Context(), id, returnID, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("return_id", returnID).Error("Failed to receive return")
+		h.respondReturnError(c, err, "Failed to receive return")
+		return
+	}
+
+	c.JSON(http.StatusOK, request)
+}
+
+// RefundReturn handles POST /orders/:id/returns/:return_id/refund
+func (h *Handler) RefundReturn(c *gin.Context) {
+	id, returnID, ok := h.parseReturnIDs(c)
+	if !ok {
+		return
+	}
+
+	request, err := h.service.RefundReturn(c.Request.Context(), id, returnID)
+	if err != nil {
+		h.logger.WithError(err).WithField("return_id", returnID).Error("Failed to refund return")
+		h.respondReturnError(c, err, "Failed to refund return")
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"order_id":      id,
+		"return_id":     returnID,
+		"refund_amount": request.RefundAmount,
+		"status":        request.OrderStatus,
+	}).Info("Return refunded successfully")
+
+	c.JSON(http.StatusOK, request)
+}
+
+func (h *Handler) parseOrderID(c *gin.Context) (uuid.UUID, bool) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+func (h *Handler) parseReturnIDs(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	id, ok := h.parseOrderID(c)
+	if !ok {
+		return uuid.Nil, uuid.Nil, false
+	}
+
+	returnIDStr := c.Param("return_id")
+	returnID, err := uuid.Parse(returnIDStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("return_id", returnIDStr).Error("Invalid return ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid return ID"})
+		return uuid.Nil, uuid.Nil, false
+	}
+	return id, returnID, true
+}
+
+func (h *Handler) bindReturnRequest(c *gin.Context, req interface{}) bool {
+	if err := c.ShouldBindJSON(req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return false
+	}
+
+	if err := h.validator.Struct(req); err != nil {
+		h.logger.WithError(err).Error("Request validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return false
+	}
+	return true
+}
+
+func (h *Handler) respondReturnError(c *gin.Context, err error, message string) {
+	switch {
+	case err == domain.ErrOrderNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+	case err == domain.ErrReturnNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": "Return not found"})
+	case err == domain.ErrOrderItemNotFound, err == domain.ErrReturnItemNotFound:
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case err == domain.ErrOrderCannotBeReturned:
+		c.JSON(http.StatusConflict, gin.H{"error": "Order cannot be returned in its current status"})
+	case err == domain.ErrReturnExceedsFulfilled:
+		c.JSON(http.StatusConflict, gin.H{"error": "Return quantity exceeds the delivered quantity left to return"})
+	case err == domain.ErrInvalidReturnStatus:
+		c.JSON(http.StatusConflict, gin.H{"error": "Return is not in a status that allows this operation"})
+	case err == domain.ErrRefundExceedsPaid:
+		c.JSON(http.StatusConflict, gin.H{"error": "Refund amount exceeds the amount paid for the order"})
+	case err == domain.ErrOrderRevisionConflict:
+		c.JSON(http.StatusConflict, gin.H{"error": "Order was changed by someone else, reload it and try again"})
+	case err == domain.ErrInvalidQuantity, err == domain.ErrInvalidReturnReason:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrDeliveryNotCreated):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Pickup could not be created"})
+	case errors.Is(err, domain.ErrRestockFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Returned stock could not be put back, try again"})
+	case errors.Is(err, domain.ErrRefundFailed):
+		c.JSON(http.StatusBadGateway, gin.H{"error": "Refund could not be completed, try again"})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -3,11 +3,16 @@ package http
 import (
 	"github.com/gin-gonic/gin"
 	"order/internal/application"
+	"order/internal/infrastructure/events"
+	"order/internal/transport/http/middleware"
 	"github.com/sirupsen/logrus"
 )
 
-// SetupRoutes configures the HTTP routes using the new handler
-func SetupRoutes(service *application.Service, logger *logrus.Logger) *gin.Engine {
+// SetupRoutes configures the HTTP routes using the new handler.
+// outboxRelay is optional; when set its state is included in the health output.
+// Every API route needs a verified token; requests denied by authConfig are written to the
+// order audit log.
+func SetupRoutes(service *application.Service, statsHandler *StatsHandler, outboxRelay *events.OutboxRelay, authConfig *middleware.AuthConfig, logger *logrus.Logger) *gin.Engine {
 	router := gin.New()
 	
 	// Middleware
@@ -17,7 +22,7 @@ func SetupRoutes(service *application.Service, logger *logrus.Logger) *gin.Engin
 	// CORS middleware
 	router.Use(func(c *gin.Context) {
 		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Accept, Authorization")
 		
 		if c.Request.Method == "OPTIONS" {
@@ -30,29 +35,100 @@ func SetupRoutes(service *application.Service, logger *logrus.Logger) *gin.Engin
 	
 	// Health check
 	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{"status": "healthy"})
+		if outboxRelay == nil {
+			c.JSON(200, gin.H{"status": "healthy"})
+			return
+		}
+
+		relayHealth := outboxRelay.Health(c.Request.Context())
+		c.JSON(200, gin.H{"status": relayHealth.Status, "outbox_relay": relayHealth})
 	})
 	
 	// Create handler
-	handler := NewHandler(service, logger)
+	handler := NewHandler(service, authConfig, logger)
+	authConfig.OnDenied = handler.recordAccessDenied
+	
+	// Permission checks reuse the user verified for the group
+	require := func(permissions ...string) gin.HandlerFunc {
+		return middleware.RequirePermission(authConfig, permissions...)
+	}
+	create, view, update := require(permissionCreateOrders), require(permissionViewOrders), require(permissionUpdateOrders)
 	
 	// API routes
 	v1 := router.Group("/api/v1")
+	v1.Use(require())
 	{
 		// Order routes
 		orders := v1.Group("/orders")
 		{
-			orders.POST("", handler.CreateOrder)
-			orders.GET("", handler.ListOrders)
-			orders.GET("/:id", handler.GetOrder)
-			orders.PUT("/:id/status", handler.UpdateOrderStatus)
-			orders.POST("/:id/cancel", handler.CancelOrder)
+			orders.POST("", create, handler.CreateOrder)
+			orders.GET("", view, handler.ListOrders)
+			orders.GET("/backorders", view, handler.GetBackorders)
+			orders.GET("/export", view, handler.ExportOrders)
+			orders.GET("/:id", view, handler.GetOrder)
+			orders.PATCH("/:id", update, handler.EditOrder)
+			orders.GET("/:id/revisions", view, handler.GetOrderRevisions)
+			orders.PUT("/:id/status", update, handler.UpdateOrderStatus)
+			orders.POST("/:id/cancel", update, handler.CancelOrder)
+			orders.POST("/:id/shipments", update, handler.CreateShipment)
+			orders.GET("/:id/shipments", view, handler.GetShipments)
+			orders.POST("/:id/shipments/:shipment_id/deliver", update, handler.MarkShipmentDelivered)
+			orders.POST("/:id/returns", update, handler.CreateReturn)
+			orders.GET("/:id/returns", view, handler.GetReturns)
+			orders.POST("/:id/returns/:return_id/approve", update, handler.ApproveReturn)
+			orders.POST("/:id/returns/:return_id/reject", update, handler.RejectReturn)
+			orders.POST("/:id/returns/:return_id/receive", update, handler.ReceiveReturn)
+			orders.POST("/:id/returns/:return_id/refund", update, handler.RefundReturn)
+			orders.POST("/:id/invoices", update, handler.IssueInvoice)
+			orders.GET("/:id/invoices", view, handler.GetInvoices)
+		}
+		
+		// Tax invoice, receipt and credit note routes
+		invoices := v1.Group("/invoices")
+		{
+			invoices.GET("/:id", view, handler.GetInvoice)
+			invoices.GET("/:id/pdf", view, handler.GetInvoicePDF)
+			invoices.GET("/:id/xml", view, handler.GetInvoiceXML)
+		}
+		
+		// Promo code routes
+		promotions := v1.Group("/promotions")
+		{
+			promotions.POST("", update, handler.CreatePromotion)
+			promotions.GET("", view, handler.ListPromotions)
+			promotions.GET("/:code", view, handler.GetPromotion)
+			promotions.PATCH("/:code", update, handler.UpdatePromotion)
+		}
+		
+		// Recurring order routes; the orders themselves are generated by the scheduler
+		recurringOrders := v1.Group("/recurring-orders")
+		{
+			recurringOrders.POST("", create, handler.CreateRecurringOrder)
+			recurringOrders.GET("/:id", view, handler.GetRecurringOrder)
+			recurringOrders.GET("/:id/runs", view, handler.GetRecurringOrderRuns)
+			recurringOrders.POST("/:id/skip", update, handler.SkipRecurringOrder)
+			recurringOrders.POST("/:id/pause", update, handler.PauseRecurringOrder)
+			recurringOrders.POST("/:id/resume", update, handler.ResumeRecurringOrder)
+			recurringOrders.POST("/:id/cancel", update, handler.CancelRecurringOrder)
+			recurringOrders.PUT("/:id/next", update, handler.ModifyNextOccurrence)
+			recurringOrders.DELETE("/:id/next", update, handler.ResetNextOccurrence)
 		}
 		
 		// Customer order routes
 		customers := v1.Group("/customers")
 		{
-			customers.GET("/:customer_id/orders", handler.GetOrdersByCustomer)
+			customers.GET("/:customer_id/orders", view, handler.GetOrdersByCustomer)
+			customers.GET("/:customer_id/recurring-orders", view, handler.GetCustomerRecurringOrders)
+		}
+		
+		// Statistics routes, read from the daily rollups
+		stats := v1.Group("/stats")
+		{
+			stats.GET("/daily", view, statsHandler.GetDailyStats)
+			stats.GET("/monthly", view, statsHandler.GetMonthlyStats)
+			stats.GET("/top-products", view, statsHandler.GetTopProducts)
+			stats.GET("/customer/:customer_id", view, statsHandler.GetCustomerStats)
+			stats.GET("/overview", view, statsHandler.GetOverallStats)
 		}
 	}
 	
@@ -0,0 +1,268 @@
+package http
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// orderExportColumns is the header row of the CSV export
+var orderExportColumns = []string{
+	"id", "code", "created_at", "customer_id", "status", "source", "paid_status", "payment_method",
+	"discount", "shipping_fee", "tax", "total_amount", "promo_code", "notes",
+}
+
+// ListOrders handles GET /orders. It searches orders by the query parameters and returns a page
+// with the cursor of the next one.
+func (h *Handler) ListOrders(c *gin.Context) {
+	if c.Query("offset") != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset is not supported, pass the next_cursor of the previous page as cursor"})
+		return
+	}
+
+	req, err := bindOrderSearch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.service.SearchOrders(c.Request.Context(), req)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to search orders")
+		if isSearchError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list orders"})
+		return
+	}
+
+	limit := req.Limit
+	if limit == 0 {
+		limit = domain.DefaultOrderSearchLimit
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"orders": result.Orders,
+		"pagination": gin.H{
+			"limit":       limit,
+			"count":       len(result.Orders),
+			"next_cursor": result.NextCursor,
+		},
+	})
+}
+
+// ExportOrders handles GET /orders/export. It streams every order matching the same query
+// parameters as ListOrders as CSV.
+func (h *Handler) ExportOrders(c *gin.Context) {
+	req, err := bindOrderSearch(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	writer := csv.NewWriter(c.Writer)
+	started := false
+	count := 0
+
+	err = h.service.ExportOrders(c.Request.Context(), req, func(orders []*dto.OrderResponse) error {
+		if !started {
+			started = true
+			filename := fmt.Sprintf("orders-%s.csv", time.Now().UTC().Format("20060102-150405"))
+			c.Header("Content-Type", "text/csv; charset=utf-8")
+			c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			c.Status(http.StatusOK)
+			if err := writer.Write(orderExportColumns); err != nil {
+				return err
+			}
+		}
+
+		for _, order := range orders {
+			if err := writer.Write(orderExportRow(order)); err != nil {
+				return err
+			}
+		}
+		count += len(orders)
+
+		writer.Flush()
+		return writer.Error()
+	})
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to export orders")
+		if started {
+			// The response is already on its way, so the client sees a truncated file
+			c.Abort()
+			return
+		}
+		if isSearchError(err) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to export orders"})
+		return
+	}
+
+	if !started {
+		// Nothing matched; the export is just the header row
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		writer.Write(orderExportColumns)
+		writer.Flush()
+	}
+
+	h.logger.WithField("count", count).Info("Orders exported successfully")
+}
+
+// bindOrderSearch reads the search filters, sort and page from the query string. List filters
+// take comma-separated values or repeat the parameter.
+func bindOrderSearch(c *gin.Context) (*dto.SearchOrdersRequest, error) {
+	req := &dto.SearchOrdersRequest{
+		Query:  c.Query("q"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+
+	for _, status := range queryList(c, "status") {
+		req.Statuses = append(req.Statuses, domain.OrderStatus(status))
+	}
+	for _, source := range queryList(c, "source") {
+		req.Sources = append(req.Sources, domain.OrderSource(source))
+	}
+	for _, paidStatus := range queryList(c, "paid_status") {
+		req.PaidStatuses = append(req.PaidStatuses, domain.PaidStatus(paidStatus))
+	}
+	for _, method := range queryList(c, "payment_method") {
+		req.PaymentMethods = append(req.PaymentMethods, domain.PaymentMethod(method))
+	}
+
+	if customerIDStr := c.Query("customer_id"); customerIDStr != "" {
+		customerID, err := uuid.Parse(customerIDStr)
+		if err != nil {
+			return nil, errors.New("invalid customer_id")
+		}
+		req.CustomerID = &customerID
+	}
+
+	var err error
+	if req.CreatedFrom, err = queryTime(c, "created_from", false); err != nil {
+		return nil, err
+	}
+	if req.CreatedTo, err = queryTime(c, "created_to", true); err != nil {
+		return nil, err
+	}
+	if req.MinAmount, err = queryAmount(c, "min_amount"); err != nil {
+		return nil, err
+	}
+	if req.MaxAmount, err = queryAmount(c, "max_amount"); err != nil {
+		return nil, err
+	}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > domain.MaxOrderSearchLimit {
+			return nil, fmt.Errorf("invalid limit, use 1-%d", domain.MaxOrderSearchLimit)
+		}
+		req.Limit = limit
+	}
+
+	return req, nil
+}
+
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, param := range c.QueryArray(key) {
+		for _, value := range strings.Split(param, ",") {
+			if value = strings.TrimSpace(value); value != "" {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// queryTime parses a date (YYYY-MM-DD, UTC) or an RFC 3339 timestamp. A date used as the end of
+// a range includes the whole day.
+func queryTime(c *gin.Context, key string, endOfRange bool) (*time.Time, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		if endOfRange {
+			date = date.AddDate(0, 0, 1)
+		}
+		return &date, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	return nil, fmt.Errorf("invalid %s, use YYYY-MM-DD or RFC 3339", key)
+}
+
+func queryAmount(c *gin.Context, key string) (*float64, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+
+	amount, err := strconv.ParseFloat(value, 64)
+	if err != nil || amount < 0 {
+		return nil, fmt.Errorf("invalid %s", key)
+	}
+	return &amount, nil
+}
+
+func isSearchError(err error) bool {
+	return errors.Is(err, domain.ErrInvalidOrderSearch) || errors.Is(err, domain.ErrInvalidCursor)
+}
+
+func orderExportRow(order *dto.OrderResponse) []string {
+	code, promoCode, paymentMethod := "", "", ""
+	if order.Code != nil {
+		code = *order.Code
+	}
+	if order.PromoCode != nil {
+		promoCode = *order.PromoCode
+	}
+	if order.PaymentMethod != nil {
+		paymentMethod = string(*order.PaymentMethod)
+	}
+
+	return []string{
+		order.ID.String(),
+		code,
+		order.CreatedAt.UTC().Format(time.RFC3339),
+		order.CustomerID.String(),
+		string(order.Status),
+		string(order.Source),
+		string(order.PaidStatus),
+		paymentMethod,
+		formatAmount(order.Discount),
+		formatAmount(order.ShippingFee),
+		formatAmount(order.Tax),
+		formatAmount(order.TotalAmount),
+		csvText(promoCode),
+		csvText(order.Notes),
+	}
+}
+
+// csvText keeps spreadsheets from evaluating free text that starts like a formula
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func formatAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 2, 64)
+}
@@ -0,0 +1,62 @@
+package http
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"order/internal/domain"
+)
+
+func searchContext(query string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/api/v1/orders?"+query, nil)
+	return c
+}
+
+func TestBindOrderSearchReadsFilters(t *testing.T) {
+	c := searchContext("status=pending,confirmed&status=shipped&source=LINE&payment_method=qr_code" +
+		"&created_from=2025-06-01&created_to=2025-06-30&q=ORD2025&min_amount=100&max_amount=250.5" +
+		"&sort=-total_amount&limit=50")
+
+	req, err := bindOrderSearch(c)
+	require.NoError(t, err)
+
+	assert.Equal(t, []domain.OrderStatus{"pending", "confirmed", "shipped"}, req.Statuses)
+	assert.Equal(t, []domain.OrderSource{domain.OrderSourceLINE}, req.Sources)
+	assert.Equal(t, []domain.PaymentMethod{domain.PaymentMethodQRCode}, req.PaymentMethods)
+	assert.Equal(t, time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC), *req.CreatedFrom)
+	// A date as the end of the range includes that whole day
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), *req.CreatedTo)
+	assert.Equal(t, "ORD2025", req.Query)
+	assert.Equal(t, 100.0, *req.MinAmount)
+	assert.Equal(t, 250.5, *req.MaxAmount)
+	assert.Equal(t, "-total_amount", req.Sort)
+	assert.Equal(t, 50, req.Limit)
+}
+
+func TestBindOrderSearchRejectsMalformedParameters(t *testing.T) {
+	for _, query := range []string{
+		"customer_id=42",
+		"created_from=01/06/2025",
+		"min_amount=-1",
+		"max_amount=lots",
+		"limit=0",
+		"limit=101",
+	} {
+		_, err := bindOrderSearch(searchContext(query))
+		assert.Error(t, err, query)
+	}
+}
+
+func TestCSVTextNeutralizesFormulas(t *testing.T) {
+	assert.Equal(t, "'=HYPERLINK(\"x\")", csvText("=HYPERLINK(\"x\")"))
+	assert.Equal(t, "'-5", csvText("-5"))
+	assert.Equal(t, "Leave at the door", csvText("Leave at the door"))
+	assert.Equal(t, "", csvText(""))
+}
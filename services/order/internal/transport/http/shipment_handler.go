@@ -0,0 +1,154 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+	"order/internal/application/dto"
+	"order/internal/domain"
+)
+
+// CreateShipment handles POST /orders/:id/shipments
+func (h *Handler) CreateShipment(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	var req dto.CreateShipmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind JSON")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if err := h.validator.Struct(&req); err != nil {
+		h.logger.WithError(err).Error("Request validation failed")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Validation failed", "details": err.Error()})
+		return
+	}
+
+	shipment, err := h.service.CreateShipment(c.Request.Context(), id, &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to create shipment")
+		switch {
+		case err == domain.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case err == domain.ErrOrderItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order item not found"})
+		case err == domain.ErrOrderCannotBeShipped:
+			c.JSON(http.StatusConflict, gin.H{"error": "Order cannot be shipped in its current status"})
+		case err == domain.ErrShipmentExceedsOrder:
+			c.JSON(http.StatusConflict, gin.H{"error": "Shipment quantity exceeds the quantity left to ship"})
+		case err == domain.ErrOrderRevisionConflict:
+			c.JSON(http.StatusConflict, gin.H{"error": "Order was shipped by someone else, reload it and try again"})
+		case err == domain.ErrInvalidQuantity:
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, domain.ErrDeliveryNotCreated):
+			c.JSON(http.StatusBadGateway, gin.H{"error": "Delivery could not be created"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create shipment"})
+		}
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"order_id":    id,
+		"shipment_id": shipment.ID,
+		"status":      shipment.OrderStatus,
+	}).Info("Shipment created successfully")
+
+	c.JSON(http.StatusCreated, shipment)
+}
+
+// GetShipments handles GET /orders/:id/shipments
+func (h *Handler) GetShipments(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	shipments, err := h.service.GetShipments(c.Request.Context(), id)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", id).Error("Failed to get shipments")
+		if err == domain.ErrOrderNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get shipments"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"shipments": shipments})
+}
+
+// MarkShipmentDelivered handles POST /orders/:id/shipments/:shipment_id/deliver
+func (h *Handler) MarkShipmentDelivered(c *gin.Context) {
+	idStr := c.Param("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("id", idStr).Error("Invalid order ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return
+	}
+
+	shipmentIDStr := c.Param("shipment_id")
+	shipmentID, err := uuid.Parse(shipmentIDStr)
+	if err != nil {
+		h.logger.WithError(err).WithField("shipment_id", shipmentIDStr).Error("Invalid shipment ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shipment ID"})
+		return
+	}
+
+	shipment, err := h.service.MarkShipmentDelivered(c.Request.Context(), id, shipmentID)
+	if err != nil {
+		h.logger.WithError(err).WithField("shipment_id", shipmentID).Error("Failed to mark shipment delivered")
+		switch err {
+		case domain.ErrOrderNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		case domain.ErrShipmentNotFound, domain.ErrOrderItemNotFound:
+			c.JSON(http.StatusNotFound, gin.H{"error": "Shipment not found"})
+		case domain.ErrShipmentNotInTransit:
+			c.JSON(http.StatusConflict, gin.H{"error": "Shipment is not in transit"})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to mark shipment delivered"})
+		}
+		return
+	}
+
+	h.logger.WithFields(logrus.Fields{
+		"order_id":    id,
+		"shipment_id": shipmentID,
+		"status":      shipment.OrderStatus,
+	}).Info("Shipment delivered successfully")
+
+	c.JSON(http.StatusOK, shipment)
+}
+
+// GetBackorders handles GET /orders/backorders
+func (h *Handler) GetBackorders(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit parameter"})
+		return
+	}
+
+	backorders, err := h.service.GetBackorders(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.WithError(err).Error("Failed to get backorders")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get backorders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"backorders": backorders})
+}
@@ -238,9 +238,9 @@ func (h *StatsHandler) GetOverallStats(c *gin.Context) {
 	}
 	
 	startOfMonth := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
-	endOfMonth := startOfMonth.AddDate(0, 1, 0)
+	lastOfMonth := startOfMonth.AddDate(0, 1, -1)
 	topProductsReq.StartDate = &startOfMonth
-	topProductsReq.EndDate = &endOfMonth
+	topProductsReq.EndDate = &lastOfMonth
 
 	topProducts, err := h.statsService.GetTopProducts(c.Request.Context(), topProductsReq)
 	if err != nil {
@@ -0,0 +1,17 @@
+-- Migration: 004_outbox_relay.sql
+-- Description: Add retry scheduling and dead-letter support to the event outbox for the relay worker
+
+ALTER TABLE order_events_outbox
+ADD COLUMN next_attempt_at TIMESTAMP WITH TIME ZONE,
+ADD COLUMN last_error TEXT;
+
+-- Allow events to be parked once they exhausted their retries
+ALTER TABLE order_events_outbox DROP CONSTRAINT chk_event_status;
+ALTER TABLE order_events_outbox
+ADD CONSTRAINT chk_event_status CHECK (status IN ('pending', 'sent', 'failed', 'cancelled', 'dead_letter'));
+
+-- Supports the relay's claim queries
+CREATE INDEX idx_events_status_next_attempt ON order_events_outbox(status, next_attempt_at);
+
+COMMENT ON COLUMN order_events_outbox.next_attempt_at IS 'Earliest time a failed event may be retried (exponential backoff), or until when a relay has claimed the event';
+COMMENT ON COLUMN order_events_outbox.last_error IS 'Error returned by the most recent publish attempt';
@@ -0,0 +1,11 @@
+-- Migration: 005_order_revisions.sql
+-- Description: Track order revisions so pending and confirmed orders can be edited
+
+ALTER TABLE orders
+ADD COLUMN revision INTEGER NOT NULL DEFAULT 1;
+
+COMMENT ON COLUMN orders.revision IS 'Incremented by every edit; the diff of each revision is kept in order_audit_logs';
+
+-- The audit repository writes to order_audit_logs. Revisions are stored in the same
+-- transaction as the edit, so the table must exist under that name.
+ALTER TABLE IF EXISTS order_audit_log RENAME TO order_audit_logs;
@@ -0,0 +1,54 @@
+-- Migration: 006_order_fulfilment.sql
+-- Description: Fulfil orders in several shipments, tracking shipped, delivered and backordered quantities per item
+
+ALTER TABLE order_items
+ADD COLUMN shipped_quantity INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN fulfilled_quantity INTEGER NOT NULL DEFAULT 0,
+ADD COLUMN backordered_quantity INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+ADD CONSTRAINT chk_order_items_fulfilment CHECK (
+    shipped_quantity >= 0 AND shipped_quantity <= quantity
+    AND fulfilled_quantity >= 0 AND fulfilled_quantity <= shipped_quantity
+    AND backordered_quantity >= 0 AND backordered_quantity <= quantity - shipped_quantity
+);
+
+-- Supports the backorder listing
+CREATE INDEX idx_order_items_backordered ON order_items(created_at) WHERE backordered_quantity > 0;
+
+COMMENT ON COLUMN order_items.shipped_quantity IS 'Quantity that has left in shipments';
+COMMENT ON COLUMN order_items.fulfilled_quantity IS 'Quantity delivered to the customer';
+COMMENT ON COLUMN order_items.backordered_quantity IS 'Quantity waiting for stock while the rest of the order ships';
+
+-- Partial statuses are derived from item quantities
+ALTER TABLE orders DROP CONSTRAINT chk_orders_status;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_status
+    CHECK (status IN ('pending', 'confirmed', 'processing', 'partially_shipped', 'shipped',
+                      'partially_delivered', 'delivered', 'completed', 'cancelled', 'refunded'));
+
+-- Each shipment is a delivery order in the shipping service
+CREATE TABLE IF NOT EXISTS order_shipments (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    delivery_id UUID,
+    tracking_number VARCHAR(100),
+    carrier VARCHAR(100),
+    status VARCHAR(20) NOT NULL DEFAULT 'in_transit',
+    shipped_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_shipment_status CHECK (status IN ('in_transit', 'delivered'))
+);
+
+CREATE INDEX idx_order_shipments_order_id ON order_shipments(order_id);
+
+CREATE TABLE IF NOT EXISTS order_shipment_items (
+    id UUID PRIMARY KEY,
+    shipment_id UUID NOT NULL REFERENCES order_shipments(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id),
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0)
+);
+
+CREATE INDEX idx_order_shipment_items_shipment_id ON order_shipment_items(shipment_id);
@@ -0,0 +1,50 @@
+-- Migration: 007_order_returns.sql
+-- Description: Return delivered items of an order for a refund, with pickup, restock decision and refund tracking
+
+ALTER TABLE order_items
+ADD COLUMN returned_quantity INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE order_items
+ADD CONSTRAINT chk_order_items_returned CHECK (
+    returned_quantity >= 0 AND returned_quantity <= fulfilled_quantity
+);
+
+COMMENT ON COLUMN order_items.returned_quantity IS 'Delivered quantity that is being returned or was returned';
+
+-- A return request moves from requested to approved (pickup booked), received and refunded, or is rejected
+CREATE TABLE IF NOT EXISTS order_returns (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'requested',
+    notes TEXT NOT NULL DEFAULT '',
+    refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    pickup_delivery_id UUID,
+    pickup_tracking_number VARCHAR(100),
+    rejection_reason TEXT,
+    payment_transaction_id UUID,
+    points_clawed_back INTEGER NOT NULL DEFAULT 0,
+    approved_at TIMESTAMP WITH TIME ZONE,
+    received_at TIMESTAMP WITH TIME ZONE,
+    refunded_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_return_status CHECK (status IN ('requested', 'approved', 'rejected', 'received', 'refunded'))
+);
+
+CREATE INDEX idx_order_returns_order_id ON order_returns(order_id);
+
+CREATE TABLE IF NOT EXISTS order_return_items (
+    id UUID PRIMARY KEY,
+    return_id UUID NOT NULL REFERENCES order_returns(id) ON DELETE CASCADE,
+    order_item_id UUID NOT NULL REFERENCES order_items(id),
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    reason VARCHAR(30) NOT NULL,
+    refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    restock BOOLEAN,
+    CONSTRAINT chk_return_item_reason CHECK (reason IN ('damaged', 'defective', 'wrong_item',
+                                                         'not_as_described', 'changed_mind', 'other'))
+);
+
+CREATE INDEX idx_order_return_items_return_id ON order_return_items(return_id);
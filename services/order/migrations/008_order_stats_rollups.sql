@@ -0,0 +1,143 @@
+-- Migration: 008_order_stats_rollups.sql
+-- Description: Daily order statistics rollups maintained by triggers, so the stats endpoints no longer scan every order
+-- Days and hours are in UTC. Rebuild the rollups of existing orders with the stats-backfill command.
+
+-- Orders placed in an hour of a day, by source, status and payment method
+CREATE TABLE IF NOT EXISTS order_stats_daily (
+    stat_date DATE NOT NULL,
+    hour SMALLINT NOT NULL CHECK (hour BETWEEN 0 AND 23),
+    source VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    payment_method VARCHAR(50) NOT NULL DEFAULT '',
+    orders_count INTEGER NOT NULL DEFAULT 0,
+    revenue DECIMAL(14,2) NOT NULL DEFAULT 0,
+    discount DECIMAL(14,2) NOT NULL DEFAULT 0,
+    PRIMARY KEY (stat_date, hour, source, status, payment_method)
+);
+
+-- Items ordered per product and day, by the source and status of their order
+CREATE TABLE IF NOT EXISTS order_product_stats_daily (
+    stat_date DATE NOT NULL,
+    product_id UUID NOT NULL,
+    source VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    order_count INTEGER NOT NULL DEFAULT 0,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    revenue DECIMAL(14,2) NOT NULL DEFAULT 0,
+    last_order_at TIMESTAMP WITH TIME ZONE,
+    PRIMARY KEY (stat_date, product_id, source, status)
+);
+
+CREATE INDEX idx_order_product_stats_daily_product ON order_product_stats_daily(product_id, stat_date);
+
+COMMENT ON COLUMN order_stats_daily.payment_method IS 'Payment method of the orders, empty when none was chosen';
+COMMENT ON COLUMN order_product_stats_daily.last_order_at IS 'Latest order that added to the row';
+
+-- Adds (delta = 1) or removes (delta = -1) an order from the order rollup
+CREATE OR REPLACE FUNCTION order_stats_apply(o orders, delta INTEGER) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO order_stats_daily (stat_date, hour, source, status, payment_method, orders_count, revenue, discount)
+    VALUES ((o.created_at AT TIME ZONE 'UTC')::DATE, EXTRACT(HOUR FROM o.created_at AT TIME ZONE 'UTC'),
+            COALESCE(o.source, 'online'), o.status, COALESCE(o.payment_method, ''),
+            delta, delta * o.total_amount, delta * COALESCE(o.discount, 0))
+    ON CONFLICT (stat_date, hour, source, status, payment_method) DO UPDATE SET
+        orders_count = order_stats_daily.orders_count + EXCLUDED.orders_count,
+        revenue = order_stats_daily.revenue + EXCLUDED.revenue,
+        discount = order_stats_daily.discount + EXCLUDED.discount;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Adds (delta = 1) or removes (delta = -1) an order item from the product rollup
+CREATE OR REPLACE FUNCTION order_product_stats_apply(o orders, i order_items, delta INTEGER) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO order_product_stats_daily (stat_date, product_id, source, status, order_count, quantity, revenue, last_order_at)
+    VALUES ((o.created_at AT TIME ZONE 'UTC')::DATE, i.product_id, COALESCE(o.source, 'online'), o.status,
+            delta, delta * i.quantity, delta * i.total_price, o.created_at)
+    ON CONFLICT (stat_date, product_id, source, status) DO UPDATE SET
+        order_count = order_product_stats_daily.order_count + EXCLUDED.order_count,
+        quantity = order_product_stats_daily.quantity + EXCLUDED.quantity,
+        revenue = order_product_stats_daily.revenue + EXCLUDED.revenue,
+        last_order_at = GREATEST(order_product_stats_daily.last_order_at, EXCLUDED.last_order_at);
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION order_stats_on_order_change() RETURNS TRIGGER AS $$
+DECLARE
+    item order_items;
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM order_stats_apply(NEW, 1);
+        RETURN NULL;
+    END IF;
+
+    IF (OLD.created_at, OLD.source, OLD.status, OLD.payment_method, OLD.total_amount, OLD.discount)
+       IS NOT DISTINCT FROM
+       (NEW.created_at, NEW.source, NEW.status, NEW.payment_method, NEW.total_amount, NEW.discount) THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM order_stats_apply(OLD, -1);
+    PERFORM order_stats_apply(NEW, 1);
+
+    -- Product rows are keyed by the order's day, source and status, so its items move along
+    IF (OLD.created_at, OLD.source, OLD.status) IS DISTINCT FROM (NEW.created_at, NEW.source, NEW.status) THEN
+        FOR item IN SELECT * FROM order_items WHERE order_id = NEW.id LOOP
+            PERFORM order_product_stats_apply(OLD, item, -1);
+            PERFORM order_product_stats_apply(NEW, item, 1);
+        END LOOP;
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Runs before the delete cascades to the items, while they can still be taken out of the rollup
+CREATE OR REPLACE FUNCTION order_stats_on_order_delete() RETURNS TRIGGER AS $$
+DECLARE
+    item order_items;
+BEGIN
+    PERFORM order_stats_apply(OLD, -1);
+    FOR item IN SELECT * FROM order_items WHERE order_id = OLD.id LOOP
+        PERFORM order_product_stats_apply(OLD, item, -1);
+    END LOOP;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION order_stats_on_item_change() RETURNS TRIGGER AS $$
+DECLARE
+    o orders;
+BEGIN
+    IF TG_OP = 'UPDATE' AND (OLD.product_id, OLD.quantity, OLD.total_price)
+                            IS NOT DISTINCT FROM (NEW.product_id, NEW.quantity, NEW.total_price) THEN
+        RETURN NULL;
+    END IF;
+
+    SELECT * INTO o FROM orders WHERE id = COALESCE(NEW.order_id, OLD.order_id);
+    IF NOT FOUND THEN
+        -- The order is being deleted and has taken its items out already
+        RETURN NULL;
+    END IF;
+
+    IF TG_OP IN ('UPDATE', 'DELETE') THEN
+        PERFORM order_product_stats_apply(o, OLD, -1);
+    END IF;
+    IF TG_OP IN ('INSERT', 'UPDATE') THEN
+        PERFORM order_product_stats_apply(o, NEW, 1);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER trg_orders_stats
+    AFTER INSERT OR UPDATE ON orders
+    FOR EACH ROW EXECUTE FUNCTION order_stats_on_order_change();
+
+CREATE TRIGGER trg_orders_stats_delete
+    BEFORE DELETE ON orders
+    FOR EACH ROW EXECUTE FUNCTION order_stats_on_order_delete();
+
+CREATE TRIGGER trg_order_items_stats
+    AFTER INSERT OR UPDATE OR DELETE ON order_items
+    FOR EACH ROW EXECUTE FUNCTION order_stats_on_item_change();
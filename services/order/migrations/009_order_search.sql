@@ -0,0 +1,12 @@
+-- Migration: 009_order_search.sql
+-- Description: Indexes for searching orders with keyset pagination and free-text code and notes search
+
+-- Keyset pagination reads (sort column, id) in index order
+CREATE INDEX IF NOT EXISTS idx_orders_created_at_id ON orders(created_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_updated_at_id ON orders(updated_at, id);
+CREATE INDEX IF NOT EXISTS idx_orders_total_amount_id ON orders(total_amount, id);
+
+-- Trigram indexes serve ILIKE '%text%' on the code and notes
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS idx_orders_code_trgm ON orders USING GIN (code gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_orders_notes_trgm ON orders USING GIN (notes gin_trgm_ops);
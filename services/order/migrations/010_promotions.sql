@@ -0,0 +1,65 @@
+-- Migration: 010_promotions.sql
+-- Description: Promo codes with rewards, validity windows, usage limits, product and category scopes, and their redemptions by orders
+
+CREATE TABLE IF NOT EXISTS promotions (
+    id UUID PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    reward_type VARCHAR(20) NOT NULL,
+    value DECIMAL(10,2) NOT NULL DEFAULT 0,
+    max_discount DECIMAL(10,2),
+    min_spend DECIMAL(10,2) NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP WITH TIME ZONE,
+    ends_at TIMESTAMP WITH TIME ZONE,
+    usage_limit INTEGER,
+    per_customer_limit INTEGER,
+    used_count INTEGER NOT NULL DEFAULT 0,
+    min_tier INTEGER NOT NULL DEFAULT 0,
+    stackable BOOLEAN NOT NULL DEFAULT FALSE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_promotion_reward_type CHECK (reward_type IN ('percent', 'fixed', 'free_shipping')),
+    CONSTRAINT chk_promotion_min_tier CHECK (min_tier BETWEEN 0 AND 5),
+    -- The guard that keeps redemptions within the limit
+    CONSTRAINT chk_promotion_used_count CHECK (
+        used_count >= 0 AND (usage_limit IS NULL OR used_count <= usage_limit)
+    )
+);
+
+COMMENT ON COLUMN promotions.value IS 'Percentage for percent rewards, amount for fixed rewards';
+COMMENT ON COLUMN promotions.used_count IS 'Redemptions by orders that were not cancelled';
+COMMENT ON COLUMN promotions.min_tier IS 'Lowest customer tier (1 Bronze - 5 Diamond) that can use the code, 0 for everyone';
+
+-- A promotion with scopes only discounts the matching products and categories
+CREATE TABLE IF NOT EXISTS promotion_products (
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL,
+    PRIMARY KEY (promotion_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS promotion_categories (
+    promotion_id UUID NOT NULL REFERENCES promotions(id) ON DELETE CASCADE,
+    category_id UUID NOT NULL,
+    PRIMARY KEY (promotion_id, category_id)
+);
+
+CREATE TABLE IF NOT EXISTS promotion_redemptions (
+    id UUID PRIMARY KEY,
+    promotion_id UUID NOT NULL REFERENCES promotions(id),
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    customer_id UUID NOT NULL,
+    code VARCHAR(20) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    released_at TIMESTAMP WITH TIME ZONE,
+    CONSTRAINT uq_promotion_redemption_order UNIQUE (promotion_id, order_id)
+);
+
+CREATE INDEX idx_promotion_redemptions_customer ON promotion_redemptions(promotion_id, customer_id)
+    WHERE released_at IS NULL;
+CREATE INDEX idx_promotion_redemptions_order_id ON promotion_redemptions(order_id);
+
+-- Several stacked codes are stored comma separated
+ALTER TABLE orders ALTER COLUMN promo_code TYPE VARCHAR(100);
+COMMENT ON COLUMN orders.promo_code IS 'Promo codes applied to the order, comma separated';
@@ -0,0 +1,75 @@
+-- Migration: 011_tax_invoices.sql
+-- Description: VAT modes and exemptions on orders, and tax invoices, receipts and credit notes numbered per branch
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_mode VARCHAR(20) NOT NULL DEFAULT 'exclusive';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS vat_rate DECIMAL(5,4) NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD CONSTRAINT chk_orders_tax_mode CHECK (tax_mode IN ('exclusive', 'inclusive'));
+
+-- Copied from the product when the item is ordered
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS vat_exempt BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Document numbers run without gaps per branch, document type and year. The row is locked by
+-- the transaction that takes a number until it commits, so a rolled back invoice gives its
+-- number back.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    branch_code VARCHAR(5) NOT NULL,
+    invoice_type VARCHAR(20) NOT NULL,
+    year INTEGER NOT NULL,
+    last_number BIGINT NOT NULL,
+    PRIMARY KEY (branch_code, invoice_type, year)
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id),
+    return_id UUID REFERENCES order_returns(id),
+    invoice_type VARCHAR(20) NOT NULL,
+    number VARCHAR(40) NOT NULL UNIQUE,
+    sequence BIGINT NOT NULL,
+    branch_code VARCHAR(5) NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    seller_name TEXT NOT NULL,
+    seller_tax_id VARCHAR(13) NOT NULL,
+    seller_branch_code VARCHAR(5) NOT NULL,
+    seller_address TEXT NOT NULL,
+    buyer_name TEXT NOT NULL DEFAULT '',
+    buyer_tax_id VARCHAR(13) NOT NULL DEFAULT '',
+    buyer_branch_code VARCHAR(5) NOT NULL DEFAULT '',
+    buyer_address TEXT NOT NULL DEFAULT '',
+    tax_mode VARCHAR(20) NOT NULL,
+    vat_rate DECIMAL(5,4) NOT NULL,
+    subtotal DECIMAL(10,2) NOT NULL,
+    discount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    taxable_amount DECIMAL(10,2) NOT NULL,
+    exempt_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    vat DECIMAL(10,2) NOT NULL,
+    total DECIMAL(10,2) NOT NULL,
+    reference_invoice_id UUID REFERENCES invoices(id),
+    original_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    corrected_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    purpose_code VARCHAR(10) NOT NULL DEFAULT '',
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_invoice_type CHECK (invoice_type IN ('tax_invoice', 'receipt', 'credit_note')),
+    CONSTRAINT chk_invoice_credit_note CHECK (
+        (invoice_type = 'credit_note') = (reference_invoice_id IS NOT NULL AND return_id IS NOT NULL)
+    )
+);
+
+CREATE TABLE IF NOT EXISTS invoice_lines (
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    line_no INTEGER NOT NULL,
+    product_id UUID,
+    description TEXT NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL,
+    amount DECIMAL(10,2) NOT NULL,
+    vat_exempt BOOLEAN NOT NULL DEFAULT FALSE,
+    PRIMARY KEY (invoice_id, line_no)
+);
+
+-- One tax invoice and one receipt per order, one credit note per refunded return
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_order_type ON invoices(order_id, invoice_type)
+    WHERE invoice_type <> 'credit_note';
+CREATE UNIQUE INDEX IF NOT EXISTS idx_invoices_return ON invoices(return_id) WHERE return_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_invoices_reference ON invoices(reference_invoice_id) WHERE reference_invoice_id IS NOT NULL;
@@ -0,0 +1,55 @@
+-- Migration: 012_recurring_orders.sql
+-- Description: Recurring orders that generate orders on a cron schedule, and a run per occurrence
+
+CREATE TABLE IF NOT EXISTS recurring_orders (
+    id UUID PRIMARY KEY,
+    customer_id UUID NOT NULL,
+    chat_id VARCHAR(255) NOT NULL DEFAULT '',
+    source VARCHAR(20) NOT NULL DEFAULT 'online',
+    schedule VARCHAR(100) NOT NULL,
+    shipping_address TEXT NOT NULL,
+    billing_address TEXT NOT NULL,
+    shipping_fee DECIMAL(10,2) NOT NULL DEFAULT 0,
+    notes TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    next_run_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    paused_until TIMESTAMP WITH TIME ZONE,
+    -- Items, shipping address and notes of the next order only
+    next_override JSONB,
+    reminder_sent_for TIMESTAMP WITH TIME ZONE,
+    revision INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_recurring_order_status CHECK (status IN ('active', 'paused', 'cancelled')),
+    CONSTRAINT chk_recurring_order_shipping_fee CHECK (shipping_fee >= 0)
+);
+
+CREATE TABLE IF NOT EXISTS recurring_order_items (
+    recurring_order_id UUID NOT NULL REFERENCES recurring_orders(id) ON DELETE CASCADE,
+    line_no INTEGER NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INTEGER NOT NULL,
+    unit_price DECIMAL(10,2) NOT NULL,
+    PRIMARY KEY (recurring_order_id, line_no),
+    CONSTRAINT chk_recurring_order_item_quantity CHECK (quantity > 0),
+    CONSTRAINT chk_recurring_order_item_price CHECK (unit_price >= 0)
+);
+
+-- One run per occurrence: a claimed or skipped occurrence is never ordered again
+CREATE TABLE IF NOT EXISTS recurring_order_runs (
+    id UUID PRIMARY KEY,
+    recurring_order_id UUID NOT NULL REFERENCES recurring_orders(id) ON DELETE CASCADE,
+    scheduled_for TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    order_id UUID REFERENCES orders(id),
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    CONSTRAINT chk_recurring_order_run_status CHECK (status IN ('pending', 'created', 'failed', 'skipped')),
+    CONSTRAINT uq_recurring_order_run UNIQUE (recurring_order_id, scheduled_for)
+);
+
+CREATE INDEX IF NOT EXISTS idx_recurring_orders_customer ON recurring_orders(customer_id);
+CREATE INDEX IF NOT EXISTS idx_recurring_orders_next_run ON recurring_orders(next_run_at) WHERE status = 'active';
+CREATE INDEX IF NOT EXISTS idx_recurring_orders_paused ON recurring_orders(paused_until) WHERE status = 'paused';
+CREATE INDEX IF NOT EXISTS idx_recurring_order_runs_recent ON recurring_order_runs(recurring_order_id, scheduled_for DESC);
@@ -0,0 +1,9 @@
+-- Migration: 013_audit_access_denied.sql
+-- Description: Keep denied requests in the audit log, including ones not about an existing order
+
+ALTER TABLE order_audit_logs
+ALTER COLUMN order_id DROP NOT NULL;
+
+COMMENT ON COLUMN order_audit_logs.order_id IS 'Order the entry is about; NULL for denied requests that did not name an existing order';
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_access_denied ON order_audit_logs(timestamp DESC) WHERE action = 'ACCESS_DENIED';
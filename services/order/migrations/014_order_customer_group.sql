@@ -0,0 +1,7 @@
+-- Migration: 014_order_customer_group.sql
+-- Description: Remember the customer group an order is priced for, so items added by an edit get the same pricing
+
+ALTER TABLE orders
+ADD COLUMN IF NOT EXISTS customer_group VARCHAR(50) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN orders.customer_group IS 'Pricing group of the product service the items are priced for; empty for regular pricing';
@@ -0,0 +1,10 @@
+-- Migration: 015_order_item_price_overrides.sql
+-- Description: Record item prices set by staff instead of the product service and the manager who approved selling below cost or the minimum margin
+
+ALTER TABLE order_items
+ADD COLUMN IF NOT EXISTS price_overridden BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN IF NOT EXISTS price_override_reason TEXT,
+ADD COLUMN IF NOT EXISTS margin_approved_by VARCHAR(100);
+
+COMMENT ON COLUMN order_items.price_overridden IS 'Unit price was set by staff instead of priced by the product service';
+COMMENT ON COLUMN order_items.margin_approved_by IS 'Manager who approved selling the item below cost or the minimum margin';
@@ -0,0 +1,164 @@
+-- Migration: 016_order_stats_thai_time.sql
+-- Description: Bucket the statistics rollups by Thai day and hour and count each order once per product in the product rollup
+-- Rollups written before this migration are keyed by UTC days. Rebuild them with the stats-backfill command.
+
+-- Adds (delta = 1) or removes (delta = -1) an order from the order rollup
+CREATE OR REPLACE FUNCTION order_stats_apply(o orders, delta INTEGER) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO order_stats_daily (stat_date, hour, source, status, payment_method, orders_count, revenue, discount)
+    VALUES ((o.created_at AT TIME ZONE 'Asia/Bangkok')::DATE, EXTRACT(HOUR FROM o.created_at AT TIME ZONE 'Asia/Bangkok'),
+            COALESCE(o.source, 'online'), o.status, COALESCE(o.payment_method, ''),
+            delta, delta * o.total_amount, delta * COALESCE(o.discount, 0))
+    ON CONFLICT (stat_date, hour, source, status, payment_method) DO UPDATE SET
+        orders_count = order_stats_daily.orders_count + EXCLUDED.orders_count,
+        revenue = order_stats_daily.revenue + EXCLUDED.revenue,
+        discount = order_stats_daily.discount + EXCLUDED.discount;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Adds the change of an order's items of one product to the product rollup. orders_delta is 1
+-- when the order gained its first item of the product and -1 when it lost its last one.
+CREATE OR REPLACE FUNCTION order_product_stats_apply(o orders, product UUID, orders_delta INTEGER,
+                                                     quantity_delta INTEGER, revenue_delta DECIMAL) RETURNS VOID AS $$
+BEGIN
+    INSERT INTO order_product_stats_daily (stat_date, product_id, source, status, order_count, quantity, revenue, last_order_at)
+    VALUES ((o.created_at AT TIME ZONE 'Asia/Bangkok')::DATE, product, COALESCE(o.source, 'online'), o.status,
+            orders_delta, quantity_delta, revenue_delta, o.created_at)
+    ON CONFLICT (stat_date, product_id, source, status) DO UPDATE SET
+        order_count = order_product_stats_daily.order_count + EXCLUDED.order_count,
+        quantity = order_product_stats_daily.quantity + EXCLUDED.quantity,
+        revenue = order_product_stats_daily.revenue + EXCLUDED.revenue,
+        last_order_at = GREATEST(order_product_stats_daily.last_order_at, EXCLUDED.last_order_at);
+END;
+$$ LANGUAGE plpgsql;
+
+-- Adds (delta = 1) or removes (delta = -1) all items of an order from the product rollup
+CREATE OR REPLACE FUNCTION order_product_stats_apply_order(o orders, delta INTEGER) RETURNS VOID AS $$
+DECLARE
+    line RECORD;
+BEGIN
+    FOR line IN
+        SELECT product_id, SUM(quantity)::INTEGER AS quantity, SUM(total_price) AS revenue
+        FROM order_items
+        WHERE order_id = o.id
+        GROUP BY product_id
+    LOOP
+        PERFORM order_product_stats_apply(o, line.product_id, delta, delta * line.quantity, delta * line.revenue);
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Applies the items a statement added and removed. The items are grouped by order and product,
+-- and whether the order still has items of the product afterwards decides its order count.
+CREATE OR REPLACE FUNCTION order_product_stats_apply_items(added order_items[], removed order_items[]) RETURNS VOID AS $$
+DECLARE
+    change RECORD;
+    o orders;
+    remaining INTEGER;
+BEGIN
+    FOR change IN
+        SELECT c.order_id, c.product_id, SUM(c.delta)::INTEGER AS items_delta,
+               SUM(c.delta * c.quantity)::INTEGER AS quantity, SUM(c.delta * c.total_price) AS revenue
+        FROM (
+            SELECT a.order_id, a.product_id, a.quantity, a.total_price, 1 AS delta FROM unnest(added) a
+            UNION ALL
+            SELECT r.order_id, r.product_id, r.quantity, r.total_price, -1 AS delta FROM unnest(removed) r
+        ) c
+        GROUP BY c.order_id, c.product_id
+    LOOP
+        SELECT * INTO o FROM orders WHERE id = change.order_id;
+        IF NOT FOUND THEN
+            -- The order is being deleted and has taken its items out already
+            CONTINUE;
+        END IF;
+
+        SELECT COUNT(*) INTO remaining FROM order_items
+        WHERE order_id = change.order_id AND product_id = change.product_id;
+
+        IF change.items_delta <> 0 OR change.quantity <> 0 OR change.revenue <> 0 THEN
+            PERFORM order_product_stats_apply(o, change.product_id,
+                (remaining > 0)::INTEGER - (remaining - change.items_delta > 0)::INTEGER,
+                change.quantity, change.revenue);
+        END IF;
+    END LOOP;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION order_stats_on_order_change() RETURNS TRIGGER AS $$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM order_stats_apply(NEW, 1);
+        RETURN NULL;
+    END IF;
+
+    IF (OLD.created_at, OLD.source, OLD.status, OLD.payment_method, OLD.total_amount, OLD.discount)
+       IS NOT DISTINCT FROM
+       (NEW.created_at, NEW.source, NEW.status, NEW.payment_method, NEW.total_amount, NEW.discount) THEN
+        RETURN NULL;
+    END IF;
+
+    PERFORM order_stats_apply(OLD, -1);
+    PERFORM order_stats_apply(NEW, 1);
+
+    -- Product rows are keyed by the order's day, source and status, so its items move along
+    IF (OLD.created_at, OLD.source, OLD.status) IS DISTINCT FROM (NEW.created_at, NEW.source, NEW.status) THEN
+        PERFORM order_product_stats_apply_order(OLD, -1);
+        PERFORM order_product_stats_apply_order(NEW, 1);
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Runs before the delete cascades to the items, while they can still be taken out of the rollup
+CREATE OR REPLACE FUNCTION order_stats_on_order_delete() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM order_stats_apply(OLD, -1);
+    PERFORM order_product_stats_apply_order(OLD, -1);
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Item triggers run once per statement, so an order whose items of a product are inserted or
+-- deleted together is counted once
+CREATE OR REPLACE FUNCTION order_stats_on_items_insert() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM order_product_stats_apply_items(ARRAY(SELECT n FROM new_items n), ARRAY[]::order_items[]);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION order_stats_on_items_update() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM order_product_stats_apply_items(ARRAY(SELECT n FROM new_items n), ARRAY(SELECT o FROM old_items o));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION order_stats_on_items_delete() RETURNS TRIGGER AS $$
+BEGIN
+    PERFORM order_product_stats_apply_items(ARRAY[]::order_items[], ARRAY(SELECT o FROM old_items o));
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_order_items_stats ON order_items;
+DROP FUNCTION IF EXISTS order_stats_on_item_change();
+DROP FUNCTION IF EXISTS order_product_stats_apply(orders, order_items, INTEGER);
+
+CREATE TRIGGER trg_order_items_stats_insert
+    AFTER INSERT ON order_items
+    REFERENCING NEW TABLE AS new_items
+    FOR EACH STATEMENT EXECUTE FUNCTION order_stats_on_items_insert();
+
+CREATE TRIGGER trg_order_items_stats_update
+    AFTER UPDATE ON order_items
+    REFERENCING OLD TABLE AS old_items NEW TABLE AS new_items
+    FOR EACH STATEMENT EXECUTE FUNCTION order_stats_on_items_update();
+
+CREATE TRIGGER trg_order_items_stats_delete
+    AFTER DELETE ON order_items
+    REFERENCING OLD TABLE AS old_items
+    FOR EACH STATEMENT EXECUTE FUNCTION order_stats_on_items_delete();
+
+COMMENT ON COLUMN order_product_stats_daily.order_count IS 'Orders with items of the product, each counted once';
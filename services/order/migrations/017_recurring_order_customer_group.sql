@@ -0,0 +1,7 @@
+-- Migration: 017_recurring_order_customer_group.sql
+-- Description: Remember the customer group a recurring order is priced for, so its orders and reminders get the group's prices
+
+ALTER TABLE recurring_orders
+ADD COLUMN IF NOT EXISTS customer_group VARCHAR(50) NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN recurring_orders.customer_group IS 'Pricing group of the product service the orders are priced for; empty for regular pricing';
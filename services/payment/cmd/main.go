@@ -17,6 +17,7 @@ import (
 
 	"payment/internal/application/usecase"
 	"payment/internal/infrastructure/config"
+	kafkaInfra "payment/internal/infrastructure/kafka"
 	repoImpl "payment/internal/infrastructure/repository"
 	"payment/internal/transport/http/handler"
 )
@@ -46,13 +47,17 @@ func main() {
 
 	// Initialize repositories
 	paymentRepo := repoImpl.NewPostgresPaymentRepository(db)
+
+	// Initialize event publisher
+	eventPublisher := kafkaInfra.NewEventPublisher(cfg.Kafka.Brokers, cfg.Kafka.Topics.PaymentEvents, logger)
+	defer eventPublisher.Close()
 	
 	// Initialize use cases
 	paymentUseCase := usecase.NewPaymentUseCase(
 		paymentRepo,
 		nil, // loyverseStoreRepo - to be implemented
 		nil, // deliveryContextRepo - to be implemented
-		nil, // eventRepo - to be implemented
+		eventPublisher,
 		logger,
 	)
 
@@ -85,6 +90,17 @@ func main() {
 		orderPaymentHandler,
 	)
 
+	// Start gateway event consumer
+	consumerCtx, stopConsumer := context.WithCancel(context.Background())
+	gatewayConsumer := kafkaInfra.NewGatewayConsumer(
+		cfg.Kafka.Brokers,
+		cfg.Kafka.Topics.GatewayEvents,
+		cfg.Kafka.GroupID,
+		paymentUseCase,
+		logger,
+	)
+	go gatewayConsumer.Start(consumerCtx)
+
 	// Start server
 	go func() {
 		logger.WithField("port", cfg.Server.Port).Info("Starting Payment Service")
@@ -100,6 +116,11 @@ func main() {
 
 	logger.Info("Shutting down Payment Service...")
 
+	stopConsumer()
+	if err := gatewayConsumer.Close(); err != nil {
+		logger.WithError(err).Error("Failed to close gateway event consumer")
+	}
+
 	// Give outstanding requests 30 seconds to complete
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -112,7 +133,7 @@ func main() {
 }
 
 func initDatabase(cfg *config.Config, logger *logrus.Logger) (*sqlx.DB, error) {
-	dsn := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
+	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
 		cfg.Database.Host,
 		cfg.Database.Port,
 		cfg.Database.User,
@@ -142,7 +163,7 @@ func initDatabase(cfg *config.Config, logger *logrus.Logger) (*sqlx.DB, error) {
 
 func initRedis(cfg *config.Config, logger *logrus.Logger) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%d", cfg.Redis.Host, cfg.Redis.Port),
+		Addr:     fmt.Sprintf("%s:%s", cfg.Redis.Host, cfg.Redis.Port),
 		Password: cfg.Redis.Password,
 		DB:       cfg.Redis.DB,
 	})
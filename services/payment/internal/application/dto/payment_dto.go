@@ -40,6 +40,36 @@ type UpdatePaymentStatusRequest struct {
 	Metadata         map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// CreateRefundRequest represents a request to refund part or all of what was paid for an order.
+// Retrying with the same reference returns the original refund.
+type CreateRefundRequest struct {
+	OrderID    uuid.UUID `json:"order_id" binding:"required"`
+	CustomerID uuid.UUID `json:"customer_id" binding:"required"`
+	Reference  string    `json:"reference" binding:"required,max=100"`
+	Amount     float64   `json:"amount" binding:"required,gt=0"`
+	Currency   string    `json:"currency" binding:"required,len=3"`
+	Reason     string    `json:"reason,omitempty"`
+}
+
+// GatewayPaymentEvent represents a normalized payment gateway webhook published by payment-webhook
+type GatewayPaymentEvent struct {
+	EventID        string               `json:"event_id"`
+	EventType      string               `json:"event_type"`
+	Gateway        string               `json:"gateway"`
+	GatewayEventID string               `json:"gateway_event_id,omitempty"`
+	ChargeID       string               `json:"charge_id"`
+	RefundID       string               `json:"refund_id,omitempty"`
+	PaymentID      string               `json:"payment_id,omitempty"`
+	Status         entity.PaymentStatus `json:"status"`
+	GatewayStatus  string               `json:"gateway_status"`
+	Amount         float64              `json:"amount"`
+	Currency       string               `json:"currency"`
+	FailureCode    string               `json:"failure_code,omitempty"`
+	FailureMessage string               `json:"failure_message,omitempty"`
+	OccurredAt     time.Time            `json:"occurred_at"`
+	ReceivedAt     time.Time            `json:"received_at"`
+}
+
 // PaymentFiltersRequest represents filters for payment queries
 type PaymentFiltersRequest struct {
 	Status         *entity.PaymentStatus  `json:"status,omitempty"`
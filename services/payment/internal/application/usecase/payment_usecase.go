@@ -2,7 +2,11 @@ package usecase
 
 import (
 	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"math"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -209,6 +213,217 @@ func (uc *PaymentUseCase) UpdatePaymentStatus(ctx context.Context, paymentID uui
 	return uc.mapToPaymentResponse(ctx, payment), nil
 }
 
+// ApplyGatewayEvent applies a status change reported by a payment gateway webhook. It returns
+// false without an error when the payment already has the reported status, so redelivered
+// events are harmless. Events that arrive after a later status fail the transition check.
+// A refund event gives back only its own amount, recorded as a refund of the charge.
+func (uc *PaymentUseCase) ApplyGatewayEvent(ctx context.Context, event *dto.GatewayPaymentEvent) (bool, error) {
+	payment, err := uc.getGatewayPayment(ctx, event)
+	if err != nil {
+		return false, err
+	}
+
+	if event.Status == entity.PaymentStatusRefunded {
+		return uc.applyGatewayRefund(ctx, payment, event)
+	}
+
+	if payment.Status == event.Status {
+		return false, nil
+	}
+
+	if !payment.CanUpdateStatus(event.Status) {
+		return false, fmt.Errorf("%w: cannot change %s payment to %s", entity.ErrInvalidPaymentStatus, payment.Status, event.Status)
+	}
+
+	if event.Status == entity.PaymentStatusCompleted {
+		if err := checkGatewayAmount(payment, event); err != nil {
+			return false, err
+		}
+	}
+
+	metadata := make(map[string]interface{}, len(payment.Metadata)+6)
+	for k, v := range payment.Metadata {
+		metadata[k] = v
+	}
+	metadata["gateway"] = event.Gateway
+	metadata["gateway_charge_id"] = event.ChargeID
+	metadata["gateway_event_id"] = event.GatewayEventID
+	metadata["gateway_status"] = event.GatewayStatus
+	if event.FailureCode != "" {
+		metadata["failure_code"] = event.FailureCode
+		metadata["failure_message"] = event.FailureMessage
+	}
+
+	if _, err := uc.UpdatePaymentStatus(ctx, payment.ID, &dto.UpdatePaymentStatusRequest{
+		Status:   event.Status,
+		Metadata: metadata,
+	}); err != nil {
+		return false, err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"payment_id": payment.ID,
+		"gateway":    event.Gateway,
+		"charge_id":  event.ChargeID,
+		"old_status": payment.Status,
+		"new_status": event.Status,
+	}).Info("Applied gateway payment event")
+
+	return true, nil
+}
+
+// applyGatewayRefund records a gateway refund of the charge. Each gateway refund has its own
+// reference, so partial refunds of one charge add up and a redelivered refund is not recorded twice.
+func (uc *PaymentUseCase) applyGatewayRefund(ctx context.Context, payment *entity.PaymentTransaction, event *dto.GatewayPaymentEvent) (bool, error) {
+	if event.RefundID == "" {
+		return false, fmt.Errorf("%w: %s refund of charge %s has no refund ID", entity.ErrRequiredFieldMissing, event.Gateway, event.ChargeID)
+	}
+	if event.Amount <= 0 {
+		return false, fmt.Errorf("%w: %s refund %s", entity.ErrInvalidAmount, event.Gateway, event.RefundID)
+	}
+
+	_, created, err := uc.refundPayment(ctx, &dto.CreateRefundRequest{
+		OrderID:    payment.OrderID,
+		CustomerID: payment.CustomerID,
+		Reference:  event.Gateway + ":" + event.RefundID,
+		Amount:     event.Amount,
+		Currency:   event.Currency,
+		Reason:     "refunded through " + event.Gateway,
+	}, payment, map[string]interface{}{
+		// Not gateway_charge_id, which must keep resolving to the charge itself
+		"gateway":            event.Gateway,
+		"refunded_charge_id": event.ChargeID,
+		"gateway_refund_id":  event.RefundID,
+		"gateway_event_id":   event.GatewayEventID,
+	})
+	if err != nil {
+		return false, err
+	}
+
+	if created {
+		uc.logger.WithFields(logrus.Fields{
+			"payment_id": payment.ID,
+			"gateway":    event.Gateway,
+			"charge_id":  event.ChargeID,
+			"refund_id":  event.RefundID,
+			"amount":     event.Amount,
+		}).Info("Applied gateway refund")
+	}
+	return created, nil
+}
+
+// RefundPayment records money paid back to the customer as a refunded payment transaction of the
+// order, made through the method of the payment it refunds. An order can never get back more than
+// it paid. It returns false when a refund with the same reference already exists.
+func (uc *PaymentUseCase) RefundPayment(ctx context.Context, req *dto.CreateRefundRequest) (*dto.PaymentResponse, bool, error) {
+	return uc.refundPayment(ctx, req, nil, nil)
+}
+
+// refundPayment records the refund against the given payment, or the order's latest completed
+// payment when it is nil. Extra metadata is stored on the refund transaction.
+func (uc *PaymentUseCase) refundPayment(ctx context.Context, req *dto.CreateRefundRequest, against *entity.PaymentTransaction, extra map[string]interface{}) (*dto.PaymentResponse, bool, error) {
+	payments, err := uc.paymentRepo.GetByOrderID(ctx, req.OrderID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get order payments: %w", err)
+	}
+
+	var original *entity.PaymentTransaction
+	paid, refunded := 0.0, 0.0
+	for _, payment := range payments {
+		switch payment.Status {
+		case entity.PaymentStatusCompleted:
+			paid += payment.Amount
+			if against != nil {
+				if payment.ID == against.ID {
+					original = payment
+				}
+			} else if original == nil || payment.CreatedAt.After(original.CreatedAt) {
+				original = payment
+			}
+		case entity.PaymentStatusRefunded:
+			if reference, _ := payment.Metadata["refund_reference"].(string); reference == req.Reference {
+				return uc.mapToPaymentResponse(ctx, payment), false, nil
+			}
+			refunded += payment.Amount
+		}
+	}
+
+	if original == nil && against != nil {
+		return nil, false, fmt.Errorf("%w: cannot refund %s payment %s", entity.ErrInvalidPaymentStatus, against.Status, against.ID)
+	}
+	if original == nil {
+		return nil, false, fmt.Errorf("%w: order %s has no completed payment", entity.ErrPaymentNotFound, req.OrderID)
+	}
+	if !strings.EqualFold(original.Currency, req.Currency) {
+		return nil, false, fmt.Errorf("%w: refund in %s, order paid in %s", entity.ErrInvalidCurrency, req.Currency, original.Currency)
+	}
+	if req.Amount-(paid-refunded) >= 0.005 {
+		return nil, false, fmt.Errorf("%w: refunding %.2f of %.2f left", entity.ErrRefundAmountExceeded, req.Amount, paid-refunded)
+	}
+
+	now := time.Now()
+	refund := &entity.PaymentTransaction{
+		ID:              uuid.New(),
+		OrderID:         req.OrderID,
+		CustomerID:      req.CustomerID,
+		PaymentMethod:   original.PaymentMethod,
+		PaymentChannel:  original.PaymentChannel,
+		PaymentTiming:   original.PaymentTiming,
+		Amount:          req.Amount,
+		Currency:        original.Currency,
+		Status:          entity.PaymentStatusRefunded,
+		AssignedStoreID: original.AssignedStoreID,
+		Metadata: map[string]interface{}{
+			"refund_reference": req.Reference,
+			"refund_reason":    req.Reason,
+			"refunded_payment": original.ID.String(),
+		},
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for k, v := range extra {
+		refund.Metadata[k] = v
+	}
+
+	// The cap and the reference are checked again under lock, as a concurrent refund may have been
+	// recorded since the payments were read
+	stored, created, err := uc.paymentRepo.CreateRefund(ctx, refund, req.Reference)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create refund: %w", err)
+	}
+	if !created {
+		return uc.mapToPaymentResponse(ctx, stored), false, nil
+	}
+
+	_ = uc.eventRepo.PublishPaymentEvent(ctx, &repository.PaymentEvent{
+		ID:         uuid.New(),
+		EventType:  repository.EventTypePaymentRefunded,
+		PaymentID:  refund.ID,
+		OrderID:    &refund.OrderID,
+		CustomerID: &refund.CustomerID,
+		Data: map[string]interface{}{
+			"amount":           refund.Amount,
+			"currency":         refund.Currency,
+			"payment_method":   refund.PaymentMethod,
+			"reference":        req.Reference,
+			"reason":           req.Reason,
+			"refunded_payment": original.ID,
+		},
+		OccurredAt: now,
+		Source:     "payment-service",
+		Version:    "1.0",
+	})
+
+	uc.logger.WithFields(logrus.Fields{
+		"payment_id": refund.ID,
+		"order_id":   refund.OrderID,
+		"amount":     refund.Amount,
+		"reference":  req.Reference,
+	}).Info("Refund recorded")
+
+	return uc.mapToPaymentResponse(ctx, refund), true, nil
+}
+
 // GetPaymentByID retrieves a payment by ID
 func (uc *PaymentUseCase) GetPaymentByID(ctx context.Context, paymentID uuid.UUID) (*dto.PaymentResponse, error) {
 	payment, err := uc.paymentRepo.GetByID(ctx, paymentID)
@@ -220,6 +435,43 @@ func (uc *PaymentUseCase) GetPaymentByID(ctx context.Context, paymentID uuid.UUI
 }
 
 // Helper methods
+func (uc *PaymentUseCase) getGatewayPayment(ctx context.Context, event *dto.GatewayPaymentEvent) (*entity.PaymentTransaction, error) {
+	// The payment ID travels in the charge metadata; refunds and some gateways only carry the charge
+	if paymentID, err := uuid.Parse(event.PaymentID); err == nil {
+		payment, err := uc.paymentRepo.GetByID(ctx, paymentID)
+		if err == nil {
+			return payment, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("failed to get payment: %w", err)
+		}
+	}
+
+	payment, err := uc.paymentRepo.GetByGatewayChargeID(ctx, event.Gateway, event.ChargeID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: %s charge %s", entity.ErrPaymentNotFound, event.Gateway, event.ChargeID)
+		}
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return payment, nil
+}
+
+func checkGatewayAmount(payment *entity.PaymentTransaction, event *dto.GatewayPaymentEvent) error {
+	if !strings.EqualFold(payment.Currency, event.Currency) {
+		return fmt.Errorf("%w: charged in %s, payment is in %s", entity.ErrInvalidCurrency, event.Currency, payment.Currency)
+	}
+
+	diff := event.Amount - payment.Amount
+	if math.Abs(diff) < 0.005 {
+		return nil
+	}
+	if diff < 0 {
+		return fmt.Errorf("%w: charged %.2f of %.2f", entity.ErrInsufficientPayment, event.Amount, payment.Amount)
+	}
+	return fmt.Errorf("%w: charged %.2f of %.2f", entity.ErrOverpaymentNotAllowed, event.Amount, payment.Amount)
+}
+
 func (uc *PaymentUseCase) validatePayment(ctx context.Context, payment *entity.PaymentTransaction) error {
 	// Basic validation
 	if payment.Amount <= 0 {
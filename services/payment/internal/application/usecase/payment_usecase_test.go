@@ -0,0 +1,316 @@
+package usecase
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+
+	"payment/internal/application/dto"
+	"payment/internal/domain/entity"
+	"payment/internal/domain/repository"
+)
+
+// memoryPaymentRepo keeps payments in memory; methods the use case does not call panic
+type memoryPaymentRepo struct {
+	repository.PaymentRepository
+	payments     map[uuid.UUID]*entity.PaymentTransaction
+	beforeRefund func()
+}
+
+func (r *memoryPaymentRepo) Create(ctx context.Context, payment *entity.PaymentTransaction) error {
+	r.payments[payment.ID] = payment
+	return nil
+}
+
+func (r *memoryPaymentRepo) GetByID(ctx context.Context, id uuid.UUID) (*entity.PaymentTransaction, error) {
+	payment, ok := r.payments[id]
+	if !ok {
+		return nil, sql.ErrNoRows
+	}
+	copied := *payment
+	return &copied, nil
+}
+
+func (r *memoryPaymentRepo) Update(ctx context.Context, payment *entity.PaymentTransaction) error {
+	r.payments[payment.ID] = payment
+	return nil
+}
+
+func (r *memoryPaymentRepo) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*entity.PaymentTransaction, error) {
+	var payments []*entity.PaymentTransaction
+	for _, payment := range r.payments {
+		if payment.OrderID == orderID {
+			payments = append(payments, payment)
+		}
+	}
+	return payments, nil
+}
+
+func (r *memoryPaymentRepo) GetByGatewayChargeID(ctx context.Context, gateway, chargeID string) (*entity.PaymentTransaction, error) {
+	for _, payment := range r.payments {
+		if payment.Metadata["gateway"] == gateway && payment.Metadata["gateway_charge_id"] == chargeID {
+			return payment, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+// CreateRefund enforces the cap and the reference as the Postgres repository does under lock.
+// beforeRefund, when set, runs first to stand in for a refund committed concurrently.
+func (r *memoryPaymentRepo) CreateRefund(ctx context.Context, refund *entity.PaymentTransaction, reference string) (*entity.PaymentTransaction, bool, error) {
+	if r.beforeRefund != nil {
+		r.beforeRefund()
+		r.beforeRefund = nil
+	}
+	left := 0.0
+	for _, payment := range r.payments {
+		if payment.Metadata["refund_reference"] == reference {
+			return payment, false, nil
+		}
+		if payment.OrderID != refund.OrderID {
+			continue
+		}
+		switch payment.Status {
+		case entity.PaymentStatusCompleted:
+			left += payment.Amount
+		case entity.PaymentStatusRefunded:
+			left -= payment.Amount
+		}
+	}
+	if refund.Amount-left >= 0.005 {
+		return nil, false, entity.ErrRefundAmountExceeded
+	}
+	r.payments[refund.ID] = refund
+	return refund, true, nil
+}
+
+func (r *memoryPaymentRepo) refunds() []*entity.PaymentTransaction {
+	var refunds []*entity.PaymentTransaction
+	for _, payment := range r.payments {
+		if payment.Status == entity.PaymentStatusRefunded {
+			refunds = append(refunds, payment)
+		}
+	}
+	return refunds
+}
+
+type recordingEventRepo struct {
+	repository.EventRepository
+	events []*repository.PaymentEvent
+}
+
+func (r *recordingEventRepo) PublishPaymentEvent(ctx context.Context, event *repository.PaymentEvent) error {
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *recordingEventRepo) PublishPaymentStatusChanged(ctx context.Context, paymentID uuid.UUID, oldStatus, newStatus entity.PaymentStatus) error {
+	return nil
+}
+
+func newTestPaymentUseCase() (*PaymentUseCase, *memoryPaymentRepo, *recordingEventRepo) {
+	repo := &memoryPaymentRepo{payments: map[uuid.UUID]*entity.PaymentTransaction{}}
+	events := &recordingEventRepo{}
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return NewPaymentUseCase(repo, nil, nil, events, logger), repo, events
+}
+
+func addGatewayPayment(repo *memoryPaymentRepo, status entity.PaymentStatus, amount float64) *entity.PaymentTransaction {
+	payment := &entity.PaymentTransaction{
+		ID:             uuid.New(),
+		OrderID:        uuid.New(),
+		CustomerID:     uuid.New(),
+		PaymentMethod:  entity.PaymentMethodDigitalWallet,
+		PaymentChannel: entity.PaymentChannelSAANApp,
+		PaymentTiming:  entity.PaymentTimingPrepaid,
+		Amount:         amount,
+		Currency:       "THB",
+		Status:         status,
+		Metadata: map[string]interface{}{
+			"gateway":           "omise",
+			"gateway_charge_id": "chrg_test_1",
+		},
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	repo.payments[payment.ID] = payment
+	return payment
+}
+
+func TestApplyGatewayEventCompletesPayment(t *testing.T) {
+	uc, repo, _ := newTestPaymentUseCase()
+	payment := addGatewayPayment(repo, entity.PaymentStatusPending, 1250.50)
+
+	event := &dto.GatewayPaymentEvent{
+		Gateway:        "omise",
+		GatewayEventID: "evnt_1",
+		ChargeID:       "chrg_test_1",
+		PaymentID:      payment.ID.String(),
+		Status:         entity.PaymentStatusCompleted,
+		GatewayStatus:  "successful",
+		Amount:         1250.50,
+		Currency:       "thb",
+	}
+	applied, err := uc.ApplyGatewayEvent(context.Background(), event)
+	if err != nil || !applied {
+		t.Fatalf("ApplyGatewayEvent = %v, %v", applied, err)
+	}
+
+	stored := repo.payments[payment.ID]
+	if stored.Status != entity.PaymentStatusCompleted || stored.PaidAt == nil {
+		t.Errorf("payment is %s, paid at %v", stored.Status, stored.PaidAt)
+	}
+	if stored.Metadata["gateway_event_id"] != "evnt_1" {
+		t.Errorf("metadata %v", stored.Metadata)
+	}
+
+	// A redelivery of the same status is a no-op
+	applied, err = uc.ApplyGatewayEvent(context.Background(), event)
+	if err != nil || applied {
+		t.Fatalf("redelivery = %v, %v", applied, err)
+	}
+}
+
+func TestApplyGatewayEventRejectsWrongAmount(t *testing.T) {
+	uc, repo, _ := newTestPaymentUseCase()
+	payment := addGatewayPayment(repo, entity.PaymentStatusPending, 1250.50)
+
+	_, err := uc.ApplyGatewayEvent(context.Background(), &dto.GatewayPaymentEvent{
+		Gateway:   "omise",
+		ChargeID:  "chrg_test_1",
+		PaymentID: payment.ID.String(),
+		Status:    entity.PaymentStatusCompleted,
+		Amount:    1000,
+		Currency:  "THB",
+	})
+	if !errors.Is(err, entity.ErrInsufficientPayment) {
+		t.Fatalf("short charge: got %v, want ErrInsufficientPayment", err)
+	}
+	if repo.payments[payment.ID].Status != entity.PaymentStatusPending {
+		t.Errorf("payment changed to %s", repo.payments[payment.ID].Status)
+	}
+}
+
+func TestApplyGatewayEventRefundsOnlyTheEventAmount(t *testing.T) {
+	uc, repo, events := newTestPaymentUseCase()
+	payment := addGatewayPayment(repo, entity.PaymentStatusCompleted, 1000)
+
+	refund := func(refundID string, amount float64) (bool, error) {
+		// Refund events carry only the charge, not the payment ID
+		return uc.ApplyGatewayEvent(context.Background(), &dto.GatewayPaymentEvent{
+			Gateway:        "omise",
+			GatewayEventID: "evnt_" + refundID,
+			ChargeID:       "chrg_test_1",
+			RefundID:       refundID,
+			Status:         entity.PaymentStatusRefunded,
+			Amount:         amount,
+			Currency:       "THB",
+		})
+	}
+
+	if applied, err := refund("rfnd_1", 300); err != nil || !applied {
+		t.Fatalf("first partial refund = %v, %v", applied, err)
+	}
+	if applied, err := refund("rfnd_2", 200); err != nil || !applied {
+		t.Fatalf("second partial refund = %v, %v", applied, err)
+	}
+	if applied, err := refund("rfnd_2", 200); err != nil || applied {
+		t.Fatalf("redelivered refund = %v, %v", applied, err)
+	}
+
+	// The charge stays completed; each refund is its own transaction for its own amount
+	if status := repo.payments[payment.ID].Status; status != entity.PaymentStatusCompleted {
+		t.Errorf("charge is %s after partial refunds", status)
+	}
+	refunds := repo.refunds()
+	if len(refunds) != 2 {
+		t.Fatalf("recorded %d refunds, want 2", len(refunds))
+	}
+	total := 0.0
+	for _, r := range refunds {
+		total += r.Amount
+		if r.Metadata["refunded_payment"] != payment.ID.String() || r.Metadata["refunded_charge_id"] != "chrg_test_1" {
+			t.Errorf("refund %v is not against the charge", r.Metadata)
+		}
+	}
+	if total != 500 {
+		t.Errorf("refunded %.2f, want 500", total)
+	}
+	if len(events.events) != 2 {
+		t.Errorf("published %d refund events, want 2", len(events.events))
+	}
+
+	if _, err := refund("rfnd_3", 600); !errors.Is(err, entity.ErrRefundAmountExceeded) {
+		t.Fatalf("refund over what is left: got %v, want ErrRefundAmountExceeded", err)
+	}
+}
+
+func TestApplyGatewayEventRefundNeedsRefundID(t *testing.T) {
+	uc, repo, _ := newTestPaymentUseCase()
+	addGatewayPayment(repo, entity.PaymentStatusCompleted, 1000)
+
+	_, err := uc.ApplyGatewayEvent(context.Background(), &dto.GatewayPaymentEvent{
+		Gateway:  "omise",
+		ChargeID: "chrg_test_1",
+		Status:   entity.PaymentStatusRefunded,
+		Amount:   1000,
+		Currency: "THB",
+	})
+	if !errors.Is(err, entity.ErrRequiredFieldMissing) {
+		t.Fatalf("refund without ID: got %v, want ErrRequiredFieldMissing", err)
+	}
+	if len(repo.refunds()) != 0 {
+		t.Error("refund recorded without a refund ID")
+	}
+}
+
+func TestApplyGatewayEventUnknownCharge(t *testing.T) {
+	uc, _, _ := newTestPaymentUseCase()
+
+	_, err := uc.ApplyGatewayEvent(context.Background(), &dto.GatewayPaymentEvent{
+		Gateway:  "omise",
+		ChargeID: "chrg_unknown",
+		Status:   entity.PaymentStatusCompleted,
+	})
+	if !errors.Is(err, entity.ErrPaymentNotFound) {
+		t.Fatalf("unknown charge: got %v, want ErrPaymentNotFound", err)
+	}
+}
+
+func TestRefundPaymentCapHoldsAgainstConcurrentRefund(t *testing.T) {
+	uc, repo, events := newTestPaymentUseCase()
+	payment := addGatewayPayment(repo, entity.PaymentStatusCompleted, 1000)
+
+	// Another refund of 700 is recorded after this one read the payments, leaving 300
+	repo.beforeRefund = func() {
+		repo.payments[uuid.New()] = &entity.PaymentTransaction{
+			ID:       uuid.New(),
+			OrderID:  payment.OrderID,
+			Amount:   700,
+			Currency: "THB",
+			Status:   entity.PaymentStatusRefunded,
+			Metadata: map[string]interface{}{"refund_reference": "return:concurrent"},
+		}
+	}
+
+	_, created, err := uc.RefundPayment(context.Background(), &dto.CreateRefundRequest{
+		OrderID:    payment.OrderID,
+		CustomerID: payment.CustomerID,
+		Reference:  "return:late",
+		Amount:     500,
+		Currency:   "THB",
+	})
+	if !errors.Is(err, entity.ErrRefundAmountExceeded) || created {
+		t.Fatalf("refund over what the concurrent refund left = %v, %v; want ErrRefundAmountExceeded", created, err)
+	}
+	if len(events.events) != 0 {
+		t.Errorf("published %d refund events for a refused refund", len(events.events))
+	}
+}
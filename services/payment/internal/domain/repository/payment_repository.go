@@ -15,6 +15,10 @@ type PaymentRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*entity.PaymentTransaction, error)
 	Update(ctx context.Context, payment *entity.PaymentTransaction) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// CreateRefund records a refund unless it would take the order's refunds over what it paid,
+	// atomically. It returns the refund already recorded with the same reference, and false, instead
+	// of creating another.
+	CreateRefund(ctx context.Context, refund *entity.PaymentTransaction, reference string) (*entity.PaymentTransaction, bool, error)
 
 	// Query operations for the three data retrieval types
 	
@@ -32,6 +36,7 @@ type PaymentRepository interface {
 
 	// Advanced queries
 	GetByLoyverseReceiptID(ctx context.Context, receiptID string) (*entity.PaymentTransaction, error)
+	GetByGatewayChargeID(ctx context.Context, gateway, chargeID string) (*entity.PaymentTransaction, error)
 	GetPendingPayments(ctx context.Context, limit int) ([]*entity.PaymentTransaction, error)
 	GetPaymentsByDateRange(ctx context.Context, dateFrom, dateTo time.Time, filters PaymentFilters) ([]*entity.PaymentTransaction, error)
 	GetPaymentsByChannel(ctx context.Context, channel entity.PaymentChannel, filters PaymentFilters) ([]*entity.PaymentTransaction, error)
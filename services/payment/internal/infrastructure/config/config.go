@@ -48,6 +48,7 @@ type TopicsConfig struct {
 	PaymentEvents string `json:"payment_events"`
 	OrderEvents   string `json:"order_events"`
 	DeliveryEvents string `json:"delivery_events"`
+	GatewayEvents string `json:"gateway_events"`
 }
 
 type LoggingConfig struct {
@@ -97,6 +98,7 @@ func LoadConfig() *Config {
 				PaymentEvents:  getEnv("KAFKA_TOPIC_PAYMENT_EVENTS", "payment.events"),
 				OrderEvents:    getEnv("KAFKA_TOPIC_ORDER_EVENTS", "order.events"),
 				DeliveryEvents: getEnv("KAFKA_TOPIC_DELIVERY_EVENTS", "delivery.events"),
+				GatewayEvents:  getEnv("KAFKA_TOPIC_GATEWAY_EVENTS", "payment-events"),
 			},
 		},
 		Logging: LoggingConfig{
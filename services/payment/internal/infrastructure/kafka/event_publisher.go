@@ -0,0 +1,108 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+
+	"payment/internal/domain/entity"
+	"payment/internal/domain/repository"
+)
+
+// EventPublisher implements EventRepository by publishing payment events to Kafka
+type EventPublisher struct {
+	writer *kafka.Writer
+	topic  string
+	logger *logrus.Logger
+}
+
+// NewEventPublisher creates a new Kafka payment event publisher
+func NewEventPublisher(brokers []string, topic string, logger *logrus.Logger) *EventPublisher {
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(brokers...),
+		Topic:        topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireOne,
+		BatchTimeout: 10 * time.Millisecond,
+	}
+
+	return &EventPublisher{
+		writer: writer,
+		topic:  topic,
+		logger: logger,
+	}
+}
+
+// PublishPaymentEvent publishes a payment event keyed by payment ID
+func (p *EventPublisher) PublishPaymentEvent(ctx context.Context, event *repository.PaymentEvent) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	msg := kafka.Message{
+		Key:   []byte(event.PaymentID.String()),
+		Value: data,
+		Headers: []kafka.Header{
+			{Key: "event-type", Value: []byte(event.EventType)},
+			{Key: "event-source", Value: []byte(event.Source)},
+		},
+	}
+
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		p.logger.WithError(err).Errorf("Failed to publish event %s", event.ID)
+		return fmt.Errorf("%w: %v", entity.ErrKafkaPublishFailed, err)
+	}
+
+	p.logger.WithFields(logrus.Fields{
+		"event_id":   event.ID,
+		"event_type": event.EventType,
+		"topic":      p.topic,
+	}).Debug("Event published successfully")
+
+	return nil
+}
+
+// PublishPaymentStatusChanged publishes a payment status change
+func (p *EventPublisher) PublishPaymentStatusChanged(ctx context.Context, paymentID uuid.UUID, oldStatus, newStatus entity.PaymentStatus) error {
+	return p.PublishPaymentEvent(ctx, newEvent(repository.EventTypePaymentStatusChanged, paymentID, map[string]interface{}{
+		"old_status": oldStatus,
+		"new_status": newStatus,
+	}))
+}
+
+// PublishLoyversePaymentCreated publishes a payment recorded against a Loyverse receipt
+func (p *EventPublisher) PublishLoyversePaymentCreated(ctx context.Context, paymentID uuid.UUID, receiptID string) error {
+	return p.PublishPaymentEvent(ctx, newEvent(repository.EventTypeLoyversePaymentCreated, paymentID, map[string]interface{}{
+		"loyverse_receipt_id": receiptID,
+	}))
+}
+
+// PublishCODPaymentCollected publishes a COD payment collected by a driver
+func (p *EventPublisher) PublishCODPaymentCollected(ctx context.Context, paymentID uuid.UUID, deliveryID uuid.UUID) error {
+	return p.PublishPaymentEvent(ctx, newEvent(repository.EventTypeCODPaymentCollected, paymentID, map[string]interface{}{
+		"delivery_id": deliveryID,
+	}))
+}
+
+// Close closes the Kafka writer
+func (p *EventPublisher) Close() error {
+	return p.writer.Close()
+}
+
+func newEvent(eventType string, paymentID uuid.UUID, data map[string]interface{}) *repository.PaymentEvent {
+	return &repository.PaymentEvent{
+		ID:         uuid.New(),
+		EventType:  eventType,
+		PaymentID:  paymentID,
+		Data:       data,
+		OccurredAt: time.Now(),
+		Source:     "payment-service",
+		Version:    "1.0",
+	}
+}
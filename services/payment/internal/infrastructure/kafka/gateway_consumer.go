@@ -0,0 +1,157 @@
+package kafka
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+
+	"payment/internal/application/dto"
+	"payment/internal/domain/entity"
+)
+
+const (
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 30 * time.Second
+)
+
+// GatewayEventApplier applies normalized gateway events to payments
+type GatewayEventApplier interface {
+	ApplyGatewayEvent(ctx context.Context, event *dto.GatewayPaymentEvent) (bool, error)
+}
+
+// GatewayConsumer consumes gateway events published by payment-webhook. Offsets are committed
+// only after an event is applied or dropped, so a crash mid-update redelivers the event.
+// Transient failures are retried until they succeed: payment-webhook has already claimed the
+// event, so a redelivery from the gateway would not publish it again.
+type GatewayConsumer struct {
+	reader    *kafka.Reader
+	applier   GatewayEventApplier
+	logger    *logrus.Logger
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// NewGatewayConsumer creates a new gateway event consumer
+func NewGatewayConsumer(brokers []string, topic, groupID string, applier GatewayEventApplier, logger *logrus.Logger) *GatewayConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       topic,
+		GroupID:     groupID,
+		StartOffset: kafka.FirstOffset,
+		MinBytes:    1,
+		MaxBytes:    10e6,
+	})
+
+	return &GatewayConsumer{
+		reader:    reader,
+		applier:   applier,
+		logger:    logger,
+		baseDelay: retryBaseDelay,
+		maxDelay:  retryMaxDelay,
+	}
+}
+
+// Start consumes gateway events until the context is cancelled
+func (c *GatewayConsumer) Start(ctx context.Context) {
+	c.logger.WithField("topic", c.reader.Config().Topic).Info("Starting gateway event consumer")
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.WithError(err).Error("Failed to fetch gateway event")
+			continue
+		}
+
+		// Stopping mid-retry leaves the offset uncommitted so the event is consumed again
+		if !c.handleWithRetry(ctx, msg) {
+			return
+		}
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			c.logger.WithError(err).Error("Failed to commit gateway event")
+		}
+	}
+}
+
+// Close closes the Kafka reader
+func (c *GatewayConsumer) Close() error {
+	return c.reader.Close()
+}
+
+// HandleMessage decodes and applies a single gateway event. Errors that a retry cannot fix
+// are logged and swallowed; only transient errors are returned.
+func (c *GatewayConsumer) HandleMessage(ctx context.Context, msg kafka.Message) error {
+	var event dto.GatewayPaymentEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		c.logger.WithError(err).WithField("offset", msg.Offset).Error("Dropping malformed gateway event")
+		return nil
+	}
+
+	logger := c.logger.WithFields(logrus.Fields{
+		"event_id":   event.EventID,
+		"gateway":    event.Gateway,
+		"charge_id":  event.ChargeID,
+		"payment_id": event.PaymentID,
+		"status":     event.Status,
+	})
+
+	applied, err := c.applier.ApplyGatewayEvent(ctx, &event)
+	if err != nil {
+		if isPermanent(err) {
+			logger.WithError(err).Warn("Dropping gateway event")
+			return nil
+		}
+		return err
+	}
+
+	if !applied {
+		logger.Debug("Gateway event already applied")
+	}
+	return nil
+}
+
+// handleWithRetry handles the event, retrying transient errors with a backoff capped at
+// maxDelay. It reports false when the context is cancelled before the event was handled.
+func (c *GatewayConsumer) handleWithRetry(ctx context.Context, msg kafka.Message) bool {
+	delay := c.baseDelay
+	for attempt := 1; ; attempt++ {
+		err := c.HandleMessage(ctx, msg)
+		if err == nil {
+			return true
+		}
+
+		c.logger.WithError(err).WithFields(logrus.Fields{
+			"offset":  msg.Offset,
+			"attempt": attempt,
+		}).Warn("Failed to apply gateway event, retrying")
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		if delay *= 2; delay > c.maxDelay {
+			delay = c.maxDelay
+		}
+	}
+}
+
+// isPermanent reports whether applying the event again cannot succeed
+func isPermanent(err error) bool {
+	return errors.Is(err, sql.ErrNoRows) ||
+		errors.Is(err, entity.ErrPaymentNotFound) ||
+		errors.Is(err, entity.ErrInvalidPaymentStatus) ||
+		errors.Is(err, entity.ErrInvalidCurrency) ||
+		errors.Is(err, entity.ErrInsufficientPayment) ||
+		errors.Is(err, entity.ErrOverpaymentNotAllowed) ||
+		errors.Is(err, entity.ErrRefundAmountExceeded) ||
+		errors.Is(err, entity.ErrRequiredFieldMissing) ||
+		errors.Is(err, entity.ErrInvalidAmount)
+}
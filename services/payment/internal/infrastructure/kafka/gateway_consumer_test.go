@@ -0,0 +1,128 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/sirupsen/logrus"
+
+	"payment/internal/application/dto"
+	"payment/internal/domain/entity"
+)
+
+type stubApplier struct {
+	events []*dto.GatewayPaymentEvent
+	err    error
+	// failures, when set, is how many calls fail with err before the event applies
+	failures int
+}
+
+func (a *stubApplier) ApplyGatewayEvent(ctx context.Context, event *dto.GatewayPaymentEvent) (bool, error) {
+	a.events = append(a.events, event)
+	if a.failures > 0 && len(a.events) > a.failures {
+		return true, nil
+	}
+	return a.err == nil, a.err
+}
+
+// newTestConsumer builds a consumer without a Kafka reader; HandleMessage does not use it
+func newTestConsumer(applier GatewayEventApplier) *GatewayConsumer {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &GatewayConsumer{applier: applier, logger: logger, baseDelay: time.Millisecond, maxDelay: 2 * time.Millisecond}
+}
+
+func TestHandleMessageDecodesGatewayEvent(t *testing.T) {
+	applier := &stubApplier{}
+	c := newTestConsumer(applier)
+
+	// As published by payment-webhook for an Omise partial refund
+	value := []byte(`{
+		"event_id": "omise:evnt_test_2",
+		"event_type": "payment.gateway_status",
+		"gateway": "omise",
+		"charge_id": "chrg_test_1",
+		"refund_id": "rfnd_test_2",
+		"payment_id": "3f2b8a4e-6c1d-4f7a-9e2b-5d8c1a7f0b3e",
+		"status": "refunded",
+		"gateway_status": "refund.create",
+		"amount": 200,
+		"currency": "THB",
+		"occurred_at": "2024-10-17T04:20:11Z",
+		"received_at": "2024-10-17T04:20:12Z"
+	}`)
+	if err := c.HandleMessage(context.Background(), kafka.Message{Value: value}); err != nil {
+		t.Fatalf("HandleMessage: %v", err)
+	}
+
+	if len(applier.events) != 1 {
+		t.Fatalf("applied %d events, want 1", len(applier.events))
+	}
+	event := applier.events[0]
+	if event.Status != entity.PaymentStatusRefunded || event.RefundID != "rfnd_test_2" || event.Amount != 200 {
+		t.Errorf("decoded event %+v", event)
+	}
+}
+
+func TestHandleMessageDropsMalformedEvent(t *testing.T) {
+	applier := &stubApplier{}
+	c := newTestConsumer(applier)
+
+	if err := c.HandleMessage(context.Background(), kafka.Message{Value: []byte(`not json`)}); err != nil {
+		t.Fatalf("malformed event: got %v, want it dropped", err)
+	}
+	if len(applier.events) != 0 {
+		t.Error("malformed event was applied")
+	}
+}
+
+func TestHandleMessageRetriesOnlyTransientErrors(t *testing.T) {
+	value := []byte(`{"gateway":"omise","charge_id":"chrg_test_1","status":"completed"}`)
+
+	permanent := []error{
+		entity.ErrPaymentNotFound,
+		entity.ErrInvalidPaymentStatus,
+		entity.ErrInsufficientPayment,
+		entity.ErrRefundAmountExceeded,
+		entity.ErrRequiredFieldMissing,
+	}
+	for _, cause := range permanent {
+		c := newTestConsumer(&stubApplier{err: fmt.Errorf("wrapped: %w", cause)})
+		if err := c.HandleMessage(context.Background(), kafka.Message{Value: value}); err != nil {
+			t.Errorf("%v: got %v, want the event dropped", cause, err)
+		}
+	}
+
+	transient := errors.New("connection refused")
+	c := newTestConsumer(&stubApplier{err: transient})
+	if err := c.HandleMessage(context.Background(), kafka.Message{Value: value}); !errors.Is(err, transient) {
+		t.Errorf("transient error: got %v, want it returned for a retry", err)
+	}
+}
+
+func TestHandleWithRetryKeepsRetryingTransientErrors(t *testing.T) {
+	value := []byte(`{"gateway":"omise","charge_id":"chrg_test_1","status":"completed"}`)
+
+	// More failures than the old attempt limit; the event must still be applied
+	applier := &stubApplier{err: errors.New("connection refused"), failures: 8}
+	c := newTestConsumer(applier)
+	if !c.handleWithRetry(context.Background(), kafka.Message{Value: value}) {
+		t.Fatal("handleWithRetry gave up on a transient error")
+	}
+	if len(applier.events) != 9 {
+		t.Errorf("applied %d times, want 9", len(applier.events))
+	}
+
+	// Cancelling leaves the event unhandled so its offset is not committed
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c = newTestConsumer(&stubApplier{err: errors.New("connection refused")})
+	if c.handleWithRetry(ctx, kafka.Message{Value: value}) {
+		t.Error("handleWithRetry reported a failing event as handled")
+	}
+}
@@ -74,6 +74,75 @@ func (r *PostgresPaymentRepository) Create(ctx context.Context, payment *entity.
 	return nil
 }
 
+// CreateRefund records a refund of an order in one transaction with the check that it fits. The
+// order's completed payments are locked first, so concurrent refunds of the order queue up and
+// each sums the refunds committed before it. A refund whose reference is already recorded is not
+// created again; the recorded one is returned instead.
+func (r *PostgresPaymentRepository) CreateRefund(ctx context.Context, refund *entity.PaymentTransaction, reference string) (*entity.PaymentTransaction, bool, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var locked []uuid.UUID
+	err = tx.SelectContext(ctx, &locked, `
+		SELECT id FROM payment_transactions
+		WHERE order_id = $1 AND status = $2
+		FOR UPDATE`, refund.OrderID, string(entity.PaymentStatusCompleted))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock order payments: %w", err)
+	}
+
+	var existing []*entity.PaymentTransaction
+	err = tx.SelectContext(ctx, &existing, `
+		SELECT id, order_id, customer_id, payment_method, payment_channel, payment_timing,
+			   amount, currency, status, paid_at, loyverse_receipt_id, loyverse_payment_type,
+			   assigned_store_id, metadata, created_at, updated_at, created_by, updated_by
+		FROM payment_transactions
+		WHERE metadata->>'refund_reference' = $1`, reference)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get refund by reference: %w", err)
+	}
+	if len(existing) > 0 {
+		return existing[0], false, nil
+	}
+
+	var left float64
+	err = tx.GetContext(ctx, &left, `
+		SELECT COALESCE(SUM(CASE WHEN status = $2 THEN amount ELSE 0 END), 0)
+			 - COALESCE(SUM(CASE WHEN status = $3 THEN amount ELSE 0 END), 0)
+		FROM payment_transactions
+		WHERE order_id = $1`,
+		refund.OrderID, string(entity.PaymentStatusCompleted), string(entity.PaymentStatusRefunded))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to sum order payments: %w", err)
+	}
+	if refund.Amount-left >= 0.005 {
+		return nil, false, fmt.Errorf("%w: refunding %.2f of %.2f left", entity.ErrRefundAmountExceeded, refund.Amount, left)
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+		INSERT INTO payment_transactions (
+			id, order_id, customer_id, payment_method, payment_channel, payment_timing,
+			amount, currency, status, paid_at, loyverse_receipt_id, loyverse_payment_type,
+			assigned_store_id, metadata, created_at, updated_at, created_by, updated_by
+		) VALUES (
+			:id, :order_id, :customer_id, :payment_method, :payment_channel, :payment_timing,
+			:amount, :currency, :status, :paid_at, :loyverse_receipt_id, :loyverse_payment_type,
+			:assigned_store_id, :metadata, :created_at, :updated_at, :created_by, :updated_by
+		)`, refund)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create refund: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit refund: %w", err)
+	}
+
+	return refund, true, nil
+}
+
 // GetByID retrieves a payment by ID
 func (r *PostgresPaymentRepository) GetByID(ctx context.Context, id uuid.UUID) (*entity.PaymentTransaction, error) {
 	query := `
@@ -374,20 +443,21 @@ func (r *PostgresPaymentRepository) GetByOrderID(ctx context.Context, orderID uu
 	return payments, nil
 }
 
-// GetOrderPaymentSummary retrieves payment summary for an order
+// GetOrderPaymentSummary retrieves payment summary for an order. Refunds are money paid back, so
+// they are summed on their own instead of counting towards the order total.
 func (r *PostgresPaymentRepository) GetOrderPaymentSummary(ctx context.Context, orderID uuid.UUID) (*repository.OrderPaymentSummary, error) {
 	query := `
 		SELECT 
 			order_id,
 			COUNT(*) as transaction_count,
-			SUM(amount) as total_amount,
+			SUM(CASE WHEN status <> 'refunded' THEN amount ELSE 0 END) as total_amount,
 			SUM(CASE WHEN status = 'completed' THEN amount ELSE 0 END) as paid_amount,
 			SUM(CASE WHEN status = 'pending' THEN amount ELSE 0 END) as pending_amount,
 			SUM(CASE WHEN status = 'refunded' THEN amount ELSE 0 END) as refunded_amount,
 			currency,
 			MAX(CASE WHEN status = 'completed' THEN paid_at END) as last_payment_at,
 			CASE 
-				WHEN SUM(CASE WHEN status = 'completed' THEN amount ELSE 0 END) >= SUM(amount) THEN 'fully_paid'
+				WHEN SUM(CASE WHEN status = 'completed' THEN amount ELSE 0 END) >= SUM(CASE WHEN status <> 'refunded' THEN amount ELSE 0 END) THEN 'fully_paid'
 				WHEN SUM(CASE WHEN status = 'completed' THEN amount ELSE 0 END) > 0 THEN 'partially_paid'
 				ELSE 'unpaid'
 			END as payment_status,
@@ -423,6 +493,24 @@ func (r *PostgresPaymentRepository) GetByLoyverseReceiptID(ctx context.Context,
 	return &payment, nil
 }
 
+// GetByGatewayChargeID retrieves payment by the charge ID recorded from a payment gateway
+func (r *PostgresPaymentRepository) GetByGatewayChargeID(ctx context.Context, gateway, chargeID string) (*entity.PaymentTransaction, error) {
+	query := `
+		SELECT id, order_id, customer_id, payment_method, payment_channel, payment_timing,
+			   amount, currency, status, paid_at, loyverse_receipt_id, loyverse_payment_type,
+			   assigned_store_id, metadata, created_at, updated_at, created_by, updated_by
+		FROM payment_transactions 
+		WHERE metadata->>'gateway' = $1 AND metadata->>'gateway_charge_id' = $2`
+
+	var payment entity.PaymentTransaction
+	err := r.db.GetContext(ctx, &payment, query, gateway, chargeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment by gateway charge ID: %w", err)
+	}
+
+	return &payment, nil
+}
+
 // GetPendingPayments retrieves pending payments
 func (r *PostgresPaymentRepository) GetPendingPayments(ctx context.Context, limit int) ([]*entity.PaymentTransaction, error) {
 	query := `
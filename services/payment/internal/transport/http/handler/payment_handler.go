@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -9,6 +10,7 @@ import (
 
 	"payment/internal/application/dto"
 	"payment/internal/application/usecase"
+	"payment/internal/domain/entity"
 )
 
 // PaymentHandler handles payment-related HTTP requests
@@ -63,6 +65,57 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 	})
 }
 
+// RefundPayment handles POST /payments/refunds
+func (h *PaymentHandler) RefundPayment(c *gin.Context) {
+	var req dto.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.logger.WithError(err).Error("Failed to bind request")
+		c.JSON(http.StatusBadRequest, dto.ErrorResponse{
+			Error: "Invalid request format",
+			Code:  "INVALID_REQUEST",
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	refund, created, err := h.paymentUseCase.RefundPayment(c.Request.Context(), &req)
+	if err != nil {
+		h.logger.WithError(err).WithField("order_id", req.OrderID).Error("Failed to refund payment")
+		status, code := http.StatusInternalServerError, "REFUND_FAILED"
+		switch {
+		case errors.Is(err, entity.ErrPaymentNotFound):
+			status, code = http.StatusNotFound, "PAYMENT_NOT_FOUND"
+		case errors.Is(err, entity.ErrRefundAmountExceeded):
+			status, code = http.StatusConflict, "REFUND_AMOUNT_EXCEEDED"
+		case errors.Is(err, entity.ErrInvalidCurrency):
+			status, code = http.StatusBadRequest, "INVALID_CURRENCY"
+		}
+		c.JSON(status, dto.ErrorResponse{
+			Error: "Failed to refund payment",
+			Code:  code,
+			Details: map[string]interface{}{
+				"error": err.Error(),
+			},
+		})
+		return
+	}
+
+	if !created {
+		c.JSON(http.StatusOK, dto.SuccessResponse{
+			Message: "Refund already recorded",
+			Data:    refund,
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, dto.SuccessResponse{
+		Message: "Refund recorded successfully",
+		Data:    refund,
+	})
+}
+
 // GetPayment handles GET /payments/:id
 func (h *PaymentHandler) GetPayment(c *gin.Context) {
 	idStr := c.Param("id")
@@ -141,6 +194,7 @@ func (h *PaymentHandler) RegisterRoutes(router *gin.RouterGroup) {
 	payments := router.Group("/payments")
 	{
 		payments.POST("", h.CreatePayment)
+		payments.POST("/refunds", h.RefundPayment)
 		payments.GET("/:id", h.GetPayment)
 		payments.PUT("/:id/status", h.UpdatePaymentStatus)
 	}
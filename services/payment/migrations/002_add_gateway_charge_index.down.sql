@@ -0,0 +1,4 @@
+-- Migration: 002_add_gateway_charge_index.down.sql
+-- Rollback gateway charge lookup index
+
+DROP INDEX IF EXISTS idx_payment_transactions_gateway_charge;
@@ -0,0 +1,6 @@
+-- Migration: 002_add_gateway_charge_index.up.sql
+-- Look up payments by the charge ID of the gateway webhook that updates them
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_transactions_gateway_charge
+    ON payment_transactions ((metadata->>'gateway'), (metadata->>'gateway_charge_id'))
+    WHERE metadata->>'gateway_charge_id' IS NOT NULL;
@@ -0,0 +1,3 @@
+-- Migration: 003_add_refund_reference_index.down.sql
+
+DROP INDEX IF EXISTS idx_payment_transactions_refund_reference;
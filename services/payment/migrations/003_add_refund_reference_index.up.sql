@@ -0,0 +1,6 @@
+-- Migration: 003_add_refund_reference_index.up.sql
+-- A refund is recorded once per reference, so retried refunds cannot pay the customer twice
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_payment_transactions_refund_reference
+    ON payment_transactions ((metadata->>'refund_reference'))
+    WHERE metadata->>'refund_reference' IS NOT NULL;
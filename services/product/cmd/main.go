@@ -11,11 +11,14 @@ import (
 
 	"product/internal/application"
 	"product/internal/infrastructure/cache"
+	"product/internal/domain/entity"
 	"product/internal/infrastructure/config"
 	"product/internal/infrastructure/database"
 	"product/internal/infrastructure/events"
 	"product/internal/infrastructure/loyverse"
+	"product/internal/infrastructure/notification"
 	"product/internal/transport/http/handler"
+	"product/internal/transport/http/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -61,20 +64,60 @@ func main() {
 	// Initialize repositories
 	productRepo := database.NewProductRepository(db)
 	categoryRepo := database.NewCategoryRepository(db) // Add this for sync functionality
+	reservationRepo := database.NewStockReservationRepository(db)
+	pricingRepo := database.NewPricingRepository(db)
+	priceHistoryRepo := database.NewPriceHistoryRepository(db)
+	marginRepo := database.NewMarginRepository(db)
+	availabilityRepo := database.NewAvailabilityRepository(db)
+	launchRepo := database.NewLaunchRepository(db)
 	// TODO: Add other repositories when implementations are ready
 	// priceRepo := database.NewPriceRepository(db)
 	// inventoryRepo := database.NewInventoryRepository(db)
 
 	// Initialize use cases
 	// For most operations, use direct database access (following PROJECT_RULES.md)
-	productUsecase := application.NewProductUsecase(productRepo, redisCache, logger)
+	pricingPolicy, err := entity.ParsePricingPolicy(cfg.Pricing.Precedence, cfg.Pricing.Stacking)
+	if err != nil {
+		logger.Fatalf("Invalid pricing policy: %v", err)
+	}
+	marginUsecase := application.NewMarginUsecase(productRepo, categoryRepo, marginRepo, pricingRepo, pricingPolicy, cfg.Pricing.DefaultMinMargin, logger)
+	productUsecase := application.NewProductUsecase(productRepo, priceHistoryRepo, marginUsecase, redisCache, logger)
 	// TODO: Uncomment when repository implementations are ready
 	// categoryUsecase := application.NewCategoryUsecase(categoryRepo, logger)
-	// pricingUsecase := application.NewPricingUsecase(priceRepo, productRepo, logger)
+	// pricingUsecase := application.NewPricingUsecase(priceRepo, priceHistoryRepo, productRepo, marginUsecase, redisCache, logger)
 	// inventoryUsecase := application.NewInventoryUsecase(inventoryRepo, productRepo, logger)
 	
+	cartPricingUsecase := application.NewCartPricingUsecase(productRepo, pricingRepo, pricingPolicy, logger)
+	priceHistoryUsecase := application.NewPriceHistoryUsecase(productRepo, priceHistoryRepo, marginUsecase, redisCache, eventPublisher, logger)
+	availabilityUsecase := application.NewAvailabilityUsecase(productRepo, availabilityRepo, redisCache, eventPublisher, logger)
+	launchNotifier := notification.NewLaunchNotifier(cfg.External, logger)
+	launchUsecase := application.NewLaunchUsecase(productRepo, launchRepo, launchNotifier, redisCache, eventPublisher, logger)
+
+	reservationUsecase := application.NewReservationUsecase(
+		productRepo,
+		reservationRepo,
+		launchRepo,
+		time.Duration(cfg.Reservation.DefaultTTL)*time.Second,
+		time.Duration(cfg.Reservation.MaxTTL)*time.Second,
+		logger,
+	)
+
+	// Release reservations of orders that were neither confirmed nor cancelled in time
+	sweeperCtx, stopSweeper := context.WithCancel(context.Background())
+	defer stopSweeper()
+	go reservationUsecase.StartExpirySweeper(sweeperCtx, time.Duration(cfg.Reservation.SweepInterval)*time.Second)
+
+	// Apply scheduled price changes once they take effect
+	go priceHistoryUsecase.StartPriceScheduler(sweeperCtx, time.Duration(cfg.Pricing.SchedulePollInterval)*time.Second)
+
+	// Take products on and off sale by their schedules and put them back once their time off ends
+	go availabilityUsecase.StartAvailabilityScheduler(sweeperCtx, time.Duration(cfg.Availability.PollInterval)*time.Second)
+
+	// Open launches to VIP members, telling them over LINE, and release them to everyone on time
+	go launchUsecase.StartLaunchScheduler(sweeperCtx, time.Duration(cfg.Launch.PollInterval)*time.Second)
+
 	// Initialize sync usecase for Loyverse integration
-	syncUsecase := application.NewSyncUsecase(productRepo, categoryRepo, eventPublisher, logger)
+	syncUsecase := application.NewSyncUsecase(productRepo, categoryRepo, priceHistoryRepo, eventPublisher, logger)
 
 	// Initialize Loyverse integration
 	var loyverseSyncService *loyverse.SyncService
@@ -89,9 +132,12 @@ func main() {
 	// Initialize handlers
 	productHandler := handler.NewProductHandler(productUsecase, logger)
 	syncHandler := handler.NewSyncHandler(syncUsecase, loyverseSyncService, logger)
+	reservationHandler := handler.NewReservationHandler(reservationUsecase, logger)
+	pricingHandler := handler.NewPricingHandler(cartPricingUsecase, priceHistoryUsecase, marginUsecase, logger)
+	availabilityHandler := handler.NewAvailabilityHandler(availabilityUsecase, logger)
+	launchHandler := handler.NewLaunchHandler(launchUsecase, logger)
 	// TODO: Add other handlers when ready
 	// categoryHandler := handler.NewCategoryHandler(categoryUsecase, logger)
-	// pricingHandler := handler.NewPricingHandler(pricingUsecase, logger)
 	// inventoryHandler := handler.NewInventoryHandler(inventoryUsecase, logger)
 
 	// Setup router
@@ -134,6 +180,17 @@ func main() {
 
 	// API routes
 	v1 := router.Group("/api/v1")
+	if cfg.Security.JWKSURL != "" {
+		v1.Use(middleware.Authenticate(&middleware.AuthConfig{
+			JWKSURL:            cfg.Security.JWKSURL,
+			Issuer:             cfg.Security.JWTIssuer,
+			Audience:           cfg.Security.JWTAudience,
+			KeyRefreshInterval: time.Duration(cfg.Security.JWKSRefreshInterval) * time.Second,
+			Logger:             logger,
+		}))
+	} else {
+		logger.Warn("JWT_JWKS_URL is not set; callers are not identified, so no one can approve prices below margin")
+	}
 	{
 		products := v1.Group("/products")
 		{
@@ -142,6 +199,13 @@ func main() {
 			products.GET("/:id", productHandler.GetProduct)
 			products.PUT("/:id", productHandler.UpdateProduct)
 			products.DELETE("/:id", productHandler.DeleteProduct)
+			products.GET("/:id/availability", availabilityHandler.GetAvailability)
+			products.PUT("/:id/availability", availabilityHandler.UpdateAvailability)
+			products.PUT("/:id/availability/schedule", availabilityHandler.SetSchedule)
+			products.POST("/:id/launches", launchHandler.CreateLaunch)
+			products.GET("/:id/launches", launchHandler.ListLaunches)
+			products.GET("/:id/launches/:launch_id", launchHandler.GetLaunch)
+			products.POST("/:id/launches/:launch_id/cancel", launchHandler.CancelLaunch)
 		}
 
 		sync := v1.Group("/sync")
@@ -151,6 +215,30 @@ func main() {
 			sync.GET("/status/:sync_id", syncHandler.GetSyncStatus)
 			sync.GET("/last", syncHandler.GetLastSyncTime)
 		}
+
+		reservations := v1.Group("/reservations")
+		{
+			reservations.POST("", reservationHandler.ReserveStock)
+			reservations.GET("/:order_id", reservationHandler.GetOrderReservations)
+			reservations.POST("/:order_id/consume", reservationHandler.ConsumeStock)
+			reservations.POST("/:order_id/release", reservationHandler.ReleaseStock)
+			reservations.POST("/:order_id/restock", reservationHandler.RestockStock)
+		}
+
+		pricing := v1.Group("/pricing")
+		{
+			pricing.POST("/cart", pricingHandler.PriceCart)
+			pricing.GET("/products/:id/history", pricingHandler.GetPriceHistory)
+			pricing.GET("/products/:id/as-of", pricingHandler.GetPriceAsOf)
+			pricing.POST("/products/:id/schedule", pricingHandler.SchedulePriceChange)
+			pricing.GET("/products/:id/schedule", pricingHandler.ListScheduledPriceChanges)
+			pricing.POST("/products/:id/schedule/:change_id/cancel", pricingHandler.CancelScheduledPriceChange)
+			pricing.POST("/margin-check", pricingHandler.CheckMargins)
+			pricing.GET("/margin-report", pricingHandler.GetMarginReport)
+			pricing.GET("/margin-policies", pricingHandler.ListMarginPolicies)
+			pricing.PUT("/margin-policies/:category_id", pricingHandler.SetMarginPolicy)
+			pricing.DELETE("/margin-policies/:category_id", pricingHandler.DeleteMarginPolicy)
+		}
 	}
 
 	// Start server
@@ -185,6 +273,7 @@ func main() {
 	<-quit
 
 	logger.Info("Shutting down Product Service...")
+	stopSweeper()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -0,0 +1,257 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+	"product/internal/infrastructure/events"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ProductCache removes cached products once their availability changes
+type ProductCache interface {
+	InvalidateProduct(ctx context.Context, productID uuid.UUID) error
+	InvalidateProductList(ctx context.Context) error
+}
+
+// AvailabilityUsecase takes products on and off sale, by hand or by their schedules, and
+// announces every change so chat menus and orders stop offering products that are off sale
+type AvailabilityUsecase struct {
+	productRepo      repository.ProductRepository
+	availabilityRepo repository.AvailabilityRepository
+	cache            ProductCache
+	eventPub         events.Publisher
+	logger           *logrus.Logger
+	now              func() time.Time
+}
+
+// NewAvailabilityUsecase creates a new availability usecase
+func NewAvailabilityUsecase(productRepo repository.ProductRepository, availabilityRepo repository.AvailabilityRepository, cache ProductCache, eventPub events.Publisher, logger *logrus.Logger) *AvailabilityUsecase {
+	return &AvailabilityUsecase{
+		productRepo:      productRepo,
+		availabilityRepo: availabilityRepo,
+		cache:            cache,
+		eventPub:         eventPub,
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// UpdateAvailabilityRequest takes a product off sale or puts it back on sale
+type UpdateAvailabilityRequest struct {
+	IsAdminActive *bool `json:"is_admin_active" binding:"required"`
+	// Reason is required to take a product off sale
+	Reason        string     `json:"reason" binding:"max=200"`
+	InactiveUntil *time.Time `json:"inactive_until"`
+	// AutoReactivate puts the product back on sale at InactiveUntil
+	AutoReactivate bool `json:"auto_reactivate"`
+	// ChangedBy is who makes the change. The handler sets it from the authenticated caller;
+	// only services calling without a token name it in the request body.
+	ChangedBy *uuid.UUID `json:"changed_by"`
+}
+
+// SetAvailabilityScheduleRequest replaces the schedule of a product; a null schedule removes it
+type SetAvailabilityScheduleRequest struct {
+	Schedule *entity.AvailabilitySchedule `json:"schedule"`
+	// ChangedBy is who sets the schedule, set like UpdateAvailabilityRequest.ChangedBy
+	ChangedBy *uuid.UUID `json:"changed_by"`
+}
+
+// AvailabilityStatus is whether a product is on sale, why not and for how long
+type AvailabilityStatus struct {
+	ProductID      uuid.UUID                    `json:"product_id"`
+	IsAvailable    bool                         `json:"is_available"`
+	IsActive       bool                         `json:"is_active"`
+	IsAdminActive  bool                         `json:"is_admin_active"`
+	InactiveReason *string                      `json:"inactive_reason,omitempty"`
+	InactiveUntil  *time.Time                   `json:"inactive_until,omitempty"`
+	AutoReactivate bool                         `json:"auto_reactivate"`
+	Schedule       *entity.AvailabilitySchedule `json:"schedule,omitempty"`
+	// NextScheduleChange is when the schedule next takes the product on or off sale
+	NextScheduleChange *time.Time                       `json:"next_schedule_change,omitempty"`
+	History            []*entity.ProductAvailabilityLog `json:"history"`
+}
+
+// GetAvailability returns the availability of a product with its latest changes
+func (uc *AvailabilityUsecase) GetAvailability(ctx context.Context, productID uuid.UUID, historyLimit int) (*AvailabilityStatus, error) {
+	product, err := uc.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	history, err := uc.availabilityRepo.GetAvailabilityHistory(ctx, productID, historyLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get availability history: %w", err)
+	}
+
+	status := &AvailabilityStatus{
+		ProductID:      product.ID,
+		IsAvailable:    product.IsAvailable(),
+		IsActive:       product.IsActive,
+		IsAdminActive:  product.IsAdminActive,
+		InactiveReason: product.InactiveReason,
+		InactiveUntil:  product.InactiveUntil,
+		AutoReactivate: product.AutoReactivate,
+		Schedule:       product.InactiveSchedule,
+		History:        history,
+	}
+	if product.InactiveSchedule != nil {
+		status.NextScheduleChange = product.InactiveSchedule.NextChange(uc.now())
+	}
+	return status, nil
+}
+
+// UpdateAvailability takes a product off sale or puts it back on sale
+func (uc *AvailabilityUsecase) UpdateAvailability(ctx context.Context, productID uuid.UUID, req *UpdateAvailabilityRequest) (*entity.Product, error) {
+	product, err := uc.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := uc.now()
+	var log *entity.ProductAvailabilityLog
+	if *req.IsAdminActive {
+		log = product.PutOnSale(req.ChangedBy, now)
+	} else {
+		log, err = product.TakeOffSale(req.Reason, req.InactiveUntil, req.AutoReactivate, req.ChangedBy, now)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.availabilityRepo.SaveAvailability(ctx, product, log); err != nil {
+		return nil, fmt.Errorf("failed to save availability: %w", err)
+	}
+	uc.availabilityChanged(ctx, product, log)
+	return product, nil
+}
+
+// SetSchedule replaces the availability schedule of a product and applies it right away
+func (uc *AvailabilityUsecase) SetSchedule(ctx context.Context, productID uuid.UUID, req *SetAvailabilityScheduleRequest) (*entity.Product, error) {
+	product, err := uc.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	log, err := product.SetAvailabilitySchedule(req.Schedule, req.ChangedBy, uc.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.availabilityRepo.SaveAvailability(ctx, product, log); err != nil {
+		return nil, fmt.Errorf("failed to save availability schedule: %w", err)
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"product_id": productID,
+		"scheduled":  req.Schedule != nil,
+	}).Info("Availability schedule updated")
+
+	if log != nil {
+		uc.availabilityChanged(ctx, product, log)
+	} else if err := uc.cache.InvalidateProduct(ctx, productID); err != nil {
+		uc.logger.WithError(err).WithField("product_id", productID).Error("Failed to invalidate cached product")
+	}
+	return product, nil
+}
+
+// ApplyRules takes products on and off sale as their schedules say and puts back on sale those
+// whose time off sale has passed
+func (uc *AvailabilityUsecase) ApplyRules(ctx context.Context) (int, error) {
+	now := uc.now()
+	ids, err := uc.availabilityRepo.GetAvailabilityRuleProductIDs(ctx, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get products with availability rules: %w", err)
+	}
+
+	changed := 0
+	for _, id := range ids {
+		product, log, err := uc.availabilityRepo.ApplyAvailabilityRules(ctx, id, now)
+		if err != nil {
+			uc.logger.WithError(err).WithField("product_id", id).Error("Failed to apply availability rules")
+			continue
+		}
+		if log == nil {
+			continue
+		}
+		changed++
+		uc.availabilityChanged(ctx, product, log)
+	}
+
+	if changed > 0 {
+		uc.logger.WithField("products", changed).Info("Availability rules applied")
+	}
+	return changed, nil
+}
+
+// StartAvailabilityScheduler applies availability rules every interval until the context is
+// cancelled
+func (uc *AvailabilityUsecase) StartAvailabilityScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	uc.logger.WithField("interval", interval).Info("Availability scheduler started")
+
+	for {
+		if _, err := uc.ApplyRules(ctx); err != nil && ctx.Err() == nil {
+			uc.logger.WithError(err).Error("Applying availability rules failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			uc.logger.Info("Availability scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// availabilityChanged drops the cached product whose availability changed and announces the
+// change
+func (uc *AvailabilityUsecase) availabilityChanged(ctx context.Context, product *entity.Product, change *entity.ProductAvailabilityLog) {
+	log := uc.logger.WithFields(logrus.Fields{
+		"product_id":      product.ID,
+		"change_type":     change.ChangeType,
+		"is_admin_active": product.IsAdminActive,
+	})
+
+	// A cached product would keep being offered until it expires
+	if err := uc.cache.InvalidateProduct(ctx, product.ID); err != nil {
+		log.WithError(err).Error("Failed to invalidate cached product")
+	}
+	if err := uc.cache.InvalidateProductList(ctx); err != nil {
+		log.WithError(err).Error("Failed to invalidate cached product lists")
+	}
+
+	eventType := events.ProductDeactivatedEvent
+	if product.IsAdminActive {
+		eventType = events.ProductActivatedEvent
+	}
+	event := events.NewProductEvent(eventType, product.ID, product.SKU, product.Name, change.ChangeType, map[string]interface{}{
+		"is_available":    product.IsAvailable(),
+		"is_admin_active": product.IsAdminActive,
+		"inactive_reason": product.InactiveReason,
+		"inactive_until":  product.InactiveUntil,
+	})
+	if err := uc.eventPub.PublishProductEvent(ctx, event); err != nil {
+		log.WithError(err).Error("Failed to publish availability event")
+	}
+
+	log.Info("Product availability changed")
+}
+
+// getProduct retrieves a product or reports it missing
+func (uc *AvailabilityUsecase) getProduct(ctx context.Context, productID uuid.UUID) (*entity.Product, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, entity.ErrProductNotFound
+	}
+	return product, nil
+}
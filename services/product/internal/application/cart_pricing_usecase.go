@@ -0,0 +1,213 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// CartPricingUsecase prices whole carts with the quantity tier, customer group and VIP rules,
+// combined under a configurable pricing policy
+type CartPricingUsecase struct {
+	productRepo repository.ProductRepository
+	pricingRepo repository.PricingRepository
+	policy      entity.PricingPolicy
+	logger      *logrus.Logger
+	now         func() time.Time
+}
+
+// NewCartPricingUsecase creates a new cart pricing usecase
+func NewCartPricingUsecase(productRepo repository.ProductRepository, pricingRepo repository.PricingRepository, policy entity.PricingPolicy, logger *logrus.Logger) *CartPricingUsecase {
+	return &CartPricingUsecase{
+		productRepo: productRepo,
+		pricingRepo: pricingRepo,
+		policy:      policy,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// PriceCartRequest represents a cart to price for a customer. Without a group or VIP level the
+// customer pays tier prices only.
+type PriceCartRequest struct {
+	CustomerID    *uuid.UUID      `json:"customer_id"`
+	CustomerGroup string          `json:"customer_group"`
+	VIPLevel      string          `json:"vip_level"`
+	Items         []PriceCartItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// PriceCartItem is a product quantity in the cart
+type PriceCartItem struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	Quantity  int       `json:"quantity" binding:"required,min=1"`
+}
+
+// CartPrice is the priced cart. Lines are in the order of the request.
+type CartPrice struct {
+	Lines    []CartLinePrice      `json:"lines"`
+	Subtotal decimal.Decimal      `json:"subtotal"`
+	Discount decimal.Decimal      `json:"discount"`
+	Total    decimal.Decimal      `json:"total"`
+	Currency string               `json:"currency"`
+	Policy   entity.PricingPolicy `json:"policy"`
+	PricedAt time.Time            `json:"priced_at"`
+}
+
+// CartLinePrice is the price of a cart line and the rules that made it
+type CartLinePrice struct {
+	ProductID uuid.UUID       `json:"product_id"`
+	Quantity  int             `json:"quantity"`
+	BasePrice decimal.Decimal `json:"base_price"`
+	UnitPrice decimal.Decimal `json:"unit_price"`
+	LineTotal decimal.Decimal `json:"line_total"`
+	Discount  decimal.Decimal `json:"discount"`
+	// TierQuantity is the quantity of the product across the cart, which chooses its tier
+	TierQuantity int                         `json:"tier_quantity"`
+	Applied      []entity.AppliedPricingRule `json:"applied_rules"`
+	Skipped      []entity.AppliedPricingRule `json:"skipped_rules,omitempty"`
+	// BelowCost tells that the unit price is under the cost of the product, so selling the line
+	// needs a manager's approval
+	BelowCost bool `json:"below_cost"`
+}
+
+// PriceCart prices every line of a cart. Lines of the same product count together toward its
+// quantity tiers.
+func (uc *CartPricingUsecase) PriceCart(ctx context.Context, req *PriceCartRequest) (*CartPrice, error) {
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("%w: cart has no items", entity.ErrInvalidPricingData)
+	}
+	if req.VIPLevel != "" && !entity.IsValidVIPLevel(req.VIPLevel) {
+		return nil, fmt.Errorf("%w: %q", entity.ErrInvalidVIPLevel, req.VIPLevel)
+	}
+
+	cartQuantities := make(map[uuid.UUID]int)
+	var productIDs []uuid.UUID
+	for _, item := range req.Items {
+		if item.ProductID == uuid.Nil || item.Quantity < 1 {
+			return nil, fmt.Errorf("%w: every item needs a product and a quantity of at least 1", entity.ErrInvalidPricingData)
+		}
+		if _, ok := cartQuantities[item.ProductID]; !ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+		cartQuantities[item.ProductID] += item.Quantity
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	byID := make(map[uuid.UUID]*entity.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+	now := uc.now()
+	for _, productID := range productIDs {
+		product, ok := byID[productID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", entity.ErrProductNotFound, productID)
+		}
+		// VIP only products and those in early access are sold to VIP members only
+		if err := product.CheckAccess(req.VIPLevel, now); err != nil {
+			return nil, fmt.Errorf("%w: %s", err, productID)
+		}
+	}
+
+	tiers, groups, vip, err := uc.loadRules(ctx, productIDs, req)
+	if err != nil {
+		return nil, err
+	}
+
+	cart := &CartPrice{
+		Lines:    make([]CartLinePrice, 0, len(req.Items)),
+		Subtotal: decimal.Zero,
+		Discount: decimal.Zero,
+		Total:    decimal.Zero,
+		Currency: "THB",
+		Policy:   uc.policy,
+		PricedAt: now,
+	}
+	for _, item := range req.Items {
+		product := byID[item.ProductID]
+		basePrice := decimal.NewFromFloat(product.BasePrice).Round(2)
+		priced := uc.policy.Price(entity.CartLinePricing{
+			BasePrice: basePrice,
+			Quantity:  cartQuantities[item.ProductID],
+			Tiers:     tiers[item.ProductID],
+			Group:     groups[item.ProductID],
+			VIP:       vip,
+			At:        now,
+		})
+
+		quantity := decimal.NewFromInt(int64(item.Quantity))
+		baseTotal := basePrice.Mul(quantity)
+		lineTotal := priced.UnitPrice.Mul(quantity)
+		cart.Lines = append(cart.Lines, CartLinePrice{
+			ProductID:    item.ProductID,
+			Quantity:     item.Quantity,
+			BasePrice:    basePrice,
+			UnitPrice:    priced.UnitPrice,
+			LineTotal:    lineTotal,
+			Discount:     baseTotal.Sub(lineTotal),
+			TierQuantity: cartQuantities[item.ProductID],
+			Applied:      priced.Applied,
+			Skipped:      priced.Skipped,
+			BelowCost:    product.CostPrice != nil && priced.UnitPrice.LessThan(decimal.NewFromFloat(*product.CostPrice).Round(2)),
+		})
+		cart.Subtotal = cart.Subtotal.Add(baseTotal)
+		cart.Total = cart.Total.Add(lineTotal)
+	}
+	cart.Discount = cart.Subtotal.Sub(cart.Total)
+
+	uc.logger.WithFields(logrus.Fields{
+		"customer_id":    req.CustomerID,
+		"customer_group": req.CustomerGroup,
+		"vip_level":      req.VIPLevel,
+		"lines":          len(cart.Lines),
+		"total":          cart.Total.StringFixed(2),
+	}).Debug("Cart priced")
+
+	return cart, nil
+}
+
+// loadRules loads the pricing rules the policy applies to the products of a cart
+func (uc *CartPricingUsecase) loadRules(ctx context.Context, productIDs []uuid.UUID, req *PriceCartRequest) (map[uuid.UUID][]*entity.ProductPricingTier, map[uuid.UUID]*entity.CustomerGroupPricing, *entity.VIPPricingBenefits, error) {
+	tiers := make(map[uuid.UUID][]*entity.ProductPricingTier)
+	if uc.policy.Applies(entity.PricingRuleTier) {
+		found, err := uc.pricingRepo.GetActivePricingTiers(ctx, productIDs)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get pricing tiers: %w", err)
+		}
+		for _, tier := range found {
+			tiers[tier.ProductID] = append(tiers[tier.ProductID], tier)
+		}
+	}
+
+	groups := make(map[uuid.UUID]*entity.CustomerGroupPricing)
+	if req.CustomerGroup != "" && uc.policy.Applies(entity.PricingRuleGroup) {
+		found, err := uc.pricingRepo.GetActiveCustomerGroupPricing(ctx, productIDs, req.CustomerGroup)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get customer group pricing: %w", err)
+		}
+		for _, pricing := range found {
+			groups[pricing.ProductID] = pricing
+		}
+	}
+
+	var vip *entity.VIPPricingBenefits
+	if req.VIPLevel != "" && uc.policy.Applies(entity.PricingRuleVIP) {
+		var err error
+		vip, err = uc.pricingRepo.GetVIPBenefits(ctx, req.VIPLevel)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to get VIP benefits: %w", err)
+		}
+	}
+
+	return tiers, groups, vip, nil
+}
@@ -0,0 +1,234 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+	"product/internal/infrastructure/events"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// dueLaunchBatch is how many due launches one run of the scheduler advances
+const dueLaunchBatch = 100
+
+// LaunchNotifier tells VIP members that early access to a launched product opened
+type LaunchNotifier interface {
+	// NotifyEarlyAccess messages the members of the launch's eligible VIP levels and returns how
+	// many were messaged
+	NotifyEarlyAccess(ctx context.Context, launch *entity.ProductLaunch, product *entity.Product) (int, error)
+}
+
+// LaunchUsecase schedules product launches, opens them to VIP members first and releases them
+// to everyone, telling the eligible members over LINE when early access opens
+type LaunchUsecase struct {
+	productRepo repository.ProductRepository
+	launchRepo  repository.LaunchRepository
+	notifier    LaunchNotifier
+	cache       ProductCache
+	eventPub    events.Publisher
+	logger      *logrus.Logger
+	now         func() time.Time
+}
+
+// NewLaunchUsecase creates a new launch usecase
+func NewLaunchUsecase(productRepo repository.ProductRepository, launchRepo repository.LaunchRepository, notifier LaunchNotifier, cache ProductCache, eventPub events.Publisher, logger *logrus.Logger) *LaunchUsecase {
+	return &LaunchUsecase{
+		productRepo: productRepo,
+		launchRepo:  launchRepo,
+		notifier:    notifier,
+		cache:       cache,
+		eventPub:    eventPub,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// CreateLaunchRequest schedules the launch of a product
+type CreateLaunchRequest struct {
+	// MinVIPLevel is the lowest VIP level with early access; without it every VIP level has it
+	MinVIPLevel   *string   `json:"min_vip_level"`
+	EarlyAccessAt time.Time `json:"early_access_at" binding:"required"`
+	PublicAt      time.Time `json:"public_at" binding:"required"`
+	// Quotas caps how much of the product each VIP level can reserve during early access.
+	// Levels without a quota are not capped.
+	Quotas    map[string]float64 `json:"quotas"`
+	Message   string             `json:"message" binding:"max=500"`
+	CreatedBy string             `json:"created_by" binding:"required"`
+}
+
+// CancelLaunchRequest calls off a launch that is not released yet
+type CancelLaunchRequest struct {
+	CancelledBy string `json:"cancelled_by" binding:"required"`
+}
+
+// CreateLaunch schedules the launch of a product
+func (uc *LaunchUsecase) CreateLaunch(ctx context.Context, productID uuid.UUID, req *CreateLaunchRequest) (*entity.ProductLaunch, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, entity.ErrProductNotFound
+	}
+
+	launch, err := entity.NewProductLaunch(product, req.MinVIPLevel, req.EarlyAccessAt, req.PublicAt, req.Quotas, req.Message, req.CreatedBy, uc.now())
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.launchRepo.CreateLaunch(ctx, launch); err != nil {
+		return nil, fmt.Errorf("failed to create launch: %w", err)
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"launch_id":       launch.ID,
+		"product_id":      productID,
+		"early_access_at": launch.EarlyAccessAt,
+		"public_at":       launch.PublicAt,
+		"created_by":      launch.CreatedBy,
+	}).Info("Product launch scheduled")
+
+	return launch, nil
+}
+
+// GetLaunch retrieves a launch of a product with its allocations
+func (uc *LaunchUsecase) GetLaunch(ctx context.Context, productID, id uuid.UUID) (*entity.ProductLaunch, error) {
+	launch, err := uc.launchRepo.GetLaunch(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get launch: %w", err)
+	}
+	if launch.ProductID != productID {
+		return nil, entity.ErrLaunchNotFound
+	}
+	return launch, nil
+}
+
+// ListLaunches lists the launches of a product, latest first
+func (uc *LaunchUsecase) ListLaunches(ctx context.Context, productID uuid.UUID) ([]*entity.ProductLaunch, error) {
+	launches, err := uc.launchRepo.ListLaunches(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list launches: %w", err)
+	}
+	return launches, nil
+}
+
+// CancelLaunch calls off a launch that is not released yet. A product already in early access
+// goes back to being sold to everyone.
+func (uc *LaunchUsecase) CancelLaunch(ctx context.Context, productID, id uuid.UUID, req *CancelLaunchRequest) (*entity.ProductLaunch, error) {
+	if _, err := uc.GetLaunch(ctx, productID, id); err != nil {
+		return nil, err
+	}
+
+	launch, product, err := uc.launchRepo.CancelLaunch(ctx, id, req.CancelledBy, uc.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel launch: %w", err)
+	}
+	uc.launchChanged(ctx, launch, product)
+	return launch, nil
+}
+
+// AdvanceLaunches opens early access of the launches due to open and releases those due to go
+// public, telling the eligible VIP members when early access opens
+func (uc *LaunchUsecase) AdvanceLaunches(ctx context.Context) (int, error) {
+	ids, err := uc.launchRepo.GetDueLaunchIDs(ctx, uc.now(), dueLaunchBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due launches: %w", err)
+	}
+
+	advanced := 0
+	for _, id := range ids {
+		launch, product, err := uc.launchRepo.AdvanceLaunch(ctx, id, uc.now())
+		if err != nil {
+			uc.logger.WithError(err).WithField("launch_id", id).Error("Failed to advance product launch")
+			continue
+		}
+		if launch == nil {
+			continue
+		}
+		advanced++
+		uc.launchChanged(ctx, launch, product)
+		if launch.Status == entity.LaunchStatusEarlyAccess {
+			uc.notifyEarlyAccess(ctx, launch, product)
+		}
+	}
+
+	if advanced > 0 {
+		uc.logger.WithField("launches", advanced).Info("Product launches advanced")
+	}
+	return advanced, nil
+}
+
+// StartLaunchScheduler advances due launches every interval until the context is cancelled
+func (uc *LaunchUsecase) StartLaunchScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	uc.logger.WithField("interval", interval).Info("Launch scheduler started")
+
+	for {
+		if _, err := uc.AdvanceLaunches(ctx); err != nil && ctx.Err() == nil {
+			uc.logger.WithError(err).Error("Advancing product launches failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			uc.logger.Info("Launch scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// notifyEarlyAccess tells the eligible VIP members that early access opened. Early access stays
+// open when messaging fails; the members reached so far are recorded.
+func (uc *LaunchUsecase) notifyEarlyAccess(ctx context.Context, launch *entity.ProductLaunch, product *entity.Product) {
+	log := uc.logger.WithFields(logrus.Fields{
+		"launch_id":  launch.ID,
+		"product_id": product.ID,
+	})
+
+	notified, err := uc.notifier.NotifyEarlyAccess(ctx, launch, product)
+	if err != nil {
+		log.WithError(err).Error("Failed to notify VIP members of early access")
+	}
+	launch.NotifiedCount = notified
+	if err := uc.launchRepo.SetNotifiedCount(ctx, launch.ID, notified); err != nil {
+		log.WithError(err).Error("Failed to record early access notifications")
+	}
+	log.WithField("notified", notified).Info("VIP members notified of early access")
+}
+
+// launchChanged drops the cached product whose VIP gating changed and announces the change
+func (uc *LaunchUsecase) launchChanged(ctx context.Context, launch *entity.ProductLaunch, product *entity.Product) {
+	log := uc.logger.WithFields(logrus.Fields{
+		"launch_id":  launch.ID,
+		"product_id": product.ID,
+		"status":     launch.Status,
+	})
+
+	// A cached product would keep its old VIP gating until it expires
+	if err := uc.cache.InvalidateProduct(ctx, product.ID); err != nil {
+		log.WithError(err).Error("Failed to invalidate cached product")
+	}
+	if err := uc.cache.InvalidateProductList(ctx); err != nil {
+		log.WithError(err).Error("Failed to invalidate cached product lists")
+	}
+
+	event := events.NewProductEvent(events.ProductLaunchUpdatedEvent, product.ID, product.SKU, product.Name, string(launch.Status), map[string]interface{}{
+		"launch_id":          launch.ID,
+		"min_vip_level":      launch.MinVIPLevel,
+		"eligible_levels":    launch.EligibleLevels(),
+		"vip_early_access":   product.VIPEarlyAccess,
+		"early_access_until": product.EarlyAccessUntil,
+		"public_at":          launch.PublicAt,
+	})
+	if err := uc.eventPub.PublishProductEvent(ctx, event); err != nil {
+		log.WithError(err).Error("Failed to publish launch event")
+	}
+
+	log.Info("Product launch changed")
+}
@@ -0,0 +1,381 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// MarginGuard refuses prices below cost or the minimum margin that no manager approved
+type MarginGuard interface {
+	GuardPrice(ctx context.Context, product *entity.Product, price float64, approvedBy string) (*entity.MarginCheck, error)
+}
+
+// MarginUsecase checks prices against the cost and margin targets of products, keeps the
+// minimum margin policies of categories and reports prices that fall under target
+type MarginUsecase struct {
+	productRepo      repository.ProductRepository
+	categoryRepo     repository.CategoryRepository
+	marginRepo       repository.MarginRepository
+	pricingRepo      repository.PricingRepository
+	policy           entity.PricingPolicy
+	defaultMinMargin float64
+	logger           *logrus.Logger
+	now              func() time.Time
+}
+
+// NewMarginUsecase creates a new margin usecase. Categories without a policy use the default
+// minimum margin.
+func NewMarginUsecase(productRepo repository.ProductRepository, categoryRepo repository.CategoryRepository, marginRepo repository.MarginRepository, pricingRepo repository.PricingRepository, policy entity.PricingPolicy, defaultMinMargin float64, logger *logrus.Logger) *MarginUsecase {
+	return &MarginUsecase{
+		productRepo:      productRepo,
+		categoryRepo:     categoryRepo,
+		marginRepo:       marginRepo,
+		pricingRepo:      pricingRepo,
+		policy:           policy,
+		defaultMinMargin: defaultMinMargin,
+		logger:           logger,
+		now:              time.Now,
+	}
+}
+
+// CheckMarginsRequest represents prices to check before selling at them
+type CheckMarginsRequest struct {
+	Lines []CheckMarginLine `json:"lines" binding:"required,min=1,dive"`
+}
+
+// CheckMarginLine is a unit price for a product
+type CheckMarginLine struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	UnitPrice float64   `json:"unit_price" binding:"min=0"`
+}
+
+// SetMarginPolicyRequest represents the minimum margin of a category
+type SetMarginPolicyRequest struct {
+	MinMarginPercent float64 `json:"min_margin_percent" binding:"min=0"`
+	UpdatedBy        string  `json:"updated_by" binding:"required,max=100"`
+}
+
+// MarginReport lists the promotional and VIP prices that make less than the margin target
+type MarginReport struct {
+	GeneratedAt             time.Time          `json:"generated_at"`
+	DefaultMinMarginPercent float64            `json:"default_min_margin_percent"`
+	Items                   []MarginReportItem `json:"items"`
+}
+
+// MarginReportItem is a price under the margin target of its product. The target is the
+// product's own, or the minimum margin of its category when it has none.
+type MarginReportItem struct {
+	ProductID           uuid.UUID  `json:"product_id"`
+	SKU                 string     `json:"sku"`
+	Name                string     `json:"name"`
+	CategoryID          *uuid.UUID `json:"category_id,omitempty"`
+	PriceType           string     `json:"price_type"` // "promotional" or "vip"
+	PromotionName       *string    `json:"promotion_name,omitempty"`
+	VIPLevel            string     `json:"vip_level,omitempty"`
+	BasePrice           float64    `json:"base_price"`
+	Price               float64    `json:"price"`
+	CostPrice           float64    `json:"cost_price"`
+	MarginPercent       float64    `json:"margin_percent"`
+	TargetMarginPercent float64    `json:"target_margin_percent"`
+	BelowCost           bool       `json:"below_cost"`
+}
+
+// GuardPrice checks a price of a product against its margins. A price below cost or the minimum
+// margin of the product's category is refused unless a manager approved it.
+func (uc *MarginUsecase) GuardPrice(ctx context.Context, product *entity.Product, price float64, approvedBy string) (*entity.MarginCheck, error) {
+	minMargin, err := uc.minMargin(ctx, product.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	check := entity.CheckMargin(product, price, minMargin)
+	if !check.NeedsApproval() {
+		return check, nil
+	}
+	if approvedBy == "" {
+		return nil, &entity.MarginApprovalError{Checks: []*entity.MarginCheck{check}}
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"product_id":  product.ID,
+		"price":       price,
+		"cost_price":  *product.CostPrice,
+		"margin":      *check.MarginPercent,
+		"approved_by": approvedBy,
+	}).Warn("Price below margin approved")
+
+	return check, nil
+}
+
+// CheckMargins checks unit prices against the cost and margins of their products
+func (uc *MarginUsecase) CheckMargins(ctx context.Context, req *CheckMarginsRequest) ([]*entity.MarginCheck, error) {
+	var productIDs []uuid.UUID
+	seen := make(map[uuid.UUID]bool)
+	for _, line := range req.Lines {
+		if line.ProductID == uuid.Nil || line.UnitPrice < 0 {
+			return nil, fmt.Errorf("%w: every line needs a product and a non-negative unit price", entity.ErrInvalidPricingData)
+		}
+		if !seen[line.ProductID] {
+			seen[line.ProductID] = true
+			productIDs = append(productIDs, line.ProductID)
+		}
+	}
+
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get products: %w", err)
+	}
+	byID := make(map[uuid.UUID]*entity.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	minMargins, err := uc.minMargins(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	checks := make([]*entity.MarginCheck, 0, len(req.Lines))
+	for _, line := range req.Lines {
+		product, ok := byID[line.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", entity.ErrProductNotFound, line.ProductID)
+		}
+		checks = append(checks, entity.CheckMargin(product, line.UnitPrice, minMargins.of(product.CategoryID)))
+	}
+	return checks, nil
+}
+
+// ListPolicies lists the minimum margin policies of the categories
+func (uc *MarginUsecase) ListPolicies(ctx context.Context) ([]*entity.CategoryMarginPolicy, error) {
+	policies, err := uc.marginRepo.ListPolicies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list margin policies: %w", err)
+	}
+	return policies, nil
+}
+
+// SetPolicy sets the minimum margin of a category
+func (uc *MarginUsecase) SetPolicy(ctx context.Context, categoryID uuid.UUID, req *SetMarginPolicyRequest) (*entity.CategoryMarginPolicy, error) {
+	category, err := uc.categoryRepo.GetByID(ctx, categoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+	if category == nil {
+		return nil, entity.ErrCategoryNotFound
+	}
+
+	policy, err := entity.NewCategoryMarginPolicy(categoryID, req.MinMarginPercent, req.UpdatedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := uc.marginRepo.SavePolicy(ctx, policy); err != nil {
+		return nil, fmt.Errorf("failed to save margin policy: %w", err)
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"category_id": categoryID,
+		"min_margin":  policy.MinMarginPercent,
+		"updated_by":  policy.UpdatedBy,
+	}).Info("Category margin policy set")
+
+	return policy, nil
+}
+
+// DeletePolicy removes the minimum margin of a category, which then uses the default
+func (uc *MarginUsecase) DeletePolicy(ctx context.Context, categoryID uuid.UUID) error {
+	if err := uc.marginRepo.DeletePolicy(ctx, categoryID); err != nil {
+		return fmt.Errorf("failed to delete margin policy: %w", err)
+	}
+
+	uc.logger.WithField("category_id", categoryID).Info("Category margin policy deleted")
+	return nil
+}
+
+// MarginReport lists the active promotional prices and the VIP prices of every level that make
+// less than the margin target of their product. VIP prices are those of a single unit.
+func (uc *MarginUsecase) MarginReport(ctx context.Context) (*MarginReport, error) {
+	now := uc.now()
+	report := &MarginReport{
+		GeneratedAt:             now,
+		DefaultMinMarginPercent: uc.defaultMinMargin,
+		Items:                   []MarginReportItem{},
+	}
+
+	products, err := uc.marginRepo.ListCostedProducts(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list costed products: %w", err)
+	}
+	if len(products) == 0 {
+		return report, nil
+	}
+	productIDs := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		productIDs = append(productIDs, product.ID)
+	}
+
+	minMargins, err := uc.minMargins(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	promotions, err := uc.marginRepo.GetActivePromotionalPrices(ctx, productIDs, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get promotional prices: %w", err)
+	}
+	promotionsByProduct := make(map[uuid.UUID][]*entity.Price)
+	for _, price := range promotions {
+		promotionsByProduct[price.ProductID] = append(promotionsByProduct[price.ProductID], price)
+	}
+
+	tiers, vipBenefits, err := uc.loadVIPPricing(ctx, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range products {
+		target := minMargins.of(product.CategoryID)
+		if product.ProfitMarginTarget != nil {
+			target = *product.ProfitMarginTarget
+		}
+		item := MarginReportItem{
+			ProductID:           product.ID,
+			SKU:                 product.SKU,
+			Name:                product.Name,
+			CategoryID:          product.CategoryID,
+			BasePrice:           product.BasePrice,
+			CostPrice:           *product.CostPrice,
+			TargetMarginPercent: target,
+		}
+
+		for _, promotion := range promotionsByProduct[product.ID] {
+			if underTarget(&item, promotion.Price) {
+				item.PriceType = "promotional"
+				item.PromotionName = promotion.PromotionName
+				report.Items = append(report.Items, item)
+			}
+		}
+
+		for _, vip := range vipBenefits {
+			priced := uc.policy.Price(entity.CartLinePricing{
+				BasePrice: decimal.NewFromFloat(product.BasePrice).Round(2),
+				Quantity:  1,
+				Tiers:     tiers[product.ID],
+				VIP:       vip,
+				At:        now,
+			})
+			if !appliedVIP(priced.Applied) {
+				continue
+			}
+			if underTarget(&item, priced.UnitPrice.InexactFloat64()) {
+				item.PriceType = "vip"
+				item.PromotionName = nil
+				item.VIPLevel = vip.VIPLevel
+				report.Items = append(report.Items, item)
+			}
+		}
+	}
+
+	return report, nil
+}
+
+// loadVIPPricing loads the quantity tiers of the products and the benefits of every active VIP
+// level, when the pricing policy applies VIP pricing
+func (uc *MarginUsecase) loadVIPPricing(ctx context.Context, productIDs []uuid.UUID) (map[uuid.UUID][]*entity.ProductPricingTier, []*entity.VIPPricingBenefits, error) {
+	if !uc.policy.Applies(entity.PricingRuleVIP) {
+		return nil, nil, nil
+	}
+
+	var benefits []*entity.VIPPricingBenefits
+	for _, level := range entity.VIPLevels() {
+		vip, err := uc.pricingRepo.GetVIPBenefits(ctx, level)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get VIP benefits: %w", err)
+		}
+		if vip != nil {
+			benefits = append(benefits, vip)
+		}
+	}
+
+	tiers := make(map[uuid.UUID][]*entity.ProductPricingTier)
+	if len(benefits) > 0 && uc.policy.Applies(entity.PricingRuleTier) {
+		found, err := uc.pricingRepo.GetActivePricingTiers(ctx, productIDs)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get pricing tiers: %w", err)
+		}
+		for _, tier := range found {
+			tiers[tier.ProductID] = append(tiers[tier.ProductID], tier)
+		}
+	}
+	return tiers, benefits, nil
+}
+
+// minMargin returns the minimum margin of a category
+func (uc *MarginUsecase) minMargin(ctx context.Context, categoryID *uuid.UUID) (float64, error) {
+	if categoryID == nil {
+		return uc.defaultMinMargin, nil
+	}
+	policy, err := uc.marginRepo.GetPolicy(ctx, *categoryID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get margin policy: %w", err)
+	}
+	if policy == nil {
+		return uc.defaultMinMargin, nil
+	}
+	return policy.MinMarginPercent, nil
+}
+
+// minMargins loads the minimum margin of every category with a policy
+func (uc *MarginUsecase) minMargins(ctx context.Context) (categoryMinMargins, error) {
+	policies, err := uc.marginRepo.ListPolicies(ctx)
+	if err != nil {
+		return categoryMinMargins{}, fmt.Errorf("failed to list margin policies: %w", err)
+	}
+	margins := categoryMinMargins{byCategory: make(map[uuid.UUID]float64, len(policies)), fallback: uc.defaultMinMargin}
+	for _, policy := range policies {
+		margins.byCategory[policy.CategoryID] = policy.MinMarginPercent
+	}
+	return margins, nil
+}
+
+// categoryMinMargins are the minimum margins of categories with a policy and the default
+type categoryMinMargins struct {
+	byCategory map[uuid.UUID]float64
+	fallback   float64
+}
+
+func (m categoryMinMargins) of(categoryID *uuid.UUID) float64 {
+	if categoryID != nil {
+		if margin, ok := m.byCategory[*categoryID]; ok {
+			return margin
+		}
+	}
+	return m.fallback
+}
+
+// underTarget fills the price and margin of a report item and reports whether the price makes
+// less than the item's target
+func underTarget(item *MarginReportItem, price float64) bool {
+	item.Price = price
+	item.MarginPercent = entity.MarginPercent(price, item.CostPrice)
+	item.BelowCost = price < item.CostPrice
+	return item.MarginPercent < item.TargetMarginPercent
+}
+
+// appliedVIP reports whether a VIP rule lowered the price
+func appliedVIP(applied []entity.AppliedPricingRule) bool {
+	for _, rule := range applied {
+		if rule.Rule == entity.PricingRuleVIP {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,224 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+)
+
+// The fakes embed the repository interfaces and implement only what the use cases call
+
+type fakeProductRepo struct {
+	repository.ProductRepository
+	products map[uuid.UUID]*entity.Product
+}
+
+func (r *fakeProductRepo) GetByID(ctx context.Context, id uuid.UUID) (*entity.Product, error) {
+	return r.products[id], nil
+}
+
+func (r *fakeProductRepo) GetByIDs(ctx context.Context, ids []uuid.UUID) ([]*entity.Product, error) {
+	var products []*entity.Product
+	for _, id := range ids {
+		if product, ok := r.products[id]; ok {
+			products = append(products, product)
+		}
+	}
+	return products, nil
+}
+
+type fakeMarginRepo struct {
+	repository.MarginRepository
+	policies   map[uuid.UUID]*entity.CategoryMarginPolicy
+	costed     []*entity.Product
+	promotions []*entity.Price
+}
+
+func (r *fakeMarginRepo) GetPolicy(ctx context.Context, categoryID uuid.UUID) (*entity.CategoryMarginPolicy, error) {
+	return r.policies[categoryID], nil
+}
+
+func (r *fakeMarginRepo) ListPolicies(ctx context.Context) ([]*entity.CategoryMarginPolicy, error) {
+	var policies []*entity.CategoryMarginPolicy
+	for _, policy := range r.policies {
+		policies = append(policies, policy)
+	}
+	return policies, nil
+}
+
+func (r *fakeMarginRepo) ListCostedProducts(ctx context.Context) ([]*entity.Product, error) {
+	return r.costed, nil
+}
+
+func (r *fakeMarginRepo) GetActivePromotionalPrices(ctx context.Context, productIDs []uuid.UUID, at time.Time) ([]*entity.Price, error) {
+	return r.promotions, nil
+}
+
+type fakePricingRepo struct {
+	repository.PricingRepository
+	vip map[string]*entity.VIPPricingBenefits
+}
+
+func (r *fakePricingRepo) GetVIPBenefits(ctx context.Context, vipLevel string) (*entity.VIPPricingBenefits, error) {
+	return r.vip[vipLevel], nil
+}
+
+func (r *fakePricingRepo) GetActivePricingTiers(ctx context.Context, productIDs []uuid.UUID) ([]*entity.ProductPricingTier, error) {
+	return nil, nil
+}
+
+func discardLogger() *logrus.Logger {
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return logger
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+// newTestMarginUsecase has a default minimum margin of 10% and a 20% minimum for category
+func newTestMarginUsecase(category uuid.UUID, products ...*entity.Product) (*MarginUsecase, *fakeMarginRepo, *fakePricingRepo) {
+	productRepo := &fakeProductRepo{products: map[uuid.UUID]*entity.Product{}}
+	for _, product := range products {
+		productRepo.products[product.ID] = product
+	}
+	marginRepo := &fakeMarginRepo{policies: map[uuid.UUID]*entity.CategoryMarginPolicy{
+		category: {CategoryID: category, MinMarginPercent: 20, UpdatedBy: "manager-1"},
+	}}
+	pricingRepo := &fakePricingRepo{vip: map[string]*entity.VIPPricingBenefits{}}
+	uc := NewMarginUsecase(productRepo, nil, marginRepo, pricingRepo, entity.DefaultPricingPolicy(), 10, discardLogger())
+	return uc, marginRepo, pricingRepo
+}
+
+func TestGuardPrice(t *testing.T) {
+	category := uuid.New()
+	inCategory := &entity.Product{ID: uuid.New(), CategoryID: &category, BasePrice: 100, CostPrice: floatPtr(80)}
+	uncategorised := &entity.Product{ID: uuid.New(), BasePrice: 100, CostPrice: floatPtr(80)}
+	uncosted := &entity.Product{ID: uuid.New(), BasePrice: 100}
+	uc, _, _ := newTestMarginUsecase(category)
+
+	tests := []struct {
+		name       string
+		product    *entity.Product
+		price      float64
+		approvedBy string
+		refused    bool
+	}{
+		{"over the category minimum", inCategory, 110, "", false},
+		// 15% clears the default minimum but not the category's 20%
+		{"under the category minimum", inCategory, 94.12, "", true},
+		{"under the default minimum", uncategorised, 85, "", true},
+		{"over the default minimum", uncategorised, 94.12, "", false},
+		{"below cost", uncategorised, 70, "", true},
+		{"below cost with approval", uncategorised, 70, "manager-1", false},
+		{"cost unknown", uncosted, 1, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check, err := uc.GuardPrice(context.Background(), tt.product, tt.price, tt.approvedBy)
+			if tt.refused {
+				var approvalErr *entity.MarginApprovalError
+				if !errors.As(err, &approvalErr) || len(approvalErr.Checks) != 1 {
+					t.Fatalf("got %v, want a MarginApprovalError with the check", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GuardPrice: %v", err)
+			}
+			if check.Price != tt.price {
+				t.Errorf("checked price %v, want %v", check.Price, tt.price)
+			}
+		})
+	}
+}
+
+func TestCheckMargins(t *testing.T) {
+	category := uuid.New()
+	product := &entity.Product{ID: uuid.New(), CategoryID: &category, BasePrice: 100, CostPrice: floatPtr(80)}
+	uc, _, _ := newTestMarginUsecase(category, product)
+
+	checks, err := uc.CheckMargins(context.Background(), &CheckMarginsRequest{Lines: []CheckMarginLine{
+		{ProductID: product.ID, UnitPrice: 120},
+		{ProductID: product.ID, UnitPrice: 90},
+	}})
+	if err != nil {
+		t.Fatalf("CheckMargins: %v", err)
+	}
+	if len(checks) != 2 {
+		t.Fatalf("got %d checks, want 2", len(checks))
+	}
+	if checks[0].NeedsApproval() || !checks[1].NeedsApproval() {
+		t.Errorf("approval needed = %v, %v; want false, true", checks[0].NeedsApproval(), checks[1].NeedsApproval())
+	}
+	if checks[1].MinMarginPercent != 20 {
+		t.Errorf("checked against %v%%, want the category's 20%%", checks[1].MinMarginPercent)
+	}
+
+	_, err = uc.CheckMargins(context.Background(), &CheckMarginsRequest{Lines: []CheckMarginLine{{ProductID: uuid.New(), UnitPrice: 1}}})
+	if !errors.Is(err, entity.ErrProductNotFound) {
+		t.Errorf("unknown product: got %v, want ErrProductNotFound", err)
+	}
+	_, err = uc.CheckMargins(context.Background(), &CheckMarginsRequest{Lines: []CheckMarginLine{{ProductID: product.ID, UnitPrice: -1}}})
+	if !errors.Is(err, entity.ErrInvalidPricingData) {
+		t.Errorf("negative price: got %v, want ErrInvalidPricingData", err)
+	}
+}
+
+func TestMarginReport(t *testing.T) {
+	category := uuid.New()
+	// Uses the category minimum of 20%
+	bread := &entity.Product{ID: uuid.New(), SKU: "BREAD", CategoryID: &category, BasePrice: 100, CostPrice: floatPtr(70)}
+	// Its own 40% target wins over the default minimum
+	cake := &entity.Product{ID: uuid.New(), SKU: "CAKE", BasePrice: 200, CostPrice: floatPtr(100), ProfitMarginTarget: floatPtr(40)}
+	uc, marginRepo, pricingRepo := newTestMarginUsecase(category, bread, cake)
+	marginRepo.costed = []*entity.Product{bread, cake}
+
+	sale := "Weekend sale"
+	marginRepo.promotions = []*entity.Price{
+		{ProductID: bread.ID, PriceType: "promotional", Price: 80, PromotionName: &sale}, // 12.5%
+		{ProductID: cake.ID, PriceType: "promotional", Price: 190, PromotionName: &sale}, // 47.37%
+	}
+	// 20% off leaves cake at 160 (37.5%) and bread at 80 (12.5%)
+	discount := decimal.NewFromInt(20)
+	pricingRepo.vip[entity.VIPLevelGold] = &entity.VIPPricingBenefits{
+		VIPLevel:                 entity.VIPLevelGold,
+		GlobalDiscountPercentage: &discount,
+		QuantityMultiplier:       decimal.NewFromInt(1),
+		IsActive:                 true,
+	}
+
+	report, err := uc.MarginReport(context.Background())
+	if err != nil {
+		t.Fatalf("MarginReport: %v", err)
+	}
+
+	type key struct {
+		sku       string
+		priceType string
+	}
+	found := make(map[key]MarginReportItem)
+	for _, item := range report.Items {
+		found[key{item.SKU, item.PriceType}] = item
+	}
+	if len(found) != 3 {
+		t.Fatalf("report items %+v, want bread promotional and VIP, cake VIP", report.Items)
+	}
+	if item := found[key{"BREAD", "promotional"}]; item.TargetMarginPercent != 20 || item.MarginPercent != 12.5 || item.PromotionName == nil {
+		t.Errorf("bread promotion = %+v", item)
+	}
+	if item := found[key{"CAKE", "vip"}]; item.TargetMarginPercent != 40 || item.Price != 160 || item.VIPLevel != entity.VIPLevelGold {
+		t.Errorf("cake VIP price = %+v", item)
+	}
+	if _, ok := found[key{"BREAD", "vip"}]; !ok {
+		t.Error("bread VIP price under target is missing")
+	}
+}
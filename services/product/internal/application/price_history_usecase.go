@@ -0,0 +1,249 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+	"product/internal/infrastructure/events"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// dueScheduledPriceChangeBatch is the most scheduled price changes applied in one run
+const dueScheduledPriceChangeBatch = 100
+
+// PriceCache removes cached prices once a price changes
+type PriceCache interface {
+	InvalidatePrice(ctx context.Context, productID uuid.UUID) error
+}
+
+// PriceHistoryUsecase schedules base price changes, applies them when they are due and answers
+// what a product cost at any time
+type PriceHistoryUsecase struct {
+	productRepo repository.ProductRepository
+	historyRepo repository.PriceHistoryRepository
+	margins     MarginGuard
+	cache       PriceCache
+	eventPub    events.Publisher
+	logger      *logrus.Logger
+	now         func() time.Time
+}
+
+// NewPriceHistoryUsecase creates a new price history usecase
+func NewPriceHistoryUsecase(productRepo repository.ProductRepository, historyRepo repository.PriceHistoryRepository, margins MarginGuard, cache PriceCache, eventPub events.Publisher, logger *logrus.Logger) *PriceHistoryUsecase {
+	return &PriceHistoryUsecase{
+		productRepo: productRepo,
+		historyRepo: historyRepo,
+		margins:     margins,
+		cache:       cache,
+		eventPub:    eventPub,
+		logger:      logger,
+		now:         time.Now,
+	}
+}
+
+// SchedulePriceChangeRequest represents a base price change that takes effect later
+type SchedulePriceChangeRequest struct {
+	Price       float64   `json:"price" binding:"min=0"`
+	EffectiveAt time.Time `json:"effective_at" binding:"required"`
+	Reason      string    `json:"reason" binding:"required"`
+	// ChangedBy is who schedules the change. The handler sets it from the authenticated caller;
+	// only services calling without a token name it in the request body.
+	ChangedBy string `json:"changed_by" binding:"max=100"`
+	// ApprovedBy is the manager approving a price below cost or the minimum margin. The handler
+	// sets it from the authenticated caller; it is never read from the request body.
+	ApprovedBy string `json:"-"`
+}
+
+// CancelScheduledPriceChangeRequest represents the cancellation of a scheduled price change
+type CancelScheduledPriceChangeRequest struct {
+	// ChangedBy is who cancels the change, set like SchedulePriceChangeRequest.ChangedBy
+	ChangedBy string `json:"changed_by" binding:"max=100"`
+}
+
+// SchedulePriceChange schedules a change of a product's base price
+func (uc *PriceHistoryUsecase) SchedulePriceChange(ctx context.Context, productID uuid.UUID, req *SchedulePriceChangeRequest) (*entity.ScheduledPriceChange, error) {
+	product, err := uc.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	change, err := entity.NewScheduledPriceChange(productID, req.Price, req.EffectiveAt, req.ChangedBy, req.Reason, uc.now())
+	if err != nil {
+		return nil, err
+	}
+	check, err := uc.margins.GuardPrice(ctx, product, req.Price, req.ApprovedBy)
+	if err != nil {
+		return nil, err
+	}
+	if check.NeedsApproval() {
+		change.ApprovedBy = &req.ApprovedBy
+	}
+	if err := uc.historyRepo.CreateScheduledChange(ctx, change); err != nil {
+		return nil, fmt.Errorf("failed to schedule price change: %w", err)
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"product_id":   productID,
+		"change_id":    change.ID,
+		"new_price":    change.NewPrice,
+		"effective_at": change.EffectiveAt,
+		"changed_by":   change.RequestedBy,
+	}).Info("Price change scheduled")
+
+	return change, nil
+}
+
+// ListScheduledChanges lists the scheduled price changes of a product
+func (uc *PriceHistoryUsecase) ListScheduledChanges(ctx context.Context, productID uuid.UUID) ([]*entity.ScheduledPriceChange, error) {
+	if _, err := uc.getProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	changes, err := uc.historyRepo.ListScheduledChanges(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduled price changes: %w", err)
+	}
+	return changes, nil
+}
+
+// CancelScheduledChange cancels a price change that has not taken effect yet
+func (uc *PriceHistoryUsecase) CancelScheduledChange(ctx context.Context, productID, changeID uuid.UUID, req *CancelScheduledPriceChangeRequest) (*entity.ScheduledPriceChange, error) {
+	if req.ChangedBy == "" {
+		return nil, fmt.Errorf("%w: who cancelled the change is required", entity.ErrInvalidPriceChange)
+	}
+
+	change, err := uc.historyRepo.GetScheduledChange(ctx, changeID)
+	if err != nil {
+		return nil, err
+	}
+	if change.ProductID != productID {
+		return nil, entity.ErrScheduledPriceChangeNotFound
+	}
+
+	change, err = uc.historyRepo.CancelScheduledChange(ctx, changeID, req.ChangedBy, uc.now())
+	if err != nil {
+		return nil, err
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"product_id":   productID,
+		"change_id":    changeID,
+		"cancelled_by": req.ChangedBy,
+	}).Info("Scheduled price change cancelled")
+
+	return change, nil
+}
+
+// GetPriceHistory lists the latest base price changes of a product, newest first
+func (uc *PriceHistoryUsecase) GetPriceHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.PriceHistoryEntry, error) {
+	if _, err := uc.getProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	entries, err := uc.historyRepo.GetHistory(ctx, productID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	return entries, nil
+}
+
+// GetPriceAsOf returns the base price a product had at a time
+func (uc *PriceHistoryUsecase) GetPriceAsOf(ctx context.Context, productID uuid.UUID, at time.Time) (*entity.PriceAsOf, error) {
+	product, err := uc.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	before, after, err := uc.historyRepo.GetChangesAround(ctx, productID, at)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price history: %w", err)
+	}
+	return entity.BasePriceAsOf(product, before, after, at)
+}
+
+// ApplyDueChanges applies the scheduled price changes that are due
+func (uc *PriceHistoryUsecase) ApplyDueChanges(ctx context.Context) (int, error) {
+	now := uc.now()
+	ids, err := uc.historyRepo.GetDueScheduledChangeIDs(ctx, now, dueScheduledPriceChangeBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get due price changes: %w", err)
+	}
+
+	applied := 0
+	for _, id := range ids {
+		entry, err := uc.historyRepo.ApplyScheduledChange(ctx, id, now)
+		if err != nil {
+			uc.logger.WithError(err).WithField("change_id", id).Error("Failed to apply scheduled price change")
+			continue
+		}
+		if entry == nil {
+			continue
+		}
+		applied++
+		uc.priceChanged(ctx, entry)
+	}
+
+	if applied > 0 {
+		uc.logger.WithField("changes", applied).Info("Scheduled price changes applied")
+	}
+	return applied, nil
+}
+
+// StartPriceScheduler applies due price changes every interval until the context is cancelled
+func (uc *PriceHistoryUsecase) StartPriceScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	uc.logger.WithField("interval", interval).Info("Price change scheduler started")
+
+	for {
+		if _, err := uc.ApplyDueChanges(ctx); err != nil && ctx.Err() == nil {
+			uc.logger.WithError(err).Error("Applying scheduled price changes failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			uc.logger.Info("Price change scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// priceChanged drops the cached prices of a product whose price changed and announces the change
+func (uc *PriceHistoryUsecase) priceChanged(ctx context.Context, entry *entity.PriceHistoryEntry) {
+	log := uc.logger.WithFields(logrus.Fields{
+		"product_id": entry.ProductID,
+		"old_price":  entry.OldPrice,
+		"new_price":  entry.NewPrice,
+	})
+
+	// A stale cached price would keep selling at the old price until it expires
+	if err := uc.cache.InvalidatePrice(ctx, entry.ProductID); err != nil {
+		log.WithError(err).Error("Failed to invalidate cached prices")
+	}
+
+	event := events.NewPricingEvent(events.PriceUpdatedEvent, entry.ProductID, "", entry.OldPrice, entry.NewPrice, string(entry.Source))
+	if err := uc.eventPub.PublishPricingEvent(ctx, event); err != nil {
+		log.WithError(err).Error("Failed to publish price updated event")
+	}
+
+	log.Info("Price changed")
+}
+
+// getProduct retrieves a product or reports it missing
+func (uc *PriceHistoryUsecase) getProduct(ctx context.Context, productID uuid.UUID) (*entity.Product, error) {
+	product, err := uc.productRepo.GetByID(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, entity.ErrProductNotFound
+	}
+	return product, nil
+}
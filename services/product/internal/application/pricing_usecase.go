@@ -12,19 +12,30 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// PricingUsecase handles pricing business logic
+// PricingUsecase manages the price rows of products. What a customer pays is calculated by
+// CartPricingUsecase with the cart PricingPolicy, so there is one pricing path.
 type PricingUsecase struct {
 	priceRepo   repository.PriceRepository
+	historyRepo repository.PriceHistoryRepository
 	productRepo repository.ProductRepository
+	margins     MarginGuard
+	cache       PriceCache
 	logger      *logrus.Logger
+	now         func() time.Time
 }
 
-// NewPricingUsecase creates a new pricing usecase
-func NewPricingUsecase(priceRepo repository.PriceRepository, productRepo repository.ProductRepository, logger *logrus.Logger) *PricingUsecase {
+// NewPricingUsecase creates a new pricing usecase. Prices below cost or the minimum margin need
+// a manager's approval, as base prices do, and every change of a price row is recorded in the
+// price row history.
+func NewPricingUsecase(priceRepo repository.PriceRepository, historyRepo repository.PriceHistoryRepository, productRepo repository.ProductRepository, margins MarginGuard, cache PriceCache, logger *logrus.Logger) *PricingUsecase {
 	return &PricingUsecase{
 		priceRepo:   priceRepo,
+		historyRepo: historyRepo,
 		productRepo: productRepo,
+		margins:     margins,
+		cache:       cache,
 		logger:      logger,
+		now:         time.Now,
 	}
 }
 
@@ -44,6 +55,12 @@ type CreatePriceRequest struct {
 	LocationIDs      []uuid.UUID `json:"location_ids"`
 	CustomerGroupIDs []uuid.UUID `json:"customer_group_ids"`
 	Priority         int         `json:"priority"`
+	// Who created the price and why, for the price row history
+	ChangedBy string `json:"changed_by" binding:"required,max=100"`
+	Reason    string `json:"reason" binding:"required"`
+	// ApprovedBy is the manager approving a price below cost or the minimum margin. The handler
+	// sets it from the authenticated caller; it is never read from the request body.
+	ApprovedBy string `json:"-"`
 }
 
 // UpdatePriceRequest represents the request to update a price
@@ -59,17 +76,18 @@ type UpdatePriceRequest struct {
 	CustomerGroupIDs []uuid.UUID `json:"customer_group_ids"`
 	Priority         *int        `json:"priority"`
 	IsActive         *bool       `json:"is_active"`
+	// Who changed the price and why, for the price row history
+	ChangedBy string `json:"changed_by" binding:"required,max=100"`
+	Reason    string `json:"reason" binding:"required"`
+	// ApprovedBy is the manager approving a price below cost or the minimum margin. The handler
+	// sets it from the authenticated caller; it is never read from the request body.
+	ApprovedBy string `json:"-"`
 }
 
-// PriceCalculationRequest represents request for price calculation
-type PriceCalculationRequest struct {
-	ProductID     uuid.UUID   `json:"product_id" validate:"required"`
-	CustomerID    *uuid.UUID  `json:"customer_id"`
-	VIPTierID     *uuid.UUID  `json:"vip_tier_id"`
-	GroupIDs      []uuid.UUID `json:"group_ids"`
-	LocationID    uuid.UUID   `json:"location_id" validate:"required"`
-	Quantity      int         `json:"quantity" validate:"required,min=1"`
-	CalculateDate *time.Time  `json:"calculate_date"`
+// DeletePriceRequest says who deleted a price and why, for the price row history
+type DeletePriceRequest struct {
+	ChangedBy string `json:"changed_by" binding:"required,max=100"`
+	Reason    string `json:"reason" binding:"required"`
 }
 
 // CreatePrice creates a new price
@@ -93,6 +111,10 @@ func (uc *PricingUsecase) CreatePrice(ctx context.Context, req *CreatePriceReque
 	if product == nil {
 		return nil, fmt.Errorf("product not found")
 	}
+	check, err := uc.margins.GuardPrice(ctx, product, req.Price, req.ApprovedBy)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create new price
 	price, err := entity.NewPrice(req.ProductID, req.PriceType, req.Price)
@@ -120,10 +142,19 @@ func (uc *PricingUsecase) CreatePrice(ctx context.Context, req *CreatePriceReque
 	price.SetCustomerGroupIDs(req.CustomerGroupIDs)
 	price.SetPriority(req.Priority)
 
-	// Save to database
-	if err := uc.priceRepo.Create(ctx, price); err != nil {
+	change, err := entity.NewPriceRowChange(nil, price, req.ChangedBy, req.Reason, uc.now())
+	if err != nil {
+		return nil, err
+	}
+	if check.NeedsApproval() {
+		change.ApprovedBy = &req.ApprovedBy
+	}
+
+	// Save to database, recording the new row in the price row history
+	if err := uc.historyRepo.SavePriceRow(ctx, price, change); err != nil {
 		return nil, fmt.Errorf("failed to save price: %w", err)
 	}
+	uc.priceChanged(ctx, change)
 
 	uc.logger.WithFields(logrus.Fields{
 		"price_id":   price.ID,
@@ -169,6 +200,7 @@ func (uc *PricingUsecase) UpdatePrice(ctx context.Context, id uuid.UUID, req *Up
 	if price == nil {
 		return nil, fmt.Errorf("price not found")
 	}
+	before := *price
 
 	// Update fields if provided
 	if req.Price != nil {
@@ -209,17 +241,43 @@ func (uc *PricingUsecase) UpdatePrice(ctx context.Context, id uuid.UUID, req *Up
 		}
 	}
 
-	// Save changes
-	if err := uc.priceRepo.Update(ctx, price); err != nil {
+	// A new amount, or a price put back on sale, is checked against the margins again
+	var approvedBy *string
+	if req.Price != nil || (req.IsActive != nil && *req.IsActive) {
+		product, err := uc.productRepo.GetByID(ctx, price.ProductID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get product: %w", err)
+		}
+		if product == nil {
+			return nil, fmt.Errorf("product not found")
+		}
+		check, err := uc.margins.GuardPrice(ctx, product, price.Price, req.ApprovedBy)
+		if err != nil {
+			return nil, err
+		}
+		if check.NeedsApproval() {
+			approvedBy = &req.ApprovedBy
+		}
+	}
+
+	change, err := entity.NewPriceRowChange(&before, price, req.ChangedBy, req.Reason, uc.now())
+	if err != nil {
+		return nil, err
+	}
+	change.ApprovedBy = approvedBy
+
+	// Save changes, recording the old and new row in the price row history
+	if err := uc.historyRepo.SavePriceRow(ctx, price, change); err != nil {
 		return nil, fmt.Errorf("failed to update price: %w", err)
 	}
+	uc.priceChanged(ctx, change)
 
 	uc.logger.WithField("price_id", price.ID).Info("Price updated successfully")
 	return price, nil
 }
 
-// DeletePrice deletes a price
-func (uc *PricingUsecase) DeletePrice(ctx context.Context, id uuid.UUID) error {
+// DeletePrice deletes a price, keeping its last row in the price row history
+func (uc *PricingUsecase) DeletePrice(ctx context.Context, id uuid.UUID, req *DeletePriceRequest) error {
 	// Get existing price
 	price, err := uc.priceRepo.GetByID(ctx, id)
 	if err != nil {
@@ -230,71 +288,44 @@ func (uc *PricingUsecase) DeletePrice(ctx context.Context, id uuid.UUID) error {
 		return fmt.Errorf("price not found")
 	}
 
+	change, err := entity.NewPriceRowChange(price, nil, req.ChangedBy, req.Reason, uc.now())
+	if err != nil {
+		return err
+	}
+
 	// Delete price
-	if err := uc.priceRepo.Delete(ctx, id); err != nil {
+	if err := uc.historyRepo.SavePriceRow(ctx, price, change); err != nil {
 		return fmt.Errorf("failed to delete price: %w", err)
 	}
+	uc.priceChanged(ctx, change)
 
 	uc.logger.WithField("price_id", id).Info("Price deleted successfully")
 	return nil
 }
 
-// CalculatePrice calculates the best price for a product based on conditions
-func (uc *PricingUsecase) CalculatePrice(ctx context.Context, req *PriceCalculationRequest) (*entity.PriceCalculation, error) {
-	// Get product to ensure it exists and get base price
-	product, err := uc.productRepo.GetByID(ctx, req.ProductID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get product: %w", err)
-	}
-	if product == nil {
-		return nil, fmt.Errorf("product not found")
-	}
-
-	// Create customer info for price calculation
-	customerInfo := &repository.CustomerInfo{
-		LocationID: req.LocationID,
-		Quantity:   req.Quantity,
-	}
-
-	if req.CustomerID != nil {
-		customerInfo.CustomerID = *req.CustomerID
-	}
-	if req.VIPTierID != nil {
-		customerInfo.VIPTierID = req.VIPTierID
-	}
-	customerInfo.GroupIDs = req.GroupIDs
-
-	// Try to get effective price based on conditions
-	effectivePrice, err := uc.priceRepo.GetEffectivePrice(ctx, req.ProductID, "", customerInfo)
+// GetPriceRowHistory lists the changes of a product's VIP, bulk and promotional prices, newest
+// first
+func (uc *PricingUsecase) GetPriceRowHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.PriceRowChange, error) {
+	changes, err := uc.historyRepo.GetPriceRowHistory(ctx, productID, limit)
 	if err != nil {
-		uc.logger.WithError(err).Warn("Failed to get effective price, using base price")
-	}
-
-	// Create price calculation result
-	calculation := &entity.PriceCalculation{
-		ProductID:    req.ProductID,
-		BasePrice:    product.BasePrice,
-		AppliedPrice: product.BasePrice,
-		PriceType:    "base",
-		Currency:     "THB",
-	}
-
-	if effectivePrice != nil {
-		calculation.AppliedPrice = effectivePrice.Price
-		calculation.PriceType = effectivePrice.PriceType
-		calculation.Currency = effectivePrice.Currency
-		calculation.ValidFrom = effectivePrice.ValidFrom
-		calculation.ValidTo = effectivePrice.ValidTo
-		calculation.DiscountPercent = effectivePrice.DiscountPercent
-
-		// Calculate savings
-		if effectivePrice.Price < product.BasePrice {
-			savings := product.BasePrice - effectivePrice.Price
-			calculation.Savings = &savings
-		}
+		return nil, fmt.Errorf("failed to get price row history: %w", err)
 	}
+	return changes, nil
+}
 
-	return calculation, nil
+// priceChanged drops the cached prices of a product whose price row changed
+func (uc *PricingUsecase) priceChanged(ctx context.Context, change *entity.PriceRowChange) {
+	log := uc.logger.WithFields(logrus.Fields{
+		"price_id":   change.PriceID,
+		"product_id": change.ProductID,
+		"price_type": change.PriceType,
+		"action":     change.Action,
+		"changed_by": change.ChangedBy,
+	})
+	if err := uc.cache.InvalidatePrice(ctx, change.ProductID); err != nil {
+		log.WithError(err).Error("Failed to invalidate cached prices")
+	}
+	log.Info("Price row changed")
 }
 
 // GetVIPPrices gets VIP prices for a product
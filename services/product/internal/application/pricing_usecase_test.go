@@ -0,0 +1,120 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+type fakePriceRepo struct {
+	repository.PriceRepository
+	prices map[uuid.UUID]*entity.Price
+}
+
+func (r *fakePriceRepo) GetByID(ctx context.Context, id uuid.UUID) (*entity.Price, error) {
+	price, ok := r.prices[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *price
+	return &copied, nil
+}
+
+// recordingHistoryRepo saves price rows in the price repository and records their changes
+type recordingHistoryRepo struct {
+	repository.PriceHistoryRepository
+	prices  *fakePriceRepo
+	changes []*entity.PriceRowChange
+}
+
+func (r *recordingHistoryRepo) SavePriceRow(ctx context.Context, price *entity.Price, change *entity.PriceRowChange) error {
+	if change.Action == entity.PriceRowDeleted {
+		delete(r.prices.prices, price.ID)
+	} else {
+		r.prices.prices[price.ID] = price
+	}
+	r.changes = append(r.changes, change)
+	return nil
+}
+
+type recordingPriceCache struct {
+	invalidated []uuid.UUID
+}
+
+func (c *recordingPriceCache) InvalidatePrice(ctx context.Context, productID uuid.UUID) error {
+	c.invalidated = append(c.invalidated, productID)
+	return nil
+}
+
+func TestPriceRowChangesAreRecorded(t *testing.T) {
+	category := uuid.New()
+	product := &entity.Product{ID: uuid.New(), CategoryID: &category, BasePrice: 100}
+	margins, _, _ := newTestMarginUsecase(category, product)
+	prices := &fakePriceRepo{prices: map[uuid.UUID]*entity.Price{}}
+	history := &recordingHistoryRepo{prices: prices}
+	cache := &recordingPriceCache{}
+	uc := NewPricingUsecase(prices, history, &fakeProductRepo{products: map[uuid.UUID]*entity.Product{product.ID: product}}, margins, cache, discardLogger())
+	ctx := context.Background()
+
+	price, err := uc.CreatePrice(ctx, &CreatePriceRequest{
+		ProductID: product.ID,
+		PriceType: "promotional",
+		Price:     90,
+		ChangedBy: "manager-1",
+		Reason:    "Weekend sale",
+	})
+	if err != nil {
+		t.Fatalf("CreatePrice: %v", err)
+	}
+
+	newPrice := 85.0
+	if _, err := uc.UpdatePrice(ctx, price.ID, &UpdatePriceRequest{Price: &newPrice, ChangedBy: "manager-2", Reason: "Deeper discount"}); err != nil {
+		t.Fatalf("UpdatePrice: %v", err)
+	}
+	if err := uc.DeletePrice(ctx, price.ID, &DeletePriceRequest{ChangedBy: "manager-1", Reason: "Sale ended"}); err != nil {
+		t.Fatalf("DeletePrice: %v", err)
+	}
+
+	if len(history.changes) != 3 {
+		t.Fatalf("recorded %d changes, want 3", len(history.changes))
+	}
+	created, updated, deleted := history.changes[0], history.changes[1], history.changes[2]
+	if created.Action != entity.PriceRowCreated || created.OldRow != nil || created.NewRow.Price != 90 || created.Reason != "Weekend sale" {
+		t.Errorf("created = %+v", created)
+	}
+	if updated.Action != entity.PriceRowUpdated || updated.OldRow.Price != 90 || updated.NewRow.Price != 85 || updated.ChangedBy != "manager-2" {
+		t.Errorf("updated = %+v", updated)
+	}
+	if deleted.Action != entity.PriceRowDeleted || deleted.OldRow.Price != 85 || deleted.NewRow != nil || deleted.PriceType != "promotional" {
+		t.Errorf("deleted = %+v", deleted)
+	}
+	for _, change := range history.changes {
+		if change.PriceID != price.ID || change.ProductID != product.ID {
+			t.Errorf("change %s is for price %s of product %s", change.Action, change.PriceID, change.ProductID)
+		}
+	}
+	if len(cache.invalidated) != 3 {
+		t.Errorf("cached prices invalidated %d times, want 3", len(cache.invalidated))
+	}
+}
+
+func TestPriceRowChangeNeedsWhoAndWhy(t *testing.T) {
+	product := &entity.Product{ID: uuid.New(), BasePrice: 100}
+	margins, _, _ := newTestMarginUsecase(uuid.New(), product)
+	prices := &fakePriceRepo{prices: map[uuid.UUID]*entity.Price{}}
+	history := &recordingHistoryRepo{prices: prices}
+	uc := NewPricingUsecase(prices, history, &fakeProductRepo{products: map[uuid.UUID]*entity.Product{product.ID: product}}, margins, &recordingPriceCache{}, discardLogger())
+
+	_, err := uc.CreatePrice(context.Background(), &CreatePriceRequest{ProductID: product.ID, PriceType: "vip", Price: 95, ChangedBy: "manager-1"})
+	if !errors.Is(err, entity.ErrInvalidPriceChange) {
+		t.Errorf("without a reason: got %v, want ErrInvalidPriceChange", err)
+	}
+	if len(history.changes) != 0 || len(prices.prices) != 0 {
+		t.Error("price saved without a reason")
+	}
+}
@@ -15,6 +15,8 @@ import (
 // ProductUsecase handles product business logic
 type ProductUsecase struct {
 	productRepo repository.ProductRepository
+	historyRepo repository.PriceHistoryRepository
+	margins     MarginGuard
 	cache       CacheRepository
 	logger      *logrus.Logger
 }
@@ -27,12 +29,15 @@ type CacheRepository interface {
 	GetProduct(ctx context.Context, productID uuid.UUID) (*entity.Product, error)
 	SetProduct(ctx context.Context, productID uuid.UUID, product *entity.Product, ttl time.Duration) error
 	InvalidateProduct(ctx context.Context, productID uuid.UUID) error
+	InvalidatePrice(ctx context.Context, productID uuid.UUID) error
 }
 
 // NewProductUsecase creates a new product usecase
-func NewProductUsecase(productRepo repository.ProductRepository, cache CacheRepository, logger *logrus.Logger) *ProductUsecase {
+func NewProductUsecase(productRepo repository.ProductRepository, historyRepo repository.PriceHistoryRepository, margins MarginGuard, cache CacheRepository, logger *logrus.Logger) *ProductUsecase {
 	return &ProductUsecase{
 		productRepo: productRepo,
+		historyRepo: historyRepo,
+		margins:     margins,
 		cache:       cache,
 		logger:      logger,
 	}
@@ -51,6 +56,14 @@ type CreateProductRequest struct {
 	Dimensions   *entity.ProductDimensions   `json:"dimensions"`
 	Tags         []string                    `json:"tags"`
 	IsVIPOnly    bool                        `json:"is_vip_only"`
+	IsVATExempt  bool                        `json:"is_vat_exempt"`
+	CostPrice    *float64                    `json:"cost_price" validate:"omitempty,min=0"`
+
+	// ProfitMarginTarget is the margin percentage promotional and VIP prices should keep
+	ProfitMarginTarget *float64 `json:"profit_margin_target" validate:"omitempty,min=0,max=99.99"`
+	// ApprovedBy is the manager approving a base price below cost or the minimum margin. The
+	// handler sets it from the authenticated caller; it is never read from the request body.
+	ApprovedBy string `json:"-"`
 }
 
 // UpdateProductRequest represents the request to update a product
@@ -62,7 +75,21 @@ type UpdateProductRequest struct {
 	Dimensions   *entity.ProductDimensions   `json:"dimensions"`
 	Tags         []string                    `json:"tags"`
 	IsVIPOnly    *bool                       `json:"is_vip_only"`
+	IsVATExempt  *bool                       `json:"is_vat_exempt"`
 	IsActive     *bool                       `json:"is_active"`
+	CostPrice    *float64                    `json:"cost_price"`
+
+	// ProfitMarginTarget is the margin percentage promotional and VIP prices should keep
+	ProfitMarginTarget *float64 `json:"profit_margin_target"`
+
+	// Who changed the base price and why, required with base_price for the price history. The
+	// handler sets ChangedBy from the authenticated caller; only services calling without a
+	// token name it in the request body.
+	ChangedBy         string `json:"changed_by"`
+	PriceChangeReason string `json:"price_change_reason"`
+	// ApprovedBy is the manager approving a base price below cost or the minimum margin. The
+	// handler sets it from the authenticated caller; it is never read from the request body.
+	ApprovedBy string `json:"-"`
 }
 
 // CreateProduct creates a new product
@@ -104,6 +131,22 @@ func (uc *ProductUsecase) CreateProduct(ctx context.Context, req *CreateProductR
 	product.Dimensions = req.Dimensions
 	product.Tags = req.Tags
 	product.IsVIPOnly = req.IsVIPOnly
+	product.IsVATExempt = req.IsVATExempt
+	if req.CostPrice != nil {
+		if err := product.UpdateCostPrice(*req.CostPrice); err != nil {
+			return nil, fmt.Errorf("failed to set cost price: %w", err)
+		}
+	}
+	if req.ProfitMarginTarget != nil {
+		if err := product.UpdateProfitMarginTarget(*req.ProfitMarginTarget); err != nil {
+			return nil, fmt.Errorf("failed to set profit margin target: %w", err)
+		}
+	}
+
+	// A price below cost or the category's minimum margin needs a manager's approval
+	if _, err := uc.margins.GuardPrice(ctx, product, product.BasePrice, req.ApprovedBy); err != nil {
+		return nil, err
+	}
 
 	// Save to database
 	if err := uc.productRepo.Create(ctx, product); err != nil {
@@ -211,12 +254,41 @@ func (uc *ProductUsecase) UpdateProduct(ctx context.Context, id uuid.UUID, req *
 		product.UpdatedAt = time.Now()
 	}
 
-	if req.BasePrice != nil {
+	var priceChange *entity.PriceHistoryEntry
+	if req.BasePrice != nil && *req.BasePrice != product.BasePrice {
+		priceChange, err = entity.NewPriceHistoryEntry(product.ID, *req.BasePrice, entity.PriceChangeSourceManual, req.ChangedBy, req.PriceChangeReason, time.Now())
+		if err != nil {
+			return nil, err
+		}
 		if err := product.UpdatePrice(*req.BasePrice); err != nil {
 			return nil, fmt.Errorf("failed to update price: %w", err)
 		}
 	}
 
+	if req.CostPrice != nil {
+		if err := product.UpdateCostPrice(*req.CostPrice); err != nil {
+			return nil, fmt.Errorf("failed to update cost price: %w", err)
+		}
+	}
+
+	if req.ProfitMarginTarget != nil {
+		if err := product.UpdateProfitMarginTarget(*req.ProfitMarginTarget); err != nil {
+			return nil, fmt.Errorf("failed to update profit margin target: %w", err)
+		}
+	}
+
+	// A new price or cost may leave the product selling below cost or the category's minimum
+	// margin, which needs a manager's approval
+	if priceChange != nil || req.CostPrice != nil {
+		check, err := uc.margins.GuardPrice(ctx, product, product.BasePrice, req.ApprovedBy)
+		if err != nil {
+			return nil, err
+		}
+		if priceChange != nil && check.NeedsApproval() {
+			priceChange.ApprovedBy = &req.ApprovedBy
+		}
+	}
+
 	if req.Weight != nil {
 		product.Weight = req.Weight
 		product.UpdatedAt = time.Now()
@@ -236,6 +308,10 @@ func (uc *ProductUsecase) UpdateProduct(ctx context.Context, id uuid.UUID, req *
 		product.SetVIPOnly(*req.IsVIPOnly)
 	}
 
+	if req.IsVATExempt != nil {
+		product.SetVATExempt(*req.IsVATExempt)
+	}
+
 	if req.IsActive != nil {
 		if *req.IsActive {
 			product.Activate()
@@ -244,8 +320,13 @@ func (uc *ProductUsecase) UpdateProduct(ctx context.Context, id uuid.UUID, req *
 		}
 	}
 
-	// Save changes
-	if err := uc.productRepo.Update(ctx, product); err != nil {
+	// Save changes, recording a base price change in the price history
+	if priceChange != nil {
+		err = uc.historyRepo.UpdateProductPrice(ctx, product, priceChange)
+	} else {
+		err = uc.productRepo.Update(ctx, product)
+	}
+	if err != nil {
 		return nil, fmt.Errorf("failed to update product: %w", err)
 	}
 
@@ -255,6 +336,13 @@ func (uc *ProductUsecase) UpdateProduct(ctx context.Context, id uuid.UUID, req *
 		if err := uc.cache.InvalidateProduct(ctx, product.ID); err != nil {
 			uc.logger.WithError(err).WithField("product_id", product.ID).Debug("Cache invalidation failed (product may not have been cached)")
 		}
+		// A stale cached price would keep selling at the old price until it expires, as for
+		// scheduled changes
+		if priceChange != nil {
+			if err := uc.cache.InvalidatePrice(ctx, product.ID); err != nil {
+				uc.logger.WithError(err).WithField("product_id", product.ID).Error("Failed to invalidate cached prices")
+			}
+		}
 	}
 
 	uc.logger.WithField("product_id", product.ID).Info("Product updated successfully")
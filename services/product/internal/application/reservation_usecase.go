@@ -0,0 +1,381 @@
+package application
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// expiredReservationBatch is how many expired orders one sweep releases
+const expiredReservationBatch = 100
+
+// ReservationUsecase reserves stock for orders and settles the reservations when the order is
+// confirmed, cancelled or left to expire
+type ReservationUsecase struct {
+	productRepo     repository.ProductRepository
+	reservationRepo repository.StockReservationRepository
+	launchRepo      repository.LaunchRepository
+	defaultTTL      time.Duration
+	maxTTL          time.Duration
+	logger          *logrus.Logger
+	now             func() time.Time
+}
+
+// NewReservationUsecase creates a new reservation usecase
+func NewReservationUsecase(productRepo repository.ProductRepository, reservationRepo repository.StockReservationRepository, launchRepo repository.LaunchRepository, defaultTTL, maxTTL time.Duration, logger *logrus.Logger) *ReservationUsecase {
+	return &ReservationUsecase{
+		productRepo:     productRepo,
+		reservationRepo: reservationRepo,
+		launchRepo:      launchRepo,
+		defaultTTL:      defaultTTL,
+		maxTTL:          maxTTL,
+		logger:          logger,
+		now:             time.Now,
+	}
+}
+
+// ReserveOrderStockRequest represents the request to reserve stock for an order. Retrying with
+// the same idempotency key returns the original reservations.
+type ReserveOrderStockRequest struct {
+	OrderID        uuid.UUID          `json:"order_id" binding:"required"`
+	IdempotencyKey string             `json:"idempotency_key" binding:"required"`
+	TTLSeconds     int                `json:"ttl_seconds"`
+	Items          []ReserveStockItem `json:"items" binding:"required,min=1,dive"`
+	// VIPLevel of the customer, empty when not a VIP member, chooses the launch allocations the
+	// items count against during early access
+	VIPLevel string `json:"vip_level"`
+}
+
+// ReserveStockItem is a product to reserve; without a location the best stocked one is used
+type ReserveStockItem struct {
+	ProductID  uuid.UUID  `json:"product_id" binding:"required"`
+	LocationID *uuid.UUID `json:"location_id"`
+	Quantity   float64    `json:"quantity" binding:"required,gt=0"`
+}
+
+// ReleaseOrderStockRequest represents the request to release the stock reserved for an order.
+// With an idempotency key only the stock reserved under that key is released.
+type ReleaseOrderStockRequest struct {
+	IdempotencyKey string `json:"idempotency_key"`
+	Reason         string `json:"reason"`
+}
+
+// RestockOrderStockRequest represents the request to put the stock of returned items back. Retrying
+// with the same idempotency key returns the original stock returns.
+type RestockOrderStockRequest struct {
+	IdempotencyKey string             `json:"idempotency_key" binding:"required"`
+	Reason         string             `json:"reason"`
+	Items          []RestockStockItem `json:"items" binding:"required,min=1,dive"`
+}
+
+// RestockStockItem is a returned product quantity to put back into stock
+type RestockStockItem struct {
+	ProductID uuid.UUID `json:"product_id" binding:"required"`
+	Quantity  float64   `json:"quantity" binding:"required,gt=0"`
+}
+
+// ReserveStock reserves stock for every item of an order, or for none of them. Products off
+// sale, in Loyverse or taken off sale by an admin or their schedule, cannot be reserved.
+func (uc *ReservationUsecase) ReserveStock(ctx context.Context, req *ReserveOrderStockRequest) ([]*entity.StockReservation, error) {
+	if req.OrderID == uuid.Nil {
+		return nil, fmt.Errorf("order ID is required")
+	}
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("idempotency key is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+	if req.VIPLevel != "" && !entity.IsValidVIPLevel(req.VIPLevel) {
+		return nil, fmt.Errorf("%w: %q", entity.ErrInvalidVIPLevel, req.VIPLevel)
+	}
+
+	ttl := uc.defaultTTL
+	if req.TTLSeconds > 0 {
+		ttl = time.Duration(req.TTLSeconds) * time.Second
+	}
+	if ttl > uc.maxTTL {
+		ttl = uc.maxTTL
+	}
+	expiresAt := uc.now().Add(ttl)
+
+	// Merge lines for the same product and location so each is reserved once
+	var reservations []*entity.StockReservation
+	index := make(map[string]*entity.StockReservation)
+	for _, item := range req.Items {
+		if item.ProductID == uuid.Nil {
+			return nil, fmt.Errorf("product ID is required")
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be positive")
+		}
+
+		locationID := uuid.Nil
+		if item.LocationID != nil {
+			locationID = *item.LocationID
+		}
+		key := item.ProductID.String() + "/" + locationID.String()
+		if reservation, ok := index[key]; ok {
+			reservation.Quantity += item.Quantity
+			continue
+		}
+
+		reservation := entity.NewStockReservation(req.IdempotencyKey, req.OrderID, item.ProductID, item.Quantity, expiresAt)
+		reservation.LocationID = locationID
+		index[key] = reservation
+		reservations = append(reservations, reservation)
+	}
+	if err := uc.checkAvailable(ctx, reservations, req.VIPLevel); err != nil {
+		return nil, err
+	}
+	if err := uc.assignLaunchAllocations(ctx, reservations, req.VIPLevel); err != nil {
+		return nil, err
+	}
+
+	reserved, err := uc.reservationRepo.Reserve(ctx, req.IdempotencyKey, reservations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reserve stock: %w", err)
+	}
+	if !sameReservation(reserved, req.OrderID, reservations) {
+		return nil, entity.ErrIdempotencyKeyReused
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"order_id":        req.OrderID,
+		"idempotency_key": req.IdempotencyKey,
+		"items":           len(reserved),
+		"expires_at":      reserved[0].ExpiresAt,
+	}).Info("Stock reserved for order")
+
+	return reserved, nil
+}
+
+// checkAvailable checks that a customer of the VIP level can buy every reserved product now
+func (uc *ReservationUsecase) checkAvailable(ctx context.Context, reservations []*entity.StockReservation, vipLevel string) error {
+	productIDs := make([]uuid.UUID, 0, len(reservations))
+	for _, reservation := range reservations {
+		productIDs = append(productIDs, reservation.ProductID)
+	}
+	products, err := uc.productRepo.GetByIDs(ctx, productIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get products: %w", err)
+	}
+	byID := make(map[uuid.UUID]*entity.Product, len(products))
+	for _, product := range products {
+		byID[product.ID] = product
+	}
+
+	now := uc.now()
+	for _, productID := range productIDs {
+		product, ok := byID[productID]
+		if !ok {
+			return fmt.Errorf("%w: %s", entity.ErrProductNotFound, productID)
+		}
+		if err := product.CheckAccess(vipLevel, now); err != nil {
+			return fmt.Errorf("%w: product %s", err, productID)
+		}
+	}
+	return nil
+}
+
+// assignLaunchAllocations points the reservations of products in early access at the allocation
+// of the customer's VIP level. Customers below the launch's minimum level cannot reserve them.
+func (uc *ReservationUsecase) assignLaunchAllocations(ctx context.Context, reservations []*entity.StockReservation, vipLevel string) error {
+	productIDs := make([]uuid.UUID, 0, len(reservations))
+	for _, reservation := range reservations {
+		productIDs = append(productIDs, reservation.ProductID)
+	}
+	launches, err := uc.launchRepo.GetOpenLaunches(ctx, productIDs, uc.now())
+	if err != nil {
+		return fmt.Errorf("failed to get open launches: %w", err)
+	}
+
+	for _, reservation := range reservations {
+		launch, ok := launches[reservation.ProductID]
+		if !ok {
+			continue
+		}
+		allocation, err := launch.AllocationFor(vipLevel)
+		if err != nil {
+			return fmt.Errorf("%w: product %s", err, reservation.ProductID)
+		}
+		if allocation != nil {
+			reservation.LaunchAllocationID = &allocation.ID
+		}
+	}
+	return nil
+}
+
+// GetOrderReservations retrieves the reservations of an order
+func (uc *ReservationUsecase) GetOrderReservations(ctx context.Context, orderID uuid.UUID) ([]*entity.StockReservation, error) {
+	reservations, err := uc.reservationRepo.GetByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stock reservations: %w", err)
+	}
+	if len(reservations) == 0 {
+		return nil, entity.ErrReservationNotFound
+	}
+
+	return reservations, nil
+}
+
+// ConsumeStock turns the reservations of a confirmed order into stock deductions
+func (uc *ReservationUsecase) ConsumeStock(ctx context.Context, orderID uuid.UUID) ([]*entity.StockReservation, error) {
+	reservations, err := uc.reservationRepo.Consume(ctx, orderID, uc.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to consume stock: %w", err)
+	}
+
+	uc.logger.WithField("order_id", orderID).Info("Reserved stock consumed for order")
+	return reservations, nil
+}
+
+// ReleaseStock returns the stock reserved for an order, or only the stock reserved under the
+// request's idempotency key. Releasing an order with nothing reserved succeeds, so it is safe to
+// use as a compensating action.
+func (uc *ReservationUsecase) ReleaseStock(ctx context.Context, orderID uuid.UUID, req *ReleaseOrderStockRequest) ([]*entity.StockReservation, error) {
+	reason := req.Reason
+	if reason == "" {
+		reason = "released"
+	}
+
+	reservations, err := uc.reservationRepo.Release(ctx, orderID, req.IdempotencyKey, entity.ReservationStatusReleased, reason, uc.now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to release stock: %w", err)
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"order_id":        orderID,
+		"idempotency_key": req.IdempotencyKey,
+		"reason":          reason,
+	}).Info("Reserved stock released for order")
+
+	return reservations, nil
+}
+
+// RestockStock puts the stock of items returned from a consumed order back at the locations it
+// was taken from
+func (uc *ReservationUsecase) RestockStock(ctx context.Context, orderID uuid.UUID, req *RestockOrderStockRequest) ([]*entity.StockReturn, error) {
+	if req.IdempotencyKey == "" {
+		return nil, fmt.Errorf("idempotency key is required")
+	}
+	if len(req.Items) == 0 {
+		return nil, fmt.Errorf("at least one item is required")
+	}
+
+	// Merge lines for the same product so each is restocked once
+	var returns []*entity.StockReturn
+	index := make(map[uuid.UUID]*entity.StockReturn)
+	for _, item := range req.Items {
+		if item.ProductID == uuid.Nil {
+			return nil, fmt.Errorf("product ID is required")
+		}
+		if item.Quantity <= 0 {
+			return nil, fmt.Errorf("quantity must be positive")
+		}
+		if stockReturn, ok := index[item.ProductID]; ok {
+			stockReturn.Quantity += item.Quantity
+			continue
+		}
+
+		stockReturn := entity.NewStockReturn(req.IdempotencyKey, orderID, item.ProductID, item.Quantity, req.Reason)
+		index[item.ProductID] = stockReturn
+		returns = append(returns, stockReturn)
+	}
+
+	restocked, err := uc.reservationRepo.Restock(ctx, orderID, req.IdempotencyKey, returns)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restock returned stock: %w", err)
+	}
+	for _, stockReturn := range restocked {
+		if stockReturn.OrderID != orderID {
+			return nil, entity.ErrIdempotencyKeyReused
+		}
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"order_id":        orderID,
+		"idempotency_key": req.IdempotencyKey,
+		"items":           len(restocked),
+	}).Info("Returned stock put back for order")
+
+	return restocked, nil
+}
+
+// ExpireReservations releases the reservations of orders that were neither confirmed nor
+// cancelled in time
+func (uc *ReservationUsecase) ExpireReservations(ctx context.Context) (int, error) {
+	now := uc.now()
+	orderIDs, err := uc.reservationRepo.GetExpiredOrderIDs(ctx, now, expiredReservationBatch)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get expired reservations: %w", err)
+	}
+
+	expired := 0
+	for _, orderID := range orderIDs {
+		if _, err := uc.reservationRepo.Release(ctx, orderID, "", entity.ReservationStatusExpired, "reservation expired", now); err != nil {
+			uc.logger.WithError(err).WithField("order_id", orderID).Error("Failed to expire stock reservation")
+			continue
+		}
+		expired++
+	}
+
+	if expired > 0 {
+		uc.logger.WithField("orders", expired).Info("Expired stock reservations released")
+	}
+	return expired, nil
+}
+
+// StartExpirySweeper expires reservations every interval until the context is cancelled
+func (uc *ReservationUsecase) StartExpirySweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	uc.logger.WithField("interval", interval).Info("Stock reservation expiry sweeper started")
+
+	for {
+		if _, err := uc.ExpireReservations(ctx); err != nil && ctx.Err() == nil {
+			uc.logger.WithError(err).Error("Stock reservation expiry sweep failed")
+		}
+
+		select {
+		case <-ctx.Done():
+			uc.logger.Info("Stock reservation expiry sweeper stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sameReservation checks that reservations returned for an idempotency key are the ones that
+// were requested, so a key reused for another order or other items is refused
+func sameReservation(reserved []*entity.StockReservation, orderID uuid.UUID, requested []*entity.StockReservation) bool {
+	if len(reserved) != len(requested) {
+		return false
+	}
+
+	quantities := make(map[uuid.UUID]float64)
+	for _, reservation := range requested {
+		quantities[reservation.ProductID] += reservation.Quantity
+	}
+	for _, reservation := range reserved {
+		if reservation.OrderID != orderID {
+			return false
+		}
+		quantities[reservation.ProductID] -= reservation.Quantity
+	}
+	for _, remaining := range quantities {
+		// Quantities are stored to three decimals
+		if math.Abs(remaining) > 0.0005 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,211 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+)
+
+type fakeReservationRepo struct {
+	repository.StockReservationRepository
+	reserved   []*entity.StockReservation
+	expired    []uuid.UUID
+	releases   []fakeRelease
+	releaseErr map[uuid.UUID]error
+	restocked  []*entity.StockReturn
+}
+
+type fakeRelease struct {
+	orderID        uuid.UUID
+	idempotencyKey string
+	status         entity.ReservationStatus
+	reason         string
+	at             time.Time
+}
+
+func (r *fakeReservationRepo) Reserve(ctx context.Context, idempotencyKey string, reservations []*entity.StockReservation) ([]*entity.StockReservation, error) {
+	if r.reserved != nil {
+		return r.reserved, nil
+	}
+	return reservations, nil
+}
+
+func (r *fakeReservationRepo) Release(ctx context.Context, orderID uuid.UUID, idempotencyKey string, status entity.ReservationStatus, reason string, now time.Time) ([]*entity.StockReservation, error) {
+	if err := r.releaseErr[orderID]; err != nil {
+		return nil, err
+	}
+	r.releases = append(r.releases, fakeRelease{orderID, idempotencyKey, status, reason, now})
+	return nil, nil
+}
+
+func (r *fakeReservationRepo) GetExpiredOrderIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	return r.expired, nil
+}
+
+func (r *fakeReservationRepo) Restock(ctx context.Context, orderID uuid.UUID, idempotencyKey string, returns []*entity.StockReturn) ([]*entity.StockReturn, error) {
+	if r.restocked != nil {
+		return r.restocked, nil
+	}
+	return returns, nil
+}
+
+type fakeLaunchRepo struct {
+	repository.LaunchRepository
+}
+
+func (r *fakeLaunchRepo) GetOpenLaunches(ctx context.Context, productIDs []uuid.UUID, now time.Time) (map[uuid.UUID]*entity.ProductLaunch, error) {
+	return nil, nil
+}
+
+// newTestReservationUsecase reserves for 15 minutes by default and at most an hour, at a fixed time
+func newTestReservationUsecase(products ...*entity.Product) (*ReservationUsecase, *fakeReservationRepo, time.Time) {
+	productRepo := &fakeProductRepo{products: map[uuid.UUID]*entity.Product{}}
+	for _, product := range products {
+		productRepo.products[product.ID] = product
+	}
+	reservationRepo := &fakeReservationRepo{}
+	uc := NewReservationUsecase(productRepo, reservationRepo, &fakeLaunchRepo{}, 15*time.Minute, time.Hour, discardLogger())
+	now := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	uc.now = func() time.Time { return now }
+	return uc, reservationRepo, now
+}
+
+func TestReserveStockRefusesProductsOffSale(t *testing.T) {
+	reason := "Outside availability hours"
+	offSale := &entity.Product{ID: uuid.New(), IsActive: true, IsAdminActive: false, InactiveReason: &reason}
+	productRepo := &fakeProductRepo{products: map[uuid.UUID]*entity.Product{offSale.ID: offSale}}
+	// Stock is never reserved, so the reservation and launch repositories are not needed
+	uc := NewReservationUsecase(productRepo, nil, nil, time.Minute, time.Hour, discardLogger())
+
+	_, err := uc.ReserveStock(context.Background(), &ReserveOrderStockRequest{
+		OrderID:        uuid.New(),
+		IdempotencyKey: "order:key",
+		Items:          []ReserveStockItem{{ProductID: offSale.ID, Quantity: 1}},
+	})
+	if !errors.Is(err, entity.ErrProductNotAvailable) {
+		t.Errorf("got %v, want ErrProductNotAvailable", err)
+	}
+
+	_, err = uc.ReserveStock(context.Background(), &ReserveOrderStockRequest{
+		OrderID:        uuid.New(),
+		IdempotencyKey: "order:other",
+		Items:          []ReserveStockItem{{ProductID: uuid.New(), Quantity: 1}},
+	})
+	if !errors.Is(err, entity.ErrProductNotFound) {
+		t.Errorf("unknown product: got %v, want ErrProductNotFound", err)
+	}
+}
+
+func TestReserveStockMergesLinesAndCapsTTL(t *testing.T) {
+	product := &entity.Product{ID: uuid.New(), IsActive: true, IsAdminActive: true}
+	uc, _, now := newTestReservationUsecase(product)
+	orderID := uuid.New()
+
+	reserved, err := uc.ReserveStock(context.Background(), &ReserveOrderStockRequest{
+		OrderID:        orderID,
+		IdempotencyKey: "order:key",
+		TTLSeconds:     int((2 * time.Hour).Seconds()),
+		Items: []ReserveStockItem{
+			{ProductID: product.ID, Quantity: 2},
+			{ProductID: product.ID, Quantity: 1.5},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ReserveStock: %v", err)
+	}
+	if len(reserved) != 1 || reserved[0].Quantity != 3.5 {
+		t.Fatalf("reserved %+v, want the lines merged into one reservation", reserved)
+	}
+	if !reserved[0].ExpiresAt.Equal(now.Add(time.Hour)) {
+		t.Errorf("expires at %v, want the TTL capped at an hour", reserved[0].ExpiresAt)
+	}
+	if reserved[0].IdempotencyKey != "order:key" || reserved[0].Status != entity.ReservationStatusActive {
+		t.Errorf("reserved %+v", reserved[0])
+	}
+}
+
+func TestReserveStockRefusesReusedKey(t *testing.T) {
+	product := &entity.Product{ID: uuid.New(), IsActive: true, IsAdminActive: true}
+	uc, reservationRepo, now := newTestReservationUsecase(product)
+	// The key was used by another order before
+	reservationRepo.reserved = []*entity.StockReservation{
+		entity.NewStockReservation("order:key", uuid.New(), product.ID, 2, now.Add(time.Hour)),
+	}
+
+	_, err := uc.ReserveStock(context.Background(), &ReserveOrderStockRequest{
+		OrderID:        uuid.New(),
+		IdempotencyKey: "order:key",
+		Items:          []ReserveStockItem{{ProductID: product.ID, Quantity: 2}},
+	})
+	if !errors.Is(err, entity.ErrIdempotencyKeyReused) {
+		t.Errorf("got %v, want ErrIdempotencyKeyReused", err)
+	}
+}
+
+func TestReleaseStock(t *testing.T) {
+	uc, reservationRepo, now := newTestReservationUsecase()
+	orderID := uuid.New()
+
+	if _, err := uc.ReleaseStock(context.Background(), orderID, &ReleaseOrderStockRequest{IdempotencyKey: "order:edit"}); err != nil {
+		t.Fatalf("ReleaseStock: %v", err)
+	}
+
+	want := fakeRelease{orderID, "order:edit", entity.ReservationStatusReleased, "released", now}
+	if len(reservationRepo.releases) != 1 || reservationRepo.releases[0] != want {
+		t.Errorf("releases %+v, want %+v", reservationRepo.releases, want)
+	}
+}
+
+func TestExpireReservations(t *testing.T) {
+	uc, reservationRepo, now := newTestReservationUsecase()
+	expired, failing := uuid.New(), uuid.New()
+	reservationRepo.expired = []uuid.UUID{failing, expired}
+	reservationRepo.releaseErr = map[uuid.UUID]error{failing: errors.New("deadlock detected")}
+
+	count, err := uc.ExpireReservations(context.Background())
+	if err != nil {
+		t.Fatalf("ExpireReservations: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expired %d orders, want 1 after the failing one is skipped", count)
+	}
+
+	// All reservations of the order are released as expired, whatever key they were made under
+	want := fakeRelease{expired, "", entity.ReservationStatusExpired, "reservation expired", now}
+	if len(reservationRepo.releases) != 1 || reservationRepo.releases[0] != want {
+		t.Errorf("releases %+v, want %+v", reservationRepo.releases, want)
+	}
+}
+
+func TestRestockStock(t *testing.T) {
+	uc, reservationRepo, _ := newTestReservationUsecase()
+	orderID, productID := uuid.New(), uuid.New()
+	req := &RestockOrderStockRequest{
+		IdempotencyKey: "order:cancel:restock",
+		Reason:         "order cancelled",
+		Items: []RestockStockItem{
+			{ProductID: productID, Quantity: 1},
+			{ProductID: productID, Quantity: 2},
+		},
+	}
+
+	restocked, err := uc.RestockStock(context.Background(), orderID, req)
+	if err != nil {
+		t.Fatalf("RestockStock: %v", err)
+	}
+	if len(restocked) != 1 || restocked[0].Quantity != 3 || restocked[0].OrderID != orderID {
+		t.Fatalf("restocked %+v, want the lines merged into one return", restocked)
+	}
+
+	// The key was used by another order before
+	reservationRepo.restocked = []*entity.StockReturn{entity.NewStockReturn(req.IdempotencyKey, uuid.New(), productID, 3, "")}
+	if _, err := uc.RestockStock(context.Background(), orderID, req); !errors.Is(err, entity.ErrIdempotencyKeyReused) {
+		t.Errorf("got %v, want ErrIdempotencyKeyReused", err)
+	}
+}
@@ -17,6 +17,7 @@ import (
 type SyncUsecase struct {
 	productRepo  repository.ProductRepository
 	categoryRepo repository.CategoryRepository
+	historyRepo  repository.PriceHistoryRepository
 	eventPub     events.Publisher
 	logger       *logrus.Logger
 }
@@ -25,12 +26,14 @@ type SyncUsecase struct {
 func NewSyncUsecase(
 	productRepo repository.ProductRepository,
 	categoryRepo repository.CategoryRepository,
+	historyRepo repository.PriceHistoryRepository,
 	eventPub events.Publisher,
 	logger *logrus.Logger,
 ) *SyncUsecase {
 	return &SyncUsecase{
 		productRepo:  productRepo,
 		categoryRepo: categoryRepo,
+		historyRepo:  historyRepo,
 		eventPub:     eventPub,
 		logger:       logger,
 	}
@@ -181,6 +184,11 @@ func (uc *SyncUsecase) updateProductFromLoyverse(ctx context.Context, existing *
 	if req.Barcode != nil {
 		existing.Barcode = req.Barcode
 	}
+
+	if req.CostPrice != nil {
+		existing.CostPrice = req.CostPrice
+	}
+	uc.warnBelowCost(existing)
 	
 	// Parse category if provided
 	if req.CategoryID != nil {
@@ -196,8 +204,17 @@ func (uc *SyncUsecase) updateProductFromLoyverse(ctx context.Context, existing *
 	existing.LastSyncedAt = &[]time.Time{time.Now()}[0]
 	existing.UpdatedAt = time.Now()
 
-	// Save to database
-	if err := uc.productRepo.Update(ctx, existing); err != nil {
+	// Save to database, recording a base price change in the price history
+	if req.BasePrice != oldPrice {
+		priceChange, err := entity.NewPriceHistoryEntry(existing.ID, req.BasePrice, entity.PriceChangeSourceSync, "loyverse", "Loyverse sync "+syncID, time.Now())
+		if err != nil {
+			return fmt.Errorf("invalid price from Loyverse: %w", err)
+		}
+		err = uc.historyRepo.UpdateProductPrice(ctx, existing, priceChange)
+		if err != nil {
+			return fmt.Errorf("failed to update product: %w", err)
+		}
+	} else if err := uc.productRepo.Update(ctx, existing); err != nil {
 		return fmt.Errorf("failed to update product: %w", err)
 	}
 
@@ -255,9 +272,9 @@ func (uc *SyncUsecase) createProductFromLoyverse(ctx context.Context, req SyncPr
 
 	// Set cost price if provided
 	if req.CostPrice != nil {
-		// Note: CostPrice would need to be added to entity.Product if not already there
-		// For now, we'll store it in metadata or extend the entity
+		product.CostPrice = req.CostPrice
 	}
+	uc.warnBelowCost(product)
 
 	// Save to database
 	if err := uc.productRepo.Create(ctx, product); err != nil {
@@ -418,3 +435,17 @@ func (uc *SyncUsecase) GetLastSyncTime(ctx context.Context, syncType string) (ti
 	// For now, return a placeholder
 	return time.Now().Add(-24 * time.Hour), nil
 }
+
+// warnBelowCost logs a product Loyverse prices below its cost. Loyverse is the master of prices,
+// so the sync keeps the price rather than asking for a manager's approval.
+func (uc *SyncUsecase) warnBelowCost(product *entity.Product) {
+	if product.CostPrice == nil || product.BasePrice >= *product.CostPrice {
+		return
+	}
+	uc.logger.WithFields(logrus.Fields{
+		"product_id": product.ID,
+		"sku":        product.SKU,
+		"base_price": product.BasePrice,
+		"cost_price": *product.CostPrice,
+	}).Warn("Loyverse prices product below cost")
+}
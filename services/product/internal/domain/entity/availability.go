@@ -0,0 +1,356 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAvailability = errors.New("invalid availability")
+)
+
+// businessLocation is Thai time, which availability schedules follow
+var businessLocation = time.FixedZone("ICT", 7*60*60)
+
+// defaultScheduleReason is shown for products the schedule took off sale without a reason
+const defaultScheduleReason = "Outside availability hours"
+
+// weekdays maps the day names a schedule accepts to weekdays
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// AvailabilityWindow is a recurring period in Thai time. Without days it recurs every day and
+// without times it lasts the whole day. A window ending before it starts runs past midnight,
+// so "22:00" to "02:00" on Friday ends on Saturday morning.
+type AvailabilityWindow struct {
+	Days []string `json:"days,omitempty"` // "mon" to "sun"
+	From string   `json:"from,omitempty"` // "06:00"
+	To   string   `json:"to,omitempty"`   // "11:00"
+}
+
+// AvailabilitySchedule takes a product on and off sale on recurring days and hours. A product
+// with available windows is only on sale during them, such as breakfast items from 6 to 11am,
+// and it is off sale during its closed windows, such as on Mondays.
+type AvailabilitySchedule struct {
+	Available []AvailabilityWindow `json:"available,omitempty"`
+	Closed    []AvailabilityWindow `json:"closed,omitempty"`
+	// Reason is shown while the schedule keeps the product off sale
+	Reason string `json:"reason,omitempty"`
+}
+
+// Validate checks that the schedule has windows and that their days and times are valid
+func (s *AvailabilitySchedule) Validate() error {
+	if len(s.Available) == 0 && len(s.Closed) == 0 {
+		return fmt.Errorf("%w: a schedule needs available or closed windows", ErrInvalidAvailability)
+	}
+	for _, window := range append(append([]AvailabilityWindow(nil), s.Available...), s.Closed...) {
+		if _, _, _, err := window.parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AvailableAt reports whether the schedule has the product on sale at the time
+func (s *AvailabilitySchedule) AvailableAt(t time.Time) bool {
+	local := t.In(businessLocation)
+	if len(s.Available) > 0 && !anyWindowContains(s.Available, local) {
+		return false
+	}
+	return !anyWindowContains(s.Closed, local)
+}
+
+// NextChange returns when the schedule next takes the product on or off sale after the time,
+// or nil when it never does
+func (s *AvailabilitySchedule) NextChange(after time.Time) *time.Time {
+	// Sale only starts or stops at midnight or where a window starts or ends
+	boundaries := map[int]bool{0: true}
+	for _, window := range append(append([]AvailabilityWindow(nil), s.Available...), s.Closed...) {
+		_, from, to, err := window.parse()
+		if err != nil {
+			continue
+		}
+		boundaries[from] = true
+		boundaries[to] = true
+	}
+	minutes := make([]int, 0, len(boundaries))
+	for minute := range boundaries {
+		minutes = append(minutes, minute)
+	}
+	sort.Ints(minutes)
+
+	available := s.AvailableAt(after)
+	local := after.In(businessLocation)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, businessLocation)
+	// Every window recurs within a week
+	for i := 0; i <= 7; i++ {
+		for _, minute := range minutes {
+			at := day.AddDate(0, 0, i).Add(time.Duration(minute) * time.Minute)
+			if at.After(after) && s.AvailableAt(at) != available {
+				return &at
+			}
+		}
+	}
+	return nil
+}
+
+// Value stores the schedule as JSON
+func (s AvailabilitySchedule) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the schedule from JSON
+func (s *AvailabilitySchedule) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("cannot scan AvailabilitySchedule")
+	}
+}
+
+// parse returns the days of the window, none meaning every day, and its start and end in
+// minutes of the day
+func (w AvailabilityWindow) parse() (map[time.Weekday]bool, int, int, error) {
+	var days map[time.Weekday]bool
+	if len(w.Days) > 0 {
+		days = make(map[time.Weekday]bool, len(w.Days))
+	}
+	for _, name := range w.Days {
+		day, ok := weekdays[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, 0, 0, fmt.Errorf("%w: unknown day %q", ErrInvalidAvailability, name)
+		}
+		days[day] = true
+	}
+
+	if w.From == "" && w.To == "" {
+		return days, 0, 0, nil
+	}
+	if w.From == "" || w.To == "" {
+		return nil, 0, 0, fmt.Errorf("%w: a window needs both from and to, or neither for the whole day", ErrInvalidAvailability)
+	}
+	from, err := parseClock(w.From)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	to, err := parseClock(w.To)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	if from == to {
+		return nil, 0, 0, fmt.Errorf("%w: a window cannot start and end at %s", ErrInvalidAvailability, w.From)
+	}
+	return days, from, to, nil
+}
+
+// contains reports whether the window covers the time, given in Thai time
+func (w AvailabilityWindow) contains(local time.Time) bool {
+	days, from, to, err := w.parse()
+	if err != nil {
+		return false
+	}
+	onDay := func(day time.Weekday) bool {
+		return days == nil || days[day]
+	}
+	minute := local.Hour()*60 + local.Minute()
+	weekday := local.Weekday()
+	yesterday := (weekday + 6) % 7
+
+	switch {
+	case from == to:
+		return onDay(weekday)
+	case from < to:
+		return onDay(weekday) && minute >= from && minute < to
+	default:
+		return (onDay(weekday) && minute >= from) || (onDay(yesterday) && minute < to)
+	}
+}
+
+// anyWindowContains reports whether any of the windows covers the time
+func anyWindowContains(windows []AvailabilityWindow, local time.Time) bool {
+	for _, window := range windows {
+		if window.contains(local) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses a time of day such as "06:30" into minutes of the day
+func parseClock(clock string) (int, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("%w: time %q must be HH:MM", ErrInvalidAvailability, clock)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// TableName specifies the table name for GORM
+func (ProductAvailabilityLog) TableName() string {
+	return "product_availability_log"
+}
+
+// IsAvailable reports whether the product is on sale: active in Loyverse and not taken off sale
+// by an admin or its schedule
+func (p *Product) IsAvailable() bool {
+	return p.IsActive && p.IsAdminActive
+}
+
+// TakeOffSale takes the product off sale for the reason, until a time when one is given. It is
+// put back on sale at that time when autoReactivate is set.
+func (p *Product) TakeOffSale(reason string, until *time.Time, autoReactivate bool, changedBy *uuid.UUID, now time.Time) (*ProductAvailabilityLog, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: a reason is required", ErrInvalidAvailability)
+	}
+	if until != nil && !until.After(now) {
+		return nil, fmt.Errorf("%w: inactive_until must be in the future", ErrInvalidAvailability)
+	}
+	if autoReactivate && until == nil {
+		return nil, fmt.Errorf("%w: auto_reactivate needs inactive_until", ErrInvalidAvailability)
+	}
+
+	wasActive := p.IsAdminActive
+	p.IsAdminActive = false
+	p.InactiveReason = &reason
+	p.InactiveUntil = until
+	p.AutoReactivate = autoReactivate
+	// The schedule leaves products an admin took off sale alone
+	p.InactiveBySchedule = false
+	p.UpdatedAt = now
+	return p.availabilityLog(ChangeTypeDeactivated, wasActive, changedBy, now), nil
+}
+
+// PutOnSale puts the product back on sale. Its schedule still takes it off sale again outside
+// the hours it is available.
+func (p *Product) PutOnSale(changedBy *uuid.UUID, now time.Time) *ProductAvailabilityLog {
+	wasActive := p.IsAdminActive
+	p.putOnSale(now)
+	return p.availabilityLog(ChangeTypeActivated, wasActive, changedBy, now)
+}
+
+// SetAvailabilitySchedule replaces the schedule of the product, nil removing it, and applies it
+// right away
+func (p *Product) SetAvailabilitySchedule(schedule *AvailabilitySchedule, changedBy *uuid.UUID, now time.Time) (*ProductAvailabilityLog, error) {
+	if schedule != nil {
+		if err := schedule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	wasActive := p.IsAdminActive
+	p.InactiveSchedule = schedule
+	p.UpdatedAt = now
+	if schedule == nil {
+		if !p.InactiveBySchedule {
+			return nil, nil
+		}
+		p.putOnSale(now)
+		return p.availabilityLog(ChangeTypeScheduled, wasActive, changedBy, now), nil
+	}
+
+	changed := p.applySchedule(now)
+	if !changed {
+		return nil, nil
+	}
+	return p.availabilityLog(ChangeTypeScheduled, wasActive, changedBy, now), nil
+}
+
+// ShouldAutoReactivate reports whether the time the product was taken off sale until has passed
+// and it should go back on sale
+func (p *Product) ShouldAutoReactivate(now time.Time) bool {
+	if p.IsAdminActive || !p.AutoReactivate || p.InactiveUntil == nil {
+		return false
+	}
+	return !now.Before(*p.InactiveUntil)
+}
+
+// ApplyAvailabilityRules puts the product back on sale once the time it was taken off sale
+// until passes and follows its schedule. It returns the log entry of the change, or nil when
+// the product stays as it is.
+func (p *Product) ApplyAvailabilityRules(now time.Time) *ProductAvailabilityLog {
+	wasActive := p.IsAdminActive
+	changeType := ""
+	if p.ShouldAutoReactivate(now) {
+		p.putOnSale(now)
+		changeType = ChangeTypeAutoReactivated
+	}
+	if p.applySchedule(now) {
+		changeType = ChangeTypeScheduled
+	}
+	if changeType == "" {
+		return nil
+	}
+	return p.availabilityLog(changeType, wasActive, nil, now)
+}
+
+// applySchedule takes the product on or off sale as its schedule says and reports whether it
+// did. Products an admin took off sale are left alone.
+func (p *Product) applySchedule(now time.Time) bool {
+	if p.InactiveSchedule == nil || (!p.IsAdminActive && !p.InactiveBySchedule) {
+		return false
+	}
+
+	available := p.InactiveSchedule.AvailableAt(now)
+	switch {
+	case available && !p.IsAdminActive:
+		p.putOnSale(now)
+		return true
+	case !available && p.IsAdminActive:
+		reason := p.InactiveSchedule.Reason
+		if reason == "" {
+			reason = defaultScheduleReason
+		}
+		p.IsAdminActive = false
+		p.InactiveReason = &reason
+		// Shown as when the product is back; the schedule puts it back, not auto reactivation
+		p.InactiveUntil = p.InactiveSchedule.NextChange(now)
+		p.AutoReactivate = false
+		p.InactiveBySchedule = true
+		p.UpdatedAt = now
+		return true
+	}
+	return false
+}
+
+// putOnSale clears what took the product off sale
+func (p *Product) putOnSale(now time.Time) {
+	p.IsAdminActive = true
+	p.InactiveReason = nil
+	p.InactiveUntil = nil
+	p.AutoReactivate = false
+	p.InactiveBySchedule = false
+	p.UpdatedAt = now
+}
+
+// availabilityLog records a change of the product's availability
+func (p *Product) availabilityLog(changeType string, wasActive bool, changedBy *uuid.UUID, now time.Time) *ProductAvailabilityLog {
+	isActive := p.IsAdminActive
+	return &ProductAvailabilityLog{
+		ID:             uuid.New(),
+		ProductID:      p.ID,
+		ChangedBy:      changedBy,
+		ChangeType:     changeType,
+		OldStatus:      &wasActive,
+		NewStatus:      &isActive,
+		Reason:         p.InactiveReason,
+		ScheduledUntil: p.InactiveUntil,
+		CreatedAt:      now,
+	}
+}
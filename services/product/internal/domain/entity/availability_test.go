@@ -0,0 +1,163 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ict builds a time in Thai time; 14 October 2024 is a Monday
+func ict(day, hour, minute int) time.Time {
+	return time.Date(2024, 10, day, hour, minute, 0, 0, businessLocation)
+}
+
+var (
+	breakfast    = &AvailabilitySchedule{Available: []AvailabilityWindow{{From: "06:00", To: "11:00"}}}
+	closedMonday = &AvailabilitySchedule{Closed: []AvailabilityWindow{{Days: []string{"mon"}}}}
+	lateNight    = &AvailabilitySchedule{Available: []AvailabilityWindow{{Days: []string{"fri"}, From: "22:00", To: "02:00"}}}
+)
+
+func TestAvailabilityScheduleAvailableAt(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *AvailabilitySchedule
+		at       time.Time
+		want     bool
+	}{
+		{"breakfast before opening", breakfast, ict(15, 5, 59), false},
+		{"breakfast at opening", breakfast, ict(15, 6, 0), true},
+		{"breakfast before closing", breakfast, ict(15, 10, 59), true},
+		{"breakfast at closing", breakfast, ict(15, 11, 0), false},
+		{"breakfast in UTC", breakfast, time.Date(2024, 10, 14, 23, 30, 0, 0, time.UTC), true},
+		{"closed on Monday morning", closedMonday, ict(14, 0, 0), false},
+		{"closed on Monday night", closedMonday, ict(14, 23, 59), false},
+		{"open on Tuesday", closedMonday, ict(15, 0, 0), true},
+		{"open on Sunday", closedMonday, ict(13, 12, 0), true},
+		{"late night on Friday evening", lateNight, ict(18, 21, 59), false},
+		{"late night from Friday 22:00", lateNight, ict(18, 22, 0), true},
+		{"late night past midnight", lateNight, ict(19, 1, 59), true},
+		{"late night ends on Saturday 02:00", lateNight, ict(19, 2, 0), false},
+		{"late night not on Saturday evening", lateNight, ict(19, 23, 0), false},
+		{"late night not on Friday morning", lateNight, ict(18, 1, 0), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.AvailableAt(tt.at); got != tt.want {
+				t.Errorf("AvailableAt(%s) = %v, want %v", tt.at, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAvailabilityScheduleNextChange(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *AvailabilitySchedule
+		after    time.Time
+		want     time.Time
+	}{
+		{"breakfast closes", breakfast, ict(15, 7, 0), ict(15, 11, 0)},
+		{"breakfast opens the next day", breakfast, ict(15, 11, 0), ict(16, 6, 0)},
+		{"Monday ends at midnight", closedMonday, ict(14, 9, 0), ict(15, 0, 0)},
+		{"next Monday", closedMonday, ict(15, 9, 0), ict(21, 0, 0)},
+		{"late night ends past midnight", lateNight, ict(18, 23, 0), ict(19, 2, 0)},
+		{"late night opens next Friday", lateNight, ict(19, 2, 0), ict(25, 22, 0)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.schedule.NextChange(tt.after)
+			if got == nil || !got.Equal(tt.want) {
+				t.Errorf("NextChange(%s) = %v, want %s", tt.after, got, tt.want)
+			}
+		})
+	}
+
+	always := &AvailabilitySchedule{Available: []AvailabilityWindow{{}}}
+	if got := always.NextChange(ict(15, 9, 0)); got != nil {
+		t.Errorf("NextChange of a schedule that never changes = %s, want nil", got)
+	}
+}
+
+func TestAvailabilityScheduleValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule *AvailabilitySchedule
+		valid    bool
+	}{
+		{"breakfast", breakfast, true},
+		{"closed Mondays", closedMonday, true},
+		{"past midnight", lateNight, true},
+		{"no windows", &AvailabilitySchedule{}, false},
+		{"unknown day", &AvailabilitySchedule{Closed: []AvailabilityWindow{{Days: []string{"funday"}}}}, false},
+		{"from without to", &AvailabilitySchedule{Available: []AvailabilityWindow{{From: "06:00"}}}, false},
+		{"bad time", &AvailabilitySchedule{Available: []AvailabilityWindow{{From: "6am", To: "11:00"}}}, false},
+		{"starts when it ends", &AvailabilitySchedule{Available: []AvailabilityWindow{{From: "06:00", To: "06:00"}}}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.schedule.Validate()
+			if tt.valid && err != nil {
+				t.Errorf("Validate: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidAvailability) {
+				t.Errorf("got %v, want ErrInvalidAvailability", err)
+			}
+		})
+	}
+}
+
+func TestApplyAvailabilityRulesFollowsSchedule(t *testing.T) {
+	product := &Product{ID: uuid.New(), IsActive: true, IsAdminActive: true, InactiveSchedule: breakfast}
+
+	log := product.ApplyAvailabilityRules(ict(15, 12, 0))
+	if log == nil || log.ChangeType != ChangeTypeScheduled || product.IsAvailable() {
+		t.Fatalf("after breakfast: log %+v, available %v; want taken off sale", log, product.IsAvailable())
+	}
+	if !product.InactiveBySchedule || product.InactiveUntil == nil || !product.InactiveUntil.Equal(ict(16, 6, 0)) {
+		t.Errorf("off sale by schedule %v until %v, want until 06:00 the next day", product.InactiveBySchedule, product.InactiveUntil)
+	}
+
+	if log := product.ApplyAvailabilityRules(ict(15, 13, 0)); log != nil {
+		t.Errorf("still closed: got log %+v, want none", log)
+	}
+
+	log = product.ApplyAvailabilityRules(ict(16, 6, 0))
+	if log == nil || !product.IsAvailable() || product.InactiveReason != nil {
+		t.Errorf("at breakfast: log %+v, available %v; want back on sale", log, product.IsAvailable())
+	}
+}
+
+func TestApplyAvailabilityRulesLeavesAdminChangesAlone(t *testing.T) {
+	product := &Product{ID: uuid.New(), IsActive: true, IsAdminActive: true, InactiveSchedule: breakfast}
+	if _, err := product.TakeOffSale("Oven broken", nil, false, nil, ict(15, 7, 0)); err != nil {
+		t.Fatalf("TakeOffSale: %v", err)
+	}
+
+	// The schedule would have it on sale at breakfast and off sale after it
+	for _, at := range []time.Time{ict(15, 8, 0), ict(15, 12, 0), ict(16, 6, 0)} {
+		if log := product.ApplyAvailabilityRules(at); log != nil {
+			t.Errorf("at %s: got log %+v, want the admin's change kept", at, log)
+		}
+		if product.IsAdminActive || *product.InactiveReason != "Oven broken" {
+			t.Fatalf("at %s: admin change overridden", at)
+		}
+	}
+}
+
+func TestApplyAvailabilityRulesAutoReactivates(t *testing.T) {
+	until := ict(15, 9, 0)
+	product := &Product{ID: uuid.New(), IsActive: true, IsAdminActive: true}
+	if _, err := product.TakeOffSale("Restocking", &until, true, nil, ict(15, 7, 0)); err != nil {
+		t.Fatalf("TakeOffSale: %v", err)
+	}
+
+	if log := product.ApplyAvailabilityRules(ict(15, 8, 59)); log != nil {
+		t.Errorf("before inactive_until: got log %+v", log)
+	}
+	log := product.ApplyAvailabilityRules(until)
+	if log == nil || log.ChangeType != ChangeTypeAutoReactivated || !product.IsAvailable() {
+		t.Errorf("at inactive_until: log %+v, available %v; want reactivated", log, product.IsAvailable())
+	}
+}
@@ -0,0 +1,331 @@
+package entity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// PricingRule is a rule that can lower the price of a cart line
+type PricingRule string
+
+const (
+	// PricingRuleTier prices the line at the quantity tier the cart reaches
+	PricingRuleTier PricingRule = "tier"
+	// PricingRuleGroup applies the base price and discount of the customer's group
+	PricingRuleGroup PricingRule = "customer_group"
+	// PricingRuleVIP applies the global discount of the customer's VIP level
+	PricingRuleVIP PricingRule = "vip"
+)
+
+// StackingMode decides how the rules that apply to a line are combined
+type StackingMode string
+
+const (
+	// StackingModeStack applies every rule in precedence order, each to the price the rule
+	// before it left
+	StackingModeStack StackingMode = "stack"
+	// StackingModeBest applies only the rule giving the lowest price; on a tie the rule first
+	// in precedence wins
+	StackingModeBest StackingMode = "best"
+)
+
+// PricingPolicy is the order the pricing rules are applied in and how they stack. Rules left
+// out of the precedence are not applied.
+type PricingPolicy struct {
+	Precedence []PricingRule `json:"precedence"`
+	Stacking   StackingMode  `json:"stacking"`
+}
+
+// DefaultPricingPolicy stacks tier, customer group and VIP pricing in that order
+func DefaultPricingPolicy() PricingPolicy {
+	return PricingPolicy{
+		Precedence: []PricingRule{PricingRuleTier, PricingRuleGroup, PricingRuleVIP},
+		Stacking:   StackingModeStack,
+	}
+}
+
+// ParsePricingPolicy builds a policy from rule names in precedence order and a stacking mode
+func ParsePricingPolicy(precedence []string, stacking string) (PricingPolicy, error) {
+	policy := PricingPolicy{Stacking: StackingMode(strings.TrimSpace(stacking))}
+	if policy.Stacking != StackingModeStack && policy.Stacking != StackingModeBest {
+		return PricingPolicy{}, fmt.Errorf("%w: unknown stacking mode %q", ErrInvalidPricingData, stacking)
+	}
+
+	seen := make(map[PricingRule]bool)
+	for _, name := range precedence {
+		rule := PricingRule(strings.TrimSpace(name))
+		if rule == "" {
+			continue
+		}
+		switch rule {
+		case PricingRuleTier, PricingRuleGroup, PricingRuleVIP:
+		default:
+			return PricingPolicy{}, fmt.Errorf("%w: unknown pricing rule %q", ErrInvalidPricingData, name)
+		}
+		if seen[rule] {
+			return PricingPolicy{}, fmt.Errorf("%w: pricing rule %q is listed twice", ErrInvalidPricingData, name)
+		}
+		seen[rule] = true
+		policy.Precedence = append(policy.Precedence, rule)
+	}
+	return policy, nil
+}
+
+// Applies reports whether the policy applies a rule
+func (p PricingPolicy) Applies(rule PricingRule) bool {
+	for _, r := range p.Precedence {
+		if r == rule {
+			return true
+		}
+	}
+	return false
+}
+
+// AppliedPricingRule explains what a rule did to the unit price of a line
+type AppliedPricingRule struct {
+	Rule        PricingRule     `json:"rule"`
+	Description string          `json:"description"`
+	PriceBefore decimal.Decimal `json:"price_before"`
+	PriceAfter  decimal.Decimal `json:"price_after"`
+	// Reason is set on rules that matched the line but were not applied
+	Reason string `json:"reason,omitempty"`
+}
+
+// CartLinePricing is what can price one line of a cart
+type CartLinePricing struct {
+	BasePrice decimal.Decimal
+	// Quantity chooses the tier; it is the quantity of the product across the whole cart
+	Quantity int
+	Tiers    []*ProductPricingTier
+	Group    *CustomerGroupPricing
+	VIP      *VIPPricingBenefits
+	At       time.Time
+}
+
+// PricedLine is the unit price of a line and the rules behind it
+type PricedLine struct {
+	UnitPrice decimal.Decimal
+	Applied   []AppliedPricingRule
+	// Skipped lists rules that matched but did not lower the price, or lost to a better rule
+	Skipped []AppliedPricingRule
+}
+
+// pricingStep is a rule that matched a line, as a change to the unit price
+type pricingStep struct {
+	rule        PricingRule
+	description string
+	apply       func(price decimal.Decimal) decimal.Decimal
+}
+
+// Price works out the unit price of a line under the policy. Prices are rounded to satang and
+// never go below zero.
+func (p PricingPolicy) Price(line CartLinePricing) PricedLine {
+	var steps []pricingStep
+	for _, rule := range p.Precedence {
+		if step, ok := line.step(rule, p.Applies(PricingRuleVIP)); ok {
+			steps = append(steps, step)
+		}
+	}
+
+	base := roundPrice(line.BasePrice)
+	result := PricedLine{UnitPrice: base}
+
+	if p.Stacking == StackingModeBest {
+		best := -1
+		bestPrice := base
+		prices := make([]decimal.Decimal, len(steps))
+		for i, step := range steps {
+			prices[i] = roundPrice(step.apply(base))
+			if prices[i].LessThan(bestPrice) {
+				best, bestPrice = i, prices[i]
+			}
+		}
+		for i, step := range steps {
+			explained := AppliedPricingRule{Rule: step.rule, Description: step.description, PriceBefore: base, PriceAfter: prices[i]}
+			switch {
+			case i == best:
+				result.Applied = append(result.Applied, explained)
+			case !prices[i].LessThan(base):
+				explained.Reason = "not lower than the current price"
+				result.Skipped = append(result.Skipped, explained)
+			default:
+				explained.Reason = fmt.Sprintf("%s pricing gives a lower price", steps[best].rule)
+				result.Skipped = append(result.Skipped, explained)
+			}
+		}
+		result.UnitPrice = bestPrice
+		return result
+	}
+
+	for _, step := range steps {
+		price := roundPrice(step.apply(result.UnitPrice))
+		explained := AppliedPricingRule{Rule: step.rule, Description: step.description, PriceBefore: result.UnitPrice, PriceAfter: price}
+		if !price.LessThan(result.UnitPrice) {
+			explained.Reason = "not lower than the current price"
+			result.Skipped = append(result.Skipped, explained)
+			continue
+		}
+		result.Applied = append(result.Applied, explained)
+		result.UnitPrice = price
+	}
+	return result
+}
+
+// step turns a rule into a pricing step when it matches the line
+func (l CartLinePricing) step(rule PricingRule, vipApplies bool) (pricingStep, bool) {
+	switch rule {
+	case PricingRuleTier:
+		quantity := l.Quantity
+		if vipApplies {
+			quantity = l.VIP.tierQuantity(quantity)
+		}
+		tier := OptimalPricingTier(l.Tiers, quantity, l.At)
+		if tier == nil {
+			return pricingStep{}, false
+		}
+		description := fmt.Sprintf("%s at %s for %d or more", tier.name(), tier.Price.StringFixed(2), tier.MinQuantity)
+		if quantity != l.Quantity {
+			description += fmt.Sprintf(", %d counted for %d with the VIP quantity multiplier", l.Quantity, quantity)
+		}
+		return pricingStep{
+			rule:        rule,
+			description: description,
+			apply: func(decimal.Decimal) decimal.Decimal {
+				return tier.Price
+			},
+		}, true
+
+	case PricingRuleGroup:
+		group := l.Group
+		if group == nil || !group.IsActive || (group.BasePrice == nil && group.DiscountPercentage == nil) {
+			return pricingStep{}, false
+		}
+		var parts []string
+		if group.BasePrice != nil {
+			parts = append(parts, "base price "+group.BasePrice.StringFixed(2))
+		}
+		if group.DiscountPercentage != nil {
+			parts = append(parts, group.DiscountPercentage.String()+"% off")
+		}
+		return pricingStep{
+			rule:        rule,
+			description: fmt.Sprintf("%s group %s", group.CustomerGroup, strings.Join(parts, " and ")),
+			apply: func(price decimal.Decimal) decimal.Decimal {
+				if group.BasePrice != nil && group.BasePrice.LessThan(price) {
+					price = *group.BasePrice
+				}
+				if group.DiscountPercentage != nil {
+					price = percentOff(price, *group.DiscountPercentage)
+				}
+				return price
+			},
+		}, true
+
+	case PricingRuleVIP:
+		vip := l.VIP
+		if vip == nil || !vip.IsActive || vip.GlobalDiscountPercentage == nil {
+			return pricingStep{}, false
+		}
+		return pricingStep{
+			rule:        rule,
+			description: fmt.Sprintf("%s VIP %s%% off", vip.VIPLevel, vip.GlobalDiscountPercentage.String()),
+			apply: func(price decimal.Decimal) decimal.Decimal {
+				return percentOff(price, *vip.GlobalDiscountPercentage)
+			},
+		}, true
+	}
+	return pricingStep{}, false
+}
+
+// OptimalPricingTier returns the active tier with the highest minimum the quantity reaches on
+// the given date, or nil when the quantity reaches none
+func OptimalPricingTier(tiers []*ProductPricingTier, quantity int, at time.Time) *ProductPricingTier {
+	var best *ProductPricingTier
+	for _, tier := range tiers {
+		if !tier.AppliesTo(quantity, at) {
+			continue
+		}
+		if best == nil || tier.MinQuantity > best.MinQuantity {
+			best = tier
+		}
+	}
+	return best
+}
+
+// AppliesTo reports whether the tier is active on the given date and covers the quantity.
+// Validity dates are inclusive.
+func (t *ProductPricingTier) AppliesTo(quantity int, at time.Time) bool {
+	if !t.IsActive || quantity < t.MinQuantity || (t.MaxQuantity != nil && quantity > *t.MaxQuantity) {
+		return false
+	}
+	if t.ValidFrom != nil && at.Before(*t.ValidFrom) {
+		return false
+	}
+	if t.ValidUntil != nil && !at.Before(t.ValidUntil.AddDate(0, 0, 1)) {
+		return false
+	}
+	return true
+}
+
+// ValidatePricingTiers checks that no two active tiers of a product cover the same quantity
+func ValidatePricingTiers(tiers []*ProductPricingTier) error {
+	active := make([]*ProductPricingTier, 0, len(tiers))
+	for _, tier := range tiers {
+		if tier.MinQuantity < 1 || (tier.MaxQuantity != nil && *tier.MaxQuantity < tier.MinQuantity) || tier.Price.IsNegative() {
+			return fmt.Errorf("%w: tier %s has an invalid quantity range or price", ErrInvalidPricingData, tier.name())
+		}
+		if tier.IsActive {
+			active = append(active, tier)
+		}
+	}
+
+	sort.Slice(active, func(i, j int) bool { return active[i].MinQuantity < active[j].MinQuantity })
+	for i := 1; i < len(active); i++ {
+		previous := active[i-1]
+		if previous.MaxQuantity == nil || *previous.MaxQuantity >= active[i].MinQuantity {
+			return fmt.Errorf("%w: tiers %s and %s overlap", ErrInvalidPricingData, previous.name(), active[i].name())
+		}
+	}
+	return nil
+}
+
+func (t *ProductPricingTier) name() string {
+	if t.TierName != nil && *t.TierName != "" {
+		return fmt.Sprintf("tier %q", *t.TierName)
+	}
+	return fmt.Sprintf("tier %d+", t.MinQuantity)
+}
+
+// tierQuantity is the quantity a VIP level counts toward quantity tiers
+func (b *VIPPricingBenefits) tierQuantity(quantity int) int {
+	if b == nil || !b.IsActive || !b.QuantityMultiplier.GreaterThan(decimal.NewFromInt(1)) {
+		return quantity
+	}
+	return int(decimal.NewFromInt(int64(quantity)).Mul(b.QuantityMultiplier).IntPart())
+}
+
+// TableName maps pricing tiers to their table
+func (ProductPricingTier) TableName() string { return "product_pricing_tiers" }
+
+// TableName maps customer group pricing to its table
+func (CustomerGroupPricing) TableName() string { return "customer_group_pricing" }
+
+// TableName maps VIP pricing benefits to their table
+func (VIPPricingBenefits) TableName() string { return "vip_pricing_benefits" }
+
+var hundred = decimal.NewFromInt(100)
+
+func percentOff(price, percent decimal.Decimal) decimal.Decimal {
+	return price.Mul(hundred.Sub(percent)).Div(hundred)
+}
+
+func roundPrice(price decimal.Decimal) decimal.Decimal {
+	if price.IsNegative() {
+		return decimal.Zero
+	}
+	return price.Round(2)
+}
@@ -0,0 +1,192 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+func dec(v string) decimal.Decimal { return decimal.RequireFromString(v) }
+
+func decPtr(v string) *decimal.Decimal {
+	d := dec(v)
+	return &d
+}
+
+func intPtr(v int) *int { return &v }
+
+func tier(min int, max *int, price string) *ProductPricingTier {
+	return &ProductPricingTier{MinQuantity: min, MaxQuantity: max, Price: dec(price), IsActive: true}
+}
+
+func TestPricingPolicyPrice(t *testing.T) {
+	at := time.Date(2024, 10, 17, 12, 0, 0, 0, time.UTC)
+	// 10 or more at 90, against a base price of 100
+	tiers := []*ProductPricingTier{tier(10, nil, "90")}
+	group := &CustomerGroupPricing{CustomerGroup: "wholesale", DiscountPercentage: decPtr("10"), IsActive: true}
+	gold := &VIPPricingBenefits{VIPLevel: VIPLevelGold, GlobalDiscountPercentage: decPtr("5"), QuantityMultiplier: dec("1"), IsActive: true}
+	doubled := &VIPPricingBenefits{VIPLevel: VIPLevelGold, QuantityMultiplier: dec("2"), IsActive: true}
+
+	tests := []struct {
+		name    string
+		policy  PricingPolicy
+		line    CartLinePricing
+		want    string
+		applied []PricingRule
+	}{
+		{
+			name:   "no rule matches",
+			policy: DefaultPricingPolicy(),
+			line:   CartLinePricing{BasePrice: dec("100"), Quantity: 1, Tiers: tiers},
+			want:   "100",
+		},
+		{
+			name:    "rules stack in precedence order",
+			policy:  DefaultPricingPolicy(),
+			line:    CartLinePricing{BasePrice: dec("100"), Quantity: 10, Tiers: tiers, Group: group, VIP: gold},
+			want:    "76.95", // 90, then 10% off to 81, then 5% off
+			applied: []PricingRule{PricingRuleTier, PricingRuleGroup, PricingRuleVIP},
+		},
+		{
+			name:    "VIP global discount applies on its own",
+			policy:  DefaultPricingPolicy(),
+			line:    CartLinePricing{BasePrice: dec("100"), Quantity: 1, VIP: gold},
+			want:    "95",
+			applied: []PricingRule{PricingRuleVIP},
+		},
+		{
+			name:    "best mode applies only the lowest",
+			policy:  PricingPolicy{Precedence: DefaultPricingPolicy().Precedence, Stacking: StackingModeBest},
+			line:    CartLinePricing{BasePrice: dec("100"), Quantity: 10, Tiers: tiers, Group: group, VIP: gold},
+			want:    "90",
+			applied: []PricingRule{PricingRuleTier},
+		},
+		{
+			name:    "rules left out of the precedence are not applied",
+			policy:  PricingPolicy{Precedence: []PricingRule{PricingRuleGroup}, Stacking: StackingModeStack},
+			line:    CartLinePricing{BasePrice: dec("100"), Quantity: 10, Tiers: tiers, Group: group, VIP: gold},
+			want:    "90",
+			applied: []PricingRule{PricingRuleGroup},
+		},
+		{
+			name:    "VIP quantity multiplier reaches the tier",
+			policy:  DefaultPricingPolicy(),
+			line:    CartLinePricing{BasePrice: dec("100"), Quantity: 5, Tiers: tiers, VIP: doubled},
+			want:    "90",
+			applied: []PricingRule{PricingRuleTier},
+		},
+		{
+			name:   "inactive group is ignored",
+			policy: DefaultPricingPolicy(),
+			line:   CartLinePricing{BasePrice: dec("100"), Quantity: 1, Group: &CustomerGroupPricing{DiscountPercentage: decPtr("10")}},
+			want:   "100",
+		},
+		{
+			name:    "rounded to satang",
+			policy:  DefaultPricingPolicy(),
+			line:    CartLinePricing{BasePrice: dec("9.99"), Quantity: 1, VIP: gold},
+			want:    "9.49",
+			applied: []PricingRule{PricingRuleVIP},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.line.At = at
+			priced := tt.policy.Price(tt.line)
+			if !priced.UnitPrice.Equal(dec(tt.want)) {
+				t.Errorf("unit price %s, want %s", priced.UnitPrice, tt.want)
+			}
+			if len(priced.Applied) != len(tt.applied) {
+				t.Fatalf("applied %+v, want %v", priced.Applied, tt.applied)
+			}
+			for i, rule := range tt.applied {
+				if priced.Applied[i].Rule != rule {
+					t.Errorf("applied rule %d is %s, want %s", i, priced.Applied[i].Rule, rule)
+				}
+			}
+		})
+	}
+}
+
+func TestPricingPolicyPriceSkipsRulesThatDoNotLowerThePrice(t *testing.T) {
+	// The group base price is above the tier price, so stacking it changes nothing
+	group := &CustomerGroupPricing{CustomerGroup: "retail", BasePrice: decPtr("95"), IsActive: true}
+	priced := DefaultPricingPolicy().Price(CartLinePricing{
+		BasePrice: dec("100"),
+		Quantity:  10,
+		Tiers:     []*ProductPricingTier{tier(10, nil, "90")},
+		Group:     group,
+		At:        time.Now(),
+	})
+	if !priced.UnitPrice.Equal(dec("90")) {
+		t.Errorf("unit price %s, want 90", priced.UnitPrice)
+	}
+	if len(priced.Skipped) != 1 || priced.Skipped[0].Rule != PricingRuleGroup || priced.Skipped[0].Reason == "" {
+		t.Errorf("skipped %+v, want the group rule with a reason", priced.Skipped)
+	}
+}
+
+func TestOptimalPricingTier(t *testing.T) {
+	at := time.Date(2024, 10, 17, 12, 0, 0, 0, time.UTC)
+	yesterday, tomorrow := at.AddDate(0, 0, -1), at.AddDate(0, 0, 1)
+	small := tier(1, intPtr(9), "100")
+	medium := tier(10, intPtr(49), "90")
+	large := tier(50, nil, "80")
+	inactive := tier(20, nil, "70")
+	inactive.IsActive = false
+	expired := tier(30, nil, "60")
+	expired.ValidUntil = &yesterday
+	endsToday := tier(40, nil, "85")
+	endsToday.ValidUntil = &at
+	notStarted := tier(25, nil, "65")
+	notStarted.ValidFrom = &tomorrow
+	tiers := []*ProductPricingTier{small, medium, large, inactive, expired, endsToday, notStarted}
+
+	tests := []struct {
+		quantity int
+		want     *ProductPricingTier
+	}{
+		{0, nil},
+		{5, small},
+		{10, medium},
+		{45, endsToday},
+		{60, large},
+	}
+	for _, tt := range tests {
+		if got := OptimalPricingTier(tiers, tt.quantity, at); got != tt.want {
+			t.Errorf("quantity %d: got %+v, want %+v", tt.quantity, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePricingTiers(t *testing.T) {
+	inactive := tier(5, nil, "80")
+	inactive.IsActive = false
+
+	tests := []struct {
+		name  string
+		tiers []*ProductPricingTier
+		valid bool
+	}{
+		{"ranges follow on", []*ProductPricingTier{tier(10, nil, "80"), tier(1, intPtr(9), "100")}, true},
+		{"inactive tiers may overlap", []*ProductPricingTier{tier(1, intPtr(9), "100"), inactive}, true},
+		{"overlapping ranges", []*ProductPricingTier{tier(1, intPtr(10), "100"), tier(10, nil, "80")}, false},
+		{"open range followed by another", []*ProductPricingTier{tier(1, nil, "100"), tier(10, nil, "80")}, false},
+		{"zero minimum", []*ProductPricingTier{tier(0, nil, "100")}, false},
+		{"maximum below minimum", []*ProductPricingTier{tier(10, intPtr(5), "100")}, false},
+		{"negative price", []*ProductPricingTier{tier(1, nil, "-1")}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePricingTiers(tt.tiers)
+			if tt.valid && err != nil {
+				t.Errorf("ValidatePricingTiers: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidPricingData) {
+				t.Errorf("got %v, want ErrInvalidPricingData", err)
+			}
+		})
+	}
+}
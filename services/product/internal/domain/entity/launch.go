@@ -0,0 +1,305 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LaunchStatus represents the state of a product launch
+type LaunchStatus string
+
+const (
+	LaunchStatusScheduled   LaunchStatus = "scheduled"
+	LaunchStatusEarlyAccess LaunchStatus = "early_access"
+	LaunchStatusReleased    LaunchStatus = "released"
+	LaunchStatusCancelled   LaunchStatus = "cancelled"
+)
+
+var (
+	ErrInvalidLaunch             = errors.New("invalid product launch")
+	ErrLaunchNotFound            = errors.New("product launch not found")
+	ErrLaunchNotPending          = errors.New("product launch was already released or cancelled")
+	ErrLaunchExists              = errors.New("product already has a launch that is not released")
+	ErrLaunchAllocationExhausted = errors.New("launch allocation for the VIP level is used up")
+)
+
+// ProductLaunch opens a product to VIP members from the minimum level up at EarlyAccessAt, and to
+// everyone at PublicAt. During early access each level with an allocation can reserve at most
+// its quota of the product; levels without one are not limited.
+type ProductLaunch struct {
+	ID            uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID     uuid.UUID    `json:"product_id" gorm:"type:uuid;not null"`
+	MinVIPLevel   *string      `json:"min_vip_level,omitempty"`
+	EarlyAccessAt time.Time    `json:"early_access_at" gorm:"not null"`
+	PublicAt      time.Time    `json:"public_at" gorm:"not null"`
+	Message       string       `json:"message" gorm:"not null"`
+	Status        LaunchStatus `json:"status" gorm:"not null;default:scheduled"`
+	// NotifiedCount is how many LINE users were told early access opened
+	NotifiedCount int                 `json:"notified_count" gorm:"not null;default:0"`
+	OpenedAt      *time.Time          `json:"opened_at,omitempty"`
+	ReleasedAt    *time.Time          `json:"released_at,omitempty"`
+	CancelledAt   *time.Time          `json:"cancelled_at,omitempty"`
+	CancelledBy   *string             `json:"cancelled_by,omitempty"`
+	CreatedBy     string              `json:"created_by" gorm:"not null"`
+	CreatedAt     time.Time           `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt     time.Time           `json:"updated_at" gorm:"autoUpdateTime"`
+	Allocations   []*LaunchAllocation `json:"allocations" gorm:"foreignKey:LaunchID"`
+}
+
+// TableName specifies the table name for GORM
+func (ProductLaunch) TableName() string {
+	return "product_launches"
+}
+
+// LaunchAllocation is how much of a launched product a VIP level may reserve during early
+// access. Claimed goes up as orders reserve the product and back down when they release it.
+type LaunchAllocation struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	LaunchID uuid.UUID `json:"launch_id" gorm:"type:uuid;not null"`
+	VIPLevel string    `json:"vip_level" gorm:"not null"`
+	Quota    float64   `json:"quota" gorm:"not null"`
+	Claimed  float64   `json:"claimed" gorm:"not null;default:0"`
+}
+
+// TableName specifies the table name for GORM
+func (LaunchAllocation) TableName() string {
+	return "product_launch_allocations"
+}
+
+// Remaining is how much of the allocation is left to reserve
+func (a *LaunchAllocation) Remaining() float64 {
+	return a.Quota - a.Claimed
+}
+
+// NewProductLaunch schedules the launch of a product. VIP only products have no public release
+// to launch, and early access must open in the future and before the product goes public.
+func NewProductLaunch(product *Product, minVIPLevel *string, earlyAccessAt, publicAt time.Time, quotas map[string]float64, message, createdBy string, now time.Time) (*ProductLaunch, error) {
+	if product.IsVIPOnly {
+		return nil, fmt.Errorf("%w: VIP only products are never released to everyone", ErrInvalidLaunch)
+	}
+	if strings.TrimSpace(createdBy) == "" {
+		return nil, fmt.Errorf("%w: who created the launch is required", ErrInvalidLaunch)
+	}
+	if minVIPLevel != nil && !IsValidVIPLevel(*minVIPLevel) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidVIPLevel, *minVIPLevel)
+	}
+	if !earlyAccessAt.After(now) {
+		return nil, fmt.Errorf("%w: early_access_at must be in the future", ErrInvalidLaunch)
+	}
+	if !publicAt.After(earlyAccessAt) {
+		return nil, fmt.Errorf("%w: public_at must be after early_access_at", ErrInvalidLaunch)
+	}
+
+	for level := range quotas {
+		if !IsValidVIPLevel(level) {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidVIPLevel, level)
+		}
+	}
+
+	launch := &ProductLaunch{
+		ID:            uuid.New(),
+		ProductID:     product.ID,
+		MinVIPLevel:   minVIPLevel,
+		EarlyAccessAt: earlyAccessAt,
+		PublicAt:      publicAt,
+		Message:       strings.TrimSpace(message),
+		Status:        LaunchStatusScheduled,
+		CreatedBy:     createdBy,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	// In level order, so launches list their allocations the same way every time
+	for _, level := range vipLevels {
+		quota, ok := quotas[level]
+		if !ok {
+			continue
+		}
+		if !launch.IsEligible(level) {
+			return nil, fmt.Errorf("%w: %s is below the minimum VIP level of the launch", ErrInvalidLaunch, level)
+		}
+		if quota <= 0 {
+			return nil, fmt.Errorf("%w: the %s quota must be positive", ErrInvalidLaunch, level)
+		}
+		launch.Allocations = append(launch.Allocations, &LaunchAllocation{
+			ID:       uuid.New(),
+			LaunchID: launch.ID,
+			VIPLevel: level,
+			Quota:    quota,
+		})
+	}
+	return launch, nil
+}
+
+// IsPending reports whether the launch is yet to be released or cancelled
+func (l *ProductLaunch) IsPending() bool {
+	return l.Status == LaunchStatusScheduled || l.Status == LaunchStatusEarlyAccess
+}
+
+// IsEligible reports whether members of the VIP level get early access
+func (l *ProductLaunch) IsEligible(vipLevel string) bool {
+	return meetsVIPLevel(vipLevel, l.MinVIPLevel)
+}
+
+// EligibleLevels lists the VIP levels that get early access, from lowest to highest
+func (l *ProductLaunch) EligibleLevels() []string {
+	return VIPLevelsFrom(l.MinVIPLevel)
+}
+
+// AllocationFor returns the allocation a reservation by a member of the VIP level counts
+// against during early access, nil when the level has no quota
+func (l *ProductLaunch) AllocationFor(vipLevel string) (*LaunchAllocation, error) {
+	if !l.IsEligible(vipLevel) {
+		return nil, fmt.Errorf("%w: early access is for %s", ErrVIPAccessRequired, l.audience())
+	}
+	for _, allocation := range l.Allocations {
+		if allocation.VIPLevel == vipLevel {
+			return allocation, nil
+		}
+	}
+	return nil, nil
+}
+
+// OpenEarlyAccess opens the product to the eligible VIP levels until the launch goes public
+func (l *ProductLaunch) OpenEarlyAccess(product *Product, now time.Time) error {
+	if l.Status != LaunchStatusScheduled {
+		return ErrLaunchNotPending
+	}
+	publicAt := l.PublicAt
+	product.MinVIPLevel = l.MinVIPLevel
+	product.VIPEarlyAccess = true
+	product.EarlyAccessUntil = &publicAt
+	product.UpdatedAt = now
+
+	l.Status = LaunchStatusEarlyAccess
+	l.OpenedAt = &now
+	l.UpdatedAt = now
+	return nil
+}
+
+// Release opens the product to everyone
+func (l *ProductLaunch) Release(product *Product, now time.Time) error {
+	if l.Status != LaunchStatusEarlyAccess {
+		return ErrLaunchNotPending
+	}
+	product.endEarlyAccess(now)
+
+	l.Status = LaunchStatusReleased
+	l.ReleasedAt = &now
+	l.UpdatedAt = now
+	return nil
+}
+
+// Cancel calls off a launch that is not released yet. A product already in early access goes
+// back to being sold to everyone.
+func (l *ProductLaunch) Cancel(product *Product, cancelledBy string, now time.Time) error {
+	if !l.IsPending() {
+		return ErrLaunchNotPending
+	}
+	if strings.TrimSpace(cancelledBy) == "" {
+		return fmt.Errorf("%w: who cancelled the launch is required", ErrInvalidLaunch)
+	}
+	if l.Status == LaunchStatusEarlyAccess {
+		product.endEarlyAccess(now)
+	}
+
+	l.Status = LaunchStatusCancelled
+	l.CancelledAt = &now
+	l.CancelledBy = &cancelledBy
+	l.UpdatedAt = now
+	return nil
+}
+
+// audience describes who the launch opens to first
+func (l *ProductLaunch) audience() string {
+	if l.MinVIPLevel == nil {
+		return "VIP members"
+	}
+	return fmt.Sprintf("VIP %s members and above", *l.MinVIPLevel)
+}
+
+// InEarlyAccess reports whether the product is open to VIP members only at the time
+func (p *Product) InEarlyAccess(now time.Time) bool {
+	return p.VIPEarlyAccess && (p.EarlyAccessUntil == nil || now.Before(*p.EarlyAccessUntil))
+}
+
+// CheckAccess checks that a customer of the VIP level, empty for customers who are not VIP
+// members, can buy the product at the time
+func (p *Product) CheckAccess(vipLevel string, now time.Time) error {
+	if !p.IsAvailable() {
+		return ErrProductNotAvailable
+	}
+	if vipLevel != "" && !IsValidVIPLevel(vipLevel) {
+		return fmt.Errorf("%w: %q", ErrInvalidVIPLevel, vipLevel)
+	}
+
+	earlyAccess := p.InEarlyAccess(now)
+	if !p.IsVIPOnly && !earlyAccess {
+		return nil
+	}
+	if vipLevel == "" {
+		if earlyAccess && p.EarlyAccessUntil != nil {
+			return fmt.Errorf("%w: VIP early access until %s", ErrVIPAccessRequired,
+				p.EarlyAccessUntil.In(businessLocation).Format("2006-01-02 15:04"))
+		}
+		return fmt.Errorf("%w: VIP members only", ErrVIPAccessRequired)
+	}
+	if !meetsVIPLevel(vipLevel, p.MinVIPLevel) {
+		return fmt.Errorf("%w: requires VIP %s or above", ErrVIPAccessRequired, *p.MinVIPLevel)
+	}
+	return nil
+}
+
+// endEarlyAccess opens the product to everyone
+func (p *Product) endEarlyAccess(now time.Time) {
+	p.MinVIPLevel = nil
+	p.VIPEarlyAccess = false
+	p.EarlyAccessUntil = nil
+	p.UpdatedAt = now
+}
+
+// VIPLevelsFrom lists the VIP levels at or above the minimum, all of them when it is nil
+func VIPLevelsFrom(minVIPLevel *string) []string {
+	var levels []string
+	for _, level := range vipLevels {
+		if meetsVIPLevel(level, minVIPLevel) {
+			levels = append(levels, level)
+		}
+	}
+	return levels
+}
+
+// VIPLevelsUpTo lists the VIP levels at or below the level
+func VIPLevelsUpTo(vipLevel string) []string {
+	var levels []string
+	for _, level := range vipLevels {
+		levels = append(levels, level)
+		if level == vipLevel {
+			return levels
+		}
+	}
+	return nil
+}
+
+// meetsVIPLevel reports whether the VIP level is at or above the minimum. Any level meets no
+// minimum; no level meets any.
+func meetsVIPLevel(vipLevel string, minVIPLevel *string) bool {
+	rank := vipLevelRank(vipLevel)
+	if rank == 0 {
+		return false
+	}
+	return minVIPLevel == nil || rank >= vipLevelRank(*minVIPLevel)
+}
+
+// vipLevelRank is the position of the level from 1 for the lowest, 0 for unknown levels
+func vipLevelRank(level string) int {
+	for i, known := range vipLevels {
+		if level == known {
+			return i + 1
+		}
+	}
+	return 0
+}
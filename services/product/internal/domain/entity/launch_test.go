@@ -0,0 +1,164 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func stringPtr(v string) *string { return &v }
+
+func TestNewProductLaunch(t *testing.T) {
+	now := time.Date(2024, 10, 17, 9, 0, 0, 0, businessLocation)
+	earlyAccess, public := now.Add(24*time.Hour), now.Add(72*time.Hour)
+	product := &Product{ID: uuid.New(), IsActive: true, IsAdminActive: true}
+	vipOnly := &Product{ID: uuid.New(), IsActive: true, IsAdminActive: true, IsVIPOnly: true}
+	gold := stringPtr(VIPLevelGold)
+
+	tests := []struct {
+		name          string
+		product       *Product
+		minVIPLevel   *string
+		earlyAccessAt time.Time
+		publicAt      time.Time
+		quotas        map[string]float64
+		createdBy     string
+		want          error
+	}{
+		{"every level", product, nil, earlyAccess, public, nil, "admin-1", nil},
+		{"gold and above with quotas", product, gold, earlyAccess, public, map[string]float64{VIPLevelGold: 10, VIPLevelDiamond: 5}, "admin-1", nil},
+		{"VIP only product", vipOnly, nil, earlyAccess, public, nil, "admin-1", ErrInvalidLaunch},
+		{"without creator", product, nil, earlyAccess, public, nil, " ", ErrInvalidLaunch},
+		{"unknown minimum level", product, stringPtr("iron"), earlyAccess, public, nil, "admin-1", ErrInvalidVIPLevel},
+		{"early access in the past", product, nil, now, public, nil, "admin-1", ErrInvalidLaunch},
+		{"public before early access", product, nil, earlyAccess, earlyAccess, nil, "admin-1", ErrInvalidLaunch},
+		{"quota of unknown level", product, nil, earlyAccess, public, map[string]float64{"iron": 1}, "admin-1", ErrInvalidVIPLevel},
+		{"quota below the minimum level", product, gold, earlyAccess, public, map[string]float64{VIPLevelSilver: 1}, "admin-1", ErrInvalidLaunch},
+		{"zero quota", product, nil, earlyAccess, public, map[string]float64{VIPLevelGold: 0}, "admin-1", ErrInvalidLaunch},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			launch, err := NewProductLaunch(tt.product, tt.minVIPLevel, tt.earlyAccessAt, tt.publicAt, tt.quotas, "New flavour", tt.createdBy, now)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Errorf("got %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewProductLaunch: %v", err)
+			}
+			if launch.Status != LaunchStatusScheduled || len(launch.Allocations) != len(tt.quotas) {
+				t.Errorf("launch %+v, want scheduled with %d allocations", launch, len(tt.quotas))
+			}
+		})
+	}
+}
+
+func TestNewProductLaunchOrdersAllocationsByLevel(t *testing.T) {
+	now := time.Now()
+	product := &Product{ID: uuid.New()}
+	launch, err := NewProductLaunch(product, nil, now.Add(time.Hour), now.Add(2*time.Hour),
+		map[string]float64{VIPLevelDiamond: 1, VIPLevelBronze: 3, VIPLevelGold: 2}, "", "admin-1", now)
+	if err != nil {
+		t.Fatalf("NewProductLaunch: %v", err)
+	}
+
+	want := []string{VIPLevelBronze, VIPLevelGold, VIPLevelDiamond}
+	for i, allocation := range launch.Allocations {
+		if allocation.VIPLevel != want[i] || allocation.LaunchID != launch.ID {
+			t.Errorf("allocation %d = %+v, want %s of the launch", i, allocation, want[i])
+		}
+	}
+}
+
+func TestLaunchAllocationFor(t *testing.T) {
+	launch := &ProductLaunch{
+		MinVIPLevel: stringPtr(VIPLevelGold),
+		Allocations: []*LaunchAllocation{{VIPLevel: VIPLevelGold, Quota: 10}},
+	}
+
+	allocation, err := launch.AllocationFor(VIPLevelGold)
+	if err != nil || allocation == nil || allocation.Quota != 10 {
+		t.Errorf("gold: got %+v, %v; want the gold allocation", allocation, err)
+	}
+	allocation, err = launch.AllocationFor(VIPLevelDiamond)
+	if err != nil || allocation != nil {
+		t.Errorf("diamond: got %+v, %v; want no limit", allocation, err)
+	}
+	for _, level := range []string{VIPLevelSilver, ""} {
+		if _, err := launch.AllocationFor(level); !errors.Is(err, ErrVIPAccessRequired) {
+			t.Errorf("%q: got %v, want ErrVIPAccessRequired", level, err)
+		}
+	}
+}
+
+func TestLaunchLifecycle(t *testing.T) {
+	now := time.Now()
+	product := &Product{ID: uuid.New(), IsActive: true, IsAdminActive: true}
+	launch, err := NewProductLaunch(product, stringPtr(VIPLevelGold), now.Add(time.Hour), now.Add(2*time.Hour), nil, "", "admin-1", now)
+	if err != nil {
+		t.Fatalf("NewProductLaunch: %v", err)
+	}
+
+	if err := launch.Release(product, now); !errors.Is(err, ErrLaunchNotPending) {
+		t.Errorf("release before early access: got %v, want ErrLaunchNotPending", err)
+	}
+	if err := launch.OpenEarlyAccess(product, now.Add(time.Hour)); err != nil {
+		t.Fatalf("OpenEarlyAccess: %v", err)
+	}
+	if !product.InEarlyAccess(now.Add(time.Hour)) || *product.MinVIPLevel != VIPLevelGold {
+		t.Errorf("product %+v, want in early access for gold", product)
+	}
+	if err := launch.Release(product, now.Add(2*time.Hour)); err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if product.InEarlyAccess(now.Add(2*time.Hour)) || product.MinVIPLevel != nil {
+		t.Errorf("product %+v, want released to everyone", product)
+	}
+	if err := launch.Cancel(product, "admin-1", now); !errors.Is(err, ErrLaunchNotPending) {
+		t.Errorf("cancel after release: got %v, want ErrLaunchNotPending", err)
+	}
+}
+
+func TestCheckAccess(t *testing.T) {
+	now := time.Date(2024, 10, 17, 9, 0, 0, 0, businessLocation)
+	later := now.Add(time.Hour)
+
+	open := &Product{IsActive: true, IsAdminActive: true}
+	offSale := &Product{IsActive: true, IsAdminActive: false}
+	vipOnly := &Product{IsActive: true, IsAdminActive: true, IsVIPOnly: true, MinVIPLevel: stringPtr(VIPLevelGold)}
+	earlyAccess := &Product{IsActive: true, IsAdminActive: true, VIPEarlyAccess: true, EarlyAccessUntil: &later}
+	earlyAccessEnded := &Product{IsActive: true, IsAdminActive: true, VIPEarlyAccess: true, EarlyAccessUntil: &now}
+
+	tests := []struct {
+		name     string
+		product  *Product
+		vipLevel string
+		want     error
+	}{
+		{"open to everyone", open, "", nil},
+		{"off sale", offSale, VIPLevelDiamond, ErrProductNotAvailable},
+		{"unknown level", open, "iron", ErrInvalidVIPLevel},
+		{"VIP only for non members", vipOnly, "", ErrVIPAccessRequired},
+		{"VIP only below the minimum", vipOnly, VIPLevelSilver, ErrVIPAccessRequired},
+		{"VIP only at the minimum", vipOnly, VIPLevelGold, nil},
+		{"VIP only above the minimum", vipOnly, VIPLevelPlatinum, nil},
+		{"early access for non members", earlyAccess, "", ErrVIPAccessRequired},
+		{"early access for members", earlyAccess, VIPLevelBronze, nil},
+		{"after early access", earlyAccessEnded, "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.product.CheckAccess(tt.vipLevel, now)
+			if tt.want == nil && err != nil {
+				t.Errorf("CheckAccess: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
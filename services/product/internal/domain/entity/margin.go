@@ -0,0 +1,118 @@
+package entity
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCategoryNotFound       = errors.New("category not found")
+	ErrInvalidMarginPolicy    = errors.New("invalid margin policy")
+	ErrMarginApprovalRequired = errors.New("price is below cost or the minimum margin and needs a manager's approval")
+)
+
+// CategoryMarginPolicy is the minimum margin the products of a category may be priced at.
+// Categories without a policy use the default minimum margin.
+type CategoryMarginPolicy struct {
+	CategoryID       uuid.UUID `json:"category_id" gorm:"type:uuid;primary_key"`
+	MinMarginPercent float64   `json:"min_margin_percent" gorm:"not null"`
+	UpdatedBy        string    `json:"updated_by" gorm:"not null"`
+	CreatedAt        time.Time `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt        time.Time `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (CategoryMarginPolicy) TableName() string {
+	return "category_margin_policies"
+}
+
+// NewCategoryMarginPolicy creates the margin policy of a category
+func NewCategoryMarginPolicy(categoryID uuid.UUID, minMarginPercent float64, updatedBy string) (*CategoryMarginPolicy, error) {
+	if minMarginPercent < 0 || minMarginPercent >= 100 {
+		return nil, fmt.Errorf("%w: minimum margin must be at least 0%% and under 100%%", ErrInvalidMarginPolicy)
+	}
+	if updatedBy == "" {
+		return nil, fmt.Errorf("%w: who set the policy is required", ErrInvalidMarginPolicy)
+	}
+
+	now := time.Now()
+	return &CategoryMarginPolicy{
+		CategoryID:       categoryID,
+		MinMarginPercent: minMarginPercent,
+		UpdatedBy:        updatedBy,
+		CreatedAt:        now,
+		UpdatedAt:        now,
+	}, nil
+}
+
+// MarginCheck compares a price of a product with its cost
+type MarginCheck struct {
+	ProductID uuid.UUID `json:"product_id"`
+	Price     float64   `json:"price"`
+	CostPrice *float64  `json:"cost_price,omitempty"`
+	// MarginPercent is empty when the cost of the product is unknown; nothing is checked then
+	MarginPercent       *float64 `json:"margin_percent,omitempty"`
+	MinMarginPercent    float64  `json:"min_margin_percent"`
+	TargetMarginPercent *float64 `json:"target_margin_percent,omitempty"`
+	BelowCost           bool     `json:"below_cost"`
+	BelowMinimum        bool     `json:"below_minimum"`
+	BelowTarget         bool     `json:"below_target"`
+}
+
+// CheckMargin checks a price of a product against its cost, the minimum margin of its category
+// and its margin target
+func CheckMargin(product *Product, price, minMarginPercent float64) *MarginCheck {
+	check := &MarginCheck{
+		ProductID:           product.ID,
+		Price:               price,
+		CostPrice:           product.CostPrice,
+		MinMarginPercent:    minMarginPercent,
+		TargetMarginPercent: product.ProfitMarginTarget,
+	}
+	if product.CostPrice == nil {
+		return check
+	}
+
+	margin := MarginPercent(price, *product.CostPrice)
+	check.MarginPercent = &margin
+	check.BelowCost = price < *product.CostPrice
+	check.BelowMinimum = margin < minMarginPercent
+	if product.ProfitMarginTarget != nil {
+		check.BelowTarget = margin < *product.ProfitMarginTarget
+	}
+	return check
+}
+
+// NeedsApproval tells whether the price may only be used with a manager's approval
+func (c *MarginCheck) NeedsApproval() bool {
+	return c.BelowCost || c.BelowMinimum
+}
+
+// MarginPercent is the margin a price makes over a cost as a percentage of the price, rounded
+// to two decimals. Giving away a product that costs something is a margin of -100%.
+func MarginPercent(price, cost float64) float64 {
+	if price <= 0 {
+		if cost > 0 {
+			return -100
+		}
+		return 0
+	}
+	return math.Round((price-cost)/price*10000) / 100
+}
+
+// MarginApprovalError lists the prices that need a manager's approval
+type MarginApprovalError struct {
+	Checks []*MarginCheck
+}
+
+func (e *MarginApprovalError) Error() string {
+	return ErrMarginApprovalRequired.Error()
+}
+
+func (e *MarginApprovalError) Unwrap() error {
+	return ErrMarginApprovalRequired
+}
@@ -0,0 +1,96 @@
+package entity
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestMarginPercent(t *testing.T) {
+	tests := []struct {
+		name        string
+		price, cost float64
+		want        float64
+	}{
+		{"healthy margin", 100, 75, 25},
+		{"at cost", 80, 80, 0},
+		{"below cost", 80, 100, -25},
+		{"rounded to two decimals", 30, 20, 33.33},
+		{"given away", 0, 50, -100},
+		{"free and costless", 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MarginPercent(tt.price, tt.cost); got != tt.want {
+				t.Errorf("MarginPercent(%v, %v) = %v, want %v", tt.price, tt.cost, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckMargin(t *testing.T) {
+	costed := &Product{ID: uuid.New(), CostPrice: floatPtr(80), ProfitMarginTarget: floatPtr(30)}
+
+	tests := []struct {
+		name          string
+		product       *Product
+		price         float64
+		minMargin     float64
+		belowCost     bool
+		belowMinimum  bool
+		belowTarget   bool
+		needsApproval bool
+	}{
+		{"above every margin", costed, 200, 10, false, false, false, false},
+		{"under target only", costed, 100, 10, false, false, true, false},
+		{"under the category minimum", costed, 85, 10, false, true, true, true},
+		{"below cost", costed, 70, 0, true, true, true, true},
+		{"cost unknown", &Product{ID: uuid.New()}, 1, 10, false, false, false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			check := CheckMargin(tt.product, tt.price, tt.minMargin)
+			if check.BelowCost != tt.belowCost || check.BelowMinimum != tt.belowMinimum || check.BelowTarget != tt.belowTarget {
+				t.Errorf("check = cost %v, minimum %v, target %v; want %v, %v, %v",
+					check.BelowCost, check.BelowMinimum, check.BelowTarget, tt.belowCost, tt.belowMinimum, tt.belowTarget)
+			}
+			if got := check.NeedsApproval(); got != tt.needsApproval {
+				t.Errorf("NeedsApproval() = %v, want %v", got, tt.needsApproval)
+			}
+			if (check.MarginPercent == nil) != (tt.product.CostPrice == nil) {
+				t.Errorf("margin percent %v with cost %v", check.MarginPercent, tt.product.CostPrice)
+			}
+		})
+	}
+}
+
+func TestNewCategoryMarginPolicy(t *testing.T) {
+	categoryID := uuid.New()
+
+	policy, err := NewCategoryMarginPolicy(categoryID, 12.5, "manager-1")
+	if err != nil {
+		t.Fatalf("NewCategoryMarginPolicy: %v", err)
+	}
+	if policy.CategoryID != categoryID || policy.MinMarginPercent != 12.5 || policy.UpdatedBy != "manager-1" {
+		t.Errorf("policy = %+v", policy)
+	}
+
+	for _, margin := range []float64{-1, 100, 150} {
+		if _, err := NewCategoryMarginPolicy(categoryID, margin, "manager-1"); !errors.Is(err, ErrInvalidMarginPolicy) {
+			t.Errorf("margin %v: got %v, want ErrInvalidMarginPolicy", margin, err)
+		}
+	}
+	if _, err := NewCategoryMarginPolicy(categoryID, 10, ""); !errors.Is(err, ErrInvalidMarginPolicy) {
+		t.Errorf("no author: got %v, want ErrInvalidMarginPolicy", err)
+	}
+}
+
+func TestMarginApprovalErrorUnwraps(t *testing.T) {
+	err := error(&MarginApprovalError{Checks: []*MarginCheck{{Price: 1}}})
+	if !errors.Is(err, ErrMarginApprovalRequired) {
+		t.Errorf("%v does not unwrap to ErrMarginApprovalRequired", err)
+	}
+}
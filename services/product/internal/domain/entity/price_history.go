@@ -0,0 +1,310 @@
+package entity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PriceChangeSource tells what changed a base price
+type PriceChangeSource string
+
+const (
+	PriceChangeSourceManual    PriceChangeSource = "manual"
+	PriceChangeSourceScheduled PriceChangeSource = "scheduled"
+	PriceChangeSourceSync      PriceChangeSource = "loyverse_sync"
+)
+
+// ScheduledPriceChangeStatus represents the state of a scheduled price change
+type ScheduledPriceChangeStatus string
+
+const (
+	ScheduledPriceChangePending   ScheduledPriceChangeStatus = "pending"
+	ScheduledPriceChangeApplied   ScheduledPriceChangeStatus = "applied"
+	ScheduledPriceChangeCancelled ScheduledPriceChangeStatus = "cancelled"
+)
+
+var (
+	ErrInvalidPriceChange             = errors.New("invalid price change")
+	ErrScheduledPriceChangeNotFound   = errors.New("scheduled price change not found")
+	ErrScheduledPriceChangeNotPending = errors.New("scheduled price change was already applied or cancelled")
+	ErrNoPriceAsOf                    = errors.New("product did not exist at that time")
+	ErrPriceNotFound                  = errors.New("price not found")
+)
+
+// PriceHistoryEntry records a change of a product's base price. Entries are never updated or
+// deleted, so the history shows what a product cost at any time.
+type PriceHistoryEntry struct {
+	ID            uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID     uuid.UUID         `json:"product_id" gorm:"type:uuid;not null"`
+	OldPrice      float64           `json:"old_price" gorm:"not null"`
+	NewPrice      float64           `json:"new_price" gorm:"not null"`
+	Currency      string            `json:"currency" gorm:"not null;default:'THB'"`
+	EffectiveFrom time.Time         `json:"effective_from" gorm:"not null"`
+	Source        PriceChangeSource `json:"source" gorm:"not null"`
+	ChangedBy     string            `json:"changed_by" gorm:"not null"`
+	Reason        string            `json:"reason" gorm:"not null"`
+	// ApprovedBy is the manager who approved a price below cost or the minimum margin
+	ApprovedBy *string `json:"approved_by,omitempty"`
+	// ScheduledChangeID is the scheduled change that made this change, if any
+	ScheduledChangeID *uuid.UUID `json:"scheduled_change_id,omitempty" gorm:"type:uuid"`
+	CreatedAt         time.Time  `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (PriceHistoryEntry) TableName() string {
+	return "price_history"
+}
+
+// NewPriceHistoryEntry creates the record of a base price change taking effect at the given time.
+// The old price is filled in when the change is saved.
+func NewPriceHistoryEntry(productID uuid.UUID, newPrice float64, source PriceChangeSource, changedBy, reason string, effectiveFrom time.Time) (*PriceHistoryEntry, error) {
+	if err := validatePriceChange(newPrice, changedBy, reason); err != nil {
+		return nil, err
+	}
+
+	return &PriceHistoryEntry{
+		ID:            uuid.New(),
+		ProductID:     productID,
+		NewPrice:      newPrice,
+		Currency:      "THB",
+		EffectiveFrom: effectiveFrom,
+		Source:        source,
+		ChangedBy:     changedBy,
+		Reason:        reason,
+		CreatedAt:     effectiveFrom,
+	}, nil
+}
+
+// ScheduledPriceChange is a base price change that takes effect at a later time
+type ScheduledPriceChange struct {
+	ID          uuid.UUID                  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	ProductID   uuid.UUID                  `json:"product_id" gorm:"type:uuid;not null"`
+	NewPrice    float64                    `json:"new_price" gorm:"not null"`
+	Currency    string                     `json:"currency" gorm:"not null;default:'THB'"`
+	EffectiveAt time.Time                  `json:"effective_at" gorm:"not null"`
+	Status      ScheduledPriceChangeStatus `json:"status" gorm:"not null;default:pending"`
+	RequestedBy string                     `json:"requested_by" gorm:"not null"`
+	Reason      string                     `json:"reason" gorm:"not null"`
+	ApprovedBy  *string                    `json:"approved_by,omitempty"`
+	AppliedAt   *time.Time                 `json:"applied_at,omitempty"`
+	CancelledAt *time.Time                 `json:"cancelled_at,omitempty"`
+	CancelledBy *string                    `json:"cancelled_by,omitempty"`
+	CreatedAt   time.Time                  `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt   time.Time                  `json:"updated_at" gorm:"autoUpdateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (ScheduledPriceChange) TableName() string {
+	return "scheduled_price_changes"
+}
+
+// NewScheduledPriceChange creates a pending change of a product's base price. It must take
+// effect after now; changes that apply right away are made on the product.
+func NewScheduledPriceChange(productID uuid.UUID, newPrice float64, effectiveAt time.Time, requestedBy, reason string, now time.Time) (*ScheduledPriceChange, error) {
+	if err := validatePriceChange(newPrice, requestedBy, reason); err != nil {
+		return nil, err
+	}
+	if !effectiveAt.After(now) {
+		return nil, fmt.Errorf("%w: effective_at must be in the future", ErrInvalidPriceChange)
+	}
+
+	return &ScheduledPriceChange{
+		ID:          uuid.New(),
+		ProductID:   productID,
+		NewPrice:    newPrice,
+		Currency:    "THB",
+		EffectiveAt: effectiveAt,
+		Status:      ScheduledPriceChangePending,
+		RequestedBy: requestedBy,
+		Reason:      reason,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}, nil
+}
+
+// PriceRowAction tells what happened to a price row
+type PriceRowAction string
+
+const (
+	PriceRowCreated PriceRowAction = "created"
+	PriceRowUpdated PriceRowAction = "updated"
+	PriceRowDeleted PriceRowAction = "deleted"
+)
+
+// PriceRowChange records a change of a VIP, bulk or promotional price row. Like base price
+// changes, entries are never updated or deleted, so the history shows who set each price and why.
+type PriceRowChange struct {
+	ID        uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	PriceID   uuid.UUID      `json:"price_id" gorm:"type:uuid;not null"`
+	ProductID uuid.UUID      `json:"product_id" gorm:"type:uuid;not null"`
+	PriceType string         `json:"price_type" gorm:"not null"`
+	Action    PriceRowAction `json:"action" gorm:"not null"`
+	// OldRow is empty for created rows and NewRow for deleted ones
+	OldRow     *PriceRowSnapshot `json:"old_row,omitempty" gorm:"type:jsonb"`
+	NewRow     *PriceRowSnapshot `json:"new_row,omitempty" gorm:"type:jsonb"`
+	ChangedBy  string            `json:"changed_by" gorm:"not null"`
+	Reason     string            `json:"reason" gorm:"not null"`
+	ApprovedBy *string           `json:"approved_by,omitempty"`
+	CreatedAt  time.Time         `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// TableName specifies the table name for GORM
+func (PriceRowChange) TableName() string {
+	return "price_row_history"
+}
+
+// PriceRowSnapshot is what a price row said when it changed
+type PriceRowSnapshot struct {
+	Price            float64     `json:"price"`
+	Currency         string      `json:"currency"`
+	MinQuantity      *int        `json:"min_quantity,omitempty"`
+	MaxQuantity      *int        `json:"max_quantity,omitempty"`
+	VIPTierID        *uuid.UUID  `json:"vip_tier_id,omitempty"`
+	ValidFrom        *time.Time  `json:"valid_from,omitempty"`
+	ValidTo          *time.Time  `json:"valid_to,omitempty"`
+	PromotionName    *string     `json:"promotion_name,omitempty"`
+	DiscountPercent  *float64    `json:"discount_percent,omitempty"`
+	LocationIDs      []uuid.UUID `json:"location_ids,omitempty"`
+	CustomerGroupIDs []uuid.UUID `json:"customer_group_ids,omitempty"`
+	IsActive         bool        `json:"is_active"`
+	Priority         int         `json:"priority"`
+}
+
+// SnapshotPrice copies what a price row says, or returns nil for no row
+func SnapshotPrice(price *Price) *PriceRowSnapshot {
+	if price == nil {
+		return nil
+	}
+	return &PriceRowSnapshot{
+		Price:            price.Price,
+		Currency:         price.Currency,
+		MinQuantity:      price.MinQuantity,
+		MaxQuantity:      price.MaxQuantity,
+		VIPTierID:        price.VIPTierID,
+		ValidFrom:        price.ValidFrom,
+		ValidTo:          price.ValidTo,
+		PromotionName:    price.PromotionName,
+		DiscountPercent:  price.DiscountPercent,
+		LocationIDs:      append([]uuid.UUID(nil), price.LocationIDs...),
+		CustomerGroupIDs: append([]uuid.UUID(nil), price.CustomerGroupIDs...),
+		IsActive:         price.IsActive,
+		Priority:         price.Priority,
+	}
+}
+
+// Value stores the snapshot as JSON
+func (s PriceRowSnapshot) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads the snapshot from JSON
+func (s *PriceRowSnapshot) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	default:
+		return errors.New("cannot scan PriceRowSnapshot")
+	}
+}
+
+// NewPriceRowChange creates the record of a price row changing from before to after. Before is
+// nil for a created row and after for a deleted one. The old row is read again from the stored
+// row when the change is saved.
+func NewPriceRowChange(before, after *Price, changedBy, reason string, now time.Time) (*PriceRowChange, error) {
+	change := &PriceRowChange{
+		ID:        uuid.New(),
+		OldRow:    SnapshotPrice(before),
+		NewRow:    SnapshotPrice(after),
+		ChangedBy: changedBy,
+		Reason:    reason,
+		CreatedAt: now,
+	}
+	row := after
+	switch {
+	case before == nil && after != nil:
+		change.Action = PriceRowCreated
+	case before != nil && after != nil:
+		change.Action = PriceRowUpdated
+	case before != nil:
+		change.Action = PriceRowDeleted
+		row = before
+	default:
+		return nil, fmt.Errorf("%w: a price row change needs a row", ErrInvalidPriceChange)
+	}
+	if err := validatePriceChange(row.Price, changedBy, reason); err != nil {
+		return nil, err
+	}
+	change.PriceID = row.ID
+	change.ProductID = row.ProductID
+	change.PriceType = row.PriceType
+	return change, nil
+}
+
+// validatePriceChange checks that a price change is valid and says who made it and why
+func validatePriceChange(newPrice float64, changedBy, reason string) error {
+	if newPrice < 0 {
+		return fmt.Errorf("%w: price must be non-negative", ErrInvalidPriceChange)
+	}
+	if changedBy == "" {
+		return fmt.Errorf("%w: who changed the price is required", ErrInvalidPriceChange)
+	}
+	if reason == "" {
+		return fmt.Errorf("%w: a reason is required", ErrInvalidPriceChange)
+	}
+	return nil
+}
+
+// PriceAsOf is the base price a product had at a point in time
+type PriceAsOf struct {
+	ProductID uuid.UUID `json:"product_id"`
+	AsOf      time.Time `json:"as_of"`
+	Price     float64   `json:"price"`
+	Currency  string    `json:"currency"`
+	// ValidFrom is empty when the price predates the history
+	ValidFrom *time.Time `json:"valid_from"`
+	// ValidTo is empty while the price is still current
+	ValidTo *time.Time `json:"valid_to"`
+	// The change that set the price, when it is in the history
+	Source    PriceChangeSource `json:"source,omitempty"`
+	ChangedBy string            `json:"changed_by,omitempty"`
+	Reason    string            `json:"reason,omitempty"`
+}
+
+// BasePriceAsOf works out the base price of a product at a time from the last change in effect
+// then and the first change after it, either of which may be nil. Without a change in effect
+// the price is the one the first later change replaced, or the current price when it never
+// changed since.
+func BasePriceAsOf(product *Product, before, after *PriceHistoryEntry, at time.Time) (*PriceAsOf, error) {
+	if at.Before(product.CreatedAt) {
+		return nil, fmt.Errorf("%w: product was created at %s", ErrNoPriceAsOf, product.CreatedAt.Format(time.RFC3339))
+	}
+
+	asOf := &PriceAsOf{
+		ProductID: product.ID,
+		AsOf:      at,
+		Price:     product.BasePrice,
+		Currency:  "THB",
+	}
+	if before != nil {
+		asOf.Price = before.NewPrice
+		asOf.Currency = before.Currency
+		asOf.ValidFrom = &before.EffectiveFrom
+		asOf.Source = before.Source
+		asOf.ChangedBy = before.ChangedBy
+		asOf.Reason = before.Reason
+	} else if after != nil {
+		asOf.Price = after.OldPrice
+		asOf.Currency = after.Currency
+	}
+	if after != nil {
+		asOf.ValidTo = &after.EffectiveFrom
+	}
+	return asOf, nil
+}
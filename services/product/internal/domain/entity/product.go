@@ -22,8 +22,30 @@ type Product struct {
 	Dimensions  *ProductDimensions `json:"dimensions" gorm:"embedded"`
 	IsActive    bool               `json:"is_active" gorm:"default:true"`
 	IsVIPOnly   bool               `json:"is_vip_only" gorm:"default:false"`
+	IsVATExempt bool               `json:"is_vat_exempt" gorm:"default:false"` // e.g. fresh produce, sold without VAT
 	Tags        []string           `json:"tags" gorm:"type:text[]"`
 
+	// Margin guardrails. Margins are percentages of the selling price; the target is the margin
+	// the product should make, below which promotional and VIP prices are reported.
+	CostPrice          *float64 `json:"cost_price,omitempty"`
+	ProfitMarginTarget *float64 `json:"profit_margin_target,omitempty"`
+
+	// Availability set by admins on top of IsActive, which follows Loyverse. A product can be
+	// taken off sale until a time, and its schedule takes it on and off sale on recurring days
+	// and hours.
+	IsAdminActive      bool                  `json:"is_admin_active" gorm:"default:true"`
+	InactiveReason     *string               `json:"inactive_reason,omitempty"`
+	InactiveUntil      *time.Time            `json:"inactive_until,omitempty"`
+	AutoReactivate     bool                  `json:"auto_reactivate" gorm:"default:false"`
+	InactiveSchedule   *AvailabilitySchedule `json:"inactive_schedule,omitempty" gorm:"type:jsonb"`
+	InactiveBySchedule bool                  `json:"inactive_by_schedule" gorm:"default:false"`
+
+	// VIP gating on top of IsVIPOnly. The minimum level applies while the product is VIP only or
+	// in early access, which a launch opens to VIP members until the product goes public.
+	MinVIPLevel      *string    `json:"min_vip_level,omitempty"`
+	VIPEarlyAccess   bool       `json:"vip_early_access" gorm:"default:false"`
+	EarlyAccessUntil *time.Time `json:"early_access_until,omitempty"`
+
 	// Master Data Protection
 	DataSourceType   string     `json:"data_source_type" gorm:"not null"` // "loyverse", "manual"
 	DataSourceID     *string    `json:"data_source_id"`
@@ -68,6 +90,7 @@ func NewProduct(name, sku, unit string, basePrice float64) (*Product, error) {
 		Unit:           unit,
 		BasePrice:      basePrice,
 		IsActive:       true,
+		IsAdminActive:  true,
 		IsVIPOnly:      false,
 		DataSourceType: "manual",
 		CreatedAt:      time.Now(),
@@ -96,12 +119,38 @@ func (p *Product) UpdatePrice(price float64) error {
 	return nil
 }
 
+// UpdateCostPrice updates the cost price
+func (p *Product) UpdateCostPrice(cost float64) error {
+	if cost < 0 {
+		return errors.New("cost price must be non-negative")
+	}
+	p.CostPrice = &cost
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
+// UpdateProfitMarginTarget updates the margin the product should make
+func (p *Product) UpdateProfitMarginTarget(target float64) error {
+	if target < 0 || target >= 100 {
+		return errors.New("profit margin target must be at least 0% and under 100%")
+	}
+	p.ProfitMarginTarget = &target
+	p.UpdatedAt = time.Now()
+	return nil
+}
+
 // SetVIPOnly sets the VIP only flag
 func (p *Product) SetVIPOnly(vipOnly bool) {
 	p.IsVIPOnly = vipOnly
 	p.UpdatedAt = time.Now()
 }
 
+// SetVATExempt sets whether the product is sold without VAT
+func (p *Product) SetVATExempt(exempt bool) {
+	p.IsVATExempt = exempt
+	p.UpdatedAt = time.Now()
+}
+
 // Activate activates the product
 func (p *Product) Activate() {
 	p.IsActive = true
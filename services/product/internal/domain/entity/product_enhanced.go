@@ -212,6 +212,24 @@ func (p *ProductEnhanced) ShouldAutoReactivate() bool {
 	return time.Now().After(*p.InactiveUntil)
 }
 
+// vipLevels are the VIP levels from lowest to highest
+var vipLevels = []string{VIPLevelBronze, VIPLevelSilver, VIPLevelGold, VIPLevelPlatinum, VIPLevelDiamond}
+
+// IsValidVIPLevel reports whether the level is a known VIP level
+func IsValidVIPLevel(level string) bool {
+	for _, known := range vipLevels {
+		if level == known {
+			return true
+		}
+	}
+	return false
+}
+
+// VIPLevels lists the VIP levels from lowest to highest
+func VIPLevels() []string {
+	return append([]string(nil), vipLevels...)
+}
+
 // Validation methods
 
 // Validate validates the product data
@@ -335,6 +353,7 @@ const (
 	VIPLevelSilver   = "silver"
 	VIPLevelGold     = "gold"
 	VIPLevelPlatinum = "platinum"
+	VIPLevelDiamond  = "diamond"
 
 	CustomerGroupRetail    = "retail"
 	CustomerGroupWholesale = "wholesale"
@@ -0,0 +1,89 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReservationStatus represents the state of a stock reservation
+type ReservationStatus string
+
+const (
+	ReservationStatusActive   ReservationStatus = "active"
+	ReservationStatusConsumed ReservationStatus = "consumed"
+	ReservationStatusReleased ReservationStatus = "released"
+	ReservationStatusExpired  ReservationStatus = "expired"
+)
+
+var (
+	ErrInsufficientStock    = errors.New("insufficient stock available")
+	ErrReservationNotFound  = errors.New("stock reservation not found")
+	ErrReservationNotActive = errors.New("stock reservation is no longer active")
+	ErrIdempotencyKeyReused = errors.New("idempotency key already used for a different reservation")
+)
+
+// StockReservation holds stock at a location for an order until it is consumed, released or
+// expires. All reservations made for an order share its idempotency key.
+type StockReservation struct {
+	ID             uuid.UUID         `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IdempotencyKey string            `json:"idempotency_key" gorm:"not null"`
+	OrderID        uuid.UUID         `json:"order_id" gorm:"type:uuid;not null"`
+	ProductID      uuid.UUID         `json:"product_id" gorm:"type:uuid;not null"`
+	LocationID     uuid.UUID         `json:"location_id" gorm:"type:uuid;not null"`
+	Quantity       float64           `json:"quantity" gorm:"not null"`
+	Status         ReservationStatus `json:"status" gorm:"not null;default:active"`
+	ReleaseReason  *string           `json:"release_reason,omitempty"`
+	ExpiresAt      time.Time         `json:"expires_at" gorm:"not null"`
+	ConsumedAt     *time.Time        `json:"consumed_at,omitempty"`
+	ReleasedAt     *time.Time        `json:"released_at,omitempty"`
+	CreatedAt      time.Time         `json:"created_at" gorm:"autoCreateTime"`
+	UpdatedAt      time.Time         `json:"updated_at" gorm:"autoUpdateTime"`
+	// LaunchAllocationID is the VIP level allocation of a product launch the reservation counts
+	// against, when it was made during early access
+	LaunchAllocationID *uuid.UUID `json:"launch_allocation_id,omitempty" gorm:"type:uuid"`
+}
+
+// NewStockReservation creates an active reservation that expires at the given time
+func NewStockReservation(idempotencyKey string, orderID, productID uuid.UUID, quantity float64, expiresAt time.Time) *StockReservation {
+	return &StockReservation{
+		ID:             uuid.New(),
+		IdempotencyKey: idempotencyKey,
+		OrderID:        orderID,
+		ProductID:      productID,
+		Quantity:       quantity,
+		Status:         ReservationStatusActive,
+		ExpiresAt:      expiresAt,
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+}
+
+// IsActive checks if the reservation still holds stock at the given time
+func (r *StockReservation) IsActive(now time.Time) bool {
+	return r.Status == ReservationStatusActive && now.Before(r.ExpiresAt)
+}
+
+// Consume marks the reservation as turned into a stock deduction
+func (r *StockReservation) Consume(now time.Time) error {
+	if !r.IsActive(now) {
+		return ErrReservationNotActive
+	}
+	r.Status = ReservationStatusConsumed
+	r.ConsumedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
+
+// Release marks the reservation as released with the given status, released or expired
+func (r *StockReservation) Release(status ReservationStatus, reason string, now time.Time) error {
+	if r.Status != ReservationStatusActive {
+		return ErrReservationNotActive
+	}
+	r.Status = status
+	r.ReleaseReason = &reason
+	r.ReleasedAt = &now
+	r.UpdatedAt = now
+	return nil
+}
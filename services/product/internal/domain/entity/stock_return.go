@@ -0,0 +1,40 @@
+package entity
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ErrReturnExceedsConsumed is returned when more stock is put back than the order consumed
+var ErrReturnExceedsConsumed = errors.New("returned quantity exceeds the stock consumed by the order")
+
+// StockReturn puts stock from a returned order back at the location its reservation was consumed
+// from. All stock returned for one return request shares its idempotency key.
+type StockReturn struct {
+	ID             uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	IdempotencyKey string    `json:"idempotency_key" gorm:"not null"`
+	OrderID        uuid.UUID `json:"order_id" gorm:"type:uuid;not null"`
+	ProductID      uuid.UUID `json:"product_id" gorm:"type:uuid;not null"`
+	LocationID     uuid.UUID `json:"location_id" gorm:"type:uuid;not null"`
+	Quantity       float64   `json:"quantity" gorm:"not null"`
+	Reason         *string   `json:"reason,omitempty"`
+	CreatedAt      time.Time `json:"created_at" gorm:"autoCreateTime"`
+}
+
+// NewStockReturn creates a stock return for a product of an order
+func NewStockReturn(idempotencyKey string, orderID, productID uuid.UUID, quantity float64, reason string) *StockReturn {
+	stockReturn := &StockReturn{
+		ID:             uuid.New(),
+		IdempotencyKey: idempotencyKey,
+		OrderID:        orderID,
+		ProductID:      productID,
+		Quantity:       quantity,
+		CreatedAt:      time.Now(),
+	}
+	if reason != "" {
+		stockReturn.Reason = &reason
+	}
+	return stockReturn
+}
@@ -167,6 +167,111 @@ type InventoryRepository interface {
 	GetTurnoverRate(ctx context.Context, productID uuid.UUID, days int) (float64, error)
 }
 
+// StockReservationRepository defines stock reservation data access operations. Each operation
+// covers every reservation of an order in one transaction.
+type StockReservationRepository interface {
+	// Reserve holds stock for the reservations, choosing a location for those without one, and
+	// claims their launch allocations. When the idempotency key was already used it reserves
+	// nothing and returns the earlier reservations.
+	Reserve(ctx context.Context, idempotencyKey string, reservations []*entity.StockReservation) ([]*entity.StockReservation, error)
+	GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*entity.StockReservation, error)
+	// Consume deducts the reserved stock of an order
+	Consume(ctx context.Context, orderID uuid.UUID, now time.Time) ([]*entity.StockReservation, error)
+	// Release returns the active reservations of an order to available stock and their launch
+	// allocations. A non-empty idempotency key limits it to the reservations made under that key.
+	Release(ctx context.Context, orderID uuid.UUID, idempotencyKey string, status entity.ReservationStatus, reason string, now time.Time) ([]*entity.StockReservation, error)
+	// GetExpiredOrderIDs lists orders with active reservations past their expiry
+	GetExpiredOrderIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	// Restock puts returned stock of an order back where it was consumed. When the idempotency
+	// key was already used it restocks nothing and returns the earlier stock returns.
+	Restock(ctx context.Context, orderID uuid.UUID, idempotencyKey string, returns []*entity.StockReturn) ([]*entity.StockReturn, error)
+}
+
+// PriceHistoryRepository keeps the history of base price and price row changes and the base
+// price changes scheduled for later
+type PriceHistoryRepository interface {
+	// UpdateProductPrice saves the product and records the change of its base price in one
+	// transaction. The old price of the entry is read from the stored product; when the price
+	// did not change the product is saved without an entry.
+	UpdateProductPrice(ctx context.Context, product *entity.Product, entry *entity.PriceHistoryEntry) error
+	// GetHistory lists the price changes of a product, newest first
+	GetHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.PriceHistoryEntry, error)
+	// GetChangesAround returns the last price change in effect at the time and the first one
+	// after it, either of which may be nil
+	GetChangesAround(ctx context.Context, productID uuid.UUID, at time.Time) (before, after *entity.PriceHistoryEntry, err error)
+
+	// SavePriceRow creates, updates or deletes a VIP, bulk or promotional price row as the change
+	// says and records the change in one transaction. The old row of the change is read from the
+	// stored row.
+	SavePriceRow(ctx context.Context, price *entity.Price, change *entity.PriceRowChange) error
+	// GetPriceRowHistory lists the changes of a product's price rows, newest first
+	GetPriceRowHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.PriceRowChange, error)
+
+	CreateScheduledChange(ctx context.Context, change *entity.ScheduledPriceChange) error
+	GetScheduledChange(ctx context.Context, id uuid.UUID) (*entity.ScheduledPriceChange, error)
+	// ListScheduledChanges lists the scheduled changes of a product by the time they take effect
+	ListScheduledChanges(ctx context.Context, productID uuid.UUID) ([]*entity.ScheduledPriceChange, error)
+	// CancelScheduledChange cancels a pending change
+	CancelScheduledChange(ctx context.Context, id uuid.UUID, cancelledBy string, now time.Time) (*entity.ScheduledPriceChange, error)
+	// GetDueScheduledChangeIDs lists pending changes due at the time, earliest first
+	GetDueScheduledChangeIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	// ApplyScheduledChange sets the base price of a due pending change and records it. It returns
+	// nil when the change was applied or cancelled elsewhere first.
+	ApplyScheduledChange(ctx context.Context, id uuid.UUID, now time.Time) (*entity.PriceHistoryEntry, error)
+}
+
+// MarginRepository keeps the margin policies of categories and loads what margin checks need
+type MarginRepository interface {
+	// GetPolicy returns the margin policy of a category, or nil when it has none
+	GetPolicy(ctx context.Context, categoryID uuid.UUID) (*entity.CategoryMarginPolicy, error)
+	ListPolicies(ctx context.Context) ([]*entity.CategoryMarginPolicy, error)
+	// SavePolicy creates or replaces the margin policy of a category
+	SavePolicy(ctx context.Context, policy *entity.CategoryMarginPolicy) error
+	DeletePolicy(ctx context.Context, categoryID uuid.UUID) error
+
+	// ListCostedProducts lists the active products whose cost is known
+	ListCostedProducts(ctx context.Context) ([]*entity.Product, error)
+	// GetActivePromotionalPrices lists the promotional prices of the products valid at the time
+	GetActivePromotionalPrices(ctx context.Context, productIDs []uuid.UUID, at time.Time) ([]*entity.Price, error)
+}
+
+// AvailabilityRepository saves the availability of products with their availability log
+type AvailabilityRepository interface {
+	AvailabilityLogRepository
+
+	// SaveAvailability saves the availability of the product and records the change in one
+	// transaction. Other product fields are left as stored.
+	SaveAvailability(ctx context.Context, product *entity.Product, log *entity.ProductAvailabilityLog) error
+	// GetAvailabilityRuleProductIDs lists the products with a schedule or due to go back on sale
+	GetAvailabilityRuleProductIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error)
+	// ApplyAvailabilityRules applies the rules of a product and records the change. It returns
+	// nil when nothing changed or the product is being changed elsewhere.
+	ApplyAvailabilityRules(ctx context.Context, productID uuid.UUID, now time.Time) (*entity.Product, *entity.ProductAvailabilityLog, error)
+}
+
+// LaunchRepository keeps product launches with their VIP level allocations
+type LaunchRepository interface {
+	// CreateLaunch saves a launch with its allocations. It fails with ErrLaunchExists when the
+	// product already has a launch that is not released.
+	CreateLaunch(ctx context.Context, launch *entity.ProductLaunch) error
+	GetLaunch(ctx context.Context, id uuid.UUID) (*entity.ProductLaunch, error)
+	// ListLaunches lists the launches of a product, latest first
+	ListLaunches(ctx context.Context, productID uuid.UUID) ([]*entity.ProductLaunch, error)
+	// GetOpenLaunches returns the launches of the products in early access at the time, by product
+	GetOpenLaunches(ctx context.Context, productIDs []uuid.UUID, now time.Time) (map[uuid.UUID]*entity.ProductLaunch, error)
+	// GetDueLaunchIDs lists scheduled launches due to open early access and launches in early
+	// access due to go public, earliest first
+	GetDueLaunchIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error)
+	// AdvanceLaunch opens early access of a due scheduled launch or releases a due launch in
+	// early access, saving its product in the same transaction. It returns nil when the launch
+	// is not due or is being changed elsewhere.
+	AdvanceLaunch(ctx context.Context, id uuid.UUID, now time.Time) (*entity.ProductLaunch, *entity.Product, error)
+	// CancelLaunch cancels a launch that is not released and ends early access of its product
+	CancelLaunch(ctx context.Context, id uuid.UUID, cancelledBy string, now time.Time) (*entity.ProductLaunch, *entity.Product, error)
+	// SetNotifiedCount records how many LINE users were told early access opened
+	SetNotifiedCount(ctx context.Context, id uuid.UUID, count int) error
+}
+
 // CacheRepository defines caching operations
 type CacheRepository interface {
 	// Basic cache operations
@@ -221,6 +326,12 @@ type ProductFilter struct {
 	Offset     int
 	OrderBy    string
 	OrderDir   string
+
+	// IsAvailable filters on being on sale: active and not taken off sale by an admin or schedule
+	IsAvailable *bool
+	// VIPLevel limits the products to those a customer of the level can buy; an empty level is
+	// a customer who is not a VIP member
+	VIPLevel *string
 }
 
 type CategoryFilter struct {
@@ -76,8 +76,11 @@ type PricingRepository interface {
 	DeleteVIPBenefits(ctx context.Context, benefitsID uuid.UUID) error
 	ListVIPBenefits(ctx context.Context) ([]*entity.VIPPricingBenefits, error)
 
-	// Price calculation
-	CalculatePrice(ctx context.Context, request PricingRequest) (*PricingResult, error)
+	// Cart pricing loads the rules of every product in a cart at once
+	GetActivePricingTiers(ctx context.Context, productIDs []uuid.UUID) ([]*entity.ProductPricingTier, error)
+	GetActiveCustomerGroupPricing(ctx context.Context, productIDs []uuid.UUID, customerGroup string) ([]*entity.CustomerGroupPricing, error)
+
+	// Tier checks
 	GetOptimalPricingTier(ctx context.Context, productID uuid.UUID, quantity int) (*entity.ProductPricingTier, error)
 	ValidatePricingTiers(ctx context.Context, productID uuid.UUID) error
 }
@@ -113,43 +116,6 @@ type ProductFilters struct {
 	SortOrder        string // "ASC" or "DESC"
 }
 
-// PricingRequest represents a pricing calculation request
-type PricingRequest struct {
-	ProductID     uuid.UUID
-	Quantity      int
-	CustomerGroup *string
-	VIPLevel      *string
-	LocationID    *uuid.UUID
-	CalculateAt   *time.Time
-}
-
-// PricingResult represents the result of price calculation
-type PricingResult struct {
-	ProductID      uuid.UUID        `json:"product_id"`
-	BasePrice      decimal.Decimal  `json:"base_price"`
-	TierPrice      decimal.Decimal  `json:"tier_price"`
-	GroupDiscount  decimal.Decimal  `json:"group_discount"`
-	VIPDiscount    decimal.Decimal  `json:"vip_discount"`
-	FinalPrice     decimal.Decimal  `json:"final_price"`
-	TotalPrice     decimal.Decimal  `json:"total_price"`
-	Savings        decimal.Decimal  `json:"savings"`
-	AppliedTier    *entity.ProductPricingTier `json:"applied_tier,omitempty"`
-	AppliedBenefits *entity.VIPPricingBenefits `json:"applied_benefits,omitempty"`
-	Breakdown      PriceBreakdown   `json:"breakdown"`
-}
-
-// PriceBreakdown provides detailed price calculation breakdown
-type PriceBreakdown struct {
-	OriginalPrice     decimal.Decimal `json:"original_price"`
-	TierDiscountApplied bool           `json:"tier_discount_applied"`
-	TierDiscountAmount  decimal.Decimal `json:"tier_discount_amount"`
-	GroupDiscountApplied bool          `json:"group_discount_applied"`
-	GroupDiscountAmount  decimal.Decimal `json:"group_discount_amount"`
-	VIPDiscountApplied   bool           `json:"vip_discount_applied"`
-	VIPDiscountAmount    decimal.Decimal `json:"vip_discount_amount"`
-	VIPQuantityMultiplier decimal.Decimal `json:"vip_quantity_multiplier"`
-}
-
 // ProductUpdate represents a product update operation
 type ProductUpdate struct {
 	ProductID uuid.UUID
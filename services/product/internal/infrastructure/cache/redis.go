@@ -389,6 +389,23 @@ func (r *RedisCache) InvalidateProduct(ctx context.Context, productID uuid.UUID)
 	return nil
 }
 
+// InvalidatePrice removes the price calculations cached by SetPrice for a product, and the hot
+// product that carries its base price
+func (r *RedisCache) InvalidatePrice(ctx context.Context, productID uuid.UUID) error {
+	patterns := []string{
+		fmt.Sprintf(PricingCalculationKey, "*", productID.String()),
+		fmt.Sprintf(ProductKey, productID.String()),
+	}
+
+	for _, pattern := range patterns {
+		if err := r.DeletePattern(ctx, pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (r *RedisCache) InvalidateProductList(ctx context.Context) error {
 	patterns := []string{
 		fmt.Sprintf(ProductListKey, "*"),
@@ -11,15 +11,19 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Environment string
-	Port        string
-	Database    DatabaseConfig
-	Redis       RedisConfig
-	Kafka       KafkaConfig
-	Cache       CacheConfig
-	External    ExternalConfig
-	Security    SecurityConfig
-	Logging     LoggingConfig
+	Environment  string
+	Port         string
+	Database     DatabaseConfig
+	Redis        RedisConfig
+	Kafka        KafkaConfig
+	Cache        CacheConfig
+	Reservation  ReservationConfig
+	Pricing      PricingConfig
+	Availability AvailabilityConfig
+	Launch       LaunchConfig
+	External     ExternalConfig
+	Security     SecurityConfig
+	Logging      LoggingConfig
 }
 
 // DatabaseConfig holds database configuration
@@ -75,6 +79,32 @@ type CacheConfig struct {
 	StatsTTL     int // seconds
 }
 
+// ReservationConfig holds stock reservation configuration
+type ReservationConfig struct {
+	DefaultTTL    int // seconds
+	MaxTTL        int // seconds
+	SweepInterval int // seconds
+}
+
+// PricingConfig holds the cart pricing policy, the scheduled price change poll and the margin
+// guardrails
+type PricingConfig struct {
+	Precedence           []string // rules in the order they apply: tier, customer_group, vip
+	Stacking             string   // "stack" applies every rule, "best" only the one with the lowest price
+	SchedulePollInterval int      // seconds
+	DefaultMinMargin     float64  // percent, for categories without a margin policy
+}
+
+// AvailabilityConfig holds the product availability scheduler configuration
+type AvailabilityConfig struct {
+	PollInterval int // seconds
+}
+
+// LaunchConfig holds the product launch scheduler configuration
+type LaunchConfig struct {
+	PollInterval int // seconds
+}
+
 // ExternalConfig holds external service configuration
 type ExternalConfig struct {
 	LoyverseService     string
@@ -94,6 +124,14 @@ type SecurityConfig struct {
 	AllowedOrigins []string
 	EnableCORS     bool
 	TrustedProxies []string
+
+	// Staff tokens are verified with the user service's JWKS when JWKSURL is set. Without it no
+	// caller is identified, so nothing that needs a permission, such as approving a price below
+	// the minimum margin, can be done.
+	JWKSURL             string
+	JWTIssuer           string
+	JWTAudience         string
+	JWKSRefreshInterval int // seconds
 }
 
 // LoggingConfig holds logging configuration
@@ -161,6 +199,31 @@ func Load() (*Config, error) {
 			StatsTTL:     getEnvInt("CACHE_STATS_TTL", 900),     // 15 minutes
 		},
 
+		Reservation: ReservationConfig{
+			DefaultTTL:    getEnvInt("RESERVATION_DEFAULT_TTL", 900),   // 15 minutes
+			MaxTTL:        getEnvInt("RESERVATION_MAX_TTL", 86400),     // 24 hours
+			SweepInterval: getEnvInt("RESERVATION_SWEEP_INTERVAL", 60), // 1 minute
+		},
+
+		Pricing: PricingConfig{
+			Precedence: strings.Split(getEnv("PRICING_PRECEDENCE", "tier,customer_group,vip"), ","),
+			Stacking:   getEnv("PRICING_STACKING", "stack"),
+			// Scheduled price changes take effect at most this late
+			SchedulePollInterval: getEnvInt("PRICE_SCHEDULE_POLL_INTERVAL", 60), // 1 minute
+			// Only below-cost prices need approval unless a category sets a minimum
+			DefaultMinMargin: getEnvFloat("MARGIN_DEFAULT_MIN_PERCENT", 0),
+		},
+
+		Availability: AvailabilityConfig{
+			// Schedules take products on and off sale at most this late
+			PollInterval: getEnvInt("AVAILABILITY_POLL_INTERVAL", 60), // 1 minute
+		},
+
+		Launch: LaunchConfig{
+			// Early access opens and launches go public at most this late
+			PollInterval: getEnvInt("LAUNCH_POLL_INTERVAL", 60), // 1 minute
+		},
+
 		External: ExternalConfig{
 			LoyverseService:     getEnv("LOYVERSE_SERVICE_URL", "http://loyverse:8100"),
 			LoyverseAPIKey:      getEnv("LOYVERSE_API_KEY", ""),
@@ -178,6 +241,11 @@ func Load() (*Config, error) {
 			AllowedOrigins: strings.Split(getEnv("ALLOWED_ORIGINS", "*"), ","),
 			EnableCORS:     getEnvBool("ENABLE_CORS", true),
 			TrustedProxies: strings.Split(getEnv("TRUSTED_PROXIES", ""), ","),
+
+			JWKSURL:             getEnv("JWT_JWKS_URL", ""),
+			JWTIssuer:           getEnv("JWT_ISSUER", ""),
+			JWTAudience:         getEnv("JWT_AUDIENCE", ""),
+			JWKSRefreshInterval: getEnvInt("JWT_JWKS_REFRESH_INTERVAL", 3600), // 1 hour
 		},
 
 		Logging: LoggingConfig{
@@ -207,6 +275,12 @@ func validateConfig(config *Config) error {
 		"Internal API Key":  config.Security.InternalAPIKey,
 	}
 
+	// Tokens are only trusted when their issuer and audience are checked
+	if config.Security.JWKSURL != "" {
+		required["JWT Issuer"] = config.Security.JWTIssuer
+		required["JWT Audience"] = config.Security.JWTAudience
+	}
+
 	for field, value := range required {
 		if value == "" {
 			return fmt.Errorf("%s is required", field)
@@ -261,3 +335,12 @@ func getEnvBool(key string, defaultValue bool) bool {
 	}
 	return defaultValue
 }
+
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
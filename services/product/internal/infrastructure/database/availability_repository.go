@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// availabilityRepository implements the AvailabilityRepository interface
+type availabilityRepository struct {
+	db *gorm.DB
+}
+
+// NewAvailabilityRepository creates a new availability repository
+func NewAvailabilityRepository(db *gorm.DB) repository.AvailabilityRepository {
+	return &availabilityRepository{db: db}
+}
+
+// SaveAvailability saves the availability of the product and records the change
+func (r *availabilityRepository) SaveAvailability(ctx context.Context, product *entity.Product, log *entity.ProductAvailabilityLog) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&entity.Product{}).Where("id = ?", product.ID).Updates(availabilityColumns(product))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return entity.ErrProductNotFound
+		}
+		if log == nil {
+			return nil
+		}
+		return tx.Create(log).Error
+	})
+}
+
+// GetAvailabilityRuleProductIDs lists the products with a schedule or due to go back on sale
+func (r *availabilityRepository) GetAvailabilityRuleProductIDs(ctx context.Context, now time.Time) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&entity.Product{}).
+		Where("inactive_schedule IS NOT NULL OR (auto_reactivate AND NOT is_admin_active AND inactive_until <= ?)", now).
+		Order("id").
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ApplyAvailabilityRules applies the rules of a product and records the change
+func (r *availabilityRepository) ApplyAvailabilityRules(ctx context.Context, productID uuid.UUID, now time.Time) (*entity.Product, *entity.ProductAvailabilityLog, error) {
+	var product *entity.Product
+	var log *entity.ProductAvailabilityLog
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Skip products another instance or an admin is changing
+		var products []*entity.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ?", productID).
+			Find(&products).Error
+		if err != nil || len(products) == 0 {
+			return err
+		}
+
+		changed := products[0].ApplyAvailabilityRules(now)
+		if changed == nil {
+			return nil
+		}
+		err = tx.Model(&entity.Product{}).Where("id = ?", productID).Updates(availabilityColumns(products[0])).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(changed).Error; err != nil {
+			return err
+		}
+
+		product, log = products[0], changed
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return product, log, nil
+}
+
+// LogAvailabilityChange records a change of a product's availability
+func (r *availabilityRepository) LogAvailabilityChange(ctx context.Context, log *entity.ProductAvailabilityLog) error {
+	return r.db.WithContext(ctx).Create(log).Error
+}
+
+// GetAvailabilityHistory lists the availability changes of a product, newest first
+func (r *availabilityRepository) GetAvailabilityHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.ProductAvailabilityLog, error) {
+	var logs []*entity.ProductAvailabilityLog
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&logs).Error
+	return logs, err
+}
+
+// GetAvailabilityChanges lists the availability changes made in the period, oldest first
+func (r *availabilityRepository) GetAvailabilityChanges(ctx context.Context, dateFrom, dateTo time.Time) ([]*entity.ProductAvailabilityLog, error) {
+	var logs []*entity.ProductAvailabilityLog
+	err := r.db.WithContext(ctx).
+		Where("created_at >= ? AND created_at < ?", dateFrom, dateTo).
+		Order("created_at").
+		Find(&logs).Error
+	return logs, err
+}
+
+// GetChangesByUser lists the availability changes a user made in the period, oldest first
+func (r *availabilityRepository) GetChangesByUser(ctx context.Context, userID uuid.UUID, dateFrom, dateTo time.Time) ([]*entity.ProductAvailabilityLog, error) {
+	var logs []*entity.ProductAvailabilityLog
+	err := r.db.WithContext(ctx).
+		Where("changed_by = ? AND created_at >= ? AND created_at < ?", userID, dateFrom, dateTo).
+		Order("created_at").
+		Find(&logs).Error
+	return logs, err
+}
+
+// availabilityColumns are the availability columns of a product. A map is used so that
+// clearing a field is saved too.
+func availabilityColumns(product *entity.Product) map[string]interface{} {
+	return map[string]interface{}{
+		"is_admin_active":      product.IsAdminActive,
+		"inactive_reason":      product.InactiveReason,
+		"inactive_until":       product.InactiveUntil,
+		"auto_reactivate":      product.AutoReactivate,
+		"inactive_schedule":    product.InactiveSchedule,
+		"inactive_by_schedule": product.InactiveBySchedule,
+		"updated_at":           product.UpdatedAt,
+	}
+}
@@ -0,0 +1,239 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// launchRepository implements the LaunchRepository interface
+type launchRepository struct {
+	db *gorm.DB
+}
+
+// NewLaunchRepository creates a new launch repository
+func NewLaunchRepository(db *gorm.DB) repository.LaunchRepository {
+	return &launchRepository{db: db}
+}
+
+// pendingLaunchStatuses are the statuses of launches that are not released or cancelled
+var pendingLaunchStatuses = []entity.LaunchStatus{entity.LaunchStatusScheduled, entity.LaunchStatusEarlyAccess}
+
+// CreateLaunch saves a launch with its allocations
+func (r *launchRepository) CreateLaunch(ctx context.Context, launch *entity.ProductLaunch) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the product so two launches of it cannot both be created
+		var product entity.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", launch.ProductID).
+			First(&product).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return entity.ErrProductNotFound
+			}
+			return err
+		}
+
+		var pending int64
+		err = tx.Model(&entity.ProductLaunch{}).
+			Where("product_id = ? AND status IN ?", launch.ProductID, pendingLaunchStatuses).
+			Count(&pending).Error
+		if err != nil {
+			return err
+		}
+		if pending > 0 {
+			return entity.ErrLaunchExists
+		}
+
+		// Allocations are saved with the launch
+		return tx.Create(launch).Error
+	})
+}
+
+// GetLaunch retrieves a launch with its allocations
+func (r *launchRepository) GetLaunch(ctx context.Context, id uuid.UUID) (*entity.ProductLaunch, error) {
+	var launch entity.ProductLaunch
+	err := r.db.WithContext(ctx).Preload("Allocations", orderAllocations).Where("id = ?", id).First(&launch).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrLaunchNotFound
+		}
+		return nil, err
+	}
+	return &launch, nil
+}
+
+// ListLaunches lists the launches of a product, latest first
+func (r *launchRepository) ListLaunches(ctx context.Context, productID uuid.UUID) ([]*entity.ProductLaunch, error) {
+	var launches []*entity.ProductLaunch
+	err := r.db.WithContext(ctx).
+		Preload("Allocations", orderAllocations).
+		Where("product_id = ?", productID).
+		Order("early_access_at DESC, created_at DESC").
+		Find(&launches).Error
+	return launches, err
+}
+
+// GetOpenLaunches returns the launches of the products in early access at the time
+func (r *launchRepository) GetOpenLaunches(ctx context.Context, productIDs []uuid.UUID, now time.Time) (map[uuid.UUID]*entity.ProductLaunch, error) {
+	var launches []*entity.ProductLaunch
+	err := r.db.WithContext(ctx).
+		Preload("Allocations").
+		Where("product_id IN ? AND status = ? AND public_at > ?", productIDs, entity.LaunchStatusEarlyAccess, now).
+		Find(&launches).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byProduct := make(map[uuid.UUID]*entity.ProductLaunch, len(launches))
+	for _, launch := range launches {
+		byProduct[launch.ProductID] = launch
+	}
+	return byProduct, nil
+}
+
+// GetDueLaunchIDs lists launches due to open early access or go public, earliest first
+func (r *launchRepository) GetDueLaunchIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&entity.ProductLaunch{}).
+		Where("(status = ? AND early_access_at <= ?) OR (status = ? AND public_at <= ?)",
+			entity.LaunchStatusScheduled, now, entity.LaunchStatusEarlyAccess, now).
+		Order("LEAST(early_access_at, public_at), created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// AdvanceLaunch opens early access of a due scheduled launch or releases a due launch in early
+// access
+func (r *launchRepository) AdvanceLaunch(ctx context.Context, id uuid.UUID, now time.Time) (*entity.ProductLaunch, *entity.Product, error) {
+	var launch *entity.ProductLaunch
+	var product *entity.Product
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Skip launches another instance is advancing or an admin is cancelling
+		var launches []*entity.ProductLaunch
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND status IN ?", id, pendingLaunchStatuses).
+			Find(&launches).Error
+		if err != nil || len(launches) == 0 {
+			return err
+		}
+		due := launches[0]
+
+		stored, err := lockLaunchProduct(tx, due.ProductID)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case due.Status == entity.LaunchStatusScheduled && !now.Before(due.EarlyAccessAt):
+			err = due.OpenEarlyAccess(stored, now)
+		case due.Status == entity.LaunchStatusEarlyAccess && !now.Before(due.PublicAt):
+			err = due.Release(stored, now)
+		default:
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := saveLaunch(tx, due, stored); err != nil {
+			return err
+		}
+
+		launch, product = due, stored
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	if launch != nil {
+		err = r.db.WithContext(ctx).Where("launch_id = ?", launch.ID).Scopes(orderAllocations).Find(&launch.Allocations).Error
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return launch, product, nil
+}
+
+// CancelLaunch cancels a launch that is not released and ends early access of its product
+func (r *launchRepository) CancelLaunch(ctx context.Context, id uuid.UUID, cancelledBy string, now time.Time) (*entity.ProductLaunch, *entity.Product, error) {
+	var launch entity.ProductLaunch
+	var product *entity.Product
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&launch).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return entity.ErrLaunchNotFound
+			}
+			return err
+		}
+
+		product, err = lockLaunchProduct(tx, launch.ProductID)
+		if err != nil {
+			return err
+		}
+		if err := launch.Cancel(product, cancelledBy, now); err != nil {
+			return err
+		}
+		return saveLaunch(tx, &launch, product)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &launch, product, nil
+}
+
+// SetNotifiedCount records how many LINE users were told early access opened
+func (r *launchRepository) SetNotifiedCount(ctx context.Context, id uuid.UUID, count int) error {
+	return r.db.WithContext(ctx).Model(&entity.ProductLaunch{}).
+		Where("id = ?", id).
+		Update("notified_count", count).Error
+}
+
+// lockLaunchProduct locks the product a launch opens
+func lockLaunchProduct(tx *gorm.DB, productID uuid.UUID) (*entity.Product, error) {
+	var product entity.Product
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", productID).First(&product).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrProductNotFound
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// saveLaunch saves the status of a launch and the VIP gating it set on its product. A map is
+// used so that clearing a field is saved too.
+func saveLaunch(tx *gorm.DB, launch *entity.ProductLaunch, product *entity.Product) error {
+	err := tx.Model(&entity.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+		"min_vip_level":      product.MinVIPLevel,
+		"vip_early_access":   product.VIPEarlyAccess,
+		"early_access_until": product.EarlyAccessUntil,
+		"updated_at":         product.UpdatedAt,
+	}).Error
+	if err != nil {
+		return err
+	}
+	return tx.Model(&entity.ProductLaunch{}).Where("id = ?", launch.ID).Updates(map[string]interface{}{
+		"status":       launch.Status,
+		"opened_at":    launch.OpenedAt,
+		"released_at":  launch.ReleasedAt,
+		"cancelled_at": launch.CancelledAt,
+		"cancelled_by": launch.CancelledBy,
+		"updated_at":   launch.UpdatedAt,
+	}).Error
+}
+
+// orderAllocations lists allocations from the lowest VIP level to the highest
+func orderAllocations(db *gorm.DB) *gorm.DB {
+	return db.Order("array_position(ARRAY['bronze', 'silver', 'gold', 'platinum', 'diamond'], vip_level::text)")
+}
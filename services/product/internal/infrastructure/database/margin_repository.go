@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// marginRepository implements the MarginRepository interface
+type marginRepository struct {
+	db *gorm.DB
+}
+
+// NewMarginRepository creates a new margin repository
+func NewMarginRepository(db *gorm.DB) repository.MarginRepository {
+	return &marginRepository{db: db}
+}
+
+// GetPolicy returns the margin policy of a category, or nil when it has none
+func (r *marginRepository) GetPolicy(ctx context.Context, categoryID uuid.UUID) (*entity.CategoryMarginPolicy, error) {
+	var policy entity.CategoryMarginPolicy
+	err := r.db.WithContext(ctx).Where("category_id = ?", categoryID).First(&policy).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListPolicies lists the margin policies of all categories
+func (r *marginRepository) ListPolicies(ctx context.Context) ([]*entity.CategoryMarginPolicy, error) {
+	var policies []*entity.CategoryMarginPolicy
+	err := r.db.WithContext(ctx).Order("category_id").Find(&policies).Error
+	return policies, err
+}
+
+// SavePolicy creates or replaces the margin policy of a category
+func (r *marginRepository) SavePolicy(ctx context.Context, policy *entity.CategoryMarginPolicy) error {
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "category_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"min_margin_percent", "updated_by", "updated_at"}),
+		}).
+		Create(policy).Error
+}
+
+// DeletePolicy deletes the margin policy of a category
+func (r *marginRepository) DeletePolicy(ctx context.Context, categoryID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&entity.CategoryMarginPolicy{}, "category_id = ?", categoryID).Error
+}
+
+// ListCostedProducts lists the active products whose cost is known
+func (r *marginRepository) ListCostedProducts(ctx context.Context) ([]*entity.Product, error) {
+	var products []*entity.Product
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND cost_price IS NOT NULL", true).
+		Order("sku").
+		Find(&products).Error
+	return products, err
+}
+
+// GetActivePromotionalPrices lists the promotional prices of the products valid at the time
+func (r *marginRepository) GetActivePromotionalPrices(ctx context.Context, productIDs []uuid.UUID, at time.Time) ([]*entity.Price, error) {
+	var prices []*entity.Price
+	err := r.db.WithContext(ctx).
+		// The location and customer group arrays are not needed here
+		Select("id", "product_id", "price_type", "price", "currency", "valid_from", "valid_to", "promotion_name", "is_active", "priority").
+		Where("product_id IN ? AND price_type = ? AND is_active = ?", productIDs, "promotional", true).
+		Where("(valid_from IS NULL OR valid_from <= ?) AND (valid_to IS NULL OR valid_to >= ?)", at, at).
+		Order("product_id, price").
+		Find(&prices).Error
+	return prices, err
+}
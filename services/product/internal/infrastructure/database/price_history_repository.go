@@ -0,0 +1,262 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// priceHistoryRepository implements the PriceHistoryRepository interface
+type priceHistoryRepository struct {
+	db *gorm.DB
+}
+
+// NewPriceHistoryRepository creates a new price history repository
+func NewPriceHistoryRepository(db *gorm.DB) repository.PriceHistoryRepository {
+	return &priceHistoryRepository{db: db}
+}
+
+// UpdateProductPrice saves the product and records the change of its base price
+func (r *priceHistoryRepository) UpdateProductPrice(ctx context.Context, product *entity.Product, entry *entity.PriceHistoryEntry) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the product so concurrent changes are recorded in the order they are made
+		var stored entity.Product
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "base_price").
+			Where("id = ?", product.ID).
+			First(&stored).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return entity.ErrProductNotFound
+			}
+			return err
+		}
+
+		if err := tx.Save(product).Error; err != nil {
+			return err
+		}
+		if stored.BasePrice == entry.NewPrice {
+			return nil
+		}
+		entry.OldPrice = stored.BasePrice
+		return tx.Create(entry).Error
+	})
+}
+
+// GetHistory lists the price changes of a product, newest first
+func (r *priceHistoryRepository) GetHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.PriceHistoryEntry, error) {
+	var entries []*entity.PriceHistoryEntry
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("effective_from DESC, created_at DESC").
+		Limit(limit).
+		Find(&entries).Error
+	return entries, err
+}
+
+// GetChangesAround returns the last price change in effect at the time and the first one after it
+func (r *priceHistoryRepository) GetChangesAround(ctx context.Context, productID uuid.UUID, at time.Time) (*entity.PriceHistoryEntry, *entity.PriceHistoryEntry, error) {
+	var before []*entity.PriceHistoryEntry
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND effective_from <= ?", productID, at).
+		Order("effective_from DESC, created_at DESC").
+		Limit(1).
+		Find(&before).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var after []*entity.PriceHistoryEntry
+	err = r.db.WithContext(ctx).
+		Where("product_id = ? AND effective_from > ?", productID, at).
+		Order("effective_from, created_at").
+		Limit(1).
+		Find(&after).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return firstPriceChange(before), firstPriceChange(after), nil
+}
+
+// SavePriceRow saves or deletes a price row and records the change
+func (r *priceHistoryRepository) SavePriceRow(ctx context.Context, price *entity.Price, change *entity.PriceRowChange) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if change.Action != entity.PriceRowCreated {
+			// Lock the row so concurrent changes are recorded in the order they are made
+			var stored entity.Price
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Omit("Product").
+				Where("id = ?", price.ID).
+				First(&stored).Error
+			if err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return entity.ErrPriceNotFound
+				}
+				return err
+			}
+			change.OldRow = entity.SnapshotPrice(&stored)
+		}
+
+		var err error
+		switch change.Action {
+		case entity.PriceRowCreated:
+			err = tx.Omit("Product").Create(price).Error
+		case entity.PriceRowUpdated:
+			err = tx.Omit("Product").Save(price).Error
+		case entity.PriceRowDeleted:
+			err = tx.Where("id = ?", price.ID).Delete(&entity.Price{}).Error
+		}
+		if err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+// GetPriceRowHistory lists the changes of a product's price rows, newest first
+func (r *priceHistoryRepository) GetPriceRowHistory(ctx context.Context, productID uuid.UUID, limit int) ([]*entity.PriceRowChange, error) {
+	var changes []*entity.PriceRowChange
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&changes).Error
+	return changes, err
+}
+
+// CreateScheduledChange creates a pending price change
+func (r *priceHistoryRepository) CreateScheduledChange(ctx context.Context, change *entity.ScheduledPriceChange) error {
+	return r.db.WithContext(ctx).Create(change).Error
+}
+
+// GetScheduledChange retrieves a scheduled price change
+func (r *priceHistoryRepository) GetScheduledChange(ctx context.Context, id uuid.UUID) (*entity.ScheduledPriceChange, error) {
+	var change entity.ScheduledPriceChange
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&change).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, entity.ErrScheduledPriceChangeNotFound
+		}
+		return nil, err
+	}
+	return &change, nil
+}
+
+// ListScheduledChanges lists the scheduled changes of a product by the time they take effect
+func (r *priceHistoryRepository) ListScheduledChanges(ctx context.Context, productID uuid.UUID) ([]*entity.ScheduledPriceChange, error) {
+	var changes []*entity.ScheduledPriceChange
+	err := r.db.WithContext(ctx).
+		Where("product_id = ?", productID).
+		Order("effective_at, created_at").
+		Find(&changes).Error
+	return changes, err
+}
+
+// CancelScheduledChange cancels a pending change
+func (r *priceHistoryRepository) CancelScheduledChange(ctx context.Context, id uuid.UUID, cancelledBy string, now time.Time) (*entity.ScheduledPriceChange, error) {
+	var change entity.ScheduledPriceChange
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&change).Error
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return entity.ErrScheduledPriceChangeNotFound
+			}
+			return err
+		}
+		if change.Status != entity.ScheduledPriceChangePending {
+			return entity.ErrScheduledPriceChangeNotPending
+		}
+
+		change.Status = entity.ScheduledPriceChangeCancelled
+		change.CancelledAt = &now
+		change.CancelledBy = &cancelledBy
+		change.UpdatedAt = now
+		return tx.Save(&change).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// GetDueScheduledChangeIDs lists pending changes due at the time, earliest first
+func (r *priceHistoryRepository) GetDueScheduledChangeIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&entity.ScheduledPriceChange{}).
+		Where("status = ? AND effective_at <= ?", entity.ScheduledPriceChangePending, now).
+		Order("effective_at, created_at").
+		Limit(limit).
+		Pluck("id", &ids).Error
+	return ids, err
+}
+
+// ApplyScheduledChange sets the base price of a due pending change and records it
+func (r *priceHistoryRepository) ApplyScheduledChange(ctx context.Context, id uuid.UUID, now time.Time) (*entity.PriceHistoryEntry, error) {
+	var entry *entity.PriceHistoryEntry
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Skip changes another instance is applying or cancelling
+		var changes []*entity.ScheduledPriceChange
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("id = ? AND status = ? AND effective_at <= ?", id, entity.ScheduledPriceChangePending, now).
+			Find(&changes).Error
+		if err != nil || len(changes) == 0 {
+			return err
+		}
+		change := changes[0]
+
+		var product entity.Product
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id", "base_price").
+			Where("id = ?", change.ProductID).
+			First(&product).Error
+		if err != nil {
+			return err
+		}
+
+		applied, err := entity.NewPriceHistoryEntry(change.ProductID, change.NewPrice, entity.PriceChangeSourceScheduled, change.RequestedBy, change.Reason, now)
+		if err != nil {
+			return err
+		}
+		applied.OldPrice = product.BasePrice
+		applied.ApprovedBy = change.ApprovedBy
+		applied.ScheduledChangeID = &change.ID
+
+		err = tx.Model(&entity.Product{}).Where("id = ?", product.ID).Update("base_price", change.NewPrice).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(applied).Error; err != nil {
+			return err
+		}
+
+		change.Status = entity.ScheduledPriceChangeApplied
+		change.AppliedAt = &now
+		change.UpdatedAt = now
+		if err := tx.Save(change).Error; err != nil {
+			return err
+		}
+
+		entry = applied
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// firstPriceChange returns the first entry or nil
+func firstPriceChange(entries []*entity.PriceHistoryEntry) *entity.PriceHistoryEntry {
+	if len(entries) == 0 {
+		return nil
+	}
+	return entries[0]
+}
@@ -1,4 +1,156 @@
 package database
 
-// This file is a placeholder for pricing repository implementation
-// To be implemented later
+import (
+	"context"
+	"errors"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// pricingRepository implements the PricingRepository interface
+type pricingRepository struct {
+	db *gorm.DB
+}
+
+// NewPricingRepository creates a new pricing repository
+func NewPricingRepository(db *gorm.DB) repository.PricingRepository {
+	return &pricingRepository{db: db}
+}
+
+// CreatePricingTier creates a quantity tier
+func (r *pricingRepository) CreatePricingTier(ctx context.Context, tier *entity.ProductPricingTier) error {
+	if tier.ID == uuid.Nil {
+		tier.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(tier).Error
+}
+
+// GetPricingTiers retrieves every tier of a product, lowest quantity first
+func (r *pricingRepository) GetPricingTiers(ctx context.Context, productID uuid.UUID) ([]*entity.ProductPricingTier, error) {
+	var tiers []*entity.ProductPricingTier
+	err := r.db.WithContext(ctx).Where("product_id = ?", productID).Order("min_quantity").Find(&tiers).Error
+	return tiers, err
+}
+
+// UpdatePricingTier updates a quantity tier
+func (r *pricingRepository) UpdatePricingTier(ctx context.Context, tier *entity.ProductPricingTier) error {
+	return r.db.WithContext(ctx).Save(tier).Error
+}
+
+// DeletePricingTier deletes a quantity tier
+func (r *pricingRepository) DeletePricingTier(ctx context.Context, tierID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&entity.ProductPricingTier{}, "id = ?", tierID).Error
+}
+
+// CreateCustomerGroupPricing creates the pricing of a customer group for a product
+func (r *pricingRepository) CreateCustomerGroupPricing(ctx context.Context, pricing *entity.CustomerGroupPricing) error {
+	if pricing.ID == uuid.Nil {
+		pricing.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(pricing).Error
+}
+
+// GetCustomerGroupPricing retrieves the active pricing of a customer group for a product
+func (r *pricingRepository) GetCustomerGroupPricing(ctx context.Context, productID uuid.UUID, customerGroup string) (*entity.CustomerGroupPricing, error) {
+	var pricing entity.CustomerGroupPricing
+	err := r.db.WithContext(ctx).
+		Where("product_id = ? AND customer_group = ? AND is_active = ?", productID, customerGroup, true).
+		First(&pricing).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &pricing, nil
+}
+
+// UpdateCustomerGroupPricing updates the pricing of a customer group
+func (r *pricingRepository) UpdateCustomerGroupPricing(ctx context.Context, pricing *entity.CustomerGroupPricing) error {
+	return r.db.WithContext(ctx).Save(pricing).Error
+}
+
+// DeleteCustomerGroupPricing deletes the pricing of a customer group
+func (r *pricingRepository) DeleteCustomerGroupPricing(ctx context.Context, pricingID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&entity.CustomerGroupPricing{}, "id = ?", pricingID).Error
+}
+
+// CreateVIPBenefits creates the pricing benefits of a VIP level
+func (r *pricingRepository) CreateVIPBenefits(ctx context.Context, benefits *entity.VIPPricingBenefits) error {
+	if benefits.ID == uuid.Nil {
+		benefits.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).Create(benefits).Error
+}
+
+// GetVIPBenefits retrieves the active pricing benefits of a VIP level
+func (r *pricingRepository) GetVIPBenefits(ctx context.Context, vipLevel string) (*entity.VIPPricingBenefits, error) {
+	var benefits entity.VIPPricingBenefits
+	err := r.db.WithContext(ctx).Where("vip_level = ? AND is_active = ?", vipLevel, true).First(&benefits).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &benefits, nil
+}
+
+// UpdateVIPBenefits updates the pricing benefits of a VIP level
+func (r *pricingRepository) UpdateVIPBenefits(ctx context.Context, benefits *entity.VIPPricingBenefits) error {
+	return r.db.WithContext(ctx).Save(benefits).Error
+}
+
+// DeleteVIPBenefits deletes the pricing benefits of a VIP level
+func (r *pricingRepository) DeleteVIPBenefits(ctx context.Context, benefitsID uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&entity.VIPPricingBenefits{}, "id = ?", benefitsID).Error
+}
+
+// ListVIPBenefits retrieves the pricing benefits of every VIP level
+func (r *pricingRepository) ListVIPBenefits(ctx context.Context) ([]*entity.VIPPricingBenefits, error) {
+	var benefits []*entity.VIPPricingBenefits
+	err := r.db.WithContext(ctx).Order("vip_level").Find(&benefits).Error
+	return benefits, err
+}
+
+// GetActivePricingTiers retrieves the active tiers of the products
+func (r *pricingRepository) GetActivePricingTiers(ctx context.Context, productIDs []uuid.UUID) ([]*entity.ProductPricingTier, error) {
+	var tiers []*entity.ProductPricingTier
+	err := r.db.WithContext(ctx).
+		Where("product_id IN ? AND is_active = ?", productIDs, true).
+		Order("product_id, min_quantity").
+		Find(&tiers).Error
+	return tiers, err
+}
+
+// GetActiveCustomerGroupPricing retrieves the active pricing of a customer group for the products
+func (r *pricingRepository) GetActiveCustomerGroupPricing(ctx context.Context, productIDs []uuid.UUID, customerGroup string) ([]*entity.CustomerGroupPricing, error) {
+	var pricing []*entity.CustomerGroupPricing
+	err := r.db.WithContext(ctx).
+		Where("product_id IN ? AND customer_group = ? AND is_active = ?", productIDs, customerGroup, true).
+		Find(&pricing).Error
+	return pricing, err
+}
+
+// GetOptimalPricingTier retrieves the tier a quantity of the product is priced at today
+func (r *pricingRepository) GetOptimalPricingTier(ctx context.Context, productID uuid.UUID, quantity int) (*entity.ProductPricingTier, error) {
+	tiers, err := r.GetActivePricingTiers(ctx, []uuid.UUID{productID})
+	if err != nil {
+		return nil, err
+	}
+	return entity.OptimalPricingTier(tiers, quantity, time.Now()), nil
+}
+
+// ValidatePricingTiers checks that the active tiers of a product do not overlap
+func (r *pricingRepository) ValidatePricingTiers(ctx context.Context, productID uuid.UUID) error {
+	tiers, err := r.GetPricingTiers(ctx, productID)
+	if err != nil {
+		return err
+	}
+	return entity.ValidatePricingTiers(tiers)
+}
@@ -199,6 +199,8 @@ func (r *productRepository) UpsertFromLoyverse(ctx context.Context, product *ent
 		product.CreatedBy = existing.CreatedBy
 		product.Version = existing.Version + 1
 		product.DataSourceType = "loyverse"
+		// VAT exemption is kept here, Loyverse does not carry it
+		product.IsVATExempt = existing.IsVATExempt
 		return r.db.WithContext(ctx).Save(product).Error
 	}
 
@@ -357,10 +359,28 @@ func (r *productRepository) applyFilters(query *gorm.DB, filter repository.Produ
 		query = query.Where("is_active = ?", *filter.IsActive)
 	}
 
+	if filter.IsAvailable != nil {
+		if *filter.IsAvailable {
+			query = query.Where("is_active AND is_admin_active")
+		} else {
+			query = query.Where("NOT (is_active AND is_admin_active)")
+		}
+	}
+
 	if filter.IsVIPOnly != nil {
 		query = query.Where("is_vip_only = ?", *filter.IsVIPOnly)
 	}
 
+	if filter.VIPLevel != nil {
+		// Products are VIP gated while VIP only or in early access; the minimum level then applies
+		gated := "(is_vip_only OR (vip_early_access AND (early_access_until IS NULL OR early_access_until > CURRENT_TIMESTAMP)))"
+		if *filter.VIPLevel == "" {
+			query = query.Where("NOT " + gated)
+		} else {
+			query = query.Where("NOT "+gated+" OR min_vip_level IS NULL OR min_vip_level IN ?", entity.VIPLevelsUpTo(*filter.VIPLevel))
+		}
+	}
+
 	if filter.SKU != nil {
 		query = query.Where("sku = ?", *filter.SKU)
 	}
@@ -0,0 +1,384 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/domain/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// stockReservationRepository implements the StockReservationRepository interface
+type stockReservationRepository struct {
+	db *gorm.DB
+}
+
+// NewStockReservationRepository creates a new stock reservation repository
+func NewStockReservationRepository(db *gorm.DB) repository.StockReservationRepository {
+	return &stockReservationRepository{db: db}
+}
+
+// Reserve holds stock for all reservations or none of them
+func (r *stockReservationRepository) Reserve(ctx context.Context, idempotencyKey string, reservations []*entity.StockReservation) ([]*entity.StockReservation, error) {
+	var result []*entity.StockReservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Serialize requests with the same key so concurrent retries cannot both reserve
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", idempotencyKey).Error; err != nil {
+			return err
+		}
+
+		var existing []*entity.StockReservation
+		if err := tx.Where("idempotency_key = ?", idempotencyKey).Order("created_at").Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			result = existing
+			return nil
+		}
+
+		// Lock inventory rows in product order so concurrent orders cannot deadlock
+		sorted := make([]*entity.StockReservation, len(reservations))
+		copy(sorted, reservations)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			return sorted[i].ProductID.String() < sorted[j].ProductID.String()
+		})
+
+		for _, reservation := range sorted {
+			inventory, err := lockInventoryForReservation(tx, reservation)
+			if err != nil {
+				return err
+			}
+
+			err = tx.Model(&entity.Inventory{}).
+				Where("id = ?", inventory.ID).
+				Update("reserved_level", gorm.Expr("reserved_level + ?", reservation.Quantity)).Error
+			if err != nil {
+				return err
+			}
+
+			if err := claimLaunchAllocation(tx, reservation); err != nil {
+				return err
+			}
+
+			reservation.LocationID = inventory.LocationID
+			if err := tx.Create(reservation).Error; err != nil {
+				return err
+			}
+		}
+
+		result = reservations
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// lockInventoryForReservation locks the inventory row the reservation draws from. Without a
+// location the location with the most available stock is used.
+func lockInventoryForReservation(tx *gorm.DB, reservation *entity.StockReservation) (*entity.Inventory, error) {
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("product_id = ? AND is_available = ?", reservation.ProductID, true)
+	if reservation.LocationID != uuid.Nil {
+		query = query.Where("location_id = ?", reservation.LocationID)
+	}
+
+	var inventory entity.Inventory
+	err := query.Order("available_level DESC").First(&inventory).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("%w: product %s is not stocked", entity.ErrInsufficientStock, reservation.ProductID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if inventory.AvailableLevel < reservation.Quantity {
+		return nil, fmt.Errorf("%w: product %s has %.3f available, %.3f requested",
+			entity.ErrInsufficientStock, reservation.ProductID, inventory.AvailableLevel, reservation.Quantity)
+	}
+	return &inventory, nil
+}
+
+// claimLaunchAllocation counts the reservation against its launch allocation, failing when the
+// quota left is too small. The conditional update keeps concurrent orders within the quota.
+func claimLaunchAllocation(tx *gorm.DB, reservation *entity.StockReservation) error {
+	if reservation.LaunchAllocationID == nil {
+		return nil
+	}
+	result := tx.Model(&entity.LaunchAllocation{}).
+		Where("id = ? AND claimed + ? <= quota", *reservation.LaunchAllocationID, reservation.Quantity).
+		Update("claimed", gorm.Expr("claimed + ?", reservation.Quantity))
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: product %s, %.3f requested",
+			entity.ErrLaunchAllocationExhausted, reservation.ProductID, reservation.Quantity)
+	}
+	return nil
+}
+
+// returnLaunchAllocation gives quantity claimed from a launch allocation back to it
+func returnLaunchAllocation(tx *gorm.DB, allocationID *uuid.UUID, quantity float64) error {
+	if allocationID == nil {
+		return nil
+	}
+	return tx.Model(&entity.LaunchAllocation{}).
+		Where("id = ?", *allocationID).
+		Update("claimed", gorm.Expr("GREATEST(claimed - ?, 0)", quantity)).Error
+}
+
+// GetByOrderID retrieves all reservations of an order
+func (r *stockReservationRepository) GetByOrderID(ctx context.Context, orderID uuid.UUID) ([]*entity.StockReservation, error) {
+	var reservations []*entity.StockReservation
+	err := r.db.WithContext(ctx).Where("order_id = ?", orderID).Order("created_at").Find(&reservations).Error
+	return reservations, err
+}
+
+// Consume deducts the active reserved stock of an order. Reservations an edited order released
+// are skipped, and consuming an already consumed order is a no-op.
+func (r *stockReservationRepository) Consume(ctx context.Context, orderID uuid.UUID, now time.Time) ([]*entity.StockReservation, error) {
+	var reservations []*entity.StockReservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservations, err = lockReservations(tx, orderID)
+		if err != nil {
+			return err
+		}
+		if len(reservations) == 0 {
+			return entity.ErrReservationNotFound
+		}
+
+		// Check every reservation first so an order is never partly consumed
+		held := false
+		for _, reservation := range reservations {
+			switch reservation.Status {
+			case entity.ReservationStatusActive:
+				if !reservation.IsActive(now) {
+					return entity.ErrReservationNotActive
+				}
+				held = true
+			case entity.ReservationStatusConsumed:
+				held = true
+			}
+		}
+		if !held {
+			return entity.ErrReservationNotActive
+		}
+
+		for _, reservation := range reservations {
+			if reservation.Status != entity.ReservationStatusActive {
+				continue
+			}
+			err := tx.Model(&entity.Inventory{}).
+				Where("product_id = ? AND location_id = ?", reservation.ProductID, reservation.LocationID).
+				Updates(map[string]interface{}{
+					"stock_level":    gorm.Expr("stock_level - ?", reservation.Quantity),
+					"reserved_level": gorm.Expr("reserved_level - ?", reservation.Quantity),
+				}).Error
+			if err != nil {
+				return err
+			}
+			if err := reservation.Consume(now); err != nil {
+				return err
+			}
+			if err := tx.Save(reservation).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// Release returns the active reservations of an order to available stock. Consumed and already
+// released reservations are left as they are. With an idempotency key only the reservations made
+// under it are released.
+func (r *stockReservationRepository) Release(ctx context.Context, orderID uuid.UUID, idempotencyKey string, status entity.ReservationStatus, reason string, now time.Time) ([]*entity.StockReservation, error) {
+	var reservations []*entity.StockReservation
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservations, err = lockReservations(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		for _, reservation := range reservations {
+			if reservation.Status != entity.ReservationStatusActive {
+				continue
+			}
+			if idempotencyKey != "" && reservation.IdempotencyKey != idempotencyKey {
+				continue
+			}
+			err := tx.Model(&entity.Inventory{}).
+				Where("product_id = ? AND location_id = ?", reservation.ProductID, reservation.LocationID).
+				Update("reserved_level", gorm.Expr("reserved_level - ?", reservation.Quantity)).Error
+			if err != nil {
+				return err
+			}
+			// Released stock goes back to the launch allocation it was claimed from
+			if err := returnLaunchAllocation(tx, reservation.LaunchAllocationID, reservation.Quantity); err != nil {
+				return err
+			}
+			if err := reservation.Release(status, reason, now); err != nil {
+				return err
+			}
+			if err := tx.Save(reservation).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetExpiredOrderIDs lists orders with active reservations past their expiry, oldest first
+func (r *stockReservationRepository) GetExpiredOrderIDs(ctx context.Context, now time.Time, limit int) ([]uuid.UUID, error) {
+	var orderIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&entity.StockReservation{}).
+		Select("order_id").
+		Where("status = ? AND expires_at <= ?", entity.ReservationStatusActive, now).
+		Group("order_id").
+		Order("MIN(expires_at)").
+		Limit(limit).
+		Pluck("order_id", &orderIDs).Error
+	return orderIDs, err
+}
+
+// Restock puts returned stock back at the locations the order's reservations were consumed
+// from, never more at a location than it gave, and gives the launch quota it claimed back.
+// Stock returned earlier for the order counts against what was consumed, so an item cannot be
+// restocked twice under different keys.
+func (r *stockReservationRepository) Restock(ctx context.Context, orderID uuid.UUID, idempotencyKey string, returns []*entity.StockReturn) ([]*entity.StockReturn, error) {
+	var result []*entity.StockReturn
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", idempotencyKey).Error; err != nil {
+			return err
+		}
+
+		var existing []*entity.StockReturn
+		if err := tx.Where("idempotency_key = ?", idempotencyKey).Order("created_at").Find(&existing).Error; err != nil {
+			return err
+		}
+		if len(existing) > 0 {
+			result = existing
+			return nil
+		}
+
+		// Locking the reservations serializes restocks of the same order
+		reservations, err := lockReservations(tx, orderID)
+		if err != nil {
+			return err
+		}
+
+		type consumedStock struct {
+			locationID   uuid.UUID
+			allocationID *uuid.UUID
+			quantity     float64
+		}
+		consumed := make(map[uuid.UUID][]*consumedStock)
+		for _, reservation := range reservations {
+			if reservation.Status != entity.ReservationStatusConsumed {
+				continue
+			}
+			consumed[reservation.ProductID] = append(consumed[reservation.ProductID], &consumedStock{
+				locationID:   reservation.LocationID,
+				allocationID: reservation.LaunchAllocationID,
+				quantity:     reservation.Quantity,
+			})
+		}
+
+		// Stock returned earlier is taken off the reservations of its location in order, so a
+		// location an edited order reserved from twice is not counted down twice
+		var returned []*entity.StockReturn
+		if err := tx.Where("order_id = ?", orderID).Find(&returned).Error; err != nil {
+			return err
+		}
+		for _, previous := range returned {
+			remaining := previous.Quantity
+			for _, stock := range consumed[previous.ProductID] {
+				if stock.locationID != previous.LocationID || remaining <= 0.0005 {
+					continue
+				}
+				quantity := remaining
+				if stock.quantity < quantity {
+					quantity = stock.quantity
+				}
+				stock.quantity -= quantity
+				remaining -= quantity
+			}
+		}
+
+		for _, stockReturn := range returns {
+			remaining := stockReturn.Quantity
+			for _, stock := range consumed[stockReturn.ProductID] {
+				// Quantities are stored to three decimals
+				if remaining <= 0.0005 {
+					break
+				}
+				if stock.quantity <= 0.0005 {
+					continue
+				}
+
+				quantity := remaining
+				if stock.quantity < quantity {
+					quantity = stock.quantity
+				}
+				stock.quantity -= quantity
+				remaining -= quantity
+
+				err := tx.Model(&entity.Inventory{}).
+					Where("product_id = ? AND location_id = ?", stockReturn.ProductID, stock.locationID).
+					Update("stock_level", gorm.Expr("stock_level + ?", quantity)).Error
+				if err != nil {
+					return err
+				}
+				// Stock put back goes back to the launch allocation it was claimed from
+				if err := returnLaunchAllocation(tx, stock.allocationID, quantity); err != nil {
+					return err
+				}
+
+				row := *stockReturn
+				row.ID = uuid.New()
+				row.LocationID = stock.locationID
+				row.Quantity = quantity
+				if err := tx.Create(&row).Error; err != nil {
+					return err
+				}
+				result = append(result, &row)
+			}
+			if remaining > 0.0005 {
+				return fmt.Errorf("%w: product %s has %.3f more returned than consumed",
+					entity.ErrReturnExceedsConsumed, stockReturn.ProductID, remaining)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func lockReservations(tx *gorm.DB, orderID uuid.UUID) ([]*entity.StockReservation, error) {
+	var reservations []*entity.StockReservation
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ?", orderID).
+		Order("created_at").
+		Find(&reservations).Error
+	return reservations, err
+}
@@ -0,0 +1,464 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"product/internal/domain/entity"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// The repositories are tested against a scripted database/sql driver that records the statements
+// they run and answers them from the test, so no database is needed
+
+type scriptedRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+// scriptedDB records statements and answers them with respond, which returns the rows of a
+// query or the rows affected by a statement
+type scriptedDB struct {
+	mu         sync.Mutex
+	statements []scriptedStatement
+	respond    func(query string, args []driver.NamedValue) (*scriptedRows, int64)
+}
+
+type scriptedStatement struct {
+	query string
+	args  []driver.NamedValue
+}
+
+var (
+	scriptedDBs  sync.Map
+	registerOnce sync.Once
+)
+
+type scriptedDriver struct{}
+
+func (scriptedDriver) Open(name string) (driver.Conn, error) {
+	db, ok := scriptedDBs.Load(name)
+	if !ok {
+		return nil, errors.New("unknown scripted database")
+	}
+	return &scriptedConn{db: db.(*scriptedDB)}, nil
+}
+
+type scriptedConn struct{ db *scriptedDB }
+
+func (c *scriptedConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepared statements are not scripted")
+}
+func (c *scriptedConn) Close() error              { return nil }
+func (c *scriptedConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *scriptedConn) Commit() error             { return nil }
+func (c *scriptedConn) Rollback() error           { return nil }
+
+func (c *scriptedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	_, affected := c.db.run(query, args)
+	return driver.RowsAffected(affected), nil
+}
+
+func (c *scriptedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	rows, _ := c.db.run(query, args)
+	if rows == nil {
+		rows = &scriptedRows{}
+	}
+	return &scriptedCursor{rows: rows}, nil
+}
+
+func (db *scriptedDB) run(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.statements = append(db.statements, scriptedStatement{query: query, args: args})
+	if db.respond == nil {
+		return nil, 1
+	}
+	return db.respond(query, args)
+}
+
+// find returns the statements containing the text
+func (db *scriptedDB) find(text string) []scriptedStatement {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var found []scriptedStatement
+	for _, statement := range db.statements {
+		if strings.Contains(statement.query, text) {
+			found = append(found, statement)
+		}
+	}
+	return found
+}
+
+type scriptedCursor struct {
+	rows *scriptedRows
+	next int
+}
+
+func (c *scriptedCursor) Columns() []string { return c.rows.columns }
+func (c *scriptedCursor) Close() error      { return nil }
+
+func (c *scriptedCursor) Next(dest []driver.Value) error {
+	if c.next >= len(c.rows.rows) {
+		return io.EOF
+	}
+	copy(dest, c.rows.rows[c.next])
+	c.next++
+	return nil
+}
+
+// newScriptedGorm opens gorm with the postgres dialect on a scripted database
+func newScriptedGorm(t *testing.T, db *scriptedDB) *gorm.DB {
+	t.Helper()
+	registerOnce.Do(func() { sql.Register("scripted", scriptedDriver{}) })
+	name := t.Name()
+	scriptedDBs.Store(name, db)
+	t.Cleanup(func() { scriptedDBs.Delete(name) })
+
+	gormDB, err := gorm.Open(postgres.New(postgres.Config{DriverName: "scripted", DSN: name}), &gorm.Config{
+		Logger:               logger.Default.LogMode(logger.Silent),
+		DisableAutomaticPing: true,
+	})
+	if err != nil {
+		t.Fatalf("open scripted database: %v", err)
+	}
+	return gormDB
+}
+
+func TestClaimLaunchAllocation(t *testing.T) {
+	allocationID := uuid.New()
+	tests := []struct {
+		name     string
+		affected int64
+		want     error
+	}{
+		{"within the quota", 1, nil},
+		{"quota used up", 0, entity.ErrLaunchAllocationExhausted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &scriptedDB{respond: func(string, []driver.NamedValue) (*scriptedRows, int64) { return nil, tt.affected }}
+			reservation := &entity.StockReservation{ProductID: uuid.New(), Quantity: 3, LaunchAllocationID: &allocationID}
+
+			err := claimLaunchAllocation(newScriptedGorm(t, db), reservation)
+			if tt.want == nil && err != nil {
+				t.Fatalf("claimLaunchAllocation: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+
+			// The quota is checked in the same statement that claims it, so concurrent orders
+			// cannot both claim the last of it
+			claims := db.find(`UPDATE "product_launch_allocations"`)
+			if len(claims) != 1 || !strings.Contains(claims[0].query, "<= quota") {
+				t.Fatalf("claim statements %+v, want one conditional update", claims)
+			}
+			if claims[0].args[0].Value != 3.0 || claims[0].args[1].Value != allocationID.String() {
+				t.Errorf("claim args %+v, want the quantity and allocation", claims[0].args)
+			}
+		})
+	}
+}
+
+func TestClaimLaunchAllocationWithoutLaunch(t *testing.T) {
+	db := &scriptedDB{}
+	reservation := &entity.StockReservation{ProductID: uuid.New(), Quantity: 3}
+
+	if err := claimLaunchAllocation(newScriptedGorm(t, db), reservation); err != nil {
+		t.Fatalf("claimLaunchAllocation: %v", err)
+	}
+	if len(db.statements) != 0 {
+		t.Errorf("ran %+v for a reservation outside a launch", db.statements)
+	}
+}
+
+func TestReleaseReturnsLaunchQuota(t *testing.T) {
+	orderID, allocationID := uuid.New(), uuid.New()
+	now := time.Now()
+	columns := []string{"id", "order_id", "product_id", "location_id", "quantity", "status", "expires_at", "created_at", "launch_allocation_id"}
+	row := func(status entity.ReservationStatus, allocation interface{}) []driver.Value {
+		return []driver.Value{uuid.NewString(), orderID.String(), uuid.NewString(), uuid.NewString(), 2.0, string(status), now, now, allocation}
+	}
+
+	db := &scriptedDB{respond: func(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+		if strings.HasPrefix(query, `SELECT * FROM "stock_reservations"`) {
+			return &scriptedRows{columns: columns, rows: [][]driver.Value{
+				row(entity.ReservationStatusActive, allocationID.String()),
+				// Consumed stock stays claimed
+				row(entity.ReservationStatusConsumed, allocationID.String()),
+				row(entity.ReservationStatusActive, nil),
+			}}, 0
+		}
+		return nil, 1
+	}}
+	repo := NewStockReservationRepository(newScriptedGorm(t, db))
+
+	released, err := repo.Release(context.Background(), orderID, "", entity.ReservationStatusReleased, "order cancelled", now)
+	if err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if len(released) != 3 || released[0].Status != entity.ReservationStatusReleased || released[1].Status != entity.ReservationStatusConsumed {
+		t.Fatalf("released %+v", released)
+	}
+
+	returns := db.find(`UPDATE "product_launch_allocations"`)
+	if len(returns) != 1 {
+		t.Fatalf("quota returned %d times, want once for the active launch reservation", len(returns))
+	}
+	if !strings.Contains(returns[0].query, "GREATEST(claimed - $1, 0)") {
+		t.Errorf("quota returned with %q", returns[0].query)
+	}
+	if returns[0].args[0].Value != 2.0 || returns[0].args[1].Value != allocationID.String() {
+		t.Errorf("return args %+v, want the quantity and allocation", returns[0].args)
+	}
+}
+
+func TestReleaseByIdempotencyKey(t *testing.T) {
+	orderID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "order_id", "product_id", "location_id", "quantity", "status", "expires_at", "created_at", "idempotency_key"}
+	row := func(key string) []driver.Value {
+		return []driver.Value{uuid.NewString(), orderID.String(), uuid.NewString(), uuid.NewString(), 2.0, string(entity.ReservationStatusActive), now.Add(time.Hour), now, key}
+	}
+
+	db := &scriptedDB{respond: func(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+		if strings.HasPrefix(query, `SELECT * FROM "stock_reservations"`) {
+			return &scriptedRows{columns: columns, rows: [][]driver.Value{
+				row("order:1:reserve"),
+				row("order:1:edit:2"),
+			}}, 0
+		}
+		return nil, 1
+	}}
+	repo := NewStockReservationRepository(newScriptedGorm(t, db))
+
+	released, err := repo.Release(context.Background(), orderID, "order:1:edit:2", entity.ReservationStatusReleased, "order edit failed", now)
+	if err != nil {
+		t.Fatalf("Release: %v", err)
+	}
+	if released[0].Status != entity.ReservationStatusActive || released[1].Status != entity.ReservationStatusReleased {
+		t.Fatalf("released %+v, want only the reservation made under the key", released)
+	}
+	if updates := db.find(`UPDATE "inventories"`); len(updates) != 1 {
+		t.Errorf("inventory updated %d times, want once", len(updates))
+	}
+}
+
+func TestReserveHoldsStock(t *testing.T) {
+	orderID, productID, locationID := uuid.New(), uuid.New(), uuid.New()
+	inventoryColumns := []string{"id", "product_id", "location_id", "stock_level", "reserved_level", "available_level", "is_available"}
+
+	tests := []struct {
+		name      string
+		available float64
+		want      error
+	}{
+		{"enough stock", 5, nil},
+		{"too little stock", 2, entity.ErrInsufficientStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &scriptedDB{respond: func(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+				if strings.HasPrefix(query, `SELECT * FROM "inventories"`) {
+					return &scriptedRows{columns: inventoryColumns, rows: [][]driver.Value{
+						{uuid.NewString(), productID.String(), locationID.String(), 10.0, 10.0 - tt.available, tt.available, true},
+					}}, 0
+				}
+				return nil, 1
+			}}
+			repo := NewStockReservationRepository(newScriptedGorm(t, db))
+			reservation := entity.NewStockReservation("order:1:reserve", orderID, productID, 3, time.Now().Add(time.Hour))
+
+			reserved, err := repo.Reserve(context.Background(), "order:1:reserve", []*entity.StockReservation{reservation})
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+				if inserts := db.find(`INSERT INTO "stock_reservations"`); len(inserts) != 0 {
+					t.Errorf("reserved %d times without stock", len(inserts))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Reserve: %v", err)
+			}
+
+			if len(reserved) != 1 || reserved[0].LocationID != locationID {
+				t.Fatalf("reserved %+v, want the stock at the inventory's location", reserved)
+			}
+			if locks := db.find(`FOR UPDATE`); len(locks) != 1 {
+				t.Errorf("inventory locked %d times, want once", len(locks))
+			}
+			updates := db.find(`UPDATE "inventories"`)
+			if len(updates) != 1 || !strings.Contains(updates[0].query, "reserved_level + $1") || updates[0].args[0].Value != 3.0 {
+				t.Errorf("inventory updates %+v, want the quantity added to reserved stock", updates)
+			}
+			if inserts := db.find(`INSERT INTO "stock_reservations"`); len(inserts) != 1 {
+				t.Errorf("reservations inserted %d times, want once", len(inserts))
+			}
+		})
+	}
+}
+
+func TestReserveReturnsEarlierReservations(t *testing.T) {
+	orderID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "order_id", "product_id", "location_id", "quantity", "status", "expires_at", "created_at", "idempotency_key"}
+
+	db := &scriptedDB{respond: func(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+		if strings.HasPrefix(query, `SELECT * FROM "stock_reservations"`) {
+			return &scriptedRows{columns: columns, rows: [][]driver.Value{
+				{uuid.NewString(), orderID.String(), uuid.NewString(), uuid.NewString(), 2.0, string(entity.ReservationStatusActive), now.Add(time.Hour), now, "order:1:reserve"},
+			}}, 0
+		}
+		return nil, 1
+	}}
+	repo := NewStockReservationRepository(newScriptedGorm(t, db))
+	retry := entity.NewStockReservation("order:1:reserve", orderID, uuid.New(), 2, now.Add(time.Hour))
+
+	reserved, err := repo.Reserve(context.Background(), "order:1:reserve", []*entity.StockReservation{retry})
+	if err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if len(reserved) != 1 || reserved[0] == retry {
+		t.Fatalf("reserved %+v, want the reservation made by the first request", reserved)
+	}
+	if len(db.find(`"inventories"`)) != 0 || len(db.find(`INSERT`)) != 0 {
+		t.Errorf("a retried request reserved stock again: %+v", db.statements)
+	}
+}
+
+func TestConsumeDeductsReservedStock(t *testing.T) {
+	orderID := uuid.New()
+	now := time.Now()
+	columns := []string{"id", "order_id", "product_id", "location_id", "quantity", "status", "expires_at", "created_at"}
+	row := func(status entity.ReservationStatus, expiresAt time.Time) []driver.Value {
+		return []driver.Value{uuid.NewString(), orderID.String(), uuid.NewString(), uuid.NewString(), 2.0, string(status), expiresAt, now}
+	}
+
+	tests := []struct {
+		name    string
+		rows    [][]driver.Value
+		want    error
+		updates int
+	}{
+		{"active reservations", [][]driver.Value{
+			row(entity.ReservationStatusActive, now.Add(time.Hour)),
+			// Released by an edit of the order
+			row(entity.ReservationStatusReleased, now.Add(time.Hour)),
+		}, nil, 1},
+		{"already consumed", [][]driver.Value{row(entity.ReservationStatusConsumed, now.Add(-time.Hour))}, nil, 0},
+		{"expired reservation", [][]driver.Value{
+			row(entity.ReservationStatusActive, now.Add(time.Hour)),
+			row(entity.ReservationStatusActive, now.Add(-time.Minute)),
+		}, entity.ErrReservationNotActive, 0},
+		{"released by expiry", [][]driver.Value{row(entity.ReservationStatusExpired, now.Add(-time.Hour))}, entity.ErrReservationNotActive, 0},
+		{"no reservation", nil, entity.ErrReservationNotFound, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &scriptedDB{respond: func(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+				if strings.HasPrefix(query, `SELECT * FROM "stock_reservations"`) {
+					return &scriptedRows{columns: columns, rows: tt.rows}, 0
+				}
+				return nil, 1
+			}}
+			repo := NewStockReservationRepository(newScriptedGorm(t, db))
+
+			consumed, err := repo.Consume(context.Background(), orderID, now)
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+			} else if err != nil {
+				t.Fatalf("Consume: %v", err)
+			} else if consumed[0].Status != entity.ReservationStatusConsumed {
+				t.Errorf("consumed %+v", consumed)
+			}
+
+			// An order is consumed entirely or not at all
+			updates := db.find(`UPDATE "inventories"`)
+			if len(updates) != tt.updates {
+				t.Fatalf("inventory updated %d times, want %d", len(updates), tt.updates)
+			}
+			for _, update := range updates {
+				if !strings.Contains(update.query, "reserved_level - ") || !strings.Contains(update.query, "stock_level - ") {
+					t.Errorf("inventory updated with %q, want stock and reserved stock deducted", update.query)
+				}
+			}
+		})
+	}
+}
+
+func TestRestockReturnsConsumedStock(t *testing.T) {
+	orderID, productID, locationID, allocationID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	now := time.Now()
+	reservationColumns := []string{"id", "order_id", "product_id", "location_id", "quantity", "status", "expires_at", "created_at", "launch_allocation_id"}
+	returnColumns := []string{"id", "idempotency_key", "order_id", "product_id", "location_id", "quantity", "created_at"}
+
+	tests := []struct {
+		name     string
+		earlier  [][]driver.Value
+		quantity float64
+		want     error
+	}{
+		{"consumed stock", nil, 3, nil},
+		{"more than consumed", nil, 5, entity.ErrReturnExceedsConsumed},
+		{"returned under another key", [][]driver.Value{
+			{uuid.NewString(), "order:1:return:1", orderID.String(), productID.String(), locationID.String(), 3.0, now},
+		}, 3, entity.ErrReturnExceedsConsumed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &scriptedDB{respond: func(query string, args []driver.NamedValue) (*scriptedRows, int64) {
+				switch {
+				case strings.HasPrefix(query, `SELECT * FROM "stock_reservations"`):
+					return &scriptedRows{columns: reservationColumns, rows: [][]driver.Value{
+						{uuid.NewString(), orderID.String(), productID.String(), locationID.String(), 4.0, string(entity.ReservationStatusConsumed), now, now, allocationID.String()},
+						// Released stock never left the shelf
+						{uuid.NewString(), orderID.String(), productID.String(), uuid.NewString(), 4.0, string(entity.ReservationStatusReleased), now, now, nil},
+					}}, 0
+				case strings.HasPrefix(query, `SELECT * FROM "stock_returns"`) && strings.Contains(query, "order_id"):
+					return &scriptedRows{columns: returnColumns, rows: tt.earlier}, 0
+				}
+				return nil, 1
+			}}
+			repo := NewStockReservationRepository(newScriptedGorm(t, db))
+			stockReturn := entity.NewStockReturn("order:1:cancel:restock", orderID, productID, tt.quantity, "order cancelled")
+
+			returned, err := repo.Restock(context.Background(), orderID, "order:1:cancel:restock", []*entity.StockReturn{stockReturn})
+			if tt.want != nil {
+				if !errors.Is(err, tt.want) {
+					t.Fatalf("got %v, want %v", err, tt.want)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Restock: %v", err)
+			}
+
+			if len(returned) != 1 || returned[0].LocationID != locationID || returned[0].Quantity != 3 {
+				t.Fatalf("returned %+v, want the stock back where it was consumed", returned)
+			}
+			updates := db.find(`UPDATE "inventories"`)
+			if len(updates) != 1 || !strings.Contains(updates[0].query, "stock_level + $1") || updates[0].args[0].Value != 3.0 {
+				t.Errorf("inventory updates %+v, want the quantity added to stock", updates)
+			}
+			if quota := db.find(`UPDATE "product_launch_allocations"`); len(quota) != 1 || quota[0].args[1].Value != allocationID.String() {
+				t.Errorf("quota updates %+v, want the quota given back to the allocation", quota)
+			}
+		})
+	}
+}
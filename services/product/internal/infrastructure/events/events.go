@@ -14,6 +14,7 @@ const (
 	ProductDeletedEvent = "product.deleted"
 	ProductActivatedEvent = "product.activated"
 	ProductDeactivatedEvent = "product.deactivated"
+	ProductLaunchUpdatedEvent = "product.launch.updated"
 	
 	// Category Events
 	CategoryCreatedEvent = "category.created"
@@ -0,0 +1,199 @@
+package notification
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"product/internal/domain/entity"
+	"product/internal/infrastructure/config"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// customerPageSize is how many customers one page of the customer service lists
+	customerPageSize = 100
+	// lineRecipientBatch is the most LINE users one notification is sent to
+	lineRecipientBatch = 500
+	// earlyAccessTemplate is the notification service template of early access messages
+	earlyAccessTemplate = "vip_early_access"
+)
+
+// LaunchNotifier tells VIP members over LINE that early access to a launch opened. It looks up
+// the LINE users of each eligible tier in the customer service and sends the messages through
+// the notification service.
+type LaunchNotifier struct {
+	customerURL     string
+	notificationURL string
+	client          *http.Client
+	logger          *logrus.Logger
+}
+
+// NewLaunchNotifier creates a new LINE launch notifier
+func NewLaunchNotifier(cfg config.ExternalConfig, logger *logrus.Logger) *LaunchNotifier {
+	return &LaunchNotifier{
+		customerURL:     cfg.CustomerService,
+		notificationURL: cfg.NotificationService,
+		client: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		logger: logger,
+	}
+}
+
+// customer is the part of a customer service customer the notifier needs
+type customer struct {
+	LineUserID *string `json:"line_user_id"`
+	IsActive   bool    `json:"is_active"`
+}
+
+// customerPage is a page of customers from the customer service
+type customerPage struct {
+	Customers  []customer `json:"customers"`
+	Pagination struct {
+		Total int `json:"total"`
+	} `json:"pagination"`
+}
+
+// notificationRequest is a notification for the notification service
+type notificationRequest struct {
+	Type       string                 `json:"type"`
+	Recipients []string               `json:"recipients"`
+	Template   string                 `json:"template"`
+	Data       map[string]interface{} `json:"data"`
+	Priority   string                 `json:"priority"`
+}
+
+// NotifyEarlyAccess messages the LINE users of every VIP level eligible for the launch and
+// returns how many were messaged. Customers of a level share the same customer tier, Bronze
+// being tier 1.
+func (n *LaunchNotifier) NotifyEarlyAccess(ctx context.Context, launch *entity.ProductLaunch, product *entity.Product) (int, error) {
+	eligible := make(map[string]bool)
+	for _, level := range launch.EligibleLevels() {
+		eligible[level] = true
+	}
+
+	notified := 0
+	for tier, level := range entity.VIPLevels() {
+		if !eligible[level] {
+			continue
+		}
+		recipients, err := n.lineUsers(ctx, tier+1)
+		if err != nil {
+			return notified, fmt.Errorf("failed to list %s customers: %w", level, err)
+		}
+
+		data := map[string]interface{}{
+			"launch_id":    launch.ID,
+			"product_id":   product.ID,
+			"product_name": product.Name,
+			"vip_level":    level,
+			"message":      launch.Message,
+			"public_at":    launch.PublicAt,
+		}
+		for _, allocation := range launch.Allocations {
+			if allocation.VIPLevel == level {
+				data["quota"] = allocation.Quota
+			}
+		}
+
+		for start := 0; start < len(recipients); start += lineRecipientBatch {
+			end := start + lineRecipientBatch
+			if end > len(recipients) {
+				end = len(recipients)
+			}
+			if err := n.send(ctx, recipients[start:end], data); err != nil {
+				return notified, fmt.Errorf("failed to notify %s customers: %w", level, err)
+			}
+			notified += end - start
+		}
+
+		n.logger.WithFields(logrus.Fields{
+			"launch_id": launch.ID,
+			"vip_level": level,
+			"notified":  len(recipients),
+		}).Info("VIP early access notified")
+	}
+	return notified, nil
+}
+
+// lineUsers lists the LINE user IDs of the active customers of a tier. The customer service
+// lists active customers only; inactive ones are skipped as well in case it ever lists them.
+func (n *LaunchNotifier) lineUsers(ctx context.Context, tier int) ([]string, error) {
+	var lineUsers []string
+	for page := 1; ; page++ {
+		params := url.Values{}
+		params.Set("tier", strconv.Itoa(tier))
+		params.Set("page", strconv.Itoa(page))
+		params.Set("limit", strconv.Itoa(customerPageSize))
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, n.customerURL+"/api/v1/customers/?"+params.Encode(), nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		req.Header.Set("User-Agent", "product-service/1.0")
+
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("request failed: %w", err)
+		}
+		var result customerPage
+		err = func() error {
+			defer resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				return fmt.Errorf("customer service returned status %d", resp.StatusCode)
+			}
+			return json.NewDecoder(resp.Body).Decode(&result)
+		}()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, c := range result.Customers {
+			if c.IsActive && c.LineUserID != nil && *c.LineUserID != "" {
+				lineUsers = append(lineUsers, *c.LineUserID)
+			}
+		}
+		if len(result.Customers) < customerPageSize || page*customerPageSize >= result.Pagination.Total {
+			return lineUsers, nil
+		}
+	}
+}
+
+// send sends one LINE notification to the recipients
+func (n *LaunchNotifier) send(ctx context.Context, recipients []string, data map[string]interface{}) error {
+	body, err := json.Marshal(&notificationRequest{
+		Type:       "line",
+		Recipients: recipients,
+		Template:   earlyAccessTemplate,
+		Data:       data,
+		Priority:   "high",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.notificationURL+"/api/notifications", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "product-service/1.0")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("notification service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
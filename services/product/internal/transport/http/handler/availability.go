@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"product/internal/application"
+	"product/internal/domain/entity"
+	"product/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// AvailabilityHandler handles product availability HTTP requests
+type AvailabilityHandler struct {
+	availabilityUsecase *application.AvailabilityUsecase
+	logger              *logrus.Logger
+}
+
+// NewAvailabilityHandler creates a new availability handler
+func NewAvailabilityHandler(availabilityUsecase *application.AvailabilityUsecase, logger *logrus.Logger) *AvailabilityHandler {
+	return &AvailabilityHandler{
+		availabilityUsecase: availabilityUsecase,
+		logger:              logger,
+	}
+}
+
+// GetAvailability returns whether a product is on sale, its schedule and its latest changes
+func (h *AvailabilityHandler) GetAvailability(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	status, err := h.availabilityUsecase.GetAvailability(c.Request.Context(), productID, limit)
+	if err != nil {
+		h.respondError(c, err, "Failed to get product availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
+
+// UpdateAvailability takes a product off sale or puts it back on sale
+func (h *AvailabilityHandler) UpdateAvailability(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	var req application.UpdateAvailabilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ChangedBy, ok = changedByID(c, req.ChangedBy); !ok {
+		return
+	}
+
+	product, err := h.availabilityUsecase.UpdateAvailability(c.Request.Context(), productID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to update product availability")
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// SetSchedule replaces the availability schedule of a product
+func (h *AvailabilityHandler) SetSchedule(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	var req application.SetAvailabilityScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ChangedBy, ok = changedByID(c, req.ChangedBy); !ok {
+		return
+	}
+
+	product, err := h.availabilityUsecase.SetSchedule(c.Request.Context(), productID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to set availability schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, product)
+}
+
+// productID parses the product ID path parameter
+func (h *AvailabilityHandler) productID(c *gin.Context) (uuid.UUID, bool) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return uuid.Nil, false
+	}
+	return productID, true
+}
+
+// respondError maps availability errors to a status and a code clients can act on
+func (h *AvailabilityHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, entity.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "PRODUCT_NOT_FOUND"})
+	case errors.Is(err, entity.ErrInvalidAvailability):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_AVAILABILITY"})
+	default:
+		h.logger.WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// changedByID returns who makes an availability change like changedBy does. The availability
+// log records users by UUID, so an authenticated caller whose ID is not one is refused too.
+func changedByID(c *gin.Context, requested *uuid.UUID) (*uuid.UUID, bool) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return requested, true
+	}
+	id, err := uuid.Parse(user.ID)
+	if err != nil {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "the authenticated user has no UUID to record the change by",
+			"code":  "CHANGED_BY_MISMATCH",
+		})
+		return nil, false
+	}
+	if requested != nil && *requested != id {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "changed_by must be the authenticated user",
+			"code":  "CHANGED_BY_MISMATCH",
+		})
+		return nil, false
+	}
+	return &id, true
+}
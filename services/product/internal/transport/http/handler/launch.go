@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"product/internal/application"
+	"product/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// LaunchHandler handles product launch HTTP requests
+type LaunchHandler struct {
+	launchUsecase *application.LaunchUsecase
+	logger        *logrus.Logger
+}
+
+// NewLaunchHandler creates a new launch handler
+func NewLaunchHandler(launchUsecase *application.LaunchUsecase, logger *logrus.Logger) *LaunchHandler {
+	return &LaunchHandler{
+		launchUsecase: launchUsecase,
+		logger:        logger,
+	}
+}
+
+// CreateLaunch schedules the launch of a product to VIP members first
+func (h *LaunchHandler) CreateLaunch(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	var req application.CreateLaunchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	launch, err := h.launchUsecase.CreateLaunch(c.Request.Context(), productID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to create product launch")
+		return
+	}
+
+	c.JSON(http.StatusCreated, launch)
+}
+
+// ListLaunches lists the launches of a product
+func (h *LaunchHandler) ListLaunches(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	launches, err := h.launchUsecase.ListLaunches(c.Request.Context(), productID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list product launches")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"launches": launches})
+}
+
+// GetLaunch returns a launch of a product with how much of each allocation is claimed
+func (h *LaunchHandler) GetLaunch(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+	launchID, ok := h.launchID(c)
+	if !ok {
+		return
+	}
+
+	launch, err := h.launchUsecase.GetLaunch(c.Request.Context(), productID, launchID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get product launch")
+		return
+	}
+
+	c.JSON(http.StatusOK, launch)
+}
+
+// CancelLaunch calls off a launch that is not released yet
+func (h *LaunchHandler) CancelLaunch(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+	launchID, ok := h.launchID(c)
+	if !ok {
+		return
+	}
+
+	var req application.CancelLaunchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	launch, err := h.launchUsecase.CancelLaunch(c.Request.Context(), productID, launchID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to cancel product launch")
+		return
+	}
+
+	c.JSON(http.StatusOK, launch)
+}
+
+// productID parses the product ID path parameter
+func (h *LaunchHandler) productID(c *gin.Context) (uuid.UUID, bool) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return uuid.Nil, false
+	}
+	return productID, true
+}
+
+// launchID parses the launch ID path parameter
+func (h *LaunchHandler) launchID(c *gin.Context) (uuid.UUID, bool) {
+	launchID, err := uuid.Parse(c.Param("launch_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid launch ID"})
+		return uuid.Nil, false
+	}
+	return launchID, true
+}
+
+// respondError maps launch errors to a status and a code clients can act on
+func (h *LaunchHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, entity.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "PRODUCT_NOT_FOUND"})
+	case errors.Is(err, entity.ErrLaunchNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "LAUNCH_NOT_FOUND"})
+	case errors.Is(err, entity.ErrLaunchExists):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "LAUNCH_EXISTS"})
+	case errors.Is(err, entity.ErrLaunchNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "LAUNCH_NOT_PENDING"})
+	case errors.Is(err, entity.ErrInvalidLaunch), errors.Is(err, entity.ErrInvalidVIPLevel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_LAUNCH"})
+	default:
+		h.logger.WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -1,4 +1,339 @@
 package handler
 
-// This file is a placeholder for pricing handler
-// To be implemented later
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"product/internal/application"
+	"product/internal/domain/entity"
+	"product/internal/transport/http/middleware"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// PricingHandler handles pricing HTTP requests
+type PricingHandler struct {
+	cartPricingUsecase  *application.CartPricingUsecase
+	priceHistoryUsecase *application.PriceHistoryUsecase
+	marginUsecase       *application.MarginUsecase
+	logger              *logrus.Logger
+}
+
+// NewPricingHandler creates a new pricing handler
+func NewPricingHandler(cartPricingUsecase *application.CartPricingUsecase, priceHistoryUsecase *application.PriceHistoryUsecase, marginUsecase *application.MarginUsecase, logger *logrus.Logger) *PricingHandler {
+	return &PricingHandler{
+		cartPricingUsecase:  cartPricingUsecase,
+		priceHistoryUsecase: priceHistoryUsecase,
+		marginUsecase:       marginUsecase,
+		logger:              logger,
+	}
+}
+
+// PriceCart prices the lines of a cart for a customer
+func (h *PricingHandler) PriceCart(c *gin.Context) {
+	var req application.PriceCartRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	cart, err := h.cartPricingUsecase.PriceCart(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to price cart")
+		return
+	}
+
+	c.JSON(http.StatusOK, cart)
+}
+
+// SchedulePriceChange schedules a change of a product's base price
+func (h *PricingHandler) SchedulePriceChange(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	var req application.SchedulePriceChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ChangedBy, ok = changedBy(c, req.ChangedBy); !ok {
+		return
+	}
+	req.ApprovedBy = marginApprover(c)
+
+	change, err := h.priceHistoryUsecase.SchedulePriceChange(c.Request.Context(), productID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to schedule price change")
+		return
+	}
+
+	c.JSON(http.StatusCreated, change)
+}
+
+// ListScheduledPriceChanges lists the scheduled price changes of a product
+func (h *PricingHandler) ListScheduledPriceChanges(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	changes, err := h.priceHistoryUsecase.ListScheduledChanges(c.Request.Context(), productID)
+	if err != nil {
+		h.respondError(c, err, "Failed to list scheduled price changes")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"scheduled_changes": changes})
+}
+
+// CancelScheduledPriceChange cancels a price change that has not taken effect yet
+func (h *PricingHandler) CancelScheduledPriceChange(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+	changeID, err := uuid.Parse(c.Param("change_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scheduled price change ID"})
+		return
+	}
+
+	var req application.CancelScheduledPriceChangeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.ChangedBy, ok = changedBy(c, req.ChangedBy); !ok {
+		return
+	}
+
+	change, err := h.priceHistoryUsecase.CancelScheduledChange(c.Request.Context(), productID, changeID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to cancel scheduled price change")
+		return
+	}
+
+	c.JSON(http.StatusOK, change)
+}
+
+// GetPriceHistory lists the base price changes of a product, newest first
+func (h *PricingHandler) GetPriceHistory(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	history, err := h.priceHistoryUsecase.GetPriceHistory(c.Request.Context(), productID, limit)
+	if err != nil {
+		h.respondError(c, err, "Failed to get price history")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"history": history})
+}
+
+// GetPriceAsOf returns the base price a product had at the time given by the at parameter
+func (h *PricingHandler) GetPriceAsOf(c *gin.Context) {
+	productID, ok := h.productID(c)
+	if !ok {
+		return
+	}
+
+	at, err := time.Parse(time.RFC3339, c.Query("at"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at must be an RFC 3339 time, e.g. 2025-01-31T14:30:00+07:00"})
+		return
+	}
+
+	price, err := h.priceHistoryUsecase.GetPriceAsOf(c.Request.Context(), productID, at)
+	if err != nil {
+		h.respondError(c, err, "Failed to get price")
+		return
+	}
+
+	c.JSON(http.StatusOK, price)
+}
+
+// CheckMargins checks unit prices against the cost and minimum margins of their products
+func (h *PricingHandler) CheckMargins(c *gin.Context) {
+	var req application.CheckMarginsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	checks, err := h.marginUsecase.CheckMargins(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to check margins")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"checks": checks})
+}
+
+// ListMarginPolicies lists the minimum margins of the categories
+func (h *PricingHandler) ListMarginPolicies(c *gin.Context) {
+	policies, err := h.marginUsecase.ListPolicies(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to list margin policies")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"policies": policies})
+}
+
+// SetMarginPolicy sets the minimum margin of a category
+func (h *PricingHandler) SetMarginPolicy(c *gin.Context) {
+	categoryID, ok := h.categoryID(c)
+	if !ok {
+		return
+	}
+
+	var req application.SetMarginPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.marginUsecase.SetPolicy(c.Request.Context(), categoryID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to set margin policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeleteMarginPolicy removes the minimum margin of a category
+func (h *PricingHandler) DeleteMarginPolicy(c *gin.Context) {
+	categoryID, ok := h.categoryID(c)
+	if !ok {
+		return
+	}
+
+	if err := h.marginUsecase.DeletePolicy(c.Request.Context(), categoryID); err != nil {
+		h.respondError(c, err, "Failed to delete margin policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Margin policy deleted successfully"})
+}
+
+// GetMarginReport lists the promotional and VIP prices under the margin target of their product
+func (h *PricingHandler) GetMarginReport(c *gin.Context) {
+	report, err := h.marginUsecase.MarginReport(c.Request.Context())
+	if err != nil {
+		h.respondError(c, err, "Failed to build margin report")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// productID parses the product ID path parameter
+func (h *PricingHandler) productID(c *gin.Context) (uuid.UUID, bool) {
+	productID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid product ID"})
+		return uuid.Nil, false
+	}
+	return productID, true
+}
+
+// categoryID parses the category ID path parameter
+func (h *PricingHandler) categoryID(c *gin.Context) (uuid.UUID, bool) {
+	categoryID, err := uuid.Parse(c.Param("category_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid category ID"})
+		return uuid.Nil, false
+	}
+	return categoryID, true
+}
+
+// respondError maps pricing errors to a status and a code clients can act on
+func (h *PricingHandler) respondError(c *gin.Context, err error, message string) {
+	if respondMarginApproval(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, entity.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "PRODUCT_NOT_FOUND"})
+	case errors.Is(err, entity.ErrProductNotAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "PRODUCT_NOT_AVAILABLE"})
+	case errors.Is(err, entity.ErrVIPAccessRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "VIP_ACCESS_REQUIRED"})
+	case errors.Is(err, entity.ErrInvalidVIPLevel), errors.Is(err, entity.ErrInvalidPricingData):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_PRICING_REQUEST"})
+	case errors.Is(err, entity.ErrInvalidPriceChange):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_PRICE_CHANGE"})
+	case errors.Is(err, entity.ErrScheduledPriceChangeNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "SCHEDULED_PRICE_CHANGE_NOT_FOUND"})
+	case errors.Is(err, entity.ErrScheduledPriceChangeNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "SCHEDULED_PRICE_CHANGE_NOT_PENDING"})
+	case errors.Is(err, entity.ErrNoPriceAsOf):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "NO_PRICE_AS_OF"})
+	case errors.Is(err, entity.ErrCategoryNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "CATEGORY_NOT_FOUND"})
+	case errors.Is(err, entity.ErrInvalidMarginPolicy):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_MARGIN_POLICY"})
+	default:
+		h.logger.WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
+
+// respondMarginApproval answers a price that needs a manager's approval with the margin checks
+// that failed, and reports whether the error was one
+func respondMarginApproval(c *gin.Context, err error) bool {
+	var approvalErr *entity.MarginApprovalError
+	if !errors.As(err, &approvalErr) {
+		return false
+	}
+	c.JSON(http.StatusUnprocessableEntity, gin.H{
+		"error":  err.Error(),
+		"code":   "MARGIN_APPROVAL_REQUIRED",
+		"checks": approvalErr.Checks,
+	})
+	return true
+}
+
+// marginApprover returns the caller as the approver of a price below cost or the minimum margin
+// when they hold the permission to approve one, and no one otherwise
+func marginApprover(c *gin.Context) string {
+	user, ok := middleware.CurrentUser(c)
+	if !ok || !middleware.HasPermission(user, middleware.PermissionApproveBelowMargin) {
+		return ""
+	}
+	return user.ID
+}
+
+// changedBy returns who makes a change: the caller when they are authenticated, and the
+// changed_by of the request for services calling without a token. A changed_by naming anyone
+// but the caller is refused, and false is returned once the response is written.
+func changedBy(c *gin.Context, requested string) (string, bool) {
+	user, ok := middleware.CurrentUser(c)
+	if !ok {
+		return requested, true
+	}
+	if requested != "" && requested != user.ID {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": "changed_by must be the authenticated user",
+			"code":  "CHANGED_BY_MISMATCH",
+		})
+		return "", false
+	}
+	return user.ID, true
+}
@@ -1,12 +1,14 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
 	"product/internal/application"
 	"product/internal/domain/entity"
 	"product/internal/domain/repository"
+	"product/internal/transport/http/middleware"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
@@ -34,8 +36,12 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	req.ApprovedBy = marginApprover(c)
 
 	product, err := h.productUsecase.CreateProduct(c.Request.Context(), &req)
+	if respondMarginApproval(c, err) {
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to create product")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create product"})
@@ -101,6 +107,31 @@ func (h *ProductHandler) GetProducts(c *gin.Context) {
 		}
 	}
 
+	if isAvailableStr := c.Query("is_available"); isAvailableStr != "" {
+		if isAvailable, err := strconv.ParseBool(isAvailableStr); err == nil {
+			filter.IsAvailable = &isAvailable
+		}
+	}
+
+	// Products are VIP gated unless staff allowed to see every product opt out with
+	// include_vip=true. Without a vip_level the list is what a customer who is not a VIP member
+	// can buy.
+	vipLevel := c.Query("vip_level")
+	if vipLevel != "" && !entity.IsValidVIPLevel(vipLevel) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid VIP level"})
+		return
+	}
+	includeVIP, _ := strconv.ParseBool(c.Query("include_vip"))
+	if includeVIP {
+		user, _ := middleware.CurrentUser(c)
+		if !middleware.HasPermission(user, middleware.PermissionListAllProducts) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Listing VIP gated products requires " + middleware.PermissionListAllProducts})
+			return
+		}
+	} else {
+		filter.VIPLevel = &vipLevel
+	}
+
 	// Handle search
 	query := c.Query("search")
 	var products []*entity.Product
@@ -135,8 +166,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	var ok bool
+	if req.ChangedBy, ok = changedBy(c, req.ChangedBy); !ok {
+		return
+	}
+	req.ApprovedBy = marginApprover(c)
 
 	product, err := h.productUsecase.UpdateProduct(c.Request.Context(), id, &req)
+	if errors.Is(err, entity.ErrInvalidPriceChange) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if respondMarginApproval(c, err) {
+		return
+	}
 	if err != nil {
 		h.logger.WithError(err).Error("Failed to update product")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product"})
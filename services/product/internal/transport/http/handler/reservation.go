@@ -0,0 +1,161 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"product/internal/application"
+	"product/internal/domain/entity"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/sirupsen/logrus"
+)
+
+// ReservationHandler handles stock reservation HTTP requests
+type ReservationHandler struct {
+	reservationUsecase *application.ReservationUsecase
+	logger             *logrus.Logger
+}
+
+// NewReservationHandler creates a new reservation handler
+func NewReservationHandler(reservationUsecase *application.ReservationUsecase, logger *logrus.Logger) *ReservationHandler {
+	return &ReservationHandler{
+		reservationUsecase: reservationUsecase,
+		logger:             logger,
+	}
+}
+
+// ReserveStock reserves stock for an order
+func (h *ReservationHandler) ReserveStock(c *gin.Context) {
+	var req application.ReserveOrderStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reservations, err := h.reservationUsecase.ReserveStock(c.Request.Context(), &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to reserve stock")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"reservations": reservations})
+}
+
+// GetOrderReservations retrieves the stock reservations of an order
+func (h *ReservationHandler) GetOrderReservations(c *gin.Context) {
+	orderID, ok := h.orderID(c)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.reservationUsecase.GetOrderReservations(c.Request.Context(), orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to get stock reservations")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
+
+// ConsumeStock deducts the stock reserved for a confirmed order
+func (h *ReservationHandler) ConsumeStock(c *gin.Context) {
+	orderID, ok := h.orderID(c)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.reservationUsecase.ConsumeStock(c.Request.Context(), orderID)
+	if err != nil {
+		h.respondError(c, err, "Failed to consume stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
+
+// ReleaseStock returns the stock reserved for an order
+func (h *ReservationHandler) ReleaseStock(c *gin.Context) {
+	orderID, ok := h.orderID(c)
+	if !ok {
+		return
+	}
+
+	// The body is optional
+	var req application.ReleaseOrderStockRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	reservations, err := h.reservationUsecase.ReleaseStock(c.Request.Context(), orderID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to release stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"reservations": reservations})
+}
+
+// RestockStock puts the stock of returned items back
+func (h *ReservationHandler) RestockStock(c *gin.Context) {
+	orderID, ok := h.orderID(c)
+	if !ok {
+		return
+	}
+
+	var req application.RestockOrderStockRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	returns, err := h.reservationUsecase.RestockStock(c.Request.Context(), orderID, &req)
+	if err != nil {
+		h.respondError(c, err, "Failed to restock returned stock")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"returns": returns})
+}
+
+func (h *ReservationHandler) orderID(c *gin.Context) (uuid.UUID, bool) {
+	orderID, err := uuid.Parse(c.Param("order_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid order ID"})
+		return uuid.Nil, false
+	}
+	return orderID, true
+}
+
+// respondError maps reservation errors to a status and a code the order service can act on
+func (h *ReservationHandler) respondError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, entity.ErrInsufficientStock):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "INSUFFICIENT_STOCK"})
+	case errors.Is(err, entity.ErrLaunchAllocationExhausted):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "LAUNCH_ALLOCATION_EXHAUSTED"})
+	case errors.Is(err, entity.ErrProductNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "PRODUCT_NOT_FOUND"})
+	case errors.Is(err, entity.ErrProductNotAvailable):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "PRODUCT_NOT_AVAILABLE"})
+	case errors.Is(err, entity.ErrVIPAccessRequired):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error(), "code": "VIP_ACCESS_REQUIRED"})
+	case errors.Is(err, entity.ErrInvalidVIPLevel):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error(), "code": "INVALID_VIP_LEVEL"})
+	case errors.Is(err, entity.ErrReservationNotActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "RESERVATION_NOT_ACTIVE"})
+	case errors.Is(err, entity.ErrIdempotencyKeyReused):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "IDEMPOTENCY_KEY_REUSED"})
+	case errors.Is(err, entity.ErrReturnExceedsConsumed):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error(), "code": "RETURN_EXCEEDS_CONSUMED"})
+	case errors.Is(err, entity.ErrReservationNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error(), "code": "RESERVATION_NOT_FOUND"})
+	default:
+		h.logger.WithError(err).Error(message)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+	}
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// Role represents the role of a staff user, as issued by the user service
+type Role string
+
+const (
+	RoleSales   Role = "sales"
+	RoleManager Role = "manager"
+	RoleAdmin   Role = "admin"
+)
+
+const (
+	// PermissionApproveBelowMargin lets a user set prices below cost or the minimum margin
+	PermissionApproveBelowMargin = "products:approve_below_margin"
+	// PermissionListAllProducts lets a user list VIP only and early access products without VIP
+	// gating
+	PermissionListAllProducts = "products:list_all"
+)
+
+// User represents the authenticated caller of a request
+type User struct {
+	ID          string   `json:"id"`
+	Email       string   `json:"email"`
+	Name        string   `json:"name"`
+	Role        Role     `json:"role"`
+	Permissions []string `json:"permissions"`
+}
+
+// AuthConfig holds token verification configuration
+type AuthConfig struct {
+	// JWKSURL is where the user service publishes its token signing keys
+	JWKSURL string
+	// Issuer and Audience must match the iss and aud claims of every token
+	Issuer   string
+	Audience string
+	// KeyRefreshInterval is how often the signing keys are fetched again. Defaults to 1 hour.
+	KeyRefreshInterval time.Duration
+	Logger             *logrus.Logger
+}
+
+// Authenticate identifies the caller from a bearer token. Requests without a token go through
+// without a user, since services call the product service too; a token that does not verify
+// is refused rather than ignored.
+func Authenticate(config *AuthConfig) gin.HandlerFunc {
+	refreshInterval := config.KeyRefreshInterval
+	if refreshInterval <= 0 {
+		refreshInterval = time.Hour
+	}
+	keys := newKeySet(config.JWKSURL, refreshInterval)
+
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok || token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization header format", "code": "INVALID_TOKEN"})
+			return
+		}
+
+		claims, err := parseToken(c.Request.Context(), keys, token, config.Issuer, config.Audience, time.Now())
+		if err != nil {
+			config.Logger.WithError(err).Warn("Token verification failed")
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token", "code": "INVALID_TOKEN"})
+			return
+		}
+
+		user := &User{
+			ID:          claims.Subject,
+			Email:       claims.Email,
+			Name:        claims.Name,
+			Role:        claims.Role,
+			Permissions: claims.Permissions,
+		}
+		if len(user.Permissions) == 0 {
+			user.Permissions = RolePermissions(user.Role)
+		}
+		c.Set("user", user)
+		c.Next()
+	}
+}
+
+// CurrentUser returns the user authenticated for the request
+func CurrentUser(c *gin.Context) (*User, bool) {
+	value, ok := c.Get("user")
+	if !ok {
+		return nil, false
+	}
+	user, ok := value.(*User)
+	return user, ok
+}
+
+// HasPermission reports whether the user holds a permission, directly or through a wildcard
+// such as "products:*" or "*"
+func HasPermission(user *User, permission string) bool {
+	if user == nil {
+		return false
+	}
+	resource, _, _ := strings.Cut(permission, ":")
+	for _, held := range user.Permissions {
+		if held == permission || held == "*" || held == resource+":*" {
+			return true
+		}
+	}
+	return false
+}
+
+// RolePermissions returns the product permissions of a role whose token lists none
+func RolePermissions(role Role) []string {
+	switch role {
+	case RoleManager:
+		return []string{PermissionApproveBelowMargin}
+	case RoleAdmin:
+		return []string{"products:*"}
+	default:
+		return nil
+	}
+}
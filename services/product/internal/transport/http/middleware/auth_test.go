@@ -0,0 +1,63 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name string
+		user *User
+		want bool
+	}{
+		{"no caller", nil, false},
+		{"granted directly", &User{Permissions: []string{PermissionApproveBelowMargin}}, true},
+		{"resource wildcard", &User{Permissions: []string{"products:*"}}, true},
+		{"global wildcard", &User{Permissions: []string{"*"}}, true},
+		{"other resource", &User{Permissions: []string{"orders:approve_below_margin", "orders:*"}}, false},
+		{"sales role", &User{Role: RoleSales, Permissions: RolePermissions(RoleSales)}, false},
+		{"manager role", &User{Role: RoleManager, Permissions: RolePermissions(RoleManager)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.user, PermissionApproveBelowMargin); got != tt.want {
+				t.Errorf("HasPermission = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKeySetBacksOffWhileJWKSIsDown(t *testing.T) {
+	var fetches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&fetches, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, 10, 17, 9, 0, 0, 0, time.UTC)
+	keys := newKeySet(server.URL, time.Hour)
+	keys.now = func() time.Time { return now }
+
+	// Every unknown kid within the backoff gets the outcome of the one failed fetch
+	for _, kid := range []string{"a", "b", "c"} {
+		if _, err := keys.key(context.Background(), kid); err == nil || errors.Is(err, errUnknownKey) {
+			t.Fatalf("kid %s: got %v, want the fetch error", kid, err)
+		}
+	}
+	if got := atomic.LoadInt32(&fetches); got != 1 {
+		t.Fatalf("fetched %d times within the backoff, want 1", got)
+	}
+
+	now = now.Add(keys.minRefetch)
+	keys.key(context.Background(), "d")
+	if got := atomic.LoadInt32(&fetches); got != 2 {
+		t.Errorf("fetched %d times after the backoff, want 2", got)
+	}
+}
@@ -0,0 +1,32 @@
+-- Stock reservations held for orders until they are confirmed, cancelled or expire
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key VARCHAR(200) NOT NULL,
+    order_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    location_id UUID NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL CHECK (quantity > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'consumed', 'released', 'expired')),
+    release_reason TEXT,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    consumed_at TIMESTAMP WITH TIME ZONE,
+    released_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- A retried reservation can never hold the same product twice
+    UNIQUE(idempotency_key, product_id, location_id)
+);
+
+ALTER TABLE stock_reservations
+ADD CONSTRAINT fk_stock_reservations_product
+FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_order_id ON stock_reservations(order_id);
+CREATE INDEX IF NOT EXISTS idx_stock_reservations_active_expiry
+    ON stock_reservations(expires_at) WHERE status = 'active';
+
+CREATE TRIGGER update_stock_reservations_updated_at
+    BEFORE UPDATE ON stock_reservations
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
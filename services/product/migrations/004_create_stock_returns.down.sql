@@ -0,0 +1,4 @@
+-- Drop stock returns
+DROP INDEX IF EXISTS idx_stock_returns_order_id;
+
+DROP TABLE IF EXISTS stock_returns;
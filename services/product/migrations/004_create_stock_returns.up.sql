@@ -0,0 +1,20 @@
+-- Stock put back from returned orders at the locations their reservations were consumed from
+CREATE TABLE IF NOT EXISTS stock_returns (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key VARCHAR(200) NOT NULL,
+    order_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    location_id UUID NOT NULL,
+    quantity DECIMAL(10,3) NOT NULL CHECK (quantity > 0),
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    -- A retried restock can never put the same product back twice
+    UNIQUE(idempotency_key, product_id, location_id)
+);
+
+ALTER TABLE stock_returns
+ADD CONSTRAINT fk_stock_returns_product
+FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+CREATE INDEX IF NOT EXISTS idx_stock_returns_order_id ON stock_returns(order_id);
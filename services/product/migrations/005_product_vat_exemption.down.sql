@@ -0,0 +1,2 @@
+-- Drop product VAT exemption
+ALTER TABLE products DROP COLUMN IF EXISTS is_vat_exempt;
@@ -0,0 +1,2 @@
+-- Products sold without VAT, such as fresh produce; orders leave them out of the taxable amount
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_vat_exempt BOOLEAN DEFAULT false;
@@ -0,0 +1,11 @@
+-- Drop cart pricing constraints
+DROP INDEX IF EXISTS idx_vip_benefits_active_level;
+DROP INDEX IF EXISTS idx_customer_group_pricing_active;
+
+ALTER TABLE customer_group_pricing DROP CONSTRAINT IF EXISTS customer_group_pricing_product_id_fkey;
+ALTER TABLE customer_group_pricing
+    ADD CONSTRAINT customer_group_pricing_product_id_fkey FOREIGN KEY (product_id) REFERENCES products_enhanced(id) ON DELETE CASCADE;
+
+ALTER TABLE product_pricing_tiers DROP CONSTRAINT IF EXISTS product_pricing_tiers_product_id_fkey;
+ALTER TABLE product_pricing_tiers
+    ADD CONSTRAINT product_pricing_tiers_product_id_fkey FOREIGN KEY (product_id) REFERENCES products_enhanced(id) ON DELETE CASCADE;
@@ -0,0 +1,14 @@
+-- Cart pricing prices the products orders are placed for, so tiers and group pricing belong to them
+ALTER TABLE product_pricing_tiers DROP CONSTRAINT IF EXISTS product_pricing_tiers_product_id_fkey;
+ALTER TABLE product_pricing_tiers
+    ADD CONSTRAINT product_pricing_tiers_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+ALTER TABLE customer_group_pricing DROP CONSTRAINT IF EXISTS customer_group_pricing_product_id_fkey;
+ALTER TABLE customer_group_pricing
+    ADD CONSTRAINT customer_group_pricing_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) ON DELETE CASCADE;
+
+-- A product has one active price per customer group and a VIP level one set of active benefits
+CREATE UNIQUE INDEX IF NOT EXISTS idx_customer_group_pricing_active
+    ON customer_group_pricing(product_id, customer_group) WHERE is_active;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vip_benefits_active_level
+    ON vip_pricing_benefits(vip_level) WHERE is_active;
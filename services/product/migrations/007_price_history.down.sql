@@ -0,0 +1,10 @@
+-- Drop scheduled price changes and the price history
+DROP TRIGGER IF EXISTS update_scheduled_price_changes_updated_at ON scheduled_price_changes;
+DROP INDEX IF EXISTS idx_scheduled_price_changes_pending_due;
+DROP INDEX IF EXISTS idx_scheduled_price_changes_product_id;
+DROP TABLE IF EXISTS scheduled_price_changes;
+
+DROP TRIGGER IF EXISTS price_history_append_only ON price_history;
+DROP FUNCTION IF EXISTS prevent_price_history_change();
+DROP INDEX IF EXISTS idx_price_history_product_effective;
+DROP TABLE IF EXISTS price_history;
@@ -0,0 +1,55 @@
+-- Every change of a product's base price, kept so disputes can look up what a product cost.
+-- There is no foreign key: the history outlives deleted products.
+CREATE TABLE IF NOT EXISTS price_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL,
+    old_price DECIMAL(10,2) NOT NULL,
+    new_price DECIMAL(10,2) NOT NULL CHECK (new_price >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'THB',
+    effective_from TIMESTAMP WITH TIME ZONE NOT NULL,
+    source VARCHAR(20) NOT NULL CHECK (source IN ('manual', 'scheduled', 'loyverse_sync')),
+    changed_by VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    scheduled_change_id UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_history_product_effective ON price_history(product_id, effective_from);
+
+-- The history is append-only
+CREATE OR REPLACE FUNCTION prevent_price_history_change()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'price history cannot be changed';
+END;
+$$ language 'plpgsql';
+
+CREATE TRIGGER price_history_append_only
+    BEFORE UPDATE OR DELETE ON price_history
+    FOR EACH ROW EXECUTE FUNCTION prevent_price_history_change();
+
+-- Base price changes that take effect later
+CREATE TABLE IF NOT EXISTS scheduled_price_changes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    new_price DECIMAL(10,2) NOT NULL CHECK (new_price >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'THB',
+    effective_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'applied', 'cancelled')),
+    requested_by VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    applied_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    cancelled_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_scheduled_price_changes_product_id ON scheduled_price_changes(product_id);
+CREATE INDEX IF NOT EXISTS idx_scheduled_price_changes_pending_due
+    ON scheduled_price_changes(effective_at) WHERE status = 'pending';
+
+CREATE TRIGGER update_scheduled_price_changes_updated_at
+    BEFORE UPDATE ON scheduled_price_changes
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
@@ -0,0 +1,9 @@
+-- Drop the margin guardrails
+ALTER TABLE scheduled_price_changes DROP COLUMN IF EXISTS approved_by;
+ALTER TABLE price_history DROP COLUMN IF EXISTS approved_by;
+
+DROP TRIGGER IF EXISTS update_category_margin_policies_updated_at ON category_margin_policies;
+DROP TABLE IF EXISTS category_margin_policies;
+
+ALTER TABLE products DROP COLUMN IF EXISTS profit_margin_target;
+ALTER TABLE products DROP COLUMN IF EXISTS cost_price;
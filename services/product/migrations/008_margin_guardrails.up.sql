@@ -0,0 +1,22 @@
+-- What a product costs and the margin it should make, as a percentage of the selling price
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price DECIMAL(10,2) CHECK (cost_price >= 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS profit_margin_target DECIMAL(5,2)
+    CHECK (profit_margin_target >= 0 AND profit_margin_target < 100);
+
+-- The minimum margin products of a category may be priced at without a manager's approval.
+-- Categories without a policy use MARGIN_DEFAULT_MIN_PERCENT.
+CREATE TABLE IF NOT EXISTS category_margin_policies (
+    category_id UUID PRIMARY KEY REFERENCES categories(id) ON DELETE CASCADE,
+    min_margin_percent DECIMAL(5,2) NOT NULL CHECK (min_margin_percent >= 0 AND min_margin_percent < 100),
+    updated_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TRIGGER update_category_margin_policies_updated_at
+    BEFORE UPDATE ON category_margin_policies
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- The manager who approved a price below cost or the minimum margin
+ALTER TABLE price_history ADD COLUMN IF NOT EXISTS approved_by VARCHAR(100);
+ALTER TABLE scheduled_price_changes ADD COLUMN IF NOT EXISTS approved_by VARCHAR(100);
@@ -0,0 +1,16 @@
+-- Drop the availability schedules
+DROP INDEX IF EXISTS idx_product_availability_log_product;
+
+-- The log keeps entries of products that are not in products_enhanced, so the foreign key is
+-- only checked for new entries
+ALTER TABLE product_availability_log ADD CONSTRAINT product_availability_log_product_id_fkey
+    FOREIGN KEY (product_id) REFERENCES products_enhanced(id) ON DELETE CASCADE NOT VALID;
+
+DROP INDEX IF EXISTS idx_products_availability_rules;
+
+ALTER TABLE products DROP COLUMN IF EXISTS inactive_by_schedule;
+ALTER TABLE products DROP COLUMN IF EXISTS inactive_schedule;
+ALTER TABLE products DROP COLUMN IF EXISTS auto_reactivate;
+ALTER TABLE products DROP COLUMN IF EXISTS inactive_until;
+ALTER TABLE products DROP COLUMN IF EXISTS inactive_reason;
+ALTER TABLE products DROP COLUMN IF EXISTS is_admin_active;
@@ -0,0 +1,21 @@
+-- Availability set by admins on top of the Loyverse status: a product can be taken off sale
+-- until a time, and a schedule of recurring days and hours takes it on and off sale
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_admin_active BOOLEAN NOT NULL DEFAULT true;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS inactive_reason VARCHAR(200);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS inactive_until TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS auto_reactivate BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS inactive_schedule JSONB;
+-- Whether the schedule took the product off sale, so it only puts back what it took off
+ALTER TABLE products ADD COLUMN IF NOT EXISTS inactive_by_schedule BOOLEAN NOT NULL DEFAULT false;
+
+-- The products the availability scheduler visits
+CREATE INDEX IF NOT EXISTS idx_products_availability_rules ON products(id)
+    WHERE inactive_schedule IS NOT NULL OR auto_reactivate;
+
+-- The availability log was written for products_enhanced and now records the products on sale.
+-- It is an audit trail, so product_id is left unconstrained: the history of products_enhanced
+-- rows is kept, and so is the history of products deleted later.
+ALTER TABLE product_availability_log DROP CONSTRAINT IF EXISTS product_availability_log_product_id_fkey;
+
+CREATE INDEX IF NOT EXISTS idx_product_availability_log_product
+    ON product_availability_log(product_id, created_at DESC);
@@ -0,0 +1,10 @@
+-- Drop the VIP launches
+ALTER TABLE stock_reservations DROP COLUMN IF EXISTS launch_allocation_id;
+
+DROP TABLE IF EXISTS product_launch_allocations;
+DROP TRIGGER IF EXISTS update_product_launches_updated_at ON product_launches;
+DROP TABLE IF EXISTS product_launches;
+
+ALTER TABLE products DROP COLUMN IF EXISTS early_access_until;
+ALTER TABLE products DROP COLUMN IF EXISTS vip_early_access;
+ALTER TABLE products DROP COLUMN IF EXISTS min_vip_level;
@@ -0,0 +1,55 @@
+-- VIP gating of the products on sale. The minimum level applies while a product is VIP only or
+-- in early access; early access ends at early_access_until.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_vip_level VARCHAR(20)
+    CHECK (min_vip_level IN ('bronze', 'silver', 'gold', 'platinum', 'diamond'));
+ALTER TABLE products ADD COLUMN IF NOT EXISTS vip_early_access BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS early_access_until TIMESTAMP WITH TIME ZONE;
+
+-- Launches open a product to VIP levels first and to everyone at public_at
+CREATE TABLE IF NOT EXISTS product_launches (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    product_id UUID NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    min_vip_level VARCHAR(20)
+        CHECK (min_vip_level IN ('bronze', 'silver', 'gold', 'platinum', 'diamond')),
+    early_access_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    public_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    message TEXT NOT NULL DEFAULT '',
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled'
+        CHECK (status IN ('scheduled', 'early_access', 'released', 'cancelled')),
+    notified_count INTEGER NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP WITH TIME ZONE,
+    released_at TIMESTAMP WITH TIME ZONE,
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    cancelled_by VARCHAR(100),
+    created_by VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+
+    CHECK (early_access_at < public_at)
+);
+
+-- A product has at most one launch that is yet to be released
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_launches_pending_product
+    ON product_launches(product_id) WHERE status IN ('scheduled', 'early_access');
+CREATE INDEX IF NOT EXISTS idx_product_launches_pending_due
+    ON product_launches(early_access_at, public_at) WHERE status IN ('scheduled', 'early_access');
+
+CREATE TRIGGER update_product_launches_updated_at
+    BEFORE UPDATE ON product_launches
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- How much of a launched product each VIP level may reserve during early access
+CREATE TABLE IF NOT EXISTS product_launch_allocations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    launch_id UUID NOT NULL REFERENCES product_launches(id) ON DELETE CASCADE,
+    vip_level VARCHAR(20) NOT NULL
+        CHECK (vip_level IN ('bronze', 'silver', 'gold', 'platinum', 'diamond')),
+    quota DECIMAL(10,3) NOT NULL CHECK (quota > 0),
+    claimed DECIMAL(10,3) NOT NULL DEFAULT 0 CHECK (claimed >= 0 AND claimed <= quota),
+
+    UNIQUE(launch_id, vip_level)
+);
+
+-- The allocation a reservation made during early access counts against
+ALTER TABLE stock_reservations ADD COLUMN IF NOT EXISTS launch_allocation_id UUID
+    REFERENCES product_launch_allocations(id) ON DELETE SET NULL;
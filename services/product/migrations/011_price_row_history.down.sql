@@ -0,0 +1,4 @@
+-- Drop the price row history
+DROP TRIGGER IF EXISTS price_row_history_append_only ON price_row_history;
+DROP INDEX IF EXISTS idx_price_row_history_product;
+DROP TABLE IF EXISTS price_row_history;
@@ -0,0 +1,22 @@
+-- Every change of a VIP, bulk or promotional price row, with who made it and why. Like the base
+-- price history there is no foreign key, so the history outlives deleted rows and products.
+CREATE TABLE IF NOT EXISTS price_row_history (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    price_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    price_type VARCHAR(50) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('created', 'updated', 'deleted')),
+    old_row JSONB,
+    new_row JSONB,
+    changed_by VARCHAR(100) NOT NULL,
+    reason TEXT NOT NULL,
+    approved_by VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_price_row_history_product ON price_row_history(product_id, created_at DESC);
+
+-- The history is append-only
+CREATE TRIGGER price_row_history_append_only
+    BEFORE UPDATE OR DELETE ON price_row_history
+    FOR EACH ROW EXECUTE FUNCTION prevent_price_history_change();
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 
 	"shipping/internal/infrastructure/config"
@@ -53,13 +54,23 @@ func main() {
 		deliveryRepo, vehicleRepo, routeRepo, providerRepo, 
 		snapshotRepo, coverageRepo, eventPublisher, cacheClient)
 	vehicleUseCase := application.NewVehicleUseCase(vehicleRepo, eventPublisher)
+	routingUseCase := application.NewRoutingUseCase(
+		routeRepo, deliveryRepo, vehicleRepo, coverageRepo, database.NewTxManager(db), eventPublisher, cacheClient)
+
+	trackingUseCase := application.NewTrackingUseCase(deliveryRepo, snapshotRepo, eventPublisher, cacheClient)
+	providerUpdateUseCase := application.NewProviderUpdateUseCase(
+		deliveryRepo, snapshotRepo, deliveryUseCase, trackingUseCase)
 
 	// Create placeholder use cases for compilation
 	providerUseCase := &application.ProviderUseCase{}
-	routingUseCase := &application.RoutingUseCase{}
-	trackingUseCase := &application.TrackingUseCase{}
 	coverageUseCase := &application.CoverageUseCase{}
 
+	// Consume Grab and LINE MAN updates relayed by delivery-webhook
+	providerConsumer := events.NewProviderConsumer(
+		cfg.KafkaBrokers, cfg.ProviderUpdatesTopic, cfg.KafkaGroupID, providerUpdateUseCase)
+	defer providerConsumer.Close()
+	go providerConsumer.Start(context.Background())
+
 	// Initialize HTTP server
 	server := http.NewServer(
 		cfg.ServerPort,
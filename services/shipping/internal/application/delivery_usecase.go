@@ -147,6 +147,50 @@ func (uc *DeliveryUsecase) GetDelivery(ctx context.Context, deliveryID uuid.UUID
 	return delivery, nil
 }
 
+// CancelDelivery cancels a delivery that has not been dispatched, for instance one whose order
+// could not be saved after booking it
+func (uc *DeliveryUsecase) CancelDelivery(ctx context.Context, deliveryID uuid.UUID, reason string) error {
+	delivery, err := uc.deliveryRepo.GetByID(ctx, deliveryID)
+	if err != nil {
+		return fmt.Errorf("failed to get delivery: %w", err)
+	}
+	if delivery.Status == entity.DeliveryStatusCancelled {
+		return nil
+	}
+
+	oldStatus := delivery.Status
+	if err := delivery.Cancel(); err != nil {
+		return err
+	}
+	if reason != "" {
+		delivery.Notes = &reason
+	}
+	if err := uc.deliveryRepo.Update(ctx, delivery); err != nil {
+		return fmt.Errorf("failed to update delivery: %w", err)
+	}
+
+	if err := uc.createDeliverySnapshot(ctx, delivery, entity.SnapshotTypeStatusUpdated, "delivery_cancelled", "system_auto", nil); err != nil {
+		// Log error but don't fail the operation
+		// TODO: Add proper logging
+	}
+
+	event := DeliveryStatusUpdatedEvent{
+		DeliveryID: delivery.ID,
+		OrderID:    delivery.OrderID,
+		CustomerID: delivery.CustomerID,
+		OldStatus:  oldStatus,
+		NewStatus:  delivery.Status,
+		UpdatedAt:  delivery.UpdatedAt,
+	}
+	if err := uc.eventPublisher.Publish(ctx, "delivery.status_updated", event); err != nil {
+		// Log error but don't fail the operation
+		// TODO: Add proper logging
+	}
+
+	uc.clearDeliveryCache(ctx, deliveryID)
+	return nil
+}
+
 // UpdateDeliveryStatus updates the status of a delivery
 func (uc *DeliveryUsecase) UpdateDeliveryStatus(ctx context.Context, deliveryID uuid.UUID, status entity.DeliveryStatus, userID *uuid.UUID) error {
 	// 1. Get current delivery
@@ -487,6 +531,11 @@ type EventPublisher interface {
 	Publish(ctx context.Context, topic string, event interface{}) error
 }
 
+// TxManager runs fn in a database transaction that the repositories join through ctx
+type TxManager interface {
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 type Cache interface {
 	Get(ctx context.Context, key string) (string, error)
 	Set(ctx context.Context, key string, value string, ttl time.Duration) error
@@ -0,0 +1,193 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"shipping/internal/domain/entity"
+	"shipping/internal/domain/repository"
+)
+
+// ErrProviderMismatch is returned when a provider reports on a delivery it does not carry
+var ErrProviderMismatch = errors.New("delivery is not handled by this provider")
+
+// ProviderStatusUpdate is a Grab or LINE MAN webhook normalized by delivery-webhook
+type ProviderStatusUpdate struct {
+	EventID         string                  `json:"event_id"`
+	EventType       string                  `json:"event_type"`
+	Provider        string                  `json:"provider"`
+	ProviderOrderID string                  `json:"provider_order_id"`
+	MerchantOrderID string                  `json:"merchant_order_id,omitempty"`
+	ProviderStatus  string                  `json:"provider_status"`
+	FailureReason   string                  `json:"failure_reason,omitempty"`
+	TrackingURL     string                  `json:"tracking_url,omitempty"`
+	Driver          *ProviderDriver         `json:"driver,omitempty"`
+	Location        *ProviderDriverLocation `json:"location,omitempty"`
+	OccurredAt      time.Time               `json:"occurred_at"`
+	ReceivedAt      time.Time               `json:"received_at"`
+}
+
+// ProviderDriver is the rider assigned by the provider
+type ProviderDriver struct {
+	Name         string `json:"name,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	LicensePlate string `json:"license_plate,omitempty"`
+}
+
+// ProviderDriverLocation is a GPS point reported by the provider
+type ProviderDriverLocation struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// ProviderUpdateUseCase applies third-party provider status updates to deliveries
+type ProviderUpdateUseCase struct {
+	deliveryRepo    repository.DeliveryRepository
+	snapshotRepo    repository.SnapshotRepository
+	deliveryUseCase *DeliveryUsecase
+	trackingUseCase *TrackingUseCase
+}
+
+// NewProviderUpdateUseCase creates a new provider update use case
+func NewProviderUpdateUseCase(
+	deliveryRepo repository.DeliveryRepository,
+	snapshotRepo repository.SnapshotRepository,
+	deliveryUseCase *DeliveryUsecase,
+	trackingUseCase *TrackingUseCase,
+) *ProviderUpdateUseCase {
+	return &ProviderUpdateUseCase{
+		deliveryRepo:    deliveryRepo,
+		snapshotRepo:    snapshotRepo,
+		deliveryUseCase: deliveryUseCase,
+		trackingUseCase: trackingUseCase,
+	}
+}
+
+// ApplyProviderUpdate records the provider update in a snapshot, moves the delivery forward
+// when the mapped status advances it, and forwards the driver position to tracking.
+func (uc *ProviderUpdateUseCase) ApplyProviderUpdate(ctx context.Context, update *ProviderStatusUpdate) error {
+	method := entity.DeliveryMethod(update.Provider)
+
+	delivery, err := uc.getProviderDelivery(ctx, method, update)
+	if err != nil {
+		return err
+	}
+	if delivery.DeliveryMethod != method {
+		return fmt.Errorf("%w: delivery %s uses %s, update from %s", ErrProviderMismatch, delivery.ID, delivery.DeliveryMethod, update.Provider)
+	}
+
+	status, mapped := entity.MapProviderStatus(method, update.ProviderStatus)
+	statusChanged := mapped && delivery.Status.Advances(status)
+	finished := delivery.Status.IsFinal() || (statusChanged && status.IsFinal())
+
+	// 1. Keep provider fields the delivery does not have yet
+	if uc.syncProviderFields(delivery, update, statusChanged && status.IsFinal()) {
+		if err := uc.deliveryRepo.Update(ctx, delivery); err != nil {
+			return fmt.Errorf("failed to update delivery: %w", err)
+		}
+	}
+
+	// 2. Record what the provider reported, including updates that do not change the status
+	if err := uc.createProviderSnapshot(ctx, delivery, update, mapped); err != nil {
+		return fmt.Errorf("failed to create provider snapshot: %w", err)
+	}
+
+	// 3. Move the delivery forward
+	if statusChanged {
+		if err := uc.deliveryUseCase.UpdateDeliveryStatus(ctx, delivery.ID, status, nil); err != nil {
+			return fmt.Errorf("failed to update delivery status: %w", err)
+		}
+	}
+
+	// 4. Feed the driver position while the delivery is still on the road
+	if update.Location != nil && !finished {
+		if err := uc.trackingUseCase.UpdateDeliveryLocation(ctx, LocationUpdateRequest{
+			DeliveryID: delivery.ID,
+			CurrentLocation: Location{
+				Latitude:  update.Location.Latitude,
+				Longitude: update.Location.Longitude,
+			},
+			UpdatedBy: update.Provider,
+		}); err != nil {
+			return fmt.Errorf("failed to update delivery location: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// getProviderDelivery finds the delivery by our ID sent as the provider's merchant order ID,
+// falling back to the provider's own order ID
+func (uc *ProviderUpdateUseCase) getProviderDelivery(ctx context.Context, method entity.DeliveryMethod, update *ProviderStatusUpdate) (*entity.DeliveryOrder, error) {
+	if deliveryID, err := uuid.Parse(update.MerchantOrderID); err == nil {
+		if delivery, err := uc.deliveryRepo.GetByID(ctx, deliveryID); err == nil {
+			return delivery, nil
+		}
+	}
+
+	delivery, err := uc.deliveryRepo.GetByProviderOrderID(ctx, method, update.ProviderOrderID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s order %s: %v", repository.ErrDeliveryNotFound, update.Provider, update.ProviderOrderID, err)
+	}
+	return delivery, nil
+}
+
+// syncProviderFields copies the provider order ID and failure reason onto the delivery and
+// reports whether anything changed
+func (uc *ProviderUpdateUseCase) syncProviderFields(delivery *entity.DeliveryOrder, update *ProviderStatusUpdate, final bool) bool {
+	changed := false
+	if delivery.ProviderOrderID == nil && update.ProviderOrderID != "" {
+		providerOrderID := update.ProviderOrderID
+		delivery.ProviderOrderID = &providerOrderID
+		changed = true
+	}
+	if final && update.FailureReason != "" {
+		reason := update.FailureReason
+		delivery.Notes = &reason
+		changed = true
+	}
+	if changed {
+		delivery.UpdatedAt = time.Now()
+	}
+	return changed
+}
+
+func (uc *ProviderUpdateUseCase) createProviderSnapshot(ctx context.Context, delivery *entity.DeliveryOrder, update *ProviderStatusUpdate, mapped bool) error {
+	snapshot, err := entity.NewDeliverySnapshotFromDelivery(
+		delivery,
+		entity.SnapshotTypeProviderUpdated,
+		update.Provider,
+		"provider_webhook",
+		nil,
+		nil,
+	)
+	if err != nil {
+		return err
+	}
+
+	snapshot.SnapshotData["provider_event_id"] = update.EventID
+	snapshot.SnapshotData["provider_status"] = update.ProviderStatus
+	snapshot.SnapshotData["provider_status_mapped"] = mapped
+	snapshot.SnapshotData["provider_occurred_at"] = update.OccurredAt
+	if update.TrackingURL != "" {
+		snapshot.SnapshotData["provider_tracking_url"] = update.TrackingURL
+	}
+	if update.FailureReason != "" {
+		snapshot.SnapshotData["provider_failure_reason"] = update.FailureReason
+	}
+	if update.Driver != nil {
+		snapshot.DriverName = update.Driver.Name
+		snapshot.SnapshotData["driver_name"] = update.Driver.Name
+		snapshot.SnapshotData["driver_phone"] = update.Driver.Phone
+		snapshot.SnapshotData["driver_license_plate"] = update.Driver.LicensePlate
+	}
+	if update.Location != nil {
+		snapshot.SnapshotData["driver_latitude"] = update.Location.Latitude
+		snapshot.SnapshotData["driver_longitude"] = update.Location.Longitude
+	}
+
+	return uc.snapshotRepo.Create(ctx, snapshot)
+}
@@ -0,0 +1,208 @@
+package application
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"shipping/internal/domain/entity"
+	"shipping/internal/domain/repository"
+)
+
+// memoryDeliveries implements the delivery lookups used by provider updates
+type memoryDeliveries struct {
+	repository.DeliveryRepository
+	deliveries map[uuid.UUID]*entity.DeliveryOrder
+}
+
+func (r *memoryDeliveries) GetByID(ctx context.Context, id uuid.UUID) (*entity.DeliveryOrder, error) {
+	delivery, ok := r.deliveries[id]
+	if !ok {
+		return nil, fmt.Errorf("delivery not found: %w", repository.ErrDeliveryNotFound)
+	}
+	copied := *delivery
+	return &copied, nil
+}
+
+func (r *memoryDeliveries) GetByProviderOrderID(ctx context.Context, method entity.DeliveryMethod, providerOrderID string) (*entity.DeliveryOrder, error) {
+	for _, delivery := range r.deliveries {
+		if delivery.DeliveryMethod == method && delivery.ProviderOrderID != nil && *delivery.ProviderOrderID == providerOrderID {
+			copied := *delivery
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrDeliveryNotFound
+}
+
+func (r *memoryDeliveries) Update(ctx context.Context, delivery *entity.DeliveryOrder) error {
+	copied := *delivery
+	r.deliveries[delivery.ID] = &copied
+	return nil
+}
+
+type memorySnapshots struct {
+	repository.SnapshotRepository
+	snapshots []*entity.DeliverySnapshot
+}
+
+func (r *memorySnapshots) Create(ctx context.Context, snapshot *entity.DeliverySnapshot) error {
+	r.snapshots = append(r.snapshots, snapshot)
+	return nil
+}
+
+func (r *memorySnapshots) countTriggeredBy(event string) int {
+	count := 0
+	for _, s := range r.snapshots {
+		if s.TriggeredEvent == event {
+			count++
+		}
+	}
+	return count
+}
+
+type recordingPublisher struct {
+	topics []string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, topic string, event interface{}) error {
+	p.topics = append(p.topics, topic)
+	return nil
+}
+
+func newProviderUpdateFixture(delivery *entity.DeliveryOrder) (*ProviderUpdateUseCase, *memoryDeliveries, *memorySnapshots) {
+	deliveries := &memoryDeliveries{deliveries: map[uuid.UUID]*entity.DeliveryOrder{delivery.ID: delivery}}
+	snapshots := &memorySnapshots{}
+	publisher := &recordingPublisher{}
+	deliveryUseCase := NewDeliveryUsecase(deliveries, nil, nil, nil, snapshots, nil, publisher, nil)
+	trackingUseCase := NewTrackingUseCase(deliveries, snapshots, publisher, nil)
+	return NewProviderUpdateUseCase(deliveries, snapshots, deliveryUseCase, trackingUseCase), deliveries, snapshots
+}
+
+func grabDelivery(status entity.DeliveryStatus) *entity.DeliveryOrder {
+	delivery := entity.NewDeliveryOrder(uuid.New(), uuid.New(), uuid.New(), entity.DeliveryMethodGrab, 80, 0)
+	delivery.Status = status
+	return delivery
+}
+
+func grabUpdate(delivery *entity.DeliveryOrder, status string) *ProviderStatusUpdate {
+	return &ProviderStatusUpdate{
+		EventID:         "grab:IN-2-TEST:" + status,
+		Provider:        "grab",
+		ProviderOrderID: "IN-2-TEST",
+		MerchantOrderID: delivery.ID.String(),
+		ProviderStatus:  status,
+		Driver:          &ProviderDriver{Name: "Somsak K.", LicensePlate: "1กข 2345"},
+		Location:        &ProviderDriverLocation{Latitude: 13.7456, Longitude: 100.5342},
+		OccurredAt:      time.Now(),
+	}
+}
+
+func TestApplyProviderUpdate_AdvancesStatusAndTracksDriver(t *testing.T) {
+	delivery := grabDelivery(entity.DeliveryStatusPlanned)
+	uc, deliveries, snapshots := newProviderUpdateFixture(delivery)
+
+	if err := uc.ApplyProviderUpdate(context.Background(), grabUpdate(delivery, "IN_DELIVERY")); err != nil {
+		t.Fatalf("ApplyProviderUpdate: %v", err)
+	}
+
+	stored := deliveries.deliveries[delivery.ID]
+	if stored.Status != entity.DeliveryStatusInTransit {
+		t.Errorf("status = %s, want in_transit", stored.Status)
+	}
+	if stored.ProviderOrderID == nil || *stored.ProviderOrderID != "IN-2-TEST" {
+		t.Errorf("provider order ID not recorded: %v", stored.ProviderOrderID)
+	}
+	if n := snapshots.countTriggeredBy("provider_webhook"); n != 1 {
+		t.Errorf("provider snapshots = %d, want 1", n)
+	}
+	if n := snapshots.countTriggeredBy("status_update"); n != 1 {
+		t.Errorf("status snapshots = %d, want 1", n)
+	}
+	if n := snapshots.countTriggeredBy("location_updated"); n != 1 {
+		t.Errorf("location snapshots = %d, want 1", n)
+	}
+}
+
+func TestApplyProviderUpdate_IgnoresOutOfOrderStatus(t *testing.T) {
+	delivery := grabDelivery(entity.DeliveryStatusDelivered)
+	uc, deliveries, snapshots := newProviderUpdateFixture(delivery)
+
+	// A late IN_DELIVERY after COMPLETED is still recorded but must not reopen the delivery
+	if err := uc.ApplyProviderUpdate(context.Background(), grabUpdate(delivery, "IN_DELIVERY")); err != nil {
+		t.Fatalf("ApplyProviderUpdate: %v", err)
+	}
+
+	if status := deliveries.deliveries[delivery.ID].Status; status != entity.DeliveryStatusDelivered {
+		t.Errorf("status = %s, want delivered", status)
+	}
+	if n := snapshots.countTriggeredBy("provider_webhook"); n != 1 {
+		t.Errorf("provider snapshots = %d, want 1", n)
+	}
+	if n := snapshots.countTriggeredBy("status_update") + snapshots.countTriggeredBy("location_updated"); n != 0 {
+		t.Errorf("finished delivery got %d status or location snapshots", n)
+	}
+}
+
+func TestApplyProviderUpdate_RecordsFailureReason(t *testing.T) {
+	delivery := grabDelivery(entity.DeliveryStatusInTransit)
+	uc, deliveries, _ := newProviderUpdateFixture(delivery)
+
+	update := grabUpdate(delivery, "FAILED")
+	update.FailureReason = "Recipient not at address"
+	if err := uc.ApplyProviderUpdate(context.Background(), update); err != nil {
+		t.Fatalf("ApplyProviderUpdate: %v", err)
+	}
+
+	stored := deliveries.deliveries[delivery.ID]
+	if stored.Status != entity.DeliveryStatusFailed {
+		t.Errorf("status = %s, want failed", stored.Status)
+	}
+	if stored.Notes == nil || *stored.Notes != "Recipient not at address" {
+		t.Errorf("notes = %v", stored.Notes)
+	}
+}
+
+func TestApplyProviderUpdate_RejectsUnknownAndMismatchedDeliveries(t *testing.T) {
+	delivery := grabDelivery(entity.DeliveryStatusPlanned)
+	uc, _, _ := newProviderUpdateFixture(delivery)
+
+	unknown := grabUpdate(delivery, "IN_DELIVERY")
+	unknown.MerchantOrderID = uuid.NewString()
+	unknown.ProviderOrderID = "IN-2-OTHER"
+	if err := uc.ApplyProviderUpdate(context.Background(), unknown); !errors.Is(err, repository.ErrDeliveryNotFound) {
+		t.Errorf("unknown delivery: got %v, want ErrDeliveryNotFound", err)
+	}
+
+	lineMan := grabUpdate(delivery, "PICKED_UP")
+	lineMan.Provider = "lineman"
+	if err := uc.ApplyProviderUpdate(context.Background(), lineMan); !errors.Is(err, ErrProviderMismatch) {
+		t.Errorf("other provider: got %v, want ErrProviderMismatch", err)
+	}
+}
+
+func TestMapProviderStatus(t *testing.T) {
+	tests := []struct {
+		method entity.DeliveryMethod
+		status string
+		want   entity.DeliveryStatus
+	}{
+		{entity.DeliveryMethodGrab, "PENDING_DROP_OFF", entity.DeliveryStatusDispatched},
+		{entity.DeliveryMethodGrab, "COMPLETED", entity.DeliveryStatusDelivered},
+		{entity.DeliveryMethodGrab, "RETURNED", entity.DeliveryStatusFailed},
+		{entity.DeliveryMethodLineMan, "PICKED_UP", entity.DeliveryStatusDispatched},
+		{entity.DeliveryMethodLineMan, "CANCELLED", entity.DeliveryStatusCancelled},
+	}
+	for _, tt := range tests {
+		got, ok := entity.MapProviderStatus(tt.method, tt.status)
+		if !ok || got != tt.want {
+			t.Errorf("MapProviderStatus(%s, %s) = %s, %v; want %s", tt.method, tt.status, got, ok, tt.want)
+		}
+	}
+
+	if _, ok := entity.MapProviderStatus(entity.DeliveryMethodLalamove, "COMPLETED"); ok {
+		t.Error("Lalamove statuses are not mapped")
+	}
+}
@@ -0,0 +1,390 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"shipping/internal/domain/entity"
+	"shipping/internal/domain/repository"
+)
+
+// Route optimization algorithms
+const (
+	AlgorithmNearestNeighbor = "nearest_neighbor"
+	AlgorithmTwoOpt          = "two_opt"
+	AlgorithmConstrained     = "constrained"
+)
+
+const (
+	earthRadiusKm          = 6371.0
+	defaultAverageSpeedKmh = 25.0 // urban average for self-delivery vehicles
+	defaultServiceTime     = 5 * time.Minute
+	maxTwoOptPasses        = 50
+)
+
+// Route optimizer errors
+var (
+	ErrUnknownAlgorithm = errors.New("unknown route optimization algorithm")
+	ErrVehicleRequired  = errors.New("constrained optimization requires an assigned vehicle")
+	ErrNoLocatableStops = errors.New("no stops with coordinates to optimize")
+)
+
+// OptimizerStop is a delivery stop fed into the route optimizer
+type OptimizerStop struct {
+	DeliveryID  uuid.UUID
+	Address     string
+	Location    *repository.Coordinates
+	Weight      float64
+	Volume      float64
+	WindowStart *time.Time
+	WindowEnd   *time.Time
+}
+
+// OptimizerOptions controls how stops are sequenced
+type OptimizerOptions struct {
+	Algorithm       string
+	Depot           *repository.Coordinates
+	StartTime       time.Time
+	AverageSpeedKmh float64
+	ServiceTime     time.Duration
+	Vehicle         *entity.DeliveryVehicle
+}
+
+// OptimizerResult is the outcome of sequencing a route
+type OptimizerResult struct {
+	Algorithm     string                 `json:"algorithm"`
+	Stops         []repository.RouteStop `json:"stops"`
+	TotalDistance float64                `json:"total_distance_km"`
+	EstimatedEnd  time.Time              `json:"estimated_end"`
+	TotalWeight   float64                `json:"total_weight"`
+	TotalVolume   float64                `json:"total_volume"`
+	// LateStops are deliveries whose ETA falls after their delivery window
+	LateStops []uuid.UUID `json:"late_stops,omitempty"`
+	// UnlocatedStops have no coordinates and are appended in their original order
+	UnlocatedStops []uuid.UUID `json:"unlocated_stops,omitempty"`
+}
+
+// OptimizeStops sequences the given stops with the requested algorithm and computes
+// per-stop ETAs. Stops without coordinates keep their relative order at the end.
+func OptimizeStops(stops []OptimizerStop, opts OptimizerOptions) (*OptimizerResult, error) {
+	if opts.Algorithm == "" {
+		opts.Algorithm = AlgorithmTwoOpt
+	}
+	if opts.AverageSpeedKmh <= 0 {
+		opts.AverageSpeedKmh = defaultAverageSpeedKmh
+	}
+	if opts.ServiceTime <= 0 {
+		opts.ServiceTime = defaultServiceTime
+	}
+
+	var located, unlocated []OptimizerStop
+	var totalWeight, totalVolume float64
+	for _, s := range stops {
+		totalWeight += s.Weight
+		totalVolume += s.Volume
+		if s.Location == nil {
+			unlocated = append(unlocated, s)
+			continue
+		}
+		located = append(located, s)
+	}
+	if len(located) == 0 {
+		return nil, ErrNoLocatableStops
+	}
+
+	var order []int
+	switch opts.Algorithm {
+	case AlgorithmNearestNeighbor:
+		order = nearestNeighborOrder(located, opts.Depot)
+	case AlgorithmTwoOpt:
+		order = twoOpt(located, opts.Depot, nearestNeighborOrder(located, opts.Depot), nil)
+	case AlgorithmConstrained:
+		if opts.Vehicle == nil {
+			return nil, ErrVehicleRequired
+		}
+		if !opts.Vehicle.CanCarry(totalWeight, totalVolume) {
+			return nil, entity.ErrInsufficientCapacity
+		}
+		order = windowAwareOrder(located, opts)
+		order = twoOpt(located, opts.Depot, order, func(candidate, current []int) bool {
+			return countLate(located, candidate, opts) <= countLate(located, current, opts)
+		})
+	default:
+		return nil, ErrUnknownAlgorithm
+	}
+
+	result := &OptimizerResult{
+		Algorithm:   opts.Algorithm,
+		TotalWeight: totalWeight,
+		TotalVolume: totalVolume,
+	}
+
+	clock := opts.StartTime
+	var prev *repository.Coordinates = opts.Depot
+	for seq, idx := range order {
+		stop := located[idx]
+		if prev != nil {
+			leg := haversineKm(*prev, *stop.Location)
+			result.TotalDistance += leg
+			clock = clock.Add(travelTime(leg, opts.AverageSpeedKmh))
+		}
+		if stop.WindowStart != nil && clock.Before(*stop.WindowStart) {
+			clock = *stop.WindowStart
+		}
+		if stop.WindowEnd != nil && clock.After(*stop.WindowEnd) {
+			result.LateStops = append(result.LateStops, stop.DeliveryID)
+		}
+
+		result.Stops = append(result.Stops, repository.RouteStop{
+			DeliveryID:        stop.DeliveryID,
+			Sequence:          seq + 1,
+			Address:           stop.Address,
+			Coordinates:       *stop.Location,
+			EstimatedArrival:  clock,
+			EstimatedDuration: int(opts.ServiceTime.Minutes()),
+			StopType:          "delivery",
+		})
+
+		clock = clock.Add(opts.ServiceTime)
+		prev = stop.Location
+	}
+
+	for _, stop := range unlocated {
+		result.UnlocatedStops = append(result.UnlocatedStops, stop.DeliveryID)
+		result.Stops = append(result.Stops, repository.RouteStop{
+			DeliveryID:        stop.DeliveryID,
+			Sequence:          len(result.Stops) + 1,
+			Address:           stop.Address,
+			EstimatedDuration: int(opts.ServiceTime.Minutes()),
+			StopType:          "delivery",
+		})
+	}
+
+	result.TotalDistance = math.Round(result.TotalDistance*100) / 100
+	result.EstimatedEnd = clock
+	return result, nil
+}
+
+// nearestNeighborOrder greedily visits the closest unvisited stop
+func nearestNeighborOrder(stops []OptimizerStop, depot *repository.Coordinates) []int {
+	visited := make([]bool, len(stops))
+	order := make([]int, 0, len(stops))
+
+	current := depot
+	if current == nil {
+		// Without a depot the route starts at the first stop
+		order = append(order, 0)
+		visited[0] = true
+		current = stops[0].Location
+	}
+
+	for len(order) < len(stops) {
+		best, bestDist := -1, math.MaxFloat64
+		for i, s := range stops {
+			if visited[i] {
+				continue
+			}
+			if d := haversineKm(*current, *s.Location); d < bestDist {
+				best, bestDist = i, d
+			}
+		}
+		visited[best] = true
+		order = append(order, best)
+		current = stops[best].Location
+	}
+
+	return order
+}
+
+// windowAwareOrder builds a greedy sequence that prefers stops whose delivery
+// window closes first among those reachable in time, falling back to distance
+func windowAwareOrder(stops []OptimizerStop, opts OptimizerOptions) []int {
+	visited := make([]bool, len(stops))
+	order := make([]int, 0, len(stops))
+	clock := opts.StartTime
+	current := opts.Depot
+
+	for len(order) < len(stops) {
+		type candidate struct {
+			idx     int
+			arrival time.Time
+			late    bool
+			dist    float64
+		}
+		var candidates []candidate
+		for i, s := range stops {
+			if visited[i] {
+				continue
+			}
+			dist := 0.0
+			if current != nil {
+				dist = haversineKm(*current, *s.Location)
+			}
+			arrival := clock.Add(travelTime(dist, opts.AverageSpeedKmh))
+			if s.WindowStart != nil && arrival.Before(*s.WindowStart) {
+				arrival = *s.WindowStart
+			}
+			late := s.WindowEnd != nil && arrival.After(*s.WindowEnd)
+			candidates = append(candidates, candidate{idx: i, arrival: arrival, late: late, dist: dist})
+		}
+
+		sort.SliceStable(candidates, func(a, b int) bool {
+			ca, cb := candidates[a], candidates[b]
+			if ca.late != cb.late {
+				return !ca.late
+			}
+			wa, wb := stops[ca.idx].WindowEnd, stops[cb.idx].WindowEnd
+			if wa != nil && wb != nil && !wa.Equal(*wb) {
+				return wa.Before(*wb)
+			}
+			if (wa == nil) != (wb == nil) {
+				return wa != nil
+			}
+			return ca.arrival.Before(cb.arrival) || (ca.arrival.Equal(cb.arrival) && ca.dist < cb.dist)
+		})
+
+		next := candidates[0]
+		visited[next.idx] = true
+		order = append(order, next.idx)
+		clock = next.arrival.Add(opts.ServiceTime)
+		current = stops[next.idx].Location
+	}
+
+	return order
+}
+
+// twoOpt improves a sequence by reversing segments while that shortens the route.
+// accept, when set, can veto a shorter candidate (e.g. when it breaks time windows);
+// it is given the candidate and the current best sequence it would replace.
+func twoOpt(stops []OptimizerStop, depot *repository.Coordinates, order []int, accept func(candidate, current []int) bool) []int {
+	best := append([]int(nil), order...)
+	bestDist := pathDistance(stops, depot, best)
+
+	for pass := 0; pass < maxTwoOptPasses; pass++ {
+		improved := false
+		for i := 0; i < len(best)-1; i++ {
+			for k := i + 1; k < len(best); k++ {
+				candidate := append([]int(nil), best...)
+				reverse(candidate[i : k+1])
+
+				dist := pathDistance(stops, depot, candidate)
+				if dist+1e-9 >= bestDist {
+					continue
+				}
+				if accept != nil && !accept(candidate, best) {
+					continue
+				}
+				best, bestDist = candidate, dist
+				improved = true
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	return best
+}
+
+// countLate counts stops whose ETA falls after their delivery window for a sequence
+func countLate(stops []OptimizerStop, order []int, opts OptimizerOptions) int {
+	late := 0
+	clock := opts.StartTime
+	prev := opts.Depot
+	for _, idx := range order {
+		s := stops[idx]
+		if prev != nil {
+			clock = clock.Add(travelTime(haversineKm(*prev, *s.Location), opts.AverageSpeedKmh))
+		}
+		if s.WindowStart != nil && clock.Before(*s.WindowStart) {
+			clock = *s.WindowStart
+		}
+		if s.WindowEnd != nil && clock.After(*s.WindowEnd) {
+			late++
+		}
+		clock = clock.Add(opts.ServiceTime)
+		prev = s.Location
+	}
+	return late
+}
+
+func pathDistance(stops []OptimizerStop, depot *repository.Coordinates, order []int) float64 {
+	total := 0.0
+	prev := depot
+	for _, idx := range order {
+		if prev != nil {
+			total += haversineKm(*prev, *stops[idx].Location)
+		}
+		prev = stops[idx].Location
+	}
+	return total
+}
+
+func reverse(s []int) {
+	for i, j := 0, len(s)-1; i < j; i, j = i+1, j-1 {
+		s[i], s[j] = s[j], s[i]
+	}
+}
+
+func travelTime(distanceKm, speedKmh float64) time.Duration {
+	return time.Duration(distanceKm / speedKmh * float64(time.Hour))
+}
+
+// haversineKm returns the great-circle distance between two coordinates in kilometers
+func haversineKm(a, b repository.Coordinates) float64 {
+	lat1 := a.Latitude * math.Pi / 180
+	lat2 := b.Latitude * math.Pi / 180
+	dLat := lat2 - lat1
+	dLon := (b.Longitude - a.Longitude) * math.Pi / 180
+
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
+
+// RouteStopChange describes how a single stop moved in a re-sequenced route
+type RouteStopChange struct {
+	DeliveryID  uuid.UUID `json:"delivery_id"`
+	OldSequence int       `json:"old_sequence"`
+	NewSequence int       `json:"new_sequence"`
+}
+
+// RouteDiff summarizes the change between the previous and the optimized route
+type RouteDiff struct {
+	PreviousDistance float64           `json:"previous_distance_km"`
+	NewDistance      float64           `json:"new_distance_km"`
+	DistanceSaved    float64           `json:"distance_saved_km"`
+	MovedStops       []RouteStopChange `json:"moved_stops"`
+	UnchangedStops   int               `json:"unchanged_stops"`
+}
+
+// diffRoutes compares the previous sequence of delivery IDs with the optimized stops
+func diffRoutes(previous []uuid.UUID, previousDistance float64, result *OptimizerResult) *RouteDiff {
+	oldSeq := make(map[uuid.UUID]int, len(previous))
+	for i, id := range previous {
+		oldSeq[id] = i + 1
+	}
+
+	diff := &RouteDiff{
+		PreviousDistance: math.Round(previousDistance*100) / 100,
+		NewDistance:      result.TotalDistance,
+		MovedStops:       []RouteStopChange{},
+	}
+	diff.DistanceSaved = math.Round((diff.PreviousDistance-diff.NewDistance)*100) / 100
+
+	for _, stop := range result.Stops {
+		if oldSeq[stop.DeliveryID] == stop.Sequence {
+			diff.UnchangedStops++
+			continue
+		}
+		diff.MovedStops = append(diff.MovedStops, RouteStopChange{
+			DeliveryID:  stop.DeliveryID,
+			OldSequence: oldSeq[stop.DeliveryID],
+			NewSequence: stop.Sequence,
+		})
+	}
+
+	return diff
+}
@@ -0,0 +1,175 @@
+package application
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"shipping/internal/domain/entity"
+	"shipping/internal/domain/repository"
+)
+
+func stopAt(lat, lng float64) OptimizerStop {
+	return OptimizerStop{
+		DeliveryID: uuid.New(),
+		Location:   &repository.Coordinates{Latitude: lat, Longitude: lng},
+		Weight:     10,
+		Volume:     0.1,
+	}
+}
+
+func sequence(result *OptimizerResult) []uuid.UUID {
+	ids := make([]uuid.UUID, len(result.Stops))
+	for i, s := range result.Stops {
+		ids[i] = s.DeliveryID
+	}
+	return ids
+}
+
+func TestOptimizeStops_NearestNeighborOrdersAlongLine(t *testing.T) {
+	depot := &repository.Coordinates{Latitude: 13.70, Longitude: 100.50}
+	far, near, mid := stopAt(13.70, 100.60), stopAt(13.70, 100.52), stopAt(13.70, 100.56)
+
+	result, err := OptimizeStops([]OptimizerStop{far, near, mid}, OptimizerOptions{
+		Algorithm: AlgorithmNearestNeighbor,
+		Depot:     depot,
+		StartTime: time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := sequence(result)
+	want := []uuid.UUID{near.DeliveryID, mid.DeliveryID, far.DeliveryID}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("stop %d: got %s, want %s", i+1, got[i], want[i])
+		}
+	}
+
+	for i := 1; i < len(result.Stops); i++ {
+		if !result.Stops[i].EstimatedArrival.After(result.Stops[i-1].EstimatedArrival) {
+			t.Errorf("ETA of stop %d is not after stop %d", i+1, i)
+		}
+	}
+	if result.TotalDistance <= 0 {
+		t.Errorf("expected positive planned distance, got %v", result.TotalDistance)
+	}
+}
+
+func TestOptimizeStops_TwoOptRemovesCrossing(t *testing.T) {
+	// Square corners visited in a crossing order
+	stops := []OptimizerStop{
+		stopAt(13.70, 100.50),
+		stopAt(13.75, 100.55),
+		stopAt(13.70, 100.55),
+		stopAt(13.75, 100.50),
+	}
+	crossing := pathDistance(stops, nil, []int{0, 1, 2, 3})
+
+	improved := twoOpt(stops, nil, []int{0, 1, 2, 3}, nil)
+	if got := pathDistance(stops, nil, improved); got >= crossing {
+		t.Errorf("2-opt did not shorten the route: %v >= %v", got, crossing)
+	}
+}
+
+func TestOptimizeStops_ConstrainedRespectsWindowsAndCapacity(t *testing.T) {
+	start := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	depot := &repository.Coordinates{Latitude: 13.70, Longitude: 100.50}
+
+	near, far := stopAt(13.70, 100.51), stopAt(13.70, 100.60)
+	// At 40km/h the far stop is only on time when served first
+	farEnd := start.Add(18 * time.Minute)
+	far.WindowEnd = &farEnd
+
+	vehicle := entity.NewDeliveryVehicle("1กข-1234", "Toyota", "Hilux", entity.VehicleTypeTruck, 2022, 100, 1)
+
+	result, err := OptimizeStops([]OptimizerStop{near, far}, OptimizerOptions{
+		Algorithm:       AlgorithmConstrained,
+		Depot:           depot,
+		StartTime:       start,
+		AverageSpeedKmh: 40,
+		Vehicle:         vehicle,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stops[0].DeliveryID != far.DeliveryID {
+		t.Errorf("expected the time-windowed stop first")
+	}
+	if len(result.LateStops) != 0 {
+		t.Errorf("expected no late stops, got %v", result.LateStops)
+	}
+
+	vehicle.MaxWeight = 15
+	_, err = OptimizeStops([]OptimizerStop{near, far}, OptimizerOptions{
+		Algorithm: AlgorithmConstrained,
+		Depot:     depot,
+		StartTime: start,
+		Vehicle:   vehicle,
+	})
+	if err != entity.ErrInsufficientCapacity {
+		t.Errorf("expected ErrInsufficientCapacity, got %v", err)
+	}
+}
+
+func TestOptimizeStops_ConstrainedNeverTradesBackLateness(t *testing.T) {
+	start := time.Date(2025, 7, 1, 8, 0, 0, 0, time.UTC)
+	depot := &repository.Coordinates{Latitude: 13.70, Longitude: 100.50}
+	windowed := func(lat, lng float64, minutes int) OptimizerStop {
+		s := stopAt(lat, lng)
+		end := start.Add(time.Duration(minutes) * time.Minute)
+		s.WindowEnd = &end
+		return s
+	}
+
+	// The greedy seed has two late stops. An early swap brings that down to one; a later,
+	// shorter swap puts a second stop back out of its window and must be rejected, even
+	// though it is no worse than the seed.
+	stops := []OptimizerStop{
+		windowed(13.79, 100.55, 45),
+		windowed(13.72, 100.54, 35),
+		windowed(13.78, 100.52, 25),
+		windowed(13.71, 100.58, 35),
+		windowed(13.78, 100.54, 45),
+	}
+	opts := OptimizerOptions{
+		Algorithm:       AlgorithmConstrained,
+		Depot:           depot,
+		StartTime:       start,
+		AverageSpeedKmh: 40,
+		ServiceTime:     5 * time.Minute,
+		Vehicle:         entity.NewDeliveryVehicle("1กข-1234", "Toyota", "Hilux", entity.VehicleTypeTruck, 2022, 100, 1),
+	}
+	if seed := countLate(stops, windowAwareOrder(stops, opts), opts); seed != 2 {
+		t.Fatalf("fixture seed has %d late stops, want 2", seed)
+	}
+
+	result, err := OptimizeStops(stops, opts)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.LateStops) != 1 {
+		t.Errorf("expected 1 late stop, got %d", len(result.LateStops))
+	}
+}
+
+func TestDiffRoutes(t *testing.T) {
+	a, b := stopAt(13.70, 100.51), stopAt(13.70, 100.52)
+	result := &OptimizerResult{
+		TotalDistance: 2,
+		Stops: []repository.RouteStop{
+			{DeliveryID: b.DeliveryID, Sequence: 1},
+			{DeliveryID: a.DeliveryID, Sequence: 2},
+		},
+	}
+
+	diff := diffRoutes([]uuid.UUID{a.DeliveryID, b.DeliveryID}, 3, result)
+
+	if len(diff.MovedStops) != 2 || diff.UnchangedStops != 0 {
+		t.Errorf("expected both stops to move, got %+v", diff)
+	}
+	if diff.DistanceSaved != 1 {
+		t.Errorf("expected 1km saved, got %v", diff.DistanceSaved)
+	}
+}
@@ -0,0 +1,307 @@
+package application
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+	"shipping/internal/domain/entity"
+	"shipping/internal/domain/repository"
+)
+
+// Reasons a delivery was left out of a daily route plan
+const (
+	UnplannedNoCoverageArea = "no_coverage_area"
+	UnplannedAreaCapacity   = "area_capacity_exceeded"
+	UnplannedNoVehicle      = "no_vehicle_available"
+)
+
+// ErrNoDraftPlan is returned when a date has no draft routes to approve or discard
+var ErrNoDraftPlan = errors.New("no draft route plan for date")
+
+// UnplannedDelivery is a delivery the planner could not place on a route
+type UnplannedDelivery struct {
+	DeliveryID uuid.UUID `json:"delivery_id"`
+	Reason     string    `json:"reason"`
+}
+
+// vehicleLoad is the set of stops packed onto one vehicle for the day
+type vehicleLoad struct {
+	vehicle *entity.DeliveryVehicle
+	group   string
+	stops   []OptimizerStop
+	weight  float64
+	volume  float64
+}
+
+// fits reports whether the stop can be added without exceeding the vehicle or stop limits
+func (l *vehicleLoad) fits(stop OptimizerStop, maxStops int) bool {
+	if maxStops > 0 && len(l.stops) >= maxStops {
+		return false
+	}
+	return l.vehicle.CanCarry(l.weight+stop.Weight, l.volume+stop.Volume)
+}
+
+func (l *vehicleLoad) add(stop OptimizerStop) {
+	l.stops = append(l.stops, stop)
+	l.weight += stop.Weight
+	l.volume += stop.Volume
+}
+
+// areaGroup collects the stops of coverage areas served by the same delivery route
+type areaGroup struct {
+	key      string
+	priority int
+	stops    []OptimizerStop
+	// waiting are the stops over their area's capacity, in priority order. They take the place
+	// of stops of the same area that get no vehicle.
+	waiting []areaStop
+}
+
+// areaStop is a stop together with the coverage area it counts against
+type areaStop struct {
+	area *entity.CoverageArea
+	stop OptimizerStop
+}
+
+// planVehicleLoads matches candidates to self-delivery coverage areas, enforces each area's
+// daily capacity net of the deliveries already routed that day and packs the stops of every
+// delivery route onto vehicles. A delivery only counts against its area once it is on a
+// vehicle. A vehicle serves
+// at most one delivery route per day; each route's stops are swept by bearing so a vehicle
+// gets a contiguous sector. Candidates are expected in priority order (earliest window first).
+func planVehicleLoads(
+	candidates []*repository.PlanningCandidate,
+	routed []*repository.PlanningCandidate,
+	areas []*entity.CoverageArea,
+	vehicles []*entity.DeliveryVehicle,
+	depot *repository.Coordinates,
+	maxStops int,
+) ([]*vehicleLoad, []UnplannedDelivery) {
+	var unplanned []UnplannedDelivery
+	groups := make(map[string]*areaGroup)
+	var ordered []*areaGroup
+	used := make(map[uuid.UUID]int)
+	stopAreas := make(map[uuid.UUID]*entity.CoverageArea)
+	for _, r := range routed {
+		if area := matchCoverageArea(areas, r); area != nil {
+			used[area.ID]++
+		}
+	}
+
+	for _, c := range candidates {
+		area := matchCoverageArea(areas, c)
+		if area == nil {
+			unplanned = append(unplanned, UnplannedDelivery{DeliveryID: c.DeliveryID, Reason: UnplannedNoCoverageArea})
+			continue
+		}
+		key := area.DeliveryRoute
+		if key == "" {
+			key = area.GetLocationString()
+		}
+		group, ok := groups[key]
+		if !ok {
+			group = &areaGroup{key: key, priority: area.PriorityOrder}
+			groups[key] = group
+			ordered = append(ordered, group)
+		}
+		if area.PriorityOrder < group.priority {
+			group.priority = area.PriorityOrder
+		}
+		if area.MaxDailyCapacity > 0 && used[area.ID] >= area.MaxDailyCapacity {
+			group.waiting = append(group.waiting, areaStop{area: area, stop: candidateStop(c)})
+			continue
+		}
+		used[area.ID]++
+		stopAreas[c.DeliveryID] = area
+		group.stops = append(group.stops, candidateStop(c))
+	}
+
+	sort.SliceStable(ordered, func(i, j int) bool {
+		if ordered[i].priority != ordered[j].priority {
+			return ordered[i].priority < ordered[j].priority
+		}
+		return ordered[i].key < ordered[j].key
+	})
+
+	// Largest vehicles first so a route needs as few vehicles as possible
+	pool := make([]*entity.DeliveryVehicle, len(vehicles))
+	copy(pool, vehicles)
+	sort.SliceStable(pool, func(i, j int) bool {
+		if pool[i].MaxWeight != pool[j].MaxWeight {
+			return pool[i].MaxWeight > pool[j].MaxWeight
+		}
+		return pool[i].MaxVolume > pool[j].MaxVolume
+	})
+
+	var loads []*vehicleLoad
+	for _, group := range ordered {
+		var current *vehicleLoad
+		groupLoads := len(loads)
+		for _, stop := range sweepOrder(group.stops, depot) {
+			if current != nil && current.fits(stop, maxStops) {
+				current.add(stop)
+				continue
+			}
+
+			vehicle := takeVehicle(&pool, stop)
+			if vehicle == nil {
+				// The delivery stays unrouted, so it leaves its place in the area to a waiting one
+				used[stopAreas[stop.DeliveryID].ID]--
+				unplanned = append(unplanned, UnplannedDelivery{DeliveryID: stop.DeliveryID, Reason: UnplannedNoVehicle})
+				continue
+			}
+			current = &vehicleLoad{vehicle: vehicle, group: group.key}
+			current.add(stop)
+			loads = append(loads, current)
+		}
+
+		for _, w := range group.waiting {
+			if used[w.area.ID] >= w.area.MaxDailyCapacity {
+				unplanned = append(unplanned, UnplannedDelivery{DeliveryID: w.stop.DeliveryID, Reason: UnplannedAreaCapacity})
+				continue
+			}
+			if load := fittingLoad(loads[groupLoads:], w.stop, maxStops); load != nil {
+				load.add(w.stop)
+				used[w.area.ID]++
+				continue
+			}
+
+			vehicle := takeVehicle(&pool, w.stop)
+			if vehicle == nil {
+				unplanned = append(unplanned, UnplannedDelivery{DeliveryID: w.stop.DeliveryID, Reason: UnplannedNoVehicle})
+				continue
+			}
+			load := &vehicleLoad{vehicle: vehicle, group: group.key}
+			load.add(w.stop)
+			loads = append(loads, load)
+			used[w.area.ID]++
+		}
+	}
+
+	return loads, unplanned
+}
+
+// fittingLoad returns the first of the loads with room for the stop
+func fittingLoad(loads []*vehicleLoad, stop OptimizerStop, maxStops int) *vehicleLoad {
+	for _, load := range loads {
+		if load.fits(stop, maxStops) {
+			return load
+		}
+	}
+	return nil
+}
+
+// matchCoverageArea returns the most specific active self-delivery area covering the
+// candidate's address, preferring the lower priority order on ties
+func matchCoverageArea(areas []*entity.CoverageArea, c *repository.PlanningCandidate) *entity.CoverageArea {
+	var best *entity.CoverageArea
+	bestScore := -1
+	for _, area := range areas {
+		if !area.IsSelfDeliveryArea || !area.IsActive {
+			continue
+		}
+		if !area.MatchesLocation(c.Province, c.District, c.Subdistrict, c.PostalCode) {
+			continue
+		}
+
+		score := 0
+		for _, field := range []string{area.District, area.Subdistrict, area.PostalCode} {
+			if field != "" {
+				score++
+			}
+		}
+		if score > bestScore || (score == bestScore && area.PriorityOrder < best.PriorityOrder) {
+			best, bestScore = area, score
+		}
+	}
+	return best
+}
+
+func candidateStop(c *repository.PlanningCandidate) OptimizerStop {
+	stop := OptimizerStop{
+		DeliveryID:  c.DeliveryID,
+		Address:     c.Address,
+		Weight:      c.Weight,
+		Volume:      c.Volume,
+		WindowStart: c.DeliveryWindowStart,
+		WindowEnd:   c.DeliveryWindowEnd,
+	}
+	if c.Latitude != nil && c.Longitude != nil {
+		stop.Location = &repository.Coordinates{Latitude: *c.Latitude, Longitude: *c.Longitude}
+	}
+	return stop
+}
+
+// sweepOrder sorts located stops by bearing around the depot (or their centroid when no
+// depot is given); stops without coordinates follow in their original order
+func sweepOrder(stops []OptimizerStop, depot *repository.Coordinates) []OptimizerStop {
+	var located, unlocated []OptimizerStop
+	for _, s := range stops {
+		if s.Location == nil {
+			unlocated = append(unlocated, s)
+			continue
+		}
+		located = append(located, s)
+	}
+	if len(located) == 0 {
+		return unlocated
+	}
+
+	center := depot
+	if center == nil {
+		var lat, lng float64
+		for _, s := range located {
+			lat += s.Location.Latitude
+			lng += s.Location.Longitude
+		}
+		center = &repository.Coordinates{
+			Latitude:  lat / float64(len(located)),
+			Longitude: lng / float64(len(located)),
+		}
+	}
+
+	bearing := func(s OptimizerStop) float64 {
+		return math.Atan2(s.Location.Latitude-center.Latitude, s.Location.Longitude-center.Longitude)
+	}
+	sort.SliceStable(located, func(i, j int) bool {
+		return bearing(located[i]) < bearing(located[j])
+	})
+
+	return append(located, unlocated...)
+}
+
+// takeVehicle removes and returns the first vehicle in the pool able to carry the stop on its own
+func takeVehicle(pool *[]*entity.DeliveryVehicle, stop OptimizerStop) *entity.DeliveryVehicle {
+	for i, v := range *pool {
+		if v.CanCarry(stop.Weight, stop.Volume) {
+			*pool = append((*pool)[:i], (*pool)[i+1:]...)
+			return v
+		}
+	}
+	return nil
+}
+
+// sequenceLoad orders the stops of a planned vehicle load. Loads without any geocoded stop
+// keep their order and get no ETAs.
+func sequenceLoad(stops []OptimizerStop, opts OptimizerOptions) (*OptimizerResult, error) {
+	result, err := OptimizeStops(stops, opts)
+	if !errors.Is(err, ErrNoLocatableStops) {
+		return result, err
+	}
+
+	result = &OptimizerResult{Algorithm: opts.Algorithm, EstimatedEnd: opts.StartTime}
+	for i, s := range stops {
+		result.TotalWeight += s.Weight
+		result.TotalVolume += s.Volume
+		result.UnlocatedStops = append(result.UnlocatedStops, s.DeliveryID)
+		result.Stops = append(result.Stops, repository.RouteStop{
+			DeliveryID: s.DeliveryID,
+			Sequence:   i + 1,
+			Address:    s.Address,
+			StopType:   "delivery",
+		})
+	}
+	return result, nil
+}
@@ -0,0 +1,170 @@
+package application
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"shipping/internal/domain/entity"
+	"shipping/internal/domain/repository"
+)
+
+func candidateAt(district string, lat, lng, weight float64) *repository.PlanningCandidate {
+	return &repository.PlanningCandidate{
+		RouteStopDetails: repository.RouteStopDetails{
+			DeliveryID: uuid.New(),
+			Latitude:   &lat,
+			Longitude:  &lng,
+			Weight:     weight,
+			Volume:     0.1,
+		},
+		Province: "Bangkok",
+		District: district,
+	}
+}
+
+func selfDeliveryArea(district, route string, capacity int) *entity.CoverageArea {
+	area, _ := entity.NewCoverageArea("Bangkok", true)
+	area.SetLocation(district, "", "")
+	area.SetDeliveryRoute(route, "A")
+	area.MaxDailyCapacity = capacity
+	return area
+}
+
+func vehicleWithCapacity(maxWeight float64) *entity.DeliveryVehicle {
+	return entity.NewDeliveryVehicle("1กข-1234", "Isuzu", "D-Max", entity.VehicleTypeTruck, 2022, maxWeight, 10)
+}
+
+func TestPlanVehicleLoads_SplitsRouteAcrossVehiclesByWeight(t *testing.T) {
+	areas := []*entity.CoverageArea{selfDeliveryArea("Bang Kapi", "A", 100)}
+	candidates := []*repository.PlanningCandidate{
+		candidateAt("Bang Kapi", 13.76, 100.64, 60),
+		candidateAt("Bang Kapi", 13.77, 100.65, 60),
+		candidateAt("Bang Kapi", 13.78, 100.66, 60),
+	}
+	small, large := vehicleWithCapacity(100), vehicleWithCapacity(150)
+
+	loads, unplanned := planVehicleLoads(candidates, nil, areas, []*entity.DeliveryVehicle{small, large}, nil, 0)
+
+	if len(unplanned) != 0 {
+		t.Fatalf("expected every delivery planned, got %+v", unplanned)
+	}
+	if len(loads) != 2 {
+		t.Fatalf("expected 2 vehicle loads, got %d", len(loads))
+	}
+	if loads[0].vehicle != large || len(loads[0].stops) != 2 {
+		t.Errorf("expected the larger vehicle to take 2 stops first")
+	}
+	if loads[1].vehicle != small || len(loads[1].stops) != 1 {
+		t.Errorf("expected the smaller vehicle to take the remaining stop")
+	}
+	for _, load := range loads {
+		if !load.vehicle.CanCarry(load.weight, load.volume) {
+			t.Errorf("load of %.0fkg exceeds vehicle limit %.0fkg", load.weight, load.vehicle.MaxWeight)
+		}
+	}
+}
+
+func TestPlanVehicleLoads_RespectsAreaCapacityAndCoverage(t *testing.T) {
+	areas := []*entity.CoverageArea{selfDeliveryArea("Bang Kapi", "A", 2)}
+	first := candidateAt("Bang Kapi", 13.76, 100.64, 5)
+	second := candidateAt("Bang Kapi", 13.77, 100.65, 5)
+	overCapacity := candidateAt("Bang Kapi", 13.78, 100.66, 5)
+	uncovered := candidateAt("Bang Rak", 13.72, 100.52, 5)
+
+	loads, unplanned := planVehicleLoads(
+		[]*repository.PlanningCandidate{first, second, overCapacity, uncovered},
+		nil, areas, []*entity.DeliveryVehicle{vehicleWithCapacity(500)}, nil, 0)
+
+	if len(loads) != 1 || len(loads[0].stops) != 2 {
+		t.Fatalf("expected one load with 2 stops, got %d loads", len(loads))
+	}
+
+	reasons := make(map[uuid.UUID]string)
+	for _, u := range unplanned {
+		reasons[u.DeliveryID] = u.Reason
+	}
+	if reasons[overCapacity.DeliveryID] != UnplannedAreaCapacity {
+		t.Errorf("expected %s for delivery over area capacity, got %q", UnplannedAreaCapacity, reasons[overCapacity.DeliveryID])
+	}
+	if reasons[uncovered.DeliveryID] != UnplannedNoCoverageArea {
+		t.Errorf("expected %s for uncovered delivery, got %q", UnplannedNoCoverageArea, reasons[uncovered.DeliveryID])
+	}
+}
+
+func TestPlanVehicleLoads_CountsRoutedDeliveriesAgainstAreaCapacity(t *testing.T) {
+	areas := []*entity.CoverageArea{selfDeliveryArea("Bang Kapi", "A", 2)}
+	routed := []*repository.PlanningCandidate{candidateAt("Bang Kapi", 13.75, 100.63, 5)}
+	first := candidateAt("Bang Kapi", 13.76, 100.64, 5)
+	overCapacity := candidateAt("Bang Kapi", 13.77, 100.65, 5)
+
+	loads, unplanned := planVehicleLoads(
+		[]*repository.PlanningCandidate{first, overCapacity},
+		routed, areas, []*entity.DeliveryVehicle{vehicleWithCapacity(500)}, nil, 0)
+
+	if len(loads) != 1 || len(loads[0].stops) != 1 || loads[0].stops[0].DeliveryID != first.DeliveryID {
+		t.Fatalf("expected only the first delivery planned next to the routed one, got %d loads", len(loads))
+	}
+	if len(unplanned) != 1 || unplanned[0].DeliveryID != overCapacity.DeliveryID || unplanned[0].Reason != UnplannedAreaCapacity {
+		t.Errorf("expected %s for the delivery over capacity, got %+v", UnplannedAreaCapacity, unplanned)
+	}
+}
+
+func TestPlanVehicleLoads_CountsOnlyDeliveriesOnVehiclesAgainstAreaCapacity(t *testing.T) {
+	areas := []*entity.CoverageArea{selfDeliveryArea("Bang Kapi", "A", 2)}
+	tooHeavy := candidateAt("Bang Kapi", 13.76, 100.64, 600)
+	first := candidateAt("Bang Kapi", 13.77, 100.65, 5)
+	waiting := candidateAt("Bang Kapi", 13.78, 100.66, 5)
+
+	loads, unplanned := planVehicleLoads(
+		[]*repository.PlanningCandidate{tooHeavy, first, waiting},
+		nil, areas, []*entity.DeliveryVehicle{vehicleWithCapacity(500)}, nil, 0)
+
+	if len(loads) != 1 || len(loads[0].stops) != 2 {
+		t.Fatalf("expected the waiting delivery to take the place of the one without a vehicle, got %d loads", len(loads))
+	}
+	if len(unplanned) != 1 || unplanned[0].DeliveryID != tooHeavy.DeliveryID || unplanned[0].Reason != UnplannedNoVehicle {
+		t.Errorf("expected %s for the delivery no vehicle can carry, got %+v", UnplannedNoVehicle, unplanned)
+	}
+}
+
+func TestPlanVehicleLoads_DoesNotShareVehiclesAcrossDeliveryRoutes(t *testing.T) {
+	areas := []*entity.CoverageArea{
+		selfDeliveryArea("Bang Kapi", "A", 100),
+		selfDeliveryArea("Bang Rak", "B", 100),
+	}
+	candidates := []*repository.PlanningCandidate{
+		candidateAt("Bang Kapi", 13.76, 100.64, 5),
+		candidateAt("Bang Rak", 13.72, 100.52, 5),
+		candidateAt("Bang Rak", 13.73, 100.53, 5),
+	}
+
+	loads, unplanned := planVehicleLoads(candidates, nil, areas, []*entity.DeliveryVehicle{vehicleWithCapacity(500)}, nil, 0)
+
+	if len(loads) != 1 || loads[0].group != "A" {
+		t.Fatalf("expected route A to get the only vehicle, got %d loads", len(loads))
+	}
+	if len(unplanned) != 2 {
+		t.Fatalf("expected route B deliveries to be unplanned, got %d", len(unplanned))
+	}
+	for _, u := range unplanned {
+		if u.Reason != UnplannedNoVehicle {
+			t.Errorf("expected %s, got %s", UnplannedNoVehicle, u.Reason)
+		}
+	}
+}
+
+func TestPlanVehicleLoads_CapsStopsPerVehicle(t *testing.T) {
+	areas := []*entity.CoverageArea{selfDeliveryArea("Bang Kapi", "A", 100)}
+	candidates := []*repository.PlanningCandidate{
+		candidateAt("Bang Kapi", 13.76, 100.64, 1),
+		candidateAt("Bang Kapi", 13.77, 100.65, 1),
+		candidateAt("Bang Kapi", 13.78, 100.66, 1),
+	}
+
+	loads, _ := planVehicleLoads(candidates, nil, areas,
+		[]*entity.DeliveryVehicle{vehicleWithCapacity(500), vehicleWithCapacity(500)}, nil, 2)
+
+	if len(loads) != 2 || len(loads[0].stops) != 2 || len(loads[1].stops) != 1 {
+		t.Fatalf("expected stops split 2/1 across vehicles, got %d loads", len(loads))
+	}
+}
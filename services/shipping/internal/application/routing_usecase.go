@@ -15,6 +15,8 @@ type RoutingUseCase struct {
 	routeRepo    repository.RouteRepository
 	deliveryRepo repository.DeliveryRepository
 	vehicleRepo  repository.VehicleRepository
+	coverageRepo repository.CoverageAreaRepository
+	txManager    TxManager
 	eventPub     EventPublisher
 	cache        Cache
 }
@@ -24,6 +26,8 @@ func NewRoutingUseCase(
 	routeRepo repository.RouteRepository,
 	deliveryRepo repository.DeliveryRepository,
 	vehicleRepo repository.VehicleRepository,
+	coverageRepo repository.CoverageAreaRepository,
+	txManager TxManager,
 	eventPub EventPublisher,
 	cache Cache,
 ) *RoutingUseCase {
@@ -31,6 +35,8 @@ func NewRoutingUseCase(
 		routeRepo:    routeRepo,
 		deliveryRepo: deliveryRepo,
 		vehicleRepo:  vehicleRepo,
+		coverageRepo: coverageRepo,
+		txManager:    txManager,
 		eventPub:     eventPub,
 		cache:        cache,
 	}
@@ -63,8 +69,56 @@ type AssignVehicleRequest struct {
 // RouteOptimizationRequest represents a request to optimize a route
 type RouteOptimizationRequest struct {
 	RouteID     uuid.UUID `json:"route_id" validate:"required"`
-	Algorithm   string    `json:"algorithm,omitempty"`
-	OptimizedBy string    `json:"optimized_by" validate:"required"`
+	// Algorithm is one of nearest_neighbor, two_opt (default) or constrained.
+	// constrained respects the assigned vehicle's weight/volume limits and delivery time windows.
+	Algorithm       string                  `json:"algorithm,omitempty"`
+	Depot           *repository.Coordinates `json:"depot,omitempty"`
+	StartTime       *time.Time              `json:"start_time,omitempty"`
+	AverageSpeedKmh float64                 `json:"average_speed_kmh,omitempty"`
+	ServiceMinutes  int                     `json:"service_minutes,omitempty"`
+	OptimizedBy     string                  `json:"optimized_by" validate:"required"`
+}
+
+// RouteOptimizationResult is the outcome of optimizing a route
+type RouteOptimizationResult struct {
+	Route  *entity.DeliveryRoute `json:"route"`
+	Plan   *OptimizerResult      `json:"plan"`
+	Diff   *RouteDiff            `json:"diff"`
+}
+
+// PlanDailyRoutesRequest represents a request to draft the self-delivery routes of a day
+type PlanDailyRoutesRequest struct {
+	Date            time.Time               `json:"date" validate:"required"`
+	Depot           *repository.Coordinates `json:"depot,omitempty"`
+	StartTime       *time.Time              `json:"start_time,omitempty"`
+	AverageSpeedKmh float64                 `json:"average_speed_kmh,omitempty"`
+	ServiceMinutes  int                     `json:"service_minutes,omitempty"`
+	// MaxStopsPerVehicle caps the stops of one route; zero leaves only weight and volume as limits
+	MaxStopsPerVehicle int    `json:"max_stops_per_vehicle,omitempty"`
+	PlannedBy          string `json:"planned_by" validate:"required"`
+}
+
+// ApproveDailyPlanRequest represents a manager's sign-off of a day's draft routes
+type ApproveDailyPlanRequest struct {
+	Date       time.Time `json:"date" validate:"required"`
+	ApprovedBy string    `json:"approved_by" validate:"required"`
+}
+
+// DailyRoutePlan is the set of draft routes covering a day's self-deliveries
+type DailyRoutePlan struct {
+	PlanID        uuid.UUID           `json:"plan_id"`
+	Date          time.Time           `json:"date"`
+	Routes        []*PlannedRoute     `json:"routes"`
+	Unplanned     []UnplannedDelivery `json:"unplanned,omitempty"`
+	TotalStops    int                 `json:"total_stops"`
+	TotalDistance float64             `json:"total_distance_km"`
+}
+
+// PlannedRoute is one vehicle's draft route within a daily plan
+type PlannedRoute struct {
+	Route         *entity.DeliveryRoute `json:"route"`
+	DeliveryRoute string                `json:"delivery_route"`
+	Plan          *OptimizerResult      `json:"plan"`
 }
 
 // CreateRoute creates a new delivery route
@@ -347,51 +401,471 @@ func (uc *RoutingUseCase) CancelRoute(ctx context.Context, routeID uuid.UUID, re
 	return nil
 }
 
-// OptimizeRoute optimizes the route using specified algorithm
-func (uc *RoutingUseCase) OptimizeRoute(ctx context.Context, req RouteOptimizationRequest) (*entity.DeliveryRoute, error) {
+// OptimizeRoute re-sequences the stops of a route using the customer address coordinates,
+// persists the stop sequence, planned distance and per-stop ETAs, and publishes the route diff
+func (uc *RoutingUseCase) OptimizeRoute(ctx context.Context, req RouteOptimizationRequest) (*RouteOptimizationResult, error) {
 	// Get route
 	route, err := uc.routeRepo.GetByID(ctx, req.RouteID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get route: %w", err)
 	}
 
-	// Get deliveries for this route
-	deliveries, err := uc.deliveryRepo.GetByRouteID(ctx, req.RouteID)
+	if route.Status != entity.RouteStatusPlanned {
+		return nil, fmt.Errorf("failed to optimize route: %w", entity.ErrRouteAlreadyStarted)
+	}
+
+	// Get stops for this route in their current order
+	stopDetails, err := uc.deliveryRepo.GetRouteStops(ctx, req.RouteID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get route deliveries: %w", err)
+		return nil, fmt.Errorf("failed to get route stops: %w", err)
 	}
 
-	if len(deliveries) == 0 {
-		return route, nil // No deliveries to optimize
+	if len(stopDetails) == 0 {
+		return &RouteOptimizationResult{Route: route}, nil // No deliveries to optimize
 	}
 
-	// Create optimization data
-	optimizationData := map[string]interface{}{
-		"algorithm":      req.Algorithm,
-		"optimized_by":   req.OptimizedBy,
-		"optimized_at":   time.Now(),
-		"total_stops":    len(deliveries),
-		"delivery_count": len(deliveries),
+	opts := OptimizerOptions{
+		Algorithm:       req.Algorithm,
+		Depot:           req.Depot,
+		StartTime:       uc.routeStartTime(route, req.StartTime),
+		AverageSpeedKmh: req.AverageSpeedKmh,
+		ServiceTime:     time.Duration(req.ServiceMinutes) * time.Minute,
 	}
 
-	// Set optimization data
-	route.SetOptimizationData(optimizationData)
+	if req.Algorithm == AlgorithmConstrained {
+		if route.AssignedVehicleID == nil {
+			return nil, ErrVehicleRequired
+		}
+		vehicle, err := uc.vehicleRepo.GetByID(ctx, *route.AssignedVehicleID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get assigned vehicle: %w", err)
+		}
+		opts.Vehicle = vehicle
+	}
+
+	stops := make([]OptimizerStop, len(stopDetails))
+	previous := make([]uuid.UUID, len(stopDetails))
+	for i, d := range stopDetails {
+		stops[i] = OptimizerStop{
+			DeliveryID:  d.DeliveryID,
+			Address:     d.Address,
+			Weight:      d.Weight,
+			Volume:      d.Volume,
+			WindowStart: d.DeliveryWindowStart,
+			WindowEnd:   d.DeliveryWindowEnd,
+		}
+		if d.Latitude != nil && d.Longitude != nil {
+			stops[i].Location = &repository.Coordinates{Latitude: *d.Latitude, Longitude: *d.Longitude}
+		}
+		previous[i] = d.DeliveryID
+	}
 
-	// Update route in repository
-	if err := uc.routeRepo.Update(ctx, route); err != nil {
-		return nil, fmt.Errorf("failed to update route: %w", err)
+	plan, err := OptimizeStops(stops, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to optimize route: %w", err)
+	}
+
+	diff := diffRoutes(previous, currentDistance(stops, req.Depot), plan)
+
+	// Update route planning
+	route.TotalPlannedDistance = plan.TotalDistance
+	route.TotalPlannedOrders = len(plan.Stops)
+	route.PlannedStartTime = &opts.StartTime
+	route.PlannedEndTime = &plan.EstimatedEnd
+
+	optimizedAt := time.Now()
+	route.SetOptimizationData(map[string]interface{}{
+		"algorithm":       plan.Algorithm,
+		"optimized_by":    req.OptimizedBy,
+		"optimized_at":    optimizedAt,
+		"total_stops":     len(plan.Stops),
+		"total_distance":  plan.TotalDistance,
+		"total_weight":    plan.TotalWeight,
+		"total_volume":    plan.TotalVolume,
+		"stops":           plan.Stops,
+		"late_stops":      plan.LateStops,
+		"unlocated_stops": plan.UnlocatedStops,
+		"diff":            diff,
+	})
+
+	// The stop sequence with its ETAs and the route's plan are saved together
+	err = uc.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := uc.deliveryRepo.UpdateStopSequence(ctx, route.ID, plan.Stops); err != nil {
+			return fmt.Errorf("failed to save stop sequence: %w", err)
+		}
+		if err := uc.routeRepo.Update(ctx, route); err != nil {
+			return fmt.Errorf("failed to update route: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	// Publish event
 	uc.eventPub.Publish(ctx, "route.optimized", map[string]interface{}{
-		"route_id":     route.ID.String(),
-		"algorithm":    req.Algorithm,
-		"optimized_by": req.OptimizedBy,
-		"optimized_at": time.Now(),
-		"total_stops":  len(deliveries),
+		"route_id":       route.ID.String(),
+		"algorithm":      plan.Algorithm,
+		"optimized_by":   req.OptimizedBy,
+		"optimized_at":   optimizedAt,
+		"total_stops":    len(plan.Stops),
+		"total_distance": plan.TotalDistance,
+		"late_stops":     plan.LateStops,
+		"diff":           diff,
 	})
 
-	return route, nil
+	return &RouteOptimizationResult{Route: route, Plan: plan, Diff: diff}, nil
+}
+
+// PlanDailyRoutes drafts one route per vehicle for the pending self-deliveries of a date.
+// Deliveries are matched to self-delivery coverage areas, capped at each area's daily capacity
+// less the deliveries already routed that day, and packed onto available vehicles with a driver
+// within their weight and volume limits. The routes stay in draft until the plan is approved;
+// re-planning replaces the previous draft in the same transaction.
+func (uc *RoutingUseCase) PlanDailyRoutes(ctx context.Context, req PlanDailyRoutesRequest) (*DailyRoutePlan, error) {
+	plannedAt := time.Now()
+
+	// Discarding the previous draft and saving the new one is all or nothing
+	var plan *DailyRoutePlan
+	err := uc.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		plan, err = uc.draftDailyPlan(ctx, req, plannedAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Publish event
+	uc.eventPub.Publish(ctx, "route.plan_drafted", map[string]interface{}{
+		"plan_id":        plan.PlanID.String(),
+		"date":           req.Date,
+		"total_routes":   len(plan.Routes),
+		"total_stops":    plan.TotalStops,
+		"total_distance": plan.TotalDistance,
+		"unplanned":      len(plan.Unplanned),
+		"planned_by":     req.PlannedBy,
+		"planned_at":     plannedAt,
+	})
+
+	return plan, nil
+}
+
+// draftDailyPlan replaces the draft routes of a date with a new plan
+func (uc *RoutingUseCase) draftDailyPlan(ctx context.Context, req PlanDailyRoutesRequest, plannedAt time.Time) (*DailyRoutePlan, error) {
+	if _, err := uc.discardDraftRoutes(ctx, req.Date); err != nil {
+		return nil, err
+	}
+
+	candidates, err := uc.deliveryRepo.GetPlanningCandidates(ctx, req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planning candidates: %w", err)
+	}
+
+	routed, err := uc.deliveryRepo.GetRoutedForDate(ctx, req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routed deliveries: %w", err)
+	}
+
+	areas, err := uc.coverageRepo.GetSelfDeliveryAreas(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get self-delivery areas: %w", err)
+	}
+
+	vehicles, err := uc.plannableVehicles(ctx, req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	loads, unplanned := planVehicleLoads(candidates, routed, areas, vehicles, req.Depot, req.MaxStopsPerVehicle)
+
+	plan := &DailyRoutePlan{
+		PlanID:    uuid.New(),
+		Date:      req.Date,
+		Routes:    make([]*PlannedRoute, 0, len(loads)),
+		Unplanned: unplanned,
+	}
+
+	routesPerGroup := make(map[string]int)
+	for _, load := range loads {
+		routesPerGroup[load.group]++
+		routeName := fmt.Sprintf("%s %s #%d", load.group, req.Date.Format("2006-01-02"), routesPerGroup[load.group])
+
+		route, err := entity.NewDeliveryRoute(routeName, req.Date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create route entity: %w", err)
+		}
+		if err := route.MarkAsDraft(); err != nil {
+			return nil, fmt.Errorf("failed to mark route as draft: %w", err)
+		}
+		if err := route.AssignVehicle(load.vehicle.ID, load.vehicle.DriverID); err != nil {
+			return nil, fmt.Errorf("failed to assign vehicle to route: %w", err)
+		}
+
+		opts := OptimizerOptions{
+			Algorithm:       AlgorithmConstrained,
+			Depot:           req.Depot,
+			StartTime:       uc.routeStartTime(route, req.StartTime),
+			AverageSpeedKmh: req.AverageSpeedKmh,
+			ServiceTime:     time.Duration(req.ServiceMinutes) * time.Minute,
+			Vehicle:         load.vehicle,
+		}
+		result, err := sequenceLoad(load.stops, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sequence route %s: %w", routeName, err)
+		}
+
+		route.TotalPlannedDistance = result.TotalDistance
+		route.TotalPlannedOrders = len(result.Stops)
+		route.PlannedStartTime = &opts.StartTime
+		route.PlannedEndTime = &result.EstimatedEnd
+		route.SetOptimizationData(map[string]interface{}{
+			"plan_id":         plan.PlanID,
+			"delivery_route":  load.group,
+			"planned_by":      req.PlannedBy,
+			"planned_at":      plannedAt,
+			"algorithm":       result.Algorithm,
+			"total_stops":     len(result.Stops),
+			"total_distance":  result.TotalDistance,
+			"total_weight":    result.TotalWeight,
+			"total_volume":    result.TotalVolume,
+			"stops":           result.Stops,
+			"late_stops":      result.LateStops,
+			"unlocated_stops": result.UnlocatedStops,
+		})
+
+		if err := uc.routeRepo.Create(ctx, route); err != nil {
+			return nil, fmt.Errorf("failed to save route: %w", err)
+		}
+
+		for _, stop := range result.Stops {
+			if err := uc.deliveryRepo.AssignRoute(ctx, stop.DeliveryID, route.ID); err != nil {
+				return nil, fmt.Errorf("failed to assign delivery to route: %w", err)
+			}
+		}
+
+		if err := uc.deliveryRepo.UpdateStopSequence(ctx, route.ID, result.Stops); err != nil {
+			return nil, fmt.Errorf("failed to save stop sequence: %w", err)
+		}
+
+		plan.Routes = append(plan.Routes, &PlannedRoute{Route: route, DeliveryRoute: load.group, Plan: result})
+		plan.TotalStops += len(result.Stops)
+		plan.TotalDistance += result.TotalDistance
+	}
+
+	return plan, nil
+}
+
+// ApproveDailyPlan moves the draft routes of a date to planned and marks their deliveries as planned
+func (uc *RoutingUseCase) ApproveDailyPlan(ctx context.Context, req ApproveDailyPlanRequest) ([]*entity.DeliveryRoute, error) {
+	approvedAt := time.Now()
+
+	// Every route of the plan is approved or none is
+	var drafts []*entity.DeliveryRoute
+	err := uc.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		drafts, err = uc.approveDraftRoutes(ctx, req, approvedAt)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	routeIDs := make([]string, len(drafts))
+	for i, route := range drafts {
+		routeIDs[i] = route.ID.String()
+	}
+
+	// Publish event
+	uc.eventPub.Publish(ctx, "route.plan_approved", map[string]interface{}{
+		"date":        req.Date,
+		"route_ids":   routeIDs,
+		"approved_by": req.ApprovedBy,
+		"approved_at": approvedAt,
+	})
+
+	return drafts, nil
+}
+
+// approveDraftRoutes moves the draft routes of a date to planned along with their deliveries
+func (uc *RoutingUseCase) approveDraftRoutes(ctx context.Context, req ApproveDailyPlanRequest, approvedAt time.Time) ([]*entity.DeliveryRoute, error) {
+	drafts, err := uc.draftRoutes(ctx, req.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(drafts) == 0 {
+		return nil, ErrNoDraftPlan
+	}
+
+	for _, route := range drafts {
+		if err := route.Approve(); err != nil {
+			return nil, fmt.Errorf("failed to approve route: %w", err)
+		}
+
+		data := route.RouteOptimizationData
+		if data == nil {
+			data = make(map[string]interface{})
+		}
+		data["approved_by"] = req.ApprovedBy
+		data["approved_at"] = approvedAt
+		route.SetOptimizationData(data)
+
+		if err := uc.routeRepo.Update(ctx, route); err != nil {
+			return nil, fmt.Errorf("failed to update route: %w", err)
+		}
+
+		deliveries, err := uc.deliveryRepo.GetByRouteID(ctx, route.ID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get route deliveries: %w", err)
+		}
+
+		var deliveryIDs []uuid.UUID
+		for _, delivery := range deliveries {
+			deliveryIDs = append(deliveryIDs, delivery.ID)
+		}
+
+		if len(deliveryIDs) > 0 {
+			if err := uc.deliveryRepo.UpdateMultipleStatuses(ctx, deliveryIDs, entity.DeliveryStatusPlanned); err != nil {
+				return nil, fmt.Errorf("failed to update delivery statuses: %w", err)
+			}
+		}
+	}
+
+	return drafts, nil
+}
+
+// DiscardDailyPlan deletes the draft routes of a date and releases their deliveries
+func (uc *RoutingUseCase) DiscardDailyPlan(ctx context.Context, date time.Time, discardedBy string) error {
+	var discarded int
+	err := uc.txManager.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		discarded, err = uc.discardDraftRoutes(ctx, date)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	if discarded == 0 {
+		return ErrNoDraftPlan
+	}
+
+	// Publish event
+	uc.eventPub.Publish(ctx, "route.plan_discarded", map[string]interface{}{
+		"date":         date,
+		"total_routes": discarded,
+		"discarded_by": discardedBy,
+		"discarded_at": time.Now(),
+	})
+
+	return nil
+}
+
+// draftRoutes returns the routes of a date that are still awaiting approval
+func (uc *RoutingUseCase) draftRoutes(ctx context.Context, date time.Time) ([]*entity.DeliveryRoute, error) {
+	routes, err := uc.routeRepo.GetByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes by date: %w", err)
+	}
+
+	var drafts []*entity.DeliveryRoute
+	for _, route := range routes {
+		if route.Status == entity.RouteStatusDraft {
+			drafts = append(drafts, route)
+		}
+	}
+
+	return drafts, nil
+}
+
+// discardDraftRoutes deletes the draft routes of a date after releasing their deliveries. Callers
+// run it in a transaction so a failure never leaves part of a plan behind.
+func (uc *RoutingUseCase) discardDraftRoutes(ctx context.Context, date time.Time) (int, error) {
+	drafts, err := uc.draftRoutes(ctx, date)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, route := range drafts {
+		if err := uc.deliveryRepo.UnassignRoute(ctx, route.ID); err != nil {
+			return 0, fmt.Errorf("failed to release route deliveries: %w", err)
+		}
+		if err := uc.routeRepo.Delete(ctx, route.ID); err != nil {
+			return 0, fmt.Errorf("failed to delete draft route: %w", err)
+		}
+	}
+
+	return len(drafts), nil
+}
+
+// plannableVehicles returns available vehicles with an assigned driver where neither the vehicle
+// nor the driver already has a planned or in-progress route on the date
+func (uc *RoutingUseCase) plannableVehicles(ctx context.Context, date time.Time) ([]*entity.DeliveryVehicle, error) {
+	vehicles, err := uc.vehicleRepo.GetAvailable(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get available vehicles: %w", err)
+	}
+
+	routes, err := uc.routeRepo.GetByDate(ctx, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routes by date: %w", err)
+	}
+
+	busyVehicles := make(map[uuid.UUID]bool)
+	busyDrivers := make(map[uuid.UUID]bool)
+	for _, route := range routes {
+		if !route.IsActive() {
+			continue
+		}
+		if route.AssignedVehicleID != nil {
+			busyVehicles[*route.AssignedVehicleID] = true
+		}
+		if route.AssignedDriverID != nil {
+			busyDrivers[*route.AssignedDriverID] = true
+		}
+	}
+
+	var plannable []*entity.DeliveryVehicle
+	for _, vehicle := range vehicles {
+		if !vehicle.IsAvailable() || vehicle.DriverID == nil {
+			continue
+		}
+		if busyVehicles[vehicle.ID] || busyDrivers[*vehicle.DriverID] {
+			continue
+		}
+		plannable = append(plannable, vehicle)
+	}
+
+	return plannable, nil
+}
+
+// routeStartTime resolves when a route departs: the explicit request time, the route's
+// planned start, or 08:00 on the route date
+func (uc *RoutingUseCase) routeStartTime(route *entity.DeliveryRoute, requested *time.Time) time.Time {
+	if requested != nil {
+		return *requested
+	}
+	if route.PlannedStartTime != nil {
+		return *route.PlannedStartTime
+	}
+	d := route.RouteDate
+	return time.Date(d.Year(), d.Month(), d.Day(), 8, 0, 0, 0, d.Location())
+}
+
+// currentDistance measures the route distance in its existing stop order
+func currentDistance(stops []OptimizerStop, depot *repository.Coordinates) float64 {
+	var located []OptimizerStop
+	for _, s := range stops {
+		if s.Location != nil {
+			located = append(located, s)
+		}
+	}
+	order := make([]int, len(located))
+	for i := range order {
+		order[i] = i
+	}
+	return pathDistance(located, depot, order)
 }
 
 // GetRouteMetrics retrieves metrics for a specific route
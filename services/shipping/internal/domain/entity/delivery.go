@@ -100,6 +100,19 @@ func (d *DeliveryOrder) UpdateStatus(status DeliveryStatus) {
 	}
 }
 
+// Cancel cancels a delivery that has not left yet. Cancelling a cancelled delivery does nothing.
+func (d *DeliveryOrder) Cancel() error {
+	switch d.Status {
+	case DeliveryStatusCancelled:
+		return nil
+	case DeliveryStatusPending, DeliveryStatusPlanned, DeliveryStatusFailed:
+		d.UpdateStatus(DeliveryStatusCancelled)
+		return nil
+	default:
+		return ErrDeliveryCannotBeCancelled
+	}
+}
+
 // SetTrackingInfo sets tracking information from provider
 func (d *DeliveryOrder) SetTrackingInfo(trackingNumber, providerOrderID string) {
 	d.TrackingNumber = &trackingNumber
@@ -0,0 +1,70 @@
+package entity
+
+// grabStatuses maps Grab Express delivery statuses onto delivery statuses
+var grabStatuses = map[string]DeliveryStatus{
+	"ALLOCATING":       DeliveryStatusPending,
+	"PENDING_PICKUP":   DeliveryStatusPlanned,
+	"PICKING_UP":       DeliveryStatusPlanned,
+	"PENDING_DROP_OFF": DeliveryStatusDispatched,
+	"IN_DELIVERY":      DeliveryStatusInTransit,
+	"COMPLETED":        DeliveryStatusDelivered,
+	"CANCELED":         DeliveryStatusCancelled,
+	"FAILED":           DeliveryStatusFailed,
+	"IN_RETURN":        DeliveryStatusFailed,
+	"RETURNED":         DeliveryStatusFailed,
+}
+
+// lineManStatuses maps LINE MAN delivery statuses onto delivery statuses
+var lineManStatuses = map[string]DeliveryStatus{
+	"ASSIGNING_DRIVER":   DeliveryStatusPending,
+	"DRIVER_ASSIGNED":    DeliveryStatusPlanned,
+	"ARRIVED_AT_PICKUP":  DeliveryStatusPlanned,
+	"PICKED_UP":          DeliveryStatusDispatched,
+	"DELIVERING":         DeliveryStatusInTransit,
+	"ARRIVED_AT_DROPOFF": DeliveryStatusInTransit,
+	"COMPLETED":          DeliveryStatusDelivered,
+	"CANCELLED":          DeliveryStatusCancelled,
+	"FAILED":             DeliveryStatusFailed,
+	"RETURNED":           DeliveryStatusFailed,
+}
+
+// deliveryStatusRank orders statuses along the delivery lifecycle
+var deliveryStatusRank = map[DeliveryStatus]int{
+	DeliveryStatusPending:    0,
+	DeliveryStatusPlanned:    1,
+	DeliveryStatusDispatched: 2,
+	DeliveryStatusInTransit:  3,
+	DeliveryStatusDelivered:  4,
+	DeliveryStatusFailed:     4,
+	DeliveryStatusCancelled:  4,
+}
+
+// MapProviderStatus maps a third-party provider status onto a delivery status
+func MapProviderStatus(method DeliveryMethod, providerStatus string) (DeliveryStatus, bool) {
+	var statuses map[string]DeliveryStatus
+	switch method {
+	case DeliveryMethodGrab:
+		statuses = grabStatuses
+	case DeliveryMethodLineMan:
+		statuses = lineManStatuses
+	default:
+		return "", false
+	}
+
+	status, ok := statuses[providerStatus]
+	return status, ok
+}
+
+// IsFinal returns true once the delivery is delivered, failed or cancelled
+func (s DeliveryStatus) IsFinal() bool {
+	return s == DeliveryStatusDelivered || s == DeliveryStatusFailed || s == DeliveryStatusCancelled
+}
+
+// Advances returns true if moving from the current status to next goes forward in the
+// lifecycle. Provider webhooks can arrive out of order, so older statuses are ignored.
+func (s DeliveryStatus) Advances(next DeliveryStatus) bool {
+	if s.IsFinal() {
+		return false
+	}
+	return deliveryStatusRank[next] > deliveryStatusRank[s]
+}
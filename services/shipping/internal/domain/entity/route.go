@@ -39,6 +39,7 @@ type DeliveryRoute struct {
 type RouteStatus string
 
 const (
+	RouteStatusDraft       RouteStatus = "draft"
 	RouteStatusPlanned     RouteStatus = "planned"
 	RouteStatusInProgress  RouteStatus = "in_progress"
 	RouteStatusCompleted   RouteStatus = "completed"
@@ -56,6 +57,7 @@ var (
 	ErrRouteNotStarted         = errors.New("route has not been started yet")
 	ErrRouteAlreadyCompleted   = errors.New("route is already completed")
 	ErrRouteInvalidStatus      = errors.New("invalid route status")
+	ErrRouteNotDraft           = errors.New("route is not a draft")
 )
 
 // NewDeliveryRoute creates a new delivery route with validation
@@ -82,7 +84,7 @@ func NewDeliveryRoute(routeName string, routeDate time.Time) (*DeliveryRoute, er
 
 // AssignVehicle assigns a vehicle to the route
 func (r *DeliveryRoute) AssignVehicle(vehicleID uuid.UUID, driverID *uuid.UUID) error {
-	if r.Status != RouteStatusPlanned {
+	if r.Status != RouteStatusPlanned && r.Status != RouteStatusDraft {
 		return ErrRouteAlreadyStarted
 	}
 	
@@ -116,6 +118,30 @@ func (r *DeliveryRoute) SetPlanning(startTime, endTime time.Time, distance float
 	return nil
 }
 
+// MarkAsDraft marks the route as part of a plan that still needs approval
+func (r *DeliveryRoute) MarkAsDraft() error {
+	if r.Status != RouteStatusPlanned {
+		return ErrRouteInvalidStatus
+	}
+	
+	r.Status = RouteStatusDraft
+	r.UpdatedAt = time.Now()
+	
+	return nil
+}
+
+// Approve moves a draft route to planned once the plan has been signed off
+func (r *DeliveryRoute) Approve() error {
+	if r.Status != RouteStatusDraft {
+		return ErrRouteNotDraft
+	}
+	
+	r.Status = RouteStatusPlanned
+	r.UpdatedAt = time.Now()
+	
+	return nil
+}
+
 // StartRoute starts the route execution
 func (r *DeliveryRoute) StartRoute() error {
 	if r.Status != RouteStatusPlanned {
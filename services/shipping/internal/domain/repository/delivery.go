@@ -15,6 +15,7 @@ type DeliveryRepository interface {
 	GetByID(ctx context.Context, id uuid.UUID) (*entity.DeliveryOrder, error)
 	GetByOrderID(ctx context.Context, orderID uuid.UUID) (*entity.DeliveryOrder, error)
 	GetByTrackingNumber(ctx context.Context, trackingNumber string) (*entity.DeliveryOrder, error)
+	GetByProviderOrderID(ctx context.Context, method entity.DeliveryMethod, providerOrderID string) (*entity.DeliveryOrder, error)
 	Update(ctx context.Context, delivery *entity.DeliveryOrder) error
 	Delete(ctx context.Context, id uuid.UUID) error
 	
@@ -49,6 +50,15 @@ type DeliveryRepository interface {
 	AssignVehicle(ctx context.Context, id uuid.UUID, vehicleID uuid.UUID) error
 	AssignRoute(ctx context.Context, id uuid.UUID, routeID uuid.UUID) error
 	
+	// Route stop sequencing
+	GetRouteStops(ctx context.Context, routeID uuid.UUID) ([]*RouteStopDetails, error)
+	UpdateStopSequence(ctx context.Context, routeID uuid.UUID, stops []RouteStop) error
+	
+	// Daily route planning
+	GetPlanningCandidates(ctx context.Context, date time.Time) ([]*PlanningCandidate, error)
+	GetRoutedForDate(ctx context.Context, date time.Time) ([]*PlanningCandidate, error)
+	UnassignRoute(ctx context.Context, routeID uuid.UUID) error
+	
 	// Bulk operations
 	UpdateMultipleStatuses(ctx context.Context, ids []uuid.UUID, status entity.DeliveryStatus) error
 	GetDeliveriesByIDs(ctx context.Context, ids []uuid.UUID) ([]*entity.DeliveryOrder, error)
@@ -83,3 +93,26 @@ type DeliveryMetrics struct {
 	SuccessRate           float64                                  `json:"success_rate_percentage"`
 	OnTimeDeliveryRate    float64                                  `json:"on_time_delivery_rate"`
 }
+
+// RouteStopDetails holds the data needed to sequence a delivery within a route
+type RouteStopDetails struct {
+	DeliveryID          uuid.UUID  `json:"delivery_id" db:"delivery_id"`
+	Address             string     `json:"address" db:"address"`
+	Latitude            *float64   `json:"latitude" db:"latitude"`
+	Longitude           *float64   `json:"longitude" db:"longitude"`
+	Weight              float64    `json:"weight" db:"weight"`
+	Volume              float64    `json:"volume" db:"volume"`
+	DeliveryWindowStart *time.Time `json:"delivery_window_start" db:"delivery_window_start"`
+	DeliveryWindowEnd   *time.Time `json:"delivery_window_end" db:"delivery_window_end"`
+	StopSequence        *int       `json:"stop_sequence" db:"stop_sequence"`
+}
+
+// PlanningCandidate is an unrouted self-delivery with the address details needed to
+// match it to a coverage area and place it on a vehicle
+type PlanningCandidate struct {
+	RouteStopDetails
+	Province    string `json:"province" db:"province"`
+	District    string `json:"district" db:"district"`
+	Subdistrict string `json:"subdistrict" db:"subdistrict"`
+	PostalCode  string `json:"postal_code" db:"postal_code"`
+}
@@ -10,6 +10,8 @@ type Config struct {
 	RedisURL        string
 	KafkaBrokers    []string
 	KafkaTopic      string
+	KafkaGroupID    string
+	ProviderUpdatesTopic string
 	ServiceName     string
 }
 
@@ -20,6 +22,8 @@ func Load() *Config {
 		RedisURL:     getEnv("REDIS_URL", "redis://localhost:6379"),
 		KafkaBrokers: []string{getEnv("KAFKA_BROKERS", "localhost:9092")},
 		KafkaTopic:   getEnv("KAFKA_TOPIC", "shipping.events"),
+		KafkaGroupID: getEnv("KAFKA_GROUP_ID", "shipping-service"),
+		ProviderUpdatesTopic: getEnv("KAFKA_PROVIDER_UPDATES_TOPIC", "delivery-updates"),
 		ServiceName:  getEnv("SERVICE_NAME", "shipping-service"),
 	}
 }
@@ -1,7 +1,9 @@
 package database
 
 import (
+	"context"
 	"fmt"
+	"log"
 	
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
@@ -21,3 +23,59 @@ func NewConnection(databaseURL string) (*sqlx.DB, error) {
 	
 	return db, nil
 }
+
+// txKey is the context key under which an active transaction is stored
+type txKey struct{}
+
+// TxManager runs work in a database transaction
+type TxManager struct {
+	db *sqlx.DB
+}
+
+// NewTxManager creates a transaction manager for the connection pool
+func NewTxManager(db *sqlx.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithTransaction runs fn inside a database transaction. Repositories resolve their executor
+// through executor(ctx), so every write made inside fn is committed or rolled back together.
+func (m *TxManager) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	// Join an already running transaction instead of nesting
+	if _, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			_ = tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Printf("Failed to rollback transaction: %v", rbErr)
+		}
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// executor returns the transaction bound to ctx, or the connection pool when no transaction
+// is active
+func executor(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+	if tx, ok := ctx.Value(txKey{}).(*sqlx.Tx); ok {
+		return tx
+	}
+	return db
+}
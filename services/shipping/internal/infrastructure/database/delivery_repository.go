@@ -115,6 +115,29 @@ func (r *DeliveryRepository) GetByTrackingNumber(ctx context.Context, trackingNu
 	return &delivery, nil
 }
 
+// GetByProviderOrderID retrieves a delivery by the order ID of its third-party provider
+func (r *DeliveryRepository) GetByProviderOrderID(ctx context.Context, method entity.DeliveryMethod, providerOrderID string) (*entity.DeliveryOrder, error) {
+	query := `
+		SELECT id, order_id, customer_id, customer_address_id, delivery_method,
+			   priority_level, delivery_fee, cod_amount, weight, volume,
+			   provider_id, provider_order_id, tracking_number, vehicle_id,
+			   route_id, estimated_delivery_time, status, notes, attempts,
+			   completed_at, created_at, updated_at
+		FROM deliveries 
+		WHERE delivery_method = $1 AND provider_order_id = $2`
+
+	var delivery entity.DeliveryOrder
+	err := r.db.GetContext(ctx, &delivery, query, method, providerOrderID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("delivery not found: %w", err)
+		}
+		return nil, fmt.Errorf("failed to get delivery by provider order ID: %w", err)
+	}
+
+	return &delivery, nil
+}
+
 // GetByStatus retrieves deliveries by status
 func (r *DeliveryRepository) GetByStatus(ctx context.Context, status entity.DeliveryStatus, limit, offset int) ([]*entity.DeliveryOrder, error) {
 	query := `
@@ -330,7 +353,7 @@ func (r *DeliveryRepository) AssignRoute(ctx context.Context, deliveryID, routeI
 			updated_at = $2
 		WHERE id = $3`
 
-	result, err := r.db.ExecContext(ctx, query, routeID, time.Now(), deliveryID)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, routeID, time.Now(), deliveryID)
 	if err != nil {
 		return fmt.Errorf("failed to assign route: %w", err)
 	}
@@ -359,7 +382,7 @@ func (r *DeliveryRepository) UpdateMultipleStatuses(ctx context.Context, ids []u
 			updated_at = $2
 		WHERE id = ANY($3)`
 
-	result, err := r.db.ExecContext(ctx, query, status, time.Now(), ids)
+	result, err := executor(ctx, r.db).ExecContext(ctx, query, status, time.Now(), ids)
 	if err != nil {
 		return fmt.Errorf("failed to update multiple delivery statuses: %w", err)
 	}
@@ -515,7 +538,7 @@ func (r *DeliveryRepository) GetByRouteID(ctx context.Context, routeID uuid.UUID
 		ORDER BY created_at`
 
 	var deliveries []*entity.DeliveryOrder
-	err := r.db.SelectContext(ctx, &deliveries, query, routeID)
+	err := sqlx.SelectContext(ctx, executor(ctx, r.db), &deliveries, query, routeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get deliveries by route ID: %w", err)
 	}
@@ -523,6 +546,142 @@ func (r *DeliveryRepository) GetByRouteID(ctx context.Context, routeID uuid.UUID
 	return deliveries, nil
 }
 
+// GetRouteStops retrieves the stops of a route with the customer address coordinates,
+// ordered by their current stop sequence
+func (r *DeliveryRepository) GetRouteStops(ctx context.Context, routeID uuid.UUID) ([]*repository.RouteStopDetails, error) {
+	query := `
+		SELECT d.id AS delivery_id,
+			   COALESCE(ca.address_line1, '') AS address,
+			   ca.latitude, ca.longitude,
+			   COALESCE(d.weight, 0) AS weight, COALESCE(d.volume, 0) AS volume,
+			   d.delivery_window_start, d.delivery_window_end, d.stop_sequence
+		FROM deliveries d
+		LEFT JOIN customer_addresses ca ON ca.id = d.customer_address_id
+		WHERE d.route_id = $1
+		AND d.status NOT IN ($2, $3)
+		ORDER BY d.stop_sequence NULLS LAST, d.created_at`
+
+	var stops []*repository.RouteStopDetails
+	err := r.db.SelectContext(ctx, &stops, query, routeID,
+		entity.DeliveryStatusCancelled, entity.DeliveryStatusDelivered)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get route stops: %w", err)
+	}
+
+	return stops, nil
+}
+
+// UpdateStopSequence stores the stop sequence and ETA of every stop in a route. It joins the
+// caller's transaction, or runs in its own.
+func (r *DeliveryRepository) UpdateStopSequence(ctx context.Context, routeID uuid.UUID, stops []repository.RouteStop) error {
+	query := `
+		UPDATE deliveries 
+		SET stop_sequence = $1, estimated_delivery_time = $2, updated_at = $3
+		WHERE id = $4 AND route_id = $5`
+
+	return NewTxManager(r.db).WithTransaction(ctx, func(ctx context.Context) error {
+		now := time.Now()
+		for _, stop := range stops {
+			var eta *time.Time
+			if !stop.EstimatedArrival.IsZero() {
+				arrival := stop.EstimatedArrival
+				eta = &arrival
+			}
+
+			if _, err := executor(ctx, r.db).ExecContext(ctx, query, stop.Sequence, eta, now, stop.DeliveryID, routeID); err != nil {
+				return fmt.Errorf("failed to update stop sequence: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// GetPlanningCandidates retrieves pending self-deliveries for a date that are not on a route yet,
+// earliest delivery window first
+func (r *DeliveryRepository) GetPlanningCandidates(ctx context.Context, date time.Time) ([]*repository.PlanningCandidate, error) {
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	endOfDay := startOfDay.Add(24 * time.Hour)
+
+	query := `
+		SELECT d.id AS delivery_id,
+			   COALESCE(ca.address_line1, '') AS address,
+			   ca.latitude, ca.longitude,
+			   COALESCE(d.weight, 0) AS weight, COALESCE(d.volume, 0) AS volume,
+			   d.delivery_window_start, d.delivery_window_end, d.stop_sequence,
+			   COALESCE(ca.province, '') AS province, COALESCE(ca.district, '') AS district,
+			   COALESCE(ca.subdistrict, '') AS subdistrict, COALESCE(ca.postal_code, '') AS postal_code
+		FROM deliveries d
+		LEFT JOIN customer_addresses ca ON ca.id = d.customer_address_id
+		WHERE d.delivery_method = $1
+		AND d.status = $2
+		AND d.route_id IS NULL
+		AND d.planned_delivery_date >= $3
+		AND d.planned_delivery_date < $4
+		ORDER BY d.delivery_window_end NULLS LAST, d.created_at`
+
+	var candidates []*repository.PlanningCandidate
+	err := sqlx.SelectContext(ctx, executor(ctx, r.db), &candidates, query,
+		entity.DeliveryMethodSelfDelivery,
+		entity.DeliveryStatusPending,
+		startOfDay, endOfDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get planning candidates: %w", err)
+	}
+
+	return candidates, nil
+}
+
+// GetRoutedForDate retrieves the self-deliveries for a date that are already on a route, with
+// the address details needed to match them to coverage areas
+func (r *DeliveryRepository) GetRoutedForDate(ctx context.Context, date time.Time) ([]*repository.PlanningCandidate, error) {
+	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
+	endOfDay := startOfDay.Add(24 * time.Hour)
+
+	query := `
+		SELECT d.id AS delivery_id,
+			   COALESCE(ca.address_line1, '') AS address,
+			   ca.latitude, ca.longitude,
+			   COALESCE(d.weight, 0) AS weight, COALESCE(d.volume, 0) AS volume,
+			   d.delivery_window_start, d.delivery_window_end, d.stop_sequence,
+			   COALESCE(ca.province, '') AS province, COALESCE(ca.district, '') AS district,
+			   COALESCE(ca.subdistrict, '') AS subdistrict, COALESCE(ca.postal_code, '') AS postal_code
+		FROM deliveries d
+		LEFT JOIN customer_addresses ca ON ca.id = d.customer_address_id
+		WHERE d.delivery_method = $1
+		AND d.status <> $2
+		AND d.route_id IS NOT NULL
+		AND d.planned_delivery_date >= $3
+		AND d.planned_delivery_date < $4`
+
+	var routed []*repository.PlanningCandidate
+	err := sqlx.SelectContext(ctx, executor(ctx, r.db), &routed, query,
+		entity.DeliveryMethodSelfDelivery,
+		entity.DeliveryStatusCancelled,
+		startOfDay, endOfDay)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get routed deliveries: %w", err)
+	}
+
+	return routed, nil
+}
+
+// UnassignRoute releases every delivery from a route and clears its stop sequence
+func (r *DeliveryRepository) UnassignRoute(ctx context.Context, routeID uuid.UUID) error {
+	query := `
+		UPDATE deliveries SET
+			route_id = NULL,
+			stop_sequence = NULL,
+			estimated_delivery_time = NULL,
+			updated_at = $1
+		WHERE route_id = $2`
+
+	if _, err := executor(ctx, r.db).ExecContext(ctx, query, time.Now(), routeID); err != nil {
+		return fmt.Errorf("failed to unassign route deliveries: %w", err)
+	}
+
+	return nil
+}
+
 // GetByDeliveryMethod retrieves deliveries by delivery method
 func (r *DeliveryRepository) GetByDeliveryMethod(ctx context.Context, method entity.DeliveryMethod, limit, offset int) ([]*entity.DeliveryOrder, error) {
 	query := `
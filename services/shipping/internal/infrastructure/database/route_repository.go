@@ -46,7 +46,7 @@ func (r *routeRepository) Create(ctx context.Context, route *entity.DeliveryRout
 		"total_planned_orders": route.TotalPlannedOrders,
 	})
 
-	_, err := r.db.NamedExecContext(ctx, query, map[string]interface{}{
+	_, err := sqlx.NamedExecContext(ctx, executor(ctx, r.db), query, map[string]interface{}{
 		"id":                      route.ID,
 		"route_name":              route.RouteName,
 		"route_date":              route.RouteDate,
@@ -167,7 +167,7 @@ func (r *routeRepository) Update(ctx context.Context, route *entity.DeliveryRout
 	})
 	route.UpdatedAt = time.Now()
 
-	_, err := r.db.NamedExecContext(ctx, query, map[string]interface{}{
+	_, err := sqlx.NamedExecContext(ctx, executor(ctx, r.db), query, map[string]interface{}{
 		"id":                      route.ID,
 		"route_name":              route.RouteName,
 		"route_date":              route.RouteDate,
@@ -191,7 +191,7 @@ func (r *routeRepository) Update(ctx context.Context, route *entity.DeliveryRout
 // Delete deletes a route by ID
 func (r *routeRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `DELETE FROM delivery_routes WHERE id = $1`
-	_, err := r.db.ExecContext(ctx, query, id)
+	_, err := executor(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
 
@@ -226,7 +226,7 @@ func (r *routeRepository) GetByDate(ctx context.Context, date time.Time) ([]*ent
 		WHERE route_date = $1
 		ORDER BY route_name`
 
-	rows, err := r.db.QueryxContext(ctx, query, date)
+	rows, err := executor(ctx, r.db).QueryxContext(ctx, query, date)
 	if err != nil {
 		return nil, err
 	}
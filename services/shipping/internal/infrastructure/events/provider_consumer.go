@@ -0,0 +1,120 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"shipping/internal/application"
+	"shipping/internal/domain/repository"
+)
+
+const (
+	maxHandleAttempts = 5
+	retryBaseDelay    = 500 * time.Millisecond
+)
+
+// ProviderUpdateApplier applies normalized provider updates to deliveries
+type ProviderUpdateApplier interface {
+	ApplyProviderUpdate(ctx context.Context, update *application.ProviderStatusUpdate) error
+}
+
+// ProviderConsumer consumes Grab and LINE MAN updates published by delivery-webhook. Offsets
+// are committed only after an update is applied or dropped, so a crash redelivers it.
+type ProviderConsumer struct {
+	reader  *kafka.Reader
+	applier ProviderUpdateApplier
+}
+
+// NewProviderConsumer creates a new provider update consumer
+func NewProviderConsumer(brokers []string, topic, groupID string, applier ProviderUpdateApplier) *ProviderConsumer {
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:     brokers,
+		Topic:       topic,
+		GroupID:     groupID,
+		StartOffset: kafka.FirstOffset,
+		MinBytes:    1,
+		MaxBytes:    10e6,
+	})
+
+	return &ProviderConsumer{
+		reader:  reader,
+		applier: applier,
+	}
+}
+
+// Start consumes provider updates until the context is cancelled
+func (c *ProviderConsumer) Start(ctx context.Context) {
+	log.Printf("Starting provider update consumer on topic %s", c.reader.Config().Topic)
+
+	for {
+		msg, err := c.reader.FetchMessage(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("Failed to fetch provider update: %v", err)
+			continue
+		}
+
+		c.handleWithRetry(ctx, msg)
+
+		if err := c.reader.CommitMessages(ctx, msg); err != nil && ctx.Err() == nil {
+			log.Printf("Failed to commit provider update: %v", err)
+		}
+	}
+}
+
+// Close closes the Kafka reader
+func (c *ProviderConsumer) Close() error {
+	return c.reader.Close()
+}
+
+// HandleMessage decodes and applies a single provider update. Errors that a retry cannot fix
+// are logged and swallowed; only transient errors are returned.
+func (c *ProviderConsumer) HandleMessage(ctx context.Context, msg kafka.Message) error {
+	var update application.ProviderStatusUpdate
+	if err := json.Unmarshal(msg.Value, &update); err != nil {
+		log.Printf("Dropping malformed provider update at offset %d: %v", msg.Offset, err)
+		return nil
+	}
+
+	err := c.applier.ApplyProviderUpdate(ctx, &update)
+	if err != nil && isPermanent(err) {
+		log.Printf("Dropping %s update %s: %v", update.Provider, update.EventID, err)
+		return nil
+	}
+	return err
+}
+
+func (c *ProviderConsumer) handleWithRetry(ctx context.Context, msg kafka.Message) {
+	delay := retryBaseDelay
+	for attempt := 1; ; attempt++ {
+		err := c.HandleMessage(ctx, msg)
+		if err == nil {
+			return
+		}
+
+		if attempt == maxHandleAttempts {
+			log.Printf("Giving up on provider update at offset %d: %v", msg.Offset, err)
+			return
+		}
+
+		log.Printf("Failed to apply provider update (attempt %d), retrying: %v", attempt, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// isPermanent reports whether applying the update again cannot succeed
+func isPermanent(err error) bool {
+	return errors.Is(err, repository.ErrDeliveryNotFound) ||
+		errors.Is(err, application.ErrProviderMismatch)
+}
@@ -2,6 +2,7 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -74,12 +75,36 @@ func (h *DeliveryHandler) UpdateDelivery(w http.ResponseWriter, r *http.Request)
 	})
 }
 
-// CancelDelivery cancels a delivery (placeholder)
+// CancelDelivery cancels a delivery that has not been dispatched
 func (h *DeliveryHandler) CancelDelivery(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement CancelDelivery method in the use case
-	writeJSONResponse(w, r, http.StatusOK, map[string]string{
-		"message": "Cancel delivery endpoint - method not implemented yet",
-	})
+	vars := mux.Vars(r)
+	deliveryID, err := parseUUID(vars["id"])
+	if err != nil {
+		writeBadRequestError(w, r, "Invalid delivery ID")
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeBadRequestError(w, r, "Invalid request body")
+			return
+		}
+	}
+
+	err = h.deliveryUseCase.CancelDelivery(r.Context(), deliveryID, req.Reason)
+	if errors.Is(err, entity.ErrDeliveryCannotBeCancelled) {
+		writeConflictError(w, r, err.Error())
+		return
+	}
+	if err != nil {
+		writeInternalServerError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, map[string]string{"message": "Delivery cancelled successfully"})
 }
 
 // AssignDelivery assigns a delivery to a vehicle
@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
+	"time"
 
+	"github.com/gorilla/mux"
 	"shipping/internal/application"
+	"shipping/internal/domain/entity"
 )
 
 // RoutingHandler handles routing-related HTTP requests
@@ -36,10 +41,123 @@ func (h *RoutingHandler) CreateRoute(w http.ResponseWriter, r *http.Request) {
 	writeErrorResponse(w, r, http.StatusNotImplemented, "NOT_IMPLEMENTED", "CreateRoute not implemented", "")
 }
 
-// OptimizeRoute optimizes a route
+// OptimizeRoute re-sequences the stops of a route
 func (h *RoutingHandler) OptimizeRoute(w http.ResponseWriter, r *http.Request) {
-	// TODO: Implement route optimization
-	writeErrorResponse(w, r, http.StatusNotImplemented, "NOT_IMPLEMENTED", "OptimizeRoute not implemented", "")
+	vars := mux.Vars(r)
+	id, err := parseUUID(vars["id"])
+	if err != nil {
+		writeBadRequestError(w, r, "Invalid route ID")
+		return
+	}
+
+	var req application.RouteOptimizationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequestError(w, r, "Invalid request body")
+		return
+	}
+	req.RouteID = id
+
+	result, err := h.routingUseCase.OptimizeRoute(r.Context(), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, application.ErrUnknownAlgorithm),
+			errors.Is(err, application.ErrVehicleRequired),
+			errors.Is(err, application.ErrNoLocatableStops),
+			errors.Is(err, entity.ErrInsufficientCapacity),
+			errors.Is(err, entity.ErrRouteAlreadyStarted):
+			writeErrorResponse(w, r, http.StatusUnprocessableEntity, "OPTIMIZATION_FAILED", "Route cannot be optimized", err.Error())
+		default:
+			writeInternalServerError(w, r, err)
+		}
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, result)
+}
+
+// PlanDailyRoutes drafts the self-delivery routes of a day for manager approval
+func (h *RoutingHandler) PlanDailyRoutes(w http.ResponseWriter, r *http.Request) {
+	var req application.PlanDailyRoutesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequestError(w, r, "Invalid request body")
+		return
+	}
+
+	if req.Date.IsZero() || req.PlannedBy == "" {
+		writeBadRequestError(w, r, "date and planned_by are required")
+		return
+	}
+
+	plan, err := h.routingUseCase.PlanDailyRoutes(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, entity.ErrRouteInvalidDate) {
+			writeBadRequestError(w, r, err.Error())
+			return
+		}
+		writeInternalServerError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusCreated, plan)
+}
+
+// ApproveDailyPlan moves a day's draft routes to planned
+func (h *RoutingHandler) ApproveDailyPlan(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse("2006-01-02", mux.Vars(r)["date"])
+	if err != nil {
+		writeBadRequestError(w, r, "Invalid plan date, expected YYYY-MM-DD")
+		return
+	}
+
+	var req application.ApproveDailyPlanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeBadRequestError(w, r, "Invalid request body")
+		return
+	}
+	req.Date = date
+
+	if req.ApprovedBy == "" {
+		writeBadRequestError(w, r, "approved_by is required")
+		return
+	}
+
+	routes, err := h.routingUseCase.ApproveDailyPlan(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, application.ErrNoDraftPlan) {
+			writeErrorResponse(w, r, http.StatusNotFound, "PLAN_NOT_FOUND", "No draft plan for date", err.Error())
+			return
+		}
+		writeInternalServerError(w, r, err)
+		return
+	}
+
+	writeJSONResponse(w, r, http.StatusOK, routes)
+}
+
+// DiscardDailyPlan deletes a day's draft routes
+func (h *RoutingHandler) DiscardDailyPlan(w http.ResponseWriter, r *http.Request) {
+	date, err := time.Parse("2006-01-02", mux.Vars(r)["date"])
+	if err != nil {
+		writeBadRequestError(w, r, "Invalid plan date, expected YYYY-MM-DD")
+		return
+	}
+
+	discardedBy := r.URL.Query().Get("discarded_by")
+	if discardedBy == "" {
+		writeBadRequestError(w, r, "discarded_by is required")
+		return
+	}
+
+	if err := h.routingUseCase.DiscardDailyPlan(r.Context(), date, discardedBy); err != nil {
+		if errors.Is(err, application.ErrNoDraftPlan) {
+			writeErrorResponse(w, r, http.StatusNotFound, "PLAN_NOT_FOUND", "No draft plan for date", err.Error())
+			return
+		}
+		writeInternalServerError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // UpdateRoute updates a route
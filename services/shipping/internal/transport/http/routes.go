@@ -38,6 +38,7 @@ func setupRoutes(
 	deliveryRoutes.HandleFunc("/{id}", deliveryHandler.UpdateDelivery).Methods("PUT")
 	deliveryRoutes.HandleFunc("/{id}", deliveryHandler.DeleteDelivery).Methods("DELETE")
 	deliveryRoutes.HandleFunc("/{id}/status", deliveryHandler.UpdateDeliveryStatus).Methods("PATCH")
+	deliveryRoutes.HandleFunc("/{id}/cancel", deliveryHandler.CancelDelivery).Methods("POST")
 	deliveryRoutes.HandleFunc("/{id}/tracking", deliveryHandler.GetDeliveryTracking).Methods("GET")
 	deliveryRoutes.HandleFunc("/tracking/{tracking_number}", deliveryHandler.GetDeliveryByTracking).Methods("GET")
 	deliveryRoutes.HandleFunc("/search", deliveryHandler.SearchDeliveries).Methods("GET")
@@ -62,7 +63,11 @@ func setupRoutes(
 	routingRoutes := api.PathPrefix("/routes").Subrouter()
 	routingRoutes.HandleFunc("/calculate", routingHandler.CalculateRoute).Methods("POST")
 	routingRoutes.HandleFunc("/optimize", routingHandler.OptimizeRoutes).Methods("POST")
+	routingRoutes.HandleFunc("/plans", routingHandler.PlanDailyRoutes).Methods("POST")
+	routingRoutes.HandleFunc("/plans/{date}/approve", routingHandler.ApproveDailyPlan).Methods("POST")
+	routingRoutes.HandleFunc("/plans/{date}", routingHandler.DiscardDailyPlan).Methods("DELETE")
 	routingRoutes.HandleFunc("/{id}", routingHandler.GetRoute).Methods("GET")
+	routingRoutes.HandleFunc("/{id}/optimize", routingHandler.OptimizeRoute).Methods("POST")
 
 	// Tracking routes
 	trackingRoutes := api.PathPrefix("/tracking").Subrouter()
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_deliveries_route_sequence;
+
+ALTER TABLE deliveries
+    DROP COLUMN IF EXISTS delivery_window_end,
+    DROP COLUMN IF EXISTS delivery_window_start,
+    DROP COLUMN IF EXISTS stop_sequence;
@@ -0,0 +1,11 @@
+-- Route stop sequencing: per-delivery stop order and customer delivery windows
+ALTER TABLE deliveries
+    ADD COLUMN IF NOT EXISTS stop_sequence INTEGER,
+    ADD COLUMN IF NOT EXISTS delivery_window_start TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS delivery_window_end TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_route_sequence ON deliveries(route_id, stop_sequence);
+
+COMMENT ON COLUMN deliveries.stop_sequence IS 'Position of the delivery within its route, set by route optimization';
+COMMENT ON COLUMN deliveries.delivery_window_start IS 'Earliest time the customer accepts the delivery';
+COMMENT ON COLUMN deliveries.delivery_window_end IS 'Latest time the customer accepts the delivery';
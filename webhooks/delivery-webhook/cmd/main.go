@@ -1,33 +1,161 @@
+// webhooks/delivery-webhook/cmd/main.go
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
+	"github.com/segmentio/kafka-go"
+
+	"webhooks/delivery-webhook/internal/handler"
+	"webhooks/delivery-webhook/internal/processor"
+	"webhooks/delivery-webhook/internal/provider"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8095"
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
 	}
 
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"healthy","service":"delivery-webhook"}`)
-	})
+	// Get configuration from environment
+	port := getEnv("PORT", "8095")
+	grabSecret := getEnv("GRAB_WEBHOOK_SECRET", "")
+	lineManSecret := getEnv("LINEMAN_WEBHOOK_SECRET", "")
+	webhookTolerance := getEnv("WEBHOOK_TIMESTAMP_TOLERANCE", "10m")
+	kafkaBrokers := getEnv("KAFKA_BROKERS", "kafka:9092")
+	kafkaTopic := getEnv("KAFKA_TOPIC", "delivery-updates")
+	redisAddr := getEnv("REDIS_ADDR", "redis:6379")
 
-	http.HandleFunc("/webhook/grab", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"accepted","message":"Grab webhook received"}`)
-	})
+	tolerance, err := time.ParseDuration(webhookTolerance)
+	if err != nil {
+		log.Fatalf("Invalid WEBHOOK_TIMESTAMP_TOLERANCE: %v", err)
+	}
 
-	http.HandleFunc("/webhook/lineman", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"accepted","message":"LineMan webhook received"}`)
+	// Initialize Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: redisAddr,
 	})
 
-	log.Printf("Starting delivery webhook service on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	// Test Redis connection
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+
+	// Initialize Kafka writer
+	kafkaWriter := &kafka.Writer{
+		Addr:         kafka.TCP(kafkaBrokers),
+		Topic:        kafkaTopic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+	}
+	defer kafkaWriter.Close()
+
+	// Initialize providers; a provider without a secret stays disabled
+	var grab *provider.Grab
+	if grabSecret != "" {
+		grab = provider.NewGrab(grabSecret, tolerance)
+	} else {
+		log.Println("GRAB_WEBHOOK_SECRET not set, Grab webhooks are disabled")
+	}
+
+	var lineMan *provider.LineMan
+	if lineManSecret != "" {
+		lineMan = provider.NewLineMan(lineManSecret, tolerance)
+	} else {
+		log.Println("LINEMAN_WEBHOOK_SECRET not set, LINE MAN webhooks are disabled")
+	}
+
+	// Initialize components
+	processor := processor.NewProcessor(redisClient)
+	webhookHandler := handler.NewHandler(grab, lineMan, processor, kafkaWriter)
+
+	// Setup routes
+	router := mux.NewRouter()
+
+	// Webhook endpoints
+	router.HandleFunc("/webhook/grab", webhookHandler.Grab).Methods("POST")
+	router.HandleFunc("/webhook/lineman", webhookHandler.LineMan).Methods("POST")
+	router.HandleFunc("/health", healthCheckHandler).Methods("GET")
+	router.HandleFunc("/ready", readinessHandler(redisClient, kafkaBrokers)).Methods("GET")
+
+	// Setup server
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Delivery webhook service starting on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+	<-quit
+	log.Println("Shutting down server...")
+
+	// Graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+
+	log.Println("Server exited")
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"healthy","service":"delivery-webhook"}`))
+}
+
+func readinessHandler(redisClient *redis.Client, kafkaBrokers string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+
+		// Check Redis connection
+		if err := redisClient.Ping(ctx).Err(); err != nil {
+			log.Printf("Redis health check failed: %v", err)
+			http.Error(w, "Redis not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		// Check Kafka connection
+		conn, err := kafka.Dial("tcp", kafkaBrokers)
+		if err != nil {
+			log.Printf("Kafka health check failed: %v", err)
+			http.Error(w, "Kafka not ready", http.StatusServiceUnavailable)
+			return
+		}
+		conn.Close()
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Ready"))
+	}
 }
@@ -0,0 +1,178 @@
+// webhooks/delivery-webhook/internal/handler/delivery.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"webhooks/delivery-webhook/internal/provider"
+)
+
+const maxBodyBytes = 1 << 20
+
+// IdempotencyStore remembers which provider updates were already published. Claim must be
+// atomic: it returns false when the key was already claimed.
+type IdempotencyStore interface {
+	Claim(ctx context.Context, key string, payload []byte) (bool, error)
+	Release(ctx context.Context, key string) error
+}
+
+// MessageWriter publishes messages to Kafka
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Handler handles Grab and LINE MAN webhooks. Updates are published before the provider gets
+// a 2xx, so a failed publish is retried by the provider instead of being lost.
+type Handler struct {
+	grab        *provider.Grab
+	lineMan     *provider.LineMan
+	store       IdempotencyStore
+	kafkaWriter MessageWriter
+}
+
+// NewHandler creates a new webhook handler. A nil provider is treated as not configured.
+func NewHandler(grab *provider.Grab, lineMan *provider.LineMan, store IdempotencyStore, kafkaWriter MessageWriter) *Handler {
+	return &Handler{
+		grab:        grab,
+		lineMan:     lineMan,
+		store:       store,
+		kafkaWriter: kafkaWriter,
+	}
+}
+
+// Grab handles POST /webhook/grab
+func (h *Handler) Grab(w http.ResponseWriter, r *http.Request) {
+	if h.grab == nil {
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "Grab webhook secret is not configured")
+		return
+	}
+
+	if err := h.grab.Verify(r.Header.Get(provider.GrabAuthHeader)); err != nil {
+		log.Printf("Rejected Grab webhook: %v", err)
+		writeStatus(w, http.StatusUnauthorized, "rejected", err.Error())
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+
+	update, err := h.grab.Parse(body)
+	h.handleUpdate(w, r.Context(), provider.ProviderGrab, update, err, body)
+}
+
+// LineMan handles POST /webhook/lineman
+func (h *Handler) LineMan(w http.ResponseWriter, r *http.Request) {
+	if h.lineMan == nil {
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "LINE MAN webhook secret is not configured")
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.lineMan.Verify(body, r.Header.Get(provider.LineManSignatureHeader)); err != nil {
+		log.Printf("Rejected LINE MAN webhook: %v", err)
+		writeStatus(w, http.StatusUnauthorized, "rejected", err.Error())
+		return
+	}
+
+	update, err := h.lineMan.Parse(body)
+	h.handleUpdate(w, r.Context(), provider.ProviderLineMan, update, err, body)
+}
+
+// handleUpdate claims the update, publishes it and gives the claim back when publishing fails
+func (h *Handler) handleUpdate(w http.ResponseWriter, ctx context.Context, source string, update *provider.StatusUpdate, parseErr error, body []byte) {
+	switch {
+	case parseErr == nil:
+	case errors.Is(parseErr, provider.ErrUnsupportedEvent):
+		// Acknowledge so the provider does not keep retrying events we do not act on
+		writeStatus(w, http.StatusOK, "ignored", parseErr.Error())
+		return
+	case errors.Is(parseErr, provider.ErrStaleWebhook):
+		log.Printf("Rejected %s webhook: %v", source, parseErr)
+		writeStatus(w, http.StatusUnauthorized, "rejected", parseErr.Error())
+		return
+	default:
+		log.Printf("Invalid %s webhook: %v", source, parseErr)
+		writeStatus(w, http.StatusBadRequest, "invalid", parseErr.Error())
+		return
+	}
+
+	// Claim before publishing so concurrent deliveries of one update cannot both publish it
+	key := update.IdempotencyKey()
+	claimed, err := h.store.Claim(ctx, key, body)
+	if err != nil {
+		log.Printf("Error claiming %s: %v", key, err)
+		writeStatus(w, http.StatusInternalServerError, "error", "idempotency check failed")
+		return
+	}
+	if !claimed {
+		log.Printf("Duplicate %s webhook for %s, skipping", source, key)
+		writeStatus(w, http.StatusOK, "duplicate", key)
+		return
+	}
+
+	if err := h.publishToKafka(ctx, update); err != nil {
+		log.Printf("Error publishing %s update %s: %v", source, update.EventID, err)
+		if err := h.store.Release(ctx, key); err != nil {
+			// The provider retry would be dropped as a duplicate until the claim expires
+			log.Printf("Error releasing %s: %v", key, err)
+		}
+		writeStatus(w, http.StatusInternalServerError, "error", "failed to publish update")
+		return
+	}
+
+	log.Printf("Published %s update: order=%s status=%s", source, update.ProviderOrderID, update.ProviderStatus)
+	writeStatus(w, http.StatusOK, "accepted", update.EventID)
+}
+
+// publishToKafka publishes the update keyed by provider order so updates of one delivery stay ordered
+func (h *Handler) publishToKafka(ctx context.Context, update *provider.StatusUpdate) error {
+	value, err := json.Marshal(update)
+	if err != nil {
+		return err
+	}
+
+	message := kafka.Message{
+		Key:   []byte(update.Provider + ":" + update.ProviderOrderID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "source", Value: []byte(update.Provider)},
+			{Key: "type", Value: []byte(update.EventType)},
+			{Key: "timestamp", Value: []byte(update.ReceivedAt.Format(time.RFC3339))},
+		},
+	}
+
+	return h.kafkaWriter.WriteMessages(ctx, message)
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil || len(body) == 0 {
+		log.Printf("Error reading webhook body: %v", err)
+		writeStatus(w, http.StatusBadRequest, "invalid", "empty or unreadable body")
+		return nil, false
+	}
+	return body, true
+}
+
+func writeStatus(w http.ResponseWriter, code int, status, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  status,
+		"message": message,
+	})
+}
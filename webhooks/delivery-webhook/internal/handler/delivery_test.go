@@ -0,0 +1,215 @@
+// webhooks/delivery-webhook/internal/handler/delivery_test.go
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"webhooks/delivery-webhook/internal/provider"
+)
+
+const (
+	testGrabSecret    = "grab-webhook-test-secret"
+	testLineManSecret = "lineman-webhook-test-secret"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	processed map[string]bool
+}
+
+func (s *memoryStore) Claim(ctx context.Context, key string, payload []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.processed[key] {
+		return false, nil
+	}
+	s.processed[key] = true
+	return true, nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.processed, key)
+	return nil
+}
+
+type recordingWriter struct {
+	messages []kafka.Message
+	err      error
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("..", "provider", "testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return body
+}
+
+// newTestHandler accepts the recorded fixtures by allowing a tolerance that covers their age
+func newTestHandler() (*Handler, *memoryStore, *recordingWriter) {
+	tolerance := time.Since(time.Date(2024, 10, 15, 0, 0, 0, 0, time.UTC)) + time.Hour
+	store := &memoryStore{processed: map[string]bool{}}
+	writer := &recordingWriter{}
+	h := NewHandler(
+		provider.NewGrab(testGrabSecret, tolerance),
+		provider.NewLineMan(testLineManSecret, tolerance),
+		store,
+		writer,
+	)
+	return h, store, writer
+}
+
+func grabRequest(t *testing.T, fixture, secret string) *http.Request {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/webhook/grab", bytes.NewReader(loadFixture(t, fixture)))
+	req.Header.Set(provider.GrabAuthHeader, "Bearer "+secret)
+	return req
+}
+
+func lineManRequest(t *testing.T, fixture string) *http.Request {
+	t.Helper()
+	body := loadFixture(t, fixture)
+	req := httptest.NewRequest(http.MethodPost, "/webhook/lineman", bytes.NewReader(body))
+	req.Header.Set(provider.LineManSignatureHeader, provider.NewLineMan(testLineManSecret, 0).Sign(body))
+	return req
+}
+
+func responseStatus(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp["status"]
+}
+
+func TestGrabWebhookPublishesOnce(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.Grab(rec, grabRequest(t, "grab_in_delivery.json", testGrabSecret))
+	if rec.Code != http.StatusOK || responseStatus(t, rec) != "accepted" {
+		t.Fatalf("first delivery: got %d", rec.Code)
+	}
+	if len(writer.messages) != 1 {
+		t.Fatalf("published %d messages, want 1", len(writer.messages))
+	}
+	if key := string(writer.messages[0].Key); key != "grab:IN-2-0B6TDFLRU2QKRH9P1GN4" {
+		t.Errorf("message key = %q", key)
+	}
+
+	var update provider.StatusUpdate
+	if err := json.Unmarshal(writer.messages[0].Value, &update); err != nil {
+		t.Fatalf("decoding message: %v", err)
+	}
+	if update.ProviderStatus != "IN_DELIVERY" || update.Location == nil {
+		t.Errorf("published update %+v", update)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Grab(rec, grabRequest(t, "grab_in_delivery.json", testGrabSecret))
+	if rec.Code != http.StatusOK || responseStatus(t, rec) != "duplicate" {
+		t.Fatalf("redelivery: got %d", rec.Code)
+	}
+
+	rec = httptest.NewRecorder()
+	h.Grab(rec, grabRequest(t, "grab_completed.json", testGrabSecret))
+	if rec.Code != http.StatusOK || len(writer.messages) != 2 {
+		t.Fatalf("next status: got %d with %d messages", rec.Code, len(writer.messages))
+	}
+}
+
+func TestGrabWebhookRejectsWrongSecret(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.Grab(rec, grabRequest(t, "grab_in_delivery.json", "guess"))
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("got %d, want 401", rec.Code)
+	}
+	if len(writer.messages) != 0 {
+		t.Fatal("published a rejected webhook")
+	}
+}
+
+func TestLineManWebhook(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.LineMan(rec, lineManRequest(t, "lineman_picked_up.json"))
+	if rec.Code != http.StatusOK || len(writer.messages) != 1 {
+		t.Fatalf("got %d with %d messages", rec.Code, len(writer.messages))
+	}
+
+	rec = httptest.NewRecorder()
+	h.LineMan(rec, lineManRequest(t, "lineman_rating.json"))
+	if rec.Code != http.StatusOK || responseStatus(t, rec) != "ignored" {
+		t.Errorf("unsupported event: got %d", rec.Code)
+	}
+
+	req := lineManRequest(t, "lineman_cancelled.json")
+	req.Header.Set(provider.LineManSignatureHeader, "c2lnbmF0dXJl")
+	rec = httptest.NewRecorder()
+	h.LineMan(rec, req)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: got %d, want 401", rec.Code)
+	}
+
+	if len(writer.messages) != 1 {
+		t.Errorf("published %d messages, want 1", len(writer.messages))
+	}
+}
+
+func TestWebhookPublishFailureIsRetryable(t *testing.T) {
+	h, store, writer := newTestHandler()
+	writer.err = errors.New("kafka unavailable")
+
+	rec := httptest.NewRecorder()
+	h.LineMan(rec, lineManRequest(t, "lineman_cancelled.json"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("publish failure: got %d, want 500", rec.Code)
+	}
+	if len(store.processed) != 0 {
+		t.Fatal("update marked processed although it was not published")
+	}
+
+	// The provider retry goes through once Kafka is back
+	writer.err = nil
+	rec = httptest.NewRecorder()
+	h.LineMan(rec, lineManRequest(t, "lineman_cancelled.json"))
+	if rec.Code != http.StatusOK || len(writer.messages) != 1 {
+		t.Fatalf("retry: got %d with %d messages", rec.Code, len(writer.messages))
+	}
+}
+
+func TestUnconfiguredProvider(t *testing.T) {
+	h := NewHandler(nil, nil, &memoryStore{processed: map[string]bool{}}, &recordingWriter{})
+
+	rec := httptest.NewRecorder()
+	h.Grab(rec, grabRequest(t, "grab_in_delivery.json", testGrabSecret))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", rec.Code)
+	}
+}
@@ -0,0 +1,72 @@
+// webhooks/delivery-webhook/internal/provider/event.go
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Provider codes, matching the shipping service delivery methods
+const (
+	ProviderGrab    = "grab"
+	ProviderLineMan = "lineman"
+)
+
+// EventTypeProviderStatus is the event type of every normalized provider update
+const EventTypeProviderStatus = "delivery.provider_status"
+
+// Webhook errors
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleWebhook     = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidPayload   = errors.New("invalid webhook payload")
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+// Driver is the rider assigned by the provider
+type Driver struct {
+	Name         string `json:"name,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+	LicensePlate string `json:"license_plate,omitempty"`
+}
+
+// Location is a GPS point reported by the provider
+type Location struct {
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+// StatusUpdate is a provider webhook normalized into the shape consumed by the shipping service.
+// The provider status is passed through as is; the shipping service maps it onto its own statuses.
+type StatusUpdate struct {
+	EventID         string    `json:"event_id"`
+	EventType       string    `json:"event_type"`
+	Provider        string    `json:"provider"`
+	ProviderOrderID string    `json:"provider_order_id"`
+	MerchantOrderID string    `json:"merchant_order_id,omitempty"`
+	ProviderStatus  string    `json:"provider_status"`
+	FailureReason   string    `json:"failure_reason,omitempty"`
+	TrackingURL     string    `json:"tracking_url,omitempty"`
+	Driver          *Driver   `json:"driver,omitempty"`
+	Location        *Location `json:"location,omitempty"`
+	OccurredAt      time.Time `json:"occurred_at"`
+	ReceivedAt      time.Time `json:"received_at"`
+}
+
+// IdempotencyKey identifies a single provider update. Providers repeat the same status with
+// new driver positions, so the event time is part of the key.
+func (u *StatusUpdate) IdempotencyKey() string {
+	return fmt.Sprintf("%s:%s:%s:%d", u.Provider, u.ProviderOrderID, u.ProviderStatus, u.OccurredAt.Unix())
+}
+
+// validLocation drops the zero point providers send before a driver is assigned
+func validLocation(lat, lng float64) *Location {
+	if lat == 0 && lng == 0 {
+		return nil
+	}
+	if lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil
+	}
+	return &Location{Latitude: lat, Longitude: lng}
+}
@@ -0,0 +1,109 @@
+// webhooks/delivery-webhook/internal/provider/grab_test.go
+package provider
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+const testGrabSecret = "grab-webhook-test-secret"
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return body
+}
+
+// fixtureTime is shortly after the recorded fixtures were sent
+var fixtureTime = time.Date(2024, 10, 15, 7, 46, 0, 0, time.UTC)
+
+func newTestGrab(now time.Time) *Grab {
+	g := NewGrab(testGrabSecret, 30*time.Minute)
+	g.now = func() time.Time { return now }
+	return g
+}
+
+func TestGrabVerify(t *testing.T) {
+	g := newTestGrab(fixtureTime)
+
+	if err := g.Verify(testGrabSecret); err != nil {
+		t.Errorf("raw secret rejected: %v", err)
+	}
+	if err := g.Verify("Bearer " + testGrabSecret); err != nil {
+		t.Errorf("bearer secret rejected: %v", err)
+	}
+	for _, header := range []string{"", "Bearer ", "Bearer wrong"} {
+		if err := g.Verify(header); !errors.Is(err, ErrInvalidSignature) {
+			t.Errorf("header %q: got %v, want ErrInvalidSignature", header, err)
+		}
+	}
+}
+
+func TestGrabParse(t *testing.T) {
+	g := newTestGrab(fixtureTime)
+
+	update, err := g.Parse(loadFixture(t, "grab_in_delivery.json"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if update.Provider != ProviderGrab || update.ProviderOrderID != "IN-2-0B6TDFLRU2QKRH9P1GN4" {
+		t.Errorf("got provider %q order %q", update.Provider, update.ProviderOrderID)
+	}
+	if update.MerchantOrderID != "8c1f4e2a-5b7d-4c9e-a3f6-2d8b1e7c0a94" || update.ProviderStatus != "IN_DELIVERY" {
+		t.Errorf("got merchant order %q status %q", update.MerchantOrderID, update.ProviderStatus)
+	}
+	if update.Driver == nil || update.Driver.LicensePlate != "1กข 2345" {
+		t.Errorf("Driver = %+v", update.Driver)
+	}
+	if update.Location == nil || update.Location.Latitude != 13.74562 || update.Location.Longitude != 100.53418 {
+		t.Errorf("Location = %+v", update.Location)
+	}
+	if !update.OccurredAt.Equal(time.Date(2024, 10, 15, 7, 32, 18, 0, time.UTC)) {
+		t.Errorf("OccurredAt = %v", update.OccurredAt)
+	}
+
+	allocating, err := g.Parse(loadFixture(t, "grab_allocating.json"))
+	if err != nil {
+		t.Fatalf("Parse allocating: %v", err)
+	}
+	if allocating.Driver != nil || allocating.Location != nil {
+		t.Errorf("allocating update has driver %+v location %+v", allocating.Driver, allocating.Location)
+	}
+}
+
+func TestGrabParseRejectsReplay(t *testing.T) {
+	g := newTestGrab(fixtureTime.Add(time.Hour))
+
+	if _, err := g.Parse(loadFixture(t, "grab_in_delivery.json")); !errors.Is(err, ErrStaleWebhook) {
+		t.Fatalf("replayed webhook: got %v, want ErrStaleWebhook", err)
+	}
+	if _, err := g.Parse([]byte(`{"status":"COMPLETED"}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("missing delivery ID: got %v, want ErrInvalidPayload", err)
+	}
+}
+
+func TestIdempotencyKeySeparatesPositionUpdates(t *testing.T) {
+	g := newTestGrab(fixtureTime)
+	update, err := g.Parse(loadFixture(t, "grab_in_delivery.json"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	redelivered := *update
+	redelivered.ReceivedAt = update.ReceivedAt.Add(time.Minute)
+	if redelivered.IdempotencyKey() != update.IdempotencyKey() {
+		t.Error("redelivery of the same update must share the key")
+	}
+
+	moved := *update
+	moved.OccurredAt = update.OccurredAt.Add(30 * time.Second)
+	if moved.IdempotencyKey() == update.IdempotencyKey() {
+		t.Error("a later position update with the same status must not share the key")
+	}
+}
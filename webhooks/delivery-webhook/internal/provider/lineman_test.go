@@ -0,0 +1,81 @@
+// webhooks/delivery-webhook/internal/provider/lineman_test.go
+package provider
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const testLineManSecret = "lineman-webhook-test-secret"
+
+func newTestLineMan(now time.Time) *LineMan {
+	l := NewLineMan(testLineManSecret, 30*time.Minute)
+	l.now = func() time.Time { return now }
+	return l
+}
+
+func TestLineManVerify(t *testing.T) {
+	l := newTestLineMan(fixtureTime)
+	body := loadFixture(t, "lineman_picked_up.json")
+
+	if err := l.Verify(body, l.Sign(body)); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	other := NewLineMan("another-secret", 10*time.Minute)
+	if err := l.Verify(body, other.Sign(body)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("wrong secret: got %v, want ErrInvalidSignature", err)
+	}
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-5] = '9'
+	if err := l.Verify(tampered, l.Sign(body)); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered body: got %v, want ErrInvalidSignature", err)
+	}
+
+	if err := l.Verify(body, "not base64!"); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("malformed signature: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestLineManParse(t *testing.T) {
+	l := newTestLineMan(fixtureTime)
+
+	pickedUp, err := l.Parse(loadFixture(t, "lineman_picked_up.json"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if pickedUp.Provider != ProviderLineMan || pickedUp.ProviderOrderID != "LMD-240915-48213" || pickedUp.ProviderStatus != "PICKED_UP" {
+		t.Errorf("got %+v", pickedUp)
+	}
+	if pickedUp.EventID != "lineman:evt_01J9Z4Q8K2M7N3P5R6S8T0V1W2" {
+		t.Errorf("EventID = %q", pickedUp.EventID)
+	}
+	if pickedUp.Location == nil || pickedUp.Location.Latitude != 13.80123 {
+		t.Errorf("Location = %+v", pickedUp.Location)
+	}
+
+	cancelled, err := l.Parse(loadFixture(t, "lineman_cancelled.json"))
+	if err != nil {
+		t.Fatalf("Parse cancelled: %v", err)
+	}
+	if cancelled.FailureReason != "Recipient unreachable" {
+		t.Errorf("FailureReason = %q", cancelled.FailureReason)
+	}
+	if cancelled.Location != nil {
+		t.Errorf("zero driver position kept as %+v", cancelled.Location)
+	}
+}
+
+func TestLineManParseRejectsUnsupportedAndStale(t *testing.T) {
+	l := newTestLineMan(fixtureTime)
+	if _, err := l.Parse(loadFixture(t, "lineman_rating.json")); !errors.Is(err, ErrUnsupportedEvent) {
+		t.Fatalf("rating event: got %v, want ErrUnsupportedEvent", err)
+	}
+
+	late := newTestLineMan(fixtureTime.Add(24 * time.Hour))
+	if _, err := late.Parse(loadFixture(t, "lineman_picked_up.json")); !errors.Is(err, ErrStaleWebhook) {
+		t.Fatalf("replayed webhook: got %v, want ErrStaleWebhook", err)
+	}
+}
@@ -1,33 +1,168 @@
+// webhooks/payment-webhook/cmd/main.go
 package main
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/gorilla/mux"
+	"github.com/joho/godotenv"
+	"github.com/segmentio/kafka-go"
+
+	"webhooks/payment-webhook/internal/gateway"
+	"webhooks/payment-webhook/internal/handler"
+	"webhooks/payment-webhook/internal/processor"
 )
 
 func main() {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8096"
+	// Load environment variables
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, using system environment variables")
 	}
 
-	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"healthy","service":"payment-webhook"}`)
-	})
+	// Get configuration from environment
+	port := getEnv("PORT", "8096")
+	omiseSecret := getEnv("OMISE_WEBHOOK_SECRET", "")
+	omiseTolerance := getEnv("OMISE_SIGNATURE_TOLERANCE", "5m")
+	twoC2PSecretKey := getEnv("TWOC2P_SECRET_KEY", "")
+	twoC2PMerchantID := getEnv("TWOC2P_MERCHANT_ID", "")
+	twoC2PReplayWindow := getEnv("TWOC2P_REPLAY_WINDOW", "24h")
+	kafkaBrokers := getEnv("KAFKA_BROKERS", "kafka:9092")
+	kafkaTopic := getEnv("KAFKA_TOPIC", "payment-events")
+	redisAddr := getEnv("REDIS_ADDR", "redis:6379")
 
-	http.HandleFunc("/webhook/omise", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"accepted","message":"Omise webhook received"}`)
-	})
+	tolerance, err := time.ParseDuration(omiseTolerance)
+	if err != nil {
+		log.Fatalf("Invalid OMISE_SIGNATURE_TOLERANCE: %v", err)
+	}
 
-	http.HandleFunc("/webhook/2c2p", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		fmt.Fprintf(w, `{"status":"accepted","message":"2C2P webhook received"}`)
+	replayWindow, err := time.ParseDuration(twoC2PReplayWindow)
+	if err != nil {
+		log.Fatalf("Invalid TWOC2P_REPLAY_WINDOW: %v", err)
+	}
+
+	// Initialize Redis client
+	redisClient := redis.NewClient(&redis.Options{
+		Addr: redisAddr,
 	})
 
-	log.Printf("Starting payment webhook service on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, nil))
+	// Test Redis connection
+	ctx := context.Background()
+	if err := redisClient.Ping(ctx).Err(); err != nil {
+		log.Fatalf("Failed to connect to Redis: %v", err)
+	}
+
+	// Initialize Kafka writer
+	kafkaWriter := &kafka.Writer{
+		Addr:         kafka.TCP(kafkaBrokers),
+		Topic:        kafkaTopic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: 10 * time.Millisecond,
+		RequiredAcks: kafka.RequireAll,
+	}
+	defer kafkaWriter.Close()
+
+	// Initialize gateways; a gateway without a secret stays disabled
+	var omise *gateway.Omise
+	if omiseSecret != "" {
+		omise = gateway.NewOmise(omiseSecret, tolerance)
+	} else {
+		log.Println("OMISE_WEBHOOK_SECRET not set, Omise webhooks are disabled")
+	}
+
+	var twoC2P *gateway.TwoC2P
+	if twoC2PSecretKey != "" {
+		twoC2P = gateway.NewTwoC2P(twoC2PSecretKey, twoC2PMerchantID, replayWindow)
+	} else {
+		log.Println("TWOC2P_SECRET_KEY not set, 2C2P webhooks are disabled")
+	}
+
+	// Initialize components
+	processor := processor.NewProcessor(redisClient)
+	webhookHandler := handler.NewHandler(omise, twoC2P, processor, kafkaWriter)
+
+	// Setup routes
+	router := mux.NewRouter()
+
+	// Webhook endpoints
+	router.HandleFunc("/webhook/omise", webhookHandler.Omise).Methods("POST")
+	router.HandleFunc("/webhook/2c2p", webhookHandler.TwoC2P).Methods("POST")
+	router.HandleFunc("/health", healthCheckHandler).Methods("GET")
+	router.HandleFunc("/ready", readinessHandler(redisClient, kafkaBrokers)).Methods("GET")
+
+	// Setup server
+	srv := &http.Server{
+		Addr:         ":" + port,
+		Handler:      router,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	// Start server in a goroutine
+	go func() {
+		log.Printf("Payment webhook service starting on port %s", port)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatalf("Server failed to start: %v", err)
+		}
+	}()
+
+	// Wait for interrupt signal to gracefully shutdown
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt)
+	<-quit
+	log.Println("Shutting down server...")
+
+	// Graceful shutdown
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Fatalf("Server forced to shutdown: %v", err)
+	}
+
+	log.Println("Server exited")
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
+
+func healthCheckHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"status":"healthy","service":"payment-webhook"}`))
+}
+
+func readinessHandler(redisClient *redis.Client, kafkaBrokers string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := context.Background()
+
+		// Check Redis connection
+		if err := redisClient.Ping(ctx).Err(); err != nil {
+			log.Printf("Redis health check failed: %v", err)
+			http.Error(w, "Redis not ready", http.StatusServiceUnavailable)
+			return
+		}
+
+		// Check Kafka connection
+		conn, err := kafka.Dial("tcp", kafkaBrokers)
+		if err != nil {
+			log.Printf("Kafka health check failed: %v", err)
+			http.Error(w, "Kafka not ready", http.StatusServiceUnavailable)
+			return
+		}
+		conn.Close()
+
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Ready"))
+	}
 }
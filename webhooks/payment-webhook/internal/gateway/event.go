@@ -0,0 +1,64 @@
+// webhooks/payment-webhook/internal/gateway/event.go
+package gateway
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Gateway names
+const (
+	GatewayOmise = "omise"
+	Gateway2C2P  = "2c2p"
+)
+
+// EventTypeGatewayStatus is the event type of every normalized gateway event
+const EventTypeGatewayStatus = "payment.gateway_status"
+
+// Payment statuses understood by the payment service
+const (
+	StatusProcessing = "processing"
+	StatusCompleted  = "completed"
+	StatusFailed     = "failed"
+	StatusRefunded   = "refunded"
+	StatusCancelled  = "cancelled"
+)
+
+// Webhook errors
+var (
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrStaleWebhook     = errors.New("webhook timestamp outside tolerance")
+	ErrInvalidPayload   = errors.New("invalid webhook payload")
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+	ErrUnknownMerchant  = errors.New("webhook is for another merchant")
+)
+
+// PaymentEvent is a gateway webhook normalized into the shape consumed by the payment service
+type PaymentEvent struct {
+	EventID        string    `json:"event_id"`
+	EventType      string    `json:"event_type"`
+	Gateway        string    `json:"gateway"`
+	GatewayEventID string    `json:"gateway_event_id,omitempty"`
+	ChargeID       string    `json:"charge_id"`
+	RefundID       string    `json:"refund_id,omitempty"`
+	PaymentID      string    `json:"payment_id,omitempty"`
+	Status         string    `json:"status"`
+	GatewayStatus  string    `json:"gateway_status"`
+	Amount         float64   `json:"amount"`
+	Currency       string    `json:"currency"`
+	FailureCode    string    `json:"failure_code,omitempty"`
+	FailureMessage string    `json:"failure_message,omitempty"`
+	OccurredAt     time.Time `json:"occurred_at"`
+	ReceivedAt     time.Time `json:"received_at"`
+}
+
+// IdempotencyKey identifies the state change carried by the event. Gateways redeliver the
+// same charge status several times, so the key is the charge and status, not the delivery.
+// A charge can be partially refunded more than once, so refunds are keyed by the refund.
+func (e *PaymentEvent) IdempotencyKey() string {
+	if e.Status == StatusRefunded {
+		return fmt.Sprintf("%s:%s:%s:%s", e.Gateway, e.ChargeID, e.Status, e.RefundID)
+	}
+	return fmt.Sprintf("%s:%s:%s", e.Gateway, e.ChargeID, e.Status)
+}
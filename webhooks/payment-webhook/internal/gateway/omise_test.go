@@ -0,0 +1,191 @@
+// webhooks/payment-webhook/internal/gateway/omise_test.go
+package gateway
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testOmiseSecret is "whsec_test_payment_webhook" base64-encoded, as the Omise dashboard shows it
+const testOmiseSecret = "d2hzZWNfdGVzdF9wYXltZW50X3dlYmhvb2s="
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return body
+}
+
+func newTestOmise(now time.Time) *Omise {
+	o := NewOmise(testOmiseSecret, 5*time.Minute)
+	o.now = func() time.Time { return now }
+	return o
+}
+
+func TestOmiseVerify(t *testing.T) {
+	now := time.Date(2024, 10, 15, 7, 42, 20, 0, time.UTC)
+	o := newTestOmise(now)
+	body := loadFixture(t, "omise_charge_complete.json")
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := o.Sign(body, timestamp)
+
+	if err := o.Verify(body, signature, timestamp); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	// During secret rotation Omise sends one signature per active secret
+	if err := o.Verify(body, "deadbeef, "+signature, timestamp); err != nil {
+		t.Fatalf("rotated signature rejected: %v", err)
+	}
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-3] = '9'
+	if err := o.Verify(tampered, signature, timestamp); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("tampered body: got %v, want ErrInvalidSignature", err)
+	}
+
+	other := NewOmise("another-secret", 5*time.Minute)
+	other.now = o.now
+	if err := o.Verify(body, other.Sign(body, timestamp), timestamp); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("wrong secret: got %v, want ErrInvalidSignature", err)
+	}
+
+	if err := o.Verify(body, signature, "not-a-timestamp"); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("bad timestamp: got %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestOmiseVerifyRejectsReplay(t *testing.T) {
+	signedAt := time.Date(2024, 10, 15, 7, 42, 20, 0, time.UTC)
+	body := loadFixture(t, "omise_charge_complete.json")
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := newTestOmise(signedAt).Sign(body, timestamp)
+
+	replayed := newTestOmise(signedAt.Add(6 * time.Minute))
+	if err := replayed.Verify(body, signature, timestamp); !errors.Is(err, ErrStaleWebhook) {
+		t.Fatalf("replayed request: got %v, want ErrStaleWebhook", err)
+	}
+
+	future := newTestOmise(signedAt.Add(-6 * time.Minute))
+	if err := future.Verify(body, signature, timestamp); !errors.Is(err, ErrStaleWebhook) {
+		t.Fatalf("future timestamp: got %v, want ErrStaleWebhook", err)
+	}
+}
+
+func TestOmiseParse(t *testing.T) {
+	o := newTestOmise(time.Date(2024, 10, 16, 3, 0, 0, 0, time.UTC))
+
+	tests := []struct {
+		fixture     string
+		chargeID    string
+		paymentID   string
+		status      string
+		amount      float64
+		failureCode string
+	}{
+		{"omise_charge_complete.json", "chrg_test_5xuy4vp2lx0yy7acb9m", "3f2b8a4e-6c1d-4f7a-9e2b-5d8c1a7f0b3e", StatusCompleted, 1250.50, ""},
+		{"omise_charge_failed.json", "chrg_test_5xuy6a9fw3k1n8p2d4s", "7d4e2c1a-8b3f-4e5d-a6c7-1b2d3e4f5a6b", StatusFailed, 890, "insufficient_fund"},
+		{"omise_refund_create.json", "chrg_test_5xuy4vp2lx0yy7acb9m", "3f2b8a4e-6c1d-4f7a-9e2b-5d8c1a7f0b3e", StatusRefunded, 1250.50, ""},
+		{"omise_refund_partial.json", "chrg_test_5xuy4vp2lx0yy7acb9m", "3f2b8a4e-6c1d-4f7a-9e2b-5d8c1a7f0b3e", StatusRefunded, 200, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			event, err := o.Parse(loadFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if event.Gateway != GatewayOmise || event.EventType != EventTypeGatewayStatus {
+				t.Errorf("got gateway %q type %q", event.Gateway, event.EventType)
+			}
+			if event.ChargeID != tt.chargeID {
+				t.Errorf("ChargeID = %q, want %q", event.ChargeID, tt.chargeID)
+			}
+			if event.PaymentID != tt.paymentID {
+				t.Errorf("PaymentID = %q, want %q", event.PaymentID, tt.paymentID)
+			}
+			if event.Status != tt.status {
+				t.Errorf("Status = %q, want %q", event.Status, tt.status)
+			}
+			if event.Amount != tt.amount || event.Currency != "THB" {
+				t.Errorf("Amount = %v %s, want %v THB", event.Amount, event.Currency, tt.amount)
+			}
+			if event.FailureCode != tt.failureCode {
+				t.Errorf("FailureCode = %q, want %q", event.FailureCode, tt.failureCode)
+			}
+			if event.OccurredAt.IsZero() {
+				t.Error("OccurredAt not set")
+			}
+		})
+	}
+}
+
+func TestOmiseParseUnsupportedEvent(t *testing.T) {
+	o := newTestOmise(time.Now())
+
+	if _, err := o.Parse(loadFixture(t, "omise_customer_create.json")); !errors.Is(err, ErrUnsupportedEvent) {
+		t.Fatalf("customer event: got %v, want ErrUnsupportedEvent", err)
+	}
+	if _, err := o.Parse([]byte(`{"object":"charge"}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("bare charge: got %v, want ErrInvalidPayload", err)
+	}
+}
+
+func TestIdempotencyKeyIgnoresRedelivery(t *testing.T) {
+	o := newTestOmise(time.Now())
+	first, err := o.Parse(loadFixture(t, "omise_charge_complete.json"))
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	// A redelivery of the same charge state carries a new event ID
+	redelivered := *first
+	redelivered.GatewayEventID = "evnt_test_redelivered"
+	if first.IdempotencyKey() != redelivered.IdempotencyKey() {
+		t.Errorf("redelivery key %q differs from %q", redelivered.IdempotencyKey(), first.IdempotencyKey())
+	}
+
+	refund, err := o.Parse(loadFixture(t, "omise_refund_create.json"))
+	if err != nil {
+		t.Fatalf("Parse refund: %v", err)
+	}
+	if refund.IdempotencyKey() == first.IdempotencyKey() {
+		t.Error("refund of the same charge must not share the completion key")
+	}
+}
+
+func TestIdempotencyKeySeparatesPartialRefunds(t *testing.T) {
+	o := newTestOmise(time.Now())
+	first, err := o.Parse(loadFixture(t, "omise_refund_create.json"))
+	if err != nil {
+		t.Fatalf("Parse refund: %v", err)
+	}
+	second, err := o.Parse(loadFixture(t, "omise_refund_partial.json"))
+	if err != nil {
+		t.Fatalf("Parse partial refund: %v", err)
+	}
+
+	if first.ChargeID != second.ChargeID {
+		t.Fatalf("fixtures refund different charges")
+	}
+	if second.RefundID != "rfnd_test_5xv3d6e8g0i2k4m6o8q" {
+		t.Errorf("RefundID = %q", second.RefundID)
+	}
+
+	// A second refund of the same charge is a new refund, not a redelivery of the first
+	if first.IdempotencyKey() == second.IdempotencyKey() {
+		t.Errorf("refunds %s and %s share key %q", first.RefundID, second.RefundID, first.IdempotencyKey())
+	}
+
+	redelivered := *second
+	redelivered.GatewayEventID = "evnt_test_redelivered"
+	if redelivered.IdempotencyKey() != second.IdempotencyKey() {
+		t.Errorf("redelivered refund key %q differs from %q", redelivered.IdempotencyKey(), second.IdempotencyKey())
+	}
+}
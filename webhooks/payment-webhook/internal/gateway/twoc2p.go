@@ -0,0 +1,182 @@
+// webhooks/payment-webhook/internal/gateway/twoc2p.go
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// bangkok is the time zone of 2C2P transaction timestamps (Thailand has no DST)
+var bangkok = time.FixedZone("ICT", 7*60*60)
+
+// twoC2PClockSkew is how far in the future a transaction timestamp may be
+const twoC2PClockSkew = 5 * time.Minute
+
+// TwoC2P verifies and normalizes 2C2P backend notifications
+type TwoC2P struct {
+	secretKey    []byte
+	merchantID   string
+	replayWindow time.Duration
+	now          func() time.Time
+}
+
+// NewTwoC2P creates a 2C2P notification verifier. When merchantID is set, notifications
+// for any other merchant are rejected. The token carries no signing time, so notifications
+// whose transaction is older than replayWindow are rejected as replays; the window must stay
+// shorter than the time processed events are remembered.
+func NewTwoC2P(secretKey, merchantID string, replayWindow time.Duration) *TwoC2P {
+	return &TwoC2P{
+		secretKey:    []byte(secretKey),
+		merchantID:   merchantID,
+		replayWindow: replayWindow,
+		now:          time.Now,
+	}
+}
+
+// twoC2PEnvelope is the body 2C2P posts to the backend return URL
+type twoC2PEnvelope struct {
+	Payload string `json:"payload"`
+}
+
+// twoC2PNotification holds the claims of the signed payment response token
+type twoC2PNotification struct {
+	MerchantID          string  `json:"merchantID"`
+	InvoiceNo           string  `json:"invoiceNo"`
+	Amount              float64 `json:"amount"`
+	CurrencyCode        string  `json:"currencyCode"`
+	TranRef             string  `json:"tranRef"`
+	ReferenceNo         string  `json:"referenceNo"`
+	TransactionDateTime string  `json:"transactionDateTime"`
+	UserDefined1        string  `json:"userDefined1"`
+	RespCode            string  `json:"respCode"`
+	RespDesc            string  `json:"respDesc"`
+}
+
+// Parse verifies the HS256 token in a 2C2P notification and normalizes it. The payment
+// service ID travels in userDefined1, falling back to the invoice number.
+func (t *TwoC2P) Parse(body []byte) (*PaymentEvent, error) {
+	var envelope twoC2PEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Payload == "" {
+		return nil, fmt.Errorf("%w: missing payload token", ErrInvalidPayload)
+	}
+
+	claims, err := t.verifyToken(envelope.Payload)
+	if err != nil {
+		return nil, err
+	}
+
+	var n twoC2PNotification
+	if err := json.Unmarshal(claims, &n); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPayload, err)
+	}
+
+	if t.merchantID != "" && n.MerchantID != t.merchantID {
+		return nil, ErrUnknownMerchant
+	}
+
+	occurredAt, err := time.ParseInLocation("20060102150405", n.TransactionDateTime, bangkok)
+	if err != nil {
+		return nil, fmt.Errorf("%w: transaction time %q", ErrInvalidPayload, n.TransactionDateTime)
+	}
+	receivedAt := t.now()
+	if age := receivedAt.Sub(occurredAt); age > t.replayWindow || age < -twoC2PClockSkew {
+		return nil, ErrStaleWebhook
+	}
+
+	chargeID := n.TranRef
+	if chargeID == "" {
+		chargeID = n.InvoiceNo
+	}
+	if chargeID == "" {
+		return nil, fmt.Errorf("%w: missing transaction reference", ErrInvalidPayload)
+	}
+
+	paymentID := n.UserDefined1
+	if paymentID == "" {
+		paymentID = n.InvoiceNo
+	}
+
+	status := twoC2PStatus(n.RespCode)
+	event := &PaymentEvent{
+		EventID:        fmt.Sprintf("%s:%s:%s", Gateway2C2P, chargeID, n.RespCode),
+		EventType:      EventTypeGatewayStatus,
+		Gateway:        Gateway2C2P,
+		GatewayEventID: n.ReferenceNo,
+		ChargeID:       chargeID,
+		PaymentID:      paymentID,
+		Status:         status,
+		GatewayStatus:  n.RespCode,
+		Amount:         n.Amount,
+		Currency:       n.CurrencyCode,
+		OccurredAt:     occurredAt,
+		ReceivedAt:     receivedAt,
+	}
+	if status == StatusFailed || status == StatusCancelled {
+		event.FailureCode = n.RespCode
+		event.FailureMessage = n.RespDesc
+	}
+
+	return event, nil
+}
+
+// Sign creates an HS256 token over the claims, as 2C2P does for its notifications
+func (t *TwoC2P) Sign(claims []byte) string {
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+	signingInput := header + "." + base64.RawURLEncoding.EncodeToString(claims)
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(t.mac(signingInput))
+}
+
+// verifyToken checks the token signature and returns the decoded claims
+func (t *TwoC2P) verifyToken(token string) ([]byte, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidPayload)
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token header", ErrInvalidPayload)
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return nil, ErrInvalidSignature
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil || !hmac.Equal(signature, t.mac(parts[0]+"."+parts[1])) {
+		return nil, ErrInvalidSignature
+	}
+
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed token claims", ErrInvalidPayload)
+	}
+	return claims, nil
+}
+
+func (t *TwoC2P) mac(signingInput string) []byte {
+	mac := hmac.New(sha256.New, t.secretKey)
+	mac.Write([]byte(signingInput))
+	return mac.Sum(nil)
+}
+
+// twoC2PStatus maps a 2C2P response code to a payment status
+func twoC2PStatus(respCode string) string {
+	switch respCode {
+	case "0000":
+		return StatusCompleted
+	case "0001", "2001":
+		return StatusProcessing
+	case "0003":
+		return StatusCancelled
+	default:
+		return StatusFailed
+	}
+}
@@ -0,0 +1,131 @@
+// webhooks/payment-webhook/internal/gateway/twoc2p_test.go
+package gateway
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+const (
+	testTwoC2PSecretKey    = "CD229682D3297390B9F66FF4020B758F4A5E625AF4992E5D75D311D6458B38E2"
+	testTwoC2PMerchantID   = "764764000001234"
+	testTwoC2PReplayWindow = 24 * time.Hour
+)
+
+// signedNotification wraps recorded notification claims in the token envelope 2C2P posts
+func signedNotification(t *testing.T, signer *TwoC2P, fixture string) []byte {
+	t.Helper()
+	return []byte(`{"payload":"` + signer.Sign(loadFixture(t, fixture)) + `"}`)
+}
+
+func newTestTwoC2P(now time.Time) *TwoC2P {
+	t := NewTwoC2P(testTwoC2PSecretKey, testTwoC2PMerchantID, testTwoC2PReplayWindow)
+	t.now = func() time.Time { return now }
+	return t
+}
+
+// twoC2PFixturesReceived is shortly after the latest fixture transaction
+var twoC2PFixturesReceived = time.Date(2024, 10, 15, 9, 10, 0, 0, time.UTC)
+
+func TestTwoC2PParse(t *testing.T) {
+	g := newTestTwoC2P(twoC2PFixturesReceived)
+
+	tests := []struct {
+		fixture     string
+		chargeID    string
+		paymentID   string
+		status      string
+		amount      float64
+		failureCode string
+		occurredAt  time.Time
+	}{
+		{"2c2p_success.json", "4219831", "3f2b8a4e-6c1d-4f7a-9e2b-5d8c1a7f0b3e", StatusCompleted, 1250.50, "", time.Date(2024, 10, 15, 7, 42, 18, 0, time.UTC)},
+		{"2c2p_failed.json", "4219907", "7d4e2c1a-8b3f-4e5d-a6c7-1b2d3e4f5a6b", StatusFailed, 890, "4051", time.Date(2024, 10, 15, 9, 3, 44, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.fixture, func(t *testing.T) {
+			event, err := g.Parse(signedNotification(t, g, tt.fixture))
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			if event.Gateway != Gateway2C2P {
+				t.Errorf("Gateway = %q", event.Gateway)
+			}
+			if event.ChargeID != tt.chargeID {
+				t.Errorf("ChargeID = %q, want %q", event.ChargeID, tt.chargeID)
+			}
+			if event.PaymentID != tt.paymentID {
+				t.Errorf("PaymentID = %q, want %q", event.PaymentID, tt.paymentID)
+			}
+			if event.Status != tt.status {
+				t.Errorf("Status = %q, want %q", event.Status, tt.status)
+			}
+			if event.Amount != tt.amount {
+				t.Errorf("Amount = %v, want %v", event.Amount, tt.amount)
+			}
+			if event.FailureCode != tt.failureCode {
+				t.Errorf("FailureCode = %q, want %q", event.FailureCode, tt.failureCode)
+			}
+			if !event.OccurredAt.Equal(tt.occurredAt) {
+				t.Errorf("OccurredAt = %v, want %v", event.OccurredAt, tt.occurredAt)
+			}
+		})
+	}
+}
+
+func TestTwoC2PParseRejectsForgedToken(t *testing.T) {
+	g := newTestTwoC2P(twoC2PFixturesReceived)
+
+	forger := NewTwoC2P("not-the-merchant-secret", testTwoC2PMerchantID, testTwoC2PReplayWindow)
+	if _, err := g.Parse(signedNotification(t, forger, "2c2p_success.json")); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("forged token: got %v, want ErrInvalidSignature", err)
+	}
+
+	if _, err := g.Parse([]byte(`{"payload":"a.b"}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("malformed token: got %v, want ErrInvalidPayload", err)
+	}
+
+	if _, err := g.Parse([]byte(`{}`)); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("missing payload: got %v, want ErrInvalidPayload", err)
+	}
+}
+
+func TestTwoC2PParseRejectsOtherMerchant(t *testing.T) {
+	g := NewTwoC2P(testTwoC2PSecretKey, "764764000009999", testTwoC2PReplayWindow)
+
+	if _, err := g.Parse(signedNotification(t, g, "2c2p_success.json")); !errors.Is(err, ErrUnknownMerchant) {
+		t.Fatalf("other merchant: got %v, want ErrUnknownMerchant", err)
+	}
+}
+
+func TestTwoC2PParseRejectsReplay(t *testing.T) {
+	// 2c2p_success.json was paid at 07:42:18 UTC
+	paidAt := time.Date(2024, 10, 15, 7, 42, 18, 0, time.UTC)
+
+	late := newTestTwoC2P(paidAt.Add(testTwoC2PReplayWindow + time.Minute))
+	if _, err := late.Parse(signedNotification(t, late, "2c2p_success.json")); !errors.Is(err, ErrStaleWebhook) {
+		t.Fatalf("replayed notification: got %v, want ErrStaleWebhook", err)
+	}
+
+	early := newTestTwoC2P(paidAt.Add(-10 * time.Minute))
+	if _, err := early.Parse(signedNotification(t, early, "2c2p_success.json")); !errors.Is(err, ErrStaleWebhook) {
+		t.Fatalf("future transaction: got %v, want ErrStaleWebhook", err)
+	}
+
+	inWindow := newTestTwoC2P(paidAt.Add(testTwoC2PReplayWindow - time.Minute))
+	if _, err := inWindow.Parse(signedNotification(t, inWindow, "2c2p_success.json")); err != nil {
+		t.Fatalf("retried notification inside the window: %v", err)
+	}
+}
+
+func TestTwoC2PParseRequiresTransactionTime(t *testing.T) {
+	g := newTestTwoC2P(twoC2PFixturesReceived)
+	claims := []byte(`{"merchantID":"` + testTwoC2PMerchantID + `","invoiceNo":"inv-1","amount":10,"currencyCode":"THB","tranRef":"1","respCode":"0000"}`)
+
+	body := []byte(`{"payload":"` + g.Sign(claims) + `"}`)
+	if _, err := g.Parse(body); !errors.Is(err, ErrInvalidPayload) {
+		t.Fatalf("missing transaction time: got %v, want ErrInvalidPayload", err)
+	}
+}
@@ -0,0 +1,173 @@
+// webhooks/payment-webhook/internal/handler/payment.go
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"webhooks/payment-webhook/internal/gateway"
+)
+
+const maxBodyBytes = 1 << 20
+
+// IdempotencyStore remembers which gateway events were already published. Claim must be
+// atomic: it returns false when the key was already claimed.
+type IdempotencyStore interface {
+	Claim(ctx context.Context, key string, payload []byte) (bool, error)
+	Release(ctx context.Context, key string) error
+}
+
+// MessageWriter publishes messages to Kafka
+type MessageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+}
+
+// Handler handles Omise and 2C2P webhooks. Events are published before the gateway gets
+// a 2xx, so a failed publish is retried by the gateway instead of being lost.
+type Handler struct {
+	omise       *gateway.Omise
+	twoC2P      *gateway.TwoC2P
+	store       IdempotencyStore
+	kafkaWriter MessageWriter
+}
+
+// NewHandler creates a new webhook handler. A nil gateway is treated as not configured.
+func NewHandler(omise *gateway.Omise, twoC2P *gateway.TwoC2P, store IdempotencyStore, kafkaWriter MessageWriter) *Handler {
+	return &Handler{
+		omise:       omise,
+		twoC2P:      twoC2P,
+		store:       store,
+		kafkaWriter: kafkaWriter,
+	}
+}
+
+// Omise handles POST /webhook/omise
+func (h *Handler) Omise(w http.ResponseWriter, r *http.Request) {
+	if h.omise == nil {
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "Omise webhook secret is not configured")
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.omise.Verify(body, r.Header.Get(gateway.OmiseSignatureHeader), r.Header.Get(gateway.OmiseTimestampHeader)); err != nil {
+		log.Printf("Rejected Omise webhook: %v", err)
+		writeStatus(w, http.StatusUnauthorized, "rejected", err.Error())
+		return
+	}
+
+	event, err := h.omise.Parse(body)
+	h.handleEvent(w, r.Context(), gateway.GatewayOmise, event, err, body)
+}
+
+// TwoC2P handles POST /webhook/2c2p
+func (h *Handler) TwoC2P(w http.ResponseWriter, r *http.Request) {
+	if h.twoC2P == nil {
+		writeStatus(w, http.StatusServiceUnavailable, "unavailable", "2C2P secret key is not configured")
+		return
+	}
+
+	body, ok := readBody(w, r)
+	if !ok {
+		return
+	}
+
+	// The 2C2P signature is inside the payload token, so parsing also verifies it
+	event, err := h.twoC2P.Parse(body)
+	h.handleEvent(w, r.Context(), gateway.Gateway2C2P, event, err, body)
+}
+
+// handleEvent claims the event's idempotency key and publishes the normalized event, dropping duplicates
+func (h *Handler) handleEvent(w http.ResponseWriter, ctx context.Context, source string, event *gateway.PaymentEvent, parseErr error, body []byte) {
+	switch {
+	case parseErr == nil:
+	case errors.Is(parseErr, gateway.ErrUnsupportedEvent):
+		// Acknowledge so the gateway does not keep retrying events we do not act on
+		writeStatus(w, http.StatusOK, "ignored", parseErr.Error())
+		return
+	case errors.Is(parseErr, gateway.ErrInvalidSignature), errors.Is(parseErr, gateway.ErrStaleWebhook), errors.Is(parseErr, gateway.ErrUnknownMerchant):
+		log.Printf("Rejected %s webhook: %v", source, parseErr)
+		writeStatus(w, http.StatusUnauthorized, "rejected", parseErr.Error())
+		return
+	default:
+		log.Printf("Invalid %s webhook: %v", source, parseErr)
+		writeStatus(w, http.StatusBadRequest, "invalid", parseErr.Error())
+		return
+	}
+
+	// Claim before publishing so concurrent deliveries of one event cannot both publish it
+	key := event.IdempotencyKey()
+	claimed, err := h.store.Claim(ctx, key, body)
+	if err != nil {
+		log.Printf("Error claiming %s: %v", key, err)
+		writeStatus(w, http.StatusInternalServerError, "error", "idempotency check failed")
+		return
+	}
+	if !claimed {
+		log.Printf("Duplicate %s webhook for %s, skipping", source, key)
+		writeStatus(w, http.StatusOK, "duplicate", key)
+		return
+	}
+
+	if err := h.publishToKafka(ctx, event); err != nil {
+		log.Printf("Error publishing %s event %s: %v", source, event.EventID, err)
+		if err := h.store.Release(ctx, key); err != nil {
+			// The gateway retry would be dropped as a duplicate until the claim expires
+			log.Printf("Error releasing %s: %v", key, err)
+		}
+		writeStatus(w, http.StatusInternalServerError, "error", "failed to publish event")
+		return
+	}
+
+	log.Printf("Published %s event: charge=%s status=%s", source, event.ChargeID, event.Status)
+	writeStatus(w, http.StatusOK, "accepted", event.EventID)
+}
+
+// publishToKafka publishes the normalized event keyed by charge so updates of one charge stay ordered
+func (h *Handler) publishToKafka(ctx context.Context, event *gateway.PaymentEvent) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	message := kafka.Message{
+		Key:   []byte(event.ChargeID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "source", Value: []byte(event.Gateway)},
+			{Key: "type", Value: []byte(event.EventType)},
+			{Key: "timestamp", Value: []byte(event.ReceivedAt.Format(time.RFC3339))},
+		},
+	}
+
+	return h.kafkaWriter.WriteMessages(ctx, message)
+}
+
+func readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	defer r.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxBodyBytes))
+	if err != nil || len(body) == 0 {
+		log.Printf("Error reading webhook body: %v", err)
+		writeStatus(w, http.StatusBadRequest, "invalid", "empty or unreadable body")
+		return nil, false
+	}
+	return body, true
+}
+
+func writeStatus(w http.ResponseWriter, code int, status, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]string{
+		"status":  status,
+		"message": message,
+	})
+}
@@ -0,0 +1,297 @@
+// webhooks/payment-webhook/internal/handler/payment_test.go
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"webhooks/payment-webhook/internal/gateway"
+)
+
+const (
+	testOmiseSecret      = "d2hzZWNfdGVzdF9wYXltZW50X3dlYmhvb2s="
+	testTwoC2PSecretKey  = "CD229682D3297390B9F66FF4020B758F4A5E625AF4992E5D75D311D6458B38E2"
+	testTwoC2PMerchantID = "764764000001234"
+)
+
+type memoryStore struct {
+	mu        sync.Mutex
+	processed map[string]bool
+}
+
+func (s *memoryStore) Claim(ctx context.Context, key string, payload []byte) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.processed[key] {
+		return false, nil
+	}
+	s.processed[key] = true
+	return true, nil
+}
+
+func (s *memoryStore) Release(ctx context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.processed, key)
+	return nil
+}
+
+type recordingWriter struct {
+	mu       sync.Mutex
+	messages []kafka.Message
+	err      error
+}
+
+func (w *recordingWriter) WriteMessages(ctx context.Context, msgs ...kafka.Message) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.err != nil {
+		return w.err
+	}
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	body, err := os.ReadFile(filepath.Join("..", "gateway", "testdata", name))
+	if err != nil {
+		t.Fatalf("reading fixture %s: %v", name, err)
+	}
+	return body
+}
+
+func newTestHandler() (*Handler, *memoryStore, *recordingWriter) {
+	store := &memoryStore{processed: map[string]bool{}}
+	writer := &recordingWriter{}
+	h := NewHandler(
+		gateway.NewOmise(testOmiseSecret, 5*time.Minute),
+		gateway.NewTwoC2P(testTwoC2PSecretKey, testTwoC2PMerchantID, time.Hour),
+		store,
+		writer,
+	)
+	return h, store, writer
+}
+
+func omiseRequest(t *testing.T, fixture string, signedAt time.Time) *http.Request {
+	t.Helper()
+	body := loadFixture(t, fixture)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := gateway.NewOmise(testOmiseSecret, 5*time.Minute).Sign(body, timestamp)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/omise", bytes.NewReader(body))
+	req.Header.Set(gateway.OmiseSignatureHeader, signature)
+	req.Header.Set(gateway.OmiseTimestampHeader, timestamp)
+	return req
+}
+
+// twoC2PRequest signs the fixture claims as a notification for a transaction made just now
+func twoC2PRequest(t *testing.T, fixture string) *http.Request {
+	t.Helper()
+	var claims map[string]interface{}
+	if err := json.Unmarshal(loadFixture(t, fixture), &claims); err != nil {
+		t.Fatalf("decoding fixture %s: %v", fixture, err)
+	}
+	claims["transactionDateTime"] = time.Now().In(time.FixedZone("ICT", 7*60*60)).Format("20060102150405")
+	signed, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("encoding claims: %v", err)
+	}
+
+	token := gateway.NewTwoC2P(testTwoC2PSecretKey, testTwoC2PMerchantID, time.Hour).Sign(signed)
+	body := []byte(`{"payload":"` + token + `"}`)
+	return httptest.NewRequest(http.MethodPost, "/webhook/2c2p", bytes.NewReader(body))
+}
+
+func responseStatus(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var resp map[string]string
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decoding response: %v", err)
+	}
+	return resp["status"]
+}
+
+func TestOmiseWebhookPublishesOnce(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.Omise(rec, omiseRequest(t, "omise_charge_complete.json", time.Now()))
+	if rec.Code != http.StatusOK || responseStatus(t, rec) != "accepted" {
+		t.Fatalf("first delivery: got %d", rec.Code)
+	}
+	if len(writer.messages) != 1 {
+		t.Fatalf("published %d messages, want 1", len(writer.messages))
+	}
+
+	msg := writer.messages[0]
+	if string(msg.Key) != "chrg_test_5xuy4vp2lx0yy7acb9m" {
+		t.Errorf("message key = %q", msg.Key)
+	}
+	var event gateway.PaymentEvent
+	if err := json.Unmarshal(msg.Value, &event); err != nil {
+		t.Fatalf("decoding message: %v", err)
+	}
+	if event.Status != gateway.StatusCompleted || event.PaymentID != "3f2b8a4e-6c1d-4f7a-9e2b-5d8c1a7f0b3e" {
+		t.Errorf("published event %+v", event)
+	}
+
+	// Omise retries until it sees a 2xx; the redelivery must not be published again
+	rec = httptest.NewRecorder()
+	h.Omise(rec, omiseRequest(t, "omise_charge_complete.json", time.Now()))
+	if rec.Code != http.StatusOK || responseStatus(t, rec) != "duplicate" {
+		t.Fatalf("redelivery: got %d", rec.Code)
+	}
+	if len(writer.messages) != 1 {
+		t.Fatalf("redelivery published, have %d messages", len(writer.messages))
+	}
+}
+
+func TestOmiseWebhookRejectsBadRequests(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	stale := httptest.NewRecorder()
+	h.Omise(stale, omiseRequest(t, "omise_charge_complete.json", time.Now().Add(-time.Hour)))
+	if stale.Code != http.StatusUnauthorized {
+		t.Errorf("stale timestamp: got %d, want 401", stale.Code)
+	}
+
+	req := omiseRequest(t, "omise_charge_complete.json", time.Now())
+	req.Header.Set(gateway.OmiseSignatureHeader, "0000")
+	unsigned := httptest.NewRecorder()
+	h.Omise(unsigned, req)
+	if unsigned.Code != http.StatusUnauthorized {
+		t.Errorf("bad signature: got %d, want 401", unsigned.Code)
+	}
+
+	ignored := httptest.NewRecorder()
+	h.Omise(ignored, omiseRequest(t, "omise_customer_create.json", time.Now()))
+	if ignored.Code != http.StatusOK || responseStatus(t, ignored) != "ignored" {
+		t.Errorf("unsupported event: got %d", ignored.Code)
+	}
+
+	if len(writer.messages) != 0 {
+		t.Errorf("published %d messages for rejected requests", len(writer.messages))
+	}
+}
+
+func TestWebhookPublishFailureIsRetryable(t *testing.T) {
+	h, store, writer := newTestHandler()
+	writer.err = errors.New("kafka unavailable")
+
+	rec := httptest.NewRecorder()
+	h.TwoC2P(rec, twoC2PRequest(t, "2c2p_success.json"))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("publish failure: got %d, want 500", rec.Code)
+	}
+	if len(store.processed) != 0 {
+		t.Fatal("event marked processed although it was not published")
+	}
+
+	// The gateway retry goes through once Kafka is back
+	writer.err = nil
+	rec = httptest.NewRecorder()
+	h.TwoC2P(rec, twoC2PRequest(t, "2c2p_success.json"))
+	if rec.Code != http.StatusOK || len(writer.messages) != 1 {
+		t.Fatalf("retry: got %d with %d messages", rec.Code, len(writer.messages))
+	}
+}
+
+func TestTwoC2PWebhook(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	rec := httptest.NewRecorder()
+	h.TwoC2P(rec, twoC2PRequest(t, "2c2p_failed.json"))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d, want 200", rec.Code)
+	}
+
+	var event gateway.PaymentEvent
+	if err := json.Unmarshal(writer.messages[0].Value, &event); err != nil {
+		t.Fatalf("decoding message: %v", err)
+	}
+	if event.Status != gateway.StatusFailed || event.FailureMessage != "Insufficient funds" {
+		t.Errorf("published event %+v", event)
+	}
+
+	forged := []byte(`{"payload":"` + gateway.NewTwoC2P("guess", testTwoC2PMerchantID, time.Hour).Sign(loadFixture(t, "2c2p_success.json")) + `"}`)
+	rec = httptest.NewRecorder()
+	h.TwoC2P(rec, httptest.NewRequest(http.MethodPost, "/webhook/2c2p", bytes.NewReader(forged)))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("forged token: got %d, want 401", rec.Code)
+	}
+}
+
+func TestUnconfiguredGateway(t *testing.T) {
+	h := NewHandler(nil, nil, &memoryStore{processed: map[string]bool{}}, &recordingWriter{})
+
+	rec := httptest.NewRecorder()
+	h.Omise(rec, omiseRequest(t, "omise_charge_complete.json", time.Now()))
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Errorf("got %d, want 503", rec.Code)
+	}
+}
+
+func TestConcurrentDeliveriesPublishOnce(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			h.Omise(httptest.NewRecorder(), omiseRequest(t, "omise_charge_complete.json", time.Now()))
+		}()
+	}
+	wg.Wait()
+
+	if len(writer.messages) != 1 {
+		t.Fatalf("published %d messages for one event, want 1", len(writer.messages))
+	}
+}
+
+func TestPartialRefundsArePublishedSeparately(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	for _, fixture := range []string{"omise_refund_create.json", "omise_refund_partial.json", "omise_refund_partial.json"} {
+		h.Omise(httptest.NewRecorder(), omiseRequest(t, fixture, time.Now()))
+	}
+
+	// The redelivered second refund is dropped; the two refunds of the charge are not
+	if len(writer.messages) != 2 {
+		t.Fatalf("published %d refunds, want 2", len(writer.messages))
+	}
+	var second gateway.PaymentEvent
+	if err := json.Unmarshal(writer.messages[1].Value, &second); err != nil {
+		t.Fatalf("decoding message: %v", err)
+	}
+	if second.RefundID != "rfnd_test_5xv3d6e8g0i2k4m6o8q" || second.Amount != 200 {
+		t.Errorf("second refund published as %+v", second)
+	}
+}
+
+func TestStaleTwoC2PNotificationRejected(t *testing.T) {
+	h, _, writer := newTestHandler()
+
+	// The fixture's transaction time is far outside the replay window
+	token := gateway.NewTwoC2P(testTwoC2PSecretKey, testTwoC2PMerchantID, time.Hour).Sign(loadFixture(t, "2c2p_success.json"))
+	rec := httptest.NewRecorder()
+	h.TwoC2P(rec, httptest.NewRequest(http.MethodPost, "/webhook/2c2p", bytes.NewReader([]byte(`{"payload":"`+token+`"}`))))
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("replayed notification: got %d, want 401", rec.Code)
+	}
+	if len(writer.messages) != 0 {
+		t.Errorf("published %d messages for a replay", len(writer.messages))
+	}
+}
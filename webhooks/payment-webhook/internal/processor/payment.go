@@ -0,0 +1,59 @@
+// webhooks/payment-webhook/internal/processor/payment.go
+package processor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+const (
+	processedTTL = 7 * 24 * time.Hour
+	payloadTTL   = 7 * 24 * time.Hour
+)
+
+// Processor records which gateway events were published so redeliveries and replays are dropped
+type Processor struct {
+	redis *redis.Client
+}
+
+// NewProcessor creates a new webhook processor
+func NewProcessor(redis *redis.Client) *Processor {
+	return &Processor{
+		redis: redis,
+	}
+}
+
+// Claim records the idempotency key with a single SETNX, so of two concurrent deliveries
+// of the same event only one gets true and publishes it. The raw payload is kept for debugging.
+func (p *Processor) Claim(ctx context.Context, key string, payload []byte) (bool, error) {
+	claimed, err := p.redis.SetNX(ctx, processedKey(key), time.Now().Format(time.RFC3339), processedTTL).Result()
+	if err != nil {
+		return false, fmt.Errorf("claiming event: %w", err)
+	}
+	if !claimed {
+		return false, nil
+	}
+
+	payloadKey := fmt.Sprintf("payment:webhook:payload:%s:%d", key, time.Now().Unix())
+	if err := p.redis.Set(ctx, payloadKey, payload, payloadTTL).Err(); err != nil {
+		log.Printf("Error storing webhook payload: %v", err)
+	}
+
+	return true, nil
+}
+
+// Release drops a claim whose event could not be published so the gateway retry goes through
+func (p *Processor) Release(ctx context.Context, key string) error {
+	if err := p.redis.Del(ctx, processedKey(key)).Err(); err != nil {
+		return fmt.Errorf("releasing event: %w", err)
+	}
+	return nil
+}
+
+func processedKey(key string) string {
+	return fmt.Sprintf("payment:webhook:processed:%s", key)
+}